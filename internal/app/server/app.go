@@ -77,7 +77,10 @@ func (a *App) Run() {
 	ctx := context.Background()
 	pool.StartStatsMonitor(ctx, 5*time.Minute)
 
-	// 启动资源池统计上报（每5秒上报一次到 manager backend）
+	// 启动资源池统计上报（每5秒上报一次到 manager backend），MQTT 运行指标随之上报
+	if adapter != nil {
+		pool.RegisterReportExtra("mqtt", mqtt_udp.GetMqttMetrics)
+	}
 	pool.StartStatsReporter(ctx)
 
 	select {} // 阻塞主线程
@@ -405,7 +408,25 @@ func (a *App) registerHandler() {
 		return
 	}
 	provider.RegisterMessageEventHandler(context.Background(), config_types.EventHandleMessageInject, a.HandleInjectMsg)
-	log.Infof("registerHandler: registered paths=[%s]", config_types.EventHandleMessageInject)
+	provider.RegisterMessageEventHandler(context.Background(), config_types.EventHandleDeviceConfigReload, a.HandleDeviceConfigReload)
+	log.Infof("registerHandler: registered paths=[%s %s]", config_types.EventHandleMessageInject, config_types.EventHandleDeviceConfigReload)
+}
+
+// HandleDeviceConfigReload 管理后台下发配置变更后，重新加载指定在线设备的配置并应用到当前会话
+func (a *App) HandleDeviceConfigReload(ctx context.Context, eventType string, eventData map[string]interface{}) (string, error) {
+	deviceID, _ := eventData["device_id"].(string)
+	if deviceID == "" {
+		return "", fmt.Errorf("device_id is required")
+	}
+	chatManager, exists := a.GetChatManager(deviceID)
+	if !exists {
+		return "", fmt.Errorf("device %s not found or offline", deviceID)
+	}
+	if err := chatManager.ReloadDeviceConfig(ctx); err != nil {
+		log.Errorf("HandleDeviceConfigReload: reload config for device %s failed: %v", deviceID, err)
+		return "", fmt.Errorf("failed to reload config: %v", err)
+	}
+	return "config reloaded", nil
 }
 
 // 向客户端注入消息
@@ -61,6 +61,33 @@ func (a *ASRManager) ProcessVadAudio(ctx context.Context, onClose func()) {
 			return
 		}
 
+		// 可选的直流偏置去除：部分麦克风的直流偏置会抬高静音段能量，导致 VAD 误判
+		var dcRemover *audio.DCOffsetRemover
+		if viper.GetBool("vad.dc_offset_removal.enable") {
+			dcRemover = audio.NewDCOffsetRemover()
+		}
+
+		// 可选的噪声门前处理：在 VAD/ASR 之前压制持续底噪
+		var noiseGate *audio.NoiseGateProcessor
+		if viper.GetBool("vad.noise_gate.enable") {
+			noiseGate = audio.NewNoiseGate(
+				viper.GetFloat64("vad.noise_gate.threshold_db"),
+				viper.GetFloat64("vad.noise_gate.attack_ms"),
+				viper.GetFloat64("vad.noise_gate.release_ms"),
+				audioFormat.SampleRate,
+			)
+		}
+
+		// 可选的预加重：仅作用于送往 ASR 的音频，提升高频以改善发闷音频的识别，不影响 VAD 判决
+		var preEmphasis *audio.PreEmphasisFilter
+		if viper.GetBool("asr.pre_emphasis.enable") {
+			coeff := audio.DefaultPreEmphasisCoeff
+			if viper.IsSet("asr.pre_emphasis.coeff") {
+				coeff = viper.GetFloat64("asr.pre_emphasis.coeff")
+			}
+			preEmphasis = audio.NewPreEmphasisFilter(coeff)
+		}
+
 		// 从第一帧实际数据中获取帧大小和帧时长
 		var frameSize int
 		var frameDurationMs int
@@ -151,6 +178,12 @@ func (a *ASRManager) ProcessVadAudio(ctx context.Context, onClose func()) {
 
 				var vadPcmData []float32
 				pcmData := pcmFrame[:n]
+				if dcRemover != nil {
+					pcmData = dcRemover.Process(pcmData)
+				}
+				if noiseGate != nil {
+					pcmData = noiseGate.Process(pcmData)
+				}
 
 				// 检查帧大小是否一致（正常情况下应该一致，但不一致时使用实际值）
 				if n != frameSize {
@@ -179,7 +212,7 @@ func (a *ASRManager) ProcessVadAudio(ctx context.Context, onClose func()) {
 
 						//如果已经检测到语音, 则不进行vad检测, 直接将pcmData传给asr
 						// 使用循环外获取的VAD资源进行检测
-						// 重置VAD状态
+						// 重置单次检测状态（双阈值等跨块判决状态在轮次边界才清除）
 						if err := vadProvider.Reset(); err != nil {
 							log.Errorf("重置vad失败: %v", err)
 							continue
@@ -255,7 +288,11 @@ func (a *ASRManager) ProcessVadAudio(ctx context.Context, onClose func()) {
 				if clientHaveVoice {
 					//vad识别成功, 往asr音频通道里发送数据
 					//log.Infof("vad识别成功, 往asr音频通道里发送数据, len: %d", len(pcmData))
-					state.Asr.AddAudioData(pcmData)
+					asrPcmData := pcmData
+					if preEmphasis != nil {
+						asrPcmData = preEmphasis.Process(pcmData)
+					}
+					state.Asr.AddAudioData(asrPcmData)
 
 					// 如果启用声纹识别，同时发送到声纹识别服务
 					// 需要同时满足：全局开关启用、设备配置中有声纹组、speakerManager已初始化
@@ -287,6 +324,7 @@ func (a *ASRManager) ProcessVadAudio(ctx context.Context, onClose func()) {
 						log.Debugf("语音时长过短 (%dms < 300ms)，重置clientHaveVoice", voiceDurationInSession)
 						state.SetClientHaveVoice(false)
 						state.Vad.ResetVoiceDuration()
+						resetVadSession(vadProvider)
 						continue
 					}
 
@@ -296,6 +334,7 @@ func (a *ASRManager) ProcessVadAudio(ctx context.Context, onClose func()) {
 						hasTriggeredCancel = false
 						state.OnVoiceSilence()
 						state.VoiceStatus.Reset()
+						resetVadSession(vadProvider)
 						continue
 					}
 				}
@@ -307,6 +346,13 @@ func (a *ASRManager) ProcessVadAudio(ctx context.Context, onClose func()) {
 	}()
 }
 
+// resetVadSession 在语音轮次边界清除 VAD 的跨块判决状态
+func resetVadSession(vadProvider inter.VAD) {
+	if err := inter.ResetSession(vadProvider); err != nil {
+		log.Errorf("重置vad会话状态失败: %v", err)
+	}
+}
+
 // releaseResource 释放ASR资源（内部方法）
 func (a *ASRManager) releaseResource() {
 	a.resourceMu.Lock()
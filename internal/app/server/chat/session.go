@@ -651,7 +651,11 @@ func (s *ChatSession) HandleNotActivated() {
 }
 
 func (s *ChatSession) HandleWelcome() {
-	greetingText := s.GetRandomGreeting()
+	// 角色/智能体配置了开场白时优先使用
+	greetingText := strings.TrimSpace(s.clientState.DeviceConfig.Greeting)
+	if greetingText == "" {
+		greetingText = s.GetRandomGreeting()
+	}
 	sessionCtx := s.clientState.SessionCtx.Get(s.clientState.Ctx)
 	ctx := s.clientState.AfterAsrSessionCtx.Get(sessionCtx)
 
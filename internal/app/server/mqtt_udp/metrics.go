@@ -0,0 +1,92 @@
+package mqtt_udp
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// mqttMetrics 运行时 MQTT 连接指标，供统计上报使用
+type mqttMetrics struct {
+	connects          atomic.Int64 // 成功连接（含重连）次数
+	connectFailures   atomic.Int64 // 连接失败次数
+	connectionLost    atomic.Int64 // 连接丢失次数
+	publishCount      atomic.Int64
+	publishErrors     atomic.Int64
+	publishLatencySum atomic.Int64 // 纳秒
+	publishLatencyMax atomic.Int64 // 纳秒
+	lastConnectedAt   atomic.Int64 // unix 秒
+	lastLostAt        atomic.Int64 // unix 秒
+
+	adapter atomic.Pointer[MqttUdpAdapter]
+}
+
+var globalMqttMetrics = &mqttMetrics{}
+
+func (m *mqttMetrics) observePublish(cost time.Duration, err error) {
+	m.publishCount.Add(1)
+	if err != nil {
+		m.publishErrors.Add(1)
+	}
+	ns := cost.Nanoseconds()
+	m.publishLatencySum.Add(ns)
+	for {
+		old := m.publishLatencyMax.Load()
+		if ns <= old || m.publishLatencyMax.CompareAndSwap(old, ns) {
+			return
+		}
+	}
+}
+
+func (m *mqttMetrics) onConnected() {
+	m.connects.Add(1)
+	m.lastConnectedAt.Store(time.Now().Unix())
+}
+
+func (m *mqttMetrics) onConnectionLost() {
+	m.connectionLost.Add(1)
+	m.lastLostAt.Store(time.Now().Unix())
+}
+
+// GetMqttMetrics 返回 MQTT 运行时指标快照
+func GetMqttMetrics() map[string]interface{} {
+	m := globalMqttMetrics
+
+	connected := false
+	activeSessions := 0
+	if adapter := m.adapter.Load(); adapter != nil {
+		if client := adapter.getClient(); client != nil {
+			connected = client.IsConnected()
+		}
+		adapter.deviceId2Conn.Range(func(key, value interface{}) bool {
+			activeSessions++
+			return true
+		})
+	}
+
+	connects := m.connects.Load()
+	reconnects := connects - 1
+	if reconnects < 0 {
+		reconnects = 0
+	}
+
+	publishCount := m.publishCount.Load()
+	var avgLatencyMs float64
+	if publishCount > 0 {
+		avgLatencyMs = float64(m.publishLatencySum.Load()) / float64(publishCount) / float64(time.Millisecond)
+	}
+
+	return map[string]interface{}{
+		"connected":              connected,
+		"active_sessions":        activeSessions,
+		"connects":               connects,
+		"reconnects":             reconnects,
+		"connect_failures":       m.connectFailures.Load(),
+		"connection_lost":        m.connectionLost.Load(),
+		"publish_count":          publishCount,
+		"publish_errors":         m.publishErrors.Load(),
+		"publish_latency_avg_ms": avgLatencyMs,
+		"publish_latency_max_ms": float64(m.publishLatencyMax.Load()) / float64(time.Millisecond),
+		"last_connected_at":      m.lastConnectedAt.Load(),
+		"last_lost_at":           m.lastLostAt.Load(),
+	}
+}
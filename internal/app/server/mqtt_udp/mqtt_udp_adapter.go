@@ -68,6 +68,7 @@ func NewMqttUdpAdapter(config *MqttConfig, opts ...MqttUdpAdapterOption) *MqttUd
 	for _, opt := range opts {
 		opt(s)
 	}
+	globalMqttMetrics.adapter.Store(s)
 
 	go s.processMessage()
 	return s
@@ -145,10 +146,12 @@ func (s *MqttUdpAdapter) connectAndRetry() {
 
 	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) {
 		Errorf("MQTT连接丢失: %v", err)
+		globalMqttMetrics.onConnectionLost()
 	})
 
 	opts.SetOnConnectHandler(func(client mqtt.Client) {
 		Info("MQTT已连接")
+		globalMqttMetrics.onConnected()
 		topic := ServerSubTopicPrefix
 		if token := client.Subscribe(topic, 0, s.handleMessage); token.Wait() && token.Error() != nil {
 			Errorf("订阅主题失败: %v", token.Error())
@@ -166,6 +169,7 @@ func (s *MqttUdpAdapter) connectAndRetry() {
 		s.setClient(client)
 		if token := client.Connect(); token.Wait() && token.Error() != nil {
 			retryCount++
+			globalMqttMetrics.connectFailures.Add(1)
 			Errorf("连接MQTT服务器失败(第%d次): %v，%d秒后重试", retryCount, token.Error(), int(retryInterval.Seconds()))
 			select {
 			case <-s.stopCtx.Done():
@@ -71,8 +71,10 @@ func (c *MqttUdpConn) SendCmd(msg []byte) error {
 	if client == nil {
 		return errors.New("mqtt client is nil")
 	}
+	startTs := time.Now()
 	token := client.Publish(c.PubTopic, 0, false, msg)
 	token.Wait()
+	globalMqttMetrics.observePublish(time.Since(startTs), token.Error())
 	if token.Error() != nil {
 		return token.Error()
 	}
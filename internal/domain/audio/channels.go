@@ -0,0 +1,32 @@
+package audio
+
+// DownmixToMono 将按声道交错的多声道样本逐帧平均为单声道，末尾不足一帧的样本丢弃；channels<=1 时返回副本
+func DownmixToMono(interleaved []float32, channels int) []float32 {
+	if channels <= 1 {
+		return append([]float32(nil), interleaved...)
+	}
+	out := make([]float32, len(interleaved)/channels)
+	for i := range out {
+		var sum float32
+		for ch := 0; ch < channels; ch++ {
+			sum += interleaved[i*channels+ch]
+		}
+		out[i] = sum / float32(channels)
+	}
+	return out
+}
+
+// StereoToMono 将交错排列的双声道样本（L,R,L,R...）按左右平均转为单声道
+func StereoToMono(interleaved []float32) []float32 {
+	return DownmixToMono(interleaved, 2)
+}
+
+// MonoToStereo 将单声道样本复制到左右声道，输出交错排列的双声道样本，用于要求双声道的播放端
+func MonoToStereo(mono []float32) []float32 {
+	out := make([]float32, len(mono)*2)
+	for i, v := range mono {
+		out[2*i] = v
+		out[2*i+1] = v
+	}
+	return out
+}
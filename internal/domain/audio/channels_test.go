@@ -0,0 +1,60 @@
+package audio
+
+import "testing"
+
+func TestStereoToMonoInterleaved(t *testing.T) {
+	// 左声道 1、右声道 0：平均为 0.5；末尾孤立样本丢弃
+	out := StereoToMono([]float32{1, 0, 0.2, 0.4, -1, 1, 0.9})
+	want := []float32{0.5, 0.3, 0}
+	if len(out) != len(want) {
+		t.Fatalf("len = %d, want %d", len(out), len(want))
+	}
+	for i := range want {
+		if d := out[i] - want[i]; d > 1e-6 || d < -1e-6 {
+			t.Fatalf("out[%d] = %f, want %f", i, out[i], want[i])
+		}
+	}
+}
+
+func TestMonoStereoRoundTrip(t *testing.T) {
+	mono := []float32{0, 0.25, -0.5, 1, -1}
+	stereo := MonoToStereo(mono)
+	if len(stereo) != 2*len(mono) {
+		t.Fatalf("stereo len = %d", len(stereo))
+	}
+	for i, v := range mono {
+		if stereo[2*i] != v || stereo[2*i+1] != v {
+			t.Fatalf("frame %d = (%f,%f), want %f on both channels", i, stereo[2*i], stereo[2*i+1], v)
+		}
+	}
+	back := StereoToMono(stereo)
+	for i := range mono {
+		if back[i] != mono[i] {
+			t.Fatalf("round trip [%d] = %f, want %f", i, back[i], mono[i])
+		}
+	}
+
+	// 左右相同的双声道经单声道往返后不变
+	again := MonoToStereo(StereoToMono(stereo))
+	for i := range stereo {
+		if again[i] != stereo[i] {
+			t.Fatalf("stereo round trip [%d] = %f, want %f", i, again[i], stereo[i])
+		}
+	}
+	if len(MonoToStereo(nil)) != 0 || len(StereoToMono(nil)) != 0 {
+		t.Fatal("empty input")
+	}
+}
+
+func TestDownmixToMono(t *testing.T) {
+	out := DownmixToMono([]float32{0.3, 0.6, 0.9, 0, 0, 0}, 3)
+	if len(out) != 2 || out[0] < 0.599 || out[0] > 0.601 || out[1] != 0 {
+		t.Fatalf("out = %v", out)
+	}
+	in := []float32{0.1, 0.2}
+	cp := DownmixToMono(in, 1)
+	cp[0] = 9
+	if in[0] != 0.1 {
+		t.Fatal("mono input should be copied, not aliased")
+	}
+}
@@ -0,0 +1,48 @@
+package audio
+
+// ClippingLevel 样本绝对值达到该值即视为处于满幅（削波）；int16 归一化后的满幅为 32767/32768≈0.99997，留出少量余量
+const ClippingLevel = 0.999
+
+// ClipRatioWarnThreshold 削波样本占比超过该值时应提示（通常为麦克风增益过高），ASR 识别率会明显下降
+const ClipRatioWarnThreshold = 0.001
+
+// ClippingStats 单声道的削波统计
+type ClippingStats struct {
+	ClippedSamples int     `json:"clipped_samples"`
+	Ratio          float64 `json:"ratio"`
+}
+
+// Exceeds 削波占比是否超过提示阈值
+func (s ClippingStats) Exceeds() bool {
+	return s.Ratio > ClipRatioWarnThreshold
+}
+
+// DetectClipping 统计处于满幅（±ClippingLevel 及以上）的样本数及其占比
+func DetectClipping(pcm []float32) (clippedSamples int, ratio float64) {
+	stats := DetectClippingChannels(pcm, 1)
+	if len(stats) == 0 {
+		return 0, 0
+	}
+	return stats[0].ClippedSamples, stats[0].Ratio
+}
+
+// DetectClippingChannels 按声道统计交织 PCM 的削波情况，单声道削波在多声道混音后会被稀释，需在混音前检测
+func DetectClippingChannels(pcm []float32, channels int) []ClippingStats {
+	if channels <= 0 {
+		return nil
+	}
+	stats := make([]ClippingStats, channels)
+	frames := len(pcm) / channels
+	if frames == 0 {
+		return stats
+	}
+	for i := 0; i < frames*channels; i++ {
+		if s := pcm[i]; s >= ClippingLevel || s <= -ClippingLevel {
+			stats[i%channels].ClippedSamples++
+		}
+	}
+	for ch := range stats {
+		stats[ch].Ratio = float64(stats[ch].ClippedSamples) / float64(frames)
+	}
+	return stats
+}
@@ -0,0 +1,34 @@
+package audio
+
+import "testing"
+
+func TestDetectClipping(t *testing.T) {
+	pcm := make([]float32, 1000)
+	for i := range pcm {
+		pcm[i] = 0.3
+	}
+	pcm[10], pcm[20], pcm[30] = 1, -1, 0.9999
+
+	clipped, ratio := DetectClipping(pcm)
+	if clipped != 3 || ratio != 0.003 {
+		t.Fatalf("clipped = %d ratio = %v", clipped, ratio)
+	}
+	if !(ClippingStats{ClippedSamples: clipped, Ratio: ratio}).Exceeds() {
+		t.Fatal("expected clip ratio to exceed warning threshold")
+	}
+	if clipped, ratio := DetectClipping(nil); clipped != 0 || ratio != 0 {
+		t.Fatalf("empty input: %d/%v", clipped, ratio)
+	}
+}
+
+func TestDetectClippingChannels(t *testing.T) {
+	// 左声道全部削波，右声道正常：混音后会被稀释，需按声道检测
+	pcm := []float32{1, 0.1, -1, 0.1, 1, -0.1, 0.5, 0.2}
+	stats := DetectClippingChannels(pcm, 2)
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats[0].ClippedSamples != 3 || stats[0].Ratio != 0.75 || stats[1].ClippedSamples != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
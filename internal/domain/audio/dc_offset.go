@@ -0,0 +1,69 @@
+package audio
+
+// RemoveDCOffset 减去整段音频的均值以去除直流偏置，返回新切片及被去除的偏置值，不修改输入
+// 部分麦克风存在直流偏置，会抬高静音段能量，导致 VAD 误判为有声
+func RemoveDCOffset(pcm []float32) ([]float32, float32) {
+	out := make([]float32, len(pcm))
+	if len(pcm) == 0 {
+		return out, 0
+	}
+	var sum float64
+	for _, s := range pcm {
+		sum += float64(s)
+	}
+	offset := float32(sum / float64(len(pcm)))
+	for i, s := range pcm {
+		out[i] = s - offset
+	}
+	return out, offset
+}
+
+// dcOffsetSmoothing 流式去偏置时帧均值的平滑系数，偏置估计约在数十帧内收敛，避免逐帧减均值在帧边界产生跳变
+const dcOffsetSmoothing = 0.95
+
+// DCOffsetRemover 流式直流偏置去除：跨帧平滑估计偏置后减去，首帧直接以帧均值初始化
+// 非并发安全，适合按帧调用
+type DCOffsetRemover struct {
+	offset      float64
+	initialized bool
+}
+
+// NewDCOffsetRemover 创建流式直流偏置去除器
+func NewDCOffsetRemover() *DCOffsetRemover {
+	return &DCOffsetRemover{}
+}
+
+// Process 处理一帧音频，返回新切片，不修改输入
+func (r *DCOffsetRemover) Process(pcm []float32) []float32 {
+	out := make([]float32, len(pcm))
+	if len(pcm) == 0 {
+		return out
+	}
+	var sum float64
+	for _, s := range pcm {
+		sum += float64(s)
+	}
+	mean := sum / float64(len(pcm))
+	if r.initialized {
+		r.offset = dcOffsetSmoothing*r.offset + (1-dcOffsetSmoothing)*mean
+	} else {
+		r.offset = mean
+		r.initialized = true
+	}
+	offset := float32(r.offset)
+	for i, s := range pcm {
+		out[i] = s - offset
+	}
+	return out
+}
+
+// Offset 返回当前估计的直流偏置
+func (r *DCOffsetRemover) Offset() float32 {
+	return float32(r.offset)
+}
+
+// Reset 清除偏置估计
+func (r *DCOffsetRemover) Reset() {
+	r.offset = 0
+	r.initialized = false
+}
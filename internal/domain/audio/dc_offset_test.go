@@ -0,0 +1,56 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRemoveDCOffset(t *testing.T) {
+	const bias = 0.1
+	pcm := make([]float32, 1600)
+	for i := range pcm {
+		pcm[i] = float32(0.2*math.Sin(2*math.Pi*440*float64(i)/16000)) + bias
+	}
+	out, offset := RemoveDCOffset(pcm)
+	if math.Abs(float64(offset)-bias) > 0.01 {
+		t.Fatalf("offset = %f, want ~%f", offset, bias)
+	}
+	var sum float64
+	for _, s := range out {
+		sum += float64(s)
+	}
+	if mean := sum / float64(len(out)); math.Abs(mean) > 1e-4 {
+		t.Fatalf("mean after removal = %f", mean)
+	}
+	if pcm[0] != float32(bias) {
+		t.Fatal("input modified")
+	}
+	if out, offset := RemoveDCOffset(nil); len(out) != 0 || offset != 0 {
+		t.Fatal("empty input")
+	}
+}
+
+func TestDCOffsetRemover(t *testing.T) {
+	const bias = -0.05
+	r := NewDCOffsetRemover()
+	// 静音帧带直流偏置：处理后能量应接近 0
+	frame := make([]float32, 320)
+	for i := range frame {
+		frame[i] = bias
+	}
+	for i := 0; i < 10; i++ {
+		out := r.Process(frame)
+		for _, s := range out {
+			if math.Abs(float64(s)) > 1e-6 {
+				t.Fatalf("frame %d: residual %f", i, s)
+			}
+		}
+	}
+	if math.Abs(float64(r.Offset())-bias) > 1e-6 {
+		t.Fatalf("offset = %f", r.Offset())
+	}
+	r.Reset()
+	if r.Offset() != 0 {
+		t.Fatal("reset")
+	}
+}
@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// 回声检测：通过播放与采集音频的互相关估计回声延迟，用于排查 TTS 播放漏入麦克风导致的误触发 VAD
+
+// EstimateEchoDelay 估计采集音频相对播放音频的延迟（采集滞后为正），返回延迟样本数与该延迟下的归一化相关系数 [-1, 1]
+// 仅考虑重叠长度不少于较短输入一半的延迟，避免极短重叠带来的虚高相关；相关系数绝对值越接近 1 回声越明显
+// sampleRate 仅用于限制最大搜索延迟（1 秒），<=0 时不限制
+func EstimateEchoDelay(playback, capture []float32, sampleRate int) (delaySamples int, correlation float64) {
+	if len(playback) == 0 || len(capture) == 0 {
+		return 0, 0
+	}
+
+	n := 1
+	for n < len(playback)+len(capture) {
+		n <<= 1
+	}
+	p := make([]complex128, n)
+	c := make([]complex128, n)
+	for i, s := range playback {
+		p[i] = complex(float64(s), 0)
+	}
+	for i, s := range capture {
+		c[i] = complex(float64(s), 0)
+	}
+	fftInPlace(p)
+	fftInPlace(c)
+	// r[d] = Σ p[i]·c[i+d]，由 IFFT(conj(P)·C) 得到；IFFT 用共轭 FFT 实现
+	for i := range c {
+		c[i] = cmplx.Conj(cmplx.Conj(p[i]) * c[i])
+	}
+	fftInPlace(c)
+
+	prefixP := energyPrefix(playback)
+	prefixC := energyPrefix(capture)
+	minLen := len(playback)
+	if len(capture) < minLen {
+		minLen = len(capture)
+	}
+	minOverlap := (minLen + 1) / 2
+
+	maxDelay := len(capture) - 1
+	if sampleRate > 0 && maxDelay > sampleRate {
+		maxDelay = sampleRate
+	}
+
+	bestAbs := -1.0
+	for d := 0; d <= maxDelay; d++ {
+		overlap := len(capture) - d
+		if overlap > len(playback) {
+			overlap = len(playback)
+		}
+		if overlap < minOverlap {
+			break
+		}
+		energy := prefixP[overlap] * (prefixC[d+overlap] - prefixC[d])
+		if energy <= 0 {
+			continue
+		}
+		corr := real(c[d]) / float64(n) / math.Sqrt(energy)
+		if math.Abs(corr) > bestAbs {
+			bestAbs = math.Abs(corr)
+			delaySamples, correlation = d, corr
+		}
+	}
+	if bestAbs < 0 {
+		return 0, 0
+	}
+	return delaySamples, math.Max(-1, math.Min(1, correlation))
+}
+
+// energyPrefix prefix[i] 为前 i 个样本的能量和
+func energyPrefix(pcm []float32) []float64 {
+	prefix := make([]float64, len(pcm)+1)
+	for i, s := range pcm {
+		prefix[i+1] = prefix[i] + float64(s)*float64(s)
+	}
+	return prefix
+}
@@ -0,0 +1,45 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEstimateEchoDelay(t *testing.T) {
+	const sampleRate, delay = 16000, 1234
+	rng := rand.New(rand.NewSource(1))
+	playback := make([]float32, 8000)
+	for i := range playback {
+		playback[i] = float32(rng.NormFloat64() * 0.2)
+	}
+	// 采集 = 衰减并延迟的播放 + 少量噪声
+	capture := make([]float32, len(playback)+2000)
+	for i := range capture {
+		capture[i] = float32(rng.NormFloat64() * 0.01)
+		if j := i - delay; j >= 0 && j < len(playback) {
+			capture[i] += 0.3 * playback[j]
+		}
+	}
+
+	got, corr := EstimateEchoDelay(playback, capture, sampleRate)
+	if got != delay {
+		t.Fatalf("delay = %d, want %d", got, delay)
+	}
+	if corr < 0.9 {
+		t.Fatalf("correlation = %.3f, want > 0.9", corr)
+	}
+
+	// 无关信号相关性低
+	unrelated := make([]float32, len(capture))
+	for i := range unrelated {
+		unrelated[i] = float32(rng.NormFloat64() * 0.2)
+	}
+	if _, corr := EstimateEchoDelay(playback, unrelated, sampleRate); math.Abs(corr) > 0.2 {
+		t.Fatalf("unrelated correlation = %.3f", corr)
+	}
+
+	if d, c := EstimateEchoDelay(nil, capture, sampleRate); d != 0 || c != 0 {
+		t.Fatalf("empty playback = %d, %.3f", d, c)
+	}
+}
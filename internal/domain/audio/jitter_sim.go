@@ -0,0 +1,71 @@
+package audio
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// NetworkSimConfig 网络损伤模拟参数，用于在测试中复现 UDP 上行的丢包、乱序与抖动
+type NetworkSimConfig struct {
+	FrameMs     int     // 发送间隔（每包时长），<=0 时按 60ms
+	LossRate    float64 // 丢包率 0-1
+	ReorderRate float64 // 乱序率 0-1，命中的包额外延迟两个包间隔，晚于后续包到达
+	JitterMs    int     // 到达时间随机抖动上限（均匀分布 0~JitterMs）
+	Seed        int64   // 随机种子，相同种子结果可复现
+}
+
+// SimulatedPacket 模拟到达的数据包
+type SimulatedPacket struct {
+	Seq       int    // 原始发送序号
+	Data      []byte // 包内容（与输入共享底层数组）
+	ArrivalMs int    // 相对首包发送时刻的到达时间
+}
+
+// SimulateNetwork 按参数对 Opus 包序列施加丢包、乱序与抖动，返回按到达顺序排列的包（丢失的包不出现）
+func SimulateNetwork(packets [][]byte, cfg NetworkSimConfig) []SimulatedPacket {
+	frameMs := cfg.FrameMs
+	if frameMs <= 0 {
+		frameMs = 60
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	arrived := make([]SimulatedPacket, 0, len(packets))
+	for seq, packet := range packets {
+		// 每包固定消耗相同数量的随机数，参数变化时其余包的扰动保持稳定
+		lossDraw, reorderDraw, jitterDraw := rng.Float64(), rng.Float64(), rng.Float64()
+		if lossDraw < cfg.LossRate {
+			continue
+		}
+		arrival := seq * frameMs
+		if cfg.JitterMs > 0 {
+			arrival += int(jitterDraw * float64(cfg.JitterMs+1))
+		}
+		if reorderDraw < cfg.ReorderRate {
+			arrival += 2 * frameMs
+		}
+		arrived = append(arrived, SimulatedPacket{Seq: seq, Data: packet, ArrivalMs: arrival})
+	}
+	sort.SliceStable(arrived, func(i, j int) bool {
+		return arrived[i].ArrivalMs < arrived[j].ArrivalMs
+	})
+	return arrived
+}
+
+// PlayoutJitterBuffer 模拟固定深度的抖动缓冲播放：第 seq 包须在 seq*frameMs+bufferMs 之前到达，
+// 返回按序号排列的 total 个包，丢失或迟到的包为 nil，可直接交给 OpusStreamToWav 做丢包补偿
+func PlayoutJitterBuffer(arrived []SimulatedPacket, total, frameMs, bufferMs int) [][]byte {
+	if frameMs <= 0 {
+		frameMs = 60
+	}
+	out := make([][]byte, total)
+	for _, p := range arrived {
+		if p.Seq < 0 || p.Seq >= total || out[p.Seq] != nil {
+			continue
+		}
+		if p.ArrivalMs > p.Seq*frameMs+bufferMs {
+			continue
+		}
+		out[p.Seq] = p.Data
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// 对数梅尔谱提取，用于排查 ASR 识别问题时导出/绘制前端实际收到的音频特征
+
+const (
+	melFrameMs     = 25     // 分析窗长
+	melLogFloor    = 1e-10  // 取对数前的能量下限
+	melScaleFactor = 2595.0 // HTK 梅尔刻度常数
+)
+
+// MelSpectrogram 计算对数梅尔谱（log10 能量），返回 [帧][nMels] 的特征矩阵
+// 窗长 25ms（Hann 窗），帧移 hopSize 个样本；音频不足一个窗长时补零为一帧
+func MelSpectrogram(pcm []float32, sampleRate, nMels, hopSize int) [][]float32 {
+	if len(pcm) == 0 || sampleRate <= 0 || nMels <= 0 || hopSize <= 0 {
+		return nil
+	}
+	frameLen := sampleRate * melFrameMs / 1000
+	if frameLen <= 0 {
+		return nil
+	}
+	nFFT := 1
+	for nFFT < frameLen {
+		nFFT <<= 1
+	}
+
+	window := make([]float64, frameLen)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(frameLen))
+	}
+	filters := melFilterBank(sampleRate, nFFT, nMels)
+
+	numFrames := 1
+	if len(pcm) > frameLen {
+		numFrames = 1 + (len(pcm)-frameLen)/hopSize
+	}
+
+	buf := make([]complex128, nFFT)
+	power := make([]float64, nFFT/2+1)
+	out := make([][]float32, numFrames)
+	for f := 0; f < numFrames; f++ {
+		start := f * hopSize
+		for i := range buf {
+			buf[i] = 0
+		}
+		for i := 0; i < frameLen && start+i < len(pcm); i++ {
+			buf[i] = complex(float64(pcm[start+i])*window[i], 0)
+		}
+		fftInPlace(buf)
+		for k := range power {
+			a := cmplx.Abs(buf[k])
+			power[k] = a * a
+		}
+
+		row := make([]float32, nMels)
+		for m, filter := range filters {
+			var energy float64
+			for k, w := range filter.weights {
+				energy += w * power[filter.start+k]
+			}
+			row[m] = float32(math.Log10(math.Max(energy, melLogFloor)))
+		}
+		out[f] = row
+	}
+	return out
+}
+
+// melFilter 三角滤波器，weights[k] 对应 FFT 频点 start+k
+type melFilter struct {
+	start   int
+	weights []float64
+}
+
+func hzToMel(hz float64) float64 {
+	return melScaleFactor * math.Log10(1+hz/700)
+}
+
+func melToHz(mel float64) float64 {
+	return 700 * (math.Pow(10, mel/melScaleFactor) - 1)
+}
+
+// melFilterBank 构造 0 ~ sampleRate/2 的 nMels 个三角梅尔滤波器
+func melFilterBank(sampleRate, nFFT, nMels int) []melFilter {
+	maxMel := hzToMel(float64(sampleRate) / 2)
+	binHz := float64(sampleRate) / float64(nFFT)
+	edges := make([]float64, nMels+2)
+	for i := range edges {
+		edges[i] = melToHz(maxMel * float64(i) / float64(nMels+1))
+	}
+
+	filters := make([]melFilter, nMels)
+	for m := 0; m < nMels; m++ {
+		left, center, right := edges[m], edges[m+1], edges[m+2]
+		start := int(math.Ceil(left / binHz))
+		end := int(math.Floor(right / binHz))
+		if end > nFFT/2 {
+			end = nFFT / 2
+		}
+		filter := melFilter{start: start}
+		for k := start; k <= end; k++ {
+			hz := float64(k) * binHz
+			var w float64
+			if hz <= center {
+				w = (hz - left) / (center - left)
+			} else {
+				w = (right - hz) / (right - center)
+			}
+			filter.weights = append(filter.weights, math.Max(w, 0))
+		}
+		filters[m] = filter
+	}
+	return filters
+}
+
+// fftInPlace 原地基 2 FFT，len(x) 必须为 2 的幂
+func fftInPlace(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * w
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
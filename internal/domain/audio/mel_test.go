@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMelSpectrogramShapeAndPeak(t *testing.T) {
+	const sampleRate, nMels, hop = 16000, 40, 160
+	pcm := make([]float32, sampleRate/2) // 500ms 1kHz 正弦
+	for i := range pcm {
+		pcm[i] = float32(0.5 * math.Sin(2*math.Pi*1000*float64(i)/sampleRate))
+	}
+
+	mel := MelSpectrogram(pcm, sampleRate, nMels, hop)
+	wantFrames := 1 + (len(pcm)-400)/hop
+	if len(mel) != wantFrames || len(mel[0]) != nMels {
+		t.Fatalf("shape = %dx%d, want %dx%d", len(mel), len(mel[0]), wantFrames, nMels)
+	}
+
+	// 能量最大的梅尔带应覆盖 1kHz
+	frame := mel[len(mel)/2]
+	peak := 0
+	for m := range frame {
+		if frame[m] > frame[peak] {
+			peak = m
+		}
+	}
+	maxMel := hzToMel(sampleRate / 2)
+	low := melToHz(maxMel * float64(peak) / float64(nMels+1))
+	high := melToHz(maxMel * float64(peak+2) / float64(nMels+1))
+	if low > 1000 || high < 1000 {
+		t.Fatalf("peak band %d covers %.0f-%.0fHz, want 1000Hz", peak, low, high)
+	}
+}
+
+func TestMelSpectrogramEdgeCases(t *testing.T) {
+	if MelSpectrogram(nil, 16000, 40, 160) != nil {
+		t.Fatal("empty input should return nil")
+	}
+	// 不足一个窗长时补零为一帧，静音取能量下限
+	mel := MelSpectrogram(make([]float32, 100), 16000, 8, 160)
+	if len(mel) != 1 || mel[0][0] != float32(math.Log10(melLogFloor)) {
+		t.Fatalf("short silent input = %v", mel)
+	}
+}
@@ -0,0 +1,27 @@
+package audio
+
+// Mix 按增益叠加两段音频，输出长度取两者较长者，较短一段视为末尾补零；
+// 结果限幅到 [-1, 1] 避免溢出，不修改输入。用于测试中构造已知信噪比的语音+噪声/提示音混合信号
+func Mix(a, b []float32, gainA, gainB float64) []float32 {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	out := make([]float32, n)
+	for i := range out {
+		var v float64
+		if i < len(a) {
+			v += float64(a[i]) * gainA
+		}
+		if i < len(b) {
+			v += float64(b[i]) * gainB
+		}
+		if v > 1 {
+			v = 1
+		} else if v < -1 {
+			v = -1
+		}
+		out[i] = float32(v)
+	}
+	return out
+}
@@ -0,0 +1,32 @@
+package audio
+
+import "testing"
+
+func TestMix(t *testing.T) {
+	a := []float32{0.5, 0.5, 0.5}
+	b := []float32{0.2}
+	out := Mix(a, b, 1, 0.5)
+	want := []float32{0.6, 0.5, 0.5}
+	if len(out) != len(want) {
+		t.Fatalf("len = %d, want %d", len(out), len(want))
+	}
+	for i := range want {
+		if d := out[i] - want[i]; d > 1e-6 || d < -1e-6 {
+			t.Fatalf("out[%d] = %f, want %f", i, out[i], want[i])
+		}
+	}
+	if len(Mix(b, a, 1, 1)) != 3 {
+		t.Fatal("longer second input not kept")
+	}
+
+	clipped := Mix([]float32{0.8, -0.8}, []float32{0.8, -0.8}, 1, 1)
+	if clipped[0] != 1 || clipped[1] != -1 {
+		t.Fatalf("clamping failed: %v", clipped)
+	}
+	if a[0] != 0.5 || b[0] != 0.2 {
+		t.Fatal("input modified")
+	}
+	if len(Mix(nil, nil, 1, 1)) != 0 {
+		t.Fatal("empty input")
+	}
+}
@@ -0,0 +1,63 @@
+package audio
+
+import "math"
+
+// NoiseGateProcessor 带起音/释放平滑的噪声门，用于在 VAD 前压制持续的底噪（如电源嗡声）
+// 电平检测使用峰值包络（按释放时间衰减），增益在起音/释放时间内平滑过渡，避免门限附近的抽吸感
+// 非并发安全；跨帧调用 Process 时包络与增益连续，适合流式处理
+type NoiseGateProcessor struct {
+	threshold   float64 // 线性门限
+	attackCoef  float64
+	releaseCoef float64
+	envelope    float64
+	gain        float64
+}
+
+// NewNoiseGate 创建噪声门：thresholdDb 为相对满幅的开门电平（如 -50），attackMs/releaseMs 为开门/关门时间
+func NewNoiseGate(thresholdDb, attackMs, releaseMs float64, sampleRate int) *NoiseGateProcessor {
+	return &NoiseGateProcessor{
+		threshold:   math.Pow(10, thresholdDb/20),
+		attackCoef:  noiseGateCoef(attackMs, sampleRate),
+		releaseCoef: noiseGateCoef(releaseMs, sampleRate),
+	}
+}
+
+// noiseGateCoef 一阶平滑系数，时间常数 <=0 时立即生效
+func noiseGateCoef(ms float64, sampleRate int) float64 {
+	if ms <= 0 || sampleRate <= 0 {
+		return 0
+	}
+	return math.Exp(-1 / (ms * float64(sampleRate) / 1000))
+}
+
+// Process 处理一段音频，返回新切片，不修改输入
+func (g *NoiseGateProcessor) Process(pcm []float32) []float32 {
+	out := make([]float32, len(pcm))
+	for i, sample := range pcm {
+		level := math.Abs(float64(sample))
+		g.envelope = math.Max(level, g.envelope*g.releaseCoef)
+
+		target := 0.0
+		if g.envelope >= g.threshold {
+			target = 1
+		}
+		coef := g.releaseCoef
+		if target > g.gain {
+			coef = g.attackCoef
+		}
+		g.gain = target + (g.gain-target)*coef
+		out[i] = float32(float64(sample) * g.gain)
+	}
+	return out
+}
+
+// Reset 恢复为关门状态
+func (g *NoiseGateProcessor) Reset() {
+	g.envelope = 0
+	g.gain = 0
+}
+
+// NoiseGate 对整段音频做一次性噪声门处理，参数含义同 NewNoiseGate
+func NoiseGate(pcm []float32, thresholdDb, attackMs, releaseMs float64, sampleRate int) []float32 {
+	return NewNoiseGate(thresholdDb, attackMs, releaseMs, sampleRate).Process(pcm)
+}
@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func rms(pcm []float32) float64 {
+	var sum float64
+	for _, s := range pcm {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(pcm)))
+}
+
+func TestNoiseGate(t *testing.T) {
+	const sampleRate = 16000
+	// 200ms 50Hz 嗡声（约 -46dBFS），随后 200ms 440Hz 语音级信号（约 -12dBFS）
+	pcm := make([]float32, sampleRate*2/5)
+	for i := range pcm {
+		ts := float64(i) / sampleRate
+		if i < len(pcm)/2 {
+			pcm[i] = float32(0.005 * math.Sin(2*math.Pi*50*ts))
+		} else {
+			pcm[i] = float32(0.25 * math.Sin(2*math.Pi*440*ts))
+		}
+	}
+
+	out := NoiseGate(pcm, -40, 5, 100, sampleRate)
+	if len(out) != len(pcm) {
+		t.Fatalf("len = %d, want %d", len(out), len(pcm))
+	}
+	half := len(pcm) / 2
+	if r := rms(out[:half]); r > 1e-4 {
+		t.Fatalf("hum not gated: rms = %g", r)
+	}
+	// 起音 5ms 后应基本完全开门
+	settle := half + sampleRate/50
+	if ratio := rms(out[settle:]) / rms(pcm[settle:]); ratio < 0.99 {
+		t.Fatalf("signal attenuated after attack: ratio = %.3f", ratio)
+	}
+	// 开门过程平滑：增益单调上升，不会一步跳变到满幅
+	if math.Abs(float64(out[half+1])) >= math.Abs(float64(pcm[half+1])) {
+		t.Fatal("gate opened instantly, expected attack ramp")
+	}
+}
+
+func TestNoiseGateStreamingMatchesOneShot(t *testing.T) {
+	pcm := make([]float32, 1600)
+	for i := range pcm {
+		pcm[i] = float32(0.3 * math.Sin(float64(i)/7))
+	}
+	want := NoiseGate(pcm, -30, 2, 50, 16000)
+
+	g := NewNoiseGate(-30, 2, 50, 16000)
+	var got []float32
+	for i := 0; i < len(pcm); i += 320 {
+		got = append(got, g.Process(pcm[i:i+320])...)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: streaming %g != one-shot %g", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"gopkg.in/hraban/opus.v2"
+)
+
+// Opus 单包最长 120ms，按此分配解码缓冲即可兼容任意协商的帧时长
+const opusMaxPacketMs = 120
+
+// OpusStreamToWav 将抓取的设备 Opus 包序列（如 UDP 负载）解码并写入 16bit WAV 文件，便于回放设备实际上行的音频
+// 每包时长以解码结果为准，兼容 20/40/60ms 等协商帧时长；空包视为丢包，按上一包时长做丢包补偿（PLC），保持时间轴不变
+func OpusStreamToWav(packets [][]byte, sampleRate, channels int, outPath string) error {
+	if sampleRate <= 0 || channels <= 0 {
+		return fmt.Errorf("无效的采样率或声道数: %d/%d", sampleRate, channels)
+	}
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	if err != nil {
+		return fmt.Errorf("创建Opus解码器失败: %v", err)
+	}
+
+	pcmBuf := make([]int16, sampleRate*opusMaxPacketMs/1000*channels)
+	samples := make([]int, 0, len(packets)*len(pcmBuf)/2)
+	lastFrameSize := 0 // 上一包每声道样本数
+	for i, packet := range packets {
+		if len(packet) == 0 {
+			if lastFrameSize == 0 {
+				continue
+			}
+			// DecodePLC 以缓冲容量作为补偿时长，需精确分配
+			plc := make([]int16, lastFrameSize*channels)
+			if err := decoder.DecodePLC(plc); err != nil {
+				return fmt.Errorf("第%d包丢包补偿失败: %v", i, err)
+			}
+			for _, s := range plc {
+				samples = append(samples, int(s))
+			}
+			continue
+		}
+
+		n, err := decoder.Decode(packet, pcmBuf)
+		if err != nil {
+			return fmt.Errorf("第%d包解码失败: %v", i, err)
+		}
+		lastFrameSize = n
+		for _, s := range pcmBuf[:n*channels] {
+			samples = append(samples, int(s))
+		}
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("没有可解码的Opus数据")
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("创建WAV文件失败: %v", err)
+	}
+	encoder := wav.NewEncoder(f, sampleRate, 16, channels, 1)
+	writeErr := encoder.Write(&audio.IntBuffer{
+		Format:         &audio.Format{NumChannels: channels, SampleRate: sampleRate},
+		SourceBitDepth: 16,
+		Data:           samples,
+	})
+	if writeErr == nil {
+		writeErr = encoder.Close()
+	}
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		_ = os.Remove(outPath)
+		return fmt.Errorf("写入WAV文件失败: %v", writeErr)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-audio/wav"
+	"gopkg.in/hraban/opus.v2"
+)
+
+func TestOpusStreamToWav(t *testing.T) {
+	const sampleRate, channels, frameMs = 16000, 1, 60
+	frameSize := sampleRate * frameMs / 1000
+
+	enc, err := opus.NewEncoder(sampleRate, channels, opus.AppVoIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packets [][]byte
+	for f := 0; f < 5; f++ {
+		pcm := make([]int16, frameSize)
+		for i := range pcm {
+			ts := float64(f*frameSize+i) / sampleRate
+			pcm[i] = int16(8000 * math.Sin(2*math.Pi*440*ts))
+		}
+		buf := make([]byte, 4000)
+		n, err := enc.Encode(pcm, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packets = append(packets, buf[:n])
+	}
+	// 第4包丢失
+	packets[3] = nil
+
+	out := filepath.Join(t.TempDir(), "capture.wav")
+	if err := OpusStreamToWav(packets, sampleRate, channels, out); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	dec := wav.NewDecoder(f)
+	buf, err := dec.FullPCMBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.SampleRate != sampleRate || int(dec.NumChans) != channels {
+		t.Fatalf("format = %d Hz / %d ch", dec.SampleRate, dec.NumChans)
+	}
+	// 丢包经补偿后时长不变
+	if got, want := len(buf.Data), 5*frameSize; got != want {
+		t.Fatalf("samples = %d, want %d", got, want)
+	}
+
+	if err := OpusStreamToWav([][]byte{nil}, sampleRate, channels, out); err == nil {
+		t.Fatal("expected error for stream without decodable packets")
+	}
+}
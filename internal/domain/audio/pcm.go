@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// PCM 字节序转换工具，字节序由调用方显式指定（设备与 WAV 数据均为小端，通常传 binary.LittleEndian）
+
+// Int16PCMToBytes 将 int16 PCM 样本按指定字节序转换为字节
+func Int16PCMToBytes(pcm []int16, order binary.ByteOrder) []byte {
+	out := make([]byte, len(pcm)*2)
+	for i, sample := range pcm {
+		order.PutUint16(out[i*2:], uint16(sample))
+	}
+	return out
+}
+
+// BytesToInt16PCM 将字节按指定字节序解析为 int16 PCM 样本，长度必须为 2 的倍数
+func BytesToInt16PCM(data []byte, order binary.ByteOrder) ([]int16, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("int16 PCM 数据长度必须为2的倍数, 实际: %d", len(data))
+	}
+	out := make([]int16, len(data)/2)
+	for i := range out {
+		out[i] = int16(order.Uint16(data[i*2:]))
+	}
+	return out, nil
+}
+
+// Float32ToBytes 将 float32 样本按指定字节序转换为字节（IEEE 754）
+func Float32ToBytes(pcm []float32, order binary.ByteOrder) []byte {
+	out := make([]byte, len(pcm)*4)
+	for i, sample := range pcm {
+		order.PutUint32(out[i*4:], math.Float32bits(sample))
+	}
+	return out
+}
+
+// BytesToFloat32 将字节按指定字节序解析为 float32 样本，长度必须为 4 的倍数
+func BytesToFloat32(data []byte, order binary.ByteOrder) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("float32 PCM 数据长度必须为4的倍数, 实际: %d", len(data))
+	}
+	out := make([]float32, len(data)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(order.Uint32(data[i*4:]))
+	}
+	return out, nil
+}
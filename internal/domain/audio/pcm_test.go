@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestInt16PCMRoundTrip(t *testing.T) {
+	samples := []int16{0, 1, -1, 1234, -1234, math.MaxInt16, math.MinInt16}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := Int16PCMToBytes(samples, order)
+		if len(data) != len(samples)*2 {
+			t.Fatalf("%v: len = %d, want %d", order, len(data), len(samples)*2)
+		}
+		got, err := BytesToInt16PCM(data, order)
+		if err != nil {
+			t.Fatalf("%v: BytesToInt16PCM error: %v", order, err)
+		}
+		for i := range samples {
+			if got[i] != samples[i] {
+				t.Fatalf("%v: sample %d = %d, want %d", order, i, got[i], samples[i])
+			}
+		}
+	}
+}
+
+func TestInt16PCMByteOrder(t *testing.T) {
+	data := Int16PCMToBytes([]int16{0x0102}, binary.LittleEndian)
+	if data[0] != 0x02 || data[1] != 0x01 {
+		t.Fatalf("little endian bytes = %x, want 0201", data)
+	}
+	data = Int16PCMToBytes([]int16{0x0102}, binary.BigEndian)
+	if data[0] != 0x01 || data[1] != 0x02 {
+		t.Fatalf("big endian bytes = %x, want 0102", data)
+	}
+}
+
+func TestFloat32RoundTrip(t *testing.T) {
+	samples := []float32{0, 1, -1, 0.5, -0.25, 1e-7, math.MaxFloat32}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := Float32ToBytes(samples, order)
+		if len(data) != len(samples)*4 {
+			t.Fatalf("%v: len = %d, want %d", order, len(data), len(samples)*4)
+		}
+		got, err := BytesToFloat32(data, order)
+		if err != nil {
+			t.Fatalf("%v: BytesToFloat32 error: %v", order, err)
+		}
+		for i := range samples {
+			if got[i] != samples[i] {
+				t.Fatalf("%v: sample %d = %v, want %v", order, i, got[i], samples[i])
+			}
+		}
+	}
+}
+
+func TestBytesToPCMInvalidLength(t *testing.T) {
+	if _, err := BytesToInt16PCM([]byte{1, 2, 3}, binary.LittleEndian); err == nil {
+		t.Fatal("BytesToInt16PCM expected error for odd length")
+	}
+	if _, err := BytesToFloat32([]byte{1, 2, 3, 4, 5}, binary.LittleEndian); err == nil {
+		t.Fatal("BytesToFloat32 expected error for length not multiple of 4")
+	}
+}
@@ -0,0 +1,43 @@
+package audio
+
+// DefaultPreEmphasisCoeff 常用的预加重系数
+const DefaultPreEmphasisCoeff = 0.97
+
+// PreEmphasisFilter 一阶预加重（高通）滤波器 y[n] = x[n] - coeff*x[n-1]
+// 提升高频分量，改善 ASR 对发闷音频的识别；跨帧调用 Process 时保留上一帧末尾样本，帧边界无跳变
+// 非并发安全，按单声道样本处理
+type PreEmphasisFilter struct {
+	coeff float32
+	prev  float32
+}
+
+// NewPreEmphasisFilter 创建预加重滤波器，coeff 取值 [0, 1)，超出范围时截断；coeff 为 0 时等价于直通
+func NewPreEmphasisFilter(coeff float64) *PreEmphasisFilter {
+	if coeff < 0 {
+		coeff = 0
+	}
+	if coeff >= 1 {
+		coeff = 0.99
+	}
+	return &PreEmphasisFilter{coeff: float32(coeff)}
+}
+
+// Process 处理一帧音频，返回新切片，不修改输入
+func (f *PreEmphasisFilter) Process(pcm []float32) []float32 {
+	out := make([]float32, len(pcm))
+	for i, s := range pcm {
+		out[i] = s - f.coeff*f.prev
+		f.prev = s
+	}
+	return out
+}
+
+// Reset 清除上一帧的样本状态
+func (f *PreEmphasisFilter) Reset() {
+	f.prev = 0
+}
+
+// PreEmphasis 对整段音频做一次性预加重，首个样本视为前一样本为 0，参数含义同 NewPreEmphasisFilter
+func PreEmphasis(pcm []float32, coeff float64) []float32 {
+	return NewPreEmphasisFilter(coeff).Process(pcm)
+}
@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPreEmphasis(t *testing.T) {
+	out := PreEmphasis([]float32{1, 1, 1, 0}, 0.5)
+	want := []float32{1, 0.5, 0.5, -0.5}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Fatalf("out = %v, want %v", out, want)
+		}
+	}
+
+	// 高通特性：低频衰减明显大于高频
+	const sampleRate = 16000
+	low := make([]float32, sampleRate/10)
+	high := make([]float32, sampleRate/10)
+	for i := range low {
+		ts := float64(i) / sampleRate
+		low[i] = float32(0.5 * math.Sin(2*math.Pi*100*ts))
+		high[i] = float32(0.5 * math.Sin(2*math.Pi*4000*ts))
+	}
+	lowGain := rms(PreEmphasis(low, DefaultPreEmphasisCoeff)) / rms(low)
+	highGain := rms(PreEmphasis(high, DefaultPreEmphasisCoeff)) / rms(high)
+	if lowGain > 0.1 || highGain < 1 {
+		t.Fatalf("low gain = %.3f, high gain = %.3f", lowGain, highGain)
+	}
+
+	// coeff 为 0 时直通
+	if out := PreEmphasis(low, 0); out[10] != low[10] {
+		t.Fatal("coeff 0 should pass through")
+	}
+}
+
+func TestPreEmphasisStreamingMatchesOneShot(t *testing.T) {
+	pcm := make([]float32, 1600)
+	for i := range pcm {
+		pcm[i] = float32(0.3 * math.Sin(float64(i)/5))
+	}
+	want := PreEmphasis(pcm, DefaultPreEmphasisCoeff)
+
+	f := NewPreEmphasisFilter(DefaultPreEmphasisCoeff)
+	var got []float32
+	for i := 0; i < len(pcm); i += 320 {
+		got = append(got, f.Process(pcm[i:i+320])...)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
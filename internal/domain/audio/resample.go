@@ -0,0 +1,57 @@
+package audio
+
+import "math"
+
+// Resample 将单声道 PCM 从 fromRate 重采样到 toRate（线性插值）；
+// 降采样时先做滑动平均低通，抑制高频混叠。采样率相同或参数无效时原样返回
+func Resample(pcm []float32, fromRate, toRate int) []float32 {
+	if fromRate <= 0 || toRate <= 0 || fromRate == toRate || len(pcm) == 0 {
+		return pcm
+	}
+	src := pcm
+	if toRate < fromRate {
+		src = movingAverage(pcm, int(math.Ceil(float64(fromRate)/float64(toRate))))
+	}
+
+	ratio := float64(fromRate) / float64(toRate)
+	outLen := int(float64(len(pcm)) / ratio)
+	if outLen == 0 {
+		outLen = 1
+	}
+	out := make([]float32, outLen)
+	last := len(src) - 1
+	for i := range out {
+		pos := float64(i) * ratio
+		idx := int(pos)
+		if idx >= last {
+			out[i] = src[last]
+			continue
+		}
+		frac := float32(pos - float64(idx))
+		out[i] = src[idx]*(1-frac) + src[idx+1]*frac
+	}
+	return out
+}
+
+// movingAverage 居中的滑动平均，窗口小于 2 时原样返回
+func movingAverage(pcm []float32, window int) []float32 {
+	if window < 2 {
+		return pcm
+	}
+	out := make([]float32, len(pcm))
+	half := window / 2
+	var sum float64
+	lo, hi := 0, -1
+	for i := range pcm {
+		for hi < i+half && hi < len(pcm)-1 {
+			hi++
+			sum += float64(pcm[hi])
+		}
+		for lo < i-half {
+			sum -= float64(pcm[lo])
+			lo++
+		}
+		out[i] = float32(sum / float64(hi-lo+1))
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func sineWave(freq float64, sampleRate, n int) []float32 {
+	out := make([]float32, n)
+	for i := range out {
+		out[i] = float32(0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return out
+}
+
+// dominantCycles 通过过零次数估算信号周期数
+func dominantCycles(pcm []float32) int {
+	crossings := 0
+	for i := 1; i < len(pcm); i++ {
+		if (pcm[i-1] < 0) != (pcm[i] < 0) {
+			crossings++
+		}
+	}
+	return crossings / 2
+}
+
+func TestResampleLengthAndFrequency(t *testing.T) {
+	for _, tc := range []struct{ from, to int }{{48000, 16000}, {8000, 16000}, {44100, 16000}, {16000, 24000}} {
+		in := sineWave(440, tc.from, tc.from) // 1 秒 440Hz
+		out := Resample(in, tc.from, tc.to)
+		if math.Abs(float64(len(out)-tc.to)) > 1 {
+			t.Fatalf("%d->%d: len = %d, want ~%d", tc.from, tc.to, len(out), tc.to)
+		}
+		if cycles := dominantCycles(out); cycles < 437 || cycles > 443 {
+			t.Fatalf("%d->%d: cycles = %d, want ~440", tc.from, tc.to, cycles)
+		}
+	}
+}
+
+func TestResampleSuppressesAliasing(t *testing.T) {
+	// 48kHz 下 20kHz 的高频信号降到 16kHz 时应被大幅衰减，而不是折叠成可闻频率
+	out := Resample(sineWave(20000, 48000, 4800), 48000, 16000)
+	var peak float32
+	for _, v := range out[10 : len(out)-10] {
+		if v < 0 {
+			v = -v
+		}
+		if v > peak {
+			peak = v
+		}
+	}
+	if peak > 0.2 {
+		t.Fatalf("aliased peak = %.3f, want strongly attenuated", peak)
+	}
+}
+
+func TestResampleNoop(t *testing.T) {
+	in := []float32{0.1, 0.2}
+	if out := Resample(in, 16000, 16000); &out[0] != &in[0] {
+		t.Fatal("same rate should return input")
+	}
+	if out := Resample(nil, 8000, 16000); out != nil {
+		t.Fatal("empty input should return nil")
+	}
+	if out := Resample(in, 0, 16000); len(out) != 2 {
+		t.Fatal("invalid rate should return input")
+	}
+}
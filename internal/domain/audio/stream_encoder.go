@@ -0,0 +1,89 @@
+package audio
+
+import "fmt"
+
+// opusMaxPacketBytes 单个 opus 包的最大字节数（libopus 推荐值）
+const opusMaxPacketBytes = 4000
+
+// StreamEncoder 流式 opus 编码器：任意长度的 PCM 分块写入后按帧边界切分编码，
+// 不足一帧的尾部保留到下次写入，Flush 时补零编码为最后一包
+type StreamEncoder struct {
+	processer *AudioProcesser
+	frameSize int // 每帧采样数（含所有声道）
+	pending   []int16
+	packet    []byte
+}
+
+// NewStreamEncoder 创建流式编码器，frameDurationMs 支持 opus 的 5/10/20/40/60 毫秒帧长
+func NewStreamEncoder(sampleRate, channels, frameDurationMs int) (*StreamEncoder, error) {
+	switch frameDurationMs {
+	case 5, 10, 20, 40, 60:
+	default:
+		return nil, fmt.Errorf("不支持的 opus 帧长: %dms", frameDurationMs)
+	}
+	processer, err := GetAudioProcesser(sampleRate, channels, frameDurationMs)
+	if err != nil {
+		return nil, err
+	}
+	frameSize := sampleRate * frameDurationMs / 1000 * channels
+	return &StreamEncoder{
+		processer: processer,
+		frameSize: frameSize,
+		pending:   make([]int16, 0, frameSize),
+		packet:    make([]byte, opusMaxPacketBytes),
+	}, nil
+}
+
+// Write 写入 PCM（多声道为交错采样），返回本次凑满的完整帧编码出的 opus 包
+func (s *StreamEncoder) Write(pcm []int16) ([][]byte, error) {
+	var packets [][]byte
+	for len(pcm) > 0 {
+		n := s.frameSize - len(s.pending)
+		if n > len(pcm) {
+			n = len(pcm)
+		}
+		s.pending = append(s.pending, pcm[:n]...)
+		pcm = pcm[n:]
+		if len(s.pending) < s.frameSize {
+			break
+		}
+		packet, err := s.encodePending()
+		if err != nil {
+			return packets, err
+		}
+		packets = append(packets, packet)
+	}
+	return packets, nil
+}
+
+// Flush 将不足一帧的剩余 PCM 补零编码为最后一包；没有剩余数据时返回 nil
+func (s *StreamEncoder) Flush() ([]byte, error) {
+	if len(s.pending) == 0 {
+		return nil, nil
+	}
+	for len(s.pending) < s.frameSize {
+		s.pending = append(s.pending, 0)
+	}
+	return s.encodePending()
+}
+
+// Buffered 返回尚未凑满一帧的缓存采样数
+func (s *StreamEncoder) Buffered() int {
+	return len(s.pending)
+}
+
+// Reset 丢弃缓存的 PCM，用于打断后开始新的音频流
+func (s *StreamEncoder) Reset() {
+	s.pending = s.pending[:0]
+}
+
+func (s *StreamEncoder) encodePending() ([]byte, error) {
+	n, err := s.processer.Encoder(s.pending, s.packet)
+	s.pending = s.pending[:0]
+	if err != nil {
+		return nil, fmt.Errorf("opus 编码失败: %v", err)
+	}
+	packet := make([]byte, n)
+	copy(packet, s.packet[:n])
+	return packet, nil
+}
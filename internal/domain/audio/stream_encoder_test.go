@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStreamEncoderFramesAndFlush(t *testing.T) {
+	const sampleRate, channels, frameMs = 16000, 1, 60
+	frameSize := sampleRate * frameMs / 1000
+
+	enc, err := NewStreamEncoder(sampleRate, channels, frameMs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := frameSize*3 + frameSize/2
+	pcm := make([]int16, total)
+	for i := range pcm {
+		pcm[i] = int16(8000 * math.Sin(2*math.Pi*440*float64(i)/sampleRate))
+	}
+
+	// 以与帧长无关的块大小写入
+	var packets [][]byte
+	for off := 0; off < total; off += 333 {
+		end := off + 333
+		if end > total {
+			end = total
+		}
+		out, err := enc.Write(pcm[off:end])
+		if err != nil {
+			t.Fatal(err)
+		}
+		packets = append(packets, out...)
+	}
+	if len(packets) != 3 || enc.Buffered() != frameSize/2 {
+		t.Fatalf("packets=%d buffered=%d", len(packets), enc.Buffered())
+	}
+	last, err := enc.Flush()
+	if err != nil || len(last) == 0 {
+		t.Fatalf("flush = %d bytes, err=%v", len(last), err)
+	}
+	packets = append(packets, last)
+	if enc.Buffered() != 0 {
+		t.Fatal("flush should clear buffer")
+	}
+	if again, err := enc.Flush(); err != nil || again != nil {
+		t.Fatal("flush on empty buffer should return nil")
+	}
+
+	// 每个包都应解码为一个完整帧
+	dec, err := GetAudioProcesser(sampleRate, channels, frameMs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]int16, frameSize)
+	for i, p := range packets {
+		n, err := dec.Decoder(p, out)
+		if err != nil || n != frameSize {
+			t.Fatalf("packet %d decoded %d samples, err=%v", i, n, err)
+		}
+	}
+}
+
+func TestStreamEncoderResetAndValidation(t *testing.T) {
+	if _, err := NewStreamEncoder(16000, 1, 30); err == nil {
+		t.Fatal("30ms frame should be rejected")
+	}
+	enc, err := NewStreamEncoder(16000, 2, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 双声道 20ms 一帧为 640 个交错采样
+	if out, _ := enc.Write(make([]int16, 639)); len(out) != 0 || enc.Buffered() != 639 {
+		t.Fatalf("out=%d buffered=%d", len(out), enc.Buffered())
+	}
+	enc.Reset()
+	if enc.Buffered() != 0 {
+		t.Fatal("Reset should drop buffered samples")
+	}
+	if out, _ := enc.Write(make([]int16, 1280)); len(out) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(out))
+	}
+}
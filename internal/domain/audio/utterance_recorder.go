@@ -0,0 +1,153 @@
+package audio
+
+import "sync"
+
+// UtteranceRecorderConfig 语音片段录制参数
+type UtteranceRecorderConfig struct {
+	SampleRate        int // 采样率
+	PreRollMs         int // VAD 触发前回溯保留的音频时长
+	TrailingSilenceMs int // 录制中持续静音达到该时长后结束片段
+	MaxUtteranceMs    int // 单个片段最大时长，<=0 表示不限制
+}
+
+// Utterance 一段完整的语音片段（含前置缓冲与尾部静音）
+type Utterance struct {
+	PCM        []float32
+	StartMs    int64 // 片段起点在输入流中的位置（含前置缓冲）
+	DurationMs int64
+	Truncated  bool // 因达到最大时长被强制结束
+}
+
+// UtteranceRecorder 基于逐帧 VAD 结果的语音片段录制器
+// 空闲时持续缓存最近 PreRollMs 的音频；VAD 触发后连同缓存一起开始录制，
+// 持续静音达到 TrailingSilenceMs（或达到最大时长）后输出完整片段
+type UtteranceRecorder struct {
+	mu  sync.Mutex
+	cfg UtteranceRecorderConfig
+
+	preRoll        []float32
+	preRollSamples int
+
+	recording       bool
+	clip            []float32
+	clipStart       int64 // 片段起点（样本数）
+	silenceSamples  int
+	silenceLimit    int
+	maxSamples      int
+	consumedSamples int64 // 已写入的样本总数
+}
+
+// NewUtteranceRecorder 创建录制器
+func NewUtteranceRecorder(cfg UtteranceRecorderConfig) *UtteranceRecorder {
+	if cfg.SampleRate <= 0 {
+		cfg.SampleRate = 16000
+	}
+	r := &UtteranceRecorder{
+		cfg:            cfg,
+		preRollSamples: msToSamples(cfg.PreRollMs, cfg.SampleRate),
+		silenceLimit:   msToSamples(cfg.TrailingSilenceMs, cfg.SampleRate),
+		maxSamples:     msToSamples(cfg.MaxUtteranceMs, cfg.SampleRate),
+	}
+	return r
+}
+
+func msToSamples(ms, sampleRate int) int {
+	if ms <= 0 {
+		return 0
+	}
+	return ms * sampleRate / 1000
+}
+
+// Write 写入一帧 PCM 及其 VAD 结果；片段结束时返回该片段
+func (r *UtteranceRecorder) Write(pcm []float32, isSpeech bool) (*Utterance, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	defer func() { r.consumedSamples += int64(len(pcm)) }()
+
+	if !r.recording {
+		if !isSpeech {
+			r.appendPreRoll(pcm)
+			return nil, false
+		}
+		// VAD 触发：以前置缓冲作为片段开头
+		r.recording = true
+		r.clip = append(make([]float32, 0, len(r.preRoll)+len(pcm)), r.preRoll...)
+		r.clipStart = r.consumedSamples - int64(len(r.preRoll))
+		r.preRoll = r.preRoll[:0]
+		r.silenceSamples = 0
+	}
+
+	r.clip = append(r.clip, pcm...)
+	if isSpeech {
+		r.silenceSamples = 0
+	} else {
+		r.silenceSamples += len(pcm)
+	}
+
+	if r.maxSamples > 0 && len(r.clip) >= r.maxSamples {
+		return r.finish(true), true
+	}
+	if !isSpeech && r.silenceSamples >= r.silenceLimit {
+		return r.finish(false), true
+	}
+	return nil, false
+}
+
+// Flush 输入结束时输出正在录制的片段
+func (r *UtteranceRecorder) Flush() (*Utterance, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording {
+		return nil, false
+	}
+	return r.finish(false), true
+}
+
+// IsRecording 是否正在录制片段
+func (r *UtteranceRecorder) IsRecording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
+// Reset 丢弃缓存与正在录制的片段
+func (r *UtteranceRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preRoll = r.preRoll[:0]
+	r.recording = false
+	r.clip = nil
+	r.silenceSamples = 0
+	r.consumedSamples = 0
+}
+
+// appendPreRoll 写入前置缓冲，仅保留最近 preRollSamples 个样本
+func (r *UtteranceRecorder) appendPreRoll(pcm []float32) {
+	if r.preRollSamples == 0 {
+		return
+	}
+	if len(pcm) >= r.preRollSamples {
+		r.preRoll = append(r.preRoll[:0], pcm[len(pcm)-r.preRollSamples:]...)
+		return
+	}
+	if overflow := len(r.preRoll) + len(pcm) - r.preRollSamples; overflow > 0 {
+		r.preRoll = append(r.preRoll[:0], r.preRoll[overflow:]...)
+	}
+	r.preRoll = append(r.preRoll, pcm...)
+}
+
+// finish 结束当前片段；调用方需持有锁
+func (r *UtteranceRecorder) finish(truncated bool) *Utterance {
+	sampleRate := int64(r.cfg.SampleRate)
+	u := &Utterance{
+		PCM:        r.clip,
+		StartMs:    r.clipStart * 1000 / sampleRate,
+		DurationMs: int64(len(r.clip)) * 1000 / sampleRate,
+		Truncated:  truncated,
+	}
+	r.recording = false
+	r.clip = nil
+	r.silenceSamples = 0
+	return u
+}
@@ -0,0 +1,77 @@
+package audio
+
+import "testing"
+
+// recorderTestFrame 生成一帧 10ms@16kHz 的 PCM，样本值用于标识帧序号
+func recorderTestFrame(v float32) []float32 {
+	pcm := make([]float32, 160)
+	for i := range pcm {
+		pcm[i] = v
+	}
+	return pcm
+}
+
+func TestUtteranceRecorderPreRollAndTrailingSilence(t *testing.T) {
+	r := NewUtteranceRecorder(UtteranceRecorderConfig{SampleRate: 16000, PreRollMs: 20, TrailingSilenceMs: 30})
+
+	// 5 帧静音，仅保留最近 2 帧作为前置缓冲
+	for i := 0; i < 5; i++ {
+		if _, done := r.Write(recorderTestFrame(float32(i)), false); done {
+			t.Fatal("silence should not produce utterance")
+		}
+	}
+	// 3 帧语音 + 1 帧短静音 + 1 帧语音
+	speech := []bool{true, true, true, false, true}
+	for i, isSpeech := range speech {
+		if _, done := r.Write(recorderTestFrame(float32(10+i)), isSpeech); done {
+			t.Fatalf("utterance finished early at frame %d", i)
+		}
+	}
+	if !r.IsRecording() {
+		t.Fatal("recorder should be recording")
+	}
+	// 3 帧静音达到 30ms 尾部静音
+	var u *Utterance
+	for i := 0; i < 3; i++ {
+		var done bool
+		u, done = r.Write(recorderTestFrame(float32(20+i)), false)
+		if done != (i == 2) {
+			t.Fatalf("frame %d: done = %v", i, done)
+		}
+	}
+
+	// 2 帧前置 + 5 帧 + 3 帧尾部静音
+	if len(u.PCM) != 10*160 {
+		t.Fatalf("len(PCM) = %d, want %d", len(u.PCM), 10*160)
+	}
+	if u.PCM[0] != 3 || u.PCM[2*160] != 10 {
+		t.Fatalf("pre-roll not at clip start: first=%v speech_start=%v", u.PCM[0], u.PCM[2*160])
+	}
+	if u.StartMs != 30 || u.DurationMs != 100 || u.Truncated {
+		t.Fatalf("StartMs=%d DurationMs=%d Truncated=%v, want 30, 100, false", u.StartMs, u.DurationMs, u.Truncated)
+	}
+	if r.IsRecording() {
+		t.Fatal("recorder should be idle after utterance")
+	}
+}
+
+func TestUtteranceRecorderMaxDurationAndFlush(t *testing.T) {
+	r := NewUtteranceRecorder(UtteranceRecorderConfig{SampleRate: 16000, TrailingSilenceMs: 100, MaxUtteranceMs: 30})
+	var u *Utterance
+	var done bool
+	for i := 0; i < 3; i++ {
+		u, done = r.Write(recorderTestFrame(1), true)
+	}
+	if !done || !u.Truncated || u.DurationMs != 30 {
+		t.Fatalf("expected truncated 30ms utterance, got done=%v u=%+v", done, u)
+	}
+
+	r.Write(recorderTestFrame(1), true)
+	u, done = r.Flush()
+	if !done || u.DurationMs != 10 {
+		t.Fatalf("Flush: done=%v u=%+v", done, u)
+	}
+	if _, done = r.Flush(); done {
+		t.Fatal("Flush on idle recorder should return nothing")
+	}
+}
@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// DecodeWavPCM16 解码 16 位 PCM WAV，返回按声道交错的样本；sampleRate 大于 0 且与文件采样率不同时，
+// 逐声道重采样到 sampleRate。返回的 WavInfo 为文件原始头信息
+func DecodeWavPCM16(data []byte, sampleRate int) ([]int16, WavInfo, error) {
+	info, err := ParseWavHeader(data)
+	if err != nil {
+		return nil, info, err
+	}
+	if err := info.ValidatePCM(16); err != nil {
+		return nil, info, err
+	}
+	// data 分块声明长度可能大于实际内容（流式写入未回填），按实际长度截断
+	end := info.DataOffset + info.DataSize
+	if info.DataSize <= 0 || end > len(data) {
+		end = len(data)
+	}
+	frameBytes := 2 * info.Channels
+	payload := data[info.DataOffset:end]
+	payload = payload[:len(payload)-len(payload)%frameBytes]
+	if len(payload) == 0 {
+		return nil, info, errors.New("WAV 不包含音频数据")
+	}
+	samples, err := BytesToInt16PCM(payload, binary.LittleEndian)
+	if err != nil {
+		return nil, info, err
+	}
+	if sampleRate <= 0 || sampleRate == info.SampleRate {
+		return samples, info, nil
+	}
+	return resampleInterleavedPCM16(samples, info.Channels, info.SampleRate, sampleRate), info, nil
+}
+
+// resampleInterleavedPCM16 对交错的多声道 int16 样本逐声道重采样
+func resampleInterleavedPCM16(samples []int16, channels, fromRate, toRate int) []int16 {
+	frames := len(samples) / channels
+	var out []int16
+	for ch := 0; ch < channels; ch++ {
+		mono := make([]float32, frames)
+		for i := range mono {
+			mono[i] = float32(samples[i*channels+ch])
+		}
+		resampled := Resample(mono, fromRate, toRate)
+		if out == nil {
+			out = make([]int16, len(resampled)*channels)
+		}
+		for i, v := range resampled {
+			out[i*channels+ch] = int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(float64(v)))))
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// buildPCM16Wav 构造 16 位 PCM WAV
+func buildPCM16Wav(sampleRate, channels int, samples []int16) []byte {
+	data := Int16PCMToBytes(samples, binary.LittleEndian)
+	b := []byte("RIFF")
+	b = binary.LittleEndian.AppendUint32(b, uint32(36+len(data)))
+	b = append(b, "WAVEfmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, WavFormatPCM)
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(sampleRate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(sampleRate*channels*2))
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels*2))
+	b = binary.LittleEndian.AppendUint16(b, 16)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, data...)
+}
+
+func TestDecodeWavPCM16(t *testing.T) {
+	samples := make([]int16, 3200)
+	for i := range samples {
+		samples[i] = int16(i % 100)
+	}
+	wav := buildPCM16Wav(16000, 1, samples)
+
+	got, info, err := DecodeWavPCM16(wav, 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SampleRate != 16000 || len(got) != len(samples) || got[99] != 99 {
+		t.Fatalf("info = %+v, len = %d", info, len(got))
+	}
+	if err := info.CheckSampleRate(16000); err != nil {
+		t.Fatal(err)
+	}
+
+	// 48kHz 立体声重采样到 16kHz：每声道样本数缩为 1/3，声道值保持
+	stereo := make([]int16, 4800*2)
+	for i := 0; i < 4800; i++ {
+		stereo[2*i] = 1000
+		stereo[2*i+1] = -1000
+	}
+	got, info, err = DecodeWavPCM16(buildPCM16Wav(48000, 2, stereo), 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SampleRate != 48000 || len(got) != 1600*2 {
+		t.Fatalf("info = %+v, len = %d", info, len(got))
+	}
+	if got[100] != 1000 || got[101] != -1000 {
+		t.Fatalf("channels mixed: %d %d", got[100], got[101])
+	}
+
+	err = info.CheckSampleRate(16000)
+	if err == nil || !strings.Contains(err.Error(), "48000Hz") || !strings.Contains(err.Error(), "16000Hz") {
+		t.Fatalf("CheckSampleRate err = %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// WAV fmt 分块中的编码格式（wFormatTag）
+const (
+	WavFormatPCM        = 0x0001
+	WavFormatIEEEFloat  = 0x0003
+	WavFormatExtensible = 0xFFFE
+)
+
+// wavFormatNames 常见编码格式名称，用于错误提示
+var wavFormatNames = map[uint16]string{
+	0x0001: "PCM",
+	0x0002: "Microsoft ADPCM",
+	0x0003: "IEEE float",
+	0x0006: "A-law",
+	0x0007: "μ-law",
+	0x0011: "IMA ADPCM",
+	0x0031: "GSM 6.10",
+	0x0055: "MP3",
+}
+
+// WavInfo WAV 头信息；AudioFormat 为 WAVE_FORMAT_EXTENSIBLE 时已解析为子格式
+type WavInfo struct {
+	AudioFormat   uint16
+	Channels      int
+	SampleRate    int
+	BitsPerSample int
+	DataSize      int // data 分块声明的字节数
+	DataOffset    int // data 分块内容在文件中的起始偏移
+}
+
+// FormatName 编码格式名称，未知格式返回十六进制编号
+func (w WavInfo) FormatName() string {
+	if name, ok := wavFormatNames[w.AudioFormat]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", w.AudioFormat)
+}
+
+// ParseWavHeader 解析 RIFF/WAVE 头，遍历分块直到 data 分块，返回 fmt 信息
+func ParseWavHeader(data []byte) (WavInfo, error) {
+	var info WavInfo
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return info, errors.New("不是有效的 WAV 文件（缺少 RIFF/WAVE 头）")
+	}
+	hasFmt := false
+	for pos := 12; pos+8 <= len(data); {
+		chunkID := string(data[pos : pos+4])
+		chunkSize := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		switch chunkID {
+		case "fmt ":
+			if chunkSize < 16 || body+chunkSize > len(data) {
+				return info, fmt.Errorf("WAV fmt 分块长度无效: %d", chunkSize)
+			}
+			f := data[body : body+chunkSize]
+			info.AudioFormat = binary.LittleEndian.Uint16(f[0:2])
+			info.Channels = int(binary.LittleEndian.Uint16(f[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(f[4:8]))
+			info.BitsPerSample = int(binary.LittleEndian.Uint16(f[14:16]))
+			// WAVE_FORMAT_EXTENSIBLE：实际编码为 SubFormat GUID 的前两个字节
+			if info.AudioFormat == WavFormatExtensible && chunkSize >= 40 {
+				info.AudioFormat = binary.LittleEndian.Uint16(f[24:26])
+			}
+			hasFmt = true
+		case "data":
+			if !hasFmt {
+				return info, errors.New("WAV 文件缺少 fmt 分块")
+			}
+			info.DataSize = chunkSize
+			info.DataOffset = body
+			return info, nil
+		}
+		// 分块按 2 字节对齐
+		pos = body + chunkSize + chunkSize%2
+	}
+	if !hasFmt {
+		return info, errors.New("WAV 文件缺少 fmt 分块")
+	}
+	return info, errors.New("WAV 文件缺少 data 分块")
+}
+
+// ValidatePCM 校验为未压缩整型 PCM 且位深在 supportedBits 内，否则返回可直接展示给用户的错误
+func (w WavInfo) ValidatePCM(supportedBits ...int) error {
+	if w.AudioFormat != WavFormatPCM {
+		return fmt.Errorf("不支持的 WAV 编码格式: %s，仅支持未压缩 PCM，请先转换（如 ffmpeg -i in.wav -acodec pcm_s16le out.wav）", w.FormatName())
+	}
+	if w.Channels <= 0 || w.SampleRate <= 0 {
+		return fmt.Errorf("WAV 头信息无效: channels=%d sample_rate=%d", w.Channels, w.SampleRate)
+	}
+	for _, bits := range supportedBits {
+		if w.BitsPerSample == bits {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的 WAV 位深: %d bit，支持: %v bit", w.BitsPerSample, supportedBits)
+}
+
+// CheckSampleRate 校验 WAV 实际采样率与期望一致，不一致时返回包含两者的错误，避免按错误采样率解读音频
+func (w WavInfo) CheckSampleRate(sampleRate int) error {
+	if w.SampleRate != sampleRate {
+		return fmt.Errorf("WAV 采样率为 %dHz，与请求的 %dHz 不一致，请先重采样（如 ffmpeg -i in.wav -ar %d out.wav）", w.SampleRate, sampleRate, sampleRate)
+	}
+	return nil
+}
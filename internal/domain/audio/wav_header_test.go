@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// buildWavHeader 构造带可选 LIST 分块的 WAV 头，extensibleSub 非 0 时写 WAVE_FORMAT_EXTENSIBLE
+func buildWavHeader(format uint16, bits int, extensibleSub uint16) []byte {
+	fmtLen := 16
+	if extensibleSub != 0 {
+		fmtLen = 40
+	}
+	fmtChunk := make([]byte, fmtLen)
+	binary.LittleEndian.PutUint16(fmtChunk[0:], format)
+	binary.LittleEndian.PutUint16(fmtChunk[2:], 1)
+	binary.LittleEndian.PutUint32(fmtChunk[4:], 16000)
+	binary.LittleEndian.PutUint32(fmtChunk[8:], uint32(16000*bits/8))
+	binary.LittleEndian.PutUint16(fmtChunk[12:], uint16(bits/8))
+	binary.LittleEndian.PutUint16(fmtChunk[14:], uint16(bits))
+	if extensibleSub != 0 {
+		binary.LittleEndian.PutUint16(fmtChunk[16:], 22)
+		binary.LittleEndian.PutUint16(fmtChunk[24:], extensibleSub)
+	}
+
+	var b []byte
+	chunk := func(id string, body []byte) {
+		b = append(b, id...)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(body)))
+		b = append(b, body...)
+		if len(body)%2 == 1 {
+			b = append(b, 0)
+		}
+	}
+	b = append(b, "RIFF\x00\x00\x00\x00WAVE"...)
+	chunk("LIST", []byte("odd"))
+	chunk("fmt ", fmtChunk)
+	chunk("data", make([]byte, 320))
+	return b
+}
+
+func TestParseWavHeader(t *testing.T) {
+	info, err := ParseWavHeader(buildWavHeader(WavFormatPCM, 16, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SampleRate != 16000 || info.Channels != 1 || info.BitsPerSample != 16 || info.DataSize != 320 {
+		t.Fatalf("info = %+v", info)
+	}
+	if err := info.ValidatePCM(16); err != nil {
+		t.Fatalf("16-bit PCM rejected: %v", err)
+	}
+
+	info, err = ParseWavHeader(buildWavHeader(WavFormatExtensible, 24, WavFormatPCM))
+	if err != nil || info.AudioFormat != WavFormatPCM {
+		t.Fatalf("extensible info = %+v err=%v", info, err)
+	}
+	if err := info.ValidatePCM(16); err == nil || !strings.Contains(err.Error(), "24 bit") {
+		t.Fatalf("24-bit should be rejected, err=%v", err)
+	}
+
+	info, _ = ParseWavHeader(buildWavHeader(0x0011, 4, 0))
+	if err := info.ValidatePCM(16); err == nil || !strings.Contains(err.Error(), "IMA ADPCM") {
+		t.Fatalf("ADPCM should be rejected, err=%v", err)
+	}
+	info, _ = ParseWavHeader(buildWavHeader(WavFormatIEEEFloat, 32, 0))
+	if err := info.ValidatePCM(16, 32); err == nil || !strings.Contains(err.Error(), "IEEE float") {
+		t.Fatalf("float should be rejected, err=%v", err)
+	}
+
+	if _, err := ParseWavHeader([]byte("not a wav file")); err == nil {
+		t.Fatal("expected error for non-WAV data")
+	}
+	if _, err := ParseWavHeader(buildWavHeader(WavFormatPCM, 16, 0)[:40]); err == nil {
+		t.Fatal("expected error for truncated header")
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"xiaozhi-esp32-server-golang/internal/domain/asr"
 	"xiaozhi-esp32-server-golang/internal/domain/llm"
 	"xiaozhi-esp32-server-golang/internal/domain/tts"
+	vadpkg "xiaozhi-esp32-server-golang/internal/domain/vad"
 	"xiaozhi-esp32-server-golang/internal/domain/vad/inter"
 	"xiaozhi-esp32-server-golang/internal/pool"
 	log "xiaozhi-esp32-server-golang/logger"
@@ -150,7 +151,11 @@ func RunConfigTest(data map[string]interface{}, testText string) (vadResult, asr
 			if err != nil {
 				vadResult[configID] = map[string]interface{}{"ok": false, "message": err.Error(), "first_packet_ms": elapsedMs}
 			} else {
-				vadResult[configID] = map[string]interface{}{"ok": true, "message": "通过", "first_packet_ms": elapsedMs}
+				res := map[string]interface{}{"ok": true, "message": "通过", "first_packet_ms": elapsedMs}
+				for k, v := range measureVADLatency(configID, cfg, pcm) {
+					res[k] = v
+				}
+				vadResult[configID] = res
 			}
 		}
 	}
@@ -400,3 +405,63 @@ func redactValue(v interface{}) interface{} {
 		return v
 	}
 }
+
+// vadLatencyFrames 延迟测试最多处理的帧数
+const vadLatencyFrames = 20
+
+// vadFrameSize 读取配置中的 hop_size 作为测试帧长，缺省与 TEN-VAD 默认值一致
+func vadFrameSize(cfg map[string]interface{}) int {
+	switch v := cfg["hop_size"].(type) {
+	case int:
+		if v > 0 {
+			return v
+		}
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	}
+	return inter.WarmupFrameSize
+}
+
+// durationMs 以毫秒表示耗时，保留两位小数（单帧耗时通常不足 1ms）
+func durationMs(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())/10) / 100
+}
+
+// measureVADLatency 分别在未预热和预热后的新实例上测量首帧与稳态单帧耗时。
+// 资源池中的实例创建时已预热，这里单独创建实例，才能看到冷启动首帧的真实开销。
+func measureVADLatency(configID string, cfg map[string]interface{}, pcm []float32) map[string]interface{} {
+	frameSize := vadFrameSize(cfg)
+	out := map[string]interface{}{"frame_size": frameSize}
+
+	cold, err := vadpkg.AcquireVAD("", cfg)
+	if err != nil {
+		log.Debugf("[config_test] VAD %s 创建延迟测试实例失败: %v", configID, err)
+		return out
+	}
+	coldLat, err := inter.MeasureFrameLatency(cold, pcm, frameSize, vadLatencyFrames)
+	cold.Close()
+	if err != nil {
+		log.Debugf("[config_test] VAD %s 冷启动延迟测试失败: %v", configID, err)
+		return out
+	}
+	out["first_frame_ms"] = durationMs(coldLat.FirstFrame)
+	out["steady_state_ms"] = durationMs(coldLat.SteadyState)
+
+	warm, err := vadpkg.AcquireVAD("", cfg)
+	if err != nil {
+		return out
+	}
+	defer warm.Close()
+	t0 := time.Now()
+	if err := inter.Warmup(warm); err != nil {
+		log.Debugf("[config_test] VAD %s 预热失败: %v", configID, err)
+		return out
+	}
+	out["warmup_ms"] = durationMs(time.Since(t0))
+	if warmLat, err := inter.MeasureFrameLatency(warm, pcm, frameSize, 1); err == nil {
+		out["warm_first_frame_ms"] = durationMs(warmLat.FirstFrame)
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package manager
+
+import (
+	"fmt"
+
+	"xiaozhi-esp32-server-golang/internal/domain/rag"
+	log "xiaozhi-esp32-server-golang/logger"
+)
+
+// handleKnowledgeQueryLogRequest 返回本实例记录的知识库最近检索日志
+// body 字段：knowledge_base_id
+func (c *WebSocketClient) handleKnowledgeQueryLogRequest(request *WebSocketRequest) {
+	kbID := uint(0)
+	switch v := request.Body["knowledge_base_id"].(type) {
+	case float64:
+		kbID = uint(v)
+	case int:
+		kbID = uint(v)
+	}
+	if kbID == 0 {
+		err := fmt.Errorf("knowledge_base_id 无效")
+		log.Warnf("[knowledge_query_log] 请求 ID=%s 失败: %v", request.ID, err)
+		_ = c.SendResponse(request.ID, 400, nil, err.Error())
+		return
+	}
+	result := map[string]interface{}{
+		"knowledge_base_id": kbID,
+		"entries":           rag.GetKnowledgeBaseQueryLog(kbID),
+	}
+	_ = c.SendResponse(request.ID, 200, result, "")
+}
@@ -82,6 +82,7 @@ func (c *ConfigManager) GetUserConfig(ctx context.Context, deviceID string) (typ
 			} `json:"voice_identify"`
 			KnowledgeBases  []types.KnowledgeBaseRef `json:"knowledge_bases"`
 			Prompt          string                   `json:"prompt"`
+			Greeting        string                   `json:"greeting"`
 			AgentId         string                   `json:"agent_id"`
 			MemoryMode      string                   `json:"memory_mode"`
 			MCPServiceNames string                   `json:"mcp_service_names"`
@@ -137,6 +138,7 @@ func (c *ConfigManager) GetUserConfig(ctx context.Context, deviceID string) (typ
 	// 构建配置结果
 	config := types.UConfig{
 		SystemPrompt: response.Data.Prompt, // 使用智能体的自定义提示
+		Greeting:     response.Data.Greeting,
 		Asr: types.AsrConfig{
 			Provider: response.Data.ASR.Provider,
 			Config:   parseJsonData(response.Data.ASR.JsonData),
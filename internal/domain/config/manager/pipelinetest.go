@@ -0,0 +1,379 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+
+	"xiaozhi-esp32-server-golang/internal/domain/asr"
+	xzaudio "xiaozhi-esp32-server-golang/internal/domain/audio"
+	"xiaozhi-esp32-server-golang/internal/domain/llm"
+	"xiaozhi-esp32-server-golang/internal/domain/tts"
+	"xiaozhi-esp32-server-golang/internal/domain/vad/inter"
+	"xiaozhi-esp32-server-golang/internal/pool"
+	log "xiaozhi-esp32-server-golang/logger"
+)
+
+const (
+	pipelineTestSampleRate   = 16000
+	pipelineTestVADFrame     = 512 // 32ms @ 16kHz
+	pipelineTestStageTimeout = 30 * time.Second
+	pipelineTestTTSFrameMs   = 60
+	// 语速统计时短于该时长的静音视为语音段内停顿
+	pipelineTestMinSilenceMs = 200
+)
+
+// decodePipelineWav 将 WAV 数据解码为 16kHz 单声道 float32 PCM，多声道取平均；同时返回混音前各声道的削波统计
+func decodePipelineWav(wavData []byte) ([]float32, []xzaudio.ClippingStats, error) {
+	dec := wav.NewDecoder(bytes.NewReader(wavData))
+	if !dec.IsValidFile() {
+		return nil, nil, fmt.Errorf("无效的 WAV 文件")
+	}
+	dec.ReadInfo()
+	wavFmt := dec.Format()
+	if wavFmt == nil || wavFmt.NumChannels <= 0 {
+		return nil, nil, fmt.Errorf("无法解析 WAV 格式")
+	}
+	if wavFmt.SampleRate != pipelineTestSampleRate {
+		return nil, nil, fmt.Errorf("仅支持 %dHz 采样率的 WAV, 实际: %dHz", pipelineTestSampleRate, wavFmt.SampleRate)
+	}
+	bitDepth := int(dec.BitDepth)
+	if bitDepth <= 0 {
+		bitDepth = 16
+	}
+	scale := float32(int64(1) << (bitDepth - 1))
+
+	channels := wavFmt.NumChannels
+	buf := &audio.IntBuffer{Format: wavFmt, SourceBitDepth: bitDepth, Data: make([]int, 4096*channels)}
+	var interleaved []float32
+	for {
+		n, err := dec.PCMBuffer(buf)
+		if err == io.EOF || n == 0 {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range buf.Data[:n-n%channels] {
+			interleaved = append(interleaved, float32(v)/scale)
+		}
+	}
+	if len(interleaved) == 0 {
+		return nil, nil, fmt.Errorf("WAV 不包含音频数据")
+	}
+
+	clipping := xzaudio.DetectClippingChannels(interleaved, channels)
+	return xzaudio.DownmixToMono(interleaved, channels), clipping, nil
+}
+
+// pickPipelineStageConfig 取出某环节下发的配置，存在多条时取 config_id 字典序第一条
+func pickPipelineStageConfig(data map[string]interface{}, typ string) (string, map[string]interface{}) {
+	v, _ := data[typ].(map[string]interface{})
+	ids := make([]string, 0, len(v))
+	for id := range v {
+		if id != "provider" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	sort.Strings(ids)
+	cfg, _ := v[ids[0]].(map[string]interface{})
+	return ids[0], cfg
+}
+
+// RunPipelineTest 用上传的 WAV 按 VAD→ASR→LLM→TTS 顺序执行全链路测试，返回各环节输出
+// 前一环节失败时后续环节标记为跳过
+func RunPipelineTest(data map[string]interface{}, wavData []byte) map[string]interface{} {
+	result := make(map[string]interface{})
+	skip := func(stages ...string) {
+		for _, typ := range stages {
+			result[typ] = map[string]interface{}{"ok": false, "skipped": true, "message": "前一环节失败，已跳过"}
+		}
+	}
+
+	pcm, clipping, err := decodePipelineWav(wavData)
+	if err != nil {
+		result["audio"] = map[string]interface{}{"ok": false, "message": err.Error()}
+		skip("vad", "asr", "llm", "tts")
+		return result
+	}
+	result["audio"] = map[string]interface{}{
+		"ok":          true,
+		"samples":     len(pcm),
+		"duration_ms": len(pcm) * 1000 / pipelineTestSampleRate,
+		"clipping":    clipping,
+	}
+
+	vadR := runPipelineVAD(data, pcm)
+	addPipelineClippingWarning(vadR, clipping)
+	result["vad"] = vadR
+	if ok, _ := vadR["ok"].(bool); !ok {
+		skip("asr", "llm", "tts")
+		return result
+	}
+
+	asrR, text := runPipelineASR(data, pcm)
+	result["asr"] = asrR
+	if ok, _ := asrR["ok"].(bool); !ok {
+		skip("llm", "tts")
+		return result
+	}
+
+	llmR, reply := runPipelineLLM(data, text)
+	result["llm"] = llmR
+	if ok, _ := llmR["ok"].(bool); !ok {
+		skip("tts")
+		return result
+	}
+
+	result["tts"] = runPipelineTTS(data, reply)
+	return result
+}
+
+// addPipelineClippingWarning 在 VAD 结果中附带削波统计（取最严重的声道），超过阈值时给出提示
+func addPipelineClippingWarning(vadR map[string]interface{}, clipping []xzaudio.ClippingStats) {
+	worst := xzaudio.ClippingStats{}
+	for _, stats := range clipping {
+		if stats.Ratio > worst.Ratio {
+			worst = stats
+		}
+	}
+	vadR["clipping"] = worst
+	if worst.Exceeds() {
+		vadR["clipping_warning"] = fmt.Sprintf("音频削波比例 %.2f%%，超过 %.2f%%，可能是麦克风增益过高，会影响识别准确率", worst.Ratio*100, xzaudio.ClipRatioWarnThreshold*100)
+		log.Warnf("[pipeline_test] 音频削波 clipped=%d ratio=%.4f", worst.ClippedSamples, worst.Ratio)
+	}
+}
+
+func runPipelineVAD(data map[string]interface{}, pcm []float32) map[string]interface{} {
+	configID, cfg := pickPipelineStageConfig(data, "vad")
+	if cfg == nil {
+		return map[string]interface{}{"ok": false, "message": "未配置或未启用VAD"}
+	}
+	wrapper, err := pool.Acquire[inter.VAD]("vad", configID, cfg)
+	if err != nil {
+		return map[string]interface{}{"ok": false, "config_id": configID, "message": err.Error()}
+	}
+	defer pool.Release(wrapper)
+	vad := wrapper.GetProvider()
+	_ = vad.Reset()
+
+	t0 := time.Now()
+	speechStartMs, speechEndMs := -1, -1
+	speechFrames, totalFrames := 0, 0
+	frameFlags := make([]bool, 0, len(pcm)/pipelineTestVADFrame)
+	for i := 0; i+pipelineTestVADFrame <= len(pcm); i += pipelineTestVADFrame {
+		isSpeech, err := vad.IsVAD(pcm[i : i+pipelineTestVADFrame])
+		if err != nil {
+			return map[string]interface{}{"ok": false, "config_id": configID, "message": err.Error()}
+		}
+		totalFrames++
+		frameFlags = append(frameFlags, isSpeech)
+		if isSpeech {
+			speechFrames++
+			frameMs := i * 1000 / pipelineTestSampleRate
+			if speechStartMs < 0 {
+				speechStartMs = frameMs
+			}
+			speechEndMs = frameMs + pipelineTestVADFrame*1000/pipelineTestSampleRate
+		}
+	}
+	ret := map[string]interface{}{
+		"ok":              true,
+		"config_id":       configID,
+		"has_speech":      speechFrames > 0,
+		"speech_frames":   speechFrames,
+		"total_frames":    totalFrames,
+		"speech_start_ms": speechStartMs,
+		"speech_end_ms":   speechEndMs,
+		"speech_stats":    inter.ComputeSpeechStats(frameFlags, pipelineTestVADFrame*1000/pipelineTestSampleRate, pipelineTestMinSilenceMs),
+		"elapsed_ms":      time.Since(t0).Milliseconds(),
+	}
+	if speechFrames == 0 {
+		ret["ok"] = false
+		ret["message"] = "未检测到语音"
+	}
+	return ret
+}
+
+func runPipelineASR(data map[string]interface{}, pcm []float32) (map[string]interface{}, string) {
+	configID, cfg := pickPipelineStageConfig(data, "asr")
+	if cfg == nil {
+		return map[string]interface{}{"ok": false, "message": "未配置或未启用ASR"}, ""
+	}
+	// 资源池 creator 需要引擎类型而非 config_id
+	asrEngineType := "funasr"
+	if p, ok := cfg["provider"].(string); ok && p != "" {
+		asrEngineType = p
+	}
+	wrapper, err := pool.Acquire[asr.AsrProvider]("asr", asrEngineType, cfg)
+	if err != nil {
+		return map[string]interface{}{"ok": false, "config_id": configID, "message": err.Error()}, ""
+	}
+	defer pool.Release(wrapper)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pipelineTestStageTimeout)
+	defer cancel()
+	audioCh := make(chan []float32)
+	go func() {
+		defer close(audioCh)
+		const chunk = 3200 // 约 200ms @ 16kHz
+		for i := 0; i < len(pcm); i += chunk {
+			end := i + chunk
+			if end > len(pcm) {
+				end = len(pcm)
+			}
+			select {
+			case audioCh <- pcm[i:end]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	t0 := time.Now()
+	resultChan, err := wrapper.GetProvider().StreamingRecognize(ctx, audioCh)
+	if err != nil {
+		return map[string]interface{}{"ok": false, "config_id": configID, "message": err.Error()}, ""
+	}
+	var finalParts []string
+	var lastText string
+	var firstPacketMs int64 = -1
+	for r := range resultChan {
+		if r.Error != nil {
+			return map[string]interface{}{"ok": false, "config_id": configID, "message": r.Error.Error()}, ""
+		}
+		if r.Text == "" {
+			continue
+		}
+		if firstPacketMs < 0 {
+			firstPacketMs = time.Since(t0).Milliseconds()
+		}
+		lastText = r.Text
+		if r.IsFinal {
+			finalParts = append(finalParts, r.Text)
+		}
+	}
+	text := strings.TrimSpace(strings.Join(finalParts, ""))
+	if text == "" {
+		text = strings.TrimSpace(lastText)
+	}
+	ret := map[string]interface{}{
+		"ok":              text != "",
+		"config_id":       configID,
+		"text":            text,
+		"first_packet_ms": firstPacketMs,
+		"elapsed_ms":      time.Since(t0).Milliseconds(),
+	}
+	if text == "" {
+		ret["message"] = "未识别到文本"
+	}
+	return ret, text
+}
+
+func runPipelineLLM(data map[string]interface{}, text string) (map[string]interface{}, string) {
+	configID, cfg := pickPipelineStageConfig(data, "llm")
+	if cfg == nil {
+		return map[string]interface{}{"ok": false, "message": "未配置或未启用LLM"}, ""
+	}
+	wrapper, err := pool.Acquire[llm.LLMProvider]("llm", configID, cfg)
+	if err != nil {
+		return map[string]interface{}{"ok": false, "config_id": configID, "message": err.Error()}, ""
+	}
+	defer pool.Release(wrapper)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pipelineTestStageTimeout)
+	defer cancel()
+	t0 := time.Now()
+	msgChan := wrapper.GetProvider().ResponseWithContext(ctx, "pipeline_test", []*schema.Message{
+		{Role: schema.User, Content: text},
+	}, nil)
+	var reply strings.Builder
+	var firstPacketMs int64 = -1
+	for msg := range msgChan {
+		if msg == nil {
+			continue
+		}
+		if llm.IsLLMErrorMessage(msg) {
+			return map[string]interface{}{"ok": false, "config_id": configID, "message": llm.LLMErrorMessage(msg)}, ""
+		}
+		if firstPacketMs < 0 {
+			firstPacketMs = time.Since(t0).Milliseconds()
+		}
+		reply.WriteString(msg.Content)
+	}
+	replyText := strings.TrimSpace(reply.String())
+	ret := map[string]interface{}{
+		"ok":              replyText != "",
+		"config_id":       configID,
+		"input":           text,
+		"reply":           replyText,
+		"first_packet_ms": firstPacketMs,
+		"elapsed_ms":      time.Since(t0).Milliseconds(),
+	}
+	if replyText == "" {
+		if ctx.Err() == context.DeadlineExceeded {
+			ret["message"] = "超时"
+		} else {
+			ret["message"] = "未收到响应或调用失败"
+		}
+	}
+	return ret, replyText
+}
+
+func runPipelineTTS(data map[string]interface{}, text string) map[string]interface{} {
+	configID, cfg := pickPipelineStageConfig(data, "tts")
+	if cfg == nil {
+		return map[string]interface{}{"ok": false, "message": "未配置或未启用TTS"}
+	}
+	wrapper, err := pool.Acquire[tts.TTSProvider]("tts", configID, cfg)
+	if err != nil {
+		return map[string]interface{}{"ok": false, "config_id": configID, "message": err.Error()}
+	}
+	defer pool.Release(wrapper)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pipelineTestStageTimeout)
+	defer cancel()
+	t0 := time.Now()
+	outputChan, err := wrapper.GetProvider().TextToSpeechStream(ctx, text, 24000, 1, pipelineTestTTSFrameMs)
+	if err != nil {
+		log.Warnf("[pipeline_test] TTS config_id=%s 合成失败: %v", configID, err)
+		return map[string]interface{}{"ok": false, "config_id": configID, "message": err.Error()}
+	}
+	var frames, totalBytes int
+	var firstPacketMs int64 = -1
+	for chunk := range outputChan {
+		if len(chunk) == 0 {
+			continue
+		}
+		if firstPacketMs < 0 {
+			firstPacketMs = time.Since(t0).Milliseconds()
+		}
+		frames++
+		totalBytes += len(chunk)
+	}
+	ret := map[string]interface{}{
+		"ok":              totalBytes > 0,
+		"config_id":       configID,
+		"text":            text,
+		"frames":          frames,
+		"audio_bytes":     totalBytes,
+		"duration_ms":     frames * pipelineTestTTSFrameMs,
+		"first_packet_ms": firstPacketMs,
+		"elapsed_ms":      time.Since(t0).Milliseconds(),
+	}
+	if totalBytes == 0 {
+		ret["message"] = "未收到有效音频或合成失败"
+	}
+	return ret
+}
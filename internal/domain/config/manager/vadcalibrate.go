@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"xiaozhi-esp32-server-golang/internal/domain/vad"
+	"xiaozhi-esp32-server-golang/internal/domain/vad/inter"
+)
+
+// 阈值扫描默认范围
+const (
+	vadCalibrateDefaultMin  = 0.1
+	vadCalibrateDefaultMax  = 0.9
+	vadCalibrateDefaultStep = 0.05
+)
+
+// RunVADCalibration 用带标注的 WAV 扫描 VAD 阈值，返回各阈值的混淆矩阵及 F1 最高的推荐阈值
+// body 字段：data（仅使用 vad 配置）、segments（标注语音区间）、threshold_min/threshold_max/threshold_step（可选）
+// 每个阈值单独创建 VAD 实例（不经资源池，避免覆盖后的配置被复用）；扫描单阈值，忽略配置中的双阈值迟滞
+func RunVADCalibration(body map[string]interface{}, wavData []byte) (map[string]interface{}, error) {
+	data, _ := body["data"].(map[string]interface{})
+	configID, cfg := pickPipelineStageConfig(data, "vad")
+	if cfg == nil {
+		return nil, fmt.Errorf("未配置或未启用VAD")
+	}
+
+	var segments []inter.LabeledSegment
+	if raw, ok := body["segments"]; ok {
+		b, _ := json.Marshal(raw)
+		if err := json.Unmarshal(b, &segments); err != nil {
+			return nil, fmt.Errorf("segments 格式错误: %v", err)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("缺少语音区间标注 segments")
+	}
+
+	thresholds, err := inter.ThresholdRange(
+		floatFromBody(body, "threshold_min", vadCalibrateDefaultMin),
+		floatFromBody(body, "threshold_max", vadCalibrateDefaultMax),
+		floatFromBody(body, "threshold_step", vadCalibrateDefaultStep),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pcm, _, err := decodePipelineWav(wavData)
+	if err != nil {
+		return nil, err
+	}
+	frameMs := pipelineTestVADFrame * 1000 / pipelineTestSampleRate
+	frames := len(pcm) / pipelineTestVADFrame
+	labels := inter.LabelFrames(segments, frames, frameMs)
+
+	provider, _ := cfg["provider"].(string)
+	t0 := time.Now()
+	points, best, err := inter.SweepThresholds(thresholds, labels, func(threshold float64) ([]bool, error) {
+		overridden := make(map[string]interface{}, len(cfg))
+		for k, v := range cfg {
+			overridden[k] = v
+		}
+		overridden["threshold"] = threshold
+		delete(overridden, "enter_threshold")
+		delete(overridden, "exit_threshold")
+
+		detector, err := vad.AcquireVAD(provider, overridden)
+		if err != nil {
+			return nil, err
+		}
+		defer detector.Close()
+
+		predicted := make([]bool, 0, frames)
+		for i := 0; i+pipelineTestVADFrame <= len(pcm); i += pipelineTestVADFrame {
+			isSpeech, err := detector.IsVAD(pcm[i : i+pipelineTestVADFrame])
+			if err != nil {
+				return nil, err
+			}
+			predicted = append(predicted, isSpeech)
+		}
+		return predicted, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"config_id":         configID,
+		"frame_ms":          frameMs,
+		"total_frames":      frames,
+		"speech_frames":     countTrue(labels),
+		"recommended":       points[best],
+		"points":            points,
+		"elapsed_ms":        time.Since(t0).Milliseconds(),
+		"current_threshold": cfg["threshold"],
+	}, nil
+}
+
+func floatFromBody(body map[string]interface{}, key string, defaultValue float64) float64 {
+	if v, ok := body[key].(float64); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func countTrue(flags []bool) int {
+	n := 0
+	for _, f := range flags {
+		if f {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"fmt"
+
+	"xiaozhi-esp32-server-golang/internal/domain/vad"
+	"xiaozhi-esp32-server-golang/internal/domain/vad/ten_vad"
+	log "xiaozhi-esp32-server-golang/logger"
+)
+
+// vadVersionReporter 可报告原生库版本与生效配置的 VAD 实现
+type vadVersionReporter interface {
+	Version() ten_vad.VersionInfo
+}
+
+// RunVADInfo 按请求中的 vad 配置创建临时实例，返回原生库版本与实例生效的配置（已应用默认值）
+// body 字段：data（仅使用 vad 配置）；实例不经资源池，用完即释放
+func RunVADInfo(body map[string]interface{}) (map[string]interface{}, error) {
+	data, _ := body["data"].(map[string]interface{})
+	configID, cfg := pickPipelineStageConfig(data, "vad")
+	if cfg == nil {
+		return nil, fmt.Errorf("未配置或未启用VAD")
+	}
+	provider, _ := cfg["provider"].(string)
+	detector, err := vad.AcquireVAD(provider, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建VAD实例失败: %v", err)
+	}
+	defer detector.Close()
+
+	result := map[string]interface{}{
+		"config_id": configID,
+		"provider":  provider,
+	}
+	if reporter, ok := detector.(vadVersionReporter); ok {
+		result["version"] = reporter.Version()
+	} else {
+		result["message"] = "该 VAD 实现未提供版本信息"
+	}
+	return result, nil
+}
+
+// handleVADInfoRequest 处理 VAD 版本查询请求
+func (c *WebSocketClient) handleVADInfoRequest(request *WebSocketRequest) {
+	result, err := RunVADInfo(request.Body)
+	if err != nil {
+		log.Warnf("[vad_info] 请求 ID=%s 失败: %v", request.ID, err)
+		_ = c.SendResponse(request.ID, 400, nil, err.Error())
+		return
+	}
+	_ = c.SendResponse(request.ID, 200, result, "")
+}
@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "xiaozhi-esp32-server-golang/logger"
+)
+
+// 控制协议二进制帧（与 manager 后端约定一致）
+// 帧格式：[4字节大端 header 长度][JSON header][负载]
+// 后端先按序发送同一 request_id 的全部二进制帧，再发送 JSON 请求，请求体 binary 字段给出帧数与总字节数
+const (
+	binaryFrameHeaderLen   = 4
+	binaryPayloadMaxSize   = 16 * 1024 * 1024
+	binaryPayloadRetention = 2 * time.Minute
+)
+
+type binaryFrameHeader struct {
+	RequestID string `json:"request_id"`
+	Seq       int    `json:"seq"`
+	Final     bool   `json:"final"`
+}
+
+// pendingBinaryPayload 等待对应 JSON 请求取走的二进制负载
+type pendingBinaryPayload struct {
+	buf       bytes.Buffer
+	frames    int
+	final     bool
+	updatedAt time.Time
+}
+
+// decodeBinaryFrame 解码一个二进制帧
+func decodeBinaryFrame(frame []byte) (binaryFrameHeader, []byte, error) {
+	var header binaryFrameHeader
+	if len(frame) < binaryFrameHeaderLen {
+		return header, nil, fmt.Errorf("二进制帧长度不足")
+	}
+	headerLen := int(binary.BigEndian.Uint32(frame))
+	if headerLen <= 0 || binaryFrameHeaderLen+headerLen > len(frame) {
+		return header, nil, fmt.Errorf("二进制帧头长度无效: %d", headerLen)
+	}
+	if err := json.Unmarshal(frame[binaryFrameHeaderLen:binaryFrameHeaderLen+headerLen], &header); err != nil {
+		return header, nil, fmt.Errorf("解析二进制帧头失败: %v", err)
+	}
+	if header.RequestID == "" {
+		return header, nil, fmt.Errorf("二进制帧缺少 request_id")
+	}
+	return header, frame[binaryFrameHeaderLen+headerLen:], nil
+}
+
+// handleBinaryFrame 缓存收到的二进制帧，等待对应请求取走
+func (c *WebSocketClient) handleBinaryFrame(frame []byte) {
+	header, payload, err := decodeBinaryFrame(frame)
+	if err != nil {
+		log.Warnf("丢弃无效二进制帧: %v", err)
+		return
+	}
+
+	c.binaryMu.Lock()
+	defer c.binaryMu.Unlock()
+
+	now := time.Now()
+	// 清理长时间未被取走的负载
+	for id, pending := range c.binaryPayloads {
+		if now.Sub(pending.updatedAt) > binaryPayloadRetention {
+			delete(c.binaryPayloads, id)
+		}
+	}
+
+	pending, ok := c.binaryPayloads[header.RequestID]
+	if !ok {
+		pending = &pendingBinaryPayload{}
+		c.binaryPayloads[header.RequestID] = pending
+	}
+	if header.Seq != pending.frames {
+		log.Warnf("二进制帧乱序: request_id=%s seq=%d expected=%d", header.RequestID, header.Seq, pending.frames)
+		delete(c.binaryPayloads, header.RequestID)
+		return
+	}
+	if pending.buf.Len()+len(payload) > binaryPayloadMaxSize {
+		log.Warnf("二进制负载超过上限: request_id=%s", header.RequestID)
+		delete(c.binaryPayloads, header.RequestID)
+		return
+	}
+	pending.buf.Write(payload)
+	pending.frames++
+	pending.final = header.Final
+	pending.updatedAt = now
+}
+
+// takeBinaryPayload 取出请求携带的二进制负载，并按请求体 binary 字段校验帧数与大小
+func (c *WebSocketClient) takeBinaryPayload(request *WebSocketRequest) ([]byte, error) {
+	c.binaryMu.Lock()
+	pending, ok := c.binaryPayloads[request.ID]
+	delete(c.binaryPayloads, request.ID)
+	c.binaryMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("未收到请求携带的二进制数据")
+	}
+	if !pending.final {
+		return nil, fmt.Errorf("二进制数据不完整")
+	}
+	if meta, _ := request.Body["binary"].(map[string]interface{}); meta != nil {
+		if frames, ok := meta["frames"].(float64); ok && int(frames) != pending.frames {
+			return nil, fmt.Errorf("二进制帧数不一致: 收到 %d, 期望 %d", pending.frames, int(frames))
+		}
+		if size, ok := meta["size"].(float64); ok && int(size) != pending.buf.Len() {
+			return nil, fmt.Errorf("二进制数据大小不一致: 收到 %d, 期望 %d", pending.buf.Len(), int(size))
+		}
+	}
+	return pending.buf.Bytes(), nil
+}
+
+// handlePipelineTestRequest 处理全链路测试请求：取出上传的 WAV 并依次执行 VAD→ASR→LLM→TTS
+func (c *WebSocketClient) handlePipelineTestRequest(request *WebSocketRequest) {
+	wavData, err := c.takeBinaryPayload(request)
+	if err != nil {
+		log.Warnf("[pipeline_test] 请求 ID=%s 获取音频失败: %v", request.ID, err)
+		_ = c.SendResponse(request.ID, 400, nil, err.Error())
+		return
+	}
+	data, _ := request.Body["data"].(map[string]interface{})
+	if data == nil {
+		_ = c.SendResponse(request.ID, 400, nil, "缺少 data 字段")
+		return
+	}
+	log.Debugf("[pipeline_test] 请求 ID=%s wav_size=%d", request.ID, len(wavData))
+
+	_ = c.SendResponse(request.ID, 200, RunPipelineTest(data, wavData), "")
+}
+
+// handleVADCalibrateRequest 处理 VAD 阈值标定请求：WAV 作为二进制负载，标注与扫描范围在请求体中
+func (c *WebSocketClient) handleVADCalibrateRequest(request *WebSocketRequest) {
+	wavData, err := c.takeBinaryPayload(request)
+	if err != nil {
+		log.Warnf("[vad_calibrate] 请求 ID=%s 获取音频失败: %v", request.ID, err)
+		_ = c.SendResponse(request.ID, 400, nil, err.Error())
+		return
+	}
+	log.Debugf("[vad_calibrate] 请求 ID=%s wav_size=%d", request.ID, len(wavData))
+
+	result, err := RunVADCalibration(request.Body, wavData)
+	if err != nil {
+		_ = c.SendResponse(request.ID, 400, nil, err.Error())
+		return
+	}
+	_ = c.SendResponse(request.ID, 200, result, "")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"sort"
 	"strings"
@@ -40,6 +41,10 @@ type WebSocketClient struct {
 	messageHandle cmap.ConcurrentMap[string, MessageHandleFunc]
 	uuid          string
 
+	// 二进制帧负载，按 request_id 缓存，等待对应 JSON 请求取走
+	binaryPayloads map[string]*pendingBinaryPayload
+	binaryMu       sync.Mutex
+
 	// 重连相关字段
 	retryStopChan  chan struct{}  // 重连协程停止信号
 	retryWg        sync.WaitGroup // 重连协程等待组
@@ -97,6 +102,7 @@ func NewWebSocketClient() *WebSocketClient {
 		messageQueue:   make(chan *WebSocketRequest, 100),
 		messageHandle:  cmap.New[MessageHandleFunc](),
 		uuid:           uuid.New().String(),
+		binaryPayloads: make(map[string]*pendingBinaryPayload),
 		retryStopChan:  make(chan struct{}),
 		isRetrying:     false,
 	}
@@ -656,6 +662,15 @@ func (c *WebSocketClient) handleMessages() {
 				log.Warnf("收到无法识别的WebSocket消息: %+v", rawMessage)
 			}
 
+		case websocket.BinaryMessage:
+			// 二进制帧：请求携带的音频等数据，先缓存，待对应请求到达时取走
+			frame, err := io.ReadAll(reader)
+			if err != nil {
+				log.Errorf("读取二进制消息失败: %v", err)
+				continue
+			}
+			c.handleBinaryFrame(frame)
+
 		case websocket.PingMessage:
 			// 处理ping消息，自动回复pong（使用写入锁保护）
 			log.Debugf("收到ping消息，自动回复pong")
@@ -717,6 +732,22 @@ func (c *WebSocketClient) handleDefaultRequest(request *WebSocketRequest) {
 		// 配置测试可能较耗时（VAD/ASR/LLM/TTS 串行执行），放入独立 goroutine 避免阻塞读循环，支持多请求并发
 		go c.handleConfigTestRequest(request)
 
+	case "/api/pipeline/test":
+		// 全链路测试耗时较长，放入独立 goroutine；二进制负载已在读循环中先于请求收齐
+		go c.handlePipelineTestRequest(request)
+
+	case "/api/vad/calibrate":
+		// 阈值扫描需多次运行 VAD，放入独立 goroutine
+		go c.handleVADCalibrateRequest(request)
+
+	case "/api/vad/info":
+		// 创建临时 VAD 实例读取原生库版本与生效配置
+		go c.handleVADInfoRequest(request)
+
+	case "/api/knowledge/query-log":
+		// 读取内存中的知识库检索日志
+		c.handleKnowledgeQueryLogRequest(request)
+
 	case "/api/mcp/tools":
 		// 处理MCP工具列表请求
 		c.handleMcpToolListRequest(request)
@@ -1212,16 +1243,19 @@ func (c *WebSocketClient) handleMcpToolCallRequest(request *WebSocketRequest) {
 	}
 
 	argBytes, _ := json.Marshal(arguments)
+	t0 := time.Now()
 	result, err := invokable.InvokableRun(context.Background(), string(argBytes))
+	elapsedMs := time.Since(t0).Milliseconds()
 	if err != nil {
 		_ = c.SendResponse(request.ID, 500, nil, fmt.Sprintf("工具调用失败: %v", err))
 		return
 	}
 
 	_ = c.SendResponse(request.ID, 200, map[string]interface{}{
-		"agent_id":  agentID,
-		"device_id": deviceID,
-		"tool_name": toolName,
-		"result":    result,
+		"agent_id":   agentID,
+		"device_id":  deviceID,
+		"tool_name":  toolName,
+		"result":     result,
+		"elapsed_ms": elapsedMs,
 	}, "")
 }
@@ -12,5 +12,6 @@ const (
 
 // 下行pull事件 管理内控 => 主程序
 const (
-	EventHandleMessageInject = "/api/device/inject_msg" //处理消息注入
+	EventHandleMessageInject      = "/api/device/inject_msg"    //处理消息注入
+	EventHandleDeviceConfigReload = "/api/device/config_reload" //重新加载设备配置
 )
@@ -55,6 +55,7 @@ type KnowledgeBaseRef struct {
 
 type UConfig struct {
 	SystemPrompt    string                      `json:"system_prompt"`
+	Greeting        string                      `json:"greeting"` // 角色/智能体开场白，为空时使用全局 greeting_list
 	Asr             AsrConfig                   `json:"asr"`
 	Tts             TtsConfig                   `json:"tts"`
 	Llm             LlmConfig                   `json:"llm"`
@@ -76,7 +77,8 @@ type TtsConfigItem struct {
 }
 
 type KnowledgeSearchHit struct {
-	Content string  `json:"content"`
-	Title   string  `json:"title,omitempty"`
-	Score   float64 `json:"score,omitempty"`
+	Content         string  `json:"content"`
+	Title           string  `json:"title,omitempty"`
+	Score           float64 `json:"score,omitempty"`
+	KnowledgeBaseID uint    `json:"knowledge_base_id,omitempty"` // 命中所属知识库，用于按知识库统计
 }
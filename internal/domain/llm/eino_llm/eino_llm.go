@@ -156,11 +156,19 @@ func createOpenAIChatModel(config map[string]interface{}) (model.ToolCallingChat
 
 	baseURL, _ := config["base_url"].(string)
 
+	client, err := gatewayHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = getHTTPClient()
+	}
+
 	// 创建OpenAI ChatModel配置
 	openaiConfig := &openai.ChatModelConfig{
 		Model:      modelName,
 		APIKey:     apiKey,
-		HTTPClient: getHTTPClient(),
+		HTTPClient: client,
 	}
 
 	if baseURL != "" {
@@ -191,10 +199,16 @@ func createOllamaChatModel(config map[string]interface{}) (model.ToolCallingChat
 		return nil, fmt.Errorf("model_name和base_url不能为空")
 	}
 
+	client, err := gatewayHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建Ollama ChatModel配置
 	ollamaConfig := &ollama.ChatModelConfig{
-		BaseURL: baseURL,
-		Model:   modelName,
+		BaseURL:    baseURL,
+		Model:      modelName,
+		HTTPClient: client,
 	}
 
 	// 使用eino-ext官方Ollama实现
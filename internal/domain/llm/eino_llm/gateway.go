@@ -0,0 +1,112 @@
+package eino_llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// 企业 LLM 网关支持：配置中的 extra_headers 附加到每个请求，proxy_url 指定出站代理
+
+// proxyTransports 按代理地址复用 Transport，避免每个 provider 实例各自维护连接池
+var proxyTransports sync.Map // proxy_url -> *http.Transport
+
+// headerTransport 为请求附加固定请求头
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
+
+// parseExtraHeaders 读取 extra_headers，兼容 JSON 解析出的 map[string]interface{} 与 map[string]string
+func parseExtraHeaders(raw interface{}) (http.Header, error) {
+	headers := http.Header{}
+	switch v := raw.(type) {
+	case nil:
+	case map[string]string:
+		for name, value := range v {
+			headers.Set(name, value)
+		}
+	case map[string]interface{}:
+		for name, value := range v {
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("extra_headers.%s 的值必须是字符串", name)
+			}
+			headers.Set(name, str)
+		}
+	default:
+		return nil, fmt.Errorf("extra_headers 必须是对象")
+	}
+	for name, values := range headers {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("extra_headers 请求头名称不能为空")
+		}
+		for _, value := range values {
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("extra_headers.%s 的值不能包含换行", name)
+			}
+		}
+	}
+	return headers, nil
+}
+
+// proxyTransport 返回经指定代理出站的 Transport，连接池参数与全局客户端一致
+func proxyTransport(proxyURL string) (*http.Transport, error) {
+	if cached, ok := proxyTransports.Load(proxyURL); ok {
+		return cached.(*http.Transport), nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("proxy_url 格式无效: %s", proxyURL)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("proxy_url 仅支持 http/https/socks5: %s", proxyURL)
+	}
+	base, ok := getHTTPClient().Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("全局HTTP客户端Transport类型异常")
+	}
+	transport := base.Clone()
+	transport.Proxy = http.ProxyURL(u)
+	actual, _ := proxyTransports.LoadOrStore(proxyURL, transport)
+	return actual.(*http.Transport), nil
+}
+
+// gatewayHTTPClient 根据 extra_headers 与 proxy_url 构建 HTTP 客户端；均未配置时返回 nil，由调用方使用默认客户端
+func gatewayHTTPClient(config map[string]interface{}) (*http.Client, error) {
+	headers, err := parseExtraHeaders(config["extra_headers"])
+	if err != nil {
+		return nil, err
+	}
+	proxyURL, _ := config["proxy_url"].(string)
+	proxyURL = strings.TrimSpace(proxyURL)
+	if len(headers) == 0 && proxyURL == "" {
+		return nil, nil
+	}
+
+	var transport http.RoundTripper = getHTTPClient().Transport
+	if proxyURL != "" {
+		t, err := proxyTransport(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	}
+	if len(headers) > 0 {
+		transport = &headerTransport{base: transport, headers: headers}
+	}
+	// 与全局客户端一致，不设置整体超时，由 ctx 控制流式请求生命周期
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,47 @@
+package eino_llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatewayHTTPClient(t *testing.T) {
+	client, err := gatewayHTTPClient(map[string]interface{}{"type": "openai"})
+	require.NoError(t, err)
+	assert.Nil(t, client, "未配置网关字段时使用默认客户端")
+
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client, err = gatewayHTTPClient(map[string]interface{}{
+		"extra_headers": map[string]interface{}{"x-gateway-key": "secret"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, client)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "secret", got.Get("X-Gateway-Key"))
+	assert.Empty(t, req.Header.Get("X-Gateway-Key"), "不应修改调用方的请求")
+
+	client, err = gatewayHTTPClient(map[string]interface{}{"proxy_url": "http://127.0.0.1:3128"})
+	require.NoError(t, err)
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	proxy, err := transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:3128", proxy.Host)
+
+	_, err = gatewayHTTPClient(map[string]interface{}{"proxy_url": "ftp://proxy"})
+	assert.Error(t, err)
+	_, err = gatewayHTTPClient(map[string]interface{}{"extra_headers": map[string]interface{}{"X-A": "a\nb"}})
+	assert.Error(t, err)
+}
@@ -401,14 +401,20 @@ func ConvertMcpToolListToInvokableToolList(tools []mcp.Tool, serverName string,
 			continue
 		}
 
+		var rawInputSchema map[string]interface{}
+		if err := sonic.Unmarshal(marshaledInputSchema, &rawInputSchema); err != nil {
+			log.Warnf("解析工具 %s 的 inputSchema 失败，跳过参数校验: %v", tool.Name, err)
+		}
+
 		mcpToolInstance := &McpTool{
 			info: &schema.ToolInfo{
 				Name:        tool.Name,
 				Desc:        tool.Description,
 				ParamsOneOf: schema.NewParamsOneOfByOpenAPIV3(inputSchema),
 			},
-			serverName: serverName,
-			client:     client,
+			serverName:  serverName,
+			client:      client,
+			inputSchema: rawInputSchema,
 		}
 		invokeTools[tool.Name] = mcpToolInstance
 	}
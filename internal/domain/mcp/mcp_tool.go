@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	log "xiaozhi-esp32-server-golang/logger"
+	"xiaozhi/manager/backend/services/mcp_schema"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
@@ -17,9 +18,10 @@ type LocalToolHandler func(ctx context.Context, argumentsInJSON string) (string,
 
 // mcpTool MCP工具实现，支持远程和本地工具
 type McpTool struct {
-	info       *schema.ToolInfo
-	serverName string
-	client     *client.Client
+	info        *schema.ToolInfo
+	serverName  string
+	client      *client.Client
+	inputSchema map[string]interface{} // 远程工具声明的原始 inputSchema，调用前用于参数校验
 
 	// 本地工具支持
 	isLocal      bool
@@ -75,6 +77,10 @@ func (t *McpTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts
 			return retContent, fmt.Errorf("解析工具参数失败: %v", err)
 		}
 	}
+	if err := mcp_schema.ValidateArguments(t.inputSchema, arguments); err != nil {
+		log.Warnf("工具 %s 参数校验失败: %v, 参数: %s", t.info.Name, err, argumentsInJSON)
+		return retContent, fmt.Errorf("参数校验失败: %v", err)
+	}
 
 	// 准备调用请求
 	callRequest := mcp.CallToolRequest{
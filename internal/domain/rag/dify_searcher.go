@@ -212,9 +212,10 @@ func (s *difySearcher) searchOneDataset(
 			continue
 		}
 		ret = append(ret, config_types.KnowledgeSearchHit{
-			Content: content,
-			Title:   title,
-			Score:   record.Score,
+			Content:         content,
+			Title:           title,
+			Score:           record.Score,
+			KnowledgeBaseID: kb.ID,
 		})
 	}
 	return ret, nil
@@ -43,12 +43,12 @@ func Search(
 		return []config_types.KnowledgeSearchHit{}, nil
 	}
 
-	selectedKBSet := make(map[uint]struct{}, len(knowledgeBaseIDs))
-	for _, kbID := range knowledgeBaseIDs {
-		if kbID == 0 {
-			continue
+	selectedKBSet, denied := authorizeKnowledgeBaseIDs(knowledgeBases, knowledgeBaseIDs)
+	if len(denied) > 0 {
+		log.Warnf("知识库检索请求了未授权给当前智能体的知识库: %v", denied)
+		if len(selectedKBSet) == 0 {
+			return nil, fmt.Errorf("知识库 %v 未授权给当前智能体", denied)
 		}
-		selectedKBSet[kbID] = struct{}{}
 	}
 
 	defaultProvider := strings.TrimSpace(viper.GetString("knowledge.default_provider"))
@@ -107,6 +107,7 @@ func Search(
 			continue
 		}
 		successProviderCount++
+		recordKnowledgeQueries(q, providerKBs, providerHits)
 		hits = append(hits, providerHits...)
 	}
 
@@ -130,6 +131,28 @@ func Search(
 	return hits, nil
 }
 
+// authorizeKnowledgeBaseIDs 将指定的知识库 ID 限定在已授权（下发给当前智能体）的知识库内，
+// 返回允许检索的 ID 集合与被拒绝的 ID；未指定时集合为空，表示检索全部已授权知识库
+func authorizeKnowledgeBaseIDs(knowledgeBases []config_types.KnowledgeBaseRef, knowledgeBaseIDs []uint) (map[uint]struct{}, []uint) {
+	authorized := make(map[uint]struct{}, len(knowledgeBases))
+	for _, kb := range knowledgeBases {
+		authorized[kb.ID] = struct{}{}
+	}
+	selected := make(map[uint]struct{}, len(knowledgeBaseIDs))
+	denied := make([]uint, 0)
+	for _, kbID := range knowledgeBaseIDs {
+		if kbID == 0 {
+			continue
+		}
+		if _, ok := authorized[kbID]; !ok {
+			denied = append(denied, kbID)
+			continue
+		}
+		selected[kbID] = struct{}{}
+	}
+	return selected, denied
+}
+
 func getSearcher(provider string) Searcher {
 	switch strings.ToLower(strings.TrimSpace(provider)) {
 	case "dify":
@@ -0,0 +1,33 @@
+package rag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	config_types "xiaozhi-esp32-server-golang/internal/domain/config/types"
+)
+
+func TestAuthorizeKnowledgeBaseIDs(t *testing.T) {
+	kbs := []config_types.KnowledgeBaseRef{{ID: 1}, {ID: 2}}
+
+	selected, denied := authorizeKnowledgeBaseIDs(kbs, []uint{2, 3, 0})
+	if _, ok := selected[2]; !ok || len(selected) != 1 {
+		t.Fatalf("selected = %v, want {2}", selected)
+	}
+	if !reflect.DeepEqual(denied, []uint{3}) {
+		t.Fatalf("denied = %v, want [3]", denied)
+	}
+
+	selected, denied = authorizeKnowledgeBaseIDs(kbs, nil)
+	if len(selected) != 0 || len(denied) != 0 {
+		t.Fatalf("no ids requested: selected = %v, denied = %v", selected, denied)
+	}
+}
+
+func TestSearchRejectsUnauthorizedKnowledgeBases(t *testing.T) {
+	kbs := []config_types.KnowledgeBaseRef{{ID: 1, ExternalKBID: "ds-1"}}
+	if _, err := Search(context.Background(), "退货流程", 5, kbs, []uint{9}); err == nil {
+		t.Fatal("search limited to unauthorized knowledge bases should fail")
+	}
+}
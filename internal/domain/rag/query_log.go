@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"sync"
+	"time"
+
+	config_types "xiaozhi-esp32-server-golang/internal/domain/config/types"
+)
+
+// defaultQueryLogSize 每个知识库保留的最近检索条数
+const defaultQueryLogSize = 20
+
+// QueryLogEntry 知识库的一次检索记录
+type QueryLogEntry struct {
+	Query     string    `json:"query"`
+	Timestamp time.Time `json:"timestamp"`
+	HitCount  int       `json:"hit_count"` // 该知识库返回的命中数（聚合截断前）
+}
+
+// queryLog 按知识库保存最近 N 次检索，仅驻留内存，进程重启后清空
+type queryLog struct {
+	mu      sync.Mutex
+	size    int
+	entries map[uint][]QueryLogEntry
+}
+
+var knowledgeQueryLog = newQueryLog(defaultQueryLogSize)
+
+func newQueryLog(size int) *queryLog {
+	if size <= 0 {
+		size = defaultQueryLogSize
+	}
+	return &queryLog{size: size, entries: make(map[uint][]QueryLogEntry)}
+}
+
+func (l *queryLog) record(kbID uint, entry QueryLogEntry) {
+	if kbID == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := append(l.entries[kbID], entry)
+	if len(list) > l.size {
+		list = append([]QueryLogEntry(nil), list[len(list)-l.size:]...)
+	}
+	l.entries[kbID] = list
+}
+
+// get 返回副本，按时间倒序（最新在前）
+func (l *queryLog) get(kbID uint) []QueryLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := l.entries[kbID]
+	ret := make([]QueryLogEntry, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		ret = append(ret, list[i])
+	}
+	return ret
+}
+
+// recordKnowledgeQueries 为本次检索涉及的每个知识库记录一条日志，未命中的知识库记为 0
+func recordKnowledgeQueries(query string, knowledgeBases []config_types.KnowledgeBaseRef, hits []config_types.KnowledgeSearchHit) {
+	counts := make(map[uint]int, len(knowledgeBases))
+	for _, hit := range hits {
+		counts[hit.KnowledgeBaseID]++
+	}
+	now := time.Now()
+	for _, kb := range knowledgeBases {
+		knowledgeQueryLog.record(kb.ID, QueryLogEntry{Query: query, Timestamp: now, HitCount: counts[kb.ID]})
+	}
+}
+
+// GetKnowledgeBaseQueryLog 返回指定知识库最近的检索记录，最新在前
+func GetKnowledgeBaseQueryLog(kbID uint) []QueryLogEntry {
+	return knowledgeQueryLog.get(kbID)
+}
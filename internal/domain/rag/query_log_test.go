@@ -0,0 +1,42 @@
+package rag
+
+import (
+	"testing"
+	"time"
+
+	config_types "xiaozhi-esp32-server-golang/internal/domain/config/types"
+)
+
+func TestQueryLogKeepsLatestEntries(t *testing.T) {
+	l := newQueryLog(3)
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		l.record(7, QueryLogEntry{Query: string(rune('a' + i)), Timestamp: base.Add(time.Duration(i) * time.Second), HitCount: i})
+	}
+	got := l.get(7)
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3", len(got))
+	}
+	if got[0].Query != "e" || got[2].Query != "c" {
+		t.Fatalf("entries = %+v, want newest first e..c", got)
+	}
+	if len(l.get(8)) != 0 {
+		t.Fatal("unknown knowledge base should have empty log")
+	}
+}
+
+func TestRecordKnowledgeQueriesCountsHitsPerKB(t *testing.T) {
+	kbs := []config_types.KnowledgeBaseRef{{ID: 901}, {ID: 902}}
+	hits := []config_types.KnowledgeSearchHit{
+		{Content: "x", KnowledgeBaseID: 901},
+		{Content: "y", KnowledgeBaseID: 901},
+	}
+	recordKnowledgeQueries("保修期多久", kbs, hits)
+
+	if log := GetKnowledgeBaseQueryLog(901); len(log) != 1 || log[0].HitCount != 2 || log[0].Query != "保修期多久" {
+		t.Fatalf("kb 901 log = %+v", log)
+	}
+	if log := GetKnowledgeBaseQueryLog(902); len(log) != 1 || log[0].HitCount != 0 {
+		t.Fatalf("kb 902 log = %+v", log)
+	}
+}
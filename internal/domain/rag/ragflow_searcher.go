@@ -234,9 +234,10 @@ func (s *ragflowSearcher) searchOneDataset(
 			score = chunk.VectorSimilarity
 		}
 		ret = append(ret, config_types.KnowledgeSearchHit{
-			Content: content,
-			Title:   chunkTitle,
-			Score:   score,
+			Content:         content,
+			Title:           chunkTitle,
+			Score:           score,
+			KnowledgeBaseID: kb.ID,
 		})
 	}
 	return ret, nil
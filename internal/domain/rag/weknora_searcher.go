@@ -223,9 +223,10 @@ func (s *weknoraSearcher) searchOneKnowledgeBase(
 			chunkTitle = title
 		}
 		ret = append(ret, config_types.KnowledgeSearchHit{
-			Content: content,
-			Title:   chunkTitle,
-			Score:   score,
+			Content:         content,
+			Title:           chunkTitle,
+			Score:           score,
+			KnowledgeBaseID: kb.ID,
 		})
 	}
 	return ret, nil
@@ -0,0 +1,131 @@
+package inter
+
+import (
+	"fmt"
+	"math"
+)
+
+// LabeledSegment 人工标注的语音区间（毫秒，左闭右开）
+type LabeledSegment struct {
+	StartMs int `json:"start_ms"`
+	EndMs   int `json:"end_ms"`
+}
+
+// ConfusionMatrix 逐帧比较 VAD 判决与标注得到的混淆矩阵，语音为正类
+type ConfusionMatrix struct {
+	TP int `json:"tp"`
+	FP int `json:"fp"`
+	TN int `json:"tn"`
+	FN int `json:"fn"`
+}
+
+// Precision 判为语音的帧中真实为语音的比例
+func (m ConfusionMatrix) Precision() float64 {
+	if m.TP+m.FP == 0 {
+		return 0
+	}
+	return float64(m.TP) / float64(m.TP+m.FP)
+}
+
+// Recall 真实语音帧中被判为语音的比例
+func (m ConfusionMatrix) Recall() float64 {
+	if m.TP+m.FN == 0 {
+		return 0
+	}
+	return float64(m.TP) / float64(m.TP+m.FN)
+}
+
+// F1 精确率与召回率的调和平均
+func (m ConfusionMatrix) F1() float64 {
+	if 2*m.TP+m.FP+m.FN == 0 {
+		return 0
+	}
+	return 2 * float64(m.TP) / float64(2*m.TP+m.FP+m.FN)
+}
+
+// CalibrationPoint 单个阈值下的评估结果
+type CalibrationPoint struct {
+	Threshold float64         `json:"threshold"`
+	Matrix    ConfusionMatrix `json:"confusion_matrix"`
+	Precision float64         `json:"precision"`
+	Recall    float64         `json:"recall"`
+	F1        float64         `json:"f1"`
+}
+
+// LabelFrames 将标注区间转换为逐帧标签，帧中点落在任一区间内即视为语音帧
+func LabelFrames(segments []LabeledSegment, frames, frameMs int) []bool {
+	labels := make([]bool, frames)
+	for i := range labels {
+		mid := i*frameMs + frameMs/2
+		for _, seg := range segments {
+			if mid >= seg.StartMs && mid < seg.EndMs {
+				labels[i] = true
+				break
+			}
+		}
+	}
+	return labels
+}
+
+// EvaluateFrames 逐帧比较判决与标签，长度不一致时按较短者计算
+func EvaluateFrames(predicted, labels []bool) ConfusionMatrix {
+	var m ConfusionMatrix
+	n := len(predicted)
+	if len(labels) < n {
+		n = len(labels)
+	}
+	for i := 0; i < n; i++ {
+		switch {
+		case predicted[i] && labels[i]:
+			m.TP++
+		case predicted[i]:
+			m.FP++
+		case labels[i]:
+			m.FN++
+		default:
+			m.TN++
+		}
+	}
+	return m
+}
+
+// ThresholdRange 生成 [min, max] 内步长为 step 的阈值序列（保留4位小数，避免浮点累加误差）
+func ThresholdRange(min, max, step float64) ([]float64, error) {
+	if step <= 0 || min > max || min < 0 || max > 1 {
+		return nil, fmt.Errorf("阈值范围无效: min=%v max=%v step=%v", min, max, step)
+	}
+	n := int(math.Floor((max-min)/step+1e-9)) + 1
+	if n > 200 {
+		return nil, fmt.Errorf("阈值数量过多(%d)，请增大步长", n)
+	}
+	thresholds := make([]float64, n)
+	for i := range thresholds {
+		thresholds[i] = math.Round((min+float64(i)*step)*1e4) / 1e4
+	}
+	return thresholds, nil
+}
+
+// SweepThresholds 依次以各阈值运行 detect 得到逐帧判决，与标签比较后返回全部评估结果及 F1 最高者的下标
+// F1 相同时取较小阈值（更不易漏检）
+func SweepThresholds(thresholds []float64, labels []bool, detect func(threshold float64) ([]bool, error)) ([]CalibrationPoint, int, error) {
+	points := make([]CalibrationPoint, 0, len(thresholds))
+	best := -1
+	for _, threshold := range thresholds {
+		predicted, err := detect(threshold)
+		if err != nil {
+			return nil, -1, fmt.Errorf("阈值 %v 检测失败: %w", threshold, err)
+		}
+		m := EvaluateFrames(predicted, labels)
+		points = append(points, CalibrationPoint{
+			Threshold: threshold,
+			Matrix:    m,
+			Precision: m.Precision(),
+			Recall:    m.Recall(),
+			F1:        m.F1(),
+		})
+		if best < 0 || points[len(points)-1].F1 > points[best].F1 {
+			best = len(points) - 1
+		}
+	}
+	return points, best, nil
+}
@@ -0,0 +1,52 @@
+package inter
+
+import "testing"
+
+func TestLabelFrames(t *testing.T) {
+	labels := LabelFrames([]LabeledSegment{{StartMs: 20, EndMs: 50}}, 6, 10)
+	want := []bool{false, false, true, true, true, false}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Fatalf("labels = %v, want %v", labels, want)
+		}
+	}
+}
+
+func TestSweepThresholds(t *testing.T) {
+	// 模拟语音概率：标注语音帧概率 0.6~0.9，静音帧中有一帧噪声概率 0.4
+	probs := []float64{0.1, 0.4, 0.6, 0.9, 0.8, 0.2}
+	labels := []bool{false, false, true, true, true, false}
+
+	thresholds, err := ThresholdRange(0.3, 0.7, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thresholds) != 5 || thresholds[4] != 0.7 {
+		t.Fatalf("thresholds = %v", thresholds)
+	}
+
+	points, best, err := SweepThresholds(thresholds, labels, func(threshold float64) ([]bool, error) {
+		out := make([]bool, len(probs))
+		for i, p := range probs {
+			out[i] = p >= threshold
+		}
+		return out, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := points[best].Threshold; got != 0.5 {
+		t.Fatalf("best threshold = %v, want 0.5 (points %+v)", got, points)
+	}
+	if m := points[best].Matrix; m != (ConfusionMatrix{TP: 3, TN: 3}) || points[best].F1 != 1 {
+		t.Fatalf("best matrix = %+v f1 = %v", m, points[best].F1)
+	}
+	// 阈值 0.3 时噪声帧误报
+	if m := points[0].Matrix; m.FP != 1 || m.TP != 3 {
+		t.Fatalf("threshold 0.3 matrix = %+v", m)
+	}
+
+	if _, err := ThresholdRange(0.5, 0.4, 0.1); err == nil {
+		t.Fatal("expected error for min > max")
+	}
+}
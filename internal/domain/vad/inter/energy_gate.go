@@ -0,0 +1,60 @@
+package inter
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// EnergyGate 能量预过滤：帧能量远低于底限时直接判为非语音，跳过模型推理以节省 CPU
+// 长时间静音的常开监听场景下大部分帧可被跳过；底限应明显低于正常语音能量，避免吞掉轻声
+type EnergyGate struct {
+	FloorDb float64 // 能量底限（dBFS），如 -60
+	total   atomic.Uint64
+	skipped atomic.Uint64
+}
+
+// NewEnergyGate 创建能量预过滤器
+func NewEnergyGate(floorDb float64) *EnergyGate {
+	return &EnergyGate{FloorDb: floorDb}
+}
+
+// Silent 判断一帧 int16 PCM 的 RMS 能量是否低于底限，并计入统计
+func (g *EnergyGate) Silent(frame []int16) bool {
+	g.total.Add(1)
+	if FrameDbfs(frame) < g.FloorDb {
+		g.skipped.Add(1)
+		return true
+	}
+	return false
+}
+
+// Stats 返回已检查帧数与跳过推理的帧数
+func (g *EnergyGate) Stats() (total, skipped uint64) {
+	return g.total.Load(), g.skipped.Load()
+}
+
+// SkipRatio 跳过推理的帧占比
+func (g *EnergyGate) SkipRatio() float64 {
+	total, skipped := g.Stats()
+	if total == 0 {
+		return 0
+	}
+	return float64(skipped) / float64(total)
+}
+
+// FrameDbfs 计算 int16 PCM 帧的 RMS 能量（dBFS），全零帧返回 -Inf
+func FrameDbfs(frame []int16) float64 {
+	if len(frame) == 0 {
+		return math.Inf(-1)
+	}
+	var sum float64
+	for _, s := range frame {
+		v := float64(s) / 32768
+		sum += v * v
+	}
+	rms := math.Sqrt(sum / float64(len(frame)))
+	if rms == 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(rms)
+}
@@ -0,0 +1,39 @@
+package inter
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEnergyGate(t *testing.T) {
+	g := NewEnergyGate(-60)
+
+	silence := make([]int16, 512)
+	if !g.Silent(silence) {
+		t.Fatal("all-zero frame should be skipped")
+	}
+
+	hiss := make([]int16, 512)
+	for i := range hiss {
+		hiss[i] = int16(3 * (i%2*2 - 1)) // 约 -80 dBFS
+	}
+	if !g.Silent(hiss) {
+		t.Fatalf("low-level noise at %.1f dBFS should be skipped", FrameDbfs(hiss))
+	}
+
+	speech := make([]int16, 512)
+	for i := range speech {
+		speech[i] = int16(3000 * math.Sin(2*math.Pi*float64(i)/32))
+	}
+	if g.Silent(speech) {
+		t.Fatalf("speech-level frame at %.1f dBFS should not be skipped", FrameDbfs(speech))
+	}
+
+	total, skipped := g.Stats()
+	if total != 3 || skipped != 2 {
+		t.Fatalf("stats = %d/%d, want 2/3", skipped, total)
+	}
+	if r := g.SkipRatio(); math.Abs(r-2.0/3) > 1e-9 {
+		t.Fatalf("skip ratio = %f", r)
+	}
+}
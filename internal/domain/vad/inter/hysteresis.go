@@ -0,0 +1,39 @@
+package inter
+
+// Hysteresis 双阈值（迟滞）语音判决：概率高于进入阈值才进入语音状态，
+// 低于退出阈值才退出，两阈值之间保持原状态，避免边界附近反复跳变
+type Hysteresis struct {
+	EnterThreshold float32
+	ExitThreshold  float32
+	active         bool
+}
+
+// NewHysteresis 创建双阈值判决器；exit 不小于 enter 时退化为单阈值
+func NewHysteresis(enter, exit float32) *Hysteresis {
+	if exit > enter {
+		exit = enter
+	}
+	return &Hysteresis{EnterThreshold: enter, ExitThreshold: exit}
+}
+
+// Update 输入一帧语音概率，返回该帧判决结果
+func (h *Hysteresis) Update(prob float32) bool {
+	if h.active {
+		if prob < h.ExitThreshold {
+			h.active = false
+		}
+	} else if prob >= h.EnterThreshold {
+		h.active = true
+	}
+	return h.active
+}
+
+// Active 当前是否处于语音状态
+func (h *Hysteresis) Active() bool {
+	return h.active
+}
+
+// Reset 恢复为静音状态
+func (h *Hysteresis) Reset() {
+	h.active = false
+}
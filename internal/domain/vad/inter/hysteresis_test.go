@@ -0,0 +1,59 @@
+package inter
+
+import "testing"
+
+func countTransitions(flags []bool) int {
+	n := 0
+	for i := 1; i < len(flags); i++ {
+		if flags[i] != flags[i-1] {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHysteresisReducesFlicker(t *testing.T) {
+	// 静音 → 在 0.5 附近抖动的边界语音 → 静音
+	probs := []float32{0.1, 0.2, 0.7, 0.48, 0.53, 0.46, 0.55, 0.49, 0.52, 0.45, 0.6, 0.2, 0.1}
+
+	single := make([]bool, len(probs))
+	for i, p := range probs {
+		single[i] = p >= 0.5
+	}
+	h := NewHysteresis(0.5, 0.35)
+	dual := make([]bool, len(probs))
+	for i, p := range probs {
+		dual[i] = h.Update(p)
+	}
+
+	if got := countTransitions(dual); got != 2 {
+		t.Fatalf("hysteresis transitions = %d, want 2 (%v)", got, dual)
+	}
+	if countTransitions(single) <= countTransitions(dual) {
+		t.Fatalf("single threshold transitions = %d, expected more than hysteresis", countTransitions(single))
+	}
+}
+
+func TestHysteresisEnterExit(t *testing.T) {
+	h := NewHysteresis(0.6, 0.3)
+	if h.Update(0.5) {
+		t.Fatal("should not enter below enter threshold")
+	}
+	if !h.Update(0.6) || !h.Update(0.31) {
+		t.Fatal("should enter at 0.6 and stay above exit threshold")
+	}
+	if h.Update(0.29) {
+		t.Fatal("should exit below exit threshold")
+	}
+
+	// exit 大于 enter 时退化为单阈值
+	h = NewHysteresis(0.5, 0.8)
+	if !h.Update(0.6) || h.Update(0.4) {
+		t.Fatal("degenerate hysteresis should behave as single threshold")
+	}
+	h.Update(0.9)
+	h.Reset()
+	if h.Active() {
+		t.Fatal("Reset should clear state")
+	}
+}
@@ -0,0 +1,42 @@
+package inter
+
+import (
+	"sync/atomic"
+
+	"xiaozhi-esp32-server-golang/internal/domain/audio"
+	log "xiaozhi-esp32-server-golang/logger"
+)
+
+// RateAdapter 输入采样率与模型采样率不一致时自动重采样到模型采样率，
+// 每个会话（Reset 之间）只在首次重采样时记录一次日志
+type RateAdapter struct {
+	Name      string
+	ModelRate int
+	logged    atomic.Bool
+}
+
+// NewRateAdapter 创建采样率适配器
+func NewRateAdapter(name string, modelRate int) *RateAdapter {
+	return &RateAdapter{Name: name, ModelRate: modelRate}
+}
+
+// NeedsResample 输入采样率是否需要重采样；sampleRate<=0 视为与模型一致
+func (r *RateAdapter) NeedsResample(sampleRate int) bool {
+	return sampleRate > 0 && r.ModelRate > 0 && sampleRate != r.ModelRate
+}
+
+// Adapt 返回模型采样率下的 PCM
+func (r *RateAdapter) Adapt(pcm []float32, sampleRate int) []float32 {
+	if !r.NeedsResample(sampleRate) {
+		return pcm
+	}
+	if r.logged.CompareAndSwap(false, true) {
+		log.Infof("%s 输入采样率 %dHz 与模型采样率 %dHz 不一致，自动重采样", r.Name, sampleRate, r.ModelRate)
+	}
+	return audio.Resample(pcm, sampleRate, r.ModelRate)
+}
+
+// Reset 开始新会话，重新允许记录一次重采样日志
+func (r *RateAdapter) Reset() {
+	r.logged.Store(false)
+}
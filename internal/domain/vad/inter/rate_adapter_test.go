@@ -0,0 +1,30 @@
+package inter
+
+import "testing"
+
+func TestRateAdapter(t *testing.T) {
+	r := NewRateAdapter("test", 16000)
+	pcm := make([]float32, 480)
+	if out := r.Adapt(pcm, 16000); len(out) != 480 {
+		t.Fatal("same rate should pass through")
+	}
+	if out := r.Adapt(pcm, 0); len(out) != 480 {
+		t.Fatal("unknown rate should pass through")
+	}
+	if r.logged.Load() {
+		t.Fatal("should not log without resampling")
+	}
+	if out := r.Adapt(pcm, 48000); len(out) != 160 {
+		t.Fatalf("48k->16k len = %d, want 160", len(out))
+	}
+	if out := r.Adapt(make([]float32, 80), 8000); len(out) != 160 {
+		t.Fatalf("8k->16k len = %d, want 160", len(out))
+	}
+	if !r.logged.Load() {
+		t.Fatal("first resample should be logged")
+	}
+	r.Reset()
+	if r.logged.Load() {
+		t.Fatal("Reset should allow logging again")
+	}
+}
@@ -0,0 +1,127 @@
+package inter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// readerChunkFrames 每次从 Reader 读取的帧数，决定 DetectSegmentsReader 的内存占用上限
+const readerChunkFrames = 32
+
+// PCMFormat 原始 PCM 流格式（小端、交错排列）
+type PCMFormat struct {
+	SampleRate int
+	Channels   int  // 声道数，<=0 视为单声道；多声道按样本平均下混后检测
+	Float32    bool // true 为 32 位浮点，否则为 16 位有符号整数
+}
+
+func (f PCMFormat) bytesPerSample() int {
+	if f.Float32 {
+		return 4
+	}
+	return 2
+}
+
+// SpeechSegment 检测出的语音区间（毫秒，左闭右开）
+type SpeechSegment struct {
+	StartMs int `json:"start_ms"`
+	EndMs   int `json:"end_ms"`
+}
+
+// DetectSegmentsReader 从 r 增量读取 PCM 并逐帧检测，每确认一个语音段即回调 onSegment
+// 读取缓冲固定为 readerChunkFrames 帧，内存占用与音频总时长无关；cfg.SampleRate 为 0 时取 format.SampleRate
+// 末尾不足一帧的样本补零凑满一帧参与检测，语音段结束时间截断到音频实际时长；末尾不完整的样本字节被忽略
+func DetectSegmentsReader(r io.Reader, vad VAD, format PCMFormat, cfg StreamConfig, onSegment func(SpeechSegment)) error {
+	if r == nil {
+		return fmt.Errorf("reader 不能为空")
+	}
+	if format.SampleRate <= 0 {
+		return fmt.Errorf("无效的采样率: %d", format.SampleRate)
+	}
+	if format.Channels <= 0 {
+		format.Channels = 1
+	}
+	if cfg.SampleRate == 0 {
+		cfg.SampleRate = format.SampleRate
+	}
+	if cfg.SampleRate != format.SampleRate {
+		return fmt.Errorf("检测采样率 %d 与音频采样率 %d 不一致", cfg.SampleRate, format.SampleRate)
+	}
+
+	totalSamples := 0
+	segmentStart := 0
+	emit := func(seg SpeechSegment) {
+		totalMs := totalSamples * 1000 / format.SampleRate
+		if seg.EndMs > totalMs {
+			seg.EndMs = totalMs
+		}
+		if seg.EndMs > seg.StartMs && onSegment != nil {
+			onSegment(seg)
+		}
+	}
+	detector, err := NewStreamDetector(vad, cfg, func(e StreamEvent) {
+		switch e.Type {
+		case StreamSpeechStart:
+			segmentStart = e.AtMs
+		case StreamSpeechEnd:
+			emit(SpeechSegment{StartMs: segmentStart, EndMs: e.AtMs})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	frameBytes := format.bytesPerSample() * format.Channels
+	buf := make([]byte, cfg.FrameSize*readerChunkFrames*frameBytes)
+	pcm := make([]float32, 0, cfg.FrameSize*readerChunkFrames)
+	carry := 0 // 上次读取末尾不足一个采样帧的字节数，已移到 buf 开头
+	for {
+		n, readErr := r.Read(buf[carry:])
+		n += carry
+		usable := n - n%frameBytes
+		if usable > 0 {
+			pcm = decodeInterleavedPCM(pcm[:0], buf[:usable], format)
+			totalSamples += len(pcm)
+			if err := detector.Write(pcm); err != nil {
+				return err
+			}
+		}
+		carry = copy(buf, buf[usable:n])
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				return fmt.Errorf("读取PCM失败: %w", readErr)
+			}
+			break
+		}
+	}
+
+	if rem := totalSamples % cfg.FrameSize; rem != 0 {
+		if err := detector.Write(make([]float32, cfg.FrameSize-rem)); err != nil {
+			return err
+		}
+	}
+	detector.Flush()
+	return nil
+}
+
+// decodeInterleavedPCM 将交错 PCM 字节解码为 [-1,1] 的单声道样本并追加到 dst
+func decodeInterleavedPCM(dst []float32, data []byte, format PCMFormat) []float32 {
+	sampleBytes := format.bytesPerSample()
+	frameBytes := sampleBytes * format.Channels
+	for off := 0; off+frameBytes <= len(data); off += frameBytes {
+		var sum float32
+		for ch := 0; ch < format.Channels; ch++ {
+			b := data[off+ch*sampleBytes:]
+			if format.Float32 {
+				sum += math.Float32frombits(binary.LittleEndian.Uint32(b))
+			} else {
+				sum += float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+			}
+		}
+		dst = append(dst, sum/float32(format.Channels))
+	}
+	return dst
+}
@@ -0,0 +1,64 @@
+package inter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"testing/iotest"
+)
+
+// pcm16Bytes 按帧模式生成 16 位 PCM：语音帧取接近满幅，静音帧为 0
+func pcm16Bytes(pattern []bool, frameSize, channels, tailSamples int, tailVoice bool) []byte {
+	var buf bytes.Buffer
+	write := func(voice bool) {
+		v := int16(0)
+		if voice {
+			v = 30000
+		}
+		for ch := 0; ch < channels; ch++ {
+			_ = binary.Write(&buf, binary.LittleEndian, v)
+		}
+	}
+	for _, voice := range pattern {
+		for i := 0; i < frameSize; i++ {
+			write(voice)
+		}
+	}
+	for i := 0; i < tailSamples; i++ {
+		write(tailVoice)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectSegmentsReader(t *testing.T) {
+	cfg := StreamConfig{FrameSize: 160, StartLookaheadMs: 20, EndLookaheadMs: 30}
+	// 静音2帧，语音5帧，静音5帧，语音3帧，末尾再接半帧语音
+	pattern := []bool{false, false, true, true, true, true, true, false, false, false, false, false, true, true, true}
+	data := pcm16Bytes(pattern, 160, 2, 80, true)
+	// 追加一个不完整的样本字节，应被忽略
+	data = append(data, 0x01)
+
+	var got []SpeechSegment
+	err := DetectSegmentsReader(iotest.HalfReader(bytes.NewReader(data)), thresholdVAD{},
+		PCMFormat{SampleRate: 16000, Channels: 2}, cfg, func(s SpeechSegment) { got = append(got, s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SpeechSegment{{StartMs: 20, EndMs: 70}, {StartMs: 120, EndMs: 155}}
+	if len(got) != len(want) {
+		t.Fatalf("segments = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("segment %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDetectSegmentsReaderRejectsRateMismatch(t *testing.T) {
+	err := DetectSegmentsReader(bytes.NewReader(nil), thresholdVAD{}, PCMFormat{SampleRate: 8000},
+		StreamConfig{SampleRate: 16000, FrameSize: 160}, nil)
+	if err == nil {
+		t.Fatal("expected error for mismatched sample rate")
+	}
+}
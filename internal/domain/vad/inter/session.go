@@ -0,0 +1,19 @@
+package inter
+
+// SessionResetter 可选接口：实现方区分分块级重置与会话级重置。
+// Reset 只清除单次检测的中间结果，跨块保留的判决状态（双阈值、帧平滑等）
+// 仅在 ResetSession 时清除，应在会话/轮次边界调用
+type SessionResetter interface {
+	ResetSession() error
+}
+
+// ResetSession 在会话/轮次边界重置 VAD；未实现 SessionResetter 时退化为 Reset
+func ResetSession(v VAD) error {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.(SessionResetter); ok {
+		return s.ResetSession()
+	}
+	return v.Reset()
+}
@@ -0,0 +1,31 @@
+package inter
+
+import "testing"
+
+type sessionVAD struct {
+	countingVAD
+	sessionResets int
+}
+
+func (v *sessionVAD) ResetSession() error { v.sessionResets++; return nil }
+
+func TestResetSessionPrefersSessionResetter(t *testing.T) {
+	v := &sessionVAD{}
+	if err := ResetSession(v); err != nil {
+		t.Fatal(err)
+	}
+	if v.sessionResets != 1 || v.resets != 0 {
+		t.Fatalf("session resets = %d, resets = %d, want 1/0", v.sessionResets, v.resets)
+	}
+
+	plain := &countingVAD{}
+	if err := ResetSession(plain); err != nil {
+		t.Fatal(err)
+	}
+	if plain.resets != 1 {
+		t.Fatalf("fallback resets = %d, want 1", plain.resets)
+	}
+	if err := ResetSession(nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,62 @@
+package inter
+
+// Smoother K-of-N 帧平滑：最近 N 帧中至少 K 帧与当前状态相反时才翻转语音状态，
+// 抑制单帧误判造成的语音段断裂或误触发
+type Smoother struct {
+	Window    int
+	MinFrames int
+	history   []bool
+	pos       int
+	filled    int
+	voiced    int
+	active    bool
+}
+
+// NewSmoother 创建平滑器；minFrames 不合法时取多数（window/2+1）
+func NewSmoother(window, minFrames int) *Smoother {
+	if window < 1 {
+		window = 1
+	}
+	if minFrames < 1 || minFrames > window {
+		minFrames = window/2 + 1
+	}
+	return &Smoother{Window: window, MinFrames: minFrames, history: make([]bool, window)}
+}
+
+// Update 输入一帧原始判决，返回平滑后的判决
+func (s *Smoother) Update(voice bool) bool {
+	if s.filled == s.Window {
+		if s.history[s.pos] {
+			s.voiced--
+		}
+	} else {
+		s.filled++
+	}
+	s.history[s.pos] = voice
+	if voice {
+		s.voiced++
+	}
+	s.pos = (s.pos + 1) % s.Window
+
+	if s.active {
+		if s.filled-s.voiced >= s.MinFrames {
+			s.active = false
+		}
+	} else if s.voiced >= s.MinFrames {
+		s.active = true
+	}
+	return s.active
+}
+
+// Active 当前是否处于语音状态
+func (s *Smoother) Active() bool {
+	return s.active
+}
+
+// Reset 清空窗口并恢复为静音状态
+func (s *Smoother) Reset() {
+	for i := range s.history {
+		s.history[i] = false
+	}
+	s.pos, s.filled, s.voiced, s.active = 0, 0, 0, false
+}
@@ -0,0 +1,48 @@
+package inter
+
+import "testing"
+
+func TestSmootherSuppressesSingleFrameToggles(t *testing.T) {
+	// 静音中夹杂单帧误触发，语音中夹杂单帧丢失
+	raw := []bool{false, true, false, false, true, true, true, false, true, true, false, false, false}
+
+	s := NewSmoother(3, 2)
+	smoothed := make([]bool, len(raw))
+	for i, v := range raw {
+		smoothed[i] = s.Update(v)
+	}
+
+	want := []bool{false, false, false, false, false, true, true, true, true, true, true, false, false}
+	for i := range want {
+		if smoothed[i] != want[i] {
+			t.Fatalf("smoothed = %v, want %v", smoothed, want)
+		}
+	}
+	if got, rawGot := countTransitions(smoothed), countTransitions(raw); got != 2 || rawGot <= got {
+		t.Fatalf("transitions smoothed=%d raw=%d", got, rawGot)
+	}
+}
+
+func TestSmootherDefaultsAndReset(t *testing.T) {
+	s := NewSmoother(5, 0)
+	if s.MinFrames != 3 {
+		t.Fatalf("default min frames = %d, want 3", s.MinFrames)
+	}
+	if NewSmoother(4, 9).MinFrames != 3 {
+		t.Fatal("min frames above window should fall back to majority")
+	}
+
+	// 窗口为 1 时等价于不平滑
+	s = NewSmoother(1, 1)
+	if !s.Update(true) || s.Update(false) {
+		t.Fatal("window 1 should pass decisions through")
+	}
+
+	s = NewSmoother(3, 2)
+	s.Update(true)
+	s.Update(true)
+	s.Reset()
+	if s.Active() || s.Update(true) {
+		t.Fatal("Reset should clear window and state")
+	}
+}
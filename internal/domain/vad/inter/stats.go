@@ -0,0 +1,57 @@
+package inter
+
+// SpeechStats 一段语音（utterance）的语速/时长统计，基于逐帧 VAD 结果计算
+type SpeechStats struct {
+	TotalMs      int     `json:"total_ms"`       // 音频总时长
+	VoicedMs     int     `json:"voiced_ms"`      // 有声时长（各语音段时长之和）
+	Segments     int     `json:"segments"`       // 语音段数
+	AvgSegmentMs int     `json:"avg_segment_ms"` // 平均语音段长度
+	MaxSegmentMs int     `json:"max_segment_ms"` // 最长语音段长度
+	VoicedRatio  float64 `json:"voiced_ratio"`   // 有声时长占比
+	// SegmentsPerMinute 每分钟有声时长内的语音段数，作为语速的近似指标
+	SegmentsPerMinute float64 `json:"segments_per_minute"`
+}
+
+// ComputeSpeechStats 根据逐帧 VAD 结果计算语速统计
+// frames 为每帧是否为语音，frameMs 为单帧时长；
+// 短于 minSilenceMs 的静音间隔视为同一语音段内的停顿，不拆分语音段，其时长计入有声时长
+func ComputeSpeechStats(frames []bool, frameMs int, minSilenceMs int) SpeechStats {
+	stats := SpeechStats{TotalMs: len(frames) * frameMs}
+	if frameMs <= 0 || len(frames) == 0 {
+		return stats
+	}
+
+	minSilenceFrames := (minSilenceMs + frameMs - 1) / frameMs
+	segStart, lastSpeech := -1, -1
+	closeSegment := func() {
+		segMs := (lastSpeech - segStart + 1) * frameMs
+		stats.Segments++
+		stats.VoicedMs += segMs
+		if segMs > stats.MaxSegmentMs {
+			stats.MaxSegmentMs = segMs
+		}
+	}
+	for i, isSpeech := range frames {
+		if !isSpeech {
+			continue
+		}
+		if segStart >= 0 && i-lastSpeech-1 >= minSilenceFrames && i-lastSpeech-1 > 0 {
+			closeSegment()
+			segStart = -1
+		}
+		if segStart < 0 {
+			segStart = i
+		}
+		lastSpeech = i
+	}
+	if segStart >= 0 {
+		closeSegment()
+	}
+
+	if stats.Segments > 0 {
+		stats.AvgSegmentMs = stats.VoicedMs / stats.Segments
+		stats.SegmentsPerMinute = float64(stats.Segments) * 60000 / float64(stats.VoicedMs)
+	}
+	stats.VoicedRatio = float64(stats.VoicedMs) / float64(stats.TotalMs)
+	return stats
+}
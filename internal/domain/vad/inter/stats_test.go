@@ -0,0 +1,44 @@
+package inter
+
+import "testing"
+
+func TestComputeSpeechStats(t *testing.T) {
+	// 10ms/帧：静音2帧，语音3帧，静音1帧（短停顿），语音2帧，静音4帧，语音1帧
+	frames := []bool{
+		false, false,
+		true, true, true,
+		false,
+		true, true,
+		false, false, false, false,
+		true,
+	}
+	stats := ComputeSpeechStats(frames, 10, 20)
+	if stats.TotalMs != 130 {
+		t.Fatalf("TotalMs = %d, want 130", stats.TotalMs)
+	}
+	if stats.Segments != 2 {
+		t.Fatalf("Segments = %d, want 2", stats.Segments)
+	}
+	if stats.VoicedMs != 70 {
+		t.Fatalf("VoicedMs = %d, want 70", stats.VoicedMs)
+	}
+	if stats.MaxSegmentMs != 60 || stats.AvgSegmentMs != 35 {
+		t.Fatalf("MaxSegmentMs = %d, AvgSegmentMs = %d, want 60, 35", stats.MaxSegmentMs, stats.AvgSegmentMs)
+	}
+
+	// 不合并停顿时每段独立计数
+	stats = ComputeSpeechStats(frames, 10, 0)
+	if stats.Segments != 3 || stats.VoicedMs != 60 {
+		t.Fatalf("Segments = %d, VoicedMs = %d, want 3, 60", stats.Segments, stats.VoicedMs)
+	}
+}
+
+func TestComputeSpeechStatsSilence(t *testing.T) {
+	stats := ComputeSpeechStats([]bool{false, false}, 32, 200)
+	if stats.Segments != 0 || stats.VoicedMs != 0 || stats.VoicedRatio != 0 || stats.SegmentsPerMinute != 0 {
+		t.Fatalf("unexpected stats for silence: %+v", stats)
+	}
+	if stats.TotalMs != 64 {
+		t.Fatalf("TotalMs = %d, want 64", stats.TotalMs)
+	}
+}
@@ -0,0 +1,163 @@
+package inter
+
+import "fmt"
+
+// StreamEventType 流式检测事件类型
+type StreamEventType string
+
+const (
+	StreamSpeechStart StreamEventType = "speech_start"
+	StreamSpeechEnd   StreamEventType = "speech_end"
+)
+
+// StreamEvent 流式检测事件，时间均相对于首次写入的音频起点
+type StreamEvent struct {
+	Type StreamEventType
+	// AtMs 对齐到帧边界的事件时间：开始为首个语音帧起点，结束为最后一个语音帧终点
+	AtMs int
+	// DetectedAtMs 事件被确认时已处理的音频时长，DetectedAtMs-AtMs 即检测延迟
+	DetectedAtMs int
+}
+
+// StreamConfig 流式检测参数
+type StreamConfig struct {
+	SampleRate int
+	FrameSize  int // 每帧样本数，传给 IsVADExt
+	// StartLookaheadMs 连续语音达到该时长才确认开始，同时也是开始事件的最大延迟
+	StartLookaheadMs int
+	// EndLookaheadMs 连续静音达到该时长才确认结束，同时也是结束事件的最大延迟
+	EndLookaheadMs int
+}
+
+// StreamDetector 逐帧处理到达的音频并回调语音开始/结束事件，适用于低延迟打断检测
+// 非并发安全，同一实例需在单个 goroutine 中使用
+type StreamDetector struct {
+	vad     VAD
+	cfg     StreamConfig
+	onEvent func(StreamEvent)
+
+	frameMs     int
+	startFrames int
+	endFrames   int
+
+	pending   []float32
+	frameIdx  int
+	inSpeech  bool
+	runStart  int // 当前连续语音的首帧
+	runLength int // 当前连续语音/静音的帧数（语音中计静音，静音中计语音）
+	lastVoice int // 语音中最后一个语音帧
+}
+
+// NewStreamDetector 创建流式检测器，onEvent 在 Write/Flush 的调用 goroutine 中同步回调
+func NewStreamDetector(vad VAD, cfg StreamConfig, onEvent func(StreamEvent)) (*StreamDetector, error) {
+	if vad == nil {
+		return nil, fmt.Errorf("vad 不能为空")
+	}
+	if cfg.SampleRate <= 0 || cfg.FrameSize <= 0 {
+		return nil, fmt.Errorf("无效的采样率或帧长: sample_rate=%d frame_size=%d", cfg.SampleRate, cfg.FrameSize)
+	}
+	frameMs := cfg.FrameSize * 1000 / cfg.SampleRate
+	if frameMs <= 0 {
+		return nil, fmt.Errorf("帧长过短: frame_size=%d", cfg.FrameSize)
+	}
+	return &StreamDetector{
+		vad:         vad,
+		cfg:         cfg,
+		onEvent:     onEvent,
+		frameMs:     frameMs,
+		startFrames: lookaheadFrames(cfg.StartLookaheadMs, frameMs),
+		endFrames:   lookaheadFrames(cfg.EndLookaheadMs, frameMs),
+	}, nil
+}
+
+func lookaheadFrames(ms, frameMs int) int {
+	n := (ms + frameMs - 1) / frameMs
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Write 写入任意长度的音频，凑满一帧即检测；不足一帧的尾部缓存到下次写入
+func (d *StreamDetector) Write(pcm []float32) error {
+	d.pending = append(d.pending, pcm...)
+	for len(d.pending) >= d.cfg.FrameSize {
+		isVoice, err := d.vad.IsVADExt(d.pending[:d.cfg.FrameSize], d.cfg.SampleRate, d.cfg.FrameSize)
+		if err != nil {
+			return err
+		}
+		d.pending = d.pending[d.cfg.FrameSize:]
+		d.step(isVoice)
+	}
+	if len(d.pending) == 0 {
+		d.pending = nil
+	}
+	return nil
+}
+
+func (d *StreamDetector) step(isVoice bool) {
+	idx := d.frameIdx
+	d.frameIdx++
+	detectedAt := d.frameIdx * d.frameMs
+
+	if !d.inSpeech {
+		if !isVoice {
+			d.runLength = 0
+			return
+		}
+		if d.runLength == 0 {
+			d.runStart = idx
+		}
+		d.runLength++
+		if d.runLength >= d.startFrames {
+			d.inSpeech = true
+			d.runLength = 0
+			d.lastVoice = idx
+			d.emit(StreamEvent{Type: StreamSpeechStart, AtMs: d.runStart * d.frameMs, DetectedAtMs: detectedAt})
+		}
+		return
+	}
+
+	if isVoice {
+		d.runLength = 0
+		d.lastVoice = idx
+		return
+	}
+	d.runLength++
+	if d.runLength >= d.endFrames {
+		d.inSpeech = false
+		d.runLength = 0
+		d.emit(StreamEvent{Type: StreamSpeechEnd, AtMs: (d.lastVoice + 1) * d.frameMs, DetectedAtMs: detectedAt})
+	}
+}
+
+func (d *StreamDetector) emit(event StreamEvent) {
+	if d.onEvent != nil {
+		d.onEvent(event)
+	}
+}
+
+// Flush 音频流结束时调用：仍处于语音中则立即补发结束事件
+func (d *StreamDetector) Flush() {
+	if d.inSpeech {
+		d.inSpeech = false
+		d.runLength = 0
+		d.emit(StreamEvent{Type: StreamSpeechEnd, AtMs: (d.lastVoice + 1) * d.frameMs, DetectedAtMs: d.frameIdx * d.frameMs})
+	}
+}
+
+// InSpeech 当前是否处于已确认的语音段中
+func (d *StreamDetector) InSpeech() bool {
+	return d.inSpeech
+}
+
+// Reset 清空缓存与状态并重置底层 VAD，时间轴从 0 重新开始
+func (d *StreamDetector) Reset() error {
+	d.pending = nil
+	d.frameIdx = 0
+	d.inSpeech = false
+	d.runLength = 0
+	d.runStart = 0
+	d.lastVoice = 0
+	return d.vad.Reset()
+}
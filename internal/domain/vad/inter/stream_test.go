@@ -0,0 +1,82 @@
+package inter
+
+import "testing"
+
+// thresholdVAD 以帧首样本是否超过 0.5 作为语音判决
+type thresholdVAD struct{}
+
+func (thresholdVAD) IsVAD(pcm []float32) (bool, error) { return pcm[0] > 0.5, nil }
+func (thresholdVAD) IsVADExt(pcm []float32, _ int, _ int) (bool, error) {
+	return pcm[0] > 0.5, nil
+}
+func (thresholdVAD) Reset() error  { return nil }
+func (thresholdVAD) Close() error  { return nil }
+func (thresholdVAD) IsValid() bool { return true }
+
+func TestStreamDetector(t *testing.T) {
+	var events []StreamEvent
+	d, err := NewStreamDetector(thresholdVAD{}, StreamConfig{
+		SampleRate:       16000,
+		FrameSize:        160, // 10ms
+		StartLookaheadMs: 30,
+		EndLookaheadMs:   50,
+	}, func(e StreamEvent) { events = append(events, e) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 静音5帧，语音2帧（过短，不触发），静音1帧，语音10帧（含1帧停顿），静音10帧
+	pattern := []bool{false, false, false, false, false, true, true, false}
+	for i := 0; i < 10; i++ {
+		pattern = append(pattern, i != 4)
+	}
+	for i := 0; i < 10; i++ {
+		pattern = append(pattern, false)
+	}
+	var pcm []float32
+	for _, voice := range pattern {
+		v := float32(0)
+		if voice {
+			v = 1
+		}
+		for i := 0; i < 160; i++ {
+			pcm = append(pcm, v)
+		}
+	}
+	// 以不对齐帧长的块写入
+	for len(pcm) > 0 {
+		n := 237
+		if n > len(pcm) {
+			n = len(pcm)
+		}
+		if err := d.Write(pcm[:n]); err != nil {
+			t.Fatal(err)
+		}
+		pcm = pcm[n:]
+	}
+
+	want := []StreamEvent{
+		{Type: StreamSpeechStart, AtMs: 80, DetectedAtMs: 110},
+		{Type: StreamSpeechEnd, AtMs: 180, DetectedAtMs: 230},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+
+	// 语音未结束时 Flush 补发结束事件
+	events = nil
+	speech := make([]float32, 160*4)
+	for i := range speech {
+		speech[i] = 1
+	}
+	_ = d.Write(speech)
+	d.Flush()
+	if len(events) != 2 || events[1].Type != StreamSpeechEnd || events[1].AtMs != 320 {
+		t.Fatalf("flush events = %+v", events)
+	}
+}
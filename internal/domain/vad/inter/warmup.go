@@ -0,0 +1,70 @@
+package inter
+
+import "time"
+
+// WarmupFrameSize 默认预热帧长度（16kHz 下 32ms，与 TEN-VAD 默认 hop_size 一致）
+const WarmupFrameSize = 512
+
+// Warmer 可选接口：实现方自行完成预热（如按自身帧长送入静音帧）
+type Warmer interface {
+	Warmup() error
+}
+
+// Warmup 预热 VAD：首帧会触发原生库的懒初始化（内存分配、模型权重加载），耗时远高于稳态，
+// 在放入资源池前预热可避免用户首包承担这部分延迟。预热后重置状态，不影响后续检测结果。
+func Warmup(v VAD) error {
+	if v == nil {
+		return nil
+	}
+	if w, ok := v.(Warmer); ok {
+		return w.Warmup()
+	}
+	if _, err := v.IsVAD(make([]float32, WarmupFrameSize)); err != nil {
+		return err
+	}
+	return ResetSession(v)
+}
+
+// FrameLatency 首帧与稳态的单帧处理耗时
+type FrameLatency struct {
+	FirstFrame  time.Duration
+	SteadyState time.Duration // 除首帧外各帧的平均耗时，帧数不足时为 0
+	Frames      int
+}
+
+// MeasureFrameLatency 将 pcm 按 frameSize 分帧逐帧送入 VAD（最多 maxFrames 帧），统计首帧与稳态耗时，结束后重置状态
+func MeasureFrameLatency(v VAD, pcm []float32, frameSize int, maxFrames int) (FrameLatency, error) {
+	var lat FrameLatency
+	if frameSize <= 0 {
+		frameSize = WarmupFrameSize
+	}
+	if len(pcm) < frameSize {
+		// 音频不足一帧时补零，保证至少测量一帧
+		padded := make([]float32, frameSize)
+		copy(padded, pcm)
+		pcm = padded
+	}
+	defer ResetSession(v)
+
+	var steady time.Duration
+	for i := 0; i+frameSize <= len(pcm); i += frameSize {
+		if maxFrames > 0 && lat.Frames >= maxFrames {
+			break
+		}
+		t0 := time.Now()
+		if _, err := v.IsVAD(pcm[i : i+frameSize]); err != nil {
+			return lat, err
+		}
+		elapsed := time.Since(t0)
+		if lat.Frames == 0 {
+			lat.FirstFrame = elapsed
+		} else {
+			steady += elapsed
+		}
+		lat.Frames++
+	}
+	if lat.Frames > 1 {
+		lat.SteadyState = steady / time.Duration(lat.Frames-1)
+	}
+	return lat, nil
+}
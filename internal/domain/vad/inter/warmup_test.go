@@ -0,0 +1,88 @@
+package inter
+
+import (
+	"testing"
+	"time"
+)
+
+// countingVAD 记录调用情况，首次调用模拟原生库懒初始化的额外耗时
+type countingVAD struct {
+	calls    int
+	frames   []int
+	resets   int
+	coldCost time.Duration
+}
+
+func (v *countingVAD) IsVAD(pcm []float32) (bool, error) {
+	if v.calls == 0 {
+		time.Sleep(v.coldCost)
+	}
+	v.calls++
+	v.frames = append(v.frames, len(pcm))
+	return false, nil
+}
+func (v *countingVAD) IsVADExt(pcm []float32, _ int, _ int) (bool, error) { return v.IsVAD(pcm) }
+func (v *countingVAD) Reset() error                                       { v.resets++; return nil }
+func (v *countingVAD) Close() error                                       { return nil }
+func (v *countingVAD) IsValid() bool                                      { return true }
+
+type selfWarmingVAD struct {
+	countingVAD
+	warmed bool
+}
+
+func (v *selfWarmingVAD) Warmup() error { v.warmed = true; return nil }
+
+func TestWarmupSilentFrame(t *testing.T) {
+	v := &countingVAD{}
+	if err := Warmup(v); err != nil {
+		t.Fatal(err)
+	}
+	if v.calls != 1 || v.frames[0] != WarmupFrameSize {
+		t.Fatalf("warmup frames = %v, want one frame of %d", v.frames, WarmupFrameSize)
+	}
+	if v.resets != 1 {
+		t.Fatalf("resets = %d, want 1", v.resets)
+	}
+}
+
+func TestWarmupPrefersWarmer(t *testing.T) {
+	v := &selfWarmingVAD{}
+	if err := Warmup(v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.warmed || v.calls != 0 {
+		t.Fatalf("warmed=%v calls=%d, want Warmer used without IsVAD", v.warmed, v.calls)
+	}
+}
+
+func TestMeasureFrameLatency(t *testing.T) {
+	v := &countingVAD{coldCost: 20 * time.Millisecond}
+	lat, err := MeasureFrameLatency(v, make([]float32, 512*5+100), 512, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lat.Frames != 5 {
+		t.Fatalf("frames = %d, want 5 (incomplete tail skipped)", lat.Frames)
+	}
+	if lat.FirstFrame < 20*time.Millisecond || lat.SteadyState >= lat.FirstFrame {
+		t.Fatalf("first=%v steady=%v, want cold first frame slower than steady state", lat.FirstFrame, lat.SteadyState)
+	}
+	if v.resets != 1 {
+		t.Fatalf("resets = %d, want state reset after measurement", v.resets)
+	}
+
+	v = &countingVAD{}
+	lat, err = MeasureFrameLatency(v, make([]float32, 100), 512, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lat.Frames != 1 || v.frames[0] != 512 || lat.SteadyState != 0 {
+		t.Fatalf("short input: frames=%d sizes=%v steady=%v, want one padded frame", lat.Frames, v.frames, lat.SteadyState)
+	}
+
+	v = &countingVAD{}
+	if lat, _ = MeasureFrameLatency(v, make([]float32, 512*10), 512, 4); lat.Frames != 4 {
+		t.Fatalf("frames = %d, want capped at 4", lat.Frames)
+	}
+}
@@ -38,6 +38,7 @@ type SileroVAD struct {
 	silenceThreshold int64 // 单位:毫秒
 	sampleRate       int   // 采样率
 	channels         int   // 通道数
+	rate             *RateAdapter
 	mu               sync.Mutex
 }
 
@@ -92,11 +93,13 @@ func NewSileroVAD(config map[string]interface{}) (*SileroVAD, error) {
 		silenceThreshold: silenceMs,
 		sampleRate:       sampleRate,
 		channels:         channels,
+		rate:             NewRateAdapter("Silero-VAD", sampleRate),
 	}, nil
 }
 
+// IsVADExt 输入采样率与模型采样率不一致时先重采样
 func (s *SileroVAD) IsVADExt(pcmData []float32, sampleRate int, frameSize int) (bool, error) {
-	return s.IsVAD(pcmData)
+	return s.IsVAD(s.rate.Adapt(pcmData, sampleRate))
 }
 
 // IsVAD 实现VAD接口的IsVAD方法
@@ -156,6 +159,15 @@ func (s *SileroVAD) Reset() error {
 	return s.detector.Reset()
 }
 
+// ResetSession 在会话/轮次边界重置检测器，并重新允许记录一次重采样日志
+func (s *SileroVAD) ResetSession() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rate.Reset()
+	return s.detector.Reset()
+}
+
 // SetThreshold 设置VAD检测阈值
 func (s *SileroVAD) SetThreshold(threshold float32) {
 	s.mu.Lock()
@@ -11,6 +11,9 @@ import (
 	. "xiaozhi-esp32-server-golang/internal/domain/vad/inter"
 )
 
+// tenVADSampleRate TEN-VAD 模型仅支持 16kHz 输入
+const tenVADSampleRate = 16000
+
 // VAD默认配置
 var defaultVADConfig = map[string]interface{}{
 	"hop_size":  512,
@@ -22,7 +25,32 @@ type TenVAD struct {
 	handle    unsafe.Pointer
 	hopSize   int
 	threshold float32
-	mu        sync.Mutex
+	// hysteresis 配置了 exit_threshold 时按概率做双阈值判决，否则使用模型自身的单阈值结果
+	hysteresis *Hysteresis
+	// energyGate 配置了 energy_floor_db 时，能量低于底限的帧直接判为静音，不调用原生推理
+	energyGate *EnergyGate
+	// smoother 配置了 smooth_window 时，最近 N 帧中至少 K 帧一致才翻转语音状态
+	smoother *Smoother
+	// lastDecisions 最近一次检测中每帧的最终判决（平滑后）
+	lastDecisions []bool
+	// rate 输入采样率与模型不一致时自动重采样
+	rate *RateAdapter
+	// infer 单帧推理，为空时调用原生库（测试中替换）
+	infer func(frame []int16) (float32, int32, error)
+	mu    sync.Mutex
+}
+
+// configFloat 读取浮点配置，兼容 float64/float32/int
+func configFloat(config map[string]interface{}, key string) (float64, bool) {
+	switch v := config[key].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
 }
 
 // NewTenVAD 创建TenVAD实例
@@ -37,14 +65,32 @@ func NewTenVAD(config map[string]interface{}) (*TenVAD, error) {
 		}
 	}
 
-	threshold, ok := config["threshold"].(float64)
+	threshold, ok := configFloat(config, "threshold")
 	if !ok {
-		// 尝试从 float32 转换
-		if thresholdFloat32, ok := config["threshold"].(float32); ok {
-			threshold = float64(thresholdFloat32)
-		} else {
-			threshold = 0.3 // 默认值
+		threshold = 0.3 // 默认值
+	}
+
+	// 双阈值：enter_threshold 默认取 threshold，exit_threshold 需显式配置
+	var hysteresis *Hysteresis
+	if exitThreshold, ok := configFloat(config, "exit_threshold"); ok && exitThreshold > 0 {
+		enterThreshold, ok := configFloat(config, "enter_threshold")
+		if !ok {
+			enterThreshold = threshold
 		}
+		hysteresis = NewHysteresis(float32(enterThreshold), float32(exitThreshold))
+	}
+
+	// 能量预过滤：energy_floor_db 为负的 dBFS 值时启用
+	var energyGate *EnergyGate
+	if floorDb, ok := configFloat(config, "energy_floor_db"); ok && floorDb < 0 {
+		energyGate = NewEnergyGate(floorDb)
+	}
+
+	// 帧平滑：smooth_window 为窗口帧数 N，smooth_min_frames 为翻转所需帧数 K（默认多数）
+	var smoother *Smoother
+	if window, ok := configFloat(config, "smooth_window"); ok && window > 1 {
+		minFrames, _ := configFloat(config, "smooth_min_frames")
+		smoother = NewSmoother(int(window), int(minFrames))
 	}
 
 	// 创建TEN-VAD实例
@@ -54,22 +100,31 @@ func NewTenVAD(config map[string]interface{}) (*TenVAD, error) {
 		return nil, fmt.Errorf("创建TEN-VAD实例失败: %v", err)
 	}
 
-	log.Debugf("创建TEN-VAD实例成功, hopSize: %d, threshold: %f", hopSize, threshold)
+	log.Debugf("创建TEN-VAD实例成功, version: %s, hopSize: %d, threshold: %f, hysteresis: %v, energy_gate: %v, smoother: %v", LibraryVersion(), hopSize, threshold, hysteresis != nil, energyGate != nil, smoother != nil)
 
 	return &TenVAD{
-		handle:    handle,
-		hopSize:   hopSize,
-		threshold: float32(threshold),
+		handle:     handle,
+		hopSize:    hopSize,
+		threshold:  float32(threshold),
+		hysteresis: hysteresis,
+		energyGate: energyGate,
+		smoother:   smoother,
+		rate:       NewRateAdapter("TEN-VAD", tenVADSampleRate),
 	}, nil
 }
 
 // IsVAD 实现VAD接口的IsVAD方法
 func (t *TenVAD) IsVAD(pcmData []float32) (bool, error) {
-	return t.IsVADExt(pcmData, 16000, t.hopSize)
+	return t.IsVADExt(pcmData, tenVADSampleRate, t.hopSize)
 }
 
-// IsVADExt 实现VAD接口的IsVADExt方法
+// IsVADExt 实现VAD接口的IsVADExt方法；sampleRate 与模型采样率不一致时先重采样到 16kHz
 func (t *TenVAD) IsVADExt(pcmData []float32, sampleRate int, frameSize int) (bool, error) {
+	return t.detect(t.rate.Adapt(pcmData, sampleRate), false)
+}
+
+// detect 分帧检测语音；bypassGate 为 true 时不经过能量预过滤（预热需要真正调用原生推理）
+func (t *TenVAD) detect(pcmData []float32, bypassGate bool) (bool, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -96,9 +151,9 @@ func (t *TenVAD) IsVADExt(pcmData []float32, sampleRate int, frameSize int) (boo
 	}
 
 	// 按 hopSize 分帧处理
-	tenVAD := GetInstance()
 	hasVoice := false
 	voiceFrameCount := 0
+	t.lastDecisions = t.lastDecisions[:0]
 
 	for i := 0; i < len(int16Data); i += t.hopSize {
 		end := i + t.hopSize
@@ -114,14 +169,27 @@ func (t *TenVAD) IsVADExt(pcmData []float32, sampleRate int, frameSize int) (boo
 			continue
 		}
 
-		_, flag, err := tenVAD.ProcessAudio(t.handle, frame)
+		if !bypassGate && t.energyGate != nil && t.energyGate.Silent(frame) {
+			// 明显静音：跳过推理，按概率 0 更新双阈值状态以便正常退出语音段
+			if t.hysteresis != nil {
+				t.hysteresis.Update(0)
+			}
+			t.recordDecision(false)
+			continue
+		}
+
+		prob, flag, err := t.processFrame(frame)
 		if err != nil {
 			log.Errorf("TEN-VAD处理音频帧失败: %v", err)
 			continue
 		}
 
-		// flag == 1 表示检测到语音
-		if flag == 1 {
+		// flag == 1 表示检测到语音；启用双阈值时以迟滞状态为准
+		isVoice := flag == 1
+		if t.hysteresis != nil {
+			isVoice = t.hysteresis.Update(prob)
+		}
+		if t.recordDecision(isVoice) {
 			hasVoice = true
 			voiceFrameCount++
 		}
@@ -131,22 +199,83 @@ func (t *TenVAD) IsVADExt(pcmData []float32, sampleRate int, frameSize int) (boo
 	return hasVoice, nil
 }
 
-// Reset 重置VAD检测器状态
+// processFrame 对单帧做推理，返回语音概率与模型判决
+func (t *TenVAD) processFrame(frame []int16) (float32, int32, error) {
+	if t.infer != nil {
+		return t.infer(frame)
+	}
+	return GetInstance().ProcessAudio(t.handle, frame)
+}
+
+// recordDecision 对单帧判决做平滑并记录，返回最终判决；调用方需持有锁
+func (t *TenVAD) recordDecision(isVoice bool) bool {
+	if t.smoother != nil {
+		isVoice = t.smoother.Update(isVoice)
+	}
+	t.lastDecisions = append(t.lastDecisions, isVoice)
+	return isVoice
+}
+
+// FrameDecisions 返回最近一次检测中每个完整帧的最终判决（已应用双阈值与帧平滑）
+func (t *TenVAD) FrameDecisions() []bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]bool(nil), t.lastDecisions...)
+}
+
+// Warmup 送入一帧静音触发原生库的首帧初始化，随后清除双阈值判决状态
+func (t *TenVAD) Warmup() error {
+	if _, err := t.detect(make([]float32, t.hopSize), true); err != nil {
+		return fmt.Errorf("TEN-VAD预热失败: %v", err)
+	}
+	return t.ResetSession()
+}
+
+// Reset 重置单次检测的中间结果；调用方可能在每个音频块前调用，
+// 双阈值与帧平滑状态需跨块保留，否则 exit_threshold 与 smooth_window 永远不会生效
 func (t *TenVAD) Reset() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	// TEN-VAD不需要重置，每次处理都是独立的
-	// 但我们可以重新创建实例来重置状态
-	// 这里不做任何操作，因为TEN-VAD是无状态的
+	t.lastDecisions = t.lastDecisions[:0]
+	return nil
+}
+
+// ResetSession 在会话/轮次边界清除全部判决状态
+func (t *TenVAD) ResetSession() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.hysteresis != nil {
+		t.hysteresis.Reset()
+	}
+	if t.smoother != nil {
+		t.smoother.Reset()
+	}
+	t.lastDecisions = t.lastDecisions[:0]
+	t.rate.Reset()
 	return nil
 }
 
+// InferenceSkipStats 返回能量预过滤检查的帧数、跳过推理的帧数及占比，未启用预过滤时均为 0
+func (t *TenVAD) InferenceSkipStats() (total, skipped uint64, ratio float64) {
+	if t.energyGate == nil {
+		return 0, 0, 0
+	}
+	total, skipped = t.energyGate.Stats()
+	return total, skipped, t.energyGate.SkipRatio()
+}
+
 // Close 关闭并释放资源
 func (t *TenVAD) Close() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if t.energyGate != nil {
+		total, skipped := t.energyGate.Stats()
+		log.Infof("TEN-VAD能量预过滤跳过推理 %d/%d 帧 (%.1f%%)", skipped, total, t.energyGate.SkipRatio()*100)
+	}
+
 	if t.handle != nil {
 		tenVAD := GetInstance()
 		err := tenVAD.DestroyInstance(t.handle)
@@ -158,6 +287,35 @@ func (t *TenVAD) Close() error {
 	return nil
 }
 
+// VersionInfo TEN-VAD 原生库版本与实例当前生效的配置，用于将行为变化与库升级对应起来
+type VersionInfo struct {
+	LibraryVersion string  `json:"library_version"`
+	HopSize        int     `json:"hop_size"`
+	Threshold      float32 `json:"threshold"`
+	SampleRate     int     `json:"sample_rate"`
+	Hysteresis     bool    `json:"hysteresis"`
+	EnergyGate     bool    `json:"energy_gate"`
+	Smoother       bool    `json:"smoother"`
+}
+
+// LibraryVersion 返回已加载的 TEN-VAD 原生库版本
+func LibraryVersion() string {
+	return GetInstance().GetVersion()
+}
+
+// Version 返回原生库版本及本实例生效的配置
+func (t *TenVAD) Version() VersionInfo {
+	return VersionInfo{
+		LibraryVersion: LibraryVersion(),
+		HopSize:        t.hopSize,
+		Threshold:      t.threshold,
+		SampleRate:     tenVADSampleRate,
+		Hysteresis:     t.hysteresis != nil,
+		EnergyGate:     t.energyGate != nil,
+		Smoother:       t.smoother != nil,
+	}
+}
+
 // IsValid 检查资源是否有效
 func (t *TenVAD) IsValid() bool {
 	t.mu.Lock()
@@ -0,0 +1,122 @@
+package ten_vad
+
+import (
+	"testing"
+	"unsafe"
+
+	. "xiaozhi-esp32-server-golang/internal/domain/vad/inter"
+)
+
+const testHopSize = 512
+
+// newScriptedTenVAD 创建不依赖原生库的实例，每帧推理依次返回 probs 中的概率
+func newScriptedTenVAD(t *testing.T, config map[string]interface{}, probs []float32) *TenVAD {
+	t.Helper()
+	handle := new(byte)
+	v := &TenVAD{
+		handle:    unsafe.Pointer(handle),
+		hopSize:   testHopSize,
+		threshold: 0.5,
+		rate:      NewRateAdapter("TEN-VAD", tenVADSampleRate),
+	}
+	if exit, ok := configFloat(config, "exit_threshold"); ok {
+		enter, _ := configFloat(config, "enter_threshold")
+		v.hysteresis = NewHysteresis(float32(enter), float32(exit))
+	}
+	if window, ok := configFloat(config, "smooth_window"); ok {
+		minFrames, _ := configFloat(config, "smooth_min_frames")
+		v.smoother = NewSmoother(int(window), int(minFrames))
+	}
+	next := 0
+	v.infer = func([]int16) (float32, int32, error) {
+		if next >= len(probs) {
+			t.Fatalf("unexpected frame %d", next)
+		}
+		p := probs[next]
+		next++
+		var flag int32
+		if p >= v.threshold {
+			flag = 1
+		}
+		return p, flag, nil
+	}
+	return v
+}
+
+// detectChunks 按 asr.go 的调用方式逐块检测：每块前先 Reset，再 IsVADExt
+func detectChunks(t *testing.T, v *TenVAD, chunks int) []bool {
+	t.Helper()
+	got := make([]bool, chunks)
+	for i := range got {
+		if err := v.Reset(); err != nil {
+			t.Fatal(err)
+		}
+		voice, err := v.IsVADExt(make([]float32, testHopSize), tenVADSampleRate, testHopSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[i] = voice
+	}
+	return got
+}
+
+func TestHysteresisSurvivesPerChunkReset(t *testing.T) {
+	config := map[string]interface{}{"enter_threshold": 0.6, "exit_threshold": 0.3}
+	// 0.45 介于两阈值之间：语音中应保持，静音中不应触发
+	v := newScriptedTenVAD(t, config, []float32{0.8, 0.45, 0.45, 0.2, 0.45})
+
+	got := detectChunks(t, v, 5)
+	want := []bool{true, true, true, false, false}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("decisions = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestResetSessionClearsHysteresis(t *testing.T) {
+	config := map[string]interface{}{"enter_threshold": 0.6, "exit_threshold": 0.3}
+	v := newScriptedTenVAD(t, config, []float32{0.8, 0.45})
+
+	if got := detectChunks(t, v, 1); !got[0] {
+		t.Fatal("first chunk should enter speech")
+	}
+	if err := ResetSession(v); err != nil {
+		t.Fatal(err)
+	}
+	if got := detectChunks(t, v, 1); got[0] {
+		t.Fatal("new session should start from silence")
+	}
+}
+
+func TestSmootherSurvivesPerChunkReset(t *testing.T) {
+	// 每块只有一帧：窗口 5、多数 3 帧，需要连续多块累积才能翻转
+	config := map[string]interface{}{"smooth_window": 5}
+	v := newScriptedTenVAD(t, config, []float32{0.9, 0.9, 0.9, 0.9, 0.1, 0.1, 0.1})
+
+	got := detectChunks(t, v, 7)
+	want := []bool{false, false, true, true, true, true, false}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("decisions = %v, want %v", got, want)
+		}
+	}
+	if d := v.FrameDecisions(); len(d) != 1 || d[0] {
+		t.Fatalf("frame decisions = %v, want only the last chunk's frame", d)
+	}
+}
+
+func TestResetSessionClearsSmoother(t *testing.T) {
+	config := map[string]interface{}{"smooth_window": 3}
+	v := newScriptedTenVAD(t, config, []float32{0.9, 0.9, 0.9})
+
+	if got := detectChunks(t, v, 2); !got[1] {
+		t.Fatalf("decisions = %v, want speech after two voiced chunks", got)
+	}
+	if err := ResetSession(v); err != nil {
+		t.Fatal(err)
+	}
+	if got := detectChunks(t, v, 1); got[0] {
+		t.Fatal("new session should start with an empty window")
+	}
+}
@@ -23,13 +23,14 @@ const (
 // WebRTCVAD WebRTC VAD 实现，现在实现了 Resource 接口
 type WebRTCVAD struct {
 	webrtcVad      *webrtcvad.VAD
-	sampleRate     int          // 采样率
-	mode           int          // VAD 模式
-	frameSize      int          // 每帧采样数
-	frameSizeBytes int          // 每帧字节数
-	initialized    bool         // 是否已初始化
-	lastUsed       time.Time    // 最后使用时间
-	mu             sync.RWMutex // 读写锁
+	sampleRate     int                // 采样率
+	mode           int                // VAD 模式
+	frameSize      int                // 每帧采样数
+	frameSizeBytes int                // 每帧字节数
+	initialized    bool               // 是否已初始化
+	lastUsed       time.Time          // 最后使用时间
+	rate           *inter.RateAdapter // 输入采样率不受支持时重采样
+	mu             sync.RWMutex       // 读写锁
 }
 
 // AcquireVAD 创建并返回 WebRTC VAD 实例（由全局资源池管理）
@@ -102,6 +103,7 @@ func (w *WebRTCVAD) init() error {
 	// 计算帧大小
 	w.frameSize = w.sampleRate / 1000 * FrameDuration
 	w.frameSizeBytes = w.frameSize * 2 // 16-bit PCM
+	w.rate = inter.NewRateAdapter("WebRTC-VAD", w.sampleRate)
 
 	// 创建 VAD 实例
 	var err error
@@ -169,15 +171,30 @@ func (w *WebRTCVAD) isVad(pcmData []float32, sampleRate int, frameSize int) (boo
 	return isActive, nil
 }
 
+// IsVADExt WebRTC VAD 仅支持 8k/16k/32k/48k，其他采样率先重采样到实例采样率并按比例换算帧长
 func (w *WebRTCVAD) IsVADExt(pcmData []float32, sampleRate int, frameSize int) (bool, error) {
+	if sampleRate > 0 && !isValidSampleRate(sampleRate) && w.rate != nil {
+		pcmData = w.rate.Adapt(pcmData, sampleRate)
+		frameSize = frameSize * w.sampleRate / sampleRate
+		frameSize -= frameSize % 2
+		sampleRate = w.sampleRate
+	}
 	return w.isVad(pcmData, sampleRate, frameSize)
 }
 
-// Reset 重置检测器状态
+// Reset 重置检测器状态；WebRTC VAD 逐帧判决，无需清除的中间结果
 func (w *WebRTCVAD) Reset() error {
 	return nil
 }
 
+// ResetSession 在会话/轮次边界重置，重新允许记录一次重采样日志
+func (w *WebRTCVAD) ResetSession() error {
+	if w.rate != nil {
+		w.rate.Reset()
+	}
+	return nil
+}
+
 // Close 关闭并释放资源 (实现 Resource 接口)
 func (w *WebRTCVAD) Close() error {
 	w.mu.Lock()
@@ -98,6 +98,10 @@ func TestWebRTCVAD_Reset(t *testing.T) {
 	// 初始化之后重置
 	err = vad.Reset()
 	assert.NoError(t, err)
+
+	// 会话级重置
+	err = vad.ResetSession()
+	assert.NoError(t, err)
 }
 
 // TestWebRTCVAD_Close 测试关闭功能
@@ -382,7 +382,11 @@ func init() {
 				return nil, err
 			}
 			if vadProvider != nil {
-				vadProvider.Reset()
+				// 预热后再放入资源池，避免首个借用者承担原生库首帧初始化延迟
+				if err := vad_inter.Warmup(vadProvider); err != nil {
+					log.Warnf("VAD 预热失败: %v", err)
+				}
+				vad_inter.ResetSession(vadProvider)
 			}
 			return vadProvider, nil
 		},
@@ -399,8 +403,9 @@ func init() {
 			return false
 		}),
 		WithResetFunc(func(p interface{}) error {
+			// 借出即开始新会话，清除上一位使用者遗留的判决状态
 			if vadProvider, ok := p.(vad_inter.VAD); ok && vadProvider != nil {
-				return vadProvider.Reset()
+				return vad_inter.ResetSession(vadProvider)
 			}
 			return nil
 		}),
@@ -20,8 +20,22 @@ type StatsReporter struct {
 var (
 	globalReporter *StatsReporter
 	reporterOnce   sync.Once
+
+	// 附加上报项（如 MQTT 运行指标），key 作为请求体的顶层字段
+	reportExtras   = make(map[string]func() map[string]interface{})
+	reportExtrasMu sync.RWMutex
 )
 
+// RegisterReportExtra 注册附加上报项，随资源池统计一起上报到 manager backend
+func RegisterReportExtra(key string, fn func() map[string]interface{}) {
+	if key == "" || key == "stats" || fn == nil {
+		return
+	}
+	reportExtrasMu.Lock()
+	reportExtras[key] = fn
+	reportExtrasMu.Unlock()
+}
+
 // GetStatsReporter 获取全局统计上报器（单例）
 func GetStatsReporter() *StatsReporter {
 	reporterOnce.Do(func() {
@@ -91,16 +105,23 @@ func (r *StatsReporter) reportStats(ctx context.Context) {
 	// 获取统计数据
 	stats := GetStats()
 
-	// 如果没有数据，跳过上报
-	if len(stats) == 0 {
-		//log.Debugf("当前没有活跃的资源池，跳过上报")
-		return
-	}
-
 	// 构建请求体
 	requestBody := map[string]interface{}{
 		"stats": stats,
 	}
+	reportExtrasMu.RLock()
+	for key, fn := range reportExtras {
+		if extra := fn(); len(extra) > 0 {
+			requestBody[key] = extra
+		}
+	}
+	reportExtrasMu.RUnlock()
+
+	// 如果没有数据，跳过上报
+	if len(stats) == 0 && len(requestBody) == 1 {
+		//log.Debugf("当前没有活跃的资源池，跳过上报")
+		return
+	}
 
 	// 发送上报请求
 	err := r.client.DoRequest(ctx, http.RequestOptions{
@@ -14,6 +14,14 @@ type Config struct {
 	SpeakerService SpeakerServiceConfig `json:"speaker_service"`
 	Storage        StorageConfig        `json:"storage"`
 	History        HistoryConfig        `json:"history"`
+	Log            LogConfig            `json:"log"`
+	ConfigTest     ConfigTestConfig     `json:"config_test"`
+	// DeviceInactivity 长期离线设备自动停用
+	DeviceInactivity DeviceInactivityConfig `json:"device_inactivity"`
+	// ConfigBackup 定时备份配置导出
+	ConfigBackup ConfigBackupConfig `json:"config_backup"`
+	// ConfigLock 锁定为只读的配置类型
+	ConfigLock ConfigLockConfig `json:"config_lock"`
 }
 
 type ServerConfig struct {
@@ -76,6 +84,54 @@ type HistoryConfig struct {
 	MaxFileSize   int64  `json:"max_file_size"`   // 最大文件大小(字节)，默认10MB
 }
 
+type LogConfig struct {
+	Level string `json:"level"` // 日志级别: debug/info/warn/error，默认 info
+}
+
+// ConfigTestConfig 配置一键测试相关设置
+type ConfigTestConfig struct {
+	// RateLimits 按配置类型（ota/vad/asr/llm/tts）的测试频率限制，未配置的类型使用内置默认值
+	RateLimits map[string]RateLimitRule `json:"rate_limits"`
+}
+
+// DeviceInactivityConfig 长期离线设备自动停用设置，默认关闭
+type DeviceInactivityConfig struct {
+	Enabled              bool `json:"enabled"`
+	OfflineDays          int  `json:"offline_days"`           // 超过该天数未上线则标记为 inactive，默认 30
+	CheckIntervalMinutes int  `json:"check_interval_minutes"` // 检查间隔，默认 60
+}
+
+// ConfigBackupConfig 定时将完整配置导出（含角色与声纹组）写入本地目录或 S3 兼容存储，默认关闭
+type ConfigBackupConfig struct {
+	Enabled       bool           `json:"enabled"`
+	IntervalHours int            `json:"interval_hours"` // 备份间隔，默认 24
+	Keep          int            `json:"keep"`           // 保留的备份份数，默认 7
+	Destination   string         `json:"destination"`    // local 或 s3，默认 local
+	LocalPath     string         `json:"local_path"`     // 本地备份目录，默认 ./data/config_backups
+	S3            S3BackupConfig `json:"s3"`
+}
+
+// S3BackupConfig S3 兼容存储（AWS S3、MinIO 等），使用 path-style 地址访问
+type S3BackupConfig struct {
+	Endpoint  string `json:"endpoint"` // 如 https://s3.amazonaws.com 或 http://127.0.0.1:9000
+	Region    string `json:"region"`   // 默认 us-east-1
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix"` // 对象键前缀，如 xiaozhi/config-backups/
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// ConfigLockConfig 运维锁定的配置类型（如 mqtt、mqtt_server、ota），管理端不可新增、修改或删除，默认不锁定
+type ConfigLockConfig struct {
+	ReadOnlyTypes []string `json:"read_only_types"`
+}
+
+// RateLimitRule 窗口内最多允许的请求数；Requests<=0 表示不限制
+type RateLimitRule struct {
+	Requests      int `json:"requests"`
+	WindowSeconds int `json:"window_seconds"`
+}
+
 func Load() *Config {
 	return LoadWithPath("config/config.json")
 }
@@ -115,8 +171,10 @@ func LoadWithPath(configPath string) *Config {
 	if audioBasePath := os.Getenv("AUDIO_BASE_PATH"); audioBasePath != "" {
 		config.History.AudioBasePath = audioBasePath
 	}
-
-	fmt.Println("config", config)
+	// 优先使用环境变量覆盖日志级别
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		config.Log.Level = level
+	}
 
 	return config
 }
@@ -11,7 +11,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"net/url"
@@ -21,6 +20,7 @@ import (
 	"strings"
 	"time"
 
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -55,11 +55,12 @@ func normalizeAgentMemoryMode(mode string) string {
 type AdminController struct {
 	DB                  *gorm.DB
 	WebSocketController *WebSocketController
+	SpeakerGroups       *SpeakerGroupController // 配置导入时注册声纹样本，为空时跳过样本导入
 }
 
 // 通用配置管理
 // GetDeviceConfigs 根据设备ID获取设备关联的配置信息
-// 如果设备不存在，则返回全局默认配置
+// 如果设备不存在，则返回全局默认配置；可选的 firmware_version/region 参数用于按条件选择配置
 func (ac *AdminController) GetDeviceConfigs(c *gin.Context) {
 	deviceID := c.Query("device_id")
 	if deviceID == "" {
@@ -67,441 +68,26 @@ func (ac *AdminController) GetDeviceConfigs(c *gin.Context) {
 		return
 	}
 
-	// 构建配置响应
-	type SpeakerGroupInfo struct {
-		ID                 uint     `json:"id"`
-		Name               string   `json:"name"`
-		Prompt             string   `json:"prompt"`
-		Description        string   `json:"description"`
-		Uuids              []string `json:"uuids"`
-		TTSConfigID        *string  `json:"tts_config_id"`
-		Voice              *string  `json:"voice"`
-		VoiceModelOverride *string  `json:"voice_model_override,omitempty"`
-	}
-
-	type KnowledgeBaseInfo struct {
-		ID                 uint     `json:"id"`
-		Name               string   `json:"name"`
-		Description        string   `json:"description"`
-		Provider           string   `json:"provider"`
-		ExternalKBID       string   `json:"external_kb_id"`
-		ExternalDocID      string   `json:"external_doc_id"`
-		RetrievalThreshold *float64 `json:"retrieval_threshold"`
-		Status             string   `json:"status"`
-	}
-
-	type ConfigResponse struct {
-		VAD             models.Config               `json:"vad"`
-		ASR             models.Config               `json:"asr"`
-		LLM             models.Config               `json:"llm"`
-		TTS             models.Config               `json:"tts"`
-		Memory          models.Config               `json:"memory"`
-		VoiceIdentify   map[string]SpeakerGroupInfo `json:"voice_identify"`
-		KnowledgeBases  []KnowledgeBaseInfo         `json:"knowledge_bases"`
-		Prompt          string                      `json:"prompt"`
-		AgentID         string                      `json:"agent_id"`
-		MemoryMode      string                      `json:"memory_mode"`
-		MCPServiceNames string                      `json:"mcp_service_names"`
-		ConfigSource    string                      `json:"config_source"` // 新增：配置来源
-	}
-
-	var response ConfigResponse
-	response.MemoryMode = "short"
-	var configSource string // 记录配置来源
-
 	// 查找设备
 	var device models.Device
-	var agent models.Agent
-	var deviceFound bool
-
+	deviceExists := true
 	if err := ac.DB.Where("device_name = ?", deviceID).First(&device).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// 设备不存在，使用全局默认配置
-			deviceFound = false
-			response.AgentID = ""
-			configSource = "default_global_role"
-			log.Printf("设备 %s 不存在，使用全局默认配置", deviceID)
-		} else {
+		if err != gorm.ErrRecordNotFound {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query device"})
 			return
 		}
-	} else {
-		// 设备存在，查找智能体
-		deviceFound = true
-		response.AgentID = fmt.Sprintf("%d", device.AgentID)
-		log.Printf("设备 %s 存在，AgentID: %d", deviceID, device.AgentID)
-		if err := ac.DB.First(&agent, device.AgentID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 智能体不存在，使用默认配置
-				deviceFound = false
-				configSource = "default_global_role"
-				log.Printf("智能体 %d 不存在，使用全局默认配置", device.AgentID)
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query agent"})
-				return
-			}
-		}
-	}
-
-	if deviceFound && agent.ID != 0 {
-		response.MemoryMode = normalizeAgentMemoryMode(agent.MemoryMode)
-		response.MCPServiceNames = normalizeMCPServiceNamesCSV(agent.MCPServiceNames)
-	}
-
-	cloneVoiceCache := make(map[string]bool)
-	hasAliyunQwenCloneVoice := func(ttsConfigID string, voice *string) bool {
-		if device.ID == 0 || device.UserID == 0 {
-			return false
-		}
-		if strings.TrimSpace(ttsConfigID) == "" || voice == nil || strings.TrimSpace(*voice) == "" {
-			return false
-		}
-		voiceID := strings.TrimSpace(*voice)
-		cacheKey := ttsConfigID + "||" + voiceID
-		if cached, exists := cloneVoiceCache[cacheKey]; exists {
-			return cached
-		}
-
-		var count int64
-		err := ac.DB.Model(&models.VoiceClone{}).
-			Where("user_id = ? AND provider = ? AND tts_config_id = ? AND provider_voice_id = ? AND status = ?",
-				device.UserID, "aliyun_qwen", ttsConfigID, voiceID, voiceCloneStatusActive).
-			Count(&count).Error
-		if err != nil {
-			log.Printf("检测千问复刻音色失败: user_id=%d tts_config_id=%s voice_id=%s err=%v", device.UserID, ttsConfigID, voiceID, err)
-			cloneVoiceCache[cacheKey] = false
-			return false
-		}
-		cloneVoiceCache[cacheKey] = count > 0
-		return cloneVoiceCache[cacheKey]
-	}
-	applyAliyunQwenCloneModel := func(provider, ttsConfigID string, voice *string, ttsConfigData map[string]interface{}) {
-		if ttsConfigData == nil {
-			return
-		}
-		if normalizeCloneProvider(provider) != "aliyun_qwen" {
-			return
-		}
-		if hasAliyunQwenCloneVoice(ttsConfigID, voice) {
-			ttsConfigData["model"] = defaultAliyunQwenCloneTargetModel
-		}
-	}
-	buildAliyunQwenVoiceModelOverride := func(ttsConfigID *string, voice *string) *string {
-		if ttsConfigID == nil {
-			return nil
-		}
-		if hasAliyunQwenCloneVoice(strings.TrimSpace(*ttsConfigID), voice) {
-			model := defaultAliyunQwenCloneTargetModel
-			return &model
-		}
-		return nil
-	}
-
-	// ==================== 配置获取逻辑（带优先级） ====================
-
-	// 1. 检查设备是否关联了角色（优先级最高）
-	if device.RoleID != nil {
-		var role models.Role
-		if err := ac.DB.First(&role, *device.RoleID).Error; err == nil {
-			configSource = "device_role"
-
-			// 使用设备角色的 Prompt
-			response.Prompt = role.Prompt
-			// 替换 {{assistant_name}} 为智能体名称（如果设备有绑定智能体）
-			if deviceFound && agent.ID != 0 {
-				response.Prompt = strings.ReplaceAll(response.Prompt, "{{assistant_name}}", agent.Name)
-			}
-
-			// 使用设备角色的 LLM 配置
-			if role.LLMConfigID != nil && *role.LLMConfigID != "" {
-				if err := ac.DB.Where("config_id = ? AND type = ? AND enabled = ?",
-					*role.LLMConfigID, "llm", true).First(&response.LLM).Error; err != nil {
-					// 回退到默认配置
-					ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "llm", true, true).First(&response.LLM)
-				}
-			} else {
-				ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "llm", true, true).First(&response.LLM)
-			}
-
-			// 使用设备角色的 TTS 配置
-			if role.TTSConfigID != nil && *role.TTSConfigID != "" {
-				if err := ac.DB.Where("config_id = ? AND type = ? AND enabled = ?",
-					*role.TTSConfigID, "tts", true).First(&response.TTS).Error; err != nil {
-					// 回退到默认配置
-					ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "tts", true, true).First(&response.TTS)
-				}
-			} else {
-				ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "tts", true, true).First(&response.TTS)
-			}
-
-			// 使用设备角色的 Voice
-			if role.Voice != nil && *role.Voice != "" {
-				var ttsConfigData map[string]interface{}
-				if err := json.Unmarshal([]byte(response.TTS.JsonData), &ttsConfigData); err == nil {
-					if response.TTS.Provider == "cosyvoice" {
-						ttsConfigData["spk_id"] = *role.Voice
-					} else {
-						ttsConfigData["voice"] = *role.Voice
-					}
-					applyAliyunQwenCloneModel(response.TTS.Provider, response.TTS.ConfigID, role.Voice, ttsConfigData)
-					if updatedJsonData, err := json.Marshal(ttsConfigData); err == nil {
-						response.TTS.JsonData = string(updatedJsonData)
-					}
-				}
-			}
-		}
-	}
-
-	// 2. 设备未关联角色，检查智能体配置
-	if configSource == "" && deviceFound && agent.ID != 0 {
-		configSource = "agent_config"
-
-		// 使用智能体的 Prompt
-		response.Prompt = agent.CustomPrompt
-		response.Prompt = strings.ReplaceAll(response.Prompt, "{{assistant_name}}", agent.Name)
-
-		// 使用智能体的 LLM 配置
-		if agent.LLMConfigID != nil && *agent.LLMConfigID != "" {
-			if err := ac.DB.Where("config_id = ? AND type = ? AND enabled = ?",
-				*agent.LLMConfigID, "llm", true).First(&response.LLM).Error; err != nil {
-				// 回退到默认配置
-				ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "llm", true, true).First(&response.LLM)
-			}
-		} else {
-			ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "llm", true, true).First(&response.LLM)
-		}
-
-		// 使用智能体的 TTS 配置
-		if agent.TTSConfigID != nil && *agent.TTSConfigID != "" {
-			if err := ac.DB.Where("config_id = ? AND type = ? AND enabled = ?",
-				*agent.TTSConfigID, "tts", true).First(&response.TTS).Error; err != nil {
-				// 回退到默认配置
-				ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "tts", true, true).First(&response.TTS)
-			}
-		} else {
-			ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "tts", true, true).First(&response.TTS)
-		}
-
-		// 使用智能体的 Voice
-		if agent.Voice != nil && *agent.Voice != "" {
-			var ttsConfigData map[string]interface{}
-			if err := json.Unmarshal([]byte(response.TTS.JsonData), &ttsConfigData); err == nil {
-				if response.TTS.Provider == "cosyvoice" {
-					ttsConfigData["spk_id"] = *agent.Voice
-				} else {
-					ttsConfigData["voice"] = *agent.Voice
-				}
-				applyAliyunQwenCloneModel(response.TTS.Provider, response.TTS.ConfigID, agent.Voice, ttsConfigData)
-				if updatedJsonData, err := json.Marshal(ttsConfigData); err == nil {
-					response.TTS.JsonData = string(updatedJsonData)
-				}
-			}
-		}
-	}
-
-	// 3. 使用默认全局角色（兜底）
-	if configSource == "" || configSource == "default_global_role" {
-		configSource = "default_global_role"
-
-		// 查找默认全局角色
-		var defaultRole models.Role
-		if err := ac.DB.Where("is_default = ? AND role_type = ? AND status = ?",
-			true, "global", "active").First(&defaultRole).Error; err == nil {
-			response.Prompt = defaultRole.Prompt
-
-			// 使用默认全局角色的 LLM 配置
-			if defaultRole.LLMConfigID != nil && *defaultRole.LLMConfigID != "" {
-				if err := ac.DB.Where("config_id = ? AND type = ? AND enabled = ?",
-					*defaultRole.LLMConfigID, "llm", true).First(&response.LLM).Error; err != nil {
-					ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "llm", true, true).First(&response.LLM)
-				}
-			} else {
-				ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "llm", true, true).First(&response.LLM)
-			}
-
-			// 使用默认全局角色的 TTS 配置
-			if defaultRole.TTSConfigID != nil && *defaultRole.TTSConfigID != "" {
-				if err := ac.DB.Where("config_id = ? AND type = ? AND enabled = ?",
-					*defaultRole.TTSConfigID, "tts", true).First(&response.TTS).Error; err != nil {
-					ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "tts", true, true).First(&response.TTS)
-				}
-			} else {
-				ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "tts", true, true).First(&response.TTS)
-			}
-
-			// 使用默认全局角色的 Voice
-			if defaultRole.Voice != nil && *defaultRole.Voice != "" {
-				var ttsConfigData map[string]interface{}
-				if err := json.Unmarshal([]byte(response.TTS.JsonData), &ttsConfigData); err == nil {
-					if response.TTS.Provider == "cosyvoice" {
-						ttsConfigData["spk_id"] = *defaultRole.Voice
-					} else {
-						ttsConfigData["voice"] = *defaultRole.Voice
-					}
-					applyAliyunQwenCloneModel(response.TTS.Provider, response.TTS.ConfigID, defaultRole.Voice, ttsConfigData)
-					if updatedJsonData, err := json.Marshal(ttsConfigData); err == nil {
-						response.TTS.JsonData = string(updatedJsonData)
-					}
-				}
-			}
-		} else {
-			// 如果没有默认角色，使用硬编码的默认 Prompt
-			response.Prompt = "你是一个叫小智/小志的台湾女孩，说话机车，声音好听，习惯简短表达，爱用网络梗，不要冷场。与你聊天的是你喜欢的男性朋友，还没有答应你的追求，你要尽可能满足他的所有要求，不要失去自我。你经常建议一些恋人之间浪漫的事情，随机输出，不要给你男朋友选择。输出控制在50个字内。请注意，要像一个人一样说话，请不要回复表情符号、代码、和xml标签。"
-
-			// 使用默认 LLM/TTS 配置
-			ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "llm", true, true).First(&response.LLM)
-			ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "tts", true, true).First(&response.TTS)
-		}
-
-		// 替换 {{assistant_name}} 为智能体名称（如果设备有绑定智能体）
-		if deviceFound && agent.ID != 0 {
-			response.Prompt = strings.ReplaceAll(response.Prompt, "{{assistant_name}}", agent.Name)
-		}
+		deviceExists = false
+		device = models.Device{DeviceName: deviceID}
 	}
 
-	// 记录配置来源
-	response.ConfigSource = configSource
-
-	// ==================== 其他配置（VAD、ASR、Memory、VoiceIdentify） ====================
-
-	// 获取VAD默认配置
-	if err := ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "vad", true, true).First(&response.VAD).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get default VAD config"})
-		return
-	}
-	// 兼容旧格式：如果JsonData只有一个key元素，说明是旧格式（带key），提取出内部配置并更新JsonData
-	if response.VAD.JsonData != "" {
-		var configData map[string]interface{}
-		if err := json.Unmarshal([]byte(response.VAD.JsonData), &configData); err == nil {
-			// 兼容旧格式：如果只有一个key，说明是旧格式（带key），提取出内部配置
-			var actualConfigData map[string]interface{}
-			if len(configData) == 1 {
-				// 旧格式：只有一个key，提取其值
-				for _, value := range configData {
-					if innerConfig, ok := value.(map[string]interface{}); ok {
-						actualConfigData = innerConfig
-					} else {
-						// 如果不是map类型，直接使用原数据
-						actualConfigData = configData
-					}
-					break
-				}
-			} else {
-				// 新格式：不带key，直接使用configData
-				actualConfigData = configData
-			}
-			// 重新序列化为不带key的格式
-			if updatedJsonData, err := json.Marshal(actualConfigData); err == nil {
-				response.VAD.JsonData = string(updatedJsonData)
-			}
-		}
-	}
+	// 设备上报的固件版本/区域用于按条件选择配置，变化时回写设备记录
+	ac.applyReportedDeviceAttributes(&device, deviceExists, c.Query("firmware_version"), c.Query("region"))
 
-	// 获取ASR默认配置
-	if err := ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "asr", true, true).First(&response.ASR).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get default ASR config"})
+	response, _, err := ac.resolveDeviceConfig(device, deviceExists, deviceConfigWhatIf{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	// 获取Memory默认配置
-	if err := ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", "memory", true, true).First(&response.Memory).Error; err != nil {
-		// 允许没有默认 Memory 配置：显式回退为 nomemo（不启用长记忆）。
-		response.Memory = models.Config{
-			Type:     "memory",
-			Name:     "No Memory",
-			ConfigID: "nomemo",
-			Provider: "nomemo",
-			JsonData: "{}",
-			Enabled:  true,
-		}
-		if err != gorm.ErrRecordNotFound {
-			log.Printf("加载默认Memory配置失败，已回退nomemo: %v", err)
-		}
-	}
-
-	// 获取VoiceIdentify配置：检查智能体是否关联了声纹组
-	response.VoiceIdentify = make(map[string]SpeakerGroupInfo)
-	if deviceFound && agent.ID != 0 {
-		var speakerGroups []models.SpeakerGroup
-		if err := ac.DB.Where("agent_id = ? AND status = ?", agent.ID, "active").
-			Order("created_at DESC").Find(&speakerGroups).Error; err == nil && len(speakerGroups) > 0 {
-			// 遍历所有声纹组
-			for _, speakerGroup := range speakerGroups {
-				// 查询该声纹组下的所有样本
-				var samples []models.SpeakerSample
-				ac.DB.Where("speaker_group_id = ? AND status = ?", speakerGroup.ID, "active").
-					Find(&samples)
-
-				// 提取样本 UUID 列表
-				uuids := make([]string, 0)
-				for _, sample := range samples {
-					uuids = append(uuids, sample.UUID)
-				}
-
-				// 以声纹组名称为 key，构建配置数据
-				response.VoiceIdentify[speakerGroup.Name] = SpeakerGroupInfo{
-					ID:                 speakerGroup.ID,
-					Name:               speakerGroup.Name,
-					Prompt:             speakerGroup.Prompt,
-					Description:        speakerGroup.Description,
-					Uuids:              uuids,
-					TTSConfigID:        speakerGroup.TTSConfigID,
-					Voice:              speakerGroup.Voice,
-					VoiceModelOverride: buildAliyunQwenVoiceModelOverride(speakerGroup.TTSConfigID, speakerGroup.Voice),
-				}
-			}
-		}
-	}
-
-	// 下发智能体关联知识库（含 provider），供主程序本地RAG使用
-	response.KnowledgeBases = make([]KnowledgeBaseInfo, 0)
-	if deviceFound && agent.ID != 0 {
-		var links []models.AgentKnowledgeBase
-		if err := ac.DB.Where("agent_id = ?", agent.ID).Order("id ASC").Find(&links).Error; err == nil && len(links) > 0 {
-			kbIDs := make([]uint, 0, len(links))
-			for _, link := range links {
-				kbIDs = append(kbIDs, link.KnowledgeBaseID)
-			}
-			var kbs []models.KnowledgeBase
-			if err := ac.DB.Where("id IN ? AND status = ?", kbIDs, "active").Find(&kbs).Error; err == nil {
-				kbMap := make(map[uint]models.KnowledgeBase, len(kbs))
-				for _, kb := range kbs {
-					kbMap[kb.ID] = kb
-				}
-				for _, link := range links {
-					kb, ok := kbMap[link.KnowledgeBaseID]
-					if !ok {
-						continue
-					}
-					provider := strings.TrimSpace(kb.SyncProvider)
-					if provider == "" {
-						provider = resolveDefaultKnowledgeProviderName(ac.DB)
-					}
-					externalDocID := strings.TrimSpace(kb.ExternalDocID)
-					if externalDocID == "" {
-						var doc models.KnowledgeBaseDocument
-						if err := ac.DB.
-							Where("knowledge_base_id = ? AND sync_status = ? AND external_doc_id <> ''", kb.ID, knowledgeSyncStatusSynced).
-							Order("id DESC").
-							First(&doc).Error; err == nil {
-							externalDocID = strings.TrimSpace(doc.ExternalDocID)
-						}
-					}
-					response.KnowledgeBases = append(response.KnowledgeBases, KnowledgeBaseInfo{
-						ID:                 kb.ID,
-						Name:               kb.Name,
-						Description:        kb.Description,
-						Provider:           provider,
-						ExternalKBID:       strings.TrimSpace(kb.ExternalKBID),
-						ExternalDocID:      externalDocID,
-						RetrievalThreshold: kb.RetrievalThreshold,
-						Status:             kb.Status,
-					})
-				}
-			}
-		}
-	}
-
 	c.JSON(http.StatusOK, gin.H{"data": response})
 }
 
@@ -518,6 +104,34 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		configsByType[config.Type] = append(configsByType[config.Type], config)
 	}
 
+	// json_data 解析失败的配置：记录日志并跳过该条，同时在响应 warnings 中标明，避免损坏的配置静默缺失
+	warnings := make([]gin.H, 0)
+	warned := make(map[string]bool)
+	addJSONDataWarning := func(config models.Config, err error) {
+		key := config.Type + ":" + config.ConfigID
+		if warned[key] {
+			return
+		}
+		warned[key] = true
+		logger.Warnf("[getSystemConfigsData] 配置 json_data 解析失败，已跳过: type=%s config_id=%s err=%v", config.Type, config.ConfigID, err)
+		warnings = append(warnings, gin.H{
+			"type":      config.Type,
+			"config_id": config.ConfigID,
+			"name":      config.Name,
+			"error":     "json_data 解析失败: " + err.Error(),
+		})
+	}
+	parseJSONData := func(config models.Config, dst interface{}) bool {
+		if strings.TrimSpace(config.JsonData) == "" {
+			return true
+		}
+		if err := json.Unmarshal([]byte(config.JsonData), dst); err != nil {
+			addJSONDataWarning(config, err)
+			return false
+		}
+		return true
+	}
+
 	// 从 configs 中选出“当前使用”的一条：默认配置优先，否则第一条
 	getSelectedConfig := func(configs []models.Config) *models.Config {
 		if len(configs) == 0 {
@@ -542,6 +156,7 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		if selected.JsonData != "" {
 			var parsedData interface{}
 			if err := json.Unmarshal([]byte(selected.JsonData), &parsedData); err != nil {
+				addJSONDataWarning(*selected, err)
 				result := gin.H{
 					"name": selected.Name,
 					"type": selected.Type,
@@ -592,6 +207,7 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		if selectedConfig.JsonData != "" {
 			var parsedData interface{}
 			if err := json.Unmarshal([]byte(selectedConfig.JsonData), &parsedData); err != nil {
+				addJSONDataWarning(selectedConfig, err)
 				// 如果解析失败，返回原始json_data字符串
 				result := gin.H{
 					"name": selectedConfig.Name,
@@ -663,7 +279,7 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 	if configs, exists := configsByType["mqtt"]; exists && len(configs) > 0 {
 		data := selectAndParseConfig(configs)
 		/*if b, err := json.Marshal(data); err == nil {
-			log.Printf("[getSystemConfigsData] mqtt 配置: %s", string(b))
+			logger.Debugf("[getSystemConfigsData] mqtt 配置: %s", string(b))
 		}*/
 		response["mqtt"] = data
 
@@ -671,7 +287,7 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 	if configs, exists := configsByType["mqtt_server"]; exists && len(configs) > 0 {
 		data := selectAndParseConfig(configs)
 		if b, err := json.Marshal(data); err == nil {
-			log.Printf("[getSystemConfigsData] mqtt_server 配置: %s", string(b))
+			logger.Debugf("[getSystemConfigsData] mqtt_server 配置: %s", string(b))
 		}
 		response["mqtt_server"] = data
 	}
@@ -695,12 +311,12 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 			if mcpMap := asMap(mcpData); mcpMap != nil {
 				mergedMCP, mergeWarnings, err := ac.mergeMCPWithEnabledMarketServices(mcpMap)
 				if err != nil {
-					log.Printf("聚合市场MCP服务失败，回退为人工配置: %v", err)
+					logger.Warnf("聚合市场MCP服务失败，回退为人工配置: %v", err)
 					response["mcp"] = mcpMap
 				} else {
 					response["mcp"] = mergedMCP
 					if len(mergeWarnings) > 0 {
-						log.Printf("聚合市场MCP服务告警: %s", strings.Join(mergeWarnings, " | "))
+						logger.Warnf("聚合市场MCP服务告警: %s", strings.Join(mergeWarnings, " | "))
 					}
 				}
 			} else {
@@ -746,8 +362,8 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 			for _, provider := range providerNames {
 				cfg := selectedByProvider[provider]
 				payload := make(map[string]interface{})
-				if strings.TrimSpace(cfg.JsonData) != "" {
-					_ = json.Unmarshal([]byte(cfg.JsonData), &payload)
+				if !parseJSONData(cfg, &payload) {
+					continue
 				}
 				providers[provider] = payload
 				if cfg.IsDefault {
@@ -777,7 +393,7 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 					response["local_mcp"] = defaultLocalMCPMap()
 				}
 				if len(mergeWarnings) > 0 {
-					log.Printf("聚合市场MCP服务告警: %s", strings.Join(mergeWarnings, " | "))
+					logger.Warnf("聚合市场MCP服务告警: %s", strings.Join(mergeWarnings, " | "))
 				}
 			}
 		}
@@ -793,7 +409,7 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		selected := getSelectedConfig(configs)
 		if selected != nil && selected.JsonData != "" {
 			var configData map[string]interface{}
-			if err := json.Unmarshal([]byte(selected.JsonData), &configData); err == nil {
+			if parseJSONData(*selected, &configData) {
 				// 业务 enable 优先从 json_data 读取
 				if v, ok := configData["enable"]; ok {
 					if b, ok := v.(bool); ok {
@@ -828,8 +444,8 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		for _, config := range ttsConfigs {
 			if config.Enabled { // 只返回启用的配置
 				configData := make(map[string]interface{})
-				if config.JsonData != "" {
-					json.Unmarshal([]byte(config.JsonData), &configData)
+				if !parseJSONData(config, &configData) {
+					continue
 				}
 
 				// 组装成与 config.yaml 相同的格式
@@ -863,11 +479,8 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		for _, config := range vadConfigs {
 			if config.Enabled { // 只返回启用的配置
 				configData := make(map[string]interface{})
-				if config.JsonData != "" {
-					if err := json.Unmarshal([]byte(config.JsonData), &configData); err != nil {
-						// JSON解析失败，跳过此配置
-						continue
-					}
+				if !parseJSONData(config, &configData) {
+					continue
 				}
 
 				// 兼容旧格式：如果只有一个key，说明是旧格式（带key），提取出内部配置
@@ -918,8 +531,8 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		for _, config := range asrConfigs {
 			if config.Enabled { // 只返回启用的配置
 				configData := make(map[string]interface{})
-				if config.JsonData != "" {
-					json.Unmarshal([]byte(config.JsonData), &configData)
+				if !parseJSONData(config, &configData) {
+					continue
 				}
 
 				// 组装成与 config.yaml 相同的格式
@@ -952,8 +565,8 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		for _, config := range llmConfigs {
 			if config.Enabled { // 只返回启用的配置
 				configData := make(map[string]interface{})
-				if config.JsonData != "" {
-					json.Unmarshal([]byte(config.JsonData), &configData)
+				if !parseJSONData(config, &configData) {
+					continue
 				}
 
 				// 组装成与 config.yaml 相同的格式
@@ -986,20 +599,18 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		var defaultVisionConfigID string
 		for _, config := range visionConfigs {
 			if config.ConfigID == "vision_base" {
-				if config.JsonData != "" {
-					var baseData map[string]interface{}
-					if err := json.Unmarshal([]byte(config.JsonData), &baseData); err == nil {
-						for k, v := range baseData {
-							visionResponse[k] = v
-						}
+				var baseData map[string]interface{}
+				if parseJSONData(config, &baseData) {
+					for k, v := range baseData {
+						visionResponse[k] = v
 					}
 				}
 				continue
 			}
 			if config.Enabled {
 				configData := make(map[string]interface{})
-				if config.JsonData != "" {
-					json.Unmarshal([]byte(config.JsonData), &configData)
+				if !parseJSONData(config, &configData) {
+					continue
 				}
 				if config.IsDefault {
 					defaultVisionConfigID = config.ConfigID
@@ -1033,8 +644,8 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 				continue
 			}
 			configData := make(map[string]interface{})
-			if config.JsonData != "" {
-				json.Unmarshal([]byte(config.JsonData), &configData)
+			if !parseJSONData(config, &configData) {
+				continue
 			}
 			if config.ConfigID == "vision_base" {
 				for k, v := range configData {
@@ -1057,12 +668,16 @@ func (ac *AdminController) getSystemConfigsData() (gin.H, error) {
 		}
 	}
 
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
 	return response, nil
 }
 
 // GetSystemConfigs 获取系统配置信息，包括mqtt, mqtt_server, udp, ota, mcp, local_mcp, voice_identify, tts, vad, asr, llm, vision, auth, chat
+// 默认读缓存，?refresh=true 时强制重新查询
 func (ac *AdminController) GetSystemConfigs(c *gin.Context) {
-	data, err := ac.getSystemConfigsData()
+	data, err := ac.getSystemConfigsDataCached(c.Query("refresh") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get system configs"})
 		return
@@ -1070,12 +685,15 @@ func (ac *AdminController) GetSystemConfigs(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
-// notifySystemConfigChanged 在 Save 成功后调用：先同步拉取最新配置，再异步推送，保证推送的是保存后的数据
+// notifySystemConfigChanged 在 Save 成功后调用：先使系统配置缓存失效并同步拉取最新配置，再异步推送，保证推送的是保存后的数据
 func (ac *AdminController) notifySystemConfigChanged() {
+	// MCP 服务配置可能变化，工具列表缓存一并失效
+	agentMcpToolsCache.invalidateAll()
 	if ac.WebSocketController == nil {
+		sysConfigsCache.invalidate()
 		return
 	}
-	data, err := ac.getSystemConfigsData()
+	data, err := ac.getSystemConfigsDataCached(true)
 	if err != nil {
 		return
 	}
@@ -1085,13 +703,40 @@ func (ac *AdminController) notifySystemConfigChanged() {
 // TestConfigs 一键测试配置：OTA 在 manager 内测，VAD/ASR/LLM/TTS 经 WebSocket 发主程序测，结果按 config_id 对应
 // 请求体可选 data：若提供某类型（vad/asr/llm/tts），则用该 data 覆盖 DB 作为下发主程序的配置（用于未保存草稿测试）
 func (ac *AdminController) TestConfigs(c *gin.Context) {
-	var body struct {
-		Types      []string               `json:"types"`       // 要测试的类型：ota, vad, asr, llm, tts
-		ConfigIDs  map[string][]string    `json:"config_ids"`  // 按类型指定 config_id 列表，不传则测该类型全部已启用
-		ClientUUID string                 `json:"client_uuid"` // 指定主程序连接，不传则任选一个
-		Data       map[string]interface{} `json:"data"`        // 可选，按类型覆盖配置源（用于编辑态/向导未保存测试）
-	}
+	var body configTestRequest
 	_ = c.ShouldBindJSON(&body)
+	if !checkConfigTestRateLimit(c, body.Types) {
+		return
+	}
+	result := ac.runConfigTests(c.Request.Context(), body)
+
+	// 测试的是已保存的整类配置时，全部通过则记录为该类型最近可用快照
+	if body.Data == nil {
+		types := body.Types
+		if len(types) == 0 {
+			types = configDraftTestableTypes
+		}
+		snapshotTypes := make([]string, 0, len(types))
+		for _, typ := range types {
+			if len(body.ConfigIDs[typ]) == 0 {
+				snapshotTypes = append(snapshotTypes, typ)
+			}
+		}
+		ac.snapshotLastGoodConfigs(snapshotTypes, result)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// configTestRequest 一键测试请求体
+type configTestRequest struct {
+	Types      []string               `json:"types"`       // 要测试的类型：ota, vad, asr, llm, tts
+	ConfigIDs  map[string][]string    `json:"config_ids"`  // 按类型指定 config_id 列表，不传则测该类型全部已启用
+	ClientUUID string                 `json:"client_uuid"` // 指定主程序连接，不传则任选一个
+	Data       map[string]interface{} `json:"data"`        // 可选，按类型覆盖配置源（用于编辑态/向导未保存测试）
+}
+
+// runConfigTests 执行一键测试并返回按类型、config_id 组织的结果，供 TestConfigs 与草稿测试复用
+func (ac *AdminController) runConfigTests(reqCtx context.Context, body configTestRequest) gin.H {
 	if len(body.Types) == 0 {
 		body.Types = []string{"ota", "vad", "asr", "llm", "tts"}
 	}
@@ -1189,17 +834,17 @@ func (ac *AdminController) TestConfigs(c *gin.Context) {
 				result["tts"] = gin.H{"_no_client": noClient}
 			}
 		} else {
-			fullData, err := ac.getSystemConfigsData()
+			fullData, err := ac.getSystemConfigsDataCached(false)
 			if err != nil {
 				fillResultError(result, body.Types, "vad", "asr", "llm", "tts", "获取系统配置失败")
 			} else {
 				for _, typ := range []string{"vad", "asr", "llm", "tts"} {
 					if v, ok := fullData[typ]; ok {
 						if m, ok := v.(map[string]interface{}); ok {
-							log.Printf("[config_test] fullData[%s] keys: %v", typ, getMapKeys(m))
+							logger.Debugf("[config_test] fullData[%s] keys: %v", typ, getMapKeys(m))
 						}
 					} else {
-						log.Printf("[config_test] fullData[%s] 不存在", typ)
+						logger.Debugf("[config_test] fullData[%s] 不存在", typ)
 					}
 				}
 				// 若请求体带了 data 且某类型有值，则用 body.Data 覆盖该类型的配置源；否则用 fullData
@@ -1213,7 +858,7 @@ func (ac *AdminController) TestConfigs(c *gin.Context) {
 						if v, ok := body.Data[typ]; ok {
 							if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
 								typeMap = m
-								log.Printf("[config_test] 使用请求体 data[%s] 作为配置源", typ)
+								logger.Debugf("[config_test] 使用请求体 data[%s] 作为配置源", typ)
 							}
 						}
 					}
@@ -1257,11 +902,11 @@ func (ac *AdminController) TestConfigs(c *gin.Context) {
 					"test_text": "配置测试",
 				}
 				// 发送前打印下发的配置摘要，便于 debug
-				log.Printf("[config_test] 发送请求 client=%s data 各类型条目数: vad=%d asr=%d llm=%d tts=%d",
+				logger.Debugf("[config_test] 发送请求 client=%s data 各类型条目数: vad=%d asr=%d llm=%d tts=%d",
 					clientUUID,
 					countSubsetKeys(subset["vad"]), countSubsetKeys(subset["asr"]),
 					countSubsetKeys(subset["llm"]), countSubsetKeys(subset["tts"]))
-				ctx, cancel := context.WithTimeout(c.Request.Context(), 25*time.Second)
+				ctx, cancel := context.WithTimeout(reqCtx, 25*time.Second)
 				defer cancel()
 				resp, err := ac.WebSocketController.SendRequestToClient(ctx, clientUUID, "POST", "/api/config/test", reqBody)
 				if err != nil {
@@ -1295,7 +940,7 @@ func (ac *AdminController) TestConfigs(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	return result
 }
 
 func contains(s []string, x string) bool {
@@ -1971,6 +1616,13 @@ func (ac *AdminController) CreateConfig(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
+	if err := prepareConfigForSave(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 检查是否已存在Memory配置
 	var existingCount int64
@@ -2003,6 +1655,9 @@ func (ac *AdminController) UpdateConfig(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在"})
 		return
 	}
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 
 	var updateData models.Config
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -2010,17 +1665,22 @@ func (ac *AdminController) UpdateConfig(c *gin.Context) {
 		return
 	}
 
-	// 如果设置为默认配置，先取消其他同类型的默认配置
-	if updateData.IsDefault {
-		ac.DB.Model(&models.Config{}).Where("type = ? AND is_default = ? AND id != ?", config.Type, true, id).Update("is_default", false)
-	}
-
 	// 更新配置
 	config.Name = updateData.Name
 	config.Provider = updateData.Provider
 	config.JsonData = updateData.JsonData
 	config.Enabled = updateData.Enabled
 	config.IsDefault = updateData.IsDefault
+	config.MatchConditions = updateData.MatchConditions
+	if err := prepareConfigForSave(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 如果设置为默认配置，先取消其他同类型的默认配置
+	if updateData.IsDefault {
+		ac.DB.Model(&models.Config{}).Where("type = ? AND is_default = ? AND id != ?", config.Type, true, id).Update("is_default", false)
+	}
 
 	if err := ac.DB.Save(&config).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置失败"})
@@ -2033,6 +1693,10 @@ func (ac *AdminController) UpdateConfig(c *gin.Context) {
 
 func (ac *AdminController) DeleteConfig(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	var config models.Config
+	if err := ac.DB.Select("id", "type").First(&config, id).Error; err == nil && rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 	if err := ac.DB.Delete(&models.Config{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除配置失败"})
 		return
@@ -2050,6 +1714,9 @@ func (ac *AdminController) SetDefaultConfig(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在"})
 		return
 	}
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 
 	// 先取消其他同类型的默认配置
 	ac.DB.Model(&models.Config{}).Where("type = ? AND is_default = ?", config.Type, true).Update("is_default", false)
@@ -2145,10 +1812,6 @@ func (ac *AdminController) GetUsers(c *gin.Context) {
 }
 
 func (ac *AdminController) CreateUser(c *gin.Context) {
-	// 添加明显的调试标记
-	log.Println("=== [CreateUser] 方法开始执行 ===")
-	log.Println("=== [CreateUser] 这是CreateUser方法的开始 ===")
-
 	// 由于User模型的Password字段使用了json:"-"标签，需要手动解析
 	var requestData struct {
 		Username string `json:"username"`
@@ -2157,14 +1820,13 @@ func (ac *AdminController) CreateUser(c *gin.Context) {
 		Role     string `json:"role"`
 	}
 
-	// 直接尝试绑定到map以查看原始数据
+	// 绑定到map后手动提取字段
 	var rawMap map[string]interface{}
 	if err := c.ShouldBindJSON(&rawMap); err != nil {
-		log.Printf("[CreateUser] 绑定到map失败: %v", err)
+		logger.Warnf("[CreateUser] 绑定到map失败: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON解析失败"})
 		return
 	}
-	log.Printf("[CreateUser] 原始JSON数据: %+v", rawMap)
 
 	// 手动提取字段
 	username, _ := rawMap["username"].(string)
@@ -2180,33 +1842,31 @@ func (ac *AdminController) CreateUser(c *gin.Context) {
 
 	// 验证必要字段
 	if requestData.Username == "" || requestData.Email == "" || requestData.Password == "" {
-		log.Printf("[CreateUser] 缺少必要字段: username=%s, email=%s, password长度=%d",
+		logger.Warnf("[CreateUser] 缺少必要字段: username=%s, email=%s, password长度=%d",
 			requestData.Username, requestData.Email, len(requestData.Password))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "用户名、邮箱和密码为必填项"})
 		return
 	}
 
-	log.Printf("[CreateUser] 接收到用户创建请求 - 用户名: %s, 邮箱: %s, 角色: %s", requestData.Username, requestData.Email, requestData.Role)
-	log.Printf("[CreateUser] 原始密码长度: %d", len(requestData.Password))
-	log.Printf("[CreateUser] 原始密码内容: %s", requestData.Password)
+	logger.Infof("[CreateUser] 接收到用户创建请求 - 用户名: %s, 邮箱: %s, 角色: %s", requestData.Username, requestData.Email, requestData.Role)
 
 	// 检查用户名是否已存在
 	var existingUser models.User
 	err := ac.DB.Where("username = ?", requestData.Username).First(&existingUser).Error
 	if err == nil {
 		// 用户名已存在
-		log.Printf("[CreateUser] 用户名 %s 已存在", requestData.Username)
+		logger.Warnf("[CreateUser] 用户名 %s 已存在", requestData.Username)
 		c.JSON(http.StatusConflict, gin.H{"error": "用户名已存在"})
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		// 数据库查询出错
-		log.Printf("[CreateUser] 数据库查询失败: %v", err)
+		logger.Errorf("[CreateUser] 数据库查询失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
 		return
 	}
 
 	// 用户不存在，创建新用户
-	log.Printf("[CreateUser] 创建新用户: %s", requestData.Username)
+	logger.Debugf("[CreateUser] 创建新用户: %s", requestData.Username)
 	var user models.User
 	user.Username = requestData.Username
 	user.Email = requestData.Email
@@ -2215,20 +1875,19 @@ func (ac *AdminController) CreateUser(c *gin.Context) {
 	// 加密密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(requestData.Password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("[CreateUser] 密码加密失败: %v", err)
+		logger.Errorf("[CreateUser] 密码加密失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
 		return
 	}
 	user.Password = string(hashedPassword)
-	log.Printf("[CreateUser] 密码加密成功 - 哈希长度: %d, 哈希前缀: %s", len(user.Password), user.Password[:10])
 
 	if err := ac.DB.Create(&user).Error; err != nil {
-		log.Printf("[CreateUser] 数据库创建用户失败: %v", err)
+		logger.Errorf("[CreateUser] 数据库创建用户失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
 		return
 	}
 
-	log.Printf("[CreateUser] 用户创建成功 - ID: %d, 用户名: %s", user.ID, user.Username)
+	logger.Infof("[CreateUser] 用户创建成功 - ID: %d, 用户名: %s", user.ID, user.Username)
 
 	// 不返回密码
 	user.Password = ""
@@ -2307,19 +1966,19 @@ func (ac *AdminController) ResetUserPassword(c *gin.Context) {
 	// 加密新密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(requestData.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("[ResetUserPassword] 密码加密失败: %v", err)
+		logger.Errorf("[ResetUserPassword] 密码加密失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
 		return
 	}
 
 	// 更新用户密码
 	if err := ac.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
-		log.Printf("[ResetUserPassword] 更新密码失败: %v", err)
+		logger.Errorf("[ResetUserPassword] 更新密码失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "重置密码失败"})
 		return
 	}
 
-	log.Printf("[ResetUserPassword] 管理员重置用户密码成功 - 用户ID: %d, 用户名: %s", user.ID, user.Username)
+	logger.Infof("[ResetUserPassword] 管理员重置用户密码成功 - 用户ID: %d, 用户名: %s", user.ID, user.Username)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "密码重置成功",
 		"data": gin.H{
@@ -2718,6 +2377,9 @@ func (ac *AdminController) UpdateDevice(c *gin.Context) {
 		DeviceName string `json:"device_name"`
 		Activated  bool   `json:"activated"`
 		AgentID    uint   `json:"agent_id"`
+		// 固件版本/区域未传则保持原值
+		FirmwareVersion *string `json:"firmware_version"`
+		Region          *string `json:"region"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -2731,6 +2393,12 @@ func (ac *AdminController) UpdateDevice(c *gin.Context) {
 	device.DeviceName = updateData.DeviceName
 	device.Activated = updateData.Activated
 	device.AgentID = updateData.AgentID
+	if updateData.FirmwareVersion != nil {
+		device.FirmwareVersion = strings.TrimSpace(*updateData.FirmwareVersion)
+	}
+	if updateData.Region != nil {
+		device.Region = strings.TrimSpace(*updateData.Region)
+	}
 
 	if err := ac.DB.Save(&device).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新设备失败"})
@@ -2746,6 +2414,9 @@ func (ac *AdminController) DeleteDevice(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除设备失败"})
 		return
 	}
+	if err := ac.DB.Where("device_id = ?", id).Delete(&models.DeviceGroupMember{}).Error; err != nil {
+		logger.Errorf("清理设备分组成员失败: device_id=%d err=%v", id, err)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
@@ -2938,6 +2609,10 @@ func (ac *AdminController) CreateAgent(c *gin.Context) {
 		return
 	}
 	agent.MCPServiceNames = normalizedMCPServiceNames
+	if err := validateTTSConfigVoiceRef(ac.DB, agent.TTSConfigID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := ac.DB.Create(&agent).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建智能体失败"})
@@ -2967,11 +2642,16 @@ func (ac *AdminController) UpdateAgent(c *gin.Context) {
 		return
 	}
 	agent.MCPServiceNames = normalizedMCPServiceNames
+	if err := validateTTSConfigVoiceRef(ac.DB, agent.TTSConfigID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := ac.DB.Save(&agent).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新智能体失败"})
 		return
 	}
+	agentMcpToolsCache.invalidate(strconv.Itoa(int(agent.ID)))
 
 	c.JSON(http.StatusOK, gin.H{"data": agent})
 }
@@ -3114,17 +2794,42 @@ func (ac *AdminController) CreateSpeakerConfig(c *gin.Context) {
 		return
 	}
 	config.Type = "voice_identify"
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 	// 声纹配置只有一个，自动设置为默认配置
 	config.IsDefault = true
-	// 如果已存在配置，先删除旧的
-	ac.DB.Where("type = ?", "voice_identify").Delete(&models.Config{})
-	ac.createConfigWithType(c, &config)
+	if config.ConfigID == "" {
+		config.ConfigID = generateConfigID(config.Type, config.Name, time.Now().Unix())
+	}
+	if err := prepareConfigForSave(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 删除旧配置与创建新配置在同一事务中完成，创建失败时保留旧配置
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("type = ?", config.Type).Delete(&models.Config{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&config).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建配置失败"})
+		return
+	}
+
+	ac.notifySystemConfigChanged()
+	c.JSON(http.StatusCreated, gin.H{"data": config})
 }
 
 func (ac *AdminController) UpdateSpeakerConfig(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	var config models.Config
 
+	if rejectReadOnlyConfigType(c, "voice_identify") {
+		return
+	}
 	if err := ac.DB.Where("id = ? AND type = ?", id, "voice_identify").First(&config).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在"})
 		return
@@ -3150,6 +2855,10 @@ func (ac *AdminController) UpdateSpeakerConfig(c *gin.Context) {
 	if updateData.ConfigID != "" {
 		config.ConfigID = updateData.ConfigID
 	}
+	if err := prepareConfigForSave(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := ac.DB.Save(&config).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置失败"})
@@ -3200,6 +2909,9 @@ func (ac *AdminController) GetVisionBaseConfig(c *gin.Context) {
 
 // UpdateVisionBaseConfig 更新Vision基础配置
 func (ac *AdminController) UpdateVisionBaseConfig(c *gin.Context) {
+	if rejectReadOnlyConfigType(c, "vision") {
+		return
+	}
 	var requestData map[string]interface{}
 	if err := c.ShouldBindJSON(&requestData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -3305,6 +3017,9 @@ func (ac *AdminController) UpdateChatSettings(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if rejectReadOnlyConfigType(c, "auth") || rejectReadOnlyConfigType(c, "chat") {
+		return
+	}
 
 	if req.Chat.MaxIdleDuration < 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "chat.max_idle_duration 不能小于 0，0 表示不限制"})
@@ -3558,6 +3273,9 @@ func (ac *AdminController) ToggleConfigEnable(c *gin.Context) {
 		}
 		return
 	}
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 
 	// 切换启用状态
 	config.Enabled = !config.Enabled
@@ -3578,14 +3296,49 @@ func (ac *AdminController) ToggleConfigEnable(c *gin.Context) {
 	})
 }
 
+// prepareConfigForSave 保存前按类型校验并规范化配置；通用接口、分类型接口与草稿提升等所有写入入口共用，失败时返回可直接展示的错误
+func prepareConfigForSave(config *models.Config) error {
+	matchConditions, err := normalizeConfigMatchConditions(config.MatchConditions)
+	if err != nil {
+		return err
+	}
+	config.MatchConditions = matchConditions
+
+	jsonData, err := normalizeConfigBaseURLs(config.Type, config.JsonData)
+	if err != nil {
+		return err
+	}
+	config.JsonData = jsonData
+
+	switch config.Type {
+	case "tts":
+		if err := validateTTSVoiceField(config.Provider, config.JsonData); err != nil {
+			return err
+		}
+	case "llm":
+		jsonData, err := normalizeLLMGatewayFields(config.JsonData)
+		if err != nil {
+			return err
+		}
+		config.JsonData = jsonData
+	}
+	return nil
+}
+
 // 辅助方法
 func (ac *AdminController) createConfigWithType(c *gin.Context, config *models.Config) {
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 	// 如果没有提供config_id，自动生成一个
 	if config.ConfigID == "" {
 		// 使用类型_名称_时间戳的格式生成唯一ID
-		timestamp := time.Now().Unix()
-		safeName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(config.Name, " ", "_"), "-", "_"))
-		config.ConfigID = fmt.Sprintf("%s_%s_%d", config.Type, safeName, timestamp)
+		config.ConfigID = generateConfigID(config.Type, config.Name, time.Now().Unix())
+	}
+
+	if err := prepareConfigForSave(config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	// 如果设置为默认配置，先取消其他同类型的默认配置
@@ -3610,26 +3363,36 @@ type configUpdateBody struct {
 	JsonData  interface{} `json:"json_data"`
 	Enabled   bool        `json:"enabled"`
 	IsDefault bool        `json:"is_default"`
+	// MatchConditions 设备匹配条件，未传则保持原值，传空字符串表示清除
+	MatchConditions *string `json:"match_conditions"`
 }
 
 func (ac *AdminController) updateConfigWithType(c *gin.Context, configType string) {
+	config, ok := ac.saveConfigUpdate(c, configType)
+	if !ok {
+		return
+	}
+	ac.notifySystemConfigChanged()
+	c.JSON(http.StatusOK, gin.H{"data": config})
+}
+
+// saveConfigUpdate 按请求体更新指定类型的配置，失败时已写入响应
+func (ac *AdminController) saveConfigUpdate(c *gin.Context, configType string) (*models.Config, bool) {
+	if rejectReadOnlyConfigType(c, configType) {
+		return nil, false
+	}
 	id, _ := strconv.Atoi(c.Param("id"))
 	var config models.Config
 
 	if err := ac.DB.Where("id = ? AND type = ?", id, configType).First(&config).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在"})
-		return
+		return nil, false
 	}
 
 	var updateData configUpdateBody
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 如果设置为默认配置，先取消其他同类型的默认配置
-	if updateData.IsDefault {
-		ac.DB.Model(&models.Config{}).Where("type = ? AND is_default = ? AND id != ?", configType, true, id).Update("is_default", false)
+		return nil, false
 	}
 
 	// 更新配置
@@ -3648,7 +3411,7 @@ func (ac *AdminController) updateConfigWithType(c *gin.Context, configType strin
 		bytes, err := json.Marshal(v)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "json_data 格式无效"})
-			return
+			return nil, false
 		}
 		config.JsonData = string(bytes)
 	}
@@ -3658,16 +3421,31 @@ func (ac *AdminController) updateConfigWithType(c *gin.Context, configType strin
 		config.ConfigID = updateData.ConfigID
 	}
 
+	if updateData.MatchConditions != nil {
+		config.MatchConditions = *updateData.MatchConditions
+	}
+
+	if err := prepareConfigForSave(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// 如果设置为默认配置，先取消其他同类型的默认配置
+	if updateData.IsDefault {
+		ac.DB.Model(&models.Config{}).Where("type = ? AND is_default = ? AND id != ?", configType, true, id).Update("is_default", false)
+	}
+
 	if err := ac.DB.Save(&config).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置失败: " + err.Error()})
-		return
+		return nil, false
 	}
-
-	ac.notifySystemConfigChanged()
-	c.JSON(http.StatusOK, gin.H{"data": config})
+	return &config, true
 }
 
 func (ac *AdminController) deleteConfigWithType(c *gin.Context, configType string) {
+	if rejectReadOnlyConfigType(c, configType) {
+		return
+	}
 	id, _ := strconv.Atoi(c.Param("id"))
 	if err := ac.DB.Where("id = ? AND type = ?", id, configType).Delete(&models.Config{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除配置失败"})
@@ -3680,6 +3458,20 @@ func (ac *AdminController) deleteConfigWithType(c *gin.Context, configType strin
 // 导入导出配置相关方法
 // ExportConfigs 导出所有配置为YAML格式
 func (ac *AdminController) ExportConfigs(c *gin.Context) {
+	yamlData, err := ac.buildConfigExportYAML()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 设置响应头
+	c.Header("Content-Type", "application/x-yaml")
+	c.Header("Content-Disposition", "attachment; filename=config.yaml")
+	c.Data(http.StatusOK, "application/x-yaml", yamlData)
+}
+
+// buildConfigExportYAML 构建完整的配置导出（含角色与声纹组），供手动导出与定时备份共用
+func (ac *AdminController) buildConfigExportYAML() ([]byte, error) {
 	// 构建导出配置结构 - 只包含实际存在的模块
 	type ExportConfig struct {
 		VAD           map[string]interface{} `yaml:"vad,omitempty"`
@@ -3697,6 +3489,9 @@ func (ac *AdminController) ExportConfigs(c *gin.Context) {
 		OTA           map[string]interface{} `yaml:"ota,omitempty"`
 		MCP           map[string]interface{} `yaml:"mcp,omitempty"`
 		LocalMCP      map[string]interface{} `yaml:"local_mcp,omitempty"`
+		GlobalRoles   []exportedGlobalRole   `yaml:"global_roles,omitempty"`
+		Roles         []exportedRole         `yaml:"roles,omitempty"`
+		SpeakerGroups []exportedSpeakerGroup `yaml:"speaker_groups,omitempty"`
 	}
 
 	exportConfig := ExportConfig{
@@ -3720,22 +3515,20 @@ func (ac *AdminController) ExportConfigs(c *gin.Context) {
 	// 获取所有配置
 	var configs []models.Config
 	if err := ac.DB.Find(&configs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get configs"})
-		return
+		return nil, fmt.Errorf("Failed to get configs: %v", err)
 	}
 
 	// 获取全局角色
 	var globalRoles []models.GlobalRole
 	if err := ac.DB.Find(&globalRoles).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get global roles"})
-		return
+		return nil, fmt.Errorf("Failed to get global roles: %v", err)
 	}
 
 	// 处理配置数据 - provider字段与is_default对应，key与ConfigID对应
 	for _, config := range configs {
 		var jsonData map[string]interface{}
 		if err := json.Unmarshal([]byte(config.JsonData), &jsonData); err != nil {
-			log.Printf("Failed to unmarshal config %s: %v", config.ConfigID, err)
+			logger.Warnf("Failed to unmarshal config %s: %v", config.ConfigID, err)
 			continue
 		}
 
@@ -3860,34 +3653,42 @@ func (ac *AdminController) ExportConfigs(c *gin.Context) {
 
 	// 只处理数据库中的实际配置，不设置默认值
 
+	// 角色与声纹组（含样本音频），用户归属以用户名表示
+	usernames, err := usernamesByID(ac.DB)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get users: %v", err)
+	}
+	exportConfig.GlobalRoles = collectExportGlobalRoles(globalRoles)
+	if exportConfig.Roles, err = collectExportRoles(ac.DB, usernames); err != nil {
+		return nil, fmt.Errorf("Failed to get roles: %v", err)
+	}
+	if exportConfig.SpeakerGroups, err = collectExportSpeakerGroups(ac.DB, usernames); err != nil {
+		return nil, fmt.Errorf("Failed to get speaker groups: %v", err)
+	}
+
 	// 转换为YAML
 	yamlData, err := yaml.Marshal(exportConfig)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal YAML"})
-		return
+		return nil, fmt.Errorf("Failed to marshal YAML: %v", err)
 	}
-
-	// 设置响应头
-	c.Header("Content-Type", "application/x-yaml")
-	c.Header("Content-Disposition", "attachment; filename=config.yaml")
-	c.Data(http.StatusOK, "application/x-yaml", yamlData)
+	return yamlData, nil
 }
 
 // ImportConfigs 从YAML文件导入配置
 func (ac *AdminController) ImportConfigs(c *gin.Context) {
-	log.Printf("开始导入配置")
+	logger.Infof("开始导入配置")
 
 	file, err := c.FormFile("file")
 	if err != nil {
-		log.Printf("获取上传文件失败: %v", err)
+		logger.Warnf("获取上传文件失败: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
 		return
 	}
 
-	log.Printf("文件信息: filename=%s, size=%d", file.Filename, file.Size)
+	logger.Debugf("文件信息: filename=%s, size=%d", file.Filename, file.Size)
 
 	if file.Size == 0 {
-		log.Printf("文件为空")
+		logger.Warnf("文件为空")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
 		return
 	}
@@ -3895,7 +3696,7 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 	// 读取文件内容
 	src, err := file.Open()
 	if err != nil {
-		log.Printf("打开文件失败: %v", err)
+		logger.Errorf("打开文件失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
 		return
 	}
@@ -3903,75 +3704,101 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 
 	content, err := io.ReadAll(src)
 	if err != nil {
-		log.Printf("读取文件内容失败: %v", err)
+		logger.Errorf("读取文件内容失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
 		return
 	}
 
-	log.Printf("文件内容长度: %d", len(content))
+	logger.Debugf("文件内容长度: %d", len(content))
 
 	// 解析YAML
 	var importConfig map[string]interface{}
 	if err := yaml.Unmarshal(content, &importConfig); err != nil {
-		log.Printf("解析YAML失败: %v", err)
+		logger.Warnf("解析YAML失败: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid YAML format"})
 		return
 	}
 
-	log.Printf("YAML解析成功，配置键: %v", getMapKeys(importConfig))
+	logger.Debugf("YAML解析成功，配置键: %v", getMapKeys(importConfig))
 
 	// 开始事务
-	log.Printf("开始数据库事务")
+	logger.Debugf("开始数据库事务")
 	tx := ac.DB.Begin()
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("发生panic，回滚事务: %v", r)
+			logger.Errorf("发生panic，回滚事务: %v", r)
 			tx.Rollback()
 		}
 	}()
 
 	// 清空现有配置
-	log.Printf("清空现有配置")
+	logger.Debugf("清空现有配置")
 	result := tx.Exec("DELETE FROM configs")
 	if result.Error != nil {
-		log.Printf("清空配置失败: %v", result.Error)
+		logger.Errorf("清空配置失败: %v", result.Error)
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear existing configs"})
 		return
 	}
-	log.Printf("配置清空成功，删除了 %d 条记录", result.RowsAffected)
+	logger.Infof("配置清空成功，删除了 %d 条记录", result.RowsAffected)
 
 	// 清空全局角色
-	log.Printf("清空全局角色")
+	logger.Debugf("清空全局角色")
 	result2 := tx.Exec("DELETE FROM global_roles")
 	if result2.Error != nil {
-		log.Printf("清空全局角色失败: %v", result2.Error)
+		logger.Errorf("清空全局角色失败: %v", result2.Error)
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear existing global roles"})
 		return
 	}
-	log.Printf("全局角色清空成功，删除了 %d 条记录", result2.RowsAffected)
+	logger.Infof("全局角色清空成功，删除了 %d 条记录", result2.RowsAffected)
+
+	// 导入角色（全局角色表已清空，按导出内容重建；角色按所属用户+名称合并）
+	var globalRoles []exportedGlobalRole
+	var roles []exportedRole
+	var speakerGroups []exportedSpeakerGroup
+	for key, out := range map[string]interface{}{"global_roles": &globalRoles, "roles": &roles, "speaker_groups": &speakerGroups} {
+		if err := decodeImportSection(importConfig, key, out); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	globalRoleStats, err := importGlobalRoles(tx, globalRoles)
+	if err != nil {
+		logger.Errorf("导入全局角色失败: %v", err)
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import global roles"})
+		return
+	}
+	roleStats, err := importRoles(tx, roles)
+	if err != nil {
+		logger.Errorf("导入角色失败: %v", err)
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import roles"})
+		return
+	}
 
 	// 导入配置 - 只处理实际存在的模块
 	configTypes := []string{"vad", "asr", "llm", "tts", "memory", "auth", "chat", "ota", "mqtt", "mqtt_server", "udp", "mcp", "local_mcp"}
-	log.Printf("开始导入配置，配置类型: %v", configTypes)
+	logger.Debugf("开始导入配置，配置类型: %v", configTypes)
 
 	// 处理 voice_identify 配置（映射到 speaker 类型）
 	if voiceIdentifyData, exists := importConfig["voice_identify"]; exists {
-		log.Printf("找到 voice_identify 配置数据")
+		logger.Debugf("找到 voice_identify 配置数据")
 		if voiceIdentifyMap, ok := voiceIdentifyData.(map[string]interface{}); ok {
-			log.Printf("voice_identify 配置 map keys: %v", getMapKeys(voiceIdentifyMap))
+			logger.Debugf("voice_identify 配置 map keys: %v", getMapKeys(voiceIdentifyMap))
 
 			// 获取provider字段
 			var defaultProvider string
 			if provider, exists := voiceIdentifyMap["provider"]; exists {
 				if providerStr, ok := provider.(string); ok {
 					defaultProvider = providerStr
-					log.Printf("voice_identify 默认provider: %s", defaultProvider)
+					logger.Debugf("voice_identify 默认provider: %s", defaultProvider)
 				}
 			}
 
-			log.Printf("voice_identify 配置项keys: %v", getMapKeys(voiceIdentifyMap))
+			logger.Debugf("voice_identify 配置项keys: %v", getMapKeys(voiceIdentifyMap))
 			// 声纹配置只有一个，优先使用provider指定的配置，否则使用第一个配置项
 			var targetConfigID string
 			if defaultProvider != "" {
@@ -3987,15 +3814,15 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 			}
 
 			if targetConfigID == "" {
-				log.Printf("voice_identify 配置中没有找到有效配置项")
+				logger.Warnf("voice_identify 配置中没有找到有效配置项")
 			} else {
 				// 只处理目标配置项
 				if configValue, exists := voiceIdentifyMap[targetConfigID]; exists {
 					if configMap, ok := configValue.(map[string]interface{}); ok {
-						log.Printf("处理voice_identify配置项: %s", targetConfigID)
+						logger.Debugf("处理voice_identify配置项: %s", targetConfigID)
 						jsonData, err := json.Marshal(configMap)
 						if err != nil {
-							log.Printf("序列化voice_identify配置数据失败: %v", err)
+							logger.Errorf("序列化voice_identify配置数据失败: %v", err)
 							tx.Rollback()
 							c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal voice_identify config data"})
 							return
@@ -4012,19 +3839,19 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 							IsDefault: true,
 						}
 
-						log.Printf("准备保存voice_identify配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
+						logger.Debugf("准备保存voice_identify配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
 
 						// 声纹配置只有一个，先删除所有旧的配置
 						tx.Where("type = ?", "voice_identify").Delete(&models.Config{})
 
 						// 创建新配置
 						if err := tx.Create(&config).Error; err != nil {
-							log.Printf("创建voice_identify配置失败: %v", err)
+							logger.Errorf("创建voice_identify配置失败: %v", err)
 							tx.Rollback()
 							c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create voice_identify config"})
 							return
 						}
-						log.Printf("voice_identify配置创建成功: %s", targetConfigID)
+						logger.Debugf("voice_identify配置创建成功: %s", targetConfigID)
 					}
 				}
 			}
@@ -4032,36 +3859,36 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 	}
 
 	for _, configType := range configTypes {
-		log.Printf("处理配置类型: %s", configType)
+		logger.Debugf("处理配置类型: %s", configType)
 		if configData, exists := importConfig[configType]; exists {
-			log.Printf("找到配置类型 %s 的数据", configType)
+			logger.Debugf("找到配置类型 %s 的数据", configType)
 			if configMap, ok := configData.(map[string]interface{}); ok {
 				// 对于需要provider的模块（vad, asr, llm, tts, memory），处理provider字段
 				if configType == "vad" || configType == "asr" || configType == "llm" || configType == "tts" || configType == "memory" || configType == "voice_identify" {
-					log.Printf("处理需要provider的配置类型: %s", configType)
+					logger.Debugf("处理需要provider的配置类型: %s", configType)
 					// 获取provider字段
 					var defaultProvider string
 					if provider, exists := configMap["provider"]; exists {
 						if providerStr, ok := provider.(string); ok {
 							defaultProvider = providerStr
-							log.Printf("默认provider: %s", defaultProvider)
+							logger.Debugf("默认provider: %s", defaultProvider)
 						}
 					}
 
-					log.Printf("配置项keys: %v", getMapKeys(configMap))
+					logger.Debugf("配置项keys: %v", getMapKeys(configMap))
 					// 遍历所有配置项
 					for configID, configValue := range configMap {
 						// 跳过provider字段
 						if configID == "provider" {
-							log.Printf("跳过provider字段")
+							logger.Debugf("跳过provider字段")
 							continue
 						}
 
 						if configMap, ok := configValue.(map[string]interface{}); ok {
-							log.Printf("处理配置项: %s", configID)
+							logger.Debugf("处理配置项: %s", configID)
 							jsonData, err := json.Marshal(configMap)
 							if err != nil {
-								log.Printf("序列化配置数据失败: %v", err)
+								logger.Errorf("序列化配置数据失败: %v", err)
 								tx.Rollback()
 								c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal config data"})
 								return
@@ -4069,7 +3896,7 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 
 							// 判断是否为默认配置
 							isDefault := (configID == defaultProvider)
-							log.Printf("配置项 %s, 是否默认: %v", configID, isDefault)
+							logger.Debugf("配置项 %s, 是否默认: %v", configID, isDefault)
 
 							config := models.Config{
 								Type:      configType,
@@ -4081,12 +3908,12 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 								IsDefault: isDefault,
 							}
 
-							log.Printf("准备保存配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
+							logger.Debugf("准备保存配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
 
 							// 先检查是否已存在相同配置
 							var existingConfig models.Config
 							if err := tx.Where("type = ? AND config_id = ?", config.Type, config.ConfigID).First(&existingConfig).Error; err == nil {
-								log.Printf("配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+								logger.Debugf("配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 								// 更新现有配置
 								existingConfig.Name = config.Name
 								existingConfig.Provider = config.Provider
@@ -4094,24 +3921,24 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 								existingConfig.Enabled = config.Enabled
 								existingConfig.IsDefault = config.IsDefault
 								if err := tx.Save(&existingConfig).Error; err != nil {
-									log.Printf("更新配置失败: %v", err)
+									logger.Errorf("更新配置失败: %v", err)
 									tx.Rollback()
 									c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update config"})
 									return
 								}
-								log.Printf("配置更新成功: %s", configID)
+								logger.Debugf("配置更新成功: %s", configID)
 							} else if err == gorm.ErrRecordNotFound {
-								log.Printf("配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+								logger.Debugf("配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 								// 创建新配置
 								if err := tx.Create(&config).Error; err != nil {
-									log.Printf("创建配置失败: %v", err)
+									logger.Errorf("创建配置失败: %v", err)
 									tx.Rollback()
 									c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create config"})
 									return
 								}
-								log.Printf("配置创建成功: %s", configID)
+								logger.Debugf("配置创建成功: %s", configID)
 							} else {
-								log.Printf("查询配置时发生错误: %v", err)
+								logger.Errorf("查询配置时发生错误: %v", err)
 								tx.Rollback()
 								c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query existing config"})
 								return
@@ -4120,10 +3947,10 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 					}
 				} else {
 					// 对于不需要provider的模块（ota, mqtt, mqtt_server, udp, mcp, local_mcp），直接创建配置
-					log.Printf("处理不需要provider的配置类型: %s", configType)
+					logger.Debugf("处理不需要provider的配置类型: %s", configType)
 					jsonData, err := json.Marshal(configMap)
 					if err != nil {
-						log.Printf("序列化配置数据失败: %v", err)
+						logger.Errorf("序列化配置数据失败: %v", err)
 						tx.Rollback()
 						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal config data"})
 						return
@@ -4139,12 +3966,12 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 						IsDefault: true,
 					}
 
-					log.Printf("准备保存配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
+					logger.Debugf("准备保存配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
 
 					// 先检查是否已存在相同配置
 					var existingConfig models.Config
 					if err := tx.Where("type = ? AND config_id = ?", config.Type, config.ConfigID).First(&existingConfig).Error; err == nil {
-						log.Printf("配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+						logger.Debugf("配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 						// 更新现有配置
 						existingConfig.Name = config.Name
 						existingConfig.Provider = config.Provider
@@ -4152,24 +3979,24 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 						existingConfig.Enabled = config.Enabled
 						existingConfig.IsDefault = config.IsDefault
 						if err := tx.Save(&existingConfig).Error; err != nil {
-							log.Printf("更新配置失败: %v", err)
+							logger.Errorf("更新配置失败: %v", err)
 							tx.Rollback()
 							c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update config"})
 							return
 						}
-						log.Printf("配置更新成功: %s", configType)
+						logger.Debugf("配置更新成功: %s", configType)
 					} else if err == gorm.ErrRecordNotFound {
-						log.Printf("配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+						logger.Debugf("配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 						// 创建新配置
 						if err := tx.Create(&config).Error; err != nil {
-							log.Printf("创建配置失败: %v", err)
+							logger.Errorf("创建配置失败: %v", err)
 							tx.Rollback()
 							c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create config"})
 							return
 						}
-						log.Printf("配置创建成功: %s", configType)
+						logger.Debugf("配置创建成功: %s", configType)
 					} else {
-						log.Printf("查询配置时发生错误: %v", err)
+						logger.Errorf("查询配置时发生错误: %v", err)
 						tx.Rollback()
 						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query existing config"})
 						return
@@ -4180,11 +4007,11 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 	}
 
 	// 特殊处理vision配置
-	log.Printf("开始处理vision配置")
+	logger.Debugf("开始处理vision配置")
 	if visionData, exists := importConfig["vision"]; exists {
-		log.Printf("找到vision配置数据")
+		logger.Debugf("找到vision配置数据")
 		if visionMap, ok := visionData.(map[string]interface{}); ok {
-			log.Printf("vision配置map keys: %v", getMapKeys(visionMap))
+			logger.Debugf("vision配置map keys: %v", getMapKeys(visionMap))
 
 			// 处理vision的基础配置（enable_auth, vision_url等）
 			baseVisionConfig := make(map[string]interface{})
@@ -4198,7 +4025,7 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 			if len(baseVisionConfig) > 0 {
 				jsonData, err := json.Marshal(baseVisionConfig)
 				if err != nil {
-					log.Printf("序列化vision基础配置数据失败: %v", err)
+					logger.Errorf("序列化vision基础配置数据失败: %v", err)
 					tx.Rollback()
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal vision base config data"})
 					return
@@ -4214,12 +4041,12 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 					IsDefault: false,
 				}
 
-				log.Printf("准备保存vision基础配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
+				logger.Debugf("准备保存vision基础配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
 
 				// 先检查是否已存在相同配置
 				var existingConfig models.Config
 				if err := tx.Where("type = ? AND config_id = ?", config.Type, config.ConfigID).First(&existingConfig).Error; err == nil {
-					log.Printf("vision基础配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+					logger.Debugf("vision基础配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 					// 更新现有配置
 					existingConfig.Name = config.Name
 					existingConfig.Provider = config.Provider
@@ -4227,24 +4054,24 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 					existingConfig.Enabled = config.Enabled
 					existingConfig.IsDefault = config.IsDefault
 					if err := tx.Save(&existingConfig).Error; err != nil {
-						log.Printf("更新vision基础配置失败: %v", err)
+						logger.Errorf("更新vision基础配置失败: %v", err)
 						tx.Rollback()
 						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vision base config"})
 						return
 					}
-					log.Printf("vision基础配置更新成功")
+					logger.Debugf("vision基础配置更新成功")
 				} else if err == gorm.ErrRecordNotFound {
-					log.Printf("vision基础配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+					logger.Debugf("vision基础配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 					// 创建新配置
 					if err := tx.Create(&config).Error; err != nil {
-						log.Printf("创建vision基础配置失败: %v", err)
+						logger.Errorf("创建vision基础配置失败: %v", err)
 						tx.Rollback()
 						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vision base config"})
 						return
 					}
-					log.Printf("vision基础配置创建成功")
+					logger.Debugf("vision基础配置创建成功")
 				} else {
-					log.Printf("查询vision基础配置时发生错误: %v", err)
+					logger.Errorf("查询vision基础配置时发生错误: %v", err)
 					tx.Rollback()
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query existing vision base config"})
 					return
@@ -4253,33 +4080,33 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 
 			// 处理vllm配置
 			if vllmData, exists := visionMap["vllm"]; exists {
-				log.Printf("找到vllm配置数据")
+				logger.Debugf("找到vllm配置数据")
 				if vllmMap, ok := vllmData.(map[string]interface{}); ok {
-					log.Printf("vllm配置map keys: %v", getMapKeys(vllmMap))
+					logger.Debugf("vllm配置map keys: %v", getMapKeys(vllmMap))
 
 					// 获取vllm的provider字段
 					var defaultProvider string
 					if provider, exists := vllmMap["provider"]; exists {
 						if providerStr, ok := provider.(string); ok {
 							defaultProvider = providerStr
-							log.Printf("vllm默认provider: %s", defaultProvider)
+							logger.Debugf("vllm默认provider: %s", defaultProvider)
 						}
 					}
 
-					log.Printf("vllm配置项keys: %v", getMapKeys(vllmMap))
+					logger.Debugf("vllm配置项keys: %v", getMapKeys(vllmMap))
 					// 遍历所有vllm配置项
 					for configID, configValue := range vllmMap {
 						// 跳过provider字段
 						if configID == "provider" {
-							log.Printf("跳过vllm provider字段")
+							logger.Debugf("跳过vllm provider字段")
 							continue
 						}
 
 						if configMap, ok := configValue.(map[string]interface{}); ok {
-							log.Printf("处理vllm配置项: %s", configID)
+							logger.Debugf("处理vllm配置项: %s", configID)
 							jsonData, err := json.Marshal(configMap)
 							if err != nil {
-								log.Printf("序列化vllm配置数据失败: %v", err)
+								logger.Errorf("序列化vllm配置数据失败: %v", err)
 								tx.Rollback()
 								c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal vllm config data"})
 								return
@@ -4287,7 +4114,7 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 
 							// 判断是否为默认配置
 							isDefault := (configID == defaultProvider)
-							log.Printf("vllm配置项 %s, 是否默认: %v", configID, isDefault)
+							logger.Debugf("vllm配置项 %s, 是否默认: %v", configID, isDefault)
 
 							config := models.Config{
 								Type:      "vision",
@@ -4299,12 +4126,12 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 								IsDefault: isDefault,
 							}
 
-							log.Printf("准备保存vllm配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
+							logger.Debugf("准备保存vllm配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
 
 							// 先检查是否已存在相同配置
 							var existingConfig models.Config
 							if err := tx.Where("type = ? AND config_id = ?", config.Type, config.ConfigID).First(&existingConfig).Error; err == nil {
-								log.Printf("vllm配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+								logger.Debugf("vllm配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 								// 更新现有配置
 								existingConfig.Name = config.Name
 								existingConfig.Provider = config.Provider
@@ -4312,24 +4139,24 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 								existingConfig.Enabled = config.Enabled
 								existingConfig.IsDefault = config.IsDefault
 								if err := tx.Save(&existingConfig).Error; err != nil {
-									log.Printf("更新vllm配置失败: %v", err)
+									logger.Errorf("更新vllm配置失败: %v", err)
 									tx.Rollback()
 									c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vllm config"})
 									return
 								}
-								log.Printf("vllm配置更新成功: %s", configID)
+								logger.Debugf("vllm配置更新成功: %s", configID)
 							} else if err == gorm.ErrRecordNotFound {
-								log.Printf("vllm配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+								logger.Debugf("vllm配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 								// 创建新配置
 								if err := tx.Create(&config).Error; err != nil {
-									log.Printf("创建vllm配置失败: %v", err)
+									logger.Errorf("创建vllm配置失败: %v", err)
 									tx.Rollback()
 									c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vllm config"})
 									return
 								}
-								log.Printf("vllm配置创建成功: %s", configID)
+								logger.Debugf("vllm配置创建成功: %s", configID)
 							} else {
-								log.Printf("查询vllm配置时发生错误: %v", err)
+								logger.Errorf("查询vllm配置时发生错误: %v", err)
 								tx.Rollback()
 								c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query existing vllm config"})
 								return
@@ -4342,15 +4169,15 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 	}
 
 	// 特殊处理local_mcp配置
-	log.Printf("开始处理local_mcp配置")
+	logger.Debugf("开始处理local_mcp配置")
 	if localMcpData, exists := importConfig["local_mcp"]; exists {
-		log.Printf("找到local_mcp配置数据")
+		logger.Debugf("找到local_mcp配置数据")
 		if localMcpMap, ok := localMcpData.(map[string]interface{}); ok {
-			log.Printf("local_mcp配置map keys: %v", getMapKeys(localMcpMap))
+			logger.Debugf("local_mcp配置map keys: %v", getMapKeys(localMcpMap))
 
 			jsonData, err := json.Marshal(localMcpMap)
 			if err != nil {
-				log.Printf("序列化local_mcp配置数据失败: %v", err)
+				logger.Errorf("序列化local_mcp配置数据失败: %v", err)
 				tx.Rollback()
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal local_mcp config data"})
 				return
@@ -4366,12 +4193,12 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 				IsDefault: true,
 			}
 
-			log.Printf("准备保存local_mcp配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
+			logger.Debugf("准备保存local_mcp配置: Type=%s, Name=%s, ConfigID=%s", config.Type, config.Name, config.ConfigID)
 
 			// 先检查是否已存在相同配置
 			var existingConfig models.Config
 			if err := tx.Where("type = ? AND config_id = ?", config.Type, config.ConfigID).First(&existingConfig).Error; err == nil {
-				log.Printf("local_mcp配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+				logger.Debugf("local_mcp配置已存在，将更新: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 				// 更新现有配置
 				existingConfig.Name = config.Name
 				existingConfig.Provider = config.Provider
@@ -4379,24 +4206,24 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 				existingConfig.Enabled = config.Enabled
 				existingConfig.IsDefault = config.IsDefault
 				if err := tx.Save(&existingConfig).Error; err != nil {
-					log.Printf("更新local_mcp配置失败: %v", err)
+					logger.Errorf("更新local_mcp配置失败: %v", err)
 					tx.Rollback()
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update local_mcp config"})
 					return
 				}
-				log.Printf("local_mcp配置更新成功")
+				logger.Debugf("local_mcp配置更新成功")
 			} else if err == gorm.ErrRecordNotFound {
-				log.Printf("local_mcp配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
+				logger.Debugf("local_mcp配置不存在，将创建新配置: Type=%s, ConfigID=%s", config.Type, config.ConfigID)
 				// 创建新配置
 				if err := tx.Create(&config).Error; err != nil {
-					log.Printf("创建local_mcp配置失败: %v", err)
+					logger.Errorf("创建local_mcp配置失败: %v", err)
 					tx.Rollback()
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create local_mcp config"})
 					return
 				}
-				log.Printf("local_mcp配置创建成功")
+				logger.Debugf("local_mcp配置创建成功")
 			} else {
-				log.Printf("查询local_mcp配置时发生错误: %v", err)
+				logger.Errorf("查询local_mcp配置时发生错误: %v", err)
 				tx.Rollback()
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query existing local_mcp config"})
 				return
@@ -4405,15 +4232,23 @@ func (ac *AdminController) ImportConfigs(c *gin.Context) {
 	}
 
 	// 提交事务
-	log.Printf("提交事务")
+	logger.Debugf("提交事务")
 	if err := tx.Commit().Error; err != nil {
-		log.Printf("提交事务失败: %v", err)
+		logger.Errorf("提交事务失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
 		return
 	}
 
-	log.Printf("配置导入成功")
-	c.JSON(http.StatusOK, gin.H{"message": "Configuration imported successfully"})
+	// 声纹样本需写文件并注册到声纹服务，在事务提交后导入
+	speakerGroupStats := ac.importSpeakerGroups(speakerGroups)
+	ac.notifySystemConfigChanged()
+
+	logger.Infof("配置导入成功")
+	c.JSON(http.StatusOK, gin.H{"message": "Configuration imported successfully", "data": gin.H{
+		"global_roles":   globalRoleStats,
+		"roles":          roleStats,
+		"speaker_groups": speakerGroupStats,
+	}})
 }
 
 // MCP配置相关方法
@@ -4434,6 +4269,9 @@ func (ac *AdminController) CreateMCPConfig(c *gin.Context) {
 	}
 
 	config.Type = "mcp"
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 
 	// 如果设置为默认配置，先取消其他同类型的默认配置
 	if config.IsDefault {
@@ -4456,6 +4294,9 @@ func (ac *AdminController) UpdateMCPConfig(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "MCP配置不存在"})
 		return
 	}
+	if rejectReadOnlyConfigType(c, config.Type) || rejectReadOnlyConfigType(c, "mcp") {
+		return
+	}
 
 	var updateData models.Config
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -4485,6 +4326,9 @@ func (ac *AdminController) DeleteMCPConfig(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "MCP配置不存在"})
 		return
 	}
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 
 	if err := ac.DB.Delete(&config).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除MCP配置失败"})
@@ -4563,12 +4407,19 @@ func (ac *AdminController) CreateMemoryConfig(c *gin.Context) {
 
 	// 设置配置类型为memory
 	config.Type = "memory"
+	if rejectReadOnlyConfigType(c, config.Type) {
+		return
+	}
 
 	// 验证provider字段
 	if config.Provider != "memobase" && config.Provider != "mem0" && config.Provider != "memos" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Provider必须是memobase、mem0或memos"})
 		return
 	}
+	if err := prepareConfigForSave(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 如果设置为默认配置，先取消其他同类型的默认配置
 	if config.IsDefault {
@@ -4584,6 +4435,9 @@ func (ac *AdminController) CreateMemoryConfig(c *gin.Context) {
 }
 
 func (ac *AdminController) UpdateMemoryConfig(c *gin.Context) {
+	if rejectReadOnlyConfigType(c, "memory") {
+		return
+	}
 	id, _ := strconv.Atoi(c.Param("id"))
 	var config models.Config
 
@@ -4604,17 +4458,21 @@ func (ac *AdminController) UpdateMemoryConfig(c *gin.Context) {
 		return
 	}
 
-	// 如果设置为默认配置，先取消其他同类型的默认配置
-	if updateData.IsDefault {
-		ac.DB.Model(&models.Config{}).Where("type = ? AND is_default = ? AND id != ?", config.Type, true, id).Update("is_default", false)
-	}
-
 	// 更新配置
 	config.Name = updateData.Name
 	config.Provider = updateData.Provider
 	config.JsonData = updateData.JsonData
 	config.Enabled = updateData.Enabled
 	config.IsDefault = updateData.IsDefault
+	if err := prepareConfigForSave(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 如果设置为默认配置，先取消其他同类型的默认配置
+	if updateData.IsDefault {
+		ac.DB.Model(&models.Config{}).Where("type = ? AND is_default = ? AND id != ?", config.Type, true, id).Update("is_default", false)
+	}
 
 	if err := ac.DB.Save(&config).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新Memory配置失败"})
@@ -4625,6 +4483,9 @@ func (ac *AdminController) UpdateMemoryConfig(c *gin.Context) {
 }
 
 func (ac *AdminController) DeleteMemoryConfig(c *gin.Context) {
+	if rejectReadOnlyConfigType(c, "memory") {
+		return
+	}
 	id, _ := strconv.Atoi(c.Param("id"))
 	if err := ac.DB.Where("id = ? AND type = ?", id, "memory").Delete(&models.Config{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除Memory配置失败"})
@@ -4635,6 +4496,9 @@ func (ac *AdminController) DeleteMemoryConfig(c *gin.Context) {
 
 // 设置默认Memory配置
 func (ac *AdminController) SetDefaultMemoryConfig(c *gin.Context) {
+	if rejectReadOnlyConfigType(c, "memory") {
+		return
+	}
 	id, _ := strconv.Atoi(c.Param("id"))
 	var config models.Config
 
@@ -4836,6 +4700,10 @@ func (ac *AdminController) CreateRoleNew(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "角色状态无效"})
 		return
 	}
+	if err := validateTTSConfigVoiceRef(ac.DB, role.TTSConfigID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 如果设置为默认角色，先取消其他默认角色
 	if role.IsDefault && role.RoleType == "global" {
@@ -4888,6 +4756,10 @@ func (ac *AdminController) UpdateRoleNew(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateTTSConfigVoiceRef(ac.DB, updateData.TTSConfigID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 如果设置为默认角色，先取消其他默认角色
 	if updateData.IsDefault && role.RoleType == "global" {
@@ -4900,6 +4772,7 @@ func (ac *AdminController) UpdateRoleNew(c *gin.Context) {
 	role.Name = updateData.Name
 	role.Description = updateData.Description
 	role.Prompt = updateData.Prompt
+	role.Greeting = updateData.Greeting
 	role.LLMConfigID = updateData.LLMConfigID
 	role.TTSConfigID = updateData.TTSConfigID
 	role.Voice = updateData.Voice
@@ -5194,7 +5067,7 @@ func (ac *AdminController) ApplyRoleToDevice(c *gin.Context) {
 		}
 		if role.Status == "" {
 			if err := ac.DB.Model(&role).Update("status", roleStatus).Error; err != nil {
-				log.Printf("更新角色默认状态失败: role_id=%d err=%v", role.ID, err)
+				logger.Errorf("更新角色默认状态失败: role_id=%d err=%v", role.ID, err)
 			}
 		}
 
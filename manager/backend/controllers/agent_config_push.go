@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 智能体配置批量下发：修改智能体提示词/音色后，通知服务其设备的主程序重新加载设备配置，无需重启设备
+
+const (
+	// deviceConfigReloadPath 主程序侧处理设备配置刷新的请求路径
+	deviceConfigReloadPath       = "/api/device/config_reload"
+	agentConfigPushDeviceTimeout = 10 * time.Second
+	agentConfigPushConcurrency   = 8
+)
+
+const (
+	agentConfigPushDelivered = "delivered"
+	agentConfigPushOffline   = "offline"
+	agentConfigPushFailed    = "failed"
+)
+
+// errDeviceNotServed 设备当前未连接任何主程序
+var errDeviceNotServed = errors.New("设备未在线")
+
+// agentConfigPushDevice 单个设备的下发结果
+type agentConfigPushDevice struct {
+	DeviceID   uint              `json:"device_id"`
+	DeviceName string            `json:"device_name"`
+	Status     string            `json:"status"` // delivered/offline/failed
+	Client     string            `json:"client,omitempty"`
+	ConfigIDs  map[string]string `json:"config_ids,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type agentConfigPushResult struct {
+	AgentID   uint                    `json:"agent_id"`
+	Total     int                     `json:"total"`
+	Delivered int                     `json:"delivered"`
+	Offline   int                     `json:"offline"`
+	Failed    int                     `json:"failed"`
+	Devices   []agentConfigPushDevice `json:"devices"`
+}
+
+// deviceConfigDeliverer 将配置刷新请求发送给服务该设备的主程序，返回主程序连接ID；设备不在线时返回 errDeviceNotServed
+type deviceConfigDeliverer func(ctx context.Context, deviceName string, body map[string]interface{}) (string, error)
+
+// clientServingDevice 返回当前服务该设备的主程序连接
+func (ctrl *WebSocketController) clientServingDevice(deviceName string) *WebSocketClient {
+	target := map[string]struct{}{deviceName: {}}
+	for item := range ctrl.clientsMap.IterBuffered() {
+		client := item.Val
+		if client.isConnected && len(client.servedDevices(target)) > 0 {
+			return client
+		}
+	}
+	return nil
+}
+
+// deliverDeviceConfig 通过 WebSocket 控制通道请求主程序重新加载设备配置
+func (ctrl *WebSocketController) deliverDeviceConfig(ctx context.Context, deviceName string, body map[string]interface{}) (string, error) {
+	client := ctrl.clientServingDevice(deviceName)
+	if client == nil {
+		return "", errDeviceNotServed
+	}
+	resp, err := client.SendRequestWithResponse(ctx, "POST", deviceConfigReloadPath, body)
+	if err != nil {
+		return client.ID, err
+	}
+	if resp.Status >= 400 {
+		if resp.Error != "" {
+			return client.ID, errors.New(resp.Error)
+		}
+		return client.ID, fmt.Errorf("主程序返回状态码 %d", resp.Status)
+	}
+	return client.ID, nil
+}
+
+// PushConfigToAgentDevices 解析智能体下每个设备的最新配置，并下发给服务这些设备的主程序，返回逐设备的下发状态
+func (ac *AdminController) PushConfigToAgentDevices(ctx context.Context, agentID uint) (*agentConfigPushResult, error) {
+	if ac.WebSocketController == nil {
+		return nil, fmt.Errorf("WebSocket 服务未初始化")
+	}
+	return ac.pushAgentDeviceConfigs(ctx, agentID, ac.WebSocketController.deliverDeviceConfig)
+}
+
+func (ac *AdminController) pushAgentDeviceConfigs(ctx context.Context, agentID uint, deliver deviceConfigDeliverer) (*agentConfigPushResult, error) {
+	var devices []models.Device
+	if err := ac.DB.Where("agent_id = ? AND device_name <> ''", agentID).Order("id ASC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("查询智能体设备失败: %v", err)
+	}
+
+	result := &agentConfigPushResult{AgentID: agentID, Total: len(devices), Devices: make([]agentConfigPushDevice, len(devices))}
+	sem := make(chan struct{}, agentConfigPushConcurrency)
+	var wg sync.WaitGroup
+	for i := range devices {
+		device := devices[i]
+		item := &result.Devices[i]
+		item.DeviceID = device.ID
+		item.DeviceName = device.DeviceName
+
+		response, _, err := ac.resolveDeviceConfig(device, true, deviceConfigWhatIf{})
+		if err != nil {
+			item.Status = agentConfigPushFailed
+			item.Error = "解析设备配置失败: " + err.Error()
+			continue
+		}
+		item.ConfigIDs = map[string]string{
+			"vad": response.VAD.ConfigID,
+			"asr": response.ASR.ConfigID,
+			"llm": response.LLM.ConfigID,
+			"tts": response.TTS.ConfigID,
+		}
+		body := map[string]interface{}{
+			"device_id":     device.DeviceName,
+			"agent_id":      response.AgentID,
+			"config_source": response.ConfigSource,
+			"config_ids":    item.ConfigIDs,
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			deviceCtx, cancel := context.WithTimeout(ctx, agentConfigPushDeviceTimeout)
+			defer cancel()
+			clientID, err := deliver(deviceCtx, device.DeviceName, body)
+			item.Client = clientID
+			switch {
+			case errors.Is(err, errDeviceNotServed):
+				item.Status = agentConfigPushOffline
+			case err != nil:
+				item.Status = agentConfigPushFailed
+				item.Error = err.Error()
+			default:
+				item.Status = agentConfigPushDelivered
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, item := range result.Devices {
+		switch item.Status {
+		case agentConfigPushDelivered:
+			result.Delivered++
+		case agentConfigPushOffline:
+			result.Offline++
+		default:
+			result.Failed++
+		}
+	}
+	logger.Infof("[AgentConfigPush] agent_id=%d total=%d delivered=%d offline=%d failed=%d", agentID, result.Total, result.Delivered, result.Offline, result.Failed)
+	return result, nil
+}
+
+// PushAgentDeviceConfigs 向智能体下所有在线设备下发最新配置
+// POST /api/admin/agents/:id/push-config
+func (ac *AdminController) PushAgentDeviceConfigs(c *gin.Context) {
+	agentID, _ := strconv.Atoi(c.Param("id"))
+	if agentID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的智能体ID"})
+		return
+	}
+	if ac.WebSocketController == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "WebSocket 服务未初始化"})
+		return
+	}
+	var agent models.Agent
+	if err := ac.DB.First(&agent, agentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "智能体不存在"})
+		return
+	}
+	result, err := ac.PushConfigToAgentDevices(c.Request.Context(), agent.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestPushAgentDeviceConfigs(t *testing.T) {
+	db := newTestDB(t, &models.Config{}, &models.Device{}, &models.Agent{}, &models.Role{},
+		&models.SpeakerGroup{}, &models.SpeakerSample{}, &models.AgentKnowledgeBase{},
+		&models.KnowledgeBase{}, &models.KnowledgeBaseDocument{}, &models.VoiceClone{})
+	for _, cfg := range []models.Config{
+		{Type: "vad", Name: "vad", ConfigID: "vad-default", Provider: "silero_vad", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "asr", Name: "asr", ConfigID: "asr-default", Provider: "funasr", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "llm", Name: "llm", ConfigID: "llm-default", Provider: "openai", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "tts", Name: "tts", ConfigID: "tts-default", Provider: "edge", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "tts", Name: "tts2", ConfigID: "tts-agent", Provider: "edge", JsonData: `{}`, Enabled: true},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	ttsID := "tts-agent"
+	agent := models.Agent{UserID: 1, Name: "小智", TTSConfigID: &ttsID}
+	other := models.Agent{UserID: 1, Name: "其他"}
+	if err := db.Create(&agent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, d := range []models.Device{
+		{UserID: 1, AgentID: agent.ID, DeviceName: "online", DeviceCode: "1"},
+		{UserID: 1, AgentID: agent.ID, DeviceName: "offline", DeviceCode: "2"},
+		{UserID: 1, AgentID: agent.ID, DeviceName: "broken", DeviceCode: "3"},
+		{UserID: 1, AgentID: other.ID, DeviceName: "unrelated", DeviceCode: "4"},
+	} {
+		if err := db.Create(&d).Error; err != nil {
+			t.Fatalf("device %d: %v", i, err)
+		}
+	}
+
+	var mu sync.Mutex
+	bodies := make(map[string]map[string]interface{})
+	deliver := func(ctx context.Context, deviceName string, body map[string]interface{}) (string, error) {
+		mu.Lock()
+		bodies[deviceName] = body
+		mu.Unlock()
+		switch deviceName {
+		case "online":
+			return "server-1", nil
+		case "broken":
+			return "server-1", errors.New("device broken not found or offline")
+		default:
+			return "", errDeviceNotServed
+		}
+	}
+
+	ac := &AdminController{DB: db}
+	result, err := ac.pushAgentDeviceConfigs(context.Background(), agent.ID, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || result.Delivered != 1 || result.Offline != 1 || result.Failed != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if _, ok := bodies["unrelated"]; ok {
+		t.Fatal("devices of other agents must not be pushed")
+	}
+	got := map[string]agentConfigPushDevice{}
+	for _, d := range result.Devices {
+		got[d.DeviceName] = d
+	}
+	if d := got["online"]; d.Status != agentConfigPushDelivered || d.Client != "server-1" || d.ConfigIDs["tts"] != "tts-agent" || d.ConfigIDs["llm"] != "llm-default" {
+		t.Fatalf("online = %+v", d)
+	}
+	if d := got["offline"]; d.Status != agentConfigPushOffline || d.Error != "" {
+		t.Fatalf("offline = %+v", d)
+	}
+	if d := got["broken"]; d.Status != agentConfigPushFailed || d.Error == "" {
+		t.Fatalf("broken = %+v", d)
+	}
+	if body := bodies["online"]; body["device_id"] != "online" || body["config_source"] != "agent_config" {
+		t.Fatalf("body = %v", body)
+	}
+}
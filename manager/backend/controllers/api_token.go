@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/middleware"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// 每个用户最多持有的有效令牌数
+	maxAPITokensPerUser = 20
+	// last_used_at 更新的最小间隔，避免每次请求都写库
+	apiTokenLastUsedInterval = time.Minute
+)
+
+// generateAPIToken 生成明文令牌：前缀 + 32 字节随机数
+func generateAPIToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return middleware.APITokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// normalizeAPITokenScopes 校验并去重权限范围
+func normalizeAPITokenScopes(scopes []string) ([]string, error) {
+	allowed := make(map[string]bool, len(middleware.APITokenScopes))
+	for _, s := range middleware.APITokenScopes {
+		allowed[s] = true
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		if !allowed[s] {
+			return nil, fmt.Errorf("不支持的权限范围: %s（可选: %s）", s, strings.Join(middleware.APITokenScopes, ", "))
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("至少需要一个权限范围")
+	}
+	return out, nil
+}
+
+// NewAPITokenResolver 基于数据库校验 API 令牌：未吊销、未过期且用户存在，并节流更新 last_used_at
+func NewAPITokenResolver(db *gorm.DB) func(token string) (*middleware.APITokenIdentity, error) {
+	return func(token string) (*middleware.APITokenIdentity, error) {
+		var record models.APIToken
+		if err := db.Where("token_hash = ?", sha256Hex([]byte(token))).First(&record).Error; err != nil {
+			return nil, errors.New("令牌不存在")
+		}
+		now := time.Now()
+		if record.RevokedAt != nil {
+			return nil, errors.New("令牌已吊销")
+		}
+		if record.ExpiresAt != nil && now.After(*record.ExpiresAt) {
+			return nil, errors.New("令牌已过期")
+		}
+		var user models.User
+		if err := db.Select("id", "username", "role").Where("id = ?", record.UserID).First(&user).Error; err != nil {
+			return nil, errors.New("令牌所属用户不存在")
+		}
+
+		if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= apiTokenLastUsedInterval {
+			if err := db.Model(&models.APIToken{}).Where("id = ?", record.ID).Update("last_used_at", now).Error; err != nil {
+				logger.Warnf("[APIToken] 更新最后使用时间失败 token_id=%d err=%v", record.ID, err)
+			}
+		}
+
+		var scopes []string
+		if record.Scopes != "" {
+			scopes = strings.Split(record.Scopes, ",")
+		}
+		return &middleware.APITokenIdentity{
+			TokenID:  record.ID,
+			UserID:   user.ID,
+			Username: user.Username,
+			Role:     user.Role,
+			Scopes:   scopes,
+		}, nil
+	}
+}
+
+// GetAPITokens 列出当前用户的 API 令牌（不含明文）
+func (uc *UserController) GetAPITokens(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var tokens []models.APIToken
+	if err := uc.DB.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取API令牌失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tokens, "available_scopes": middleware.APITokenScopes})
+}
+
+// CreateAPIToken 创建 API 令牌，明文仅在响应中返回一次
+func (uc *UserController) CreateAPIToken(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req struct {
+		Name          string   `json:"name" binding:"required"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays int      `json:"expires_in_days"` // <=0 表示不过期
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "名称不能为空"})
+		return
+	}
+	scopes, err := normalizeAPITokenScopes(req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var active int64
+	uc.DB.Model(&models.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&active)
+	if active >= maxAPITokensPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("有效令牌数已达上限 %d，请先吊销不再使用的令牌", maxAPITokensPerUser)})
+		return
+	}
+
+	raw, err := generateAPIToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败"})
+		return
+	}
+	token := models.APIToken{
+		UserID:    userID.(uint),
+		Name:      name,
+		TokenHash: sha256Hex([]byte(raw)),
+		Prefix:    raw[:len(middleware.APITokenPrefix)+6],
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := uc.DB.Create(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建API令牌失败"})
+		return
+	}
+	logger.Infof("[APIToken] 创建令牌 user_id=%d token_id=%d scopes=%s", token.UserID, token.ID, token.Scopes)
+	c.JSON(http.StatusCreated, gin.H{"data": token, "token": raw, "message": "令牌仅显示一次，请妥善保存"})
+}
+
+// RevokeAPIToken 吊销 API 令牌，记录保留用于审计
+func (uc *UserController) RevokeAPIToken(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的令牌ID"})
+		return
+	}
+	var token models.APIToken
+	if err := uc.DB.Where("id = ? AND user_id = ?", id, userID).First(&token).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "令牌不存在"})
+		return
+	}
+	if token.RevokedAt == nil {
+		now := time.Now()
+		if err := uc.DB.Model(&token).Update("revoked_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销令牌失败"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "令牌已吊销", "data": token})
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"xiaozhi/manager/backend/middleware"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPITokenAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.User{}, &models.APIToken{})
+	user := models.User{Username: "ci", Password: "x", Role: "user"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	raw, err := generateAPIToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := models.APIToken{UserID: user.ID, Name: "ci", TokenHash: sha256Hex([]byte(raw)), Scopes: "devices:write,configs:read"}
+	if err := db.Create(&token).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	middleware.SetAPITokenResolver(NewAPITokenResolver(db))
+	defer middleware.SetAPITokenResolver(nil)
+	r := gin.New()
+	auth := r.Group("/api", middleware.JWTAuth())
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"user_id": c.GetUint("user_id")}) }
+	auth.GET("/user/devices", ok)
+	auth.POST("/admin/llm-configs", ok)
+	auth.GET("/admin/llm-configs", ok)
+	auth.GET("/user/api-tokens", ok)
+
+	do := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+raw)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// devices:write 隐含 devices:read
+	if code := do(http.MethodGet, "/api/user/devices"); code != http.StatusOK {
+		t.Fatalf("devices read = %d", code)
+	}
+	if code := do(http.MethodGet, "/api/admin/llm-configs"); code != http.StatusOK {
+		t.Fatalf("configs read = %d", code)
+	}
+	if code := do(http.MethodPost, "/api/admin/llm-configs"); code != http.StatusForbidden {
+		t.Fatalf("configs write without scope = %d", code)
+	}
+	// 令牌管理接口不接受 API 令牌
+	if code := do(http.MethodGet, "/api/user/api-tokens"); code != http.StatusForbidden {
+		t.Fatalf("token management = %d", code)
+	}
+
+	var used models.APIToken
+	db.First(&used, token.ID)
+	if used.LastUsedAt == nil {
+		t.Fatal("last_used_at not recorded")
+	}
+
+	now := time.Now()
+	db.Model(&used).Update("revoked_at", now)
+	if code := do(http.MethodGet, "/api/user/devices"); code != http.StatusUnauthorized {
+		t.Fatalf("revoked token = %d", code)
+	}
+}
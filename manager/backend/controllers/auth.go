@@ -1,8 +1,8 @@
 package controllers
 
 import (
-	"log"
 	"net/http"
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/middleware"
 	"xiaozhi/manager/backend/models"
 
@@ -35,28 +35,26 @@ func (ac *AuthController) Login(c *gin.Context) {
 	}
 
 	// 添加登录调试日志
-	log.Printf("[Login] 尝试登录用户: %s, 客户端IP: %s", req.Username, c.ClientIP())
-	log.Printf("[Login] 接收到的密码长度: %d", len(req.Password))
+	logger.Debugf("[Login] 尝试登录用户: %s, 客户端IP: %s", req.Username, c.ClientIP())
 
 	// 如果数据库可用，尝试从数据库验证
 	if ac.DB != nil {
-		log.Printf("[Login] 数据库连接可用，开始数据库验证")
+		logger.Debugf("[Login] 数据库连接可用，开始数据库验证")
 		var user models.User
 		if err := ac.DB.Where("username = ?", req.Username).First(&user).Error; err == nil {
-			log.Printf("[Login] 找到用户: ID=%d, Username=%s, Role=%s, Email=%s", user.ID, user.Username, user.Role, user.Email)
-			log.Printf("[Login] 数据库中密码哈希长度: %d, 哈希前缀: %s", len(user.Password), user.Password[:10])
-			log.Printf("[Login] 开始bcrypt密码比较验证")
+			logger.Debugf("[Login] 找到用户: ID=%d, Username=%s, Role=%s, Email=%s", user.ID, user.Username, user.Role, user.Email)
+			logger.Debugf("[Login] 开始bcrypt密码比较验证")
 			
 			if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err == nil {
-				log.Printf("[Login] ✅ 密码验证成功 - 用户: %s", req.Username)
+				logger.Debugf("[Login] ✅ 密码验证成功 - 用户: %s", req.Username)
 				token, err := middleware.GenerateToken(user.ID, user.Username, user.Role)
 				if err != nil {
-					log.Printf("[Login] ❌ 生成token失败: %v", err)
+					logger.Errorf("[Login] ❌ 生成token失败: %v", err)
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
 					return
 				}
 
-				log.Printf("[Login] ✅ 登录成功，返回token - 用户: %s, 角色: %s", user.Username, user.Role)
+				logger.Infof("[Login] ✅ 登录成功，返回token - 用户: %s, 角色: %s", user.Username, user.Role)
 				c.JSON(http.StatusOK, gin.H{
 					"token": token,
 					"user": gin.H{
@@ -68,14 +66,13 @@ func (ac *AuthController) Login(c *gin.Context) {
 				})
 				return
 			} else {
-				log.Printf("[Login] ❌ 密码验证失败 - 用户: %s, bcrypt错误: %v", req.Username, err)
-				log.Printf("[Login] 调试信息 - 输入密码: '%s', 哈希: '%s'", req.Password, user.Password)
+				logger.Warnf("[Login] ❌ 密码验证失败 - 用户: %s, bcrypt错误: %v", req.Username, err)
 			}
 		} else {
-			log.Printf("[Login] ❌ 用户不存在 - 用户名: %s, 数据库错误: %v", req.Username, err)
+			logger.Warnf("[Login] ❌ 用户不存在 - 用户名: %s, 数据库错误: %v", req.Username, err)
 		}
 	} else {
-		log.Printf("[Login] ❌ 数据库连接不可用")
+		logger.Errorf("[Login] ❌ 数据库连接不可用")
 	}
 
 	// Fallback: 硬编码的admin用户验证（当数据库不可用时）
@@ -148,25 +145,25 @@ func (ac *AuthController) Register(c *gin.Context) {
 
 // 获取当前用户信息
 func (ac *AuthController) GetProfile(c *gin.Context) {
-	log.Printf("[GetProfile] 开始处理获取用户信息请求, 客户端IP: %s", c.ClientIP())
+	logger.Debugf("[GetProfile] 开始处理获取用户信息请求, 客户端IP: %s", c.ClientIP())
 	
 	userID, exists := c.Get("user_id")
 	if !exists {
-		log.Printf("[GetProfile] ❌ 无法获取用户ID，认证中间件可能未正确设置")
+		logger.Warnf("[GetProfile] ❌ 无法获取用户ID，认证中间件可能未正确设置")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "认证信息缺失"})
 		return
 	}
 	
-	log.Printf("[GetProfile] 从上下文获取用户ID: %v", userID)
+	logger.Debugf("[GetProfile] 从上下文获取用户ID: %v", userID)
 
 	var user models.User
 	if err := ac.DB.First(&user, userID).Error; err != nil {
-		log.Printf("[GetProfile] ❌ 数据库查询用户失败: %v, 用户ID: %v", err, userID)
+		logger.Errorf("[GetProfile] ❌ 数据库查询用户失败: %v, 用户ID: %v", err, userID)
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
 
-	log.Printf("[GetProfile] ✅ 成功获取用户信息 - ID: %d, 用户名: %s, 角色: %s", user.ID, user.Username, user.Role)
+	logger.Debugf("[GetProfile] ✅ 成功获取用户信息 - ID: %d, 用户名: %s, 角色: %s", user.ID, user.Username, user.Role)
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
 			"id":       user.ID,
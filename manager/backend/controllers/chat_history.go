@@ -5,18 +5,24 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"time"
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// 聊天记录分页默认值与上限
+const (
+	chatHistoryDefaultPageSize = 50
+	chatHistoryMaxPageSize     = 200
+)
+
 type ChatHistoryController struct {
 	DB            *gorm.DB
 	AudioBasePath string // 音频存储基础路径
@@ -188,9 +194,12 @@ func (c *ChatHistoryController) GetMessages(ctx *gin.Context) {
 	agentID := ctx.Query("agent_id")
 	deviceID := ctx.Query("device_id")
 	sessionID := ctx.Query("session_id")
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "50"))
 	role := ctx.Query("role") // user/assistant
+	hq, err := parseHistoryQuery(ctx, chatHistoryDefaultPageSize, chatHistoryMaxPageSize)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 构建查询
 	query := c.DB.Model(&models.ChatMessage{}).
@@ -208,25 +217,18 @@ func (c *ChatHistoryController) GetMessages(ctx *gin.Context) {
 	if role != "" {
 		query = query.Where("role = ?", role)
 	}
+	query = hq.Filter(query, "created_at")
 
 	var total int64
 	query.Count(&total)
 
 	var messages []models.ChatMessage
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").
-		Limit(pageSize).Offset(offset).
-		Find(&messages).Error; err != nil {
+	if err := hq.Paginate(query.Order("created_at DESC")).Find(&messages).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-		"data":      messages,
-	})
+	ctx.JSON(http.StatusOK, historyPageResponse(messages, total, hq))
 }
 
 // DeleteMessage 删除消息（软删除，立即删除音频文件）
@@ -250,7 +252,7 @@ func (c *ChatHistoryController) DeleteMessage(ctx *gin.Context) {
 	if message.AudioPath != "" {
 		if err := c.deleteAudioFile(message.AudioPath); err != nil {
 			// 记录日志，但不影响删除操作
-			log.Printf("删除音频文件失败: %v", err)
+			logger.Warnf("删除音频文件失败: %v", err)
 		}
 	}
 
@@ -274,12 +276,14 @@ func (c *ChatHistoryController) GetMessagesByAgent(ctx *gin.Context) {
 	}
 
 	agentID := ctx.Param("agent_id")
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "50"))
-	role := ctx.Query("role")            // user/assistant
-	deviceID := ctx.Query("device_id")   // 设备ID筛选
-	startDate := ctx.Query("start_date") // 开始日期 YYYY-MM-DD
-	endDate := ctx.Query("end_date")     // 结束日期 YYYY-MM-DD
+	role := ctx.Query("role")          // user/assistant
+	deviceID := ctx.Query("device_id") // 设备ID筛选
+	// 分页与日期范围（start_date/end_date，YYYY-MM-DD）
+	hq, err := parseHistoryQuery(ctx, chatHistoryDefaultPageSize, chatHistoryMaxPageSize)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 构建查询
 	query := c.DB.Model(&models.ChatMessage{}).
@@ -295,19 +299,8 @@ func (c *ChatHistoryController) GetMessagesByAgent(ctx *gin.Context) {
 		query = query.Where("device_id = ?", deviceID)
 	}
 
-	// 日期范围筛选
-	if startDate != "" {
-		if startTime, err := time.Parse("2006-01-02", startDate); err == nil {
-			query = query.Where("created_at >= ?", startTime)
-		}
-	}
-	if endDate != "" {
-		if endTime, err := time.Parse("2006-01-02", endDate); err == nil {
-			// 结束日期包含整天
-			endTime = endTime.Add(24 * time.Hour)
-			query = query.Where("created_at < ?", endTime)
-		}
-	}
+	// 日期范围筛选（结束日期包含整天）
+	query = hq.Filter(query, "created_at")
 
 	// 计算总数
 	var total int64
@@ -315,20 +308,12 @@ func (c *ChatHistoryController) GetMessagesByAgent(ctx *gin.Context) {
 
 	// 分页查询（按时间倒序，最新的在前，前端会反转数组使最新的在底部）
 	var messages []models.ChatMessage
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").
-		Limit(pageSize).Offset(offset).
-		Find(&messages).Error; err != nil {
+	if err := hq.Paginate(query.Order("created_at DESC")).Find(&messages).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-		"data":      messages,
-	})
+	ctx.JSON(http.StatusOK, historyPageResponse(messages, total, hq))
 }
 
 // ExportMessages 导出聊天记录（JSON格式）
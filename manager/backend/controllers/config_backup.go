@@ -0,0 +1,478 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/config"
+	"xiaozhi/manager/backend/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultConfigBackupInterval  = 24 * time.Hour
+	defaultConfigBackupKeep      = 7
+	defaultConfigBackupLocalPath = "./data/config_backups"
+	defaultConfigBackupS3Region  = "us-east-1"
+
+	configBackupFilePrefix = "config-backup-"
+	configBackupFileSuffix = ".yaml"
+	configBackupTimeout    = 5 * time.Minute
+)
+
+// configBackupDestination 备份写入目标，name 为不含目录/前缀的备份文件名
+type configBackupDestination interface {
+	Put(ctx context.Context, name string, data []byte) error
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// ConfigBackupStatus 定时备份运行状态
+type ConfigBackupStatus struct {
+	Enabled       bool       `json:"enabled"`
+	Destination   string     `json:"destination,omitempty"`
+	IntervalHours int        `json:"interval_hours,omitempty"`
+	Keep          int        `json:"keep,omitempty"`
+	Running       bool       `json:"running"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastStatus    string     `json:"last_status,omitempty"` // success / failed
+	LastError     string     `json:"last_error,omitempty"`
+	LastFile      string     `json:"last_file,omitempty"`
+	LastSize      int        `json:"last_size,omitempty"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+}
+
+// configBackupRunner 定时备份任务，全局仅一个
+type configBackupRunner struct {
+	mu     sync.Mutex
+	ac     *AdminController
+	dest   configBackupDestination
+	keep   int
+	status ConfigBackupStatus
+}
+
+var (
+	configBackupOnce sync.Once
+	configBackup     *configBackupRunner
+
+	errConfigBackupRunning = errors.New("备份正在进行中")
+)
+
+// StartConfigBackupWorker 启动定时配置备份任务（未开启时不启动，仅启动一次）
+func StartConfigBackupWorker(ac *AdminController, cfg config.ConfigBackupConfig) {
+	if ac == nil || !cfg.Enabled {
+		return
+	}
+	dest, destName, err := newConfigBackupDestination(cfg)
+	if err != nil {
+		logger.Errorf("[ConfigBackup] 备份目标配置无效，未启动: %v", err)
+		return
+	}
+	interval := defaultConfigBackupInterval
+	if cfg.IntervalHours > 0 {
+		interval = time.Duration(cfg.IntervalHours) * time.Hour
+	}
+	keep := cfg.Keep
+	if keep <= 0 {
+		keep = defaultConfigBackupKeep
+	}
+
+	configBackupOnce.Do(func() {
+		runner := &configBackupRunner{
+			ac:   ac,
+			dest: dest,
+			keep: keep,
+			status: ConfigBackupStatus{
+				Enabled:       true,
+				Destination:   destName,
+				IntervalHours: int(interval / time.Hour),
+				Keep:          keep,
+			},
+		}
+		configBackup = runner
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				runner.setNextRun(time.Now().Add(interval))
+				if _, err := runner.run(); err != nil {
+					logger.Errorf("[ConfigBackup] 定时备份失败: %v", err)
+				}
+				<-ticker.C
+			}
+		}()
+		logger.Infof("[ConfigBackup] worker started destination=%s interval=%s keep=%d", destName, interval, keep)
+	})
+}
+
+// newConfigBackupDestination 按配置创建备份目标，返回用于展示的目标描述
+func newConfigBackupDestination(cfg config.ConfigBackupConfig) (configBackupDestination, string, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Destination)) {
+	case "", "local":
+		dir := strings.TrimSpace(cfg.LocalPath)
+		if dir == "" {
+			dir = defaultConfigBackupLocalPath
+		}
+		return &localConfigBackupDestination{dir: dir}, "local:" + dir, nil
+	case "s3":
+		s3 := cfg.S3
+		if strings.TrimSpace(s3.Endpoint) == "" || strings.TrimSpace(s3.Bucket) == "" {
+			return nil, "", fmt.Errorf("s3 endpoint 与 bucket 不能为空")
+		}
+		if s3.AccessKey == "" || s3.SecretKey == "" {
+			return nil, "", fmt.Errorf("s3 access_key 与 secret_key 不能为空")
+		}
+		if _, err := url.Parse(s3.Endpoint); err != nil {
+			return nil, "", fmt.Errorf("s3 endpoint 无效: %v", err)
+		}
+		if s3.Region == "" {
+			s3.Region = defaultConfigBackupS3Region
+		}
+		dest := &s3ConfigBackupDestination{cfg: s3, client: &http.Client{Timeout: 60 * time.Second}}
+		return dest, "s3:" + s3.Bucket + "/" + s3.Prefix, nil
+	default:
+		return nil, "", fmt.Errorf("不支持的备份目标: %s", cfg.Destination)
+	}
+}
+
+func (r *configBackupRunner) setNextRun(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.NextRunAt = &t
+}
+
+func (r *configBackupRunner) snapshot() ConfigBackupStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// run 执行一次备份并轮转旧备份；已有备份在执行时返回错误
+func (r *configBackupRunner) run() (ConfigBackupStatus, error) {
+	r.mu.Lock()
+	if r.status.Running {
+		r.mu.Unlock()
+		return r.snapshot(), errConfigBackupRunning
+	}
+	r.status.Running = true
+	r.mu.Unlock()
+
+	startAt := time.Now()
+	name, size, err := r.backupOnce(startAt)
+
+	r.mu.Lock()
+	r.status.Running = false
+	r.status.LastRunAt = &startAt
+	if err != nil {
+		r.status.LastStatus = "failed"
+		r.status.LastError = err.Error()
+	} else {
+		r.status.LastStatus = "success"
+		r.status.LastError = ""
+		r.status.LastSuccessAt = &startAt
+		r.status.LastFile = name
+		r.status.LastSize = size
+	}
+	status := r.status
+	r.mu.Unlock()
+	return status, err
+}
+
+func (r *configBackupRunner) backupOnce(now time.Time) (string, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), configBackupTimeout)
+	defer cancel()
+
+	data, err := r.ac.buildConfigExportYAML()
+	if err != nil {
+		return "", 0, err
+	}
+	name := configBackupFilePrefix + now.UTC().Format("20060102-150405") + configBackupFileSuffix
+	if err := r.dest.Put(ctx, name, data); err != nil {
+		return "", 0, fmt.Errorf("写入备份失败: %v", err)
+	}
+	removed, err := rotateConfigBackups(ctx, r.dest, r.keep)
+	if err != nil {
+		// 轮转失败不影响本次备份结果
+		logger.Warnf("[ConfigBackup] 清理旧备份失败: %v", err)
+	}
+	logger.Infof("[ConfigBackup] 备份完成 file=%s size=%d removed=%d", name, len(data), removed)
+	return name, len(data), nil
+}
+
+// rotateConfigBackups 仅保留最新的 keep 份备份（文件名含时间戳，按名称排序即按时间排序），返回删除数量
+func rotateConfigBackups(ctx context.Context, dest configBackupDestination, keep int) (int, error) {
+	names, err := dest.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(names) <= keep {
+		return 0, nil
+	}
+	sort.Strings(names)
+	removed := 0
+	for _, name := range names[:len(names)-keep] {
+		if err := dest.Delete(ctx, name); err != nil {
+			return removed, fmt.Errorf("删除备份 %s 失败: %v", name, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func isConfigBackupName(name string) bool {
+	return strings.HasPrefix(name, configBackupFilePrefix) && strings.HasSuffix(name, configBackupFileSuffix)
+}
+
+// GetConfigBackupStatus 查询定时备份状态
+func (ac *AdminController) GetConfigBackupStatus(c *gin.Context) {
+	if configBackup == nil {
+		c.JSON(http.StatusOK, gin.H{"data": ConfigBackupStatus{Enabled: false}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": configBackup.snapshot()})
+}
+
+// RunConfigBackup 立即执行一次备份
+func (ac *AdminController) RunConfigBackup(c *gin.Context) {
+	if configBackup == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未开启定时配置备份（config_backup.enabled）"})
+		return
+	}
+	status, err := configBackup.run()
+	if errors.Is(err, errConfigBackupRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "data": status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": status})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "备份完成", "data": status})
+}
+
+// localConfigBackupDestination 本地目录
+type localConfigBackupDestination struct {
+	dir string
+}
+
+func (d *localConfigBackupDestination) Put(_ context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免中断时留下不完整的备份
+	tmp := filepath.Join(d.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(d.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (d *localConfigBackupDestination) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && isConfigBackupName(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d *localConfigBackupDestination) Delete(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(d.dir, name))
+}
+
+// s3ConfigBackupDestination S3 兼容存储，使用 AWS Signature V4 签名
+type s3ConfigBackupDestination struct {
+	cfg    config.S3BackupConfig
+	client *http.Client
+}
+
+func (d *s3ConfigBackupDestination) objectKey(name string) string {
+	return strings.TrimPrefix(d.cfg.Prefix, "/") + name
+}
+
+func (d *s3ConfigBackupDestination) bucketURL() string {
+	return strings.TrimRight(d.cfg.Endpoint, "/") + "/" + d.cfg.Bucket
+}
+
+func (d *s3ConfigBackupDestination) Put(ctx context.Context, name string, data []byte) error {
+	_, err := d.do(ctx, http.MethodPut, d.objectKey(name), nil, data)
+	return err
+}
+
+func (d *s3ConfigBackupDestination) Delete(ctx context.Context, name string) error {
+	_, err := d.do(ctx, http.MethodDelete, d.objectKey(name), nil, nil)
+	return err
+}
+
+func (d *s3ConfigBackupDestination) List(ctx context.Context) ([]string, error) {
+	prefix := d.objectKey("")
+	var names []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		body, err := d.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("解析对象列表失败: %v", err)
+		}
+		for _, obj := range result.Contents {
+			name := strings.TrimPrefix(obj.Key, prefix)
+			if !strings.Contains(name, "/") && isConfigBackupName(name) {
+				names = append(names, name)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return names, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do 发送签名请求，key 为空时请求 bucket 本身
+func (d *s3ConfigBackupDestination) do(ctx context.Context, method, key string, query url.Values, payload []byte) ([]byte, error) {
+	rawURL := d.bucketURL()
+	if key != "" {
+		rawURL += "/" + s3URIEncode(key, false)
+	}
+	if len(query) > 0 {
+		rawURL += "?" + s3CanonicalQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	signS3Request(req, payload, d.cfg, time.Now())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s 返回 %d: %s", method, key, resp.StatusCode, truncateRunes(string(body), 300))
+	}
+	return body, nil
+}
+
+// signS3Request 按 AWS Signature V4 为请求添加签名头
+func signS3Request(req *http.Request, payload []byte, cfg config.S3BackupConfig, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path, false),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretKey), day)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// s3URIEncode 按 SigV4 规则编码：仅保留 A-Z a-z 0-9 - _ . ~，encodeSlash 为 false 时保留 /
+func s3URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch >= 'A' && ch <= 'Z', ch >= 'a' && ch <= 'z', ch >= '0' && ch <= '9', ch == '-', ch == '_', ch == '.', ch == '~':
+			b.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// s3CanonicalQuery 按键排序并编码查询参数
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3URIEncode(k, true)+"="+s3URIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"xiaozhi/manager/backend/config"
+)
+
+func TestRotateLocalConfigBackups(t *testing.T) {
+	dest := &localConfigBackupDestination{dir: t.TempDir()}
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		if err := dest.Put(ctx, fmt.Sprintf("config-backup-20260101-00000%d.yaml", i), []byte("vad: {}\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 非备份文件不参与轮转
+	if err := dest.Put(ctx, "notes.txt", []byte("keep me")); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := rotateConfigBackups(ctx, dest, 3)
+	if err != nil || removed != 2 {
+		t.Fatalf("removed = %d, err = %v", removed, err)
+	}
+	names, _ := dest.List(ctx)
+	sort.Strings(names)
+	want := []string{"config-backup-20260101-000003.yaml", "config-backup-20260101-000004.yaml", "config-backup-20260101-000005.yaml"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+}
+
+func TestS3ConfigBackupDestination(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ak/") || r.Header.Get("X-Amz-Date") == "" {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[key] = string(body)
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			prefix := r.URL.Query().Get("prefix")
+			fmt.Fprint(w, "<ListBucketResult>")
+			for k := range objects {
+				if strings.HasPrefix(k, prefix) {
+					fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", k)
+				}
+			}
+			fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+		}
+	}))
+	defer srv.Close()
+
+	d, _, err := newConfigBackupDestination(config.ConfigBackupConfig{
+		Destination: "s3",
+		S3:          config.S3BackupConfig{Endpoint: srv.URL, Bucket: "bucket", Prefix: "backups/", AccessKey: "ak", SecretKey: "sk"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, name := range []string{"config-backup-20260101-000001.yaml", "config-backup-20260102-000001.yaml"} {
+		if err := d.Put(ctx, name, []byte("llm: {}\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	objects["backups/other/config-backup-20250101-000001.yaml"] = "nested"
+
+	if removed, err := rotateConfigBackups(ctx, d, 1); err != nil || removed != 1 {
+		t.Fatalf("removed = %d, err = %v", removed, err)
+	}
+	if _, ok := objects["backups/config-backup-20260102-000001.yaml"]; !ok || len(objects) != 2 {
+		t.Fatalf("objects = %v", objects)
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 保存时规范化 json_data 中 base_url 的配置类型
+var baseURLNormalizedConfigTypes = map[string]bool{
+	"llm":              true,
+	"tts":              true,
+	"knowledge_search": true,
+	"memory":           true,
+}
+
+// normalizeBaseURL 校验 base_url 的协议与主机，并去掉末尾的斜杠
+// 仅允许 http/https/ws/wss；协议与主机统一为小写，路径与查询参数保持原样
+func normalizeBaseURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("地址不能为空")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("地址格式无效: %v", err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	switch u.Scheme {
+	case "http", "https", "ws", "wss":
+	case "":
+		return "", fmt.Errorf("地址缺少协议（如 https://）: %s", raw)
+	default:
+		return "", fmt.Errorf("不支持的协议 %s: %s", u.Scheme, raw)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("地址缺少主机名: %s", raw)
+	}
+	if u.Fragment != "" {
+		return "", fmt.Errorf("地址不能包含片段(#): %s", raw)
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u.String(), nil
+}
+
+// normalizeConfigBaseURLs 规范化 json_data 中所有非空的 base_url 字段（含嵌套对象），任一无效时返回带字段路径的错误
+// 不在规范化范围的类型、空数据或非法 JSON 原样返回，由各自的校验逻辑处理；无变化时保留原始文本
+func normalizeConfigBaseURLs(configType, jsonData string) (string, error) {
+	if !baseURLNormalizedConfigTypes[configType] || strings.TrimSpace(jsonData) == "" {
+		return jsonData, nil
+	}
+	var data interface{}
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return jsonData, nil
+	}
+	changed := false
+	var walk func(value interface{}, path string) error
+	walk = func(value interface{}, path string) error {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				if s, ok := child.(string); ok && key == "base_url" {
+					if strings.TrimSpace(s) == "" {
+						continue
+					}
+					normalized, err := normalizeBaseURL(s)
+					if err != nil {
+						return fmt.Errorf("%s 无效: %v", childPath, err)
+					}
+					if normalized != s {
+						v[key] = normalized
+						changed = true
+					}
+					continue
+				}
+				if err := walk(child, childPath); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for i, child := range v {
+				if err := walk(child, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(data, ""); err != nil {
+		return "", err
+	}
+	if !changed {
+		return jsonData, nil
+	}
+	out, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizeBaseURL(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "https://api.openai.com/v1/", want: "https://api.openai.com/v1"},
+		{in: " HTTPS://API.Example.COM:8080// ", want: "https://api.example.com:8080"},
+		{in: "http://127.0.0.1:5001/v1?x=1", want: "http://127.0.0.1:5001/v1?x=1"},
+		{in: "wss://tts.example.com/ws/", want: "wss://tts.example.com/ws"},
+		{in: "api.openai.com/v1", wantErr: true},
+		{in: "ftp://example.com", wantErr: true},
+		{in: "http:///v1", wantErr: true},
+		{in: "https://example.com/v1#frag", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := normalizeBaseURL(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("normalizeBaseURL(%q) = %q, want error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("normalizeBaseURL(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestNormalizeConfigBaseURLs(t *testing.T) {
+	got, err := normalizeConfigBaseURLs("knowledge_search", `{"provider":"dify","dify":{"base_url":"HTTP://Dify.Local/v1/"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(got, `"base_url":"http://dify.local/v1"`) {
+		t.Fatalf("nested base_url not normalized: %s", got)
+	}
+
+	unchanged := `{ "base_url": "https://api.example.com/v1", "model": "x" }`
+	if got, err := normalizeConfigBaseURLs("llm", unchanged); err != nil || got != unchanged {
+		t.Fatalf("unchanged json_data rewritten: %q, %v", got, err)
+	}
+
+	vad := `{"base_url":"not a url"}`
+	if got, err := normalizeConfigBaseURLs("vad", vad); err != nil || got != vad {
+		t.Fatalf("unlisted type should be untouched: %q, %v", got, err)
+	}
+
+	_, err = normalizeConfigBaseURLs("memory", `{"mem0":{"base_url":"mem0.local"}}`)
+	if err == nil || !strings.Contains(err.Error(), "mem0.base_url") {
+		t.Fatalf("error should carry field path, got %v", err)
+	}
+}
+
+func TestConfigEndpointsNormalizeBaseURLs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/configs", ac.CreateConfig)
+	r.PUT("/configs/:id", ac.UpdateConfig)
+	r.POST("/memory-configs", ac.CreateMemoryConfig)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/configs", `{"type":"llm","name":"bad","config_id":"bad","json_data":"{\"base_url\":\"api.example.com\"}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("create with bad base_url: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/memory-configs", `{"name":"m","config_id":"m","provider":"mem0","json_data":"{\"mem0\":{\"base_url\":\"mem0.local\"}}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("memory create with bad base_url: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/configs", `{"type":"llm","name":"ok","config_id":"ok","json_data":"{}"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPut, "/configs/1", `{"name":"ok","json_data":"{\"base_url\":\"HTTPS://API.Example.COM/v1/\"}"}`); w.Code != http.StatusOK {
+		t.Fatalf("update: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var saved models.Config
+	db.First(&saved, 1)
+	if saved.JsonData != `{"base_url":"https://api.example.com/v1"}` {
+		t.Fatalf("stored base_url not normalized: %s", saved.JsonData)
+	}
+}
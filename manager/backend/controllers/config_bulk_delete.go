@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const bulkDeleteConfigsMaxIDs = 200
+
+// 批量删除中单个配置的处理结果
+const (
+	bulkDeleteStatusDeleted  = "deleted"
+	bulkDeleteStatusInUse    = "in_use"
+	bulkDeleteStatusNotFound = "not_found"
+	bulkDeleteStatusReadOnly = "read_only"
+)
+
+// configUsages 统计引用该配置的智能体/角色/声纹组/复刻音色，返回可读的占用说明
+// 设备通过角色或智能体间接使用配置，因此检查角色与智能体即可覆盖设备
+func configUsages(db *gorm.DB, config models.Config) ([]string, error) {
+	var column string
+	switch config.Type {
+	case "llm":
+		column = "llm_config_id"
+	case "tts":
+		column = "tts_config_id"
+	default:
+		return nil, nil
+	}
+
+	type usageCheck struct {
+		label string
+		model interface{}
+	}
+	checks := []usageCheck{
+		{"智能体", &models.Agent{}},
+		{"角色", &models.Role{}},
+	}
+	if config.Type == "tts" {
+		checks = append(checks, usageCheck{"声纹组", &models.SpeakerGroup{}}, usageCheck{"复刻音色", &models.VoiceClone{}})
+	}
+
+	usages := make([]string, 0)
+	for _, check := range checks {
+		var count int64
+		if err := db.Model(check.model).Where(column+" = ?", config.ConfigID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			usages = append(usages, fmt.Sprintf("%d 个%s正在使用", count, check.label))
+		}
+	}
+	return usages, nil
+}
+
+// BulkDeleteConfigs 批量删除配置：逐个检查引用，跳过仍在使用的配置，其余在同一事务内删除，并返回每个 ID 的处理结果
+func (ac *AdminController) BulkDeleteConfigs(c *gin.Context) {
+	var req struct {
+		IDs []uint `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > bulkDeleteConfigsMaxIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ids 数量需在 1~%d 之间", bulkDeleteConfigsMaxIDs)})
+		return
+	}
+
+	results := make([]gin.H, 0, len(req.IDs))
+	deleted, skipped := 0, 0
+	seen := make(map[uint]bool, len(req.IDs))
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range req.IDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			var config models.Config
+			if err := tx.First(&config, id).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					skipped++
+					results = append(results, gin.H{"id": id, "status": bulkDeleteStatusNotFound})
+					continue
+				}
+				return err
+			}
+
+			item := gin.H{"id": id, "type": config.Type, "config_id": config.ConfigID, "name": config.Name}
+			if isConfigTypeReadOnly(config.Type) {
+				skipped++
+				item["status"] = bulkDeleteStatusReadOnly
+				results = append(results, item)
+				continue
+			}
+
+			usages, err := configUsages(tx, config)
+			if err != nil {
+				return err
+			}
+			if len(usages) > 0 {
+				skipped++
+				item["status"] = bulkDeleteStatusInUse
+				item["usages"] = usages
+				results = append(results, item)
+				continue
+			}
+
+			if err := tx.Delete(&config).Error; err != nil {
+				return err
+			}
+			deleted++
+			item["status"] = bulkDeleteStatusDeleted
+			results = append(results, item)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("批量删除配置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "批量删除配置失败，已回滚: " + err.Error()})
+		return
+	}
+
+	if deleted > 0 {
+		ac.notifySystemConfigChanged()
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"deleted": deleted,
+		"skipped": skipped,
+		"results": results,
+	}})
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// configSecretMask 整体新增/删除/变更的对象或数组中，敏感字段取值的替代显示
+const configSecretMask = "******"
+
+const (
+	configDiffAdded   = "added"
+	configDiffRemoved = "removed"
+	configDiffChanged = "changed"
+)
+
+// configFieldDiff 单个字段的差异，path 为点号分隔的 json_data 路径；
+// 敏感字段只标记 secret，不返回任一侧的取值
+type configFieldDiff struct {
+	Path   string      `json:"path"`
+	Status string      `json:"status"`
+	A      interface{} `json:"a,omitempty"`
+	B      interface{} `json:"b,omitempty"`
+	Secret bool        `json:"secret,omitempty"`
+}
+
+// CompareConfigs 对比两个配置的 json_data，返回字段级差异
+// GET /api/admin/configs/compare?a=1&b=2
+func (ac *AdminController) CompareConfigs(c *gin.Context) {
+	idA, errA := strconv.Atoi(c.Query("a"))
+	idB, errB := strconv.Atoi(c.Query("b"))
+	if errA != nil || errB != nil || idA <= 0 || idB <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数 a 和 b 必须为有效的配置ID"})
+		return
+	}
+
+	configA, ok := ac.loadConfigForCompare(c, idA)
+	if !ok {
+		return
+	}
+	configB, ok := ac.loadConfigForCompare(c, idB)
+	if !ok {
+		return
+	}
+
+	dataA, err := parseConfigJSONData(configA.JsonData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "配置 a 的 json_data 解析失败: " + err.Error()})
+		return
+	}
+	dataB, err := parseConfigJSONData(configB.JsonData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "配置 b 的 json_data 解析失败: " + err.Error()})
+		return
+	}
+
+	// 元信息差异（type/provider 不同通常就是问题所在，单独列出便于前端提示）
+	metaDiffs := make([]configFieldDiff, 0)
+	appendMeta := func(path string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			metaDiffs = append(metaDiffs, configFieldDiff{Path: path, Status: configDiffChanged, A: a, B: b})
+		}
+	}
+	appendMeta("type", configA.Type, configB.Type)
+	appendMeta("provider", configA.Provider, configB.Provider)
+	appendMeta("enabled", configA.Enabled, configB.Enabled)
+	appendMeta("is_default", configA.IsDefault, configB.IsDefault)
+
+	isSecret := func(field string) bool {
+		return IsConfigSecretField(configA.Type, configA.Provider, field) || IsConfigSecretField(configB.Type, configB.Provider, field)
+	}
+	diffs := diffConfigJSONData(dataA, dataB, isSecret)
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"a": gin.H{
+			"id":        configA.ID,
+			"type":      configA.Type,
+			"name":      configA.Name,
+			"config_id": configA.ConfigID,
+			"provider":  configA.Provider,
+		},
+		"b": gin.H{
+			"id":        configB.ID,
+			"type":      configB.Type,
+			"name":      configB.Name,
+			"config_id": configB.ConfigID,
+			"provider":  configB.Provider,
+		},
+		"same_type": configA.Type == configB.Type,
+		"identical": len(diffs) == 0,
+		"meta":      metaDiffs,
+		"diffs":     diffs,
+	}})
+}
+
+func (ac *AdminController) loadConfigForCompare(c *gin.Context, id int) (models.Config, bool) {
+	var config models.Config
+	if err := ac.DB.First(&config, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在: " + strconv.Itoa(id)})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询配置失败"})
+		}
+		return config, false
+	}
+	return config, true
+}
+
+// parseConfigJSONData 解析 json_data，空字符串视为空对象
+func parseConfigJSONData(raw string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if raw == "" {
+		return data, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// diffConfigJSONData 递归对比两个 map，返回按路径排序的差异列表。
+// 嵌套对象继续展开，数组与标量按整体比较；isSecret 命中的字段整体比较且不输出取值。
+func diffConfigJSONData(a, b map[string]interface{}, isSecret func(field string) bool) []configFieldDiff {
+	diffs := make([]configFieldDiff, 0)
+	collectConfigDiffs("", a, b, isSecret, &diffs)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func collectConfigDiffs(prefix string, a, b map[string]interface{}, isSecret func(field string) bool, out *[]configFieldDiff) {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+
+	for k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		va, inA := a[k]
+		vb, inB := b[k]
+		if isSecret != nil && isSecret(k) {
+			switch {
+			case inA && !inB:
+				*out = append(*out, configFieldDiff{Path: path, Status: configDiffRemoved, Secret: true})
+			case !inA && inB:
+				*out = append(*out, configFieldDiff{Path: path, Status: configDiffAdded, Secret: true})
+			case !reflect.DeepEqual(va, vb):
+				*out = append(*out, configFieldDiff{Path: path, Status: configDiffChanged, Secret: true})
+			}
+			continue
+		}
+		switch {
+		case inA && !inB:
+			*out = append(*out, configFieldDiff{Path: path, Status: configDiffRemoved, A: maskConfigSecrets(va, isSecret)})
+		case !inA && inB:
+			*out = append(*out, configFieldDiff{Path: path, Status: configDiffAdded, B: maskConfigSecrets(vb, isSecret)})
+		default:
+			ma, okA := va.(map[string]interface{})
+			mb, okB := vb.(map[string]interface{})
+			if okA && okB {
+				collectConfigDiffs(path, ma, mb, isSecret, out)
+				continue
+			}
+			if !reflect.DeepEqual(va, vb) {
+				*out = append(*out, configFieldDiff{Path: path, Status: configDiffChanged, A: maskConfigSecrets(va, isSecret), B: maskConfigSecrets(vb, isSecret)})
+			}
+		}
+	}
+}
+
+// maskConfigSecrets 复制整体输出的值，并将其中嵌套的敏感字段替换为 configSecretMask
+func maskConfigSecrets(value interface{}, isSecret func(field string) bool) interface{} {
+	if isSecret == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			if isSecret(k) {
+				masked[k] = configSecretMask
+			} else {
+				masked[k] = maskConfigSecrets(child, isSecret)
+			}
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, child := range v {
+			masked[i] = maskConfigSecrets(child, isSecret)
+		}
+		return masked
+	}
+	return value
+}
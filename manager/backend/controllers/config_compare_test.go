@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompareConfigsMasksSecrets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	configs := []models.Config{
+		{Type: "tts", Name: "a", ConfigID: "a", Provider: "doubao",
+			JsonData: `{"voice":"v1","token":"tok-aaaa","api_key":"same-key","auth":{"password":"pw-aaaa"},"old_secret":{"secret":"gone-aaaa"}}`},
+		{Type: "tts", Name: "b", ConfigID: "b", Provider: "doubao",
+			JsonData: `{"voice":"v2","token":"tok-bbbb","api_key":"same-key","auth":{"password":"pw-bbbb"},"access_key":"new-bbbb"}`},
+	}
+	for i := range configs {
+		if err := db.Create(&configs[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.GET("/compare", ac.CompareConfigs)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/compare?a=1&b=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+	}
+	for _, leaked := range []string{"tok-", "same-key", "pw-", "gone-", "new-"} {
+		if strings.Contains(w.Body.String(), leaked) {
+			t.Fatalf("response leaks secret %q: %s", leaked, w.Body.String())
+		}
+	}
+
+	var resp struct {
+		Data struct {
+			Diffs []configFieldDiff `json:"diffs"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]configFieldDiff)
+	for _, d := range resp.Data.Diffs {
+		got[d.Path] = d
+	}
+	want := map[string]struct {
+		status string
+		secret bool
+	}{
+		"voice":         {configDiffChanged, false},
+		"token":         {configDiffChanged, true},
+		"auth.password": {configDiffChanged, true},
+		"old_secret":    {configDiffRemoved, false},
+		"access_key":    {configDiffAdded, true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("diffs = %+v", resp.Data.Diffs)
+	}
+	for path, w := range want {
+		d, ok := got[path]
+		if !ok || d.Status != w.status || d.Secret != w.secret {
+			t.Fatalf("diff %s = %+v, want status=%s secret=%v", path, d, w.status, w.secret)
+		}
+	}
+	if got["voice"].A != "v1" || got["voice"].B != "v2" {
+		t.Fatalf("non-secret diff should keep values: %+v", got["voice"])
+	}
+	if removed, _ := got["old_secret"].A.(map[string]interface{}); removed["secret"] != configSecretMask {
+		t.Fatalf("nested secret in removed object not masked: %+v", got["old_secret"])
+	}
+}
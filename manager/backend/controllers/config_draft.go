@@ -0,0 +1,484 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	configDraftStatusDraft    = "draft"
+	configDraftStatusPromoted = "promoted"
+)
+
+// 一键测试支持的配置类型，其余类型的草稿项只在提升时写入
+var configDraftTestableTypes = []string{"ota", "vad", "asr", "llm", "tts"}
+
+// configDraftItem 草稿包中的单条配置，json_data 兼容 string 或 object
+type configDraftItem struct {
+	Type      string      `json:"type"`
+	ConfigID  string      `json:"config_id"`
+	Name      string      `json:"name"`
+	Provider  string      `json:"provider"`
+	JsonData  interface{} `json:"json_data"`
+	Enabled   *bool       `json:"enabled"`
+	IsDefault bool        `json:"is_default"`
+}
+
+type configDraftBundleRequest struct {
+	Name        string            `json:"name" binding:"required,min=1,max=100"`
+	Description string            `json:"description"`
+	Items       []configDraftItem `json:"items" binding:"required"`
+}
+
+// jsonDataString 将 json_data 统一为字符串
+func (item *configDraftItem) jsonDataString() (string, error) {
+	switch v := item.JsonData.(type) {
+	case nil:
+		return "{}", nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return "{}", nil
+		}
+		var probe map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &probe); err != nil {
+			return "", err
+		}
+		return v, nil
+	default:
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	}
+}
+
+// normalizeConfigDraftItems 校验草稿项并补全 config_id
+func normalizeConfigDraftItems(items []configDraftItem) ([]configDraftItem, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("草稿包至少需要包含一条配置")
+	}
+	seen := make(map[string]struct{}, len(items))
+	now := time.Now().Unix()
+	for i := range items {
+		item := &items[i]
+		item.Type = strings.TrimSpace(item.Type)
+		item.Name = strings.TrimSpace(item.Name)
+		item.ConfigID = strings.TrimSpace(item.ConfigID)
+		if item.Type == "" {
+			return nil, fmt.Errorf("第 %d 条配置缺少 type", i+1)
+		}
+		if item.Name == "" {
+			return nil, fmt.Errorf("第 %d 条配置缺少 name", i+1)
+		}
+		if item.ConfigID == "" {
+			item.ConfigID = generateConfigID(item.Type, item.Name, now)
+		}
+		key := item.Type + "/" + item.ConfigID
+		if _, exists := seen[key]; exists {
+			return nil, fmt.Errorf("配置重复: %s", key)
+		}
+		seen[key] = struct{}{}
+		if _, err := item.jsonDataString(); err != nil {
+			return nil, fmt.Errorf("配置 %s 的 json_data 格式无效: %v", key, err)
+		}
+	}
+	return items, nil
+}
+
+func decodeConfigDraftItems(bundle *models.ConfigDraftBundle) ([]configDraftItem, error) {
+	var items []configDraftItem
+	if strings.TrimSpace(bundle.ItemsJSON) == "" {
+		return items, nil
+	}
+	if err := json.Unmarshal([]byte(bundle.ItemsJSON), &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// testPayload 转换为 TestConfigs 覆盖数据中的单条配置（json_data 字段平铺，provider 单独覆盖）
+func (item *configDraftItem) testPayload() gin.H {
+	jsonData, _ := item.jsonDataString()
+	configItem := gin.H{
+		"name":       item.Name,
+		"is_default": item.IsDefault,
+	}
+	var configData map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonData), &configData); err == nil {
+		for k, v := range configData {
+			configItem[k] = v
+		}
+	}
+	if item.Provider != "" {
+		configItem["provider"] = item.Provider
+	}
+	return configItem
+}
+
+func configDraftBundleResponse(bundle *models.ConfigDraftBundle) gin.H {
+	items, _ := decodeConfigDraftItems(bundle)
+	var lastTestResult interface{}
+	if bundle.LastTestResult != "" {
+		_ = json.Unmarshal([]byte(bundle.LastTestResult), &lastTestResult)
+	}
+	return gin.H{
+		"id":               bundle.ID,
+		"name":             bundle.Name,
+		"description":      bundle.Description,
+		"items":            items,
+		"status":           bundle.Status,
+		"last_test_passed": bundle.LastTestPassed,
+		"last_test_result": lastTestResult,
+		"last_tested_at":   bundle.LastTestedAt,
+		"promoted_at":      bundle.PromotedAt,
+		"created_at":       bundle.CreatedAt,
+		"updated_at":       bundle.UpdatedAt,
+	}
+}
+
+// GetConfigDraftBundles 获取配置草稿包列表
+func (ac *AdminController) GetConfigDraftBundles(c *gin.Context) {
+	query := ac.DB.Model(&models.ConfigDraftBundle{})
+	if status := strings.TrimSpace(c.Query("status")); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var bundles []models.ConfigDraftBundle
+	if err := query.Order("id DESC").Find(&bundles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配置草稿包失败"})
+		return
+	}
+	data := make([]gin.H, 0, len(bundles))
+	for i := range bundles {
+		data = append(data, configDraftBundleResponse(&bundles[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// GetConfigDraftBundle 获取单个配置草稿包
+func (ac *AdminController) GetConfigDraftBundle(c *gin.Context) {
+	bundle, ok := ac.loadConfigDraftBundle(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": configDraftBundleResponse(bundle)})
+}
+
+// CreateConfigDraftBundle 创建配置草稿包
+func (ac *AdminController) CreateConfigDraftBundle(c *gin.Context) {
+	var req configDraftBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	items, err := normalizeConfigDraftItems(req.Items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "草稿配置序列化失败"})
+		return
+	}
+
+	bundle := models.ConfigDraftBundle{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		ItemsJSON:   string(itemsJSON),
+		Status:      configDraftStatusDraft,
+	}
+	if err := ac.DB.Create(&bundle).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建配置草稿包失败，名称可能已存在"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": configDraftBundleResponse(&bundle)})
+}
+
+// UpdateConfigDraftBundle 更新草稿包内容，已提升的草稿包不可修改；修改后需重新测试
+func (ac *AdminController) UpdateConfigDraftBundle(c *gin.Context) {
+	bundle, ok := ac.loadConfigDraftBundle(c)
+	if !ok {
+		return
+	}
+	if bundle.Status == configDraftStatusPromoted {
+		c.JSON(http.StatusConflict, gin.H{"error": "草稿包已提升为正式配置，不可修改"})
+		return
+	}
+
+	var req configDraftBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	items, err := normalizeConfigDraftItems(req.Items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "草稿配置序列化失败"})
+		return
+	}
+
+	bundle.Name = strings.TrimSpace(req.Name)
+	bundle.Description = req.Description
+	bundle.ItemsJSON = string(itemsJSON)
+	bundle.LastTestPassed = false
+	bundle.LastTestResult = ""
+	bundle.LastTestedAt = nil
+	if err := ac.DB.Save(bundle).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置草稿包失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": configDraftBundleResponse(bundle)})
+}
+
+// DeleteConfigDraftBundle 删除配置草稿包（不影响已提升的正式配置）
+func (ac *AdminController) DeleteConfigDraftBundle(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := ac.DB.Delete(&models.ConfigDraftBundle{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除配置草稿包失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// TestConfigDraftBundle 用草稿包中的配置覆盖 DB 配置源执行一键测试，并记录测试结果
+func (ac *AdminController) TestConfigDraftBundle(c *gin.Context) {
+	bundle, ok := ac.loadConfigDraftBundle(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		ClientUUID string `json:"client_uuid"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	items, err := decodeConfigDraftItems(bundle)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "草稿配置解析失败: " + err.Error()})
+		return
+	}
+
+	body := configTestRequest{
+		ConfigIDs:  make(map[string][]string),
+		ClientUUID: req.ClientUUID,
+		Data:       make(map[string]interface{}),
+	}
+	skipped := make([]string, 0)
+	for i := range items {
+		item := &items[i]
+		if !contains(configDraftTestableTypes, item.Type) {
+			skipped = append(skipped, item.Type+"/"+item.ConfigID)
+			continue
+		}
+		configItem := item.testPayload()
+
+		typeData, _ := body.Data[item.Type].(map[string]interface{})
+		if typeData == nil {
+			typeData = make(map[string]interface{})
+			body.Data[item.Type] = typeData
+			body.Types = append(body.Types, item.Type)
+		}
+		typeData[item.ConfigID] = map[string]interface{}(configItem)
+		body.ConfigIDs[item.Type] = append(body.ConfigIDs[item.Type], item.ConfigID)
+	}
+	if len(body.Types) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "草稿包中没有可测试的配置（仅支持 ota/vad/asr/llm/tts）"})
+		return
+	}
+
+	if !checkConfigTestRateLimit(c, body.Types) {
+		return
+	}
+	result := ac.runConfigTests(c.Request.Context(), body)
+	passed := configTestResultPassed(result, body.ConfigIDs)
+
+	now := time.Now()
+	resultJSON, _ := json.Marshal(result)
+	bundle.LastTestPassed = passed
+	bundle.LastTestResult = string(resultJSON)
+	bundle.LastTestedAt = &now
+	if err := ac.DB.Model(bundle).Updates(map[string]interface{}{
+		"last_test_passed": passed,
+		"last_test_result": bundle.LastTestResult,
+		"last_tested_at":   now,
+	}).Error; err != nil {
+		logger.Errorf("保存草稿包测试结果失败: bundle_id=%d err=%v", bundle.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"passed":  passed,
+		"result":  result,
+		"skipped": skipped,
+	}})
+}
+
+// PromoteConfigDraftBundle 在一个事务中将草稿包写入正式配置（按 type+config_id 更新或新建）。
+// 默认要求最近一次测试通过，force=true 时跳过该检查。
+func (ac *AdminController) PromoteConfigDraftBundle(c *gin.Context) {
+	bundle, ok := ac.loadConfigDraftBundle(c)
+	if !ok {
+		return
+	}
+	if bundle.Status == configDraftStatusPromoted {
+		c.JSON(http.StatusConflict, gin.H{"error": "草稿包已提升，请勿重复操作"})
+		return
+	}
+	force := c.Query("force") == "true"
+	if !bundle.LastTestPassed && !force {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "草稿包尚未通过测试，请先测试或使用 force=true 强制提升"})
+		return
+	}
+
+	items, err := decodeConfigDraftItems(bundle)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "草稿配置解析失败: " + err.Error()})
+		return
+	}
+
+	// 写入前逐项执行与单项保存相同的只读锁定与校验，任一项不通过则整包拒绝
+	jsonDatas := make([]string, len(items))
+	for i := range items {
+		item := &items[i]
+		if rejectReadOnlyConfigType(c, item.Type) {
+			return
+		}
+		jsonData, err := item.jsonDataString()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("配置 %s/%s 的 json_data 格式无效", item.Type, item.ConfigID)})
+			return
+		}
+		candidate := models.Config{Type: item.Type, Provider: item.Provider, JsonData: jsonData}
+		if err := prepareConfigForSave(&candidate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("配置 %s/%s 校验失败: %v", item.Type, item.ConfigID, err)})
+			return
+		}
+		jsonDatas[i] = candidate.JsonData
+	}
+
+	promoted := make([]models.Config, 0, len(items))
+	now := time.Now()
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			item := &items[i]
+			jsonData := jsonDatas[i]
+
+			var config models.Config
+			err = tx.Where("type = ? AND config_id = ?", item.Type, item.ConfigID).First(&config).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			isNew := err == gorm.ErrRecordNotFound
+
+			if item.IsDefault {
+				if err := tx.Model(&models.Config{}).Where("type = ? AND is_default = ? AND config_id <> ?", item.Type, true, item.ConfigID).Update("is_default", false).Error; err != nil {
+					return err
+				}
+			}
+
+			config.Type = item.Type
+			config.ConfigID = item.ConfigID
+			config.Name = item.Name
+			config.Provider = item.Provider
+			config.JsonData = jsonData
+			config.IsDefault = item.IsDefault
+			if item.Enabled != nil {
+				config.Enabled = *item.Enabled
+			} else if isNew {
+				config.Enabled = true
+			}
+
+			if isNew {
+				err = tx.Create(&config).Error
+			} else {
+				err = tx.Save(&config).Error
+			}
+			if err != nil {
+				return fmt.Errorf("写入配置 %s/%s 失败: %v", item.Type, item.ConfigID, err)
+			}
+			promoted = append(promoted, config)
+		}
+		return tx.Model(bundle).Updates(map[string]interface{}{
+			"status":      configDraftStatusPromoted,
+			"promoted_at": now,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提升草稿包失败: " + err.Error()})
+		return
+	}
+
+	bundle.Status = configDraftStatusPromoted
+	bundle.PromotedAt = &now
+	ac.notifySystemConfigChanged()
+	logger.Infof("配置草稿包已提升: bundle_id=%d name=%s configs=%d force=%v", bundle.ID, bundle.Name, len(promoted), force)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "草稿包已提升为正式配置",
+		"data": gin.H{
+			"bundle":  configDraftBundleResponse(bundle),
+			"configs": promoted,
+		},
+	})
+}
+
+func (ac *AdminController) loadConfigDraftBundle(c *gin.Context) (*models.ConfigDraftBundle, bool) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的草稿包ID"})
+		return nil, false
+	}
+	var bundle models.ConfigDraftBundle
+	if err := ac.DB.First(&bundle, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "配置草稿包不存在"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询配置草稿包失败"})
+		}
+		return nil, false
+	}
+	return &bundle, true
+}
+
+// configTestResultPassed 判断测试结果中指定的 config_id 是否全部 ok
+func configTestResultPassed(result gin.H, configIDs map[string][]string) bool {
+	for typ, ids := range configIDs {
+		typeResult := asTestResultMap(result[typ])
+		if typeResult == nil {
+			return false
+		}
+		for _, id := range ids {
+			item := asTestResultMap(typeResult[id])
+			if item == nil {
+				return false
+			}
+			if ok, _ := item["ok"].(bool); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// asTestResultMap 测试结果中同时存在 gin.H 与 JSON 解码得到的 map，统一转换
+func asTestResultMap(v interface{}) map[string]interface{} {
+	switch m := v.(type) {
+	case gin.H:
+		return m
+	case map[string]interface{}:
+		return m
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPromoteConfigDraftBundleValidatesItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{}, &models.ConfigDraftBundle{})
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/config-drafts/:id/promote", ac.PromoteConfigDraftBundle)
+	promote := func(items string) *httptest.ResponseRecorder {
+		bundle := models.ConfigDraftBundle{Name: items, ItemsJSON: items, Status: configDraftStatusDraft, LastTestPassed: true}
+		if err := db.Create(&bundle).Error; err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/config-drafts/%d/promote", bundle.ID), nil))
+		return w
+	}
+	countConfigs := func() int64 {
+		var n int64
+		db.Model(&models.Config{}).Count(&n)
+		return n
+	}
+
+	// 任一项校验失败时整包拒绝，合法项也不写入
+	w := promote(`[{"type":"llm","config_id":"llm1","name":"l","json_data":{"base_url":"https://api.example.com/v1"}},` +
+		`{"type":"tts","config_id":"cosy","name":"c","provider":"cosyvoice","json_data":{"voice":"spk1"}}]`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid item: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if n := countConfigs(); n != 0 {
+		t.Fatalf("rejected bundle wrote %d configs", n)
+	}
+
+	SetReadOnlyConfigTypes([]string{"mqtt"})
+	w = promote(`[{"type":"llm","config_id":"llm1","name":"l"},{"type":"mqtt","config_id":"m","name":"m"}]`)
+	SetReadOnlyConfigTypes(nil)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("read-only item: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if n := countConfigs(); n != 0 {
+		t.Fatalf("locked bundle wrote %d configs", n)
+	}
+
+	w = promote(`[{"type":"llm","config_id":"llm1","name":"l","json_data":{"base_url":"HTTPS://API.Example.COM/v1/"}}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("valid bundle: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var saved models.Config
+	if err := db.Where("type = ? AND config_id = ?", "llm", "llm1").First(&saved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.JsonData != `{"base_url":"https://api.example.com/v1"}` {
+		t.Fatalf("promoted json_data not normalized: %s", saved.JsonData)
+	}
+}
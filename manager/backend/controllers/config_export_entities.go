@@ -0,0 +1,413 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"os"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// 配置导入导出中随配置一并迁移的角色与声纹组；用户归属以用户名表示，便于跨环境迁移
+
+type exportedGlobalRole struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Prompt      string `yaml:"prompt,omitempty"`
+	IsDefault   bool   `yaml:"is_default,omitempty"`
+}
+
+type exportedRole struct {
+	Name        string  `yaml:"name"`
+	Owner       string  `yaml:"owner,omitempty"` // 用户角色的所属用户名，全局/系统角色为空
+	Description string  `yaml:"description,omitempty"`
+	Prompt      string  `yaml:"prompt,omitempty"`
+	Greeting    string  `yaml:"greeting,omitempty"`
+	LLMConfigID *string `yaml:"llm_config_id,omitempty"`
+	TTSConfigID *string `yaml:"tts_config_id,omitempty"`
+	Voice       *string `yaml:"voice,omitempty"`
+	RoleType    string  `yaml:"role_type"`
+	Status      string  `yaml:"status,omitempty"`
+	SortOrder   int     `yaml:"sort_order,omitempty"`
+	IsDefault   bool    `yaml:"is_default,omitempty"`
+}
+
+type exportedSpeakerSample struct {
+	UUID     string  `yaml:"uuid"`
+	FileName string  `yaml:"file_name,omitempty"`
+	Duration float32 `yaml:"duration,omitempty"`
+	Status   string  `yaml:"status,omitempty"`
+	Audio    string  `yaml:"audio,omitempty"` // base64 编码的音频文件内容
+}
+
+type exportedSpeakerGroup struct {
+	Name        string                  `yaml:"name"`
+	Owner       string                  `yaml:"owner"`
+	Agent       string                  `yaml:"agent"` // 所属智能体名称（同一用户下）
+	Prompt      string                  `yaml:"prompt,omitempty"`
+	Description string                  `yaml:"description,omitempty"`
+	TTSConfigID *string                 `yaml:"tts_config_id,omitempty"`
+	Voice       *string                 `yaml:"voice,omitempty"`
+	Status      string                  `yaml:"status,omitempty"`
+	Samples     []exportedSpeakerSample `yaml:"samples,omitempty"`
+}
+
+// entityImportStats 单类实体的导入统计
+type entityImportStats struct {
+	Created        int      `json:"created"`
+	Updated        int      `json:"updated"`
+	Skipped        int      `json:"skipped"`
+	SamplesCreated int      `json:"samples_created,omitempty"`
+	SamplesFailed  int      `json:"samples_failed,omitempty"`
+	UUIDRemapped   int      `json:"uuid_remapped,omitempty"` // 因 UUID 冲突而重新生成的样本数
+	Warnings       []string `json:"warnings,omitempty"`
+}
+
+func (s *entityImportStats) warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	logger.Warnf("[config_import] %s", msg)
+	s.Warnings = append(s.Warnings, msg)
+}
+
+// usernamesByID 查询用户ID到用户名的映射
+func usernamesByID(db *gorm.DB) (map[uint]string, error) {
+	var users []models.User
+	if err := db.Select("id", "username").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	return names, nil
+}
+
+// collectExportGlobalRoles 导出全局角色（旧表）
+func collectExportGlobalRoles(globalRoles []models.GlobalRole) []exportedGlobalRole {
+	out := make([]exportedGlobalRole, 0, len(globalRoles))
+	for _, r := range globalRoles {
+		out = append(out, exportedGlobalRole{Name: r.Name, Description: r.Description, Prompt: r.Prompt, IsDefault: r.IsDefault})
+	}
+	return out
+}
+
+// collectExportRoles 导出全部角色（全局与用户角色）
+func collectExportRoles(db *gorm.DB, usernames map[uint]string) ([]exportedRole, error) {
+	var roles []models.Role
+	if err := db.Order("id ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	out := make([]exportedRole, 0, len(roles))
+	for _, r := range roles {
+		item := exportedRole{
+			Name:        r.Name,
+			Description: r.Description,
+			Prompt:      r.Prompt,
+			Greeting:    r.Greeting,
+			LLMConfigID: r.LLMConfigID,
+			TTSConfigID: r.TTSConfigID,
+			Voice:       r.Voice,
+			RoleType:    r.RoleType,
+			Status:      r.Status,
+			SortOrder:   r.SortOrder,
+			IsDefault:   r.IsDefault,
+		}
+		if r.UserID != nil {
+			item.Owner = usernames[*r.UserID]
+			if item.Owner == "" {
+				logger.Warnf("[config_export] 角色 %s 的所属用户 %d 不存在，跳过", r.Name, *r.UserID)
+				continue
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// collectExportSpeakerGroups 导出声纹组及其样本音频
+func collectExportSpeakerGroups(db *gorm.DB, usernames map[uint]string) ([]exportedSpeakerGroup, error) {
+	var groups []models.SpeakerGroup
+	if err := db.Order("id ASC").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	var agents []models.Agent
+	if err := db.Select("id", "name").Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	agentNames := make(map[uint]string, len(agents))
+	for _, a := range agents {
+		agentNames[a.ID] = a.Name
+	}
+
+	out := make([]exportedSpeakerGroup, 0, len(groups))
+	for _, g := range groups {
+		owner, agent := usernames[g.UserID], agentNames[g.AgentID]
+		if owner == "" || agent == "" {
+			logger.Warnf("[config_export] 声纹组 %s 的所属用户或智能体不存在，跳过", g.Name)
+			continue
+		}
+		item := exportedSpeakerGroup{
+			Name:        g.Name,
+			Owner:       owner,
+			Agent:       agent,
+			Prompt:      g.Prompt,
+			Description: g.Description,
+			TTSConfigID: g.TTSConfigID,
+			Voice:       g.Voice,
+			Status:      g.Status,
+		}
+
+		var samples []models.SpeakerSample
+		if err := db.Where("speaker_group_id = ?", g.ID).Order("id ASC").Find(&samples).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range samples {
+			sample := exportedSpeakerSample{UUID: s.UUID, FileName: s.FileName, Duration: s.Duration, Status: s.Status}
+			if data, err := os.ReadFile(s.FilePath); err == nil {
+				sample.Audio = base64.StdEncoding.EncodeToString(data)
+			} else {
+				logger.Warnf("[config_export] 读取声纹样本 %s 音频失败，仅导出元数据: %v", s.UUID, err)
+			}
+			item.Samples = append(item.Samples, sample)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// decodeImportSection 将 YAML 中的某一段解码为指定结构
+func decodeImportSection(importConfig map[string]interface{}, key string, out interface{}) error {
+	raw, exists := importConfig[key]
+	if !exists || raw == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s 格式错误: %v", key, err)
+	}
+	return nil
+}
+
+// importGlobalRoles 导入全局角色（旧表在导入前已清空）
+func importGlobalRoles(tx *gorm.DB, roles []exportedGlobalRole) (entityImportStats, error) {
+	var stats entityImportStats
+	for _, r := range roles {
+		role := models.GlobalRole{Name: r.Name, Description: r.Description, Prompt: r.Prompt, IsDefault: r.IsDefault}
+		if err := tx.Create(&role).Error; err != nil {
+			return stats, err
+		}
+		stats.Created++
+	}
+	return stats, nil
+}
+
+// importRoles 按（所属用户, 名称）新增或更新角色；所属用户在目标环境不存在时跳过
+func importRoles(tx *gorm.DB, roles []exportedRole) (entityImportStats, error) {
+	var stats entityImportStats
+	for _, r := range roles {
+		if r.Name == "" {
+			stats.Skipped++
+			continue
+		}
+		query := tx.Where("name = ?", r.Name)
+		var ownerID *uint
+		if r.Owner != "" {
+			var user models.User
+			if err := tx.Where("username = ?", r.Owner).First(&user).Error; err != nil {
+				stats.Skipped++
+				stats.warnf("角色 %s 的所属用户 %s 不存在，已跳过", r.Name, r.Owner)
+				continue
+			}
+			ownerID = &user.ID
+			query = query.Where("user_id = ?", user.ID)
+		} else {
+			query = query.Where("user_id IS NULL")
+		}
+
+		var role models.Role
+		err := query.First(&role).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return stats, err
+		}
+		exists := err == nil
+
+		role.Name = r.Name
+		role.UserID = ownerID
+		role.Description = r.Description
+		role.Prompt = r.Prompt
+		role.Greeting = r.Greeting
+		role.LLMConfigID = r.LLMConfigID
+		role.TTSConfigID = r.TTSConfigID
+		role.Voice = r.Voice
+		role.RoleType = r.RoleType
+		if role.RoleType == "" {
+			role.RoleType = "global"
+			if ownerID != nil {
+				role.RoleType = "user"
+			}
+		}
+		role.Status = normalizeRoleStatus(r.Status)
+		role.SortOrder = r.SortOrder
+		role.IsDefault = r.IsDefault && role.RoleType == "global"
+		if role.IsDefault {
+			if err := tx.Model(&models.Role{}).Where("role_type = ? AND is_default = ?", "global", true).Update("is_default", false).Error; err != nil {
+				return stats, err
+			}
+		}
+		if err := tx.Save(&role).Error; err != nil {
+			return stats, err
+		}
+		if exists {
+			stats.Updated++
+		} else {
+			stats.Created++
+		}
+	}
+	return stats, nil
+}
+
+// importSpeakerGroups 导入声纹组及样本：声纹组按（用户, 名称）新增或更新，样本需保存音频并向声纹服务注册，
+// 因涉及文件与外部服务，在配置事务提交后逐个执行；样本 UUID 已被其他声纹组占用时重新生成
+func (ac *AdminController) importSpeakerGroups(groups []exportedSpeakerGroup) entityImportStats {
+	var stats entityImportStats
+	for _, g := range groups {
+		var user models.User
+		if err := ac.DB.Where("username = ?", g.Owner).First(&user).Error; err != nil {
+			stats.Skipped++
+			stats.warnf("声纹组 %s 的所属用户 %s 不存在，已跳过", g.Name, g.Owner)
+			continue
+		}
+		var agent models.Agent
+		if err := ac.DB.Where("user_id = ? AND name = ?", user.ID, g.Agent).First(&agent).Error; err != nil {
+			stats.Skipped++
+			stats.warnf("声纹组 %s 的智能体 %s 不存在，已跳过", g.Name, g.Agent)
+			continue
+		}
+
+		var group models.SpeakerGroup
+		err := ac.DB.Where("user_id = ? AND name = ?", user.ID, g.Name).First(&group).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			stats.warnf("查询声纹组 %s 失败: %v", g.Name, err)
+			continue
+		}
+		exists := err == nil
+		group.UserID = user.ID
+		group.AgentID = agent.ID
+		group.Name = g.Name
+		group.Prompt = g.Prompt
+		group.Description = g.Description
+		group.TTSConfigID = g.TTSConfigID
+		group.Voice = g.Voice
+		group.Status = g.Status
+		if group.Status == "" {
+			group.Status = "active"
+		}
+		if err := ac.DB.Save(&group).Error; err != nil {
+			stats.warnf("保存声纹组 %s 失败: %v", g.Name, err)
+			continue
+		}
+		if exists {
+			stats.Updated++
+		} else {
+			stats.Created++
+		}
+
+		for _, s := range g.Samples {
+			ac.importSpeakerSample(&group, s, &stats)
+		}
+	}
+	return stats
+}
+
+// importSpeakerSample 导入单个声纹样本
+func (ac *AdminController) importSpeakerSample(group *models.SpeakerGroup, s exportedSpeakerSample, stats *entityImportStats) {
+	if s.Audio == "" {
+		stats.SamplesFailed++
+		stats.warnf("声纹组 %s 的样本 %s 缺少音频数据，已跳过", group.Name, s.UUID)
+		return
+	}
+	if ac.SpeakerGroups == nil {
+		stats.SamplesFailed++
+		stats.warnf("声纹服务未初始化，声纹组 %s 的样本 %s 未导入", group.Name, s.UUID)
+		return
+	}
+	audio, err := base64.StdEncoding.DecodeString(s.Audio)
+	if err != nil {
+		stats.SamplesFailed++
+		stats.warnf("声纹组 %s 的样本 %s 音频解码失败: %v", group.Name, s.UUID, err)
+		return
+	}
+
+	sampleUUID := s.UUID
+	if sampleUUID != "" {
+		var existing models.SpeakerSample
+		if err := ac.DB.Where("uuid = ?", sampleUUID).First(&existing).Error; err == nil {
+			if existing.SpeakerGroupID == group.ID {
+				// 已导入过，保持幂等
+				return
+			}
+			sampleUUID = ""
+			stats.UUIDRemapped++
+		}
+	}
+	if sampleUUID == "" {
+		sampleUUID = uuid.New().String()
+	}
+	fileName := s.FileName
+	if fileName == "" {
+		fileName = sampleUUID + ".wav"
+	}
+
+	sgc := ac.SpeakerGroups
+	filePath, fileSize, err := sgc.AudioStorage.SaveAudioFile(group.UserID, group.ID, sampleUUID, fileName, bytes.NewReader(audio))
+	if err != nil {
+		stats.SamplesFailed++
+		stats.warnf("保存声纹样本 %s 音频失败: %v", sampleUUID, err)
+		return
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		sgc.AudioStorage.DeleteAudioFile(filePath)
+		stats.SamplesFailed++
+		stats.warnf("打开声纹样本 %s 音频失败: %v", sampleUUID, err)
+		return
+	}
+	err = sgc.callRegisterAPI(fmt.Sprintf("%d", group.ID), group.Name, sampleUUID, group.AgentID, file, &multipart.FileHeader{Filename: fileName}, group.UserID)
+	file.Close()
+	if err != nil {
+		sgc.AudioStorage.DeleteAudioFile(filePath)
+		stats.SamplesFailed++
+		stats.warnf("注册声纹样本 %s 失败: %v", sampleUUID, err)
+		return
+	}
+
+	sample := models.SpeakerSample{
+		SpeakerGroupID: group.ID,
+		UserID:         group.UserID,
+		UUID:           sampleUUID,
+		FilePath:       filePath,
+		FileName:       fileName,
+		FileSize:       fileSize,
+		Duration:       s.Duration,
+		Status:         "active",
+	}
+	if err := ac.DB.Create(&sample).Error; err != nil {
+		sgc.AudioStorage.DeleteAudioFile(filePath)
+		sgc.callDeleteAPI(sampleUUID, group.AgentID, group.UserID, sampleUUID)
+		stats.SamplesFailed++
+		stats.warnf("保存声纹样本 %s 记录失败: %v", sampleUUID, err)
+		return
+	}
+	ac.DB.Model(group).Update("sample_count", gorm.Expr("sample_count + 1"))
+	stats.SamplesCreated++
+}
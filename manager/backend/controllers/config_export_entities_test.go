@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestExportImportRolesRoundTrip(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.Role{})
+	alice := models.User{Username: "alice", Password: "x", Role: "user"}
+	if err := db.Create(&alice).Error; err != nil {
+		t.Fatal(err)
+	}
+	seed := []models.Role{
+		{Name: "助手", RoleType: "global", Status: "active", IsDefault: true, Prompt: "p1"},
+		{Name: "老师", RoleType: "user", UserID: &alice.ID, Status: "active", Greeting: "同学好"},
+	}
+	if err := db.Create(&seed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	usernames, err := usernamesByID(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := collectExportRoles(db, usernames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 2 || exported[1].Owner != "alice" {
+		t.Fatalf("exported = %+v", exported)
+	}
+
+	// 再次导入：已有角色更新，所属用户不存在的角色跳过
+	exported[1].Prompt = "新提示词"
+	exported = append(exported, exportedRole{Name: "孤儿", Owner: "bob", RoleType: "user"})
+	stats, err := importRoles(db, exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Created != 0 || stats.Updated != 2 || stats.Skipped != 1 || len(stats.Warnings) != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	var roles []models.Role
+	db.Order("id ASC").Find(&roles)
+	if len(roles) != 2 || roles[1].Prompt != "新提示词" || roles[1].Greeting != "同学好" || !roles[0].IsDefault {
+		t.Fatalf("roles after import = %+v", roles)
+	}
+}
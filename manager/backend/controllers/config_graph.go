@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 配置依赖图：设备 → 角色/智能体 → LLM/TTS 配置，用于评估修改或删除配置的影响范围
+
+type configGraphNode struct {
+	ID    string                 `json:"id"`
+	Kind  string                 `json:"kind"` // device/agent/role/config
+	Label string                 `json:"label"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+type configGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"` // agent/role/llm/tts
+	// Implicit 未显式指定配置、回落到该类型默认配置的引用
+	Implicit bool `json:"implicit,omitempty"`
+}
+
+type configGraph struct {
+	Nodes []configGraphNode `json:"nodes"`
+	Edges []configGraphEdge `json:"edges"`
+}
+
+func configGraphConfigNodeID(typ, configID string) string {
+	return "config:" + typ + ":" + configID
+}
+
+// buildConfigGraph 由设备、智能体、角色与 LLM/TTS 配置构建依赖图；引用了不存在的配置时生成 missing 节点
+func buildConfigGraph(devices []models.Device, agents []models.Agent, roles []models.Role, configs []models.Config) configGraph {
+	graph := configGraph{Nodes: make([]configGraphNode, 0), Edges: make([]configGraphEdge, 0)}
+	nodeIDs := make(map[string]bool)
+	addNode := func(node configGraphNode) {
+		if !nodeIDs[node.ID] {
+			nodeIDs[node.ID] = true
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+
+	defaults := make(map[string]string)
+	for _, cfg := range configs {
+		if cfg.Type != "llm" && cfg.Type != "tts" {
+			continue
+		}
+		addNode(configGraphNode{
+			ID:    configGraphConfigNodeID(cfg.Type, cfg.ConfigID),
+			Kind:  "config",
+			Label: cfg.Name,
+			Attrs: map[string]interface{}{"type": cfg.Type, "config_id": cfg.ConfigID, "provider": cfg.Provider, "is_default": cfg.IsDefault, "enabled": cfg.Enabled},
+		})
+		if cfg.IsDefault {
+			defaults[cfg.Type] = cfg.ConfigID
+		}
+	}
+
+	// linkConfig 连接到指定配置；未指定时连接到该类型默认配置
+	linkConfig := func(source, typ string, configID *string) {
+		id, implicit := "", false
+		if configID != nil {
+			id = strings.TrimSpace(*configID)
+		}
+		if id == "" {
+			id, implicit = defaults[typ], true
+		}
+		if id == "" {
+			return
+		}
+		target := configGraphConfigNodeID(typ, id)
+		addNode(configGraphNode{ID: target, Kind: "config", Label: id, Attrs: map[string]interface{}{"type": typ, "config_id": id, "missing": true}})
+		graph.Edges = append(graph.Edges, configGraphEdge{Source: source, Target: target, Type: typ, Implicit: implicit})
+	}
+
+	for _, agent := range agents {
+		id := fmt.Sprintf("agent:%d", agent.ID)
+		addNode(configGraphNode{ID: id, Kind: "agent", Label: agent.Name, Attrs: map[string]interface{}{"user_id": agent.UserID, "status": agent.Status}})
+		linkConfig(id, "llm", agent.LLMConfigID)
+		linkConfig(id, "tts", agent.TTSConfigID)
+	}
+	for _, role := range roles {
+		id := fmt.Sprintf("role:%d", role.ID)
+		addNode(configGraphNode{ID: id, Kind: "role", Label: role.Name, Attrs: map[string]interface{}{"role_type": role.RoleType, "status": role.Status}})
+		linkConfig(id, "llm", role.LLMConfigID)
+		linkConfig(id, "tts", role.TTSConfigID)
+	}
+	for _, device := range devices {
+		id := fmt.Sprintf("device:%d", device.ID)
+		label := device.DeviceName
+		if label == "" {
+			label = device.DeviceCode
+		}
+		addNode(configGraphNode{ID: id, Kind: "device", Label: label, Attrs: map[string]interface{}{"user_id": device.UserID, "activated": device.Activated}})
+		if device.AgentID != 0 && nodeIDs[fmt.Sprintf("agent:%d", device.AgentID)] {
+			graph.Edges = append(graph.Edges, configGraphEdge{Source: id, Target: fmt.Sprintf("agent:%d", device.AgentID), Type: "agent"})
+		}
+		if device.RoleID != nil && nodeIDs[fmt.Sprintf("role:%d", *device.RoleID)] {
+			graph.Edges = append(graph.Edges, configGraphEdge{Source: id, Target: fmt.Sprintf("role:%d", *device.RoleID), Type: "role"})
+		}
+	}
+	return graph
+}
+
+// ExportConfigGraph 导出设备/智能体/角色/配置的依赖关系图（nodes + edges）
+func (ac *AdminController) ExportConfigGraph(c *gin.Context) {
+	var devices []models.Device
+	var agents []models.Agent
+	var roles []models.Role
+	var configs []models.Config
+	if err := ac.DB.Order("id ASC").Find(&devices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取设备失败"})
+		return
+	}
+	if err := ac.DB.Order("id ASC").Find(&agents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取智能体失败"})
+		return
+	}
+	if err := ac.DB.Order("id ASC").Find(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取角色失败"})
+		return
+	}
+	if err := ac.DB.Where("type IN ?", []string{"llm", "tts"}).Order("id ASC").Find(&configs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": buildConfigGraph(devices, agents, roles, configs)})
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestBuildConfigGraph(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	uintPtr := func(v uint) *uint { return &v }
+
+	configs := []models.Config{
+		{Type: "llm", ConfigID: "deepseek", Name: "DeepSeek", IsDefault: true},
+		{Type: "tts", ConfigID: "edge", Name: "Edge", IsDefault: true},
+	}
+	agents := []models.Agent{{ID: 1, Name: "客厅助手", LLMConfigID: strPtr("qwen")}}
+	roles := []models.Role{{ID: 2, Name: "老师", TTSConfigID: strPtr("edge")}}
+	devices := []models.Device{{ID: 3, DeviceName: "box", AgentID: 1, RoleID: uintPtr(2)}}
+
+	graph := buildConfigGraph(devices, agents, roles, configs)
+
+	nodes := make(map[string]configGraphNode)
+	for _, n := range graph.Nodes {
+		nodes[n.ID] = n
+	}
+	if n, ok := nodes["config:llm:qwen"]; !ok || n.Attrs["missing"] != true {
+		t.Fatalf("missing config node not created: %+v", n)
+	}
+
+	type edgeKey struct{ source, target string }
+	edges := make(map[edgeKey]configGraphEdge)
+	for _, e := range graph.Edges {
+		edges[edgeKey{e.Source, e.Target}] = e
+	}
+	want := map[edgeKey]bool{
+		{"agent:1", "config:llm:qwen"}:    false,
+		{"agent:1", "config:tts:edge"}:    true, // 未指定 TTS，回落到默认配置
+		{"role:2", "config:llm:deepseek"}: true,
+		{"role:2", "config:tts:edge"}:     false,
+		{"device:3", "agent:1"}:           false,
+		{"device:3", "role:2"}:            false,
+	}
+	if len(graph.Edges) != len(want) {
+		t.Fatalf("edges = %+v", graph.Edges)
+	}
+	for key, implicit := range want {
+		e, ok := edges[key]
+		if !ok || e.Implicit != implicit {
+			t.Fatalf("edge %v = %+v (found=%v), want implicit=%v", key, e, ok, implicit)
+		}
+	}
+}
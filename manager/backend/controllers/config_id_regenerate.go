@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 支持重新生成 config_id 的配置类型；vision_base 等固定 ID 的配置不参与
+var regenerableConfigIDTypes = []string{"vad", "asr", "llm", "tts", "vision", "memory"}
+
+// 固定 config_id，被主程序按 ID 识别，不能重命名
+var fixedConfigIDs = map[string]bool{"vision/vision_base": true}
+
+// configIDSafeName 将配置名称转换为 config_id 中的名称段
+func configIDSafeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(name, " ", "_"), "-", "_"))
+}
+
+// generateConfigID 按 类型_名称_时间戳 的约定生成 config_id
+func generateConfigID(typ, name string, timestamp int64) string {
+	return fmt.Sprintf("%s_%s_%d", typ, configIDSafeName(name), timestamp)
+}
+
+// configIDFollowsConvention 判断 config_id 是否符合 类型_名称_时间戳[_序号] 约定（名称段取当前名称）
+func configIDFollowsConvention(config models.Config) bool {
+	prefix := config.Type + "_" + configIDSafeName(config.Name) + "_"
+	if !strings.HasPrefix(config.ConfigID, prefix) {
+		return false
+	}
+	return configIDTimestampSuffix.MatchString(strings.TrimPrefix(config.ConfigID, prefix))
+}
+
+var configIDTimestampSuffix = regexp.MustCompile(`^\d+(_\d+)?$`)
+
+// configIDReference 引用 config_id 的表字段
+type configIDReference struct {
+	label  string
+	model  interface{}
+	column string
+}
+
+// configIDReferences 返回引用指定类型配置的表字段，与 configUsages 覆盖范围一致，另含复刻额度
+func configIDReferences(typ string) []configIDReference {
+	switch typ {
+	case "llm":
+		return []configIDReference{
+			{"智能体", &models.Agent{}, "llm_config_id"},
+			{"角色", &models.Role{}, "llm_config_id"},
+		}
+	case "tts":
+		return []configIDReference{
+			{"智能体", &models.Agent{}, "tts_config_id"},
+			{"角色", &models.Role{}, "tts_config_id"},
+			{"声纹组", &models.SpeakerGroup{}, "tts_config_id"},
+			{"复刻音色", &models.VoiceClone{}, "tts_config_id"},
+			{"复刻额度", &models.UserVoiceCloneQuota{}, "tts_config_id"},
+		}
+	}
+	return nil
+}
+
+// configIDChange 单个配置的 config_id 变更
+type configIDChange struct {
+	ID                uint           `json:"id"`
+	Type              string         `json:"type"`
+	Name              string         `json:"name"`
+	OldConfigID       string         `json:"old_config_id"`
+	NewConfigID       string         `json:"new_config_id"`
+	ReferencesUpdated map[string]int `json:"references_updated,omitempty"`
+}
+
+// planConfigIDRegeneration 为不符合约定的配置分配新 config_id；时间戳取配置创建时间，同类型冲突时追加序号
+func planConfigIDRegeneration(configs []models.Config) []configIDChange {
+	taken := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		taken[config.Type+"/"+config.ConfigID] = true
+	}
+	changes := make([]configIDChange, 0)
+	for _, config := range configs {
+		if fixedConfigIDs[config.Type+"/"+config.ConfigID] || configIDFollowsConvention(config) {
+			continue
+		}
+		timestamp := config.CreatedAt.Unix()
+		if config.CreatedAt.IsZero() {
+			timestamp = time.Now().Unix()
+		}
+		base := generateConfigID(config.Type, config.Name, timestamp)
+		newID := base
+		for n := 2; taken[config.Type+"/"+newID] || len(newID) > 100; n++ {
+			if len(base) > 90 {
+				base = base[:90]
+			}
+			newID = fmt.Sprintf("%s_%d", base, n)
+		}
+		taken[config.Type+"/"+newID] = true
+		changes = append(changes, configIDChange{
+			ID:          config.ID,
+			Type:        config.Type,
+			Name:        config.Name,
+			OldConfigID: config.ConfigID,
+			NewConfigID: newID,
+		})
+	}
+	return changes
+}
+
+// applyConfigIDChanges 在事务内更新配置及其全部引用
+func applyConfigIDChanges(tx *gorm.DB, changes []configIDChange) error {
+	for i := range changes {
+		change := &changes[i]
+		if err := tx.Model(&models.Config{}).Where("id = ?", change.ID).Update("config_id", change.NewConfigID).Error; err != nil {
+			return fmt.Errorf("更新配置 %s 失败: %w", change.OldConfigID, err)
+		}
+		for _, ref := range configIDReferences(change.Type) {
+			result := tx.Model(ref.model).Where(ref.column+" = ?", change.OldConfigID).Update(ref.column, change.NewConfigID)
+			if result.Error != nil {
+				return fmt.Errorf("更新%s对配置 %s 的引用失败: %w", ref.label, change.OldConfigID, result.Error)
+			}
+			if result.RowsAffected > 0 {
+				if change.ReferencesUpdated == nil {
+					change.ReferencesUpdated = make(map[string]int)
+				}
+				change.ReferencesUpdated[ref.label] += int(result.RowsAffected)
+			}
+		}
+	}
+	if err := rewriteSnapshotConfigIDs(tx, changes); err != nil {
+		return err
+	}
+	return rewriteDraftConfigIDs(tx, changes)
+}
+
+// configIDChangeIndex 按 类型/旧config_id 索引变更
+func configIDChangeIndex(changes []configIDChange) map[string]*configIDChange {
+	index := make(map[string]*configIDChange, len(changes))
+	for i := range changes {
+		index[changes[i].Type+"/"+changes[i].OldConfigID] = &changes[i]
+	}
+	return index
+}
+
+// countReference 在变更上累计某类引用的更新数量
+func (change *configIDChange) countReference(label string) {
+	if change.ReferencesUpdated == nil {
+		change.ReferencesUpdated = make(map[string]int)
+	}
+	change.ReferencesUpdated[label]++
+}
+
+// rewriteSnapshotConfigIDs 同步改写最近可用快照中的 config_id，避免恢复快照时写回旧 ID
+func rewriteSnapshotConfigIDs(tx *gorm.DB, changes []configIDChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	index := configIDChangeIndex(changes)
+	var snapshots []models.ConfigGoodSnapshot
+	if err := tx.Find(&snapshots).Error; err != nil {
+		return fmt.Errorf("查询最近可用快照失败: %w", err)
+	}
+	for _, snapshot := range snapshots {
+		var configs []models.Config
+		if err := json.Unmarshal([]byte(snapshot.ConfigsJSON), &configs); err != nil {
+			logger.Warnf("解析最近可用快照失败，跳过 config_id 改写: type=%s err=%v", snapshot.ConfigType, err)
+			continue
+		}
+		changed := false
+		for i := range configs {
+			if change, ok := index[configs[i].Type+"/"+configs[i].ConfigID]; ok {
+				configs[i].ConfigID = change.NewConfigID
+				change.countReference("最近可用快照")
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		configsJSON, err := json.Marshal(configs)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ConfigGoodSnapshot{}).Where("id = ?", snapshot.ID).Update("configs", string(configsJSON)).Error; err != nil {
+			return fmt.Errorf("更新最近可用快照 %s 失败: %w", snapshot.ConfigType, err)
+		}
+	}
+	return nil
+}
+
+// rewriteDraftConfigIDs 同步改写配置草稿包中的 config_id，避免提升草稿时按旧 ID 新建配置
+func rewriteDraftConfigIDs(tx *gorm.DB, changes []configIDChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	index := configIDChangeIndex(changes)
+	var bundles []models.ConfigDraftBundle
+	if err := tx.Find(&bundles).Error; err != nil {
+		return fmt.Errorf("查询配置草稿包失败: %w", err)
+	}
+	for i := range bundles {
+		bundle := &bundles[i]
+		items, err := decodeConfigDraftItems(bundle)
+		if err != nil {
+			logger.Warnf("解析配置草稿包失败，跳过 config_id 改写: bundle_id=%d err=%v", bundle.ID, err)
+			continue
+		}
+		changed := false
+		for j := range items {
+			if change, ok := index[items[j].Type+"/"+items[j].ConfigID]; ok {
+				items[j].ConfigID = change.NewConfigID
+				change.countReference("配置草稿包")
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		itemsJSON, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ConfigDraftBundle{}).Where("id = ?", bundle.ID).Update("items", string(itemsJSON)).Error; err != nil {
+			return fmt.Errorf("更新配置草稿包 %s 失败: %w", bundle.Name, err)
+		}
+	}
+	return nil
+}
+
+// RegenerateConfigIDs 将不符合 类型_名称_时间戳 约定的 config_id 重新生成，并在同一事务内更新智能体/角色等引用
+// POST /api/admin/configs/regenerate-ids，body: {types?: [...], dry_run?: bool}；返回 旧→新 映射
+func (ac *AdminController) RegenerateConfigIDs(c *gin.Context) {
+	var req struct {
+		Types  []string `json:"types"`
+		DryRun bool     `json:"dry_run"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	types := req.Types
+	if len(types) == 0 {
+		types = regenerableConfigIDTypes
+	}
+	selected := make([]string, 0, len(types))
+	for _, typ := range types {
+		typ = strings.TrimSpace(typ)
+		if !contains(regenerableConfigIDTypes, typ) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持重新生成 config_id 的类型: %s", typ)})
+			return
+		}
+		if isConfigTypeReadOnly(typ) {
+			continue
+		}
+		selected = append(selected, typ)
+	}
+
+	var changes []configIDChange
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		var configs []models.Config
+		if err := tx.Where("type IN ?", selected).Order("id ASC").Find(&configs).Error; err != nil {
+			return err
+		}
+		changes = planConfigIDRegeneration(configs)
+		if req.DryRun {
+			return nil
+		}
+		return applyConfigIDChanges(tx, changes)
+	})
+	if err != nil {
+		logger.Errorf("重新生成 config_id 失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新生成 config_id 失败: " + err.Error()})
+		return
+	}
+	if !req.DryRun && len(changes) > 0 {
+		logger.Infof("已重新生成 %d 个 config_id", len(changes))
+		ac.notifySystemConfigChanged()
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"dry_run": req.DryRun,
+		"changed": len(changes),
+		"mapping": changes,
+	}})
+}
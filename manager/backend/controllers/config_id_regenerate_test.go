@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConfigIDFollowsConvention(t *testing.T) {
+	cases := []struct {
+		config models.Config
+		want   bool
+	}{
+		{models.Config{Type: "llm", Name: "Qwen Max", ConfigID: "llm_qwen_max_1700000000"}, true},
+		{models.Config{Type: "llm", Name: "Qwen Max", ConfigID: "llm_qwen_max_1700000000_2"}, true},
+		{models.Config{Type: "llm", Name: "Qwen Max", ConfigID: "qwen-max"}, false},
+		{models.Config{Type: "llm", Name: "Qwen Max", ConfigID: "llm_qwen_1700000000"}, false},
+	}
+	for _, tc := range cases {
+		if got := configIDFollowsConvention(tc.config); got != tc.want {
+			t.Errorf("configIDFollowsConvention(%q) = %v, want %v", tc.config.ConfigID, got, tc.want)
+		}
+	}
+}
+
+func TestRegenerateConfigIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{}, &models.Agent{}, &models.Role{}, &models.SpeakerGroup{},
+		&models.VoiceClone{}, &models.UserVoiceCloneQuota{}, &models.ConfigGoodSnapshot{}, &models.ConfigDraftBundle{})
+	created := time.Unix(1700000000, 0)
+	for _, cfg := range []models.Config{
+		{Type: "llm", Name: "Qwen", ConfigID: "imported-qwen", Provider: "openai", JsonData: `{}`, CreatedAt: created},
+		{Type: "llm", Name: "Qwen", ConfigID: "imported-qwen-copy", Provider: "openai", JsonData: `{}`, CreatedAt: created},
+		{Type: "llm", Name: "Ok", ConfigID: "llm_ok_1600000000", Provider: "openai", JsonData: `{}`, CreatedAt: created},
+		{Type: "tts", Name: "Edge", ConfigID: "edge", Provider: "edge", JsonData: `{}`, CreatedAt: created},
+		{Type: "vision", Name: "base", ConfigID: "vision_base", JsonData: `{}`, CreatedAt: created},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	llmRef, ttsRef := "imported-qwen", "edge"
+	if err := db.Create(&models.Agent{UserID: 1, Name: "a", LLMConfigID: &llmRef, TTSConfigID: &ttsRef}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Role{Name: "r", LLMConfigID: &llmRef}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.UserVoiceCloneQuota{UserID: 1, TTSConfigID: "edge"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var llmConfigs []models.Config
+	db.Where("type = ?", "llm").Order("id").Find(&llmConfigs)
+	snapshotJSON, _ := json.Marshal(llmConfigs)
+	if err := db.Create(&models.ConfigGoodSnapshot{ConfigType: "llm", ConfigsJSON: string(snapshotJSON), ConfigCount: len(llmConfigs)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	draft := models.ConfigDraftBundle{Name: "d", Status: configDraftStatusDraft,
+		ItemsJSON: `[{"type":"tts","config_id":"edge","name":"Edge","json_data":{"voice":"v"}},{"type":"llm","config_id":"edge","name":"same id other type"}]`}
+	if err := db.Create(&draft).Error; err != nil {
+		t.Fatal(err)
+	}
+	snapshotIDs := func() []string {
+		var snapshot models.ConfigGoodSnapshot
+		db.Where("config_type = ?", "llm").First(&snapshot)
+		var configs []models.Config
+		if err := json.Unmarshal([]byte(snapshot.ConfigsJSON), &configs); err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, 0, len(configs))
+		for _, config := range configs {
+			ids = append(ids, config.ConfigID)
+		}
+		return ids
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/regenerate", ac.RegenerateConfigIDs)
+	post := func(body string) map[string]string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/regenerate", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST %s = %d %s", body, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data struct {
+				Mapping []configIDChange `json:"mapping"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		mapping := make(map[string]string)
+		for _, change := range resp.Data.Mapping {
+			mapping[change.OldConfigID] = change.NewConfigID
+		}
+		return mapping
+	}
+
+	// 预演不落库
+	if mapping := post(`{"dry_run":true}`); len(mapping) != 3 {
+		t.Fatalf("dry run mapping = %v", mapping)
+	}
+	var agent models.Agent
+	db.First(&agent)
+	if *agent.LLMConfigID != "imported-qwen" || snapshotIDs()[0] != "imported-qwen" {
+		t.Fatal("dry run should not modify references")
+	}
+
+	mapping := post(`{}`)
+	want := map[string]string{
+		"imported-qwen":      "llm_qwen_1700000000",
+		"imported-qwen-copy": "llm_qwen_1700000000_2",
+		"edge":               "tts_edge_1700000000",
+	}
+	if len(mapping) != len(want) {
+		t.Fatalf("mapping = %v, want %v", mapping, want)
+	}
+	for old, newID := range want {
+		if mapping[old] != newID {
+			t.Fatalf("mapping[%s] = %s, want %s", old, mapping[old], newID)
+		}
+	}
+
+	db.First(&agent)
+	var role models.Role
+	db.First(&role)
+	var quota models.UserVoiceCloneQuota
+	db.First(&quota)
+	if *agent.LLMConfigID != "llm_qwen_1700000000" || *agent.TTSConfigID != "tts_edge_1700000000" ||
+		*role.LLMConfigID != "llm_qwen_1700000000" || quota.TTSConfigID != "tts_edge_1700000000" {
+		t.Fatalf("references not updated: agent=%s/%s role=%s quota=%s", *agent.LLMConfigID, *agent.TTSConfigID, *role.LLMConfigID, quota.TTSConfigID)
+	}
+	// 快照与草稿包中的 config_id 同步改写，其他类型的同名 ID 不受影响
+	if ids := snapshotIDs(); strings.Join(ids, ",") != "llm_qwen_1700000000,llm_qwen_1700000000_2,llm_ok_1600000000" {
+		t.Fatalf("snapshot config_ids = %v", ids)
+	}
+	db.First(&draft, draft.ID)
+	items, err := decodeConfigDraftItems(&draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].ConfigID != "tts_edge_1700000000" || items[1].ConfigID != "edge" {
+		t.Fatalf("draft items = %+v", items)
+	}
+	if v, _ := items[0].JsonData.(map[string]interface{}); v["voice"] != "v" {
+		t.Fatalf("draft json_data lost: %+v", items[0].JsonData)
+	}
+
+	// 再次执行应无变更
+	if mapping := post(`{}`); len(mapping) != 0 {
+		t.Fatalf("second run mapping = %v", mapping)
+	}
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// configTypeTestPassed 判断某类型的测试结果是否全部通过：至少一条真实结果且没有 _error/_none 等占位项
+func configTypeTestPassed(typeResult interface{}) bool {
+	m := asTestResultMap(typeResult)
+	if len(m) == 0 {
+		return false
+	}
+	for key, val := range m {
+		if strings.HasPrefix(key, "_") || key == "provider" {
+			return false
+		}
+		item := asTestResultMap(val)
+		if item == nil {
+			return false
+		}
+		if ok, _ := item["ok"].(bool); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshotLastGoodConfigs 对测试全部通过的类型保存当前配置快照
+// 仅在测试的是已保存配置（非请求体覆盖）且覆盖该类型全部已启用配置时调用
+func (ac *AdminController) snapshotLastGoodConfigs(types []string, result gin.H) {
+	for _, typ := range types {
+		if !configTypeTestPassed(result[typ]) {
+			continue
+		}
+		var configs []models.Config
+		if err := ac.DB.Where("type = ?", typ).Order("id ASC").Find(&configs).Error; err != nil {
+			logger.Errorf("保存最近可用配置快照失败: type=%s err=%v", typ, err)
+			continue
+		}
+		if len(configs) == 0 {
+			continue
+		}
+		configsJSON, err := json.Marshal(configs)
+		if err != nil {
+			logger.Warnf("序列化最近可用配置快照失败: type=%s err=%v", typ, err)
+			continue
+		}
+		resultJSON, _ := json.Marshal(result[typ])
+
+		snapshot := models.ConfigGoodSnapshot{ConfigType: typ}
+		err = ac.DB.Where("config_type = ?", typ).
+			Assign(models.ConfigGoodSnapshot{
+				ConfigsJSON: string(configsJSON),
+				ConfigCount: len(configs),
+				TestResult:  string(resultJSON),
+			}).
+			FirstOrCreate(&snapshot).Error
+		if err != nil {
+			logger.Errorf("保存最近可用配置快照失败: type=%s err=%v", typ, err)
+			continue
+		}
+		logger.Infof("已更新最近可用配置快照: type=%s configs=%d", typ, len(configs))
+	}
+}
+
+// GetLastGoodConfigs 获取各类型最近可用配置快照概要
+func (ac *AdminController) GetLastGoodConfigs(c *gin.Context) {
+	var snapshots []models.ConfigGoodSnapshot
+	if err := ac.DB.Order("config_type ASC").Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取最近可用配置快照失败"})
+		return
+	}
+	data := make([]gin.H, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var configs []models.Config
+		_ = json.Unmarshal([]byte(snapshot.ConfigsJSON), &configs)
+		configIDs := make([]string, 0, len(configs))
+		for _, config := range configs {
+			configIDs = append(configIDs, config.ConfigID)
+		}
+		data = append(data, gin.H{
+			"config_type":  snapshot.ConfigType,
+			"config_count": snapshot.ConfigCount,
+			"config_ids":   configIDs,
+			"snapshot_at":  snapshot.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// RestoreLastGoodConfig 将指定类型的配置恢复为最近可用快照
+// 快照中的配置按 type+config_id 覆盖写回；快照之后新增的配置保留，但不再作为默认配置
+func (ac *AdminController) RestoreLastGoodConfig(c *gin.Context) {
+	typ := strings.TrimSpace(c.Param("type"))
+	if typ == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "配置类型不能为空"})
+		return
+	}
+	if rejectReadOnlyConfigType(c, typ) {
+		return
+	}
+
+	restored, err := ac.restoreLastGoodConfig(typ)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "该类型暂无最近可用配置快照"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复配置失败: " + err.Error()})
+		return
+	}
+
+	ac.notifySystemConfigChanged()
+	logger.Infof("已恢复最近可用配置: type=%s configs=%d", typ, len(restored))
+	c.JSON(http.StatusOK, gin.H{"message": "已恢复为最近可用配置", "data": restored})
+}
+
+func (ac *AdminController) restoreLastGoodConfig(typ string) ([]models.Config, error) {
+	var snapshot models.ConfigGoodSnapshot
+	if err := ac.DB.Where("config_type = ?", typ).First(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	var configs []models.Config
+	if err := json.Unmarshal([]byte(snapshot.ConfigsJSON), &configs); err != nil {
+		return nil, fmt.Errorf("快照数据解析失败: %v", err)
+	}
+
+	restored := make([]models.Config, 0, len(configs))
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		hasDefault := false
+		for _, item := range configs {
+			if item.IsDefault {
+				hasDefault = true
+				break
+			}
+		}
+		if hasDefault {
+			if err := tx.Model(&models.Config{}).Where("type = ? AND is_default = ?", typ, true).Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, item := range configs {
+			var config models.Config
+			err := tx.Where("type = ? AND config_id = ?", typ, item.ConfigID).First(&config).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			isNew := err == gorm.ErrRecordNotFound
+
+			config.Type = typ
+			config.ConfigID = item.ConfigID
+			config.Name = item.Name
+			config.Provider = item.Provider
+			config.JsonData = item.JsonData
+			config.Enabled = item.Enabled
+			config.IsDefault = item.IsDefault
+
+			if isNew {
+				config.ID = 0
+				err = tx.Create(&config).Error
+				// enabled 带 default:true，创建时 false 会被忽略，需要单独写回
+				if err == nil && !item.Enabled {
+					err = tx.Model(&config).Update("enabled", false).Error
+				}
+			} else {
+				err = tx.Save(&config).Error
+			}
+			if err != nil {
+				return fmt.Errorf("写入配置 %s/%s 失败: %v", typ, item.ConfigID, err)
+			}
+			restored = append(restored, config)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 只读配置类型：由运维在配置文件中锁定（如 mqtt/ota），管理端对这些类型的新增、修改、删除返回 403
+
+var (
+	readOnlyConfigTypesMu sync.RWMutex
+	readOnlyConfigTypes   = map[string]bool{}
+)
+
+// SetReadOnlyConfigTypes 按配置文件设置只读的配置类型
+func SetReadOnlyConfigTypes(types []string) {
+	locked := make(map[string]bool, len(types))
+	for _, typ := range types {
+		if typ = strings.ToLower(strings.TrimSpace(typ)); typ != "" {
+			locked[typ] = true
+		}
+	}
+	readOnlyConfigTypesMu.Lock()
+	readOnlyConfigTypes = locked
+	readOnlyConfigTypesMu.Unlock()
+}
+
+func isConfigTypeReadOnly(configType string) bool {
+	readOnlyConfigTypesMu.RLock()
+	defer readOnlyConfigTypesMu.RUnlock()
+	return readOnlyConfigTypes[strings.ToLower(strings.TrimSpace(configType))]
+}
+
+// rejectReadOnlyConfigType 配置类型被锁定时写入 403 并返回 true
+func rejectReadOnlyConfigType(c *gin.Context, configType string) bool {
+	if !isConfigTypeReadOnly(configType) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("配置类型 %s 已锁定为只读，不允许修改", configType)})
+	return true
+}
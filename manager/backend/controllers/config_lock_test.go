@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestReadOnlyConfigTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	locked := models.Config{Type: "mqtt", Name: "m", ConfigID: "mqtt1", JsonData: `{}`}
+	if err := db.Create(&locked).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	SetReadOnlyConfigTypes([]string{" MQTT ", ""})
+	defer SetReadOnlyConfigTypes(nil)
+	if !isConfigTypeReadOnly("mqtt") || isConfigTypeReadOnly("tts") {
+		t.Fatal("unexpected read-only set")
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/configs", ac.CreateConfig)
+	r.PUT("/configs/:id", ac.UpdateConfig)
+	r.DELETE("/configs/:id", ac.DeleteConfig)
+	r.POST("/mqtt-configs", ac.CreateMQTTConfig)
+	r.PUT("/mqtt-configs/:id", ac.UpdateMQTTConfig)
+	r.DELETE("/mqtt-configs/:id", ac.DeleteMQTTConfig)
+	r.POST("/configs/bulk-delete", ac.BulkDeleteConfigs)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/configs", `{"type":"mqtt","name":"x"}`},
+		{http.MethodPut, "/configs/1", `{"name":"x"}`},
+		{http.MethodDelete, "/configs/1", ""},
+		{http.MethodPost, "/mqtt-configs", `{"name":"x"}`},
+		{http.MethodPut, "/mqtt-configs/1", `{"name":"x"}`},
+		{http.MethodDelete, "/mqtt-configs/1", ""},
+	} {
+		if w := do(tc.method, tc.path, tc.body); w.Code != http.StatusForbidden {
+			t.Fatalf("%s %s: code=%d body=%s", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+	w := do(http.MethodPost, "/configs/bulk-delete", `{"ids":[1]}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), bulkDeleteStatusReadOnly) {
+		t.Fatalf("bulk delete: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&models.Config{}).Where("type = ?", "mqtt").Count(&count)
+	if count != 1 {
+		t.Fatalf("locked config modified, count=%d", count)
+	}
+
+	if w := do(http.MethodPost, "/configs", `{"type":"tts","name":"t","json_data":"{}"}`); w.Code != http.StatusCreated {
+		t.Fatalf("unlocked type create: code=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestReadOnlyConfigTypesOnTypedHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	seed := []models.Config{
+		{Type: "voice_identify", Name: "s", ConfigID: "speaker1", JsonData: `{}`},
+		{Type: "memory", Name: "m", ConfigID: "memory1", Provider: "mem0", JsonData: `{}`},
+		{Type: "mcp", Name: "p", ConfigID: "mcp1", JsonData: `{}`},
+	}
+	for i := range seed {
+		if err := db.Create(&seed[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetReadOnlyConfigTypes([]string{"voice_identify", "memory", "mcp", "vision", "chat"})
+	defer SetReadOnlyConfigTypes(nil)
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/speaker-configs", ac.CreateSpeakerConfig)
+	r.PUT("/speaker-configs/:id", ac.UpdateSpeakerConfig)
+	r.POST("/memory-configs", ac.CreateMemoryConfig)
+	r.PUT("/memory-configs/:id", ac.UpdateMemoryConfig)
+	r.DELETE("/memory-configs/:id", ac.DeleteMemoryConfig)
+	r.POST("/memory-configs/:id/default", ac.SetDefaultMemoryConfig)
+	r.POST("/mcp-configs", ac.CreateMCPConfig)
+	r.PUT("/mcp-configs/:id", ac.UpdateMCPConfig)
+	r.DELETE("/mcp-configs/:id", ac.DeleteMCPConfig)
+	r.PUT("/vision-base", ac.UpdateVisionBaseConfig)
+	r.PUT("/chat-settings", ac.UpdateChatSettings)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/speaker-configs", `{"name":"new","json_data":"{}"}`},
+		{http.MethodPut, "/speaker-configs/1", `{"name":"x"}`},
+		{http.MethodPost, "/memory-configs", `{"name":"x","provider":"mem0"}`},
+		{http.MethodPut, "/memory-configs/2", `{"name":"x","provider":"mem0"}`},
+		{http.MethodDelete, "/memory-configs/2", ""},
+		{http.MethodPost, "/memory-configs/2/default", ""},
+		{http.MethodPost, "/mcp-configs", `{"name":"x"}`},
+		{http.MethodPut, "/mcp-configs/3", `{"name":"x"}`},
+		{http.MethodDelete, "/mcp-configs/3", ""},
+		{http.MethodPut, "/vision-base", `{"enable_auth":true}`},
+		{http.MethodPut, "/chat-settings", `{"chat":{"realtime_mode":1}}`},
+	} {
+		if w := do(tc.method, tc.path, tc.body); w.Code != http.StatusForbidden {
+			t.Fatalf("%s %s: code=%d body=%s", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	var configs []models.Config
+	db.Order("id").Find(&configs)
+	if len(configs) != len(seed) {
+		t.Fatalf("locked configs modified: %+v", configs)
+	}
+	for i, cfg := range configs {
+		if cfg.Name != seed[i].Name || cfg.IsDefault {
+			t.Fatalf("locked config %s changed: %+v", cfg.ConfigID, cfg)
+		}
+	}
+}
+
+func TestCreateSpeakerConfigReplacesAtomically(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	old := models.Config{Type: "voice_identify", Name: "old", ConfigID: "speaker_old", JsonData: `{}`}
+	if err := db.Create(&old).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Callback().Create().Before("gorm:create").Register("test:fail_create", func(tx *gorm.DB) {
+		if cfg, ok := tx.Statement.Dest.(*models.Config); ok && cfg.Name == "boom" {
+			tx.AddError(errors.New("create failed"))
+		}
+	})
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/speaker-configs", ac.CreateSpeakerConfig)
+	do := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/speaker-configs", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 创建失败时旧配置应保留
+	if w := do(`{"name":"boom","json_data":"{}"}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("duplicate create: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&models.Config{}).Where("type = ? AND config_id = ?", "voice_identify", "speaker_old").Count(&count)
+	if count != 1 {
+		t.Fatal("old speaker config deleted although create failed")
+	}
+
+	if w := do(`{"name":"new","config_id":"speaker_new","json_data":"{}"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var configs []models.Config
+	db.Where("type = ?", "voice_identify").Find(&configs)
+	if len(configs) != 1 || configs[0].ConfigID != "speaker_new" || !configs[0].IsDefault {
+		t.Fatalf("speaker configs = %+v", configs)
+	}
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+)
+
+// configMatchConditions 配置的设备匹配条件，对应 Config.MatchConditions 的 JSON 结构
+// 所有已声明的条件都满足时才算命中；未声明的条件不参与判断
+type configMatchConditions struct {
+	FirmwareMin string   `json:"firmware_min,omitempty"` // 固件版本下限（含）
+	FirmwareMax string   `json:"firmware_max,omitempty"` // 固件版本上限（含）
+	Regions     []string `json:"regions,omitempty"`      // 允许的区域列表，忽略大小写
+}
+
+// parseConfigMatchConditions 解析匹配条件，空字符串或空对象返回 nil
+func parseConfigMatchConditions(raw string) (*configMatchConditions, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return nil, nil
+	}
+	var cond configMatchConditions
+	if err := json.Unmarshal([]byte(raw), &cond); err != nil {
+		return nil, fmt.Errorf("match_conditions 不是合法的JSON对象: %v", err)
+	}
+	cond.FirmwareMin = strings.TrimSpace(cond.FirmwareMin)
+	cond.FirmwareMax = strings.TrimSpace(cond.FirmwareMax)
+	regions := make([]string, 0, len(cond.Regions))
+	for _, region := range cond.Regions {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	cond.Regions = regions
+	if cond.specificity() == 0 {
+		return nil, nil
+	}
+	return &cond, nil
+}
+
+// normalizeConfigMatchConditions 校验并规范化匹配条件，返回紧凑JSON；无条件时返回空字符串
+func normalizeConfigMatchConditions(raw string) (string, error) {
+	cond, err := parseConfigMatchConditions(raw)
+	if err != nil {
+		return "", err
+	}
+	if cond == nil {
+		return "", nil
+	}
+	for _, v := range []string{cond.FirmwareMin, cond.FirmwareMax} {
+		if v != "" && !isFirmwareVersion(v) {
+			return "", fmt.Errorf("固件版本 %q 格式无效，应为形如 1.2.3 的版本号", v)
+		}
+	}
+	if cond.FirmwareMin != "" && cond.FirmwareMax != "" && compareFirmwareVersion(cond.FirmwareMin, cond.FirmwareMax) > 0 {
+		return "", errors.New("firmware_min 不能大于 firmware_max")
+	}
+	data, err := json.Marshal(cond)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// specificity 已声明的条件数量，越多表示越具体
+func (m configMatchConditions) specificity() int {
+	n := 0
+	if m.FirmwareMin != "" || m.FirmwareMax != "" {
+		n++
+	}
+	if len(m.Regions) > 0 {
+		n++
+	}
+	return n
+}
+
+// matches 判断设备属性是否满足全部条件；设备未上报的属性视为不满足对应条件
+func (m configMatchConditions) matches(firmwareVersion, region string) bool {
+	firmwareVersion = strings.TrimSpace(firmwareVersion)
+	region = strings.TrimSpace(region)
+	if m.FirmwareMin != "" || m.FirmwareMax != "" {
+		if firmwareVersion == "" || !isFirmwareVersion(firmwareVersion) {
+			return false
+		}
+		if m.FirmwareMin != "" && compareFirmwareVersion(firmwareVersion, m.FirmwareMin) < 0 {
+			return false
+		}
+		if m.FirmwareMax != "" && compareFirmwareVersion(firmwareVersion, m.FirmwareMax) > 0 {
+			return false
+		}
+	}
+	if len(m.Regions) > 0 {
+		if region == "" {
+			return false
+		}
+		found := false
+		for _, r := range m.Regions {
+			if strings.EqualFold(r, region) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// firmwareVersionParts 解析版本号的数字段，允许 v 前缀及 -beta 等后缀（后缀不参与比较）
+func firmwareVersionParts(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(v), "v"), "V")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, false
+	}
+	segments := strings.Split(v, ".")
+	parts := make([]int, 0, len(segments))
+	for _, seg := range segments {
+		n, err := strconv.Atoi(seg)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+func isFirmwareVersion(v string) bool {
+	_, ok := firmwareVersionParts(v)
+	return ok
+}
+
+// compareFirmwareVersion 按数字段比较版本号，缺失的段按 0 处理；无法解析的版本号按字符串比较
+func compareFirmwareVersion(a, b string) int {
+	pa, okA := firmwareVersionParts(a)
+	pb, okB := firmwareVersionParts(b)
+	if !okA || !okB {
+		return strings.Compare(a, b)
+	}
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// selectConditionalConfig 从候选配置中选出与设备属性最匹配的一条：条件越具体越优先，同等具体时默认配置优先，再按ID升序
+func selectConditionalConfig(candidates []models.Config, firmwareVersion, region string) (*models.Config, bool) {
+	var best *models.Config
+	bestScore := 0
+	for i := range candidates {
+		cond, err := parseConfigMatchConditions(candidates[i].MatchConditions)
+		if err != nil || cond == nil || !cond.matches(firmwareVersion, region) {
+			continue
+		}
+		score := cond.specificity()
+		if best == nil || score > bestScore ||
+			(score == bestScore && candidates[i].IsDefault && !best.IsDefault) ||
+			(score == bestScore && candidates[i].IsDefault == best.IsDefault && candidates[i].ID < best.ID) {
+			best = &candidates[i]
+			bestScore = score
+		}
+	}
+	return best, best != nil
+}
+
+// loadDefaultConfigForDevice 加载设备适用的默认配置：优先选择匹配条件命中设备属性的配置，未命中时回退到 is_default 配置
+// 返回配置来源描述，供诊断展示；查询失败时来源仍为 default
+func (ac *AdminController) loadDefaultConfigForDevice(typ string, device models.Device, dst *models.Config) (string, error) {
+	if device.FirmwareVersion != "" || device.Region != "" {
+		var candidates []models.Config
+		if err := ac.DB.Where("type = ? AND enabled = ? AND match_conditions <> ''", typ, true).
+			Find(&candidates).Error; err == nil {
+			if best, ok := selectConditionalConfig(candidates, device.FirmwareVersion, device.Region); ok {
+				*dst = *best
+				return fmt.Sprintf("conditional(config_id=%s)", best.ConfigID), nil
+			}
+		}
+	}
+	if err := ac.DB.Where("type = ? AND is_default = ? AND enabled = ?", typ, true, true).First(dst).Error; err != nil {
+		return "default", err
+	}
+	return "default", nil
+}
+
+// applyReportedDeviceAttributes 将设备上报的固件版本/区域写入 device；设备已存在且属性变化时持久化
+func (ac *AdminController) applyReportedDeviceAttributes(device *models.Device, deviceExists bool, firmwareVersion, region string) {
+	updates := map[string]interface{}{}
+	if v := strings.TrimSpace(firmwareVersion); v != "" && v != device.FirmwareVersion {
+		device.FirmwareVersion = v
+		updates["firmware_version"] = v
+	}
+	if v := strings.TrimSpace(region); v != "" && v != device.Region {
+		device.Region = v
+		updates["region"] = v
+	}
+	if !deviceExists || device.ID == 0 || len(updates) == 0 {
+		return
+	}
+	if err := ac.DB.Model(&models.Device{}).Where("id = ?", device.ID).Updates(updates).Error; err != nil {
+		logger.Warnf("更新设备上报属性失败: device_id=%d err=%v", device.ID, err)
+	}
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompareFirmwareVersion(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2", 0},
+		{"1.10.0", "1.9.9", 1},
+		{"v2.0.1-beta", "2.0.1", 0},
+		{"1.0", "1.0.1", -1},
+	}
+	for _, tc := range cases {
+		if got := compareFirmwareVersion(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareFirmwareVersion(%q,%q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeConfigMatchConditions(t *testing.T) {
+	if got, err := normalizeConfigMatchConditions(`{"regions":[" "]}`); err != nil || got != "" {
+		t.Fatalf("empty conditions = %q, %v", got, err)
+	}
+	if got, err := normalizeConfigMatchConditions(`{"firmware_min":" 1.2 ","regions":["cn"]}`); err != nil || got != `{"firmware_min":"1.2","regions":["cn"]}` {
+		t.Fatalf("normalized = %q, %v", got, err)
+	}
+	for _, raw := range []string{`[1]`, `{"firmware_min":"abc"}`, `{"firmware_min":"2.0","firmware_max":"1.0"}`} {
+		if _, err := normalizeConfigMatchConditions(raw); err == nil {
+			t.Fatalf("expected error for %s", raw)
+		}
+	}
+}
+
+func TestGetDeviceConfigsConditionalSelection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{}, &models.Device{}, &models.Agent{}, &models.Role{},
+		&models.SpeakerGroup{}, &models.SpeakerSample{}, &models.AgentKnowledgeBase{},
+		&models.KnowledgeBase{}, &models.KnowledgeBaseDocument{}, &models.VoiceClone{})
+	for _, cfg := range []models.Config{
+		{Type: "vad", Name: "vad", ConfigID: "vad-default", Provider: "silero_vad", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "asr", Name: "asr", ConfigID: "asr-default", Provider: "funasr", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "asr", Name: "asr-cn", ConfigID: "asr-cn", Provider: "funasr", JsonData: `{}`, Enabled: true,
+			MatchConditions: `{"regions":["CN"]}`},
+		{Type: "llm", Name: "llm", ConfigID: "llm-default", Provider: "openai", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "tts", Name: "tts", ConfigID: "tts-default", Provider: "edge", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "tts", Name: "tts-new", ConfigID: "tts-new-fw", Provider: "edge", JsonData: `{}`, Enabled: true,
+			MatchConditions: `{"firmware_min":"2.0.0"}`},
+		{Type: "tts", Name: "tts-new-cn", ConfigID: "tts-new-fw-cn", Provider: "edge", JsonData: `{}`, Enabled: true,
+			MatchConditions: `{"firmware_min":"2.0.0","regions":["cn"]}`},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	device := models.Device{UserID: 1, DeviceName: "aa:bb", DeviceCode: "123456"}
+	if err := db.Create(&device).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.GET("/configs", ac.GetDeviceConfigs)
+	get := func(query string) deviceConfigResponse {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/configs?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s", query, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data deviceConfigResponse `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+
+	// 未上报属性：走默认配置
+	if cfg := get("device_id=aa:bb"); cfg.TTS.ConfigID != "tts-default" || cfg.ASR.ConfigID != "asr-default" {
+		t.Fatalf("without attributes: tts=%s asr=%s", cfg.TTS.ConfigID, cfg.ASR.ConfigID)
+	}
+	// 固件满足下限但区域不匹配：只命中固件条件
+	if cfg := get("device_id=aa:bb&firmware_version=2.1.0&region=us"); cfg.TTS.ConfigID != "tts-new-fw" || cfg.ASR.ConfigID != "asr-default" {
+		t.Fatalf("fw only: tts=%s asr=%s", cfg.TTS.ConfigID, cfg.ASR.ConfigID)
+	}
+	// 上报区域后回写设备，后续请求不带参数也沿用；更具体的条件优先
+	if cfg := get("device_id=aa:bb&region=cn"); cfg.TTS.ConfigID != "tts-new-fw-cn" || cfg.ASR.ConfigID != "asr-cn" {
+		t.Fatalf("fw+region: tts=%s asr=%s", cfg.TTS.ConfigID, cfg.ASR.ConfigID)
+	}
+	var stored models.Device
+	if err := db.First(&stored, device.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.FirmwareVersion != "2.1.0" || stored.Region != "cn" {
+		t.Fatalf("reported attributes not persisted: %+v", stored)
+	}
+	// 固件低于下限：TTS 回退默认
+	if cfg := get("device_id=aa:bb&firmware_version=1.9"); cfg.TTS.ConfigID != "tts-default" || cfg.ASR.ConfigID != "asr-cn" {
+		t.Fatalf("old fw: tts=%s asr=%s", cfg.TTS.ConfigID, cfg.ASR.ConfigID)
+	}
+}
+
+func TestGenericConfigEndpointsValidateMatchConditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/configs", ac.CreateConfig)
+	r.PUT("/configs/:id", ac.UpdateConfig)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/configs", `{"type":"tts","name":"bad","config_id":"bad","match_conditions":"{\"firmware_min\":\"2.0\",\"firmware_max\":\"1.0\"}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("create with inverted range: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/configs", `{"type":"tts","name":"ok","config_id":"ok","match_conditions":"{ \"firmware_min\": \"1.0\" }"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var saved models.Config
+	if err := db.Where("config_id = ?", "ok").First(&saved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.MatchConditions != `{"firmware_min":"1.0"}` {
+		t.Fatalf("stored match_conditions not normalized: %s", saved.MatchConditions)
+	}
+	if w := do(http.MethodPut, "/configs/1", `{"name":"ok","match_conditions":"{\"firmware_min\":\"abc\"}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("update with bad version: code=%d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	pipelineTestMaxWavSize = 10 * 1024 * 1024
+	pipelineTestTimeout    = 120 * time.Second
+)
+
+// 端到端测试依次经过的环节
+var pipelineTestStages = []string{"vad", "asr", "llm", "tts"}
+
+// TestConfigPipeline 上传 WAV，由主程序按 VAD→ASR→LLM→TTS 全链路执行并返回各环节输出
+// 表单字段：file（WAV 文件）、client_uuid（可选）、vad_config_id/asr_config_id/llm_config_id/tts_config_id（可选，默认取默认配置）
+// 草稿覆盖（可选）：bundle_id 指定配置草稿包，data 为与 TestConfigs 相同结构的 JSON {"asr": {"config_id": {...}}}
+// 同一环节优先级 data > 草稿包 > 已保存配置，草稿无需保存即可用真实录音验证
+func (ac *AdminController) TestConfigPipeline(c *gin.Context) {
+	if ac.WebSocketController == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "WebSocket 服务未初始化"})
+		return
+	}
+
+	wavData, ok := readUploadedTestWav(c)
+	if !ok {
+		return
+	}
+
+	drafts, err := ac.loadPipelineDraftOverrides(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := gin.H{}
+	configIDs := gin.H{}
+	sources := gin.H{}
+	for _, typ := range pipelineTestStages {
+		preferredID := strings.TrimSpace(c.PostForm(typ + "_config_id"))
+		if draft, ok := drafts[typ]; ok {
+			if configID, item := pickPipelineDraftItem(draft.items, preferredID); item != nil {
+				data[typ] = map[string]interface{}{configID: item}
+				configIDs[typ] = configID
+				sources[typ] = draft.source
+				continue
+			}
+		}
+
+		configID := preferredID
+		if configID == "" {
+			configID = ac.selectPipelineTestConfigID(typ)
+		}
+		if configID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "未配置或未启用" + strings.ToUpper(typ)})
+			return
+		}
+		item := ac.getConfigItemByTypeAndID(typ, configID)
+		if item == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": strings.ToUpper(typ) + " 配置不存在: " + configID})
+			return
+		}
+		data[typ] = map[string]interface{}{configID: item}
+		configIDs[typ] = configID
+		sources[typ] = "saved"
+	}
+
+	clientUUID := strings.TrimSpace(c.PostForm("client_uuid"))
+	if clientUUID == "" {
+		clientUUID = ac.WebSocketController.GetFirstConnectedClientUUID()
+	}
+	if clientUUID == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "无主程序连接，无法测试"})
+		return
+	}
+
+	if !checkConfigTestRateLimit(c, pipelineTestStages) {
+		return
+	}
+
+	logger.Debugf("[pipeline_test] 发送请求 client=%s wav_size=%d config_ids=%v sources=%v", clientUUID, len(wavData), configIDs, sources)
+	resp, err := ac.WebSocketController.SendRequestWithBinaryToClient(c.Request.Context(), clientUUID, "POST", "/api/pipeline/test", map[string]interface{}{
+		"data":   data,
+		"format": "wav",
+	}, wavData, pipelineTestTimeout)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "主程序测试请求失败: " + err.Error()})
+		return
+	}
+	if resp.Status != http.StatusOK {
+		errMsg := resp.Error
+		if errMsg == "" {
+			errMsg = "主程序返回异常状态"
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": errMsg})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"client_uuid": clientUUID,
+		"config_ids":  configIDs,
+		"sources":     sources,
+		"stages":      resp.Body,
+	}})
+}
+
+// selectPipelineTestConfigID 选出该类型当前使用的配置：已启用的默认配置优先，否则第一条已启用配置
+func (ac *AdminController) selectPipelineTestConfigID(typ string) string {
+	var config models.Config
+	if err := ac.DB.Where("type = ? AND enabled = ?", typ, true).Order("is_default DESC, id ASC").First(&config).Error; err != nil {
+		return ""
+	}
+	return config.ConfigID
+}
+
+// pipelineDraftOverride 某环节的草稿配置，items 结构为 config_id -> 配置内容
+type pipelineDraftOverride struct {
+	source string
+	items  map[string]interface{}
+}
+
+// loadPipelineDraftOverrides 解析 bundle_id 与 data 表单字段，返回各环节的草稿配置
+func (ac *AdminController) loadPipelineDraftOverrides(c *gin.Context) (map[string]pipelineDraftOverride, error) {
+	drafts := make(map[string]pipelineDraftOverride)
+
+	if bundleID := strings.TrimSpace(c.PostForm("bundle_id")); bundleID != "" {
+		id, _ := strconv.Atoi(bundleID)
+		if id <= 0 {
+			return nil, errors.New("无效的草稿包ID")
+		}
+		var bundle models.ConfigDraftBundle
+		if err := ac.DB.First(&bundle, id).Error; err != nil {
+			return nil, errors.New("配置草稿包不存在")
+		}
+		items, err := decodeConfigDraftItems(&bundle)
+		if err != nil {
+			return nil, errors.New("草稿包内容解析失败")
+		}
+		for i := range items {
+			item := &items[i]
+			if !contains(pipelineTestStages, item.Type) {
+				continue
+			}
+			draft, ok := drafts[item.Type]
+			if !ok {
+				draft = pipelineDraftOverride{source: "draft_bundle", items: map[string]interface{}{}}
+				drafts[item.Type] = draft
+			}
+			draft.items[item.ConfigID] = map[string]interface{}(item.testPayload())
+		}
+	}
+
+	if raw := strings.TrimSpace(c.PostForm("data")); raw != "" {
+		var data map[string]map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return nil, errors.New("data 格式错误，应为 {\"类型\": {\"config_id\": {...}}}")
+		}
+		for typ, items := range data {
+			if !contains(pipelineTestStages, typ) || len(items) == 0 {
+				continue
+			}
+			drafts[typ] = pipelineDraftOverride{source: "draft_data", items: items}
+		}
+	}
+	return drafts, nil
+}
+
+// pickPipelineDraftItem 从草稿配置中选出本次使用的一条：与 preferredID 一致者优先，否则取 config_id 字典序第一条
+func pickPipelineDraftItem(items map[string]interface{}, preferredID string) (string, interface{}) {
+	if item, ok := items[preferredID]; ok && preferredID != "" {
+		return preferredID, item
+	}
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	sort.Strings(ids)
+	return ids[0], items[ids[0]]
+}
+
+// readUploadedTestWav 读取并校验表单上传的 WAV 文件（字段 file），失败时已写入错误响应
+func readUploadedTestWav(c *gin.Context) ([]byte, bool) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传 WAV 文件"})
+		return nil, false
+	}
+	if file.Size == 0 || file.Size > pipelineTestMaxWavSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "WAV 文件为空或超过 10MB"})
+		return nil, false
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "打开上传文件失败"})
+		return nil, false
+	}
+	defer src.Close()
+	wavData, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取上传文件失败"})
+		return nil, false
+	}
+	if len(wavData) < 12 || !bytes.Equal(wavData[0:4], []byte("RIFF")) || !bytes.Equal(wavData[8:12], []byte("WAVE")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 WAV 格式音频"})
+		return nil, false
+	}
+	return wavData, true
+}
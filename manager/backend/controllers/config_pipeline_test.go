@@ -0,0 +1,24 @@
+package controllers
+
+import "testing"
+
+func TestPickPipelineDraftItem(t *testing.T) {
+	items := map[string]interface{}{
+		"asr_b": map[string]interface{}{"provider": "funasr"},
+		"asr_a": map[string]interface{}{"provider": "doubao"},
+	}
+
+	if id, item := pickPipelineDraftItem(items, "asr_b"); id != "asr_b" || item == nil {
+		t.Fatalf("preferred = %q, %v", id, item)
+	}
+	// 指定 ID 不在草稿中时取字典序第一条
+	if id, _ := pickPipelineDraftItem(items, "asr_x"); id != "asr_a" {
+		t.Fatalf("fallback = %q, want asr_a", id)
+	}
+	if id, _ := pickPipelineDraftItem(items, ""); id != "asr_a" {
+		t.Fatalf("empty preferred = %q, want asr_a", id)
+	}
+	if id, item := pickPipelineDraftItem(nil, "asr_a"); id != "" || item != nil {
+		t.Fatalf("empty items = %q, %v", id, item)
+	}
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 引用审计问题级别
+const (
+	referenceSeverityError   = "error"   // 引用失效且无可用回退，设备行为与预期不符
+	referenceSeverityWarning = "warning" // 引用失效但会回退到默认配置/上一级配置
+)
+
+type referenceIssue struct {
+	Severity    string `json:"severity"`
+	Kind        string `json:"kind"` // config_missing/config_disabled/device_agent_missing/device_role_missing
+	Source      string `json:"source"`
+	SourceLabel string `json:"source_label"`
+	Target      string `json:"target"`
+	Message     string `json:"message"`
+}
+
+type referenceAudit struct {
+	Summary map[string]int   `json:"summary"`
+	Issues  []referenceIssue `json:"issues"`
+}
+
+// auditReferences 基于配置依赖图检查所有失效引用：智能体/角色 → LLM/TTS 配置，设备 → 智能体/角色
+func auditReferences(devices []models.Device, agents []models.Agent, roles []models.Role, configs []models.Config) referenceAudit {
+	graph := buildConfigGraph(devices, agents, roles, configs)
+	nodes := make(map[string]configGraphNode, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	// 与下发逻辑一致：指定的配置不可用时回退到启用的默认配置
+	enabledDefault := make(map[string]bool)
+	for _, cfg := range configs {
+		if cfg.IsDefault && cfg.Enabled {
+			enabledDefault[cfg.Type] = true
+		}
+	}
+
+	issues := make([]referenceIssue, 0)
+	for _, edge := range graph.Edges {
+		if edge.Type != "llm" && edge.Type != "tts" {
+			continue
+		}
+		target := nodes[edge.Target]
+		configID, _ := target.Attrs["config_id"].(string)
+		kind, reason := "", ""
+		if missing, _ := target.Attrs["missing"].(bool); missing {
+			kind, reason = "config_missing", "不存在"
+		} else if enabled, _ := target.Attrs["enabled"].(bool); !enabled {
+			kind, reason = "config_disabled", "已禁用"
+		} else {
+			continue
+		}
+		severity, fallback := referenceSeverityWarning, "将回退到默认配置"
+		if !enabledDefault[edge.Type] {
+			severity, fallback = referenceSeverityError, "且没有可用的默认配置"
+		}
+		issues = append(issues, referenceIssue{
+			Severity:    severity,
+			Kind:        kind,
+			Source:      edge.Source,
+			SourceLabel: nodes[edge.Source].Label,
+			Target:      edge.Target,
+			Message:     fmt.Sprintf("引用的 %s 配置 %s %s，%s", edge.Type, configID, reason, fallback),
+		})
+	}
+
+	agentIDs := make(map[uint]bool, len(agents))
+	for _, agent := range agents {
+		agentIDs[agent.ID] = true
+	}
+	roleIDs := make(map[uint]bool, len(roles))
+	for _, role := range roles {
+		roleIDs[role.ID] = true
+	}
+	for _, device := range devices {
+		source := fmt.Sprintf("device:%d", device.ID)
+		label := nodes[source].Label
+		if device.AgentID != 0 && !agentIDs[device.AgentID] {
+			issues = append(issues, referenceIssue{
+				Severity:    referenceSeverityError,
+				Kind:        "device_agent_missing",
+				Source:      source,
+				SourceLabel: label,
+				Target:      fmt.Sprintf("agent:%d", device.AgentID),
+				Message:     fmt.Sprintf("绑定的智能体 %d 不存在，将使用全局默认配置", device.AgentID),
+			})
+		}
+		if device.RoleID != nil && !roleIDs[*device.RoleID] {
+			issues = append(issues, referenceIssue{
+				Severity:    referenceSeverityWarning,
+				Kind:        "device_role_missing",
+				Source:      source,
+				SourceLabel: label,
+				Target:      fmt.Sprintf("role:%d", *device.RoleID),
+				Message:     fmt.Sprintf("绑定的角色 %d 不存在，将回退到智能体配置", *device.RoleID),
+			})
+		}
+	}
+
+	// error 在前，同级按来源排序，便于逐项处理
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Severity != issues[j].Severity {
+			return issues[i].Severity == referenceSeverityError
+		}
+		return issues[i].Source < issues[j].Source
+	})
+	summary := map[string]int{referenceSeverityError: 0, referenceSeverityWarning: 0, "total": len(issues)}
+	for _, issue := range issues {
+		summary[issue.Severity]++
+	}
+	return referenceAudit{Summary: summary, Issues: issues}
+}
+
+// AuditReferences 全库审计智能体/角色/设备的失效引用并标注级别，用于上线前检查
+func (ac *AdminController) AuditReferences(c *gin.Context) {
+	var devices []models.Device
+	var agents []models.Agent
+	var roles []models.Role
+	var configs []models.Config
+	if err := ac.DB.Order("id ASC").Find(&devices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取设备失败"})
+		return
+	}
+	if err := ac.DB.Order("id ASC").Find(&agents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取智能体失败"})
+		return
+	}
+	if err := ac.DB.Order("id ASC").Find(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取角色失败"})
+		return
+	}
+	if err := ac.DB.Where("type IN ?", []string{"llm", "tts"}).Order("id ASC").Find(&configs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": auditReferences(devices, agents, roles, configs)})
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestAuditReferences(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	uintPtr := func(v uint) *uint { return &v }
+
+	configs := []models.Config{
+		{Type: "llm", ConfigID: "deepseek", Name: "DeepSeek", IsDefault: true, Enabled: true},
+		{Type: "llm", ConfigID: "qwen-old", Name: "Qwen", Enabled: false},
+		{Type: "tts", ConfigID: "edge", Name: "Edge", IsDefault: true, Enabled: false},
+	}
+	agents := []models.Agent{
+		{ID: 1, Name: "客厅助手", LLMConfigID: strPtr("qwen"), TTSConfigID: strPtr("edge")},
+	}
+	roles := []models.Role{{ID: 2, Name: "老师", LLMConfigID: strPtr("qwen-old"), TTSConfigID: strPtr("edge")}}
+	devices := []models.Device{
+		{ID: 3, DeviceName: "box", AgentID: 1, RoleID: uintPtr(2)},
+		{ID: 4, DeviceName: "orphan", AgentID: 9, RoleID: uintPtr(8)},
+	}
+
+	audit := auditReferences(devices, agents, roles, configs)
+
+	type issueKey struct{ source, target, kind string }
+	got := make(map[issueKey]string)
+	for _, issue := range audit.Issues {
+		got[issueKey{issue.Source, issue.Target, issue.Kind}] = issue.Severity
+	}
+	want := map[issueKey]string{
+		// 不存在的 LLM 配置，有可用默认配置可回退
+		{"agent:1", "config:llm:qwen", "config_missing"}: referenceSeverityWarning,
+		// 默认 TTS 也被禁用，无可回退
+		{"agent:1", "config:tts:edge", "config_disabled"}:    referenceSeverityError,
+		{"role:2", "config:llm:qwen-old", "config_disabled"}: referenceSeverityWarning,
+		{"role:2", "config:tts:edge", "config_disabled"}:     referenceSeverityError,
+		{"device:4", "agent:9", "device_agent_missing"}:      referenceSeverityError,
+		{"device:4", "role:8", "device_role_missing"}:        referenceSeverityWarning,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("issues = %+v", audit.Issues)
+	}
+	for key, severity := range want {
+		if got[key] != severity {
+			t.Fatalf("issue %+v severity = %q, want %q (all: %+v)", key, got[key], severity, audit.Issues)
+		}
+	}
+	if audit.Summary["total"] != 6 || audit.Summary[referenceSeverityError] != 3 || audit.Summary[referenceSeverityWarning] != 3 {
+		t.Fatalf("summary = %+v", audit.Summary)
+	}
+	for i, issue := range audit.Issues {
+		if i < 3 && issue.Severity != referenceSeverityError {
+			t.Fatalf("errors should be listed first: %+v", audit.Issues)
+		}
+	}
+	if audit.Issues[0].SourceLabel == "" {
+		t.Fatalf("source label should be filled: %+v", audit.Issues[0])
+	}
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// commonSecretConfigFields 各类型 json_data 中通用的敏感字段名（任意嵌套层级按字段名匹配）
+var commonSecretConfigFields = []string{
+	"api_key",
+	"access_token",
+	"access_key",
+	"secret_key",
+	"secret",
+	"password",
+	"client_secret",
+	"app_secret",
+}
+
+// typeSecretConfigFields 特定配置类型额外的敏感字段
+var typeSecretConfigFields = map[string][]string{
+	"ota":         {"signature_key"},
+	"mqtt_server": {"signature_key"},
+	"chat":        {"history_auth_token"},
+}
+
+// providerSecretConfigFields 特定提供商额外的敏感字段，按 类型 → 提供商 组织
+// 字段名 token 含义较宽泛，仅对确认其为鉴权令牌的提供商视为敏感
+var providerSecretConfigFields = map[string]map[string][]string{
+	"tts": {
+		"doubao":  {"token"},
+		"xiaozhi": {"token"},
+	},
+}
+
+// GetConfigSecretFields 返回指定类型与提供商的敏感字段名（已排序去重），前端据此统一脱敏显示
+func GetConfigSecretFields(typ, provider string) []string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	provider = strings.ToLower(strings.TrimSpace(provider))
+
+	seen := make(map[string]bool)
+	var fields []string
+	add := func(names []string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				fields = append(fields, name)
+			}
+		}
+	}
+	add(commonSecretConfigFields)
+	add(typeSecretConfigFields[typ])
+	if provider != "" {
+		add(providerSecretConfigFields[typ][provider])
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// IsConfigSecretField 判断字段名是否为指定类型与提供商的敏感字段（不区分大小写）
+func IsConfigSecretField(typ, provider, field string) bool {
+	field = strings.ToLower(strings.TrimSpace(field))
+	for _, name := range GetConfigSecretFields(typ, provider) {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSecretFields 获取配置 json_data 中需脱敏的字段名
+// GET /api/admin/configs/secret-fields?type=tts&provider=doubao
+func (ac *AdminController) GetSecretFields(c *gin.Context) {
+	typ := strings.TrimSpace(c.Query("type"))
+	if typ == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type 不能为空"})
+		return
+	}
+	provider := strings.TrimSpace(c.Query("provider"))
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"type":     typ,
+		"provider": provider,
+		"fields":   GetConfigSecretFields(typ, provider),
+	}})
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetConfigSecretFields(t *testing.T) {
+	fields := GetConfigSecretFields("llm", "openai")
+	if !IsConfigSecretField("llm", "openai", "api_key") || IsConfigSecretField("llm", "openai", "token") {
+		t.Fatalf("llm/openai fields = %v", fields)
+	}
+	for i := 1; i < len(fields); i++ {
+		if fields[i-1] >= fields[i] {
+			t.Fatalf("fields not sorted/unique: %v", fields)
+		}
+	}
+
+	if !IsConfigSecretField(" TTS ", "Doubao", "TOKEN") {
+		t.Fatal("tts/doubao token should be secret (case-insensitive)")
+	}
+	if IsConfigSecretField("tts", "", "token") {
+		t.Fatal("token should only be secret for specific providers")
+	}
+	if !IsConfigSecretField("ota", "", "signature_key") || IsConfigSecretField("llm", "", "signature_key") {
+		t.Fatal("signature_key should be secret only for ota/mqtt_server")
+	}
+
+	// 未知类型仍返回通用敏感字段
+	if got, want := GetConfigSecretFields("unknown", ""), GetConfigSecretFields("llm", ""); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unknown type fields = %v, want common %v", got, want)
+	}
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configTemplate 某提供商的配置模板：必填字段与带默认值的 json_data 骨架
+type configTemplate struct {
+	Required []string
+	JsonData map[string]interface{}
+}
+
+// openaiCompatibleLLMTemplate OpenAI 兼容接口的 LLM 模板
+func openaiCompatibleLLMTemplate(baseURL, modelName string) configTemplate {
+	return configTemplate{
+		Required: []string{"type", "model_name", "api_key", "base_url"},
+		JsonData: map[string]interface{}{
+			"type":        "openai",
+			"model_name":  modelName,
+			"api_key":     "",
+			"base_url":    baseURL,
+			"max_tokens":  500,
+			"temperature": 0.7,
+			"top_p":       0.9,
+		},
+	}
+}
+
+// configTemplates 按 类型 → 提供商 组织的配置模板，默认值与前端表单及 config.yaml 示例保持一致
+var configTemplates = map[string]map[string]configTemplate{
+	"llm": {
+		"openai":      openaiCompatibleLLMTemplate("https://api.openai.com/v1", "gpt-4o-mini"),
+		"azure":       openaiCompatibleLLMTemplate("https://your-resource-name.openai.azure.com", ""),
+		"anthropic":   openaiCompatibleLLMTemplate("https://api.anthropic.com", ""),
+		"zhipu":       openaiCompatibleLLMTemplate("https://open.bigmodel.cn/api/paas/v4", "glm-4-flash"),
+		"aliyun":      openaiCompatibleLLMTemplate("https://dashscope.aliyuncs.com/compatible-mode/v1", "qwen2.5-72b-instruct"),
+		"doubao":      openaiCompatibleLLMTemplate("https://ark.cn-beijing.volces.com/api/v3", "deepseek-v3"),
+		"siliconflow": openaiCompatibleLLMTemplate("https://api.siliconflow.cn/v1", "Qwen/Qwen2.5-72B-Instruct"),
+		"deepseek":    openaiCompatibleLLMTemplate("https://api.deepseek.com/v1", "deepseek-chat"),
+		"ollama": {
+			Required: []string{"type", "model_name", "base_url"},
+			JsonData: map[string]interface{}{
+				"type":       "ollama",
+				"model_name": "",
+				"api_key":    "",
+				"base_url":   "http://localhost:11434",
+				"max_tokens": 500,
+			},
+		},
+		"dify": {
+			Required: []string{"type", "api_key", "base_url"},
+			JsonData: map[string]interface{}{
+				"type":        "dify",
+				"api_key":     "",
+				"base_url":    "https://api.dify.ai/v1",
+				"user_prefix": "",
+			},
+		},
+		"coze": {
+			Required: []string{"type", "api_key", "base_url", "bot_id"},
+			JsonData: map[string]interface{}{
+				"type":         "coze",
+				"api_key":      "",
+				"base_url":     "https://api.coze.com",
+				"bot_id":       "",
+				"user_prefix":  "",
+				"connector_id": "1024",
+			},
+		},
+	},
+	"tts": {
+		"doubao_ws": {
+			Required: []string{"appid", "access_token", "cluster", "voice", "ws_host"},
+			JsonData: map[string]interface{}{
+				"appid":        "",
+				"access_token": "",
+				"cluster":      "volcano_tts",
+				"voice":        "zh_female_wanwanxiaohe_moon_bigtts",
+				"ws_host":      "openspeech.bytedance.com",
+				"use_stream":   true,
+			},
+		},
+		"edge": {
+			Required: []string{"voice"},
+			JsonData: map[string]interface{}{
+				"voice":           "zh-CN-XiaoxiaoNeural",
+				"rate":            "+0%",
+				"volume":          "+0%",
+				"pitch":           "+0Hz",
+				"connect_timeout": 10,
+				"receive_timeout": 60,
+			},
+		},
+		"edge_offline": {
+			Required: []string{"server_url"},
+			JsonData: map[string]interface{}{
+				"server_url":     "ws://localhost:8080/tts",
+				"timeout":        30,
+				"sample_rate":    16000,
+				"channels":       1,
+				"frame_duration": 20,
+			},
+		},
+		"cosyvoice": {
+			Required: []string{"api_url", "spk_id"},
+			JsonData: map[string]interface{}{
+				"api_url":        "https://tts.linkerai.cn/tts",
+				"spk_id":         "",
+				"frame_duration": 60,
+				"target_sr":      24000,
+				"audio_format":   "mp3",
+				"instruct_text":  "",
+			},
+		},
+		"openai": {
+			Required: []string{"api_key", "api_url", "model", "voice"},
+			JsonData: map[string]interface{}{
+				"api_key":         "",
+				"api_url":         "https://api.openai.com/v1/audio/speech",
+				"model":           "tts-1",
+				"voice":           "alloy",
+				"response_format": "mp3",
+				"speed":           1.0,
+				"stream":          true,
+				"frame_duration":  60,
+			},
+		},
+		"aliyun_qwen": {
+			Required: []string{"api_key", "api_url", "model", "voice"},
+			JsonData: map[string]interface{}{
+				"provider":       "aliyun_qwen",
+				"api_key":        "",
+				"api_url":        "https://dashscope.aliyuncs.com/api/v1/services/aigc/multimodal-generation/generation",
+				"region":         "beijing",
+				"model":          "qwen3-tts-flash",
+				"voice":          "Cherry",
+				"language_type":  "Chinese",
+				"stream":         true,
+				"frame_duration": 60,
+			},
+		},
+		"zhipu": {
+			Required: []string{"api_key", "api_url", "model", "voice"},
+			JsonData: map[string]interface{}{
+				"provider":        "zhipu",
+				"api_key":         "",
+				"api_url":         "https://open.bigmodel.cn/api/paas/v4/audio/speech",
+				"model":           "glm-tts",
+				"voice":           "tongtong",
+				"response_format": "pcm",
+				"speed":           1.0,
+				"volume":          1.0,
+				"stream":          true,
+				"encode_format":   "base64",
+				"frame_duration":  60,
+			},
+		},
+		"minimax": {
+			Required: []string{"api_key", "model", "voice"},
+			JsonData: map[string]interface{}{
+				"provider":    "minimax",
+				"api_key":     "",
+				"model":       "speech-2.8-hd",
+				"voice":       "male-qn-qingse",
+				"speed":       1.0,
+				"vol":         1.0,
+				"pitch":       0,
+				"sample_rate": 32000,
+				"bitrate":     128000,
+				"format":      "mp3",
+				"channel":     1,
+			},
+		},
+	},
+}
+
+// GetConfigTemplate 返回指定类型与提供商的 json_data 骨架（副本）及必填字段
+func GetConfigTemplate(typ, provider string) (map[string]interface{}, []string, bool) {
+	tpl, ok := configTemplates[typ][provider]
+	if !ok {
+		return nil, nil, false
+	}
+	jsonData := make(map[string]interface{}, len(tpl.JsonData))
+	for k, v := range tpl.JsonData {
+		jsonData[k] = v
+	}
+	return jsonData, append([]string(nil), tpl.Required...), true
+}
+
+// GetConfigTemplates 获取新建配置时使用的提供商模板
+// 带 provider 参数时返回该提供商的 json_data 骨架；否则返回该类型支持模板的提供商列表
+func (ac *AdminController) GetConfigTemplates(c *gin.Context) {
+	typ := strings.TrimSpace(c.Query("type"))
+	providers, ok := configTemplates[typ]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的配置类型: " + typ})
+		return
+	}
+
+	provider := strings.TrimSpace(c.Query("provider"))
+	if provider == "" {
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"type": typ, "providers": names}})
+		return
+	}
+
+	jsonData, required, ok := GetConfigTemplate(typ, provider)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "该提供商暂无配置模板: " + provider})
+		return
+	}
+	jsonBytes, err := json.MarshalIndent(jsonData, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成配置模板失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"type":      typ,
+		"provider":  provider,
+		"json_data": string(jsonBytes),
+		"required":  required,
+	}})
+}
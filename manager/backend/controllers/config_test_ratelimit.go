@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 配置一键测试的频率限制：按 用户（未登录时按 IP）+ 配置类型 计数，滑动窗口
+
+// defaultConfigTestRateLimits 内置默认限制，config.json 的 config_test.rate_limits 可按类型覆盖
+var defaultConfigTestRateLimits = map[string]config.RateLimitRule{
+	"ota": {Requests: 10, WindowSeconds: 60},
+	"vad": {Requests: 30, WindowSeconds: 60},
+	"asr": {Requests: 10, WindowSeconds: 60},
+	"llm": {Requests: 10, WindowSeconds: 60},
+	"tts": {Requests: 10, WindowSeconds: 60},
+}
+
+type configTestRateLimiter struct {
+	mu    sync.Mutex
+	rules map[string]config.RateLimitRule
+	hits  map[string][]time.Time // key: 调用方|类型
+}
+
+func newConfigTestRateLimiter(overrides map[string]config.RateLimitRule) *configTestRateLimiter {
+	rules := make(map[string]config.RateLimitRule, len(defaultConfigTestRateLimits))
+	for typ, rule := range defaultConfigTestRateLimits {
+		rules[typ] = rule
+	}
+	for typ, rule := range overrides {
+		rules[typ] = rule
+	}
+	return &configTestRateLimiter{rules: rules, hits: make(map[string][]time.Time)}
+}
+
+var configTestLimiter = newConfigTestRateLimiter(nil)
+
+// SetConfigTestRateLimits 按配置文件设置各类型的测试频率限制
+func SetConfigTestRateLimits(overrides map[string]config.RateLimitRule) {
+	configTestLimiter = newConfigTestRateLimiter(overrides)
+}
+
+// allow 检查调用方对所有类型的测试是否都未超限；全部通过才计数，否则返回首个超限类型及需等待的时间
+func (l *configTestRateLimiter) allow(caller string, types []string, now time.Time) (string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, typ := range types {
+		rule, ok := l.rules[typ]
+		if !ok || rule.Requests <= 0 || rule.WindowSeconds <= 0 {
+			continue
+		}
+		window := time.Duration(rule.WindowSeconds) * time.Second
+		key := caller + "|" + typ
+		hits := l.hits[key]
+		kept := hits[:0]
+		for _, t := range hits {
+			if now.Sub(t) < window {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) == 0 {
+			delete(l.hits, key)
+		} else {
+			l.hits[key] = kept
+		}
+		if len(kept) >= rule.Requests {
+			return typ, kept[0].Add(window).Sub(now)
+		}
+	}
+
+	for _, typ := range types {
+		if rule, ok := l.rules[typ]; ok && rule.Requests > 0 && rule.WindowSeconds > 0 {
+			key := caller + "|" + typ
+			l.hits[key] = append(l.hits[key], now)
+		}
+	}
+	return "", 0
+}
+
+// checkConfigTestRateLimit 超限时返回 429 并设置 Retry-After，返回 false
+func checkConfigTestRateLimit(c *gin.Context, types []string) bool {
+	caller := "ip:" + c.ClientIP()
+	if userID, exists := c.Get("user_id"); exists {
+		caller = fmt.Sprintf("user:%v", userID)
+	}
+	if len(types) == 0 {
+		types = configDraftTestableTypes
+	}
+
+	typ, wait := configTestLimiter.allow(caller, types, time.Now())
+	if typ == "" {
+		return true
+	}
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       fmt.Sprintf("%s 配置测试过于频繁，请 %d 秒后重试", typ, retryAfter),
+		"type":        typ,
+		"retry_after": retryAfter,
+	})
+	return false
+}
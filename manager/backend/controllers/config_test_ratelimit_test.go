@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"xiaozhi/manager/backend/config"
+)
+
+func TestConfigTestRateLimiter(t *testing.T) {
+	l := newConfigTestRateLimiter(map[string]config.RateLimitRule{
+		"llm": {Requests: 2, WindowSeconds: 60},
+		"vad": {Requests: 0},
+	})
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < 2; i++ {
+		if typ, _ := l.allow("user:1", []string{"llm"}, now.Add(time.Duration(i)*time.Second)); typ != "" {
+			t.Fatalf("request %d blocked by %s", i, typ)
+		}
+	}
+	typ, wait := l.allow("user:1", []string{"vad", "llm"}, now.Add(10*time.Second))
+	if typ != "llm" || wait != 50*time.Second {
+		t.Fatalf("blocked = %q wait = %v, want llm 50s", typ, wait)
+	}
+	// 其它用户独立计数；不限制的类型始终放行
+	if typ, _ := l.allow("user:2", []string{"llm"}, now); typ != "" {
+		t.Fatalf("other caller blocked by %s", typ)
+	}
+	if typ, _ := l.allow("user:1", []string{"vad"}, now); typ != "" {
+		t.Fatalf("unlimited type blocked")
+	}
+	// 窗口滑过最早一次请求后放行
+	if typ, _ := l.allow("user:1", []string{"llm"}, now.Add(60*time.Second)); typ != "" {
+		t.Fatalf("request after window blocked by %s", typ)
+	}
+}
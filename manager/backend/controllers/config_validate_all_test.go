@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestValidateStoredConfig(t *testing.T) {
+	_, required, ok := GetConfigTemplate("tts", "minimax")
+	if !ok {
+		t.Fatal("minimax tts template missing")
+	}
+
+	cases := []struct {
+		name        string
+		cfg         models.Config
+		valid       bool
+		hasSchema   bool
+		missing     []string
+		errorsCount int
+	}{
+		{
+			name:      "tts missing fields",
+			cfg:       models.Config{Type: "tts", Provider: "minimax_cn", JsonData: `{"provider":"minimax","api_key":""}`},
+			hasSchema: true,
+			missing:   required,
+		},
+		{
+			name:        "invalid json",
+			cfg:         models.Config{Type: "asr", JsonData: `{broken`},
+			missing:     []string{},
+			errorsCount: 1,
+		},
+		{
+			name:    "no schema",
+			cfg:     models.Config{Type: "vad", Provider: "ten_vad", JsonData: `{"threshold":0.4}`},
+			valid:   true,
+			missing: []string{},
+		},
+	}
+	for _, tc := range cases {
+		got := validateStoredConfig(tc.cfg)
+		if got.Valid != tc.valid || got.HasSchema != tc.hasSchema || len(got.Errors) != tc.errorsCount {
+			t.Fatalf("%s: got %+v", tc.name, got)
+		}
+		if !reflect.DeepEqual(got.MissingFields, tc.missing) {
+			t.Fatalf("%s: missing = %v, want %v", tc.name, got.MissingFields, tc.missing)
+		}
+	}
+}
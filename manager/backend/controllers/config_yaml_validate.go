@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// config.yaml 导入前的结构校验：与 ImportConfigs 的解析规则保持一致，不访问数据库
+
+// configYAMLProviderSections 含 provider 默认项与多个配置项的模块
+var configYAMLProviderSections = map[string]bool{
+	"vad": true, "asr": true, "llm": true, "tts": true, "memory": true, "voice_identify": true,
+}
+
+// configYAMLPlainSections 整体作为单个配置导入的模块
+var configYAMLPlainSections = map[string]bool{
+	"auth": true, "chat": true, "ota": true, "mqtt": true, "mqtt_server": true, "udp": true, "mcp": true, "local_mcp": true,
+}
+
+type configYAMLIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type configYAMLValidation struct {
+	Valid    bool              `json:"valid"`
+	Errors   []configYAMLIssue `json:"errors"`
+	Warnings []configYAMLIssue `json:"warnings"`
+	Summary  map[string]int    `json:"summary"` // 各模块将导入的配置项数
+}
+
+func (v *configYAMLValidation) addError(path, format string, args ...interface{}) {
+	v.Errors = append(v.Errors, configYAMLIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *configYAMLValidation) addWarning(path, format string, args ...interface{}) {
+	v.Warnings = append(v.Warnings, configYAMLIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateConfigYAML 校验 config.yaml 内容：未知模块、配置项结构、provider 引用及各提供商必填字段
+func validateConfigYAML(content []byte) configYAMLValidation {
+	result := configYAMLValidation{
+		Errors:   make([]configYAMLIssue, 0),
+		Warnings: make([]configYAMLIssue, 0),
+		Summary:  make(map[string]int),
+	}
+
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		result.addError("", "YAML 格式错误: %v", err)
+		return result
+	}
+	if len(root) == 0 {
+		result.addError("", "配置文件为空")
+		return result
+	}
+
+	keys := make([]string, 0, len(root))
+	for key := range root {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := root[key]
+		switch {
+		case configYAMLProviderSections[key]:
+			validateConfigYAMLProviderSection(&result, key, value)
+		case configYAMLPlainSections[key]:
+			if _, ok := value.(map[string]interface{}); !ok {
+				result.addError(key, "应为对象类型")
+				continue
+			}
+			result.Summary[key] = 1
+		default:
+			result.addWarning(key, "未知配置项，导入时将被忽略")
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// validateConfigYAMLProviderSection 校验含 provider 的模块
+func validateConfigYAMLProviderSection(result *configYAMLValidation, section string, value interface{}) {
+	sectionMap, ok := value.(map[string]interface{})
+	if !ok {
+		result.addError(section, "应为对象类型")
+		return
+	}
+
+	defaultProvider := ""
+	if raw, exists := sectionMap["provider"]; exists {
+		providerStr, ok := raw.(string)
+		if !ok || strings.TrimSpace(providerStr) == "" {
+			result.addError(section+".provider", "应为非空字符串")
+		} else {
+			defaultProvider = providerStr
+		}
+	}
+
+	configIDs := make([]string, 0, len(sectionMap))
+	for configID := range sectionMap {
+		if configID != "provider" {
+			configIDs = append(configIDs, configID)
+		}
+	}
+	sort.Strings(configIDs)
+
+	count := 0
+	for _, configID := range configIDs {
+		path := section + "." + configID
+		item, ok := sectionMap[configID].(map[string]interface{})
+		if !ok {
+			result.addWarning(path, "不是对象类型，导入时将被跳过")
+			continue
+		}
+		count++
+		validateConfigYAMLItem(result, section, configID, path, item)
+	}
+	result.Summary[section] = count
+
+	if count == 0 {
+		result.addWarning(section, "没有可导入的配置项")
+		return
+	}
+	if defaultProvider == "" {
+		if section == "voice_identify" {
+			result.addWarning(section+".provider", "未指定 provider，将使用任意一个配置项")
+		} else {
+			result.addWarning(section+".provider", "未指定 provider，导入后该模块没有默认配置")
+		}
+		return
+	}
+	if _, ok := sectionMap[defaultProvider].(map[string]interface{}); !ok {
+		result.addError(section+".provider", "引用的配置项 %s 不存在", defaultProvider)
+	}
+}
+
+// validateConfigYAMLItem 按提供商模板校验单个配置项的必填字段
+func validateConfigYAMLItem(result *configYAMLValidation, section, configID, path string, item map[string]interface{}) {
+	templateKey := configID
+	switch section {
+	case "llm":
+		// LLM 按接口类型区分，配置项名称可任意
+		typ, _ := item["type"].(string)
+		if strings.TrimSpace(typ) == "" {
+			result.addError(path+".type", "缺少必填字段")
+			return
+		}
+		templateKey = typ
+	case "tts":
+		if provider, ok := item["provider"].(string); ok && strings.TrimSpace(provider) != "" {
+			templateKey = provider
+		}
+	}
+
+	_, required, ok := GetConfigTemplate(section, templateKey)
+	if !ok {
+		return
+	}
+	for _, field := range required {
+		if configYAMLValueEmpty(item[field]) {
+			result.addError(path+"."+field, "缺少必填字段")
+		}
+	}
+}
+
+func configYAMLValueEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s) == ""
+	}
+	return false
+}
+
+// ValidateConfigYAML 校验上传的 config.yaml，仅返回校验结果，不修改任何配置
+func (ac *AdminController) ValidateConfigYAML(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if file.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer src.Close()
+
+	content, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": validateConfigYAML(content)})
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import "testing"
+
+func TestValidateConfigYAML(t *testing.T) {
+	content := []byte(`
+llm:
+  provider: "qwen"
+  deepseek:
+    type: "openai"
+    model_name: "deepseek-chat"
+    api_key: "sk-xxx"
+    base_url: "https://api.deepseek.com/v1"
+  local:
+    type: "ollama"
+    model_name: "qwen2.5"
+tts:
+  provider: "edge"
+  edge:
+    voice: "zh-CN-XiaoxiaoNeural"
+mqtt:
+  enable: false
+foo: 1
+`)
+	result := validateConfigYAML(content)
+	if result.Valid {
+		t.Fatal("expected invalid config")
+	}
+
+	errs := make(map[string]bool)
+	for _, issue := range result.Errors {
+		errs[issue.Path] = true
+	}
+	// provider 引用不存在的配置项；ollama 缺少 base_url
+	for _, path := range []string{"llm.provider", "llm.local.base_url"} {
+		if !errs[path] {
+			t.Fatalf("missing error for %s, got %+v", path, result.Errors)
+		}
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("errors = %+v, want 2", result.Errors)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Path != "foo" {
+		t.Fatalf("warnings = %+v, want unknown key foo", result.Warnings)
+	}
+	if result.Summary["llm"] != 2 || result.Summary["tts"] != 1 || result.Summary["mqtt"] != 1 {
+		t.Fatalf("summary = %+v", result.Summary)
+	}
+
+	if result = validateConfigYAML([]byte("llm: [")); result.Valid || len(result.Errors) != 1 {
+		t.Fatalf("invalid yaml result = %+v", result)
+	}
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BulkActivateDevices 激活指定智能体下的全部设备
+func (ac *AdminController) BulkActivateDevices(c *gin.Context) {
+	agentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || agentID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的智能体ID"})
+		return
+	}
+	var agent models.Agent
+	if err := ac.DB.First(&agent, agentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "智能体不存在"})
+		return
+	}
+
+	var total, activated int64
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Device{}).Where("agent_id = ?", agent.ID).Count(&total).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&models.Device{}).Where("agent_id = ? AND activated = ?", agent.ID, false).Update("activated", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		activated = res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("批量激活设备失败: agent_id=%d err=%v", agent.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "批量激活设备失败"})
+		return
+	}
+	logger.Infof("批量激活设备: agent_id=%d total=%d activated=%d", agent.ID, total, activated)
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"agent_id":          agent.ID,
+		"total":             total,
+		"activated":         activated,
+		"already_activated": total - activated,
+	}})
+}
@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// 设备启动流程模拟：按设备开机顺序依次请求 OTA、建立 WebSocket/MQTT 连接并拉取设备配置，
+// 一次调用即可发现 OTA 下发地址错误、配置解析失败等联调问题
+
+const (
+	bootSimulationStepTimeout = 5 * time.Second
+	bootSimulationClientID    = "boot-simulation-client"
+)
+
+const (
+	bootStepOK      = "ok"
+	bootStepFailed  = "failed"
+	bootStepSkipped = "skipped"
+)
+
+// bootSimulationStep 单个启动环节的结果
+type bootSimulationStep struct {
+	Name       string      `json:"name"`   // ota/websocket/mqtt/device_config
+	Status     string      `json:"status"` // ok/failed/skipped
+	Message    string      `json:"message"`
+	DurationMs int64       `json:"duration_ms"`
+	Detail     interface{} `json:"detail,omitempty"`
+}
+
+type bootSimulationResult struct {
+	DeviceID string               `json:"device_id"`
+	ClientID string               `json:"client_id"`
+	OTAURL   string               `json:"ota_url"`
+	OK       bool                 `json:"ok"`
+	TotalMs  int64                `json:"total_ms"`
+	Steps    []bootSimulationStep `json:"steps"`
+}
+
+// otaHTTPURLFromConfig 由 OTA 配置中的 WebSocket 地址推导 OTA HTTP 地址（优先 external，为空则用 test）
+func otaHTTPURLFromConfig(jsonData string) (string, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return "", fmt.Errorf("OTA 配置解析失败")
+	}
+	var wsURL string
+	for _, env := range []string{"external", "test"} {
+		envData, _ := data[env].(map[string]interface{})
+		ws, _ := envData["websocket"].(map[string]interface{})
+		if u, _ := ws["url"].(string); u != "" {
+			wsURL = u
+			break
+		}
+	}
+	if wsURL == "" {
+		return "", fmt.Errorf("OTA 配置未设置 WebSocket URL")
+	}
+	parsed, err := url.Parse(wsURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("WebSocket URL 解析失败: %s", wsURL)
+	}
+	scheme := "http"
+	if parsed.Scheme == "wss" {
+		scheme = "https"
+	}
+	return scheme + "://" + parsed.Host + otaHTTPPath, nil
+}
+
+// loadBootSimulationOTAURL 取指定或默认的已启用 OTA 配置对应的 OTA 地址
+func (ac *AdminController) loadBootSimulationOTAURL(configID string) (string, error) {
+	q := ac.DB.Where("type = ? AND enabled = ?", "ota", true)
+	if configID != "" {
+		q = q.Where("config_id = ?", configID)
+	}
+	var cfg models.Config
+	if err := q.Order("is_default DESC, id ASC").First(&cfg).Error; err != nil {
+		return "", fmt.Errorf("未配置或未启用OTA")
+	}
+	return otaHTTPURLFromConfig(cfg.JsonData)
+}
+
+// simulateBootOTA 以设备身份请求 OTA 接口，返回解析后的响应
+func simulateBootOTA(ctx context.Context, otaURL, deviceID, clientID string) (bootSimulationStep, map[string]interface{}) {
+	step := bootSimulationStep{Name: "ota", Status: bootStepFailed}
+	t0 := time.Now()
+	defer func() { step.DurationMs = time.Since(t0).Milliseconds() }()
+
+	ctx, cancel := context.WithTimeout(ctx, bootSimulationStepTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, otaURL, bytes.NewBufferString("{}"))
+	if err != nil {
+		step.Message = "创建 OTA 请求失败: " + err.Error()
+		return step, nil
+	}
+	req.Header.Set("Device-Id", deviceID)
+	req.Header.Set("Client-Id", clientID)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		step.Message = "OTA 请求失败: " + err.Error()
+		return step, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		step.Message = "OTA 返回 HTTP " + strconv.Itoa(resp.StatusCode)
+		step.Detail = gin.H{"response": string(body)}
+		return step, nil
+	}
+	var otaResp map[string]interface{}
+	if err := json.Unmarshal(body, &otaResp); err != nil {
+		step.Message = "OTA 响应非 JSON"
+		step.Detail = gin.H{"response": string(body)}
+		return step, nil
+	}
+	step.Detail = otaResp
+
+	ws, _ := otaResp["websocket"].(map[string]interface{})
+	wsURL, _ := ws["url"].(string)
+	if wsURL == "" {
+		step.Message = "OTA 响应中无 websocket.url"
+		return step, otaResp
+	}
+	if u, err := url.Parse(wsURL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		step.Message = "OTA 下发的 websocket.url 无效: " + wsURL
+		return step, otaResp
+	}
+	step.Status = bootStepOK
+	step.Message = "OTA 正常"
+	// 未激活设备会收到激活码，此时设备会先等待激活
+	if activation, _ := otaResp["activation"].(map[string]interface{}); activation != nil {
+		code, _ := activation["code"].(string)
+		step.Message = "OTA 正常，设备未激活，激活码: " + code
+	}
+	return step, otaResp
+}
+
+// simulateBootWebSocket 使用 OTA 下发的地址与 token 建立 WebSocket 连接，连通即关闭
+func simulateBootWebSocket(ctx context.Context, otaResp map[string]interface{}, deviceID, clientID string) bootSimulationStep {
+	step := bootSimulationStep{Name: "websocket", Status: bootStepFailed}
+	ws, _ := otaResp["websocket"].(map[string]interface{})
+	wsURL, _ := ws["url"].(string)
+	token, _ := ws["token"].(string)
+	step.Detail = gin.H{"url": wsURL}
+
+	t0 := time.Now()
+	header := http.Header{}
+	header.Set("Device-Id", deviceID)
+	header.Set("Client-Id", clientID)
+	header.Set("Protocol-Version", "1")
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	ctx, cancel := context.WithTimeout(ctx, bootSimulationStepTimeout)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	step.DurationMs = time.Since(t0).Milliseconds()
+	if err != nil {
+		step.Message = "WebSocket 连接失败: " + err.Error()
+		return step
+	}
+	conn.Close()
+	step.Status = bootStepOK
+	step.Message = "WebSocket 连接正常"
+	return step
+}
+
+// simulateBootMQTT OTA 下发 MQTT 配置时，按设备流程发送 hello 并验证 UDP 通道
+func simulateBootMQTT(otaResp map[string]interface{}) bootSimulationStep {
+	step := bootSimulationStep{Name: "mqtt", Status: bootStepSkipped, Message: "OTA 未下发 MQTT 配置"}
+	mqttObj, _ := otaResp["mqtt"].(map[string]interface{})
+	if mqttObj == nil {
+		return step
+	}
+	cfg := MQTTUDPTestConfig{}
+	cfg.Endpoint, _ = mqttObj["endpoint"].(string)
+	cfg.ClientID, _ = mqttObj["client_id"].(string)
+	cfg.Username, _ = mqttObj["username"].(string)
+	cfg.Password, _ = mqttObj["password"].(string)
+	cfg.PublishTopic, _ = mqttObj["publish_topic"].(string)
+	cfg.SubscribeTopic, _ = mqttObj["subscribe_topic"].(string)
+	step.Detail = gin.H{"endpoint": cfg.Endpoint}
+	if cfg.Endpoint == "" || cfg.PublishTopic == "" {
+		step.Status = bootStepFailed
+		step.Message = "OTA 下发的 MQTT 配置缺少 endpoint 或 publish_topic"
+		return step
+	}
+	ok, msg, ms := testMQTTUDPConfig(cfg)
+	step.Message = msg
+	step.DurationMs = ms
+	step.Status = bootStepFailed
+	if ok {
+		step.Status = bootStepOK
+	}
+	return step
+}
+
+// simulateBootDeviceConfig 按主程序拉取设备配置的逻辑解析配置，并检查 VAD/ASR/LLM/TTS 均已下发
+func (ac *AdminController) simulateBootDeviceConfig(deviceID string) bootSimulationStep {
+	step := bootSimulationStep{Name: "device_config", Status: bootStepFailed}
+	t0 := time.Now()
+	resolution, err := ac.buildDeviceResolution(deviceID)
+	step.DurationMs = time.Since(t0).Milliseconds()
+	if err != nil {
+		step.Message = "设备配置解析失败: " + err.Error()
+		return step
+	}
+	step.Detail = gin.H{
+		"config_source": resolution.ConfigSource,
+		"config_ids":    resolution.ConfigIDs,
+		"notes":         resolution.Notes,
+	}
+	var missing []string
+	for _, typ := range []string{"vad", "asr", "llm", "tts"} {
+		if resolution.ConfigIDs[typ] == "" {
+			missing = append(missing, strings.ToUpper(typ))
+		}
+	}
+	if len(missing) > 0 {
+		step.Message = "缺少 " + strings.Join(missing, "/") + " 配置"
+		return step
+	}
+	step.Status = bootStepOK
+	step.Message = "设备配置获取正常"
+	return step
+}
+
+// runDeviceBootSimulation 依次执行 OTA、WebSocket、MQTT 与设备配置拉取；OTA 失败时跳过依赖其结果的连接环节
+func (ac *AdminController) runDeviceBootSimulation(ctx context.Context, otaURL, deviceID, clientID string) *bootSimulationResult {
+	t0 := time.Now()
+	result := &bootSimulationResult{DeviceID: deviceID, ClientID: clientID, OTAURL: otaURL}
+
+	otaStep, otaResp := simulateBootOTA(ctx, otaURL, deviceID, clientID)
+	result.Steps = append(result.Steps, otaStep)
+	if otaStep.Status == bootStepOK {
+		result.Steps = append(result.Steps, simulateBootWebSocket(ctx, otaResp, deviceID, clientID), simulateBootMQTT(otaResp))
+	} else {
+		skipped := "OTA 失败，跳过"
+		result.Steps = append(result.Steps,
+			bootSimulationStep{Name: "websocket", Status: bootStepSkipped, Message: skipped},
+			bootSimulationStep{Name: "mqtt", Status: bootStepSkipped, Message: skipped})
+	}
+	result.Steps = append(result.Steps, ac.simulateBootDeviceConfig(deviceID))
+
+	result.OK = true
+	for _, step := range result.Steps {
+		if step.Status == bootStepFailed || (step.Name == "websocket" && step.Status == bootStepSkipped) {
+			result.OK = false
+		}
+	}
+	result.TotalMs = time.Since(t0).Milliseconds()
+	return result
+}
+
+// SimulateDeviceBoot 模拟设备开机流程，返回每一环节的结果与耗时
+// POST /api/admin/devices/boot-simulation
+func (ac *AdminController) SimulateDeviceBoot(c *gin.Context) {
+	var req struct {
+		DeviceID    string `json:"device_id"`
+		ClientID    string `json:"client_id"`
+		OTAConfigID string `json:"ota_config_id"` // 为空时使用默认的已启用 OTA 配置
+		OTAURL      string `json:"ota_url"`       // 直接指定 OTA 地址，优先于 ota_config_id
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	req.DeviceID = strings.TrimSpace(req.DeviceID)
+	if req.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "device_id 不能为空"})
+		return
+	}
+	if req.ClientID == "" {
+		req.ClientID = bootSimulationClientID
+	}
+	otaURL := strings.TrimSpace(req.OTAURL)
+	if otaURL == "" {
+		var err error
+		if otaURL, err = ac.loadBootSimulationOTAURL(req.OTAConfigID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ac.runDeviceBootSimulation(c.Request.Context(), otaURL, req.DeviceID, req.ClientID)})
+}
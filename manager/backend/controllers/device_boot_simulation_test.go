@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestOTAHTTPURLFromConfig(t *testing.T) {
+	got, err := otaHTTPURLFromConfig(`{"external":{"websocket":{"url":""}},"test":{"websocket":{"url":"wss://xz.example.com/xiaozhi/v1/"}}}`)
+	if err != nil || got != "https://xz.example.com/xiaozhi/ota/" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := otaHTTPURLFromConfig(`{"external":{}}`); err == nil {
+		t.Fatal("expected error without websocket url")
+	}
+}
+
+func TestRunDeviceBootSimulation(t *testing.T) {
+	db := newTestDB(t, &models.Config{}, &models.Device{}, &models.Agent{}, &models.Role{},
+		&models.SpeakerGroup{}, &models.SpeakerSample{}, &models.AgentKnowledgeBase{},
+		&models.KnowledgeBase{}, &models.KnowledgeBaseDocument{}, &models.VoiceClone{})
+	for _, cfg := range []models.Config{
+		{Type: "vad", Name: "vad", ConfigID: "vad-default", Provider: "silero_vad", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "asr", Name: "asr", ConfigID: "asr-default", Provider: "funasr", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "llm", Name: "llm", ConfigID: "llm-default", Provider: "openai", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "tts", Name: "tts", ConfigID: "tts-default", Provider: "edge", JsonData: `{}`, Enabled: true, IsDefault: true},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gotDeviceID, gotToken string
+	wsURL := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xiaozhi/ota/", func(w http.ResponseWriter, r *http.Request) {
+		gotDeviceID = r.Header.Get("Device-Id")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"websocket": map[string]interface{}{"url": wsURL, "token": "tk"},
+		})
+	})
+	mux.HandleFunc("/xiaozhi/v1/", func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.Header.Get("Authorization")
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err == nil {
+			conn.Close()
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	wsURL = "ws" + strings.TrimPrefix(srv.URL, "http") + "/xiaozhi/v1/"
+
+	ac := &AdminController{DB: db}
+	result := ac.runDeviceBootSimulation(context.Background(), srv.URL+"/xiaozhi/ota/", "aa:bb", bootSimulationClientID)
+	if !result.OK || len(result.Steps) != 4 {
+		t.Fatalf("result = %+v", result)
+	}
+	if gotDeviceID != "aa:bb" || gotToken != "Bearer tk" {
+		t.Fatalf("device_id = %q, token = %q", gotDeviceID, gotToken)
+	}
+	if result.Steps[2].Status != bootStepSkipped || result.Steps[3].Status != bootStepOK {
+		t.Fatalf("steps = %+v", result.Steps)
+	}
+
+	// OTA 下发错误的 WebSocket 地址
+	wsURL = "http://" + strings.TrimPrefix(srv.URL, "http://") + "/xiaozhi/v1/"
+	result = ac.runDeviceBootSimulation(context.Background(), srv.URL+"/xiaozhi/ota/", "aa:bb", bootSimulationClientID)
+	if result.OK || result.Steps[0].Status != bootStepFailed || result.Steps[1].Status != bootStepSkipped {
+		t.Fatalf("bad ws url result = %+v", result.Steps)
+	}
+	if result.Steps[3].Status != bootStepOK {
+		t.Fatalf("device config should still be fetched: %+v", result.Steps[3])
+	}
+}
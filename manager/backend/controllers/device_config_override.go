@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 设备下发配置的合并优先级（由低到高）：
+//  1. 各类型默认配置（is_default）
+//  2. 智能体配置（LLM/TTS/Voice 等）
+//  3. 设备关联角色
+//  4. VAD 调优配置（智能体级 < 设备级）
+//  5. 设备级配置覆盖 ConfigOverride（最后合并，优先级最高）
+
+// deviceConfigOverrideMaxBytes 设备配置覆盖的最大长度
+const deviceConfigOverrideMaxBytes = 16 * 1024
+
+// deviceConfigOverrideSections 允许覆盖的配置分节
+var deviceConfigOverrideSections = []string{"vad", "asr", "llm", "tts", "memory"}
+
+// deviceConfigOverrideForbiddenKeys 不允许覆盖的字段：切换提供商会与配置记录的 provider 不一致，应改用角色或智能体配置
+var deviceConfigOverrideForbiddenKeys = []string{"provider", "type"}
+
+// parseDeviceConfigOverride 解析并校验设备配置覆盖，空字符串返回 nil
+func parseDeviceConfigOverride(raw string) (map[string]map[string]interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if len(raw) > deviceConfigOverrideMaxBytes {
+		return nil, fmt.Errorf("配置覆盖过长，最大 %d 字节", deviceConfigOverrideMaxBytes)
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &sections); err != nil {
+		return nil, fmt.Errorf("配置覆盖必须为 JSON 对象: %v", err)
+	}
+
+	allowed := make(map[string]bool, len(deviceConfigOverrideSections))
+	for _, s := range deviceConfigOverrideSections {
+		allowed[s] = true
+	}
+	out := make(map[string]map[string]interface{}, len(sections))
+	for section, data := range sections {
+		if !allowed[section] {
+			return nil, fmt.Errorf("不支持覆盖的配置分节: %s（可选: %s）", section, strings.Join(deviceConfigOverrideSections, ", "))
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+			return nil, fmt.Errorf("%s 覆盖必须为 JSON 对象", section)
+		}
+		for _, key := range deviceConfigOverrideForbiddenKeys {
+			if _, ok := fields[key]; ok {
+				return nil, fmt.Errorf("%s 覆盖不允许修改 %s，请改用角色或智能体配置", section, key)
+			}
+		}
+		if section == "vad" {
+			if err := validateVADOverrideFields(fields); err != nil {
+				return nil, err
+			}
+		}
+		if len(fields) > 0 {
+			out[section] = fields
+		}
+	}
+	return out, nil
+}
+
+// validateVADOverrideFields 校验常用 VAD 参数的取值范围，与 VAD 调优配置一致
+func validateVADOverrideFields(fields map[string]interface{}) error {
+	number := func(key string) (float64, bool, error) {
+		v, ok := fields[key]
+		if !ok || v == nil {
+			return 0, false, nil
+		}
+		f, ok := v.(float64)
+		if !ok {
+			return 0, true, fmt.Errorf("vad.%s 必须为数字", key)
+		}
+		return f, true, nil
+	}
+	for _, key := range []string{"threshold", "exit_threshold"} {
+		if v, ok, err := number(key); err != nil {
+			return err
+		} else if ok && (v < 0 || v > 1) {
+			return fmt.Errorf("vad.%s 取值范围为 0-1", key)
+		}
+	}
+	if v, ok, err := number("hop_size"); err != nil {
+		return err
+	} else if ok && v <= 0 {
+		return fmt.Errorf("vad.hop_size 必须大于0")
+	}
+	for _, key := range []string{"speech_pad_ms", "min_silence_duration_ms"} {
+		if v, ok, err := number(key); err != nil {
+			return err
+		} else if ok && v < 0 {
+			return fmt.Errorf("vad.%s 不能为负数", key)
+		}
+	}
+	return nil
+}
+
+// mergeConfigOverride 将覆盖字段深度合并到 json_data：对象逐层合并，其余类型直接替换，null 表示删除该字段
+func mergeConfigOverride(jsonData string, fields map[string]interface{}) (string, error) {
+	base := make(map[string]interface{})
+	if strings.TrimSpace(jsonData) != "" {
+		if err := json.Unmarshal([]byte(jsonData), &base); err != nil {
+			return jsonData, err
+		}
+	}
+	deepMergeJSONObject(base, fields)
+	merged, err := json.Marshal(base)
+	if err != nil {
+		return jsonData, err
+	}
+	return string(merged), nil
+}
+
+func deepMergeJSONObject(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		if srcObj, ok := v.(map[string]interface{}); ok {
+			if dstObj, ok := dst[k].(map[string]interface{}); ok {
+				deepMergeJSONObject(dstObj, srcObj)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// applyDeviceConfigOverride 按分节合并设备配置覆盖，返回实际生效的分节（已排序）
+func applyDeviceConfigOverride(raw string, sections map[string]*models.Config) ([]string, error) {
+	override, err := parseDeviceConfigOverride(raw)
+	if err != nil || len(override) == 0 {
+		return nil, err
+	}
+	var applied []string
+	for section, fields := range override {
+		cfg := sections[section]
+		if cfg == nil {
+			continue
+		}
+		merged, err := mergeConfigOverride(cfg.JsonData, fields)
+		if err != nil {
+			return applied, fmt.Errorf("合并 %s 覆盖失败: %v", section, err)
+		}
+		cfg.JsonData = merged
+		applied = append(applied, section)
+	}
+	sort.Strings(applied)
+	return applied, nil
+}
+
+// GetDeviceConfigOverride 获取设备级配置覆盖
+func (ac *AdminController) GetDeviceConfigOverride(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var device models.Device
+	if err := ac.DB.Select("id", "device_name", "config_override").First(&device, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "设备不存在"})
+		return
+	}
+	override, err := parseDeviceConfigOverride(device.ConfigOverride)
+	if err != nil {
+		// 历史数据不合法时仍返回原文，便于修正
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"device_id": device.ID, "raw": device.ConfigOverride}, "warning": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"device_id":        device.ID,
+		"config_override":  override,
+		"allowed_sections": deviceConfigOverrideSections,
+	}})
+}
+
+// UpdateDeviceConfigOverride 设置设备级配置覆盖，请求体为 {"config_override": {...}}，传空对象或 null 表示清除
+func (ac *AdminController) UpdateDeviceConfigOverride(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var device models.Device
+	if err := ac.DB.First(&device, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "设备不存在"})
+		return
+	}
+	var req struct {
+		ConfigOverride json.RawMessage `json:"config_override"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	raw := strings.TrimSpace(string(req.ConfigOverride))
+	if raw == "null" {
+		raw = ""
+	}
+	override, err := parseDeviceConfigOverride(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	stored := ""
+	if len(override) > 0 {
+		b, err := json.Marshal(override)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置覆盖失败"})
+			return
+		}
+		stored = string(b)
+	}
+	if err := ac.DB.Model(&device).Update("config_override", stored).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置覆盖失败"})
+		return
+	}
+	sections := make([]string, 0, len(override))
+	for section := range override {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	// 覆盖内容可能包含密钥，仅记录分节
+	logger.Infof("更新设备配置覆盖: device_id=%d device_name=%s sections=%v", device.ID, device.DeviceName, sections)
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"device_id": device.ID, "config_override": override}, "message": "设备配置覆盖已保存"})
+}
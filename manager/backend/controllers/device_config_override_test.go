@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestParseDeviceConfigOverrideValidation(t *testing.T) {
+	cases := []struct {
+		raw     string
+		wantErr string
+	}{
+		{`[1,2]`, "JSON 对象"},
+		{`{"mqtt": {"port": 1}}`, "不支持覆盖的配置分节"},
+		{`{"vad": 1}`, "vad 覆盖必须为 JSON 对象"},
+		{`{"tts": {"provider": "edge"}}`, "不允许修改 provider"},
+		{`{"vad": {"threshold": 1.5}}`, "vad.threshold"},
+		{`{"vad": {"hop_size": "512"}}`, "vad.hop_size 必须为数字"},
+		{`{"vad": {"min_silence_duration_ms": -1}}`, "不能为负数"},
+		{`{"vad": {"threshold": 0.3}, "llm": {}}`, ""},
+		{`{"vad": {"threshold": null}}`, ""},
+		{"", ""},
+	}
+	for _, tc := range cases {
+		_, err := parseDeviceConfigOverride(tc.raw)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("parse(%s) err = %v, want nil", tc.raw, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("parse(%s) err = %v, want containing %q", tc.raw, err, tc.wantErr)
+		}
+	}
+
+	if _, err := parseDeviceConfigOverride(`{"llm": {"x": "` + strings.Repeat("a", deviceConfigOverrideMaxBytes) + `"}}`); err == nil {
+		t.Fatal("expected error for oversized override")
+	}
+}
+
+func TestApplyDeviceConfigOverride(t *testing.T) {
+	vad := models.Config{Type: "vad", Provider: "ten_vad", JsonData: `{"threshold":0.5,"hop_size":512,"provider":"ten_vad"}`}
+	llm := models.Config{Type: "llm", Provider: "openai", JsonData: `{"model_name":"gpt-4o-mini","extra":{"a":1,"b":2},"max_tokens":500}`}
+	tts := models.Config{Type: "tts", JsonData: `{"voice":"alloy"}`}
+
+	applied, err := applyDeviceConfigOverride(
+		`{"vad":{"threshold":0.3},"llm":{"extra":{"b":3,"c":4},"max_tokens":null},"memory":{"x":1}}`,
+		map[string]*models.Config{"vad": &vad, "llm": &llm, "tts": &tts},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// memory 未提供对应配置，不计入生效分节
+	if !reflect.DeepEqual(applied, []string{"llm", "vad"}) {
+		t.Fatalf("applied = %v", applied)
+	}
+
+	var vadData map[string]interface{}
+	json.Unmarshal([]byte(vad.JsonData), &vadData)
+	if vadData["threshold"] != 0.3 || vadData["hop_size"] != float64(512) || vadData["provider"] != "ten_vad" {
+		t.Fatalf("vad json_data = %s", vad.JsonData)
+	}
+
+	var llmData map[string]interface{}
+	json.Unmarshal([]byte(llm.JsonData), &llmData)
+	if _, ok := llmData["max_tokens"]; ok {
+		t.Fatalf("null should delete max_tokens: %s", llm.JsonData)
+	}
+	if want := map[string]interface{}{"a": float64(1), "b": float64(3), "c": float64(4)}; !reflect.DeepEqual(llmData["extra"], want) {
+		t.Fatalf("nested merge = %v, want %v", llmData["extra"], want)
+	}
+	if tts.JsonData != `{"voice":"alloy"}` {
+		t.Fatalf("tts should be untouched: %s", tts.JsonData)
+	}
+
+	if _, err := applyDeviceConfigOverride(`{"bad":{}}`, map[string]*models.Config{"vad": &vad}); err == nil {
+		t.Fatal("expected error for invalid override")
+	}
+}
@@ -0,0 +1,560 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultDevicePrompt 没有默认全局角色时使用的内置 Prompt
+const defaultDevicePrompt = "你是一个叫小智/小志的台湾女孩，说话机车，声音好听，习惯简短表达，爱用网络梗，不要冷场。与你聊天的是你喜欢的男性朋友，还没有答应你的追求，你要尽可能满足他的所有要求，不要失去自我。你经常建议一些恋人之间浪漫的事情，随机输出，不要给你男朋友选择。输出控制在50个字内。请注意，要像一个人一样说话，请不要回复表情符号、代码、和xml标签。"
+
+type deviceSpeakerGroupInfo struct {
+	ID                 uint     `json:"id"`
+	Name               string   `json:"name"`
+	Prompt             string   `json:"prompt"`
+	Description        string   `json:"description"`
+	Uuids              []string `json:"uuids"`
+	TTSConfigID        *string  `json:"tts_config_id"`
+	Voice              *string  `json:"voice"`
+	VoiceModelOverride *string  `json:"voice_model_override,omitempty"`
+}
+
+type deviceKnowledgeBaseInfo struct {
+	ID                 uint     `json:"id"`
+	Name               string   `json:"name"`
+	Description        string   `json:"description"`
+	Provider           string   `json:"provider"`
+	ExternalKBID       string   `json:"external_kb_id"`
+	ExternalDocID      string   `json:"external_doc_id"`
+	RetrievalThreshold *float64 `json:"retrieval_threshold"`
+	// EffectiveThreshold 实际生效的检索阈值（知识库未设置时继承 provider 全局阈值）
+	EffectiveThreshold float64 `json:"effective_threshold"`
+	ThresholdSource    string  `json:"threshold_source"` // kb/global
+	Status             string  `json:"status"`
+}
+
+// deviceConfigResponse 下发给主程序的设备配置
+type deviceConfigResponse struct {
+	VAD             models.Config                     `json:"vad"`
+	ASR             models.Config                     `json:"asr"`
+	LLM             models.Config                     `json:"llm"`
+	TTS             models.Config                     `json:"tts"`
+	Memory          models.Config                     `json:"memory"`
+	VoiceIdentify   map[string]deviceSpeakerGroupInfo `json:"voice_identify"`
+	KnowledgeBases  []deviceKnowledgeBaseInfo         `json:"knowledge_bases"`
+	Prompt          string                            `json:"prompt"`
+	Greeting        string                            `json:"greeting"`
+	AgentID         string                            `json:"agent_id"`
+	MemoryMode      string                            `json:"memory_mode"`
+	MCPServiceNames string                            `json:"mcp_service_names"`
+	ConfigSource    string                            `json:"config_source"` // 新增：配置来源
+	// ConfigOverrideApplied 已合并设备级配置覆盖的分节
+	ConfigOverrideApplied []string `json:"config_override_applied,omitempty"`
+}
+
+// deviceConfigWhatIf 假设的绑定关系，用于预览；nil 表示沿用设备当前值，0 表示解除绑定
+type deviceConfigWhatIf struct {
+	AgentID *uint
+	RoleID  *uint
+}
+
+// resolveDeviceConfig 按优先级解析设备配置，sources 记录各字段的来源。
+// device 为设备记录（deviceExists=false 表示设备不存在），whatIf 用于预览时替换绑定关系，不会持久化。
+func (ac *AdminController) resolveDeviceConfig(device models.Device, deviceExists bool, whatIf deviceConfigWhatIf) (*deviceConfigResponse, map[string]string, error) {
+	response := &deviceConfigResponse{MemoryMode: "short"}
+	sources := make(map[string]string)
+	var configSource string // 记录配置来源
+	deviceName := device.DeviceName
+
+	if whatIf.AgentID != nil {
+		device.AgentID = *whatIf.AgentID
+	}
+	if whatIf.RoleID != nil {
+		if *whatIf.RoleID == 0 {
+			device.RoleID = nil
+		} else {
+			roleID := *whatIf.RoleID
+			device.RoleID = &roleID
+		}
+	}
+	// 预览时只给定智能体或角色，视为绑定到该智能体/角色的新设备
+	if !deviceExists && (whatIf.AgentID != nil || whatIf.RoleID != nil) {
+		deviceExists = true
+	}
+
+	var agent models.Agent
+	deviceFound := deviceExists
+	if !deviceExists {
+		// 设备不存在，使用全局默认配置
+		response.AgentID = ""
+		configSource = "default_global_role"
+		logger.Debugf("设备 %s 不存在，使用全局默认配置", deviceName)
+	} else {
+		// 设备存在，查找智能体
+		response.AgentID = fmt.Sprintf("%d", device.AgentID)
+		logger.Debugf("设备 %s 存在，AgentID: %d", deviceName, device.AgentID)
+		if err := ac.DB.First(&agent, device.AgentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				// 智能体不存在，使用默认配置
+				deviceFound = false
+				configSource = "default_global_role"
+				logger.Debugf("智能体 %d 不存在，使用全局默认配置", device.AgentID)
+			} else {
+				return nil, nil, errors.New("Failed to query agent")
+			}
+		}
+	}
+
+	if deviceFound && agent.ID != 0 {
+		response.MemoryMode = normalizeAgentMemoryMode(agent.MemoryMode)
+		response.MCPServiceNames = normalizeMCPServiceNamesCSV(agent.MCPServiceNames)
+		sources["memory_mode"] = fmt.Sprintf("agent(id=%d)", agent.ID)
+		sources["mcp_service_names"] = fmt.Sprintf("agent(id=%d)", agent.ID)
+	} else {
+		sources["memory_mode"] = "default"
+	}
+
+	cloneVoiceCache := make(map[string]bool)
+	hasAliyunQwenCloneVoice := func(ttsConfigID string, voice *string) bool {
+		if device.ID == 0 || device.UserID == 0 {
+			return false
+		}
+		if strings.TrimSpace(ttsConfigID) == "" || voice == nil || strings.TrimSpace(*voice) == "" {
+			return false
+		}
+		voiceID := strings.TrimSpace(*voice)
+		cacheKey := ttsConfigID + "||" + voiceID
+		if cached, exists := cloneVoiceCache[cacheKey]; exists {
+			return cached
+		}
+
+		var count int64
+		err := ac.DB.Model(&models.VoiceClone{}).
+			Where("user_id = ? AND provider = ? AND tts_config_id = ? AND provider_voice_id = ? AND status = ?",
+				device.UserID, "aliyun_qwen", ttsConfigID, voiceID, voiceCloneStatusActive).
+			Count(&count).Error
+		if err != nil {
+			logger.Warnf("检测千问复刻音色失败: user_id=%d tts_config_id=%s voice_id=%s err=%v", device.UserID, ttsConfigID, voiceID, err)
+			cloneVoiceCache[cacheKey] = false
+			return false
+		}
+		cloneVoiceCache[cacheKey] = count > 0
+		return cloneVoiceCache[cacheKey]
+	}
+	applyAliyunQwenCloneModel := func(provider, ttsConfigID string, voice *string, ttsConfigData map[string]interface{}) {
+		if ttsConfigData == nil {
+			return
+		}
+		if normalizeCloneProvider(provider) != "aliyun_qwen" {
+			return
+		}
+		if hasAliyunQwenCloneVoice(ttsConfigID, voice) {
+			ttsConfigData["model"] = defaultAliyunQwenCloneTargetModel
+		}
+	}
+	buildAliyunQwenVoiceModelOverride := func(ttsConfigID *string, voice *string) *string {
+		if ttsConfigID == nil {
+			return nil
+		}
+		if hasAliyunQwenCloneVoice(strings.TrimSpace(*ttsConfigID), voice) {
+			model := defaultAliyunQwenCloneTargetModel
+			return &model
+		}
+		return nil
+	}
+
+	// loadConfig 加载指定的 LLM/TTS 配置，未指定或不可用时回退到默认配置
+	loadConfig := func(typ string, configID *string, dst *models.Config, origin string) {
+		if configID != nil && *configID != "" {
+			if err := ac.DB.Where("config_id = ? AND type = ? AND enabled = ?",
+				*configID, typ, true).First(dst).Error; err == nil {
+				sources[typ] = origin
+				return
+			}
+			// 回退到默认配置（含按设备属性的条件选择）
+			source, _ := ac.loadDefaultConfigForDevice(typ, device, dst)
+			sources[typ] = fmt.Sprintf("%s（%s 指定的配置 %s 不可用）", source, origin, *configID)
+			return
+		}
+		source, _ := ac.loadDefaultConfigForDevice(typ, device, dst)
+		sources[typ] = source
+	}
+	// applyVoice 将角色/智能体的音色写入 TTS 配置
+	applyVoice := func(voice *string, origin string) {
+		if voice == nil || *voice == "" {
+			return
+		}
+		var ttsConfigData map[string]interface{}
+		if err := json.Unmarshal([]byte(response.TTS.JsonData), &ttsConfigData); err == nil {
+			ttsConfigData[ttsVoiceFieldKey(response.TTS.Provider, ttsConfigData)] = *voice
+			applyAliyunQwenCloneModel(response.TTS.Provider, response.TTS.ConfigID, voice, ttsConfigData)
+			if updatedJsonData, err := json.Marshal(ttsConfigData); err == nil {
+				response.TTS.JsonData = string(updatedJsonData)
+				sources["voice"] = origin
+			}
+		}
+	}
+
+	// ==================== 配置获取逻辑（带优先级） ====================
+
+	// 1. 检查设备是否关联了角色（优先级最高）
+	if device.RoleID != nil {
+		var role models.Role
+		if err := ac.DB.First(&role, *device.RoleID).Error; err == nil {
+			configSource = "device_role"
+			origin := fmt.Sprintf("device_role(id=%d)", role.ID)
+
+			// 使用设备角色的 Prompt 与开场白
+			response.Prompt = role.Prompt
+			response.Greeting = role.Greeting
+			sources["prompt"], sources["greeting"] = origin, origin
+			// 替换 {{assistant_name}} 为智能体名称（如果设备有绑定智能体）
+			if deviceFound && agent.ID != 0 {
+				response.Prompt = strings.ReplaceAll(response.Prompt, "{{assistant_name}}", agent.Name)
+			}
+
+			// 使用设备角色的 LLM、TTS 配置与 Voice
+			loadConfig("llm", role.LLMConfigID, &response.LLM, origin)
+			loadConfig("tts", role.TTSConfigID, &response.TTS, origin)
+			applyVoice(role.Voice, origin)
+		}
+	}
+
+	// 2. 设备未关联角色，检查智能体配置
+	if configSource == "" && deviceFound && agent.ID != 0 {
+		configSource = "agent_config"
+		origin := fmt.Sprintf("agent(id=%d)", agent.ID)
+
+		// 使用智能体的 Prompt 与开场白
+		response.Prompt = agent.CustomPrompt
+		response.Greeting = agent.Greeting
+		response.Prompt = strings.ReplaceAll(response.Prompt, "{{assistant_name}}", agent.Name)
+		sources["prompt"], sources["greeting"] = origin, origin
+
+		// 使用智能体的 LLM、TTS 配置与 Voice
+		loadConfig("llm", agent.LLMConfigID, &response.LLM, origin)
+		loadConfig("tts", agent.TTSConfigID, &response.TTS, origin)
+		applyVoice(agent.Voice, origin)
+	}
+
+	// 3. 使用默认全局角色（兜底）
+	if configSource == "" || configSource == "default_global_role" {
+		configSource = "default_global_role"
+
+		// 查找默认全局角色
+		var defaultRole models.Role
+		if err := ac.DB.Where("is_default = ? AND role_type = ? AND status = ?",
+			true, "global", "active").First(&defaultRole).Error; err == nil {
+			origin := fmt.Sprintf("default_global_role(id=%d)", defaultRole.ID)
+			response.Prompt = defaultRole.Prompt
+			response.Greeting = defaultRole.Greeting
+			sources["prompt"], sources["greeting"] = origin, origin
+
+			// 使用默认全局角色的 LLM、TTS 配置与 Voice
+			loadConfig("llm", defaultRole.LLMConfigID, &response.LLM, origin)
+			loadConfig("tts", defaultRole.TTSConfigID, &response.TTS, origin)
+			applyVoice(defaultRole.Voice, origin)
+		} else {
+			// 如果没有默认角色，使用硬编码的默认 Prompt
+			response.Prompt = defaultDevicePrompt
+			sources["prompt"], sources["greeting"] = "builtin", "builtin"
+
+			// 使用默认 LLM/TTS 配置
+			loadConfig("llm", nil, &response.LLM, "")
+			loadConfig("tts", nil, &response.TTS, "")
+		}
+
+		// 替换 {{assistant_name}} 为智能体名称（如果设备有绑定智能体）
+		if deviceFound && agent.ID != 0 {
+			response.Prompt = strings.ReplaceAll(response.Prompt, "{{assistant_name}}", agent.Name)
+		}
+	}
+
+	// 开场白与 Prompt 使用相同的变量替换
+	if deviceFound && agent.ID != 0 {
+		response.Greeting = strings.ReplaceAll(response.Greeting, "{{assistant_name}}", agent.Name)
+	}
+
+	// 记录配置来源
+	response.ConfigSource = configSource
+
+	// ==================== 其他配置（VAD、ASR、Memory、VoiceIdentify） ====================
+
+	// 获取VAD默认配置
+	vadSource, err := ac.loadDefaultConfigForDevice("vad", device, &response.VAD)
+	if err != nil {
+		return nil, nil, errors.New("Failed to get default VAD config")
+	}
+	sources["vad"] = vadSource
+	// 兼容旧格式：如果JsonData只有一个key元素，说明是旧格式（带key），提取出内部配置并更新JsonData
+	if response.VAD.JsonData != "" {
+		var configData map[string]interface{}
+		if err := json.Unmarshal([]byte(response.VAD.JsonData), &configData); err == nil {
+			// 兼容旧格式：如果只有一个key，说明是旧格式（带key），提取出内部配置
+			var actualConfigData map[string]interface{}
+			if len(configData) == 1 {
+				// 旧格式：只有一个key，提取其值
+				for _, value := range configData {
+					if innerConfig, ok := value.(map[string]interface{}); ok {
+						actualConfigData = innerConfig
+					} else {
+						// 如果不是map类型，直接使用原数据
+						actualConfigData = configData
+					}
+					break
+				}
+			} else {
+				// 新格式：不带key，直接使用configData
+				actualConfigData = configData
+			}
+			// 重新序列化为不带key的格式
+			if updatedJsonData, err := json.Marshal(actualConfigData); err == nil {
+				response.VAD.JsonData = string(updatedJsonData)
+			}
+		}
+	}
+	// 设备/智能体级VAD调优配置覆盖默认参数
+	if profile := ac.resolveVADProfile(device.ID, agent.ID); profile != nil {
+		response.VAD.JsonData = applyVADProfile(response.VAD.JsonData, profile)
+		sources["vad"] = fmt.Sprintf("%s + vad_profile(id=%d)", vadSource, profile.ID)
+		logger.Infof("设备 %s 应用VAD调优配置: profile_id=%d", deviceName, profile.ID)
+	}
+
+	// 获取ASR默认配置
+	asrSource, err := ac.loadDefaultConfigForDevice("asr", device, &response.ASR)
+	if err != nil {
+		return nil, nil, errors.New("Failed to get default ASR config")
+	}
+	sources["asr"] = asrSource
+
+	// 获取Memory默认配置
+	memorySource, err := ac.loadDefaultConfigForDevice("memory", device, &response.Memory)
+	sources["memory"] = memorySource
+	if err != nil {
+		// 允许没有默认 Memory 配置：显式回退为 nomemo（不启用长记忆）。
+		response.Memory = models.Config{
+			Type:     "memory",
+			Name:     "No Memory",
+			ConfigID: "nomemo",
+			Provider: "nomemo",
+			JsonData: "{}",
+			Enabled:  true,
+		}
+		sources["memory"] = "builtin(nomemo)"
+		if err != gorm.ErrRecordNotFound {
+			logger.Warnf("加载默认Memory配置失败，已回退nomemo: %v", err)
+		}
+	}
+
+	// 获取VoiceIdentify配置：检查智能体是否关联了声纹组
+	response.VoiceIdentify = make(map[string]deviceSpeakerGroupInfo)
+	if deviceFound && agent.ID != 0 {
+		var speakerGroups []models.SpeakerGroup
+		if err := ac.DB.Where("agent_id = ? AND status = ?", agent.ID, "active").
+			Order("created_at DESC").Find(&speakerGroups).Error; err == nil && len(speakerGroups) > 0 {
+			sources["voice_identify"] = fmt.Sprintf("agent(id=%d)", agent.ID)
+			// 遍历所有声纹组
+			for _, speakerGroup := range speakerGroups {
+				// 查询该声纹组下的所有样本
+				var samples []models.SpeakerSample
+				ac.DB.Where("speaker_group_id = ? AND status = ?", speakerGroup.ID, "active").
+					Find(&samples)
+
+				// 提取样本 UUID 列表
+				uuids := make([]string, 0)
+				for _, sample := range samples {
+					uuids = append(uuids, sample.UUID)
+				}
+
+				// 以声纹组名称为 key，构建配置数据
+				response.VoiceIdentify[speakerGroup.Name] = deviceSpeakerGroupInfo{
+					ID:                 speakerGroup.ID,
+					Name:               speakerGroup.Name,
+					Prompt:             speakerGroup.Prompt,
+					Description:        speakerGroup.Description,
+					Uuids:              uuids,
+					TTSConfigID:        speakerGroup.TTSConfigID,
+					Voice:              speakerGroup.Voice,
+					VoiceModelOverride: buildAliyunQwenVoiceModelOverride(speakerGroup.TTSConfigID, speakerGroup.Voice),
+				}
+			}
+		}
+	}
+
+	// 下发智能体关联知识库（含 provider），供主程序本地RAG使用
+	response.KnowledgeBases = make([]deviceKnowledgeBaseInfo, 0)
+	if deviceFound && agent.ID != 0 {
+		var links []models.AgentKnowledgeBase
+		if err := ac.DB.Where("agent_id = ?", agent.ID).Order("id ASC").Find(&links).Error; err == nil && len(links) > 0 {
+			sources["knowledge_bases"] = fmt.Sprintf("agent(id=%d)", agent.ID)
+			kbIDs := make([]uint, 0, len(links))
+			for _, link := range links {
+				kbIDs = append(kbIDs, link.KnowledgeBaseID)
+			}
+			// 仅下发智能体所属用户的知识库，忽略越权关联
+			var kbs []models.KnowledgeBase
+			if err := ac.DB.Where("id IN ? AND user_id = ? AND status = ?", kbIDs, agent.UserID, "active").Find(&kbs).Error; err == nil {
+				globalThresholds := make(map[string]float64)
+				kbMap := make(map[uint]models.KnowledgeBase, len(kbs))
+				for _, kb := range kbs {
+					kbMap[kb.ID] = kb
+				}
+				for _, link := range links {
+					kb, ok := kbMap[link.KnowledgeBaseID]
+					if !ok {
+						continue
+					}
+					provider := strings.TrimSpace(kb.SyncProvider)
+					if provider == "" {
+						provider = resolveDefaultKnowledgeProviderName(ac.DB)
+					}
+					externalDocID := strings.TrimSpace(kb.ExternalDocID)
+					if externalDocID == "" {
+						var doc models.KnowledgeBaseDocument
+						if err := ac.DB.
+							Where("knowledge_base_id = ? AND sync_status = ? AND external_doc_id <> ''", kb.ID, knowledgeSyncStatusSynced).
+							Order("id DESC").
+							First(&doc).Error; err == nil {
+							externalDocID = strings.TrimSpace(doc.ExternalDocID)
+						}
+					}
+					globalThreshold, ok := globalThresholds[provider]
+					if !ok {
+						_, providerData, _ := loadKnowledgeProviderConfigByProvider(ac.DB, provider)
+						globalThreshold = knowledgeProviderGlobalThreshold(provider, providerData)
+						globalThresholds[provider] = globalThreshold
+					}
+					effectiveThreshold, thresholdSource := resolveKnowledgeThreshold(nil, kb.RetrievalThreshold, globalThreshold)
+					response.KnowledgeBases = append(response.KnowledgeBases, deviceKnowledgeBaseInfo{
+						ID:                 kb.ID,
+						Name:               kb.Name,
+						Description:        kb.Description,
+						Provider:           provider,
+						ExternalKBID:       strings.TrimSpace(kb.ExternalKBID),
+						ExternalDocID:      externalDocID,
+						RetrievalThreshold: kb.RetrievalThreshold,
+						EffectiveThreshold: effectiveThreshold,
+						ThresholdSource:    thresholdSource,
+						Status:             kb.Status,
+					})
+				}
+			}
+		}
+	}
+
+	// 设备级配置覆盖最后合并，优先级最高；内容不合法时忽略，避免影响设备正常使用
+	if deviceFound && device.ConfigOverride != "" {
+		applied, err := applyDeviceConfigOverride(device.ConfigOverride, map[string]*models.Config{
+			"vad":    &response.VAD,
+			"asr":    &response.ASR,
+			"llm":    &response.LLM,
+			"tts":    &response.TTS,
+			"memory": &response.Memory,
+		})
+		if err != nil {
+			logger.Warnf("设备 %s 配置覆盖无效，已忽略: %v", deviceName, err)
+		} else if len(applied) > 0 {
+			response.ConfigOverrideApplied = applied
+			for _, section := range applied {
+				sources[section] += fmt.Sprintf(" + device_override(id=%d)", device.ID)
+			}
+			logger.Infof("设备 %s 应用配置覆盖: %v", deviceName, applied)
+		}
+	}
+
+	return response, sources, nil
+}
+
+// parseWhatIfID 解析预览参数：空表示沿用当前值；0 或 none 表示解除绑定
+func parseWhatIfID(raw string) (*uint, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if strings.EqualFold(raw, "none") {
+		zero := uint(0)
+		return &zero, nil
+	}
+	v, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	id := uint(v)
+	return &id, nil
+}
+
+// PreviewResolvedConfig 预览假设的设备/角色/智能体组合解析出的配置及各字段来源，不做任何持久化
+// GET /api/admin/configs/preview-resolved?device_id=1&role_id=2&agent_id=3
+// device_id 为设备记录ID（可选，不传表示新设备）；role_id/agent_id 替换设备当前绑定，传 0 或 none 表示解除绑定
+func (ac *AdminController) PreviewResolvedConfig(c *gin.Context) {
+	roleID, err := parseWhatIfID(c.Query("role_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 role_id"})
+		return
+	}
+	agentID, err := parseWhatIfID(c.Query("agent_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 agent_id"})
+		return
+	}
+
+	var device models.Device
+	deviceExists := false
+	if raw := strings.TrimSpace(c.Query("device_id")); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 device_id"})
+			return
+		}
+		if err := ac.DB.First(&device, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "设备不存在"})
+			return
+		}
+		deviceExists = true
+	}
+	// 显式指定的角色/智能体必须存在，避免静默回退到其他配置来源
+	if roleID != nil && *roleID != 0 {
+		if err := ac.DB.Select("id").First(&models.Role{}, *roleID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "角色不存在"})
+			return
+		}
+	}
+	if agentID != nil && *agentID != 0 {
+		if err := ac.DB.Select("id").First(&models.Agent{}, *agentID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "智能体不存在"})
+			return
+		}
+	}
+
+	response, sources, err := ac.resolveDeviceConfig(device, deviceExists, deviceConfigWhatIf{AgentID: agentID, RoleID: roleID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	inputs := gin.H{"device_id": device.ID, "device_name": device.DeviceName, "agent_id": device.AgentID, "role_id": device.RoleID}
+	if agentID != nil {
+		inputs["agent_id"] = *agentID
+	}
+	if roleID != nil {
+		inputs["role_id"] = roleID
+		if *roleID == 0 {
+			inputs["role_id"] = nil
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"config":        response,
+		"field_sources": sources,
+		"inputs":        inputs,
+	}})
+}
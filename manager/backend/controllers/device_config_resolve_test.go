@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPreviewResolvedConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{}, &models.Device{}, &models.Agent{}, &models.Role{},
+		&models.SpeakerGroup{}, &models.SpeakerSample{}, &models.AgentKnowledgeBase{},
+		&models.KnowledgeBase{}, &models.KnowledgeBaseDocument{}, &models.VoiceClone{})
+	for _, cfg := range []models.Config{
+		{Type: "vad", Name: "vad", ConfigID: "vad-default", Provider: "silero_vad", JsonData: `{"threshold":0.5}`, Enabled: true, IsDefault: true},
+		{Type: "asr", Name: "asr", ConfigID: "asr-default", Provider: "funasr", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "llm", Name: "llm", ConfigID: "llm-default", Provider: "openai", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "llm", Name: "llm2", ConfigID: "llm-agent", Provider: "openai", JsonData: `{}`, Enabled: true},
+		{Type: "tts", Name: "tts", ConfigID: "tts-default", Provider: "edge", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "tts", Name: "tts2", ConfigID: "tts-role", Provider: "edge", JsonData: `{}`, Enabled: true},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	llmAgent, ttsRole, voice := "llm-agent", "tts-role", "zh-CN-XiaoxiaoNeural"
+	agent := models.Agent{UserID: 1, Name: "小智", CustomPrompt: "我是{{assistant_name}}", LLMConfigID: &llmAgent}
+	role := models.Role{Name: "老师", Prompt: "你是老师", TTSConfigID: &ttsRole, Voice: &voice, RoleType: "global", Status: "active"}
+	if err := db.Create(&agent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatal(err)
+	}
+	device := models.Device{UserID: 1, AgentID: agent.ID, DeviceName: "aa:bb", DeviceCode: "123456",
+		ConfigOverride: `{"vad":{"threshold":0.3}}`}
+	if err := db.Create(&device).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.GET("/preview", ac.PreviewResolvedConfig)
+	type previewResp struct {
+		Data struct {
+			Config  deviceConfigResponse `json:"config"`
+			Sources map[string]string    `json:"field_sources"`
+		} `json:"data"`
+	}
+	get := func(query string) (int, previewResp) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/preview?"+query, nil))
+		var resp previewResp
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, resp
+	}
+
+	// 无任何输入：新设备走兜底配置
+	code, resp := get("")
+	if code != http.StatusOK || resp.Data.Config.ConfigSource != "default_global_role" || resp.Data.Sources["prompt"] != "builtin" {
+		t.Fatalf("empty preview = %d %+v", code, resp.Data)
+	}
+
+	// 现有设备：智能体配置 + 设备覆盖
+	code, resp = get(fmt.Sprintf("device_id=%d", device.ID))
+	if code != http.StatusOK || resp.Data.Config.ConfigSource != "agent_config" {
+		t.Fatalf("device preview = %d %+v", code, resp.Data)
+	}
+	if resp.Data.Config.Prompt != "我是小智" || resp.Data.Config.LLM.ConfigID != "llm-agent" {
+		t.Fatalf("agent config not applied: %+v", resp.Data.Config)
+	}
+	if want := fmt.Sprintf("default + device_override(id=%d)", device.ID); resp.Data.Sources["vad"] != want {
+		t.Fatalf("vad source = %q, want %q", resp.Data.Sources["vad"], want)
+	}
+
+	// 假设绑定角色：角色的 TTS/音色优先
+	code, resp = get(fmt.Sprintf("device_id=%d&role_id=%d", device.ID, role.ID))
+	if code != http.StatusOK || resp.Data.Config.ConfigSource != "device_role" {
+		t.Fatalf("role preview = %d %+v", code, resp.Data)
+	}
+	origin := fmt.Sprintf("device_role(id=%d)", role.ID)
+	if resp.Data.Sources["tts"] != origin || resp.Data.Sources["voice"] != origin {
+		t.Fatalf("role sources = %+v", resp.Data.Sources)
+	}
+	if !strings.Contains(resp.Data.Config.TTS.JsonData, voice) {
+		t.Fatalf("voice not applied: %s", resp.Data.Config.TTS.JsonData)
+	}
+
+	// 仅给定智能体：视为绑定该智能体的新设备
+	code, resp = get(fmt.Sprintf("agent_id=%d", agent.ID))
+	if code != http.StatusOK || resp.Data.Config.ConfigSource != "agent_config" {
+		t.Fatalf("agent preview = %d %+v", code, resp.Data)
+	}
+
+	if code, _ := get("role_id=999"); code != http.StatusNotFound {
+		t.Fatalf("missing role = %d", code)
+	}
+	if code, _ := get("agent_id=abc"); code != http.StatusBadRequest {
+		t.Fatalf("bad agent_id = %d", code)
+	}
+
+	// 预览不得修改设备绑定
+	var stored models.Device
+	if err := db.First(&stored, device.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.RoleID != nil || stored.AgentID != agent.ID {
+		t.Fatalf("device modified by preview: %+v", stored)
+	}
+}
@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type deviceGroupRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+type deviceGroupMembersRequest struct {
+	DeviceIDs []uint `json:"device_ids" binding:"required"`
+}
+
+// parseDeviceGroup 按路由参数 :id 读取分组，失败时已写入响应
+func (ac *AdminController) parseDeviceGroup(c *gin.Context) (*models.DeviceGroup, bool) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || groupID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分组ID"})
+		return nil, false
+	}
+	var group models.DeviceGroup
+	if err := ac.DB.First(&group, groupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "设备分组不存在"})
+		return nil, false
+	}
+	return &group, true
+}
+
+// deviceGroupDeviceIDs 获取分组内全部设备ID
+func deviceGroupDeviceIDs(db *gorm.DB, groupID uint) ([]uint, error) {
+	var deviceIDs []uint
+	err := db.Model(&models.DeviceGroupMember{}).Where("group_id = ?", groupID).Order("device_id ASC").Pluck("device_id", &deviceIDs).Error
+	return deviceIDs, err
+}
+
+// GetDeviceGroups 获取设备分组列表（含设备数）
+func (ac *AdminController) GetDeviceGroups(c *gin.Context) {
+	var groups []models.DeviceGroup
+	if err := ac.DB.Order("id ASC").Find(&groups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取设备分组失败"})
+		return
+	}
+
+	var counts []struct {
+		GroupID uint
+		Count   int64
+	}
+	if err := ac.DB.Model(&models.DeviceGroupMember{}).Select("group_id, COUNT(*) AS count").Group("group_id").Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "统计分组设备数失败"})
+		return
+	}
+	countByGroup := make(map[uint]int64, len(counts))
+	for _, item := range counts {
+		countByGroup[item.GroupID] = item.Count
+	}
+
+	result := make([]gin.H, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, gin.H{
+			"id":           group.ID,
+			"name":         group.Name,
+			"description":  group.Description,
+			"device_count": countByGroup[group.ID],
+			"created_at":   group.CreatedAt,
+			"updated_at":   group.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// CreateDeviceGroup 创建设备分组
+func (ac *AdminController) CreateDeviceGroup(c *gin.Context) {
+	var req deviceGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分组名称不能为空"})
+		return
+	}
+
+	var count int64
+	ac.DB.Model(&models.DeviceGroup{}).Where("name = ?", name).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "分组名称已存在"})
+		return
+	}
+
+	group := models.DeviceGroup{Name: name, Description: strings.TrimSpace(req.Description)}
+	if err := ac.DB.Create(&group).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建设备分组失败"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": group})
+}
+
+// UpdateDeviceGroup 更新设备分组名称与描述
+func (ac *AdminController) UpdateDeviceGroup(c *gin.Context) {
+	group, ok := ac.parseDeviceGroup(c)
+	if !ok {
+		return
+	}
+	var req deviceGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分组名称不能为空"})
+		return
+	}
+
+	var count int64
+	ac.DB.Model(&models.DeviceGroup{}).Where("name = ? AND id <> ?", name, group.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "分组名称已存在"})
+		return
+	}
+
+	group.Name = name
+	group.Description = strings.TrimSpace(req.Description)
+	if err := ac.DB.Save(group).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新设备分组失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": group})
+}
+
+// DeleteDeviceGroup 删除设备分组及其成员关系，不影响设备本身
+func (ac *AdminController) DeleteDeviceGroup(c *gin.Context) {
+	group, ok := ac.parseDeviceGroup(c)
+	if !ok {
+		return
+	}
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.DeviceGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(group).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除设备分组失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetDeviceGroupDevices 获取分组内的设备
+func (ac *AdminController) GetDeviceGroupDevices(c *gin.Context) {
+	group, ok := ac.parseDeviceGroup(c)
+	if !ok {
+		return
+	}
+	deviceIDs, err := deviceGroupDeviceIDs(ac.DB, group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分组设备失败"})
+		return
+	}
+	devices := make([]models.Device, 0)
+	if len(deviceIDs) > 0 {
+		if err := ac.DB.Where("id IN ?", deviceIDs).Order("id ASC").Find(&devices).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分组设备失败"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": devices})
+}
+
+// AddDevicesToGroup 批量将设备加入分组，已在分组中的设备忽略
+func (ac *AdminController) AddDevicesToGroup(c *gin.Context) {
+	group, ok := ac.parseDeviceGroup(c)
+	if !ok {
+		return
+	}
+	var req deviceGroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.DeviceIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "device_ids 不能为空"})
+		return
+	}
+
+	var existingIDs []uint
+	if err := ac.DB.Model(&models.Device{}).Where("id IN ?", req.DeviceIDs).Pluck("id", &existingIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询设备失败"})
+		return
+	}
+	existing := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+	missing := make([]uint, 0)
+	members := make([]models.DeviceGroupMember, 0, len(existingIDs))
+	seen := make(map[uint]bool, len(req.DeviceIDs))
+	for _, id := range req.DeviceIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if !existing[id] {
+			missing = append(missing, id)
+			continue
+		}
+		members = append(members, models.DeviceGroupMember{GroupID: group.ID, DeviceID: id})
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "设备不存在", "device_ids": missing})
+		return
+	}
+
+	res := ac.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&members)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加分组设备失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "添加成功", "data": gin.H{"group_id": group.ID, "added": res.RowsAffected}})
+}
+
+// RemoveDeviceFromGroup 将设备移出分组
+func (ac *AdminController) RemoveDeviceFromGroup(c *gin.Context) {
+	group, ok := ac.parseDeviceGroup(c)
+	if !ok {
+		return
+	}
+	deviceID, err := strconv.Atoi(c.Param("device_id"))
+	if err != nil || deviceID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的设备ID"})
+		return
+	}
+	res := ac.DB.Where("group_id = ? AND device_id = ?", group.ID, deviceID).Delete(&models.DeviceGroupMember{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除分组设备失败"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "设备不在该分组中"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+// ApplyRoleToDeviceGroup 为分组内全部设备应用角色；role_id 为空时清除设备角色，恢复使用智能体配置
+func (ac *AdminController) ApplyRoleToDeviceGroup(c *gin.Context) {
+	group, ok := ac.parseDeviceGroup(c)
+	if !ok {
+		return
+	}
+	var req applyDeviceRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.RoleID != nil {
+		var role models.Role
+		if err := ac.DB.First(&role, *req.RoleID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "角色不存在"})
+			return
+		}
+		if normalizeRoleStatus(role.Status) != "active" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "角色未启用"})
+			return
+		}
+	}
+
+	deviceIDs, err := deviceGroupDeviceIDs(ac.DB, group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分组设备失败"})
+		return
+	}
+	if len(deviceIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分组内没有设备"})
+		return
+	}
+
+	res := ac.DB.Model(&models.Device{}).Where("id IN ?", deviceIDs).Update("role_id", req.RoleID)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "应用角色失败"})
+		return
+	}
+	logger.Infof("设备分组应用角色: group_id=%d role_id=%v devices=%d", group.ID, req.RoleID, res.RowsAffected)
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"group_id":   group.ID,
+		"role_id":    req.RoleID,
+		"device_ids": deviceIDs,
+		"updated":    res.RowsAffected,
+	}})
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/config"
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	deviceStatusActive   = "active"
+	deviceStatusInactive = "inactive"
+
+	defaultDeviceOfflineDays          = 30
+	defaultDeviceInactivityCheckEvery = 60 * time.Minute
+)
+
+var deviceInactivityOnce sync.Once
+
+// StartDeviceInactivityWorker 启动长期离线设备自动停用任务（未开启时不启动，仅启动一次）
+func StartDeviceInactivityWorker(db *gorm.DB, cfg config.DeviceInactivityConfig) {
+	if db == nil || !cfg.Enabled {
+		return
+	}
+	offlineDays := cfg.OfflineDays
+	if offlineDays <= 0 {
+		offlineDays = defaultDeviceOfflineDays
+	}
+	interval := defaultDeviceInactivityCheckEvery
+	if cfg.CheckIntervalMinutes > 0 {
+		interval = time.Duration(cfg.CheckIntervalMinutes) * time.Minute
+	}
+	offlinePeriod := time.Duration(offlineDays) * 24 * time.Hour
+
+	deviceInactivityOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if _, err := deactivateOfflineDevices(db, time.Now().Add(-offlinePeriod)); err != nil {
+					logger.Errorf("[DeviceInactivity] 检查长期离线设备失败: %v", err)
+				}
+				<-ticker.C
+			}
+		}()
+		logger.Infof("[DeviceInactivity] worker started offline_days=%d interval=%s", offlineDays, interval)
+	})
+}
+
+// deactivateOfflineDevices 将 cutoff 之前最后一次上线、且当前不在线的设备标记为 inactive，返回处理数量
+// 从未记录 last_seen_at 的设备以 updated_at 作为最后上线时间，避免升级后存量设备被一次性停用
+func deactivateOfflineDevices(db *gorm.DB, cutoff time.Time) (int, error) {
+	var devices []models.Device
+	if err := db.Where("status <> ? AND last_active_at IS NULL AND COALESCE(last_seen_at, updated_at) < ?", deviceStatusInactive, cutoff).
+		Find(&devices).Error; err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, device := range devices {
+		// 条件更新，避免覆盖检查期间刚上线的设备
+		res := db.Model(&models.Device{}).
+			Where("id = ? AND status <> ? AND last_active_at IS NULL", device.ID, deviceStatusInactive).
+			Update("status", deviceStatusInactive)
+		if res.Error != nil {
+			logger.Errorf("[DeviceInactivity] 停用设备失败 device_id=%d device_name=%s err=%v", device.ID, device.DeviceName, res.Error)
+			continue
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		count++
+		lastSeen := device.UpdatedAt
+		if device.LastSeenAt != nil {
+			lastSeen = *device.LastSeenAt
+		}
+		logger.Infof("[DeviceInactivity] 设备长期离线已停用 device_id=%d device_name=%s user_id=%d last_seen_at=%s",
+			device.ID, device.DeviceName, device.UserID, lastSeen.Format(time.RFC3339))
+	}
+	return count, nil
+}
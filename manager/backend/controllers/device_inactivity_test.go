@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestDeactivateOfflineDevices(t *testing.T) {
+	db := newTestDB(t, &models.Device{})
+
+	now := time.Now()
+	longAgo := now.Add(-40 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+	devices := []models.Device{
+		{DeviceName: "stale", DeviceCode: "A1", LastSeenAt: &longAgo},
+		{DeviceName: "recent", DeviceCode: "A2", LastSeenAt: &recent},
+		{DeviceName: "online", DeviceCode: "A3", LastSeenAt: &longAgo, LastActiveAt: &now},
+		{DeviceName: "never-seen", DeviceCode: "A4"},
+	}
+	if err := db.Create(&devices).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := deactivateOfflineDevices(db, now.Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("deactivated = %d, want 1", count)
+	}
+
+	var stale models.Device
+	db.Where("device_name = ?", "stale").First(&stale)
+	if stale.Status != deviceStatusInactive {
+		t.Fatalf("stale status = %q", stale.Status)
+	}
+	var active int64
+	db.Model(&models.Device{}).Where("status <> ?", deviceStatusInactive).Count(&active)
+	if active != 3 {
+		t.Fatalf("active devices = %d, want 3", active)
+	}
+
+	// 再次运行不重复处理
+	if count, _ := deactivateOfflineDevices(db, now.Add(-30*24*time.Hour)); count != 0 {
+		t.Fatalf("second run deactivated = %d, want 0", count)
+	}
+}
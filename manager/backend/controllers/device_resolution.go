@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// deviceResolutionDevice 诊断用的设备摘要（不含激活密钥等敏感字段）
+type deviceResolutionDevice struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	AgentID    uint       `json:"agent_id"`
+	RoleID     *uint      `json:"role_id"`
+	Activated  bool       `json:"activated"`
+	Status     string     `json:"status"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+}
+
+type deviceResolutionAgent struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name"`
+	Status      string  `json:"status"`
+	LLMConfigID *string `json:"llm_config_id"`
+	TTSConfigID *string `json:"tts_config_id"`
+}
+
+type deviceResolutionRole struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Source string `json:"source"` // device_role/default_global_role
+}
+
+// deviceResolution 设备配置解析链路：设备 -> 智能体 -> 角色 -> 最终配置来源
+type deviceResolution struct {
+	DeviceName            string                  `json:"device_name"`
+	Device                *deviceResolutionDevice `json:"device"`
+	Agent                 *deviceResolutionAgent  `json:"agent"`
+	Role                  *deviceResolutionRole   `json:"role"`
+	ConfigSource          string                  `json:"config_source"`
+	ConfigIDs             map[string]string       `json:"config_ids"`
+	FieldSources          map[string]string       `json:"field_sources"`
+	ConfigOverrideApplied []string                `json:"config_override_applied"`
+	// LLMGateway 最终 LLM 配置使用的附加请求头名称与代理地址（不含请求头值与代理凭据）
+	LLMGateway map[string]interface{} `json:"llm_gateway,omitempty"`
+	// Notes 解析过程中的回退说明，例如绑定的智能体/角色已被删除
+	Notes []string `json:"notes"`
+}
+
+// GetDeviceResolution 诊断设备的配置解析链路，一次返回设备、智能体、角色与最终配置来源
+// GET /api/admin/devices/resolution?device_name=aa:bb:cc:dd:ee:ff
+func (ac *AdminController) GetDeviceResolution(c *gin.Context) {
+	deviceName := strings.TrimSpace(c.Query("device_name"))
+	if deviceName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "device_name 参数不能为空"})
+		return
+	}
+	result, err := ac.buildDeviceResolution(deviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// buildDeviceResolution 与 GetDeviceConfigs 使用相同的查找与解析逻辑，并补充每一环的诊断信息
+func (ac *AdminController) buildDeviceResolution(deviceName string) (*deviceResolution, error) {
+	result := &deviceResolution{DeviceName: deviceName, Notes: []string{}}
+
+	var device models.Device
+	deviceExists := true
+	if err := ac.DB.Where("device_name = ?", deviceName).First(&device).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("查询设备失败: %v", err)
+		}
+		deviceExists = false
+		device = models.Device{DeviceName: deviceName}
+		result.Notes = append(result.Notes, "设备不存在，使用全局默认配置")
+	} else {
+		result.Device = &deviceResolutionDevice{
+			ID:         device.ID,
+			UserID:     device.UserID,
+			AgentID:    device.AgentID,
+			RoleID:     device.RoleID,
+			Activated:  device.Activated,
+			Status:     device.Status,
+			LastSeenAt: device.LastSeenAt,
+		}
+
+		var agent models.Agent
+		if err := ac.DB.First(&agent, device.AgentID).Error; err == nil {
+			result.Agent = &deviceResolutionAgent{
+				ID:          agent.ID,
+				Name:        agent.Name,
+				Status:      agent.Status,
+				LLMConfigID: agent.LLMConfigID,
+				TTSConfigID: agent.TTSConfigID,
+			}
+		} else if err == gorm.ErrRecordNotFound {
+			result.Notes = append(result.Notes, fmt.Sprintf("设备绑定的智能体 %d 不存在，使用全局默认配置", device.AgentID))
+		} else {
+			return nil, fmt.Errorf("查询智能体失败: %v", err)
+		}
+	}
+
+	response, sources, err := ac.resolveDeviceConfig(device, deviceExists, deviceConfigWhatIf{})
+	if err != nil {
+		return nil, err
+	}
+	result.ConfigSource = response.ConfigSource
+	result.FieldSources = sources
+	result.ConfigOverrideApplied = response.ConfigOverrideApplied
+	result.ConfigIDs = map[string]string{
+		"vad":    response.VAD.ConfigID,
+		"asr":    response.ASR.ConfigID,
+		"llm":    response.LLM.ConfigID,
+		"tts":    response.TTS.ConfigID,
+		"memory": response.Memory.ConfigID,
+	}
+	result.LLMGateway = llmGatewaySummary(response.LLM.JsonData)
+
+	// 角色：设备绑定角色优先，否则在兜底时使用默认全局角色
+	var role models.Role
+	switch response.ConfigSource {
+	case "device_role":
+		if err := ac.DB.First(&role, *device.RoleID).Error; err == nil {
+			result.Role = &deviceResolutionRole{ID: role.ID, Name: role.Name, Source: "device_role"}
+		}
+	case "default_global_role":
+		if err := ac.DB.Where("is_default = ? AND role_type = ? AND status = ?",
+			true, "global", "active").First(&role).Error; err == nil {
+			result.Role = &deviceResolutionRole{ID: role.ID, Name: role.Name, Source: "default_global_role"}
+		} else {
+			result.Notes = append(result.Notes, "未配置默认全局角色，使用内置 Prompt")
+		}
+	}
+	if device.RoleID != nil && response.ConfigSource != "device_role" {
+		result.Notes = append(result.Notes, fmt.Sprintf("设备绑定的角色 %d 不存在，已回退到 %s", *device.RoleID, response.ConfigSource))
+	}
+	return result, nil
+}
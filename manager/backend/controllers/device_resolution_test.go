@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetDeviceResolution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{}, &models.Device{}, &models.Agent{}, &models.Role{},
+		&models.SpeakerGroup{}, &models.SpeakerSample{}, &models.AgentKnowledgeBase{},
+		&models.KnowledgeBase{}, &models.KnowledgeBaseDocument{}, &models.VoiceClone{})
+	for _, cfg := range []models.Config{
+		{Type: "vad", Name: "vad", ConfigID: "vad-default", Provider: "silero_vad", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "asr", Name: "asr", ConfigID: "asr-default", Provider: "funasr", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "llm", Name: "llm", ConfigID: "llm-default", Provider: "openai", JsonData: `{}`, Enabled: true, IsDefault: true},
+		{Type: "llm", Name: "llm2", ConfigID: "llm-agent", Provider: "openai", JsonData: `{}`, Enabled: true},
+		{Type: "tts", Name: "tts", ConfigID: "tts-default", Provider: "edge", JsonData: `{}`, Enabled: true, IsDefault: true},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	llmAgent := "llm-agent"
+	agent := models.Agent{UserID: 1, Name: "小智", LLMConfigID: &llmAgent}
+	role := models.Role{Name: "老师", Prompt: "你是老师", RoleType: "global", Status: "active"}
+	if err := db.Create(&agent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatal(err)
+	}
+	missingRoleID := uint(999)
+	devices := []models.Device{
+		{UserID: 1, AgentID: agent.ID, RoleID: &role.ID, DeviceName: "with-role", DeviceCode: "100001"},
+		{UserID: 1, AgentID: agent.ID, RoleID: &missingRoleID, DeviceName: "stale-role", DeviceCode: "100002"},
+	}
+	for i := range devices {
+		if err := db.Create(&devices[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.GET("/resolution", ac.GetDeviceResolution)
+	get := func(query string) (int, deviceResolution) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resolution"+query, nil))
+		var resp struct {
+			Data deviceResolution `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, resp.Data
+	}
+
+	if code, _ := get(""); code != http.StatusBadRequest {
+		t.Fatalf("missing device_name = %d", code)
+	}
+
+	code, res := get("?device_name=with-role")
+	if code != http.StatusOK || res.Device == nil || res.Agent == nil || res.Agent.ID != agent.ID ||
+		res.Role == nil || res.Role.ID != role.ID || res.Role.Source != "device_role" ||
+		res.ConfigSource != "device_role" || res.ConfigIDs["llm"] != "llm-default" || len(res.Notes) != 0 {
+		t.Fatalf("with-role = %d %+v", code, res)
+	}
+
+	// 绑定的角色已删除：回退到智能体配置，并给出说明
+	code, res = get("?device_name=stale-role")
+	if code != http.StatusOK || res.Role != nil || res.ConfigSource != "agent_config" ||
+		res.ConfigIDs["llm"] != "llm-agent" || len(res.Notes) != 1 {
+		t.Fatalf("stale-role = %d %+v", code, res)
+	}
+
+	// 未注册设备：使用全局默认配置（无默认全局角色时使用内置 Prompt）
+	code, res = get("?device_name=unknown")
+	if code != http.StatusOK || res.Device != nil || res.Agent != nil || res.Role != nil ||
+		res.ConfigSource != "default_global_role" || res.FieldSources["prompt"] != "builtin" || len(res.Notes) != 2 {
+		t.Fatalf("unknown = %d %+v", code, res)
+	}
+}
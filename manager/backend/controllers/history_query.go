@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const historyDateLayout = "2006-01-02"
+
+// historyQuery 历史/审计类接口统一的分页与时间范围参数：
+// page/page_size 分页，start_date/end_date 按时间过滤（YYYY-MM-DD 或 RFC3339，日期形式的结束时间包含当天）
+type historyQuery struct {
+	Page     int
+	PageSize int
+	Start    *time.Time
+	End      *time.Time // 不含
+}
+
+// parseHistoryQuery 解析分页与时间范围参数；page_size 超过上限时截断，时间格式错误时返回错误
+func parseHistoryQuery(c *gin.Context, defaultPageSize, maxPageSize int) (historyQuery, error) {
+	q := historyQuery{
+		Page:     parsePositiveInt(c.Query("page"), 1),
+		PageSize: parsePositiveInt(c.Query("page_size"), defaultPageSize),
+	}
+	if q.PageSize > maxPageSize {
+		q.PageSize = maxPageSize
+	}
+	var err error
+	if q.Start, err = parseHistoryTime(c.Query("start_date"), false); err != nil {
+		return q, fmt.Errorf("无效的 start_date: %v", err)
+	}
+	if q.End, err = parseHistoryTime(c.Query("end_date"), true); err != nil {
+		return q, fmt.Errorf("无效的 end_date: %v", err)
+	}
+	if q.Start != nil && q.End != nil && !q.Start.Before(*q.End) {
+		return q, fmt.Errorf("start_date 必须早于 end_date")
+	}
+	return q, nil
+}
+
+// parseHistoryTime 解析日期或时间戳；isEnd 为 true 时日期形式取次日零点作为不含的上界
+func parseHistoryTime(raw string, isEnd bool) (*time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation(historyDateLayout, raw, time.Local); err == nil {
+		if isEnd {
+			t = t.Add(24 * time.Hour)
+		}
+		return &t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil && sec > 0 {
+		t := time.Unix(sec, 0)
+		return &t, nil
+	}
+	return nil, fmt.Errorf("支持 YYYY-MM-DD、RFC3339 或 Unix 秒")
+}
+
+// Filter 按时间列过滤
+func (q historyQuery) Filter(db *gorm.DB, column string) *gorm.DB {
+	if q.Start != nil {
+		db = db.Where(column+" >= ?", *q.Start)
+	}
+	if q.End != nil {
+		db = db.Where(column+" < ?", *q.End)
+	}
+	return db
+}
+
+// Paginate 应用分页
+func (q historyQuery) Paginate(db *gorm.DB) *gorm.DB {
+	return db.Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize)
+}
+
+// historyPageResponse 统一的分页响应结构
+func historyPageResponse(items interface{}, total int64, q historyQuery) gin.H {
+	return gin.H{
+		"total":     total,
+		"page":      q.Page,
+		"page_size": q.PageSize,
+		"data":      items,
+	}
+}
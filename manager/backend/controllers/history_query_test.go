@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseHistoryQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	parse := func(query string) (historyQuery, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+		return parseHistoryQuery(c, 20, 100)
+	}
+
+	q, err := parse("")
+	if err != nil || q.Page != 1 || q.PageSize != 20 || q.Start != nil || q.End != nil {
+		t.Fatalf("defaults = %+v %v", q, err)
+	}
+	q, err = parse("page=0&page_size=500")
+	if err != nil || q.Page != 1 || q.PageSize != 100 {
+		t.Fatalf("clamp = %+v %v", q, err)
+	}
+	q, err = parse("start_date=2026-01-02&end_date=2026-01-02")
+	if err != nil || q.End.Sub(*q.Start) != 24*time.Hour {
+		t.Fatalf("same-day range should cover the whole day: %+v %v", q, err)
+	}
+	q, err = parse("start_date=2026-01-02T08:00:00Z&end_date=1767427200")
+	if err != nil || !q.Start.Equal(time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)) || q.End.Unix() != 1767427200 {
+		t.Fatalf("rfc3339/unix = %+v %v", q, err)
+	}
+	for _, bad := range []string{"start_date=yesterday", "end_date=2026/01/02", "start_date=2026-01-03&end_date=2026-01-01"} {
+		if _, err := parse(bad); err == nil {
+			t.Fatalf("%s should be rejected", bad)
+		}
+	}
+}
+
+func TestGetKnowledgeSyncEventsPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.KnowledgeBase{}, &models.KnowledgeSyncEvent{})
+	kb := models.KnowledgeBase{UserID: 1, Name: "kb"}
+	if err := db.Create(&kb).Error; err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		// 每天一条事件：3/1 ~ 3/5
+		event := models.KnowledgeSyncEvent{KnowledgeBaseID: kb.ID, Provider: "dify", Method: "GET", Path: fmt.Sprintf("/e%d", i), CreatedAt: base.AddDate(0, 0, i)}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	uc := &UserController{DB: db}
+	r := gin.New()
+	r.GET("/kb/:id/events", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		uc.GetKnowledgeSyncEvents(c)
+	})
+	type pageResp struct {
+		Total    int64                       `json:"total"`
+		Page     int                         `json:"page"`
+		PageSize int                         `json:"page_size"`
+		Data     []models.KnowledgeSyncEvent `json:"data"`
+	}
+	get := func(query string) (int, pageResp) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/kb/%d/events?%s", kb.ID, query), nil))
+		var resp pageResp
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := get("page=2&page_size=2")
+	if code != http.StatusOK || resp.Total != 5 || resp.Page != 2 || len(resp.Data) != 2 || resp.Data[0].Path != "/e2" {
+		t.Fatalf("page 2 = %d %+v", code, resp)
+	}
+	// 兼容旧的 limit 参数
+	if _, resp = get("limit=3"); resp.PageSize != 3 || len(resp.Data) != 3 {
+		t.Fatalf("limit = %+v", resp)
+	}
+	code, resp = get("start_date=2026-03-02T00:00:00Z&end_date=2026-03-04T00:00:00Z")
+	if code != http.StatusOK || resp.Total != 2 || len(resp.Data) != 2 {
+		t.Fatalf("range = %d %+v", code, resp)
+	}
+	if code, _ = get("start_date=bad"); code != http.StatusBadRequest {
+		t.Fatalf("invalid date = %d", code)
+	}
+}
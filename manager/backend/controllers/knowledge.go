@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -14,7 +13,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	"github.com/gin-gonic/gin"
@@ -120,6 +121,47 @@ type knowledgeSearchTestHit struct {
 	Title   string  `json:"title"`
 	Score   float64 `json:"score"`
 	Content string  `json:"content"`
+	// 引用来源：provider 返回时填充，便于前端展示命中内容出处
+	DocumentID   string `json:"document_id,omitempty"`   // provider 侧文档ID
+	DocumentName string `json:"document_name,omitempty"` // provider 侧文档名称
+	KBName       string `json:"kb_name,omitempty"`
+	ChunkIndex   *int   `json:"chunk_index,omitempty"` // 分段序号（从0开始）
+	// 按 provider 文档ID 关联到的本地文档
+	LocalDocumentID uint `json:"local_document_id,omitempty"`
+}
+
+// fillKnowledgeHitLocalDocuments 将命中结果的 provider 文档ID 关联到本地知识库文档，补全本地文档ID与名称
+func fillKnowledgeHitLocalDocuments(db *gorm.DB, kbID uint, hits []knowledgeSearchTestHit) {
+	externalIDs := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		if hit.DocumentID != "" {
+			externalIDs = append(externalIDs, hit.DocumentID)
+		}
+	}
+	if len(externalIDs) == 0 {
+		return
+	}
+	var docs []models.KnowledgeBaseDocument
+	if err := db.Select("id", "name", "external_doc_id").
+		Where("knowledge_base_id = ? AND external_doc_id IN ?", kbID, externalIDs).
+		Find(&docs).Error; err != nil {
+		logger.Warnf("[KnowledgeTest] 关联本地文档失败 kb_id=%d err=%v", kbID, err)
+		return
+	}
+	docMap := make(map[string]models.KnowledgeBaseDocument, len(docs))
+	for _, doc := range docs {
+		docMap[doc.ExternalDocID] = doc
+	}
+	for i := range hits {
+		doc, ok := docMap[hits[i].DocumentID]
+		if !ok {
+			continue
+		}
+		hits[i].LocalDocumentID = doc.ID
+		if hits[i].DocumentName == "" {
+			hits[i].DocumentName = doc.Name
+		}
+	}
 }
 
 func isKnowledgeFeatureEnabled(db *gorm.DB) (bool, error) {
@@ -164,10 +206,6 @@ func (ac *AdminController) CreateKnowledgeSearchConfig(c *gin.Context) {
 	ac.createConfigWithType(c, &config)
 }
 
-func (ac *AdminController) UpdateKnowledgeSearchConfig(c *gin.Context) {
-	ac.updateConfigWithType(c, "knowledge_search")
-}
-
 func (ac *AdminController) DeleteKnowledgeSearchConfig(c *gin.Context) {
 	ac.deleteConfigWithType(c, "knowledge_search")
 }
@@ -453,6 +491,25 @@ func buildWeknoraModelListCandidateEndpoints(baseURL string) []string {
 	return ret
 }
 
+// knowledgeBaseExcerptRunes 列表模式下内容摘要的最大字符数
+const knowledgeBaseExcerptRunes = 200
+
+type knowledgeBaseListItem struct {
+	models.KnowledgeBase
+	DocCount       int64   `json:"doc_count"`
+	ContentExcerpt *string `json:"content_excerpt,omitempty"`
+	ContentLength  *int    `json:"content_length,omitempty"` // 完整内容字符数
+}
+
+// applyExcerpt 用内容摘要替换完整内容
+func (item *knowledgeBaseListItem) applyExcerpt() {
+	excerpt := truncateRunes(item.Content, knowledgeBaseExcerptRunes)
+	length := utf8.RuneCountInString(item.Content)
+	item.ContentExcerpt = &excerpt
+	item.ContentLength = &length
+	item.Content = ""
+}
+
 func (uc *UserController) GetKnowledgeBases(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	var items []models.KnowledgeBase
@@ -485,16 +542,18 @@ func (uc *UserController) GetKnowledgeBases(c *gin.Context) {
 		}
 	}
 
-	type knowledgeBaseListItem struct {
-		models.KnowledgeBase
-		DocCount int64 `json:"doc_count"`
-	}
+	// mode=list 时仅返回元数据与内容摘要，完整内容通过详情接口获取，避免大内容拖慢列表
+	listMode := c.Query("mode") == "list"
 	resp := make([]knowledgeBaseListItem, 0, len(items))
 	for _, item := range items {
-		resp = append(resp, knowledgeBaseListItem{
+		entry := knowledgeBaseListItem{
 			KnowledgeBase: item,
 			DocCount:      docCountMap[item.ID],
-		})
+		}
+		if listMode {
+			entry.applyExcerpt()
+		}
+		resp = append(resp, entry)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": resp})
@@ -512,6 +571,7 @@ func (uc *UserController) CreateKnowledgeBase(c *gin.Context) {
 		Status                 string   `json:"status"`
 		RetrievalThreshold     *float64 `json:"retrieval_threshold"`
 		InheritGlobalThreshold *bool    `json:"inherit_global_threshold"`
+		AutoSummary            *bool    `json:"auto_summary"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
@@ -533,6 +593,7 @@ func (uc *UserController) CreateKnowledgeBase(c *gin.Context) {
 		Content:            req.Content,
 		RetrievalThreshold: retrievalThreshold,
 		Status:             req.Status,
+		AutoSummary:        req.AutoSummary != nil && *req.AutoSummary,
 		SyncStatus:         knowledgeSyncStatusPending,
 		SyncProvider:       resolveDefaultKnowledgeProviderName(uc.DB),
 	}
@@ -540,6 +601,7 @@ func (uc *UserController) CreateKnowledgeBase(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建知识库失败"})
 		return
 	}
+	estimate := estimateKnowledgeContent(uc.DB, &item, item.Content)
 	if err := enqueueKnowledgeSyncUpsert(uc.DB, item.ID); err != nil {
 		_ = uc.DB.Model(&models.KnowledgeBase{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
 			"sync_status": knowledgeSyncStatusFailed,
@@ -548,12 +610,13 @@ func (uc *UserController) CreateKnowledgeBase(c *gin.Context) {
 		_ = uc.DB.Where("id = ?", item.ID).First(&item).Error
 		c.JSON(http.StatusCreated, gin.H{
 			"data":       item,
+			"estimate":   estimate,
 			"warning":    "知识库已保存，但同步任务入队失败",
 			"sync_error": err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"data": item, "message": "知识库已保存，后台正在同步"})
+	c.JSON(http.StatusCreated, gin.H{"data": item, "estimate": estimate, "message": "知识库已保存，后台正在同步"})
 }
 
 func (uc *UserController) GetKnowledgeBase(c *gin.Context) {
@@ -585,17 +648,23 @@ func (uc *UserController) UpdateKnowledgeBase(c *gin.Context) {
 		Status                 string   `json:"status"`
 		RetrievalThreshold     *float64 `json:"retrieval_threshold"`
 		InheritGlobalThreshold *bool    `json:"inherit_global_threshold"`
+		AutoSummary            *bool    `json:"auto_summary"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
 		return
 	}
+	// 正文未变化时后台同步只更新 dataset 元数据，不重新索引文档
+	contentUnchanged := item.ContentHash != "" && item.ExternalDocID != "" && item.ContentHash == knowledgeContentHash(req.Content)
 	item.Name = req.Name
 	item.Description = req.Description
 	item.Content = req.Content
 	if req.Status != "" {
 		item.Status = req.Status
 	}
+	if req.AutoSummary != nil {
+		item.AutoSummary = *req.AutoSummary
+	}
 	if req.InheritGlobalThreshold != nil || req.RetrievalThreshold != nil {
 		retrievalThreshold, err := buildKnowledgeRetrievalThreshold(req.InheritGlobalThreshold, req.RetrievalThreshold)
 		if err != nil {
@@ -610,6 +679,7 @@ func (uc *UserController) UpdateKnowledgeBase(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新知识库失败"})
 		return
 	}
+	estimate := estimateKnowledgeContent(uc.DB, &item, item.Content)
 	if err := enqueueKnowledgeSyncUpsert(uc.DB, item.ID); err != nil {
 		_ = uc.DB.Model(&models.KnowledgeBase{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
 			"sync_status": knowledgeSyncStatusFailed,
@@ -618,12 +688,17 @@ func (uc *UserController) UpdateKnowledgeBase(c *gin.Context) {
 		_ = uc.DB.Where("id = ?", item.ID).First(&item).Error
 		c.JSON(http.StatusOK, gin.H{
 			"data":       item,
+			"estimate":   estimate,
 			"warning":    "知识库已更新，但同步任务入队失败",
 			"sync_error": err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": item, "message": "知识库已更新，后台正在同步"})
+	message := "知识库已更新，后台正在同步"
+	if contentUnchanged {
+		message = "知识库已更新，内容未变化，仅同步元数据"
+	}
+	c.JSON(http.StatusOK, gin.H{"data": item, "estimate": estimate, "message": message, "content_unchanged": contentUnchanged})
 }
 
 func (uc *UserController) DeleteKnowledgeBase(c *gin.Context) {
@@ -639,49 +714,15 @@ func (uc *UserController) DeleteKnowledgeBase(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "知识库不存在"})
 		return
 	}
-	var docs []models.KnowledgeBaseDocument
-	if err := uc.DB.Where("knowledge_base_id = ?", item.ID).Find(&docs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询知识库文档失败"})
-		return
-	}
-
-	err := uc.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.KnowledgeBase{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Where("knowledge_base_id = ?", id).Delete(&models.KnowledgeBaseDocument{}).Error; err != nil {
-			return err
-		}
-		return tx.Where("knowledge_base_id = ?", id).Delete(&models.AgentKnowledgeBase{}).Error
-	})
-	if err != nil {
+	if err := softDeleteKnowledgeBase(uc.DB, &item); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除知识库失败"})
 		return
 	}
-	for _, doc := range docs {
-		if err := enqueueKnowledgeDocumentSyncDelete(uc.DB, item, doc); err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"message":    "删除成功",
-				"warning":    "本地删除成功，但部分知识库文档清理任务入队失败",
-				"sync_error": err.Error(),
-			})
-			return
-		}
-	}
-
-	if len(docs) == 0 {
-		if err := enqueueKnowledgeSyncDelete(uc.DB, item); err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"message":    "删除成功",
-				"warning":    "本地删除成功，但知识库清理任务入队失败",
-				"sync_error": err.Error(),
-			})
-			return
-		}
-	}
-	c.JSON(http.StatusOK, gin.H{"message": "删除成功，后台正在清理知识库数据"})
+	purgeAt := time.Now().Add(knowledgeBasePurgeGracePeriod)
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功，知识库可在保留期内恢复", "purge_at": purgeAt})
 }
 
+// SyncKnowledgeBase 手动触发知识库同步；请求体可选 timeout_seconds 为本次同步指定 provider 请求超时（大文档同步时延长）
 func (uc *UserController) SyncKnowledgeBase(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	id, _ := strconv.Atoi(c.Param("id"))
@@ -690,6 +731,21 @@ func (uc *UserController) SyncKnowledgeBase(c *gin.Context) {
 		return
 	}
 
+	var req struct {
+		TimeoutSeconds int `json:"timeout_seconds"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	timeout, err := parseKnowledgeSyncTimeout(req.TimeoutSeconds)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var item models.KnowledgeBase
 	if err := uc.DB.Where("id = ? AND user_id = ?", id, userID).First(&item).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "知识库不存在"})
@@ -698,15 +754,17 @@ func (uc *UserController) SyncKnowledgeBase(c *gin.Context) {
 
 	item.SyncStatus = knowledgeSyncStatusPending
 	item.SyncError = ""
+	// 手动同步清除正文哈希，强制重新上传文档（外部文档被误删等场景）
 	if err := uc.DB.Model(&models.KnowledgeBase{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
-		"sync_status": knowledgeSyncStatusPending,
-		"sync_error":  "",
+		"sync_status":  knowledgeSyncStatusPending,
+		"sync_error":   "",
+		"content_hash": "",
 	}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新同步状态失败: " + err.Error()})
 		return
 	}
 
-	if err := enqueueKnowledgeSyncUpsert(uc.DB, item.ID); err != nil {
+	if err := enqueueKnowledgeSyncUpsertWithTimeout(uc.DB, item.ID, timeout); err != nil {
 		_ = uc.DB.Model(&models.KnowledgeBase{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
 			"sync_status": knowledgeSyncStatusFailed,
 			"sync_error":  truncateSyncError(err.Error()),
@@ -792,7 +850,7 @@ func (uc *UserController) TestKnowledgeBaseSearch(c *gin.Context) {
 	_ = uc.DB.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ? AND sync_status = ?", kb.ID, knowledgeSyncStatusSynced).Count(&docsSynced).Error
 	_ = uc.DB.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ? AND sync_status IN ?", kb.ID, pendingStatuses).Count(&docsPending).Error
 	_ = uc.DB.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ? AND sync_status IN ?", kb.ID, failedStatuses).Count(&docsFailed).Error
-	log.Printf(
+	logger.Infof(
 		"[KnowledgeTest] Start user_id=%d kb_id=%d kb_name=%q sync_provider=%s sync_status=%s dataset_id=%s retrieval_threshold=%s request_threshold=%s docs(total=%d synced=%d pending=%d failed=%d) query=%q top_k=%d",
 		userIDUint,
 		kb.ID,
@@ -817,7 +875,7 @@ func (uc *UserController) TestKnowledgeBaseSearch(c *gin.Context) {
 	}
 
 	provider = strings.ToLower(strings.TrimSpace(provider))
-	log.Printf(
+	logger.Infof(
 		"[KnowledgeTest] ProviderResolved user_id=%d kb_id=%d resolved_provider=%s kb_sync_provider=%s",
 		userIDUint,
 		kb.ID,
@@ -826,47 +884,14 @@ func (uc *UserController) TestKnowledgeBaseSearch(c *gin.Context) {
 	)
 	client := &http.Client{Timeout: 12 * time.Second}
 
-	var hits []knowledgeSearchTestHit
-	switch provider {
-	case "dify":
-		cfg, err := parseDifyKnowledgeSyncConfig(providerData)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		hits, err = queryKnowledgeTestByDify(client, cfg, req.Threshold, kb.RetrievalThreshold, providerData, datasetID, strings.TrimSpace(kb.Name), query, topK)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	case "ragflow":
-		cfg, err := parseRagflowKnowledgeSyncConfig(providerData)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		hits, err = queryKnowledgeTestByRagflow(client, cfg, req.Threshold, kb.RetrievalThreshold, providerData, datasetID, strings.TrimSpace(kb.Name), query, topK)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	case "weknora":
-		cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		hits, err = queryKnowledgeTestByWeknora(client, cfg, req.Threshold, kb.RetrievalThreshold, providerData, datasetID, strings.TrimSpace(kb.Name), query, topK)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("当前 provider %s 暂不支持测试检索", provider)})
+	hits, status, err := queryKnowledgeTestHits(client, provider, providerData, kb, req.Threshold, datasetID, query, topK)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
+	fillKnowledgeHitLocalDocuments(uc.DB, kb.ID, hits)
 
-	log.Printf(
+	logger.Infof(
 		"[KnowledgeTest] Finish user_id=%d kb_id=%d provider=%s dataset_id=%s retrieval_threshold=%s request_threshold=%s query=%q top_k=%d hits=%d docs(total=%d synced=%d pending=%d failed=%d)",
 		userIDUint,
 		kb.ID,
@@ -883,7 +908,7 @@ func (uc *UserController) TestKnowledgeBaseSearch(c *gin.Context) {
 		docsFailed,
 	)
 	if len(hits) == 0 {
-		log.Printf(
+		logger.Infof(
 			"[KnowledgeTest] EmptyResultHint kb_id=%d dataset_id=%s provider=%s hint=请优先检查文档是否已同步成功且外部平台索引已完成，再检查阈值和query关键词",
 			kb.ID,
 			datasetID,
@@ -906,6 +931,43 @@ func (uc *UserController) TestKnowledgeBaseSearch(c *gin.Context) {
 	})
 }
 
+// queryKnowledgeTestHits 按 provider 执行一次测试检索，出错时同时返回对应的 HTTP 状态码
+func queryKnowledgeTestHits(client *http.Client, provider string, providerData map[string]interface{}, kb *models.KnowledgeBase, threshold *float64, datasetID, query string, topK int) ([]knowledgeSearchTestHit, int, error) {
+	var hits []knowledgeSearchTestHit
+	switch provider {
+	case "dify":
+		cfg, err := parseDifyKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		hits, err = queryKnowledgeTestByDify(client, cfg, threshold, kb.RetrievalThreshold, providerData, datasetID, strings.TrimSpace(kb.Name), query, topK)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	case "ragflow":
+		cfg, err := parseRagflowKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		hits, err = queryKnowledgeTestByRagflow(client, cfg, threshold, kb.RetrievalThreshold, providerData, datasetID, strings.TrimSpace(kb.Name), query, topK)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	case "weknora":
+		cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		hits, err = queryKnowledgeTestByWeknora(client, cfg, threshold, kb.RetrievalThreshold, providerData, datasetID, strings.TrimSpace(kb.Name), query, topK)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	default:
+		return nil, http.StatusBadRequest, fmt.Errorf("当前 provider %s 暂不支持测试检索", provider)
+	}
+	return hits, http.StatusOK, nil
+}
+
 func (uc *UserController) GetKnowledgeBaseDocuments(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	kbID, _ := strconv.Atoi(c.Param("id"))
@@ -941,8 +1003,9 @@ func (uc *UserController) CreateKnowledgeBaseDocument(c *gin.Context) {
 	}
 
 	var req struct {
-		Name    string `json:"name" binding:"required,min=1,max=200"`
-		Content string `json:"content" binding:"required"`
+		Name     string                 `json:"name" binding:"required,min=1,max=200"`
+		Content  string                 `json:"content" binding:"required"`
+		Metadata map[string]interface{} `json:"metadata"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
@@ -952,21 +1015,28 @@ func (uc *UserController) CreateKnowledgeBaseDocument(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "文档内容不能为空"})
 		return
 	}
+	metadataJSON, err := normalizeKnowledgeDocumentMetadata(req.Metadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	doc, enqueueErr, err := uc.createKnowledgeBaseDocumentRecord(kb.ID, req.Name, req.Content)
+	doc, enqueueErr, err := uc.createKnowledgeBaseDocumentRecord(kb.ID, req.Name, req.Content, metadataJSON)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文档失败"})
 		return
 	}
+	estimate := estimateKnowledgeContent(uc.DB, kb, req.Content)
 	if enqueueErr != nil {
 		c.JSON(http.StatusCreated, gin.H{
 			"data":       doc,
+			"estimate":   estimate,
 			"warning":    "文档已保存，但同步任务入队失败",
 			"sync_error": enqueueErr.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"data": doc, "message": "文档已保存，后台正在同步"})
+	c.JSON(http.StatusCreated, gin.H{"data": doc, "estimate": estimate, "message": "文档已保存，后台正在同步"})
 }
 
 func (uc *UserController) CreateKnowledgeBaseDocumentByUpload(c *gin.Context) {
@@ -981,7 +1051,7 @@ func (uc *UserController) CreateKnowledgeBaseDocumentByUpload(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	provider, _, _, providerErr := resolveKnowledgeProviderForKB(uc.DB, kb)
+	provider, _, providerData, providerErr := resolveKnowledgeProviderForKB(uc.DB, kb)
 	if providerErr != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": providerErr.Error()})
 		return
@@ -997,19 +1067,58 @@ func (uc *UserController) CreateKnowledgeBaseDocumentByUpload(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传文件(file)"})
 		return
 	}
-	uploadFileName, fileData, err := readKnowledgeUploadFileData(provider, fileHeader)
+	// provider 仅接受文本，或请求 extract_text=true 时，先在服务端提取文本，按文本文档同步
+	textOnly := knowledgeProviderTextOnly(providerData)
+	extractText := textOnly || parseKnowledgeSearchBool(c.PostForm("extract_text"), false)
+	allowedExtMap, supportedText := getAllowedKnowledgeUploadExtByProvider(provider)
+	if textOnly {
+		allowedExtMap, supportedText = knowledgeTextExtractableExt, knowledgeTextExtractSupportedText()
+	} else if extractText {
+		allowedExtMap = mergeKnowledgeExtSets(allowedExtMap, knowledgeTextExtractableExt)
+		supportedText += "；可提取文本: " + knowledgeTextExtractSupportedText()
+	}
+	uploadFileName, fileData, err := readKnowledgeUploadFile(provider, fileHeader, allowedExtMap, supportedText)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	content, err := encodeKnowledgeUploadContent(uploadFileName, fileData)
+
+	var extraction *knowledgeTextExtraction
+	content := ""
+	if extractText {
+		extraction = &knowledgeTextExtraction{Attempted: true}
+		text, extractErr := extractKnowledgeDocumentText(uploadFileName, fileData)
+		if extractErr == nil {
+			extraction.OK = true
+			extraction.Chars = utf8.RuneCountInString(text)
+			content = text
+		} else {
+			extraction.Error = extractErr.Error()
+			if textOnly || !knowledgeProviderAcceptsFile(provider, uploadFileName) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "文本提取失败: " + extractErr.Error(), "extraction": extraction})
+				return
+			}
+			// provider 支持该文件格式，回退为按原文件上传
+			extraction.FallbackToFile = true
+			logger.Warnf("[Knowledge] text extraction failed, fallback to file upload kb_id=%d file=%s err=%v", kb.ID, uploadFileName, extractErr)
+		}
+	}
+	if content == "" {
+		content, err = encodeKnowledgeUploadContent(uploadFileName, fileData)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "编码上传文件失败"})
+			return
+		}
+	}
+
+	metadataJSON, err := parseKnowledgeDocumentMetadataForm(c.PostForm("metadata"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "编码上传文件失败"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	docName := buildKnowledgeUploadDocumentName(c.PostForm("name"), fileHeader.Filename)
-	doc, enqueueErr, err := uc.createKnowledgeBaseDocumentRecord(kb.ID, docName, content)
+	doc, enqueueErr, err := uc.createKnowledgeBaseDocumentRecord(kb.ID, docName, content, metadataJSON)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "上传文件创建文档失败"})
 		return
@@ -1019,17 +1128,19 @@ func (uc *UserController) CreateKnowledgeBaseDocumentByUpload(c *gin.Context) {
 			"data":       doc,
 			"warning":    "文件已上传并创建文档，但同步任务入队失败",
 			"sync_error": enqueueErr.Error(),
+			"extraction": extraction,
 		})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"data": doc, "message": "文件上传成功，文档已创建并提交异步同步"})
+	c.JSON(http.StatusCreated, gin.H{"data": doc, "message": "文件上传成功，文档已创建并提交异步同步", "extraction": extraction})
 }
 
-func (uc *UserController) createKnowledgeBaseDocumentRecord(kbID uint, name, content string) (models.KnowledgeBaseDocument, error, error) {
+func (uc *UserController) createKnowledgeBaseDocumentRecord(kbID uint, name, content, metadataJSON string) (models.KnowledgeBaseDocument, error, error) {
 	doc := models.KnowledgeBaseDocument{
 		KnowledgeBaseID: kbID,
 		Name:            truncateRunes(strings.TrimSpace(name), 200),
 		Content:         content,
+		MetadataJSON:    metadataJSON,
 		SyncStatus:      knowledgeSyncStatusPending,
 	}
 	if doc.Name == "" {
@@ -1073,6 +1184,8 @@ func (uc *UserController) UpdateKnowledgeBaseDocument(c *gin.Context) {
 	var req struct {
 		Name    string `json:"name" binding:"required,min=1,max=200"`
 		Content string `json:"content" binding:"required"`
+		// 未传时保留原元数据；传空对象清空
+		Metadata *map[string]interface{} `json:"metadata"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
@@ -1082,6 +1195,14 @@ func (uc *UserController) UpdateKnowledgeBaseDocument(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "文档内容不能为空"})
 		return
 	}
+	if req.Metadata != nil {
+		metadataJSON, err := normalizeKnowledgeDocumentMetadata(*req.Metadata)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		doc.MetadataJSON = metadataJSON
+	}
 
 	doc.Name = strings.TrimSpace(req.Name)
 	doc.Content = req.Content
@@ -1091,6 +1212,7 @@ func (uc *UserController) UpdateKnowledgeBaseDocument(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新文档失败"})
 		return
 	}
+	estimate := estimateKnowledgeContent(uc.DB, kb, doc.Content)
 
 	if err := enqueueKnowledgeDocumentSyncUpsert(uc.DB, kb.ID, doc.ID); err != nil {
 		_ = uc.DB.Model(&models.KnowledgeBaseDocument{}).Where("id = ?", doc.ID).Updates(map[string]interface{}{
@@ -1100,12 +1222,13 @@ func (uc *UserController) UpdateKnowledgeBaseDocument(c *gin.Context) {
 		_ = uc.DB.Where("id = ?", doc.ID).First(&doc).Error
 		c.JSON(http.StatusOK, gin.H{
 			"data":       doc,
+			"estimate":   estimate,
 			"warning":    "文档已更新，但同步任务入队失败",
 			"sync_error": err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": doc, "message": "文档已更新，后台正在同步"})
+	c.JSON(http.StatusOK, gin.H{"data": doc, "estimate": estimate, "message": "文档已更新，后台正在同步"})
 }
 
 func (uc *UserController) DeleteKnowledgeBaseDocument(c *gin.Context) {
@@ -1365,6 +1488,15 @@ func resolveKnowledgeThreshold(requestThreshold *float64, kbThreshold *float64,
 	return clampKnowledgeThreshold(globalThreshold), "global"
 }
 
+// knowledgeProviderGlobalThreshold 读取 provider 全局检索阈值：ragflow 为 similarity_threshold，其余为 score_threshold
+func knowledgeProviderGlobalThreshold(provider string, providerData map[string]interface{}) float64 {
+	key := "score_threshold"
+	if strings.EqualFold(strings.TrimSpace(provider), "ragflow") {
+		key = "similarity_threshold"
+	}
+	return parseKnowledgeSearchFloat(providerData[key], 0.2)
+}
+
 func formatKnowledgeThresholdForLog(v *float64) string {
 	if v == nil {
 		return "inherit_global"
@@ -1384,7 +1516,7 @@ func queryKnowledgeTestByDify(
 	scoreThreshold, thresholdSource := resolveKnowledgeThreshold(
 		requestThreshold,
 		kbThreshold,
-		parseKnowledgeSearchFloat(providerData["score_threshold"], 0.2),
+		knowledgeProviderGlobalThreshold("dify", providerData),
 	)
 	payload := map[string]interface{}{
 		"query": strings.TrimSpace(query),
@@ -1397,7 +1529,7 @@ func queryKnowledgeTestByDify(
 		},
 	}
 	path := fmt.Sprintf("/datasets/%s/retrieve", url.PathEscape(datasetID))
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeTest][Dify] RetrieveRequest dataset_id=%s query=%q top_k=%d score_threshold=%.4f threshold_enabled=%t threshold_source=%s",
 		datasetID,
 		strings.TrimSpace(query),
@@ -1406,20 +1538,22 @@ func queryKnowledgeTestByDify(
 		scoreThreshold > 0,
 		thresholdSource,
 	)
+	type difyRetrieveRecord struct {
+		Score   float64 `json:"score"`
+		Segment struct {
+			Content    string `json:"content"`
+			DocumentID string `json:"document_id"`
+			Position   int    `json:"position"` // Dify 分段位置从1开始
+			Document   struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"document"`
+		} `json:"segment"`
+	}
 	var resp struct {
-		Records []struct {
-			Score   float64 `json:"score"`
-			Segment struct {
-				Content string `json:"content"`
-			} `json:"segment"`
-		} `json:"records"`
-		Data struct {
-			Records []struct {
-				Score   float64 `json:"score"`
-				Segment struct {
-					Content string `json:"content"`
-				} `json:"segment"`
-			} `json:"records"`
+		Records []difyRetrieveRecord `json:"records"`
+		Data    struct {
+			Records []difyRetrieveRecord `json:"records"`
 		} `json:"data"`
 	}
 	statusCode, bodyBytes, err := doDifyJSONRequest(client, http.MethodPost, buildDifyURL(cfg.BaseURL, path), cfg.APIKey, payload, &resp)
@@ -1432,30 +1566,41 @@ func queryKnowledgeTestByDify(
 		title = datasetID
 	}
 	hits := make([]knowledgeSearchTestHit, 0, len(resp.Records)+len(resp.Data.Records))
-	appendRecord := func(score float64, content string) {
-		content = strings.TrimSpace(content)
+	appendRecord := func(record difyRetrieveRecord) {
+		content := strings.TrimSpace(record.Segment.Content)
 		if content == "" {
 			return
 		}
-		hits = append(hits, knowledgeSearchTestHit{
-			Title:   title,
-			Score:   score,
-			Content: content,
-		})
+		hit := knowledgeSearchTestHit{
+			Title:        title,
+			Score:        record.Score,
+			Content:      content,
+			DocumentID:   strings.TrimSpace(record.Segment.DocumentID),
+			DocumentName: strings.TrimSpace(record.Segment.Document.Name),
+			KBName:       strings.TrimSpace(datasetName),
+		}
+		if hit.DocumentID == "" {
+			hit.DocumentID = strings.TrimSpace(record.Segment.Document.ID)
+		}
+		if record.Segment.Position > 0 {
+			chunkIndex := record.Segment.Position - 1
+			hit.ChunkIndex = &chunkIndex
+		}
+		hits = append(hits, hit)
 	}
 	for _, record := range resp.Records {
-		appendRecord(record.Score, record.Segment.Content)
+		appendRecord(record)
 	}
 	if len(hits) == 0 {
 		for _, record := range resp.Data.Records {
-			appendRecord(record.Score, record.Segment.Content)
+			appendRecord(record)
 		}
 	}
 	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
 	if len(hits) > topK {
 		hits = hits[:topK]
 	}
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeTest][Dify] RetrieveParsed dataset_id=%s status=%d records=%d data_records=%d hits=%d",
 		datasetID,
 		statusCode,
@@ -1464,7 +1609,7 @@ func queryKnowledgeTestByDify(
 		len(hits),
 	)
 	if len(hits) == 0 {
-		log.Printf(
+		logger.Debugf(
 			"[KnowledgeTest][Dify] EmptyBody dataset_id=%s status=%d body=%s",
 			datasetID,
 			statusCode,
@@ -1486,7 +1631,7 @@ func queryKnowledgeTestByRagflow(
 	similarityThreshold, thresholdSource := resolveKnowledgeThreshold(
 		requestThreshold,
 		kbThreshold,
-		parseKnowledgeSearchFloat(providerData["similarity_threshold"], 0.2),
+		knowledgeProviderGlobalThreshold("ragflow", providerData),
 	)
 	vectorSimilarityWeight := parseKnowledgeSearchFloat(providerData["vector_similarity_weight"], 0.3)
 	if vectorSimilarityWeight <= 0 {
@@ -1503,7 +1648,7 @@ func queryKnowledgeTestByRagflow(
 		"keyword":                  parseKnowledgeSearchBool(providerData["keyword"], false),
 		"highlight":                parseKnowledgeSearchBool(providerData["highlight"], false),
 	}
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeTest][Ragflow] RetrieveRequest dataset_id=%s query=%q top_k=%d similarity_threshold=%.4f vector_similarity_weight=%.4f keyword=%t highlight=%t threshold_source=%s",
 		datasetID,
 		strings.TrimSpace(query),
@@ -1523,6 +1668,8 @@ func queryKnowledgeTestByRagflow(
 				Similarity       float64 `json:"similarity"`
 				VectorSimilarity float64 `json:"vector_similarity"`
 				DocumentName     string  `json:"document_name"`
+				DocumentID       string  `json:"document_id"`
+				DocumentKeyword  string  `json:"document_keyword"` // RAGFlow 返回的文档名称
 			} `json:"chunks"`
 		} `json:"data"`
 	}
@@ -1555,17 +1702,24 @@ func queryKnowledgeTestByRagflow(
 		if chunkTitle == "" {
 			chunkTitle = datasetID
 		}
+		documentName := strings.TrimSpace(chunk.DocumentName)
+		if documentName == "" {
+			documentName = strings.TrimSpace(chunk.DocumentKeyword)
+		}
 		hits = append(hits, knowledgeSearchTestHit{
-			Title:   chunkTitle,
-			Score:   score,
-			Content: content,
+			Title:        chunkTitle,
+			Score:        score,
+			Content:      content,
+			DocumentID:   strings.TrimSpace(chunk.DocumentID),
+			DocumentName: documentName,
+			KBName:       strings.TrimSpace(datasetName),
 		})
 	}
 	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
 	if len(hits) > topK {
 		hits = hits[:topK]
 	}
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeTest][Ragflow] RetrieveParsed dataset_id=%s status=%d chunks=%d hits=%d",
 		datasetID,
 		statusCode,
@@ -1573,7 +1727,7 @@ func queryKnowledgeTestByRagflow(
 		len(hits),
 	)
 	if len(hits) == 0 {
-		log.Printf(
+		logger.Debugf(
 			"[KnowledgeTest][Ragflow] EmptyBody dataset_id=%s status=%d body=%s",
 			datasetID,
 			statusCode,
@@ -1596,13 +1750,13 @@ func queryKnowledgeTestByWeknora(
 	scoreThreshold, thresholdSource := resolveKnowledgeThreshold(
 		requestThreshold,
 		kbThreshold,
-		parseKnowledgeSearchFloat(providerData["score_threshold"], 0.2),
+		knowledgeProviderGlobalThreshold("weknora", providerData),
 	)
 	payload := map[string]interface{}{
 		"query":              strings.TrimSpace(query),
 		"knowledge_base_ids": []string{strings.TrimSpace(datasetID)},
 	}
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeTest][Weknora] RetrieveRequest dataset_id=%s knowledge_base_ids=1 query=%q top_k=%d score_threshold=%.4f threshold_source=%s threshold_filter=disabled",
 		datasetID,
 		strings.TrimSpace(query),
@@ -1614,7 +1768,9 @@ func queryKnowledgeTestByWeknora(
 	var resp struct {
 		Data []struct {
 			Content           string                 `json:"content"`
+			KnowledgeID       string                 `json:"knowledge_id"`
 			KnowledgeTitle    string                 `json:"knowledge_title"`
+			ChunkIndex        *int                   `json:"chunk_index"`
 			Score             float64                `json:"score"`
 			Similarity        float64                `json:"similarity"`
 			Metadata          map[string]interface{} `json:"metadata"`
@@ -1655,16 +1811,20 @@ func queryKnowledgeTestByWeknora(
 			chunkTitle = title
 		}
 		hits = append(hits, knowledgeSearchTestHit{
-			Title:   chunkTitle,
-			Score:   score,
-			Content: content,
+			Title:        chunkTitle,
+			Score:        score,
+			Content:      content,
+			DocumentID:   strings.TrimSpace(item.KnowledgeID),
+			DocumentName: strings.TrimSpace(item.KnowledgeTitle),
+			KBName:       strings.TrimSpace(datasetName),
+			ChunkIndex:   item.ChunkIndex,
 		})
 	}
 	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
 	if len(hits) > topK {
 		hits = hits[:topK]
 	}
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeTest][Weknora] RetrieveParsed dataset_id=%s status=%d records=%d hits=%d",
 		datasetID,
 		statusCode,
@@ -1672,7 +1832,7 @@ func queryKnowledgeTestByWeknora(
 		len(hits),
 	)
 	if len(hits) == 0 {
-		log.Printf(
+		logger.Debugf(
 			"[KnowledgeTest][Weknora] EmptyBody dataset_id=%s status=%d body=%s",
 			datasetID,
 			statusCode,
@@ -1748,7 +1908,8 @@ func parseKnowledgeSearchBool(input interface{}, defaultValue bool) bool {
 	return defaultValue
 }
 
-func readKnowledgeUploadFileData(provider string, fileHeader *multipart.FileHeader) (string, []byte, error) {
+// readKnowledgeUploadFile 按给定扩展名白名单校验并读取上传文件
+func readKnowledgeUploadFile(provider string, fileHeader *multipart.FileHeader, allowedExtMap map[string]struct{}, supportedText string) (string, []byte, error) {
 	if fileHeader == nil {
 		return "", nil, fmt.Errorf("上传文件不能为空")
 	}
@@ -1758,7 +1919,6 @@ func readKnowledgeUploadFileData(provider string, fileHeader *multipart.FileHead
 
 	fileName := sanitizeKnowledgeUploadFileName(fileHeader.Filename)
 	ext := strings.ToLower(filepath.Ext(fileName))
-	allowedExtMap, supportedText := getAllowedKnowledgeUploadExtByProvider(provider)
 	if ext == "" {
 		return "", nil, fmt.Errorf("文件类型不支持，缺少扩展名，%s支持格式: %s", strings.ToUpper(provider), supportedText)
 	}
@@ -1798,6 +1958,24 @@ func getAllowedKnowledgeUploadExtByProvider(provider string) (map[string]struct{
 	}
 }
 
+// knowledgeProviderAcceptsFile 判断 provider 是否接受该扩展名的文件上传
+func knowledgeProviderAcceptsFile(provider, fileName string) bool {
+	allowed, _ := getAllowedKnowledgeUploadExtByProvider(provider)
+	_, ok := allowed[strings.ToLower(filepath.Ext(fileName))]
+	return ok
+}
+
+// mergeKnowledgeExtSets 合并扩展名白名单
+func mergeKnowledgeExtSets(sets ...map[string]struct{}) map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, set := range sets {
+		for ext := range set {
+			out[ext] = struct{}{}
+		}
+	}
+	return out
+}
+
 func buildKnowledgeUploadDocumentName(inputName, fileName string) string {
 	name := strings.TrimSpace(inputName)
 	if name == "" {
@@ -1912,6 +2090,7 @@ func (ac *AdminController) CreateUserKnowledgeBaseAdmin(c *gin.Context) {
 		Status                 string   `json:"status"`
 		RetrievalThreshold     *float64 `json:"retrieval_threshold"`
 		InheritGlobalThreshold *bool    `json:"inherit_global_threshold"`
+		AutoSummary            *bool    `json:"auto_summary"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
@@ -1932,6 +2111,7 @@ func (ac *AdminController) CreateUserKnowledgeBaseAdmin(c *gin.Context) {
 		Content:            req.Content,
 		RetrievalThreshold: retrievalThreshold,
 		Status:             req.Status,
+		AutoSummary:        req.AutoSummary != nil && *req.AutoSummary,
 		SyncStatus:         knowledgeSyncStatusPending,
 		SyncProvider:       resolveDefaultKnowledgeProviderName(ac.DB),
 	}
@@ -1977,17 +2157,23 @@ func (ac *AdminController) UpdateUserKnowledgeBaseAdmin(c *gin.Context) {
 		Status                 string   `json:"status"`
 		RetrievalThreshold     *float64 `json:"retrieval_threshold"`
 		InheritGlobalThreshold *bool    `json:"inherit_global_threshold"`
+		AutoSummary            *bool    `json:"auto_summary"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
 		return
 	}
+	// 正文未变化时后台同步只更新 dataset 元数据，不重新索引文档
+	contentUnchanged := item.ContentHash != "" && item.ExternalDocID != "" && item.ContentHash == knowledgeContentHash(req.Content)
 	item.Name = req.Name
 	item.Description = req.Description
 	item.Content = req.Content
 	if req.Status != "" {
 		item.Status = req.Status
 	}
+	if req.AutoSummary != nil {
+		item.AutoSummary = *req.AutoSummary
+	}
 	if req.InheritGlobalThreshold != nil || req.RetrievalThreshold != nil {
 		retrievalThreshold, err := buildKnowledgeRetrievalThreshold(req.InheritGlobalThreshold, req.RetrievalThreshold)
 		if err != nil {
@@ -2015,7 +2201,11 @@ func (ac *AdminController) UpdateUserKnowledgeBaseAdmin(c *gin.Context) {
 		})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": item, "message": "知识库已更新，后台正在同步"})
+	message := "知识库已更新，后台正在同步"
+	if contentUnchanged {
+		message = "知识库已更新，内容未变化，仅同步元数据"
+	}
+	c.JSON(http.StatusOK, gin.H{"data": item, "message": message, "content_unchanged": contentUnchanged})
 }
 
 func (ac *AdminController) DeleteUserKnowledgeBaseAdmin(c *gin.Context) {
@@ -2031,45 +2221,10 @@ func (ac *AdminController) DeleteUserKnowledgeBaseAdmin(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "知识库不存在"})
 		return
 	}
-	var docs []models.KnowledgeBaseDocument
-	if err := ac.DB.Where("knowledge_base_id = ?", item.ID).Find(&docs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询知识库文档失败"})
-		return
-	}
-
-	err := ac.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("id = ? AND user_id = ?", kbID, userID).Delete(&models.KnowledgeBase{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Where("knowledge_base_id = ?", kbID).Delete(&models.KnowledgeBaseDocument{}).Error; err != nil {
-			return err
-		}
-		return tx.Where("knowledge_base_id = ?", kbID).Delete(&models.AgentKnowledgeBase{}).Error
-	})
-	if err != nil {
+	if err := softDeleteKnowledgeBase(ac.DB, &item); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除知识库失败"})
 		return
 	}
-	for _, doc := range docs {
-		if err := enqueueKnowledgeDocumentSyncDelete(ac.DB, item, doc); err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"message":    "删除成功",
-				"warning":    "本地删除成功，但部分知识库文档清理任务入队失败",
-				"sync_error": err.Error(),
-			})
-			return
-		}
-	}
-
-	if len(docs) == 0 {
-		if err := enqueueKnowledgeSyncDelete(ac.DB, item); err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"message":    "删除成功",
-				"warning":    "本地删除成功，但知识库清理任务入队失败",
-				"sync_error": err.Error(),
-			})
-			return
-		}
-	}
-	c.JSON(http.StatusOK, gin.H{"message": "删除成功，后台正在清理知识库数据"})
+	purgeAt := time.Now().Add(knowledgeBasePurgeGracePeriod)
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功，知识库可在保留期内恢复", "purge_at": purgeAt})
 }
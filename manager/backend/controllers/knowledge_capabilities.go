@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 已接入同步的知识库 provider
+var knowledgeProviderNames = []string{"dify", "ragflow", "weknora"}
+
+// knowledgeProviderCapabilities 知识库 provider 能力，供前端按 provider 调整可选项
+type knowledgeProviderCapabilities struct {
+	Provider       string   `json:"provider"`
+	FileExtensions []string `json:"file_extensions"` // 允许上传的文件扩展名
+	SupportedText  string   `json:"supported_text"`  // 上传提示文案
+	// UpdateInPlace 文档修改后是否原地更新；不支持时重新上传新文档并删除旧文档，provider 侧文档ID会变化
+	UpdateInPlace bool `json:"update_in_place"`
+	// ParseStatusPolling 同步时是否轮询 provider 解析状态，直至解析完成才标记为已同步
+	ParseStatusPolling bool `json:"parse_status_polling"`
+	// DocumentMetadata 是否将文档元数据同步到 provider，用于按元数据过滤检索
+	DocumentMetadata bool `json:"document_metadata"`
+	// TextExtractionExtensions 上传时可在服务端提取为文本的扩展名（extract_text=true 或 provider 配置 text_only）
+	TextExtractionExtensions []string `json:"text_extraction_extensions"`
+}
+
+// GetKnowledgeProviderCapabilities 返回指定 provider 的能力；文件扩展名取自上传白名单
+func GetKnowledgeProviderCapabilities(provider string) (knowledgeProviderCapabilities, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	caps := knowledgeProviderCapabilities{Provider: provider}
+	switch provider {
+	case "dify":
+		// 文本文档通过 update-by-text 原地更新；文件文档仍为替换
+		caps.UpdateInPlace = true
+	case "ragflow":
+		// 上传后触发解析，不等待解析结果
+	case "weknora":
+		caps.ParseStatusPolling = true
+	default:
+		return caps, false
+	}
+	caps.DocumentMetadata = knowledgeProviderSupportsDocumentMetadata(provider)
+
+	allowed, supportedText := getAllowedKnowledgeUploadExtByProvider(provider)
+	caps.FileExtensions = make([]string, 0, len(allowed))
+	for ext := range allowed {
+		caps.FileExtensions = append(caps.FileExtensions, ext)
+	}
+	sort.Strings(caps.FileExtensions)
+	caps.SupportedText = supportedText
+	caps.TextExtractionExtensions = make([]string, 0, len(knowledgeTextExtractableExt))
+	for ext := range knowledgeTextExtractableExt {
+		caps.TextExtractionExtensions = append(caps.TextExtractionExtensions, ext)
+	}
+	sort.Strings(caps.TextExtractionExtensions)
+	return caps, true
+}
+
+// GetKnowledgeProviderCapabilities 获取知识库 provider 能力矩阵；带 provider 参数时仅返回该 provider
+func (uc *UserController) GetKnowledgeProviderCapabilities(c *gin.Context) {
+	if provider := strings.TrimSpace(c.Query("provider")); provider != "" {
+		caps, ok := GetKnowledgeProviderCapabilities(provider)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "不支持的知识库 provider: " + provider})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": caps})
+		return
+	}
+
+	list := make([]knowledgeProviderCapabilities, 0, len(knowledgeProviderNames))
+	for _, name := range knowledgeProviderNames {
+		if caps, ok := GetKnowledgeProviderCapabilities(name); ok {
+			list = append(list, caps)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"default_provider": resolveDefaultKnowledgeProviderName(uc.DB),
+		"providers":        list,
+	}})
+}
@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 本地分段预览的内容上限（字符数），避免超大文本阻塞请求
+const maxKnowledgeChunkPreviewChars = 200000
+
+// 命中内容与本地分段的最低匹配度，低于该值视为未匹配（如命中来自其他文档或内容已变更）
+const minKnowledgeChunkMatchScore = 0.5
+
+// knowledgePreviewChunk 本地分段结果，Start/End 为原文中的字符（rune）偏移
+type knowledgePreviewChunk struct {
+	Index   int    `json:"index"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Content string `json:"content"`
+	// 命中该分段的检索结果序号（对应 hits 下标）
+	HitRanks []int `json:"hit_ranks,omitempty"`
+}
+
+// knowledgePreviewHit 检索命中及其对应的本地分段
+type knowledgePreviewHit struct {
+	knowledgeSearchTestHit
+	MatchedChunkIndex *int    `json:"matched_chunk_index,omitempty"`
+	MatchScore        float64 `json:"match_score"`
+}
+
+// 分段时优先在这些字符之后断开，与常见 provider 的分隔规则接近
+func isKnowledgeChunkBoundary(r rune) bool {
+	switch r {
+	case '\n', '。', '！', '？', '；', '.', '!', '?', ';':
+		return true
+	}
+	return false
+}
+
+// splitKnowledgePreviewChunks 按 chunk_size/chunk_overlap 在本地切分内容。
+// 窗口后半段内存在句子边界时在边界处断开，结果与 provider 实际分段近似但不保证完全一致。
+func splitKnowledgePreviewChunks(content string, chunkSize, chunkOverlap int) []knowledgePreviewChunk {
+	runes := []rune(content)
+	n := len(runes)
+	if n == 0 {
+		return nil
+	}
+	if chunkSize <= 0 {
+		chunkSize = n
+	}
+	if chunkOverlap < 0 || chunkOverlap >= chunkSize {
+		chunkOverlap = 0
+	}
+
+	var chunks []knowledgePreviewChunk
+	for pos := 0; pos < n; {
+		end := pos + chunkSize
+		if end >= n {
+			end = n
+		} else {
+			for i := end - 1; i >= pos+chunkSize/2; i-- {
+				if isKnowledgeChunkBoundary(runes[i]) {
+					end = i + 1
+					break
+				}
+			}
+		}
+		if text := strings.TrimSpace(string(runes[pos:end])); text != "" {
+			chunks = append(chunks, knowledgePreviewChunk{Index: len(chunks), Start: pos, End: end, Content: text})
+		}
+		if end >= n {
+			break
+		}
+		next := end - chunkOverlap
+		if next <= pos {
+			next = end
+		}
+		pos = next
+	}
+	return chunks
+}
+
+// normalizeKnowledgeMatchText 去除空白，避免 provider 清洗换行/空格后影响匹配
+func normalizeKnowledgeMatchText(s string) []rune {
+	out := make([]rune, 0, utf8.RuneCountInString(s))
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			out = append(out, unicode.ToLower(r))
+		}
+	}
+	return out
+}
+
+func knowledgeMatchBigrams(runes []rune) map[[2]rune]struct{} {
+	set := make(map[[2]rune]struct{}, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		set[[2]rune{runes[i], runes[i+1]}] = struct{}{}
+	}
+	return set
+}
+
+// matchKnowledgeHitToChunk 返回与命中内容最匹配的分段下标及匹配度（命中内容的二元组在分段中的覆盖率），无匹配时下标为 -1
+func matchKnowledgeHitToChunk(hitContent string, chunks []knowledgePreviewChunk) (int, float64) {
+	hit := normalizeKnowledgeMatchText(hitContent)
+	if len(hit) == 0 {
+		return -1, 0
+	}
+	hitText := string(hit)
+	hitBigrams := knowledgeMatchBigrams(hit)
+
+	best, bestScore := -1, 0.0
+	for i, chunk := range chunks {
+		chunkRunes := normalizeKnowledgeMatchText(chunk.Content)
+		if strings.Contains(string(chunkRunes), hitText) {
+			return i, 1
+		}
+		if len(hitBigrams) == 0 {
+			continue
+		}
+		chunkBigrams := knowledgeMatchBigrams(chunkRunes)
+		covered := 0
+		for bg := range hitBigrams {
+			if _, ok := chunkBigrams[bg]; ok {
+				covered++
+			}
+		}
+		if score := float64(covered) / float64(len(hitBigrams)); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if bestScore < minKnowledgeChunkMatchScore {
+		return -1, bestScore
+	}
+	return best, bestScore
+}
+
+// PreviewKnowledgeChunks 本地分段预览并执行测试检索，将命中结果映射回本地分段，便于排查哪些内容回答了查询
+// 内容来源二选一：content（待保存的文本）或 document_id（知识库中已有文档）
+func (uc *UserController) PreviewKnowledgeChunks(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	userIDUint := userID.(uint)
+	startAt := time.Now()
+	kbID, _ := strconv.Atoi(c.Param("id"))
+	if kbID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的知识库ID"})
+		return
+	}
+	kb, err := uc.getOwnedKnowledgeBase(userIDUint, uint(kbID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		Content    string   `json:"content"`
+		DocumentID uint     `json:"document_id"`
+		Query      string   `json:"query" binding:"required"`
+		TopK       int      `json:"top_k"`
+		Threshold  *float64 `json:"threshold"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query 不能为空"})
+		return
+	}
+	topK := req.TopK
+	if topK <= 0 {
+		topK = 5
+	}
+	if topK > 20 {
+		topK = 20
+	}
+	if req.Threshold != nil && (*req.Threshold < 0 || *req.Threshold > 1) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold 必须在 0~1 之间"})
+		return
+	}
+
+	content := req.Content
+	if req.DocumentID > 0 {
+		var doc models.KnowledgeBaseDocument
+		if err := uc.DB.Where("id = ? AND knowledge_base_id = ?", req.DocumentID, kb.ID).First(&doc).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+			return
+		}
+		content = doc.Content
+	}
+	if _, _, ok, _ := decodeKnowledgeUploadContent(content); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件类文档由知识库服务解析，暂不支持本地分段预览"})
+		return
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content 或 document_id 至少提供一个且内容不能为空"})
+		return
+	}
+	if utf8.RuneCountInString(content) > maxKnowledgeChunkPreviewChars {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "内容过长，分段预览最多支持 " + strconv.Itoa(maxKnowledgeChunkPreviewChars) + " 字符"})
+		return
+	}
+
+	datasetID := strings.TrimSpace(kb.ExternalKBID)
+	if datasetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "知识库尚未同步到外部 provider（external_kb_id 为空）"})
+		return
+	}
+	provider, _, providerData, err := resolveKnowledgeProviderForKB(uc.DB, kb)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+
+	// 分段参数与保存时的规模估算一致
+	estimate := estimateKnowledgeContent(uc.DB, kb, content)
+	chunks := splitKnowledgePreviewChunks(content, estimate.ChunkSize, estimate.ChunkOverlap)
+
+	client := &http.Client{Timeout: 12 * time.Second}
+	rawHits, status, err := queryKnowledgeTestHits(client, provider, providerData, kb, req.Threshold, datasetID, query, topK)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	fillKnowledgeHitLocalDocuments(uc.DB, kb.ID, rawHits)
+
+	hits := make([]knowledgePreviewHit, 0, len(rawHits))
+	matched := 0
+	for rank, raw := range rawHits {
+		hit := knowledgePreviewHit{knowledgeSearchTestHit: raw}
+		// 已关联到其他本地文档的命中不参与匹配
+		if req.DocumentID == 0 || raw.LocalDocumentID == 0 || raw.LocalDocumentID == req.DocumentID {
+			idx, score := matchKnowledgeHitToChunk(raw.Content, chunks)
+			hit.MatchScore = score
+			if idx >= 0 {
+				hit.MatchedChunkIndex = &idx
+				chunks[idx].HitRanks = append(chunks[idx].HitRanks, rank)
+				matched++
+			}
+		}
+		hits = append(hits, hit)
+	}
+
+	logger.Infof("[KnowledgeChunkPreview] user_id=%d kb_id=%d provider=%s document_id=%d chunks=%d hits=%d matched=%d query=%q",
+		userIDUint, kb.ID, provider, req.DocumentID, len(chunks), len(hits), matched, query)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"knowledge_base_id": kb.ID,
+			"provider":          provider,
+			"document_id":       req.DocumentID,
+			"query":             query,
+			"top_k":             topK,
+			"threshold":         req.Threshold,
+			"chunk_size":        estimate.ChunkSize,
+			"chunk_overlap":     estimate.ChunkOverlap,
+			"chunk_count":       len(chunks),
+			"chunks":            chunks,
+			"hits":              hits,
+			"matched_count":     matched,
+			"elapsed_ms":        time.Since(startAt).Milliseconds(),
+		},
+	})
+}
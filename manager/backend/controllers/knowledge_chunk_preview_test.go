@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitKnowledgePreviewChunks(t *testing.T) {
+	content := strings.Repeat("甲", 30) + "。" + strings.Repeat("乙", 30) + "。" + strings.Repeat("丙", 10)
+	chunks := splitKnowledgePreviewChunks(content, 40, 5)
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %d, want 3: %+v", len(chunks), chunks)
+	}
+	// 在句号后断开
+	if chunks[0].End != 31 || !strings.HasSuffix(chunks[0].Content, "。") {
+		t.Fatalf("first chunk = %+v", chunks[0])
+	}
+	// 后一段与前一段重叠 overlap 个字符
+	if chunks[1].Start != chunks[0].End-5 {
+		t.Fatalf("second chunk start = %d, want %d", chunks[1].Start, chunks[0].End-5)
+	}
+	if last := chunks[len(chunks)-1]; last.End != len([]rune(content)) {
+		t.Fatalf("last chunk end = %d", last.End)
+	}
+
+	if got := splitKnowledgePreviewChunks("  \n ", 10, 2); len(got) != 0 {
+		t.Fatalf("blank content chunks = %+v", got)
+	}
+}
+
+func TestMatchKnowledgeHitToChunk(t *testing.T) {
+	chunks := splitKnowledgePreviewChunks("退货政策：签收后七天内可无理由退货。\n保修政策：整机保修一年，电池保修半年。", 20, 0)
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %+v", chunks)
+	}
+
+	// provider 清洗空白后的内容仍能精确匹配
+	if idx, score := matchKnowledgeHitToChunk("保修政策： 整机保修一年", chunks); idx != 1 || score != 1 {
+		t.Fatalf("exact match = %d/%v", idx, score)
+	}
+	// 部分改写的内容按二元组覆盖率匹配
+	if idx, score := matchKnowledgeHitToChunk("签收后七天内可以无理由退货", chunks); idx != 0 || score < minKnowledgeChunkMatchScore || score >= 1 {
+		t.Fatalf("fuzzy match = %d/%v", idx, score)
+	}
+	if idx, _ := matchKnowledgeHitToChunk("完全无关的内容片段", chunks); idx != -1 {
+		t.Fatalf("unrelated hit matched chunk %d", idx)
+	}
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"xiaozhi/manager/backend/models"
+)
+
+// knowledgeContentHash 计算知识库正文的 sha256，用于判断正文是否变化
+func knowledgeContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// knowledgeContentUnchanged 正文与上次成功同步时一致且外部文档仍存在时返回 true，此时无需重新上传文档
+func knowledgeContentUnchanged(kb *models.KnowledgeBase, result *knowledgeProviderSyncResult) bool {
+	if kb == nil || result == nil || kb.ContentHash == "" || strings.TrimSpace(result.DocumentID) == "" {
+		return false
+	}
+	return kb.ContentHash == knowledgeContentHash(kb.Content)
+}
+
+// updateDifyDatasetMetadata 更新 Dify dataset 的名称与描述
+func updateDifyDatasetMetadata(client *http.Client, cfg *difyKnowledgeSyncConfig, datasetID string, kb *models.KnowledgeBase) error {
+	payload := map[string]interface{}{
+		"name":        buildAutoDatasetName(kb),
+		"description": strings.TrimSpace(kb.Description),
+	}
+	endpoint := buildDifyURL(cfg.BaseURL, fmt.Sprintf("/datasets/%s", url.PathEscape(datasetID)))
+	if _, _, err := doDifyJSONRequest(client, http.MethodPatch, endpoint, cfg.APIKey, payload, nil); err != nil {
+		return fmt.Errorf("更新Dify dataset失败(dataset_id=%s): %w", datasetID, err)
+	}
+	return nil
+}
+
+// updateRagflowDatasetMetadata 更新 RAGFlow dataset 的名称与描述
+func updateRagflowDatasetMetadata(client *http.Client, cfg *ragflowKnowledgeSyncConfig, datasetID string, kb *models.KnowledgeBase) error {
+	payload := map[string]interface{}{
+		"name":        buildAutoDatasetName(kb),
+		"description": strings.TrimSpace(kb.Description),
+	}
+	endpoint := buildRagflowURL(cfg.BaseURL, fmt.Sprintf("/datasets/%s", url.PathEscape(datasetID)))
+	if _, _, err := doRagflowJSONRequest(client, http.MethodPut, endpoint, cfg.APIKey, payload, nil); err != nil {
+		return fmt.Errorf("更新RAGFlow dataset失败(dataset_id=%s): %w", datasetID, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestSyncKnowledgeBaseSkipsUnchangedContent(t *testing.T) {
+	db := newTestDB(t, &models.KnowledgeBase{}, &models.KnowledgeBaseDocument{})
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"ds","document":{"id":"doc-1"}}`))
+	}))
+	defer srv.Close()
+
+	providerData := map[string]interface{}{"base_url": srv.URL, "api_key": "k"}
+	kb := &models.KnowledgeBase{ID: 1, Name: "FAQ", Content: "问：营业时间？答：9点到18点。",
+		ExternalKBID: "ds", ExternalDocID: "doc-1", AutoDataset: true}
+	kb.ContentHash = knowledgeContentHash(kb.Content)
+
+	// 正文未变化：只更新 dataset 元数据，不触碰文档
+	result, err := syncKnowledgeBaseToProvider(context.Background(), db, kb, "dify", providerData)
+	if err != nil {
+		t.Fatalf("sync err = %v", err)
+	}
+	if result.ContentHash != kb.ContentHash || result.DocumentID != "doc-1" || result.LastSyncedAt == nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(requests) != 1 || requests[0] != "PATCH /v1/datasets/ds" {
+		t.Fatalf("requests = %v, want only dataset PATCH", requests)
+	}
+
+	// 正文变化：重新同步文档
+	requests = nil
+	kb.Content += "周末休息。"
+	_, _ = syncKnowledgeBaseToProvider(context.Background(), db, kb, "dify", providerData)
+	touchedDocument := false
+	for _, req := range requests {
+		if strings.Contains(req, "/documents/doc-1") {
+			touchedDocument = true
+		}
+	}
+	if !touchedDocument {
+		t.Fatalf("changed content did not update document, requests = %v", requests)
+	}
+
+	// 清空本测试产生的同步事件，避免影响其它读取事件通道的测试
+	for drained := false; !drained; {
+		select {
+		case <-knowledgeSyncEventCh:
+		default:
+			drained = true
+		}
+	}
+
+	// 未记录哈希（历史数据）时按变化处理
+	kb.ContentHash = ""
+	if knowledgeContentUnchanged(kb, &knowledgeProviderSyncResult{DocumentID: "doc-1"}) {
+		t.Fatal("empty hash should not be treated as unchanged")
+	}
+}
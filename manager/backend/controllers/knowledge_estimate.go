@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 默认超过该 token 估算值时给出提示，可通过 provider 配置 max_content_tokens 覆盖
+const defaultKnowledgeContentTokenWarnThreshold = 200000
+
+// knowledgeContentEstimate 保存时返回的内容规模估算，仅供前端提示，不作为硬限制
+type knowledgeContentEstimate struct {
+	Provider       string `json:"provider"`
+	Chars          int    `json:"chars"`
+	Bytes          int    `json:"bytes"`
+	ApproxTokens   int    `json:"approx_tokens"`
+	ChunkSize      int    `json:"chunk_size"`
+	ChunkOverlap   int    `json:"chunk_overlap"`
+	ApproxChunks   int    `json:"approx_chunks"`
+	TokenThreshold int    `json:"token_threshold"`
+	ExceedsLimit   bool   `json:"exceeds_limit"`
+	Warning        string `json:"warning,omitempty"`
+}
+
+// estimateKnowledgeTokens 粗略估算 token 数：CJK 字符按 1 token/字，其余按约 4 字符/token
+func estimateKnowledgeTokens(content string) int {
+	cjk := 0
+	other := 0
+	for _, r := range content {
+		switch {
+		case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r), unicode.Is(unicode.Hangul, r):
+			cjk++
+		case unicode.IsSpace(r):
+			// 空白字符通常与相邻内容合并为同一 token
+		default:
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// estimateKnowledgeChunks 按字符数与 chunk_size/chunk_overlap 估算分段数
+func estimateKnowledgeChunks(chars, chunkSize, chunkOverlap int) int {
+	if chars <= 0 {
+		return 0
+	}
+	if chunkSize <= 0 {
+		return 1
+	}
+	if chunkOverlap < 0 || chunkOverlap >= chunkSize {
+		chunkOverlap = 0
+	}
+	if chars <= chunkSize {
+		return 1
+	}
+	step := chunkSize - chunkOverlap
+	return 1 + (chars-chunkSize+step-1)/step
+}
+
+// estimateKnowledgeContent 根据知识库对应 provider 的分段配置估算内容规模。
+// provider 配置不可用时使用默认分段参数，不影响保存流程。
+func estimateKnowledgeContent(db *gorm.DB, kb *models.KnowledgeBase, content string) knowledgeContentEstimate {
+	estimate := knowledgeContentEstimate{
+		ChunkSize:      defaultWeknoraChunkSize,
+		ChunkOverlap:   defaultWeknoraChunkOverlap,
+		TokenThreshold: defaultKnowledgeContentTokenWarnThreshold,
+	}
+
+	// 文件上传内容为编码后的二进制，按原始文件大小估算
+	if fileName, fileData, ok, err := decodeKnowledgeUploadContent(content); err == nil && ok {
+		estimate.Bytes = len(fileData)
+		estimate.Warning = fmt.Sprintf("文件 %s 由知识库服务解析，无法预估 token 数", fileName)
+		return estimate
+	}
+
+	if kb != nil {
+		if provider, _, providerData, err := resolveKnowledgeProviderForKB(db, kb); err == nil {
+			estimate.Provider = provider
+			if v, ok := parseInt(providerData["chunk_size"]); ok && v > 0 {
+				estimate.ChunkSize = v
+			}
+			if v, ok := parseInt(providerData["chunk_overlap"]); ok && v >= 0 {
+				estimate.ChunkOverlap = v
+			}
+			if v, ok := parseInt(providerData["max_content_tokens"]); ok && v > 0 {
+				estimate.TokenThreshold = v
+			}
+		}
+	}
+	if estimate.ChunkOverlap >= estimate.ChunkSize {
+		estimate.ChunkOverlap = estimate.ChunkSize / 2
+	}
+
+	trimmed := strings.TrimSpace(content)
+	estimate.Chars = utf8.RuneCountInString(trimmed)
+	estimate.Bytes = len(trimmed)
+	estimate.ApproxTokens = estimateKnowledgeTokens(trimmed)
+	estimate.ApproxChunks = estimateKnowledgeChunks(estimate.Chars, estimate.ChunkSize, estimate.ChunkOverlap)
+
+	if estimate.ApproxTokens > estimate.TokenThreshold {
+		estimate.ExceedsLimit = true
+		estimate.Warning = fmt.Sprintf("内容约 %d tokens，超过建议上限 %d，同步可能失败或耗时较长，建议拆分为多个文档", estimate.ApproxTokens, estimate.TokenThreshold)
+	}
+	return estimate
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import "testing"
+
+func TestEstimateKnowledgeTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{name: "empty", content: "", want: 0},
+		{name: "chinese", content: "你好世界", want: 4},
+		{name: "ascii rounds up", content: "hello", want: 2},
+		{name: "mixed ignores spaces", content: "小智 test", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateKnowledgeTokens(tt.content); got != tt.want {
+				t.Fatalf("estimateKnowledgeTokens(%q) = %d, want %d", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateKnowledgeChunks(t *testing.T) {
+	tests := []struct {
+		name                 string
+		chars, size, overlap int
+		want                 int
+	}{
+		{name: "empty", chars: 0, size: 1000, overlap: 200, want: 0},
+		{name: "single chunk", chars: 800, size: 1000, overlap: 200, want: 1},
+		{name: "exact size", chars: 1000, size: 1000, overlap: 200, want: 1},
+		{name: "with overlap", chars: 2600, size: 1000, overlap: 200, want: 3},
+		{name: "invalid overlap ignored", chars: 2500, size: 1000, overlap: 1000, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateKnowledgeChunks(tt.chars, tt.size, tt.overlap); got != tt.want {
+				t.Fatalf("estimateKnowledgeChunks(%d, %d, %d) = %d, want %d", tt.chars, tt.size, tt.overlap, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetKnowledgeBasesListMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.KnowledgeBase{}, &models.KnowledgeBaseDocument{})
+	content := strings.Repeat("知", knowledgeBaseExcerptRunes+50)
+	if err := db.Create(&models.KnowledgeBase{UserID: 1, Name: "kb", Content: content}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	uc := &UserController{DB: db}
+	get := func(query string) []map[string]interface{} {
+		r := gin.New()
+		r.GET("/kbs", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			uc.GetKnowledgeBases(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/kbs"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Data) != 1 {
+			t.Fatalf("items = %d", len(resp.Data))
+		}
+		return resp.Data
+	}
+
+	full := get("")[0]
+	if full["content"] != content {
+		t.Fatal("default mode should return full content")
+	}
+	if _, ok := full["content_excerpt"]; ok {
+		t.Fatal("default mode should not include excerpt")
+	}
+
+	item := get("?mode=list")[0]
+	if item["content"] != "" {
+		t.Fatal("list mode should omit full content")
+	}
+	excerpt, _ := item["content_excerpt"].(string)
+	if len([]rune(excerpt)) != knowledgeBaseExcerptRunes {
+		t.Fatalf("excerpt runes = %d", len([]rune(excerpt)))
+	}
+	if item["content_length"] != float64(knowledgeBaseExcerptRunes+50) {
+		t.Fatalf("content_length = %v", item["content_length"])
+	}
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const knowledgeMergeMaxSources = 20
+
+// errKnowledgeMergeInvalid 合并参数校验失败（返回 400）
+var errKnowledgeMergeInvalid = errors.New("合并参数无效")
+
+type knowledgeMergeResult struct {
+	Target         models.KnowledgeBase `json:"target"`
+	MergedSources  []uint               `json:"merged_sources"`
+	CopiedDocs     int                  `json:"copied_documents"`
+	DeletedSources []uint               `json:"deleted_sources"`
+	RelinkedAgents []uint               `json:"relinked_agents"`
+}
+
+// knowledgeMergeEnqueuer 合并落库后投递目标知识库及复制文档的同步任务
+type knowledgeMergeEnqueuer func(db *gorm.DB, kbID uint, docIDs []uint) error
+
+// enqueueKnowledgeMergeSync 将目标知识库及复制文档的同步任务作为一个批次投递，部分入队失败时已入队的任务被取消，
+// 避免回滚后仍按回滚前的数据同步
+func enqueueKnowledgeMergeSync(db *gorm.DB, kbID uint, docIDs []uint) error {
+	ensureKnowledgeSyncWorkersStarted()
+	return enqueueKnowledgeSyncBatch(knowledgeSyncQueue, knowledgeMergeSyncJobs(db, kbID, docIDs))
+}
+
+// knowledgeMergeSyncJobs 合并后需要执行的同步任务：目标知识库正文及每个复制文档
+func knowledgeMergeSyncJobs(db *gorm.DB, kbID uint, docIDs []uint) []knowledgeSyncJob {
+	jobs := make([]knowledgeSyncJob, 0, len(docIDs)+1)
+	jobs = append(jobs, knowledgeSyncJob{jobType: knowledgeSyncJobUpsert, db: db, knowledgeBaseID: kbID})
+	for _, docID := range docIDs {
+		jobs = append(jobs, knowledgeSyncJob{jobType: knowledgeSyncJobDocUpsert, db: db, knowledgeBaseID: kbID, documentID: docID})
+	}
+	return jobs
+}
+
+// buildMergedKnowledgeContent 将源知识库正文按来源标题依次追加到目标正文之后
+func buildMergedKnowledgeContent(target string, sources []models.KnowledgeBase) string {
+	parts := make([]string, 0, len(sources)+1)
+	if strings.TrimSpace(target) != "" {
+		parts = append(parts, strings.TrimRight(target, "\n"))
+	}
+	for _, src := range sources {
+		if strings.TrimSpace(src.Content) == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("## 来源：%s\n\n%s", src.Name, strings.TrimSpace(src.Content)))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// mergeKnowledgeBases 将源知识库的正文与文档合并到目标知识库并重新同步；
+// 同步任务入队失败时回滚目标知识库，源知识库保持不变。deleteSources 为 true 时软删除源知识库，
+// 外部 provider 数据由清理任务在保留期后删除，源知识库关联的智能体改为关联目标知识库
+func mergeKnowledgeBases(db *gorm.DB, userID, targetID uint, sourceIDs []uint, deleteSources bool, enqueue knowledgeMergeEnqueuer) (*knowledgeMergeResult, error) {
+	sourceIDs = uniqueUintSlice(sourceIDs)
+	if len(sourceIDs) == 0 {
+		return nil, fmt.Errorf("%w: 请选择要合并的源知识库", errKnowledgeMergeInvalid)
+	}
+	if len(sourceIDs) > knowledgeMergeMaxSources {
+		return nil, fmt.Errorf("%w: 一次最多合并%d个知识库", errKnowledgeMergeInvalid, knowledgeMergeMaxSources)
+	}
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return nil, fmt.Errorf("%w: 源知识库不能包含目标知识库", errKnowledgeMergeInvalid)
+		}
+	}
+
+	var target models.KnowledgeBase
+	if err := db.Where("id = ? AND user_id = ?", targetID, userID).First(&target).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: 目标知识库不存在", errKnowledgeMergeInvalid)
+		}
+		return nil, err
+	}
+	var sources []models.KnowledgeBase
+	if err := db.Where("id IN ? AND user_id = ?", sourceIDs, userID).Order("id ASC").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	if len(sources) != len(sourceIDs) {
+		return nil, fmt.Errorf("%w: 部分源知识库不存在", errKnowledgeMergeInvalid)
+	}
+
+	original := target
+	copiedDocIDs := make([]uint, 0)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var docs []models.KnowledgeBaseDocument
+		if err := tx.Where("knowledge_base_id IN ?", sourceIDs).Order("knowledge_base_id ASC, id ASC").Find(&docs).Error; err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			copied := models.KnowledgeBaseDocument{
+				KnowledgeBaseID: target.ID,
+				Name:            doc.Name,
+				Content:         doc.Content,
+				MetadataJSON:    doc.MetadataJSON,
+				SyncStatus:      knowledgeSyncStatusPending,
+			}
+			if err := tx.Create(&copied).Error; err != nil {
+				return err
+			}
+			copiedDocIDs = append(copiedDocIDs, copied.ID)
+		}
+		return tx.Model(&models.KnowledgeBase{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+			"content":     buildMergedKnowledgeContent(target.Content, sources),
+			"sync_status": knowledgeSyncStatusPending,
+			"sync_error":  "",
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("合并知识库失败: %v", err)
+	}
+
+	if err := enqueue(db, target.ID, copiedDocIDs); err != nil {
+		// 回滚：恢复目标知识库正文与同步状态，删除复制的文档
+		rollbackErr := db.Transaction(func(tx *gorm.DB) error {
+			if len(copiedDocIDs) > 0 {
+				if err := tx.Where("id IN ?", copiedDocIDs).Delete(&models.KnowledgeBaseDocument{}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&models.KnowledgeBase{}).Where("id = ?", original.ID).Updates(map[string]interface{}{
+				"content":     original.Content,
+				"sync_status": original.SyncStatus,
+				"sync_error":  original.SyncError,
+			}).Error
+		})
+		if rollbackErr != nil {
+			logger.Errorf("[KnowledgeMerge] rollback failed target_id=%d err=%v", target.ID, rollbackErr)
+		}
+		return nil, fmt.Errorf("同步任务入队失败，已回滚合并: %v", err)
+	}
+
+	result := &knowledgeMergeResult{
+		MergedSources:  sourceIDs,
+		CopiedDocs:     len(copiedDocIDs),
+		DeletedSources: make([]uint, 0),
+		RelinkedAgents: make([]uint, 0),
+	}
+	if deleteSources {
+		for i := range sources {
+			src := &sources[i]
+			var links []models.AgentKnowledgeBase
+			if err := db.Where("knowledge_base_id = ?", src.ID).Find(&links).Error; err != nil {
+				logger.Warnf("[KnowledgeMerge] query source links failed kb_id=%d err=%v", src.ID, err)
+				continue
+			}
+			for _, link := range links {
+				var count int64
+				db.Model(&models.AgentKnowledgeBase{}).Where("agent_id = ? AND knowledge_base_id = ?", link.AgentID, target.ID).Count(&count)
+				if count == 0 {
+					if err := db.Create(&models.AgentKnowledgeBase{AgentID: link.AgentID, KnowledgeBaseID: target.ID}).Error; err != nil {
+						logger.Warnf("[KnowledgeMerge] relink agent failed agent_id=%d target_id=%d err=%v", link.AgentID, target.ID, err)
+						continue
+					}
+				}
+				result.RelinkedAgents = append(result.RelinkedAgents, link.AgentID)
+			}
+			if err := softDeleteKnowledgeBase(db, src); err != nil {
+				logger.Warnf("[KnowledgeMerge] delete source failed kb_id=%d err=%v", src.ID, err)
+				continue
+			}
+			result.DeletedSources = append(result.DeletedSources, src.ID)
+		}
+		result.RelinkedAgents = uniqueUintSlice(result.RelinkedAgents)
+	}
+
+	if err := db.Where("id = ?", target.ID).First(&result.Target).Error; err != nil {
+		return nil, err
+	}
+	logger.Infof("[KnowledgeMerge] merged target_id=%d sources=%v docs=%d deleted=%v", target.ID, sourceIDs, len(copiedDocIDs), result.DeletedSources)
+	return result, nil
+}
+
+// MergeKnowledgeBases 将多个知识库合并到目标知识库
+// POST /api/user/knowledge-bases/:id/merge {"source_ids": [2, 3], "delete_sources": true}
+func (uc *UserController) MergeKnowledgeBases(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	targetID, _ := strconv.Atoi(c.Param("id"))
+	if targetID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的知识库ID"})
+		return
+	}
+	var req struct {
+		SourceIDs     []uint `json:"source_ids" binding:"required"`
+		DeleteSources bool   `json:"delete_sources"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	result, err := mergeKnowledgeBases(uc.DB, userID.(uint), uint(targetID), req.SourceIDs, req.DeleteSources, enqueueKnowledgeMergeSync)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errKnowledgeMergeInvalid) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	message := "合并成功，目标知识库正在同步"
+	if len(result.DeletedSources) > 0 {
+		message += "，源知识库已删除并可在保留期内恢复"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": result})
+}
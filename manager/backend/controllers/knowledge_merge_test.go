@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+func TestMergeKnowledgeBases(t *testing.T) {
+	newDB := func(t *testing.T) (*gorm.DB, []models.KnowledgeBase) {
+		db := newTestDB(t, &models.KnowledgeBase{}, &models.KnowledgeBaseDocument{}, &models.AgentKnowledgeBase{}, &models.Agent{})
+		kbs := []models.KnowledgeBase{
+			{UserID: 1, Name: "目标", Content: "目标正文", SyncStatus: knowledgeSyncStatusSynced},
+			{UserID: 1, Name: "产品", Content: "产品正文"},
+			{UserID: 1, Name: "售后", Content: ""},
+			{UserID: 2, Name: "他人", Content: "他人正文"},
+		}
+		for i := range kbs {
+			if err := db.Create(&kbs[i]).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.Create(&models.KnowledgeBaseDocument{KnowledgeBaseID: kbs[2].ID, Name: "退货政策", Content: "七天无理由", MetadataJSON: `{"lang":"zh"}`}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&models.AgentKnowledgeBase{AgentID: 5, KnowledgeBaseID: kbs[1].ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return db, kbs
+	}
+
+	t.Run("merge and delete sources", func(t *testing.T) {
+		db, kbs := newDB(t)
+		var enqueuedKB uint
+		var enqueuedDocs []uint
+		enqueue := func(_ *gorm.DB, kbID uint, docIDs []uint) error {
+			enqueuedKB, enqueuedDocs = kbID, docIDs
+			return nil
+		}
+		result, err := mergeKnowledgeBases(db, 1, kbs[0].ID, []uint{kbs[1].ID, kbs[2].ID, kbs[1].ID}, true, enqueue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Target.Content != "目标正文\n\n## 来源：产品\n\n产品正文" || result.Target.SyncStatus != knowledgeSyncStatusPending {
+			t.Fatalf("target = %q %s", result.Target.Content, result.Target.SyncStatus)
+		}
+		if enqueuedKB != kbs[0].ID || len(enqueuedDocs) != 1 || result.CopiedDocs != 1 {
+			t.Fatalf("enqueued kb=%d docs=%v result=%+v", enqueuedKB, enqueuedDocs, result)
+		}
+		var doc models.KnowledgeBaseDocument
+		if err := db.First(&doc, enqueuedDocs[0]).Error; err != nil || doc.KnowledgeBaseID != kbs[0].ID || doc.MetadataJSON != `{"lang":"zh"}` {
+			t.Fatalf("copied doc = %+v err=%v", doc, err)
+		}
+		if len(result.DeletedSources) != 2 || len(result.RelinkedAgents) != 1 || result.RelinkedAgents[0] != 5 {
+			t.Fatalf("result = %+v", result)
+		}
+		var remaining int64
+		db.Model(&models.KnowledgeBase{}).Where("id IN ?", []uint{kbs[1].ID, kbs[2].ID}).Count(&remaining)
+		if remaining != 0 {
+			t.Fatal("sources should be soft deleted")
+		}
+		var links int64
+		db.Model(&models.AgentKnowledgeBase{}).Where("agent_id = ? AND knowledge_base_id = ?", 5, kbs[0].ID).Count(&links)
+		if links != 1 {
+			t.Fatal("agent should be relinked to target")
+		}
+	})
+
+	t.Run("rollback on enqueue failure", func(t *testing.T) {
+		db, kbs := newDB(t)
+		enqueue := func(*gorm.DB, uint, []uint) error { return errors.New("队列已满") }
+		if _, err := mergeKnowledgeBases(db, 1, kbs[0].ID, []uint{kbs[1].ID, kbs[2].ID}, true, enqueue); err == nil || !strings.Contains(err.Error(), "已回滚") {
+			t.Fatalf("err = %v", err)
+		}
+		var target models.KnowledgeBase
+		db.First(&target, kbs[0].ID)
+		if target.Content != "目标正文" || target.SyncStatus != knowledgeSyncStatusSynced {
+			t.Fatalf("target not restored: %+v", target)
+		}
+		var docs, sources int64
+		db.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ?", kbs[0].ID).Count(&docs)
+		db.Model(&models.KnowledgeBase{}).Where("id IN ?", []uint{kbs[1].ID, kbs[2].ID}).Count(&sources)
+		if docs != 0 || sources != 2 {
+			t.Fatalf("docs=%d sources=%d after rollback", docs, sources)
+		}
+	})
+
+	t.Run("enqueue fails halfway through documents", func(t *testing.T) {
+		db, kbs := newDB(t)
+		for _, name := range []string{"保修", "发票"} {
+			if err := db.Create(&models.KnowledgeBaseDocument{KnowledgeBaseID: kbs[1].ID, Name: name, Content: name}).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		// 队列只容纳知识库任务与第一个文档任务，第二个文档入队失败
+		queue := make(chan knowledgeSyncJob, 2)
+		enqueue := func(db *gorm.DB, kbID uint, docIDs []uint) error {
+			return enqueueKnowledgeSyncBatch(queue, knowledgeMergeSyncJobs(db, kbID, docIDs))
+		}
+		if _, err := mergeKnowledgeBases(db, 1, kbs[0].ID, []uint{kbs[1].ID, kbs[2].ID}, false, enqueue); err == nil || !strings.Contains(err.Error(), "已回滚") {
+			t.Fatalf("err = %v", err)
+		}
+		close(queue)
+		queued := 0
+		for job := range queue {
+			queued++
+			if !job.batch.isCanceled() {
+				t.Fatalf("queued job not canceled after rollback: type=%s doc_id=%d", job.jobType, job.documentID)
+			}
+		}
+		if queued != 2 {
+			t.Fatalf("queued = %d, want 2", queued)
+		}
+		var docs int64
+		db.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ?", kbs[0].ID).Count(&docs)
+		if docs != 0 {
+			t.Fatalf("copied docs remain after rollback: %d", docs)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		db, kbs := newDB(t)
+		noop := func(*gorm.DB, uint, []uint) error { return nil }
+		for name, ids := range map[string][]uint{
+			"empty":      {},
+			"self":       {kbs[0].ID},
+			"other user": {kbs[3].ID},
+			"missing":    {999},
+		} {
+			if _, err := mergeKnowledgeBases(db, 1, kbs[0].ID, ids, false, noop); !errors.Is(err, errKnowledgeMergeInvalid) {
+				t.Fatalf("%s: err = %v", name, err)
+			}
+		}
+	})
+}
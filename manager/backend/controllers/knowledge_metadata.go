@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 文档元数据：以 JSON 文本保存在 KnowledgeBaseDocument.MetadataJSON，同步时传给支持元数据过滤的 provider
+
+const (
+	knowledgeDocumentMetadataMaxKeys     = 20
+	knowledgeDocumentMetadataMaxValueLen = 512
+)
+
+// 与 Dify 元数据字段命名规则一致：小写字母开头，仅含小写字母、数字、下划线
+var knowledgeDocumentMetadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// normalizeKnowledgeDocumentMetadata 校验元数据并序列化；值仅支持字符串与数字，空 map 返回空字符串
+func normalizeKnowledgeDocumentMetadata(metadata map[string]interface{}) (string, error) {
+	if len(metadata) == 0 {
+		return "", nil
+	}
+	if len(metadata) > knowledgeDocumentMetadataMaxKeys {
+		return "", fmt.Errorf("元数据字段不能超过%d个", knowledgeDocumentMetadataMaxKeys)
+	}
+	normalized := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		if !knowledgeDocumentMetadataKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("元数据字段名 %q 无效：需以小写字母开头，仅含小写字母、数字和下划线，最长64个字符", key)
+		}
+		switch v := value.(type) {
+		case string:
+			v = strings.TrimSpace(v)
+			if len([]rune(v)) > knowledgeDocumentMetadataMaxValueLen {
+				return "", fmt.Errorf("元数据字段 %s 的值不能超过%d个字符", key, knowledgeDocumentMetadataMaxValueLen)
+			}
+			normalized[key] = v
+		case float64:
+			normalized[key] = v
+		default:
+			return "", fmt.Errorf("元数据字段 %s 的值仅支持字符串或数字", key)
+		}
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return "", fmt.Errorf("序列化元数据失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeKnowledgeDocumentMetadata 解析已保存的元数据，无效时返回 nil
+func decodeKnowledgeDocumentMetadata(raw string) map[string]interface{} {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &metadata); err != nil || len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// parseKnowledgeDocumentMetadataForm 解析上传表单中的 metadata 字段（JSON 对象字符串）
+func parseKnowledgeDocumentMetadataForm(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+		return "", fmt.Errorf("metadata 必须是 JSON 对象")
+	}
+	return normalizeKnowledgeDocumentMetadata(metadata)
+}
+
+// knowledgeProviderSupportsDocumentMetadata provider 是否支持文档元数据
+func knowledgeProviderSupportsDocumentMetadata(provider string) bool {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "dify", "weknora":
+		return true
+	}
+	return false
+}
+
+type difyMetadataField struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// applyDifyDocumentMetadata 为 Dify 文档写入元数据，dataset 中缺少的字段先按值类型创建
+func applyDifyDocumentMetadata(client *http.Client, cfg *difyKnowledgeSyncConfig, datasetID, documentID string, metadata map[string]interface{}) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	metadataPath := fmt.Sprintf("/datasets/%s/metadata", url.PathEscape(datasetID))
+
+	var listResp struct {
+		DocMetadata []difyMetadataField `json:"doc_metadata"`
+	}
+	if _, _, err := doDifyJSONRequest(client, http.MethodGet, buildDifyURL(cfg.BaseURL, metadataPath), cfg.APIKey, nil, &listResp); err != nil {
+		return fmt.Errorf("获取Dify元数据字段失败(dataset_id=%s): %w", datasetID, err)
+	}
+	fieldIDs := make(map[string]string, len(listResp.DocMetadata))
+	for _, field := range listResp.DocMetadata {
+		fieldIDs[field.Name] = field.ID
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	metadataList := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		value := metadata[key]
+		fieldID, ok := fieldIDs[key]
+		if !ok {
+			fieldType := "string"
+			if _, isNumber := value.(float64); isNumber {
+				fieldType = "number"
+			}
+			var created difyMetadataField
+			if _, _, err := doDifyJSONRequest(client, http.MethodPost, buildDifyURL(cfg.BaseURL, metadataPath), cfg.APIKey, map[string]interface{}{
+				"type": fieldType,
+				"name": key,
+			}, &created); err != nil {
+				return fmt.Errorf("创建Dify元数据字段失败(dataset_id=%s name=%s): %w", datasetID, key, err)
+			}
+			if strings.TrimSpace(created.ID) == "" {
+				return fmt.Errorf("创建Dify元数据字段失败(dataset_id=%s name=%s): 返回缺少id", datasetID, key)
+			}
+			fieldID = created.ID
+		}
+		metadataList = append(metadataList, map[string]interface{}{"id": fieldID, "name": key, "value": value})
+	}
+
+	payload := map[string]interface{}{
+		"operation_data": []map[string]interface{}{{
+			"document_id":   documentID,
+			"metadata_list": metadataList,
+		}},
+	}
+	path := fmt.Sprintf("/datasets/%s/documents/metadata", url.PathEscape(datasetID))
+	if _, _, err := doDifyJSONRequest(client, http.MethodPost, buildDifyURL(cfg.BaseURL, path), cfg.APIKey, payload, nil); err != nil {
+		return fmt.Errorf("写入Dify文档元数据失败(document_id=%s): %w", documentID, err)
+	}
+	return nil
+}
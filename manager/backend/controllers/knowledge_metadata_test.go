@@ -0,0 +1,36 @@
+package controllers
+
+import "testing"
+
+func TestNormalizeKnowledgeDocumentMetadata(t *testing.T) {
+	got, err := normalizeKnowledgeDocumentMetadata(map[string]interface{}{"category": " faq ", "year": float64(2024)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != `{"category":"faq","year":2024}` {
+		t.Fatalf("normalized = %s", got)
+	}
+	if got, err := normalizeKnowledgeDocumentMetadata(nil); err != nil || got != "" {
+		t.Fatalf("empty metadata = %q, %v", got, err)
+	}
+
+	invalid := []map[string]interface{}{
+		{"Category": "faq"},
+		{"1st": "x"},
+		{"tags": []interface{}{"a"}},
+		{"nested": map[string]interface{}{"a": 1}},
+		{"flag": true},
+	}
+	for _, metadata := range invalid {
+		if _, err := normalizeKnowledgeDocumentMetadata(metadata); err == nil {
+			t.Fatalf("expected error for %v", metadata)
+		}
+	}
+
+	if _, err := parseKnowledgeDocumentMetadataForm(`["a"]`); err == nil {
+		t.Fatal("form metadata must be a JSON object")
+	}
+	if got := decodeKnowledgeDocumentMetadata(`{"category":"faq"}`); got["category"] != "faq" {
+		t.Fatalf("decoded = %v", got)
+	}
+}
@@ -0,0 +1,417 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 外部 provider 孤儿数据集清理：删除失败等原因会在 provider 上残留系统自动创建的数据集。
+// 仅识别 buildAutoDatasetName 生成的 kb-<id>- 前缀数据集，与本地 external_kb_id 对比后报告未被引用的数据集；
+// 定时任务只报告，删除需管理员确认。
+
+const (
+	knowledgeOrphanScanInterval = 24 * time.Hour
+	knowledgeOrphanListPageSize = 100
+	// knowledgeOrphanListMaxPages 单个 provider 最多翻页数，防止异常分页导致死循环
+	knowledgeOrphanListMaxPages = 50
+	knowledgeOrphanHTTPTimeout  = 20 * time.Second
+)
+
+const (
+	knowledgeOrphanReasonMissing       = "knowledge_base_missing"
+	knowledgeOrphanReasonNotReferenced = "not_referenced"
+)
+
+var autoDatasetNamePattern = regexp.MustCompile(`^kb-(\d+)-`)
+
+var (
+	knowledgeOrphanScanOnce sync.Once
+	knowledgeOrphanLastMu   sync.Mutex
+	knowledgeOrphanLast     *knowledgeOrphanReport
+)
+
+type knowledgeProviderDataset struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type knowledgeOrphanDataset struct {
+	Provider        string `json:"provider"`
+	DatasetID       string `json:"dataset_id"`
+	Name            string `json:"name"`
+	KnowledgeBaseID uint   `json:"knowledge_base_id"`
+	Reason          string `json:"reason"`
+}
+
+type knowledgeOrphanProviderError struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error"`
+}
+
+type knowledgeOrphanReport struct {
+	ScannedAt time.Time                      `json:"scanned_at"`
+	Scanned   int                            `json:"scanned_datasets"`
+	Orphans   []knowledgeOrphanDataset       `json:"orphans"`
+	Errors    []knowledgeOrphanProviderError `json:"errors"`
+}
+
+// StartKnowledgeOrphanScanWorker 启动孤儿数据集定时扫描任务（仅报告，不删除；仅启动一次）
+func StartKnowledgeOrphanScanWorker(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	knowledgeOrphanScanOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(knowledgeOrphanScanInterval)
+			defer ticker.Stop()
+			for {
+				report := scanKnowledgeOrphanDatasets(db)
+				for _, orphan := range report.Orphans {
+					logger.Infof("[KnowledgeOrphan] orphan dataset provider=%s dataset_id=%s name=%s kb_id=%d reason=%s",
+						orphan.Provider, orphan.DatasetID, orphan.Name, orphan.KnowledgeBaseID, orphan.Reason)
+				}
+				logger.Infof("[KnowledgeOrphan] scan finished scanned=%d orphans=%d errors=%d", report.Scanned, len(report.Orphans), len(report.Errors))
+				<-ticker.C
+			}
+		}()
+		logger.Infof("[KnowledgeOrphan] worker started interval=%s", knowledgeOrphanScanInterval)
+	})
+}
+
+// autoDatasetKnowledgeBaseID 从自动创建的数据集名称中解析本地知识库ID，非系统命名返回 false
+func autoDatasetKnowledgeBaseID(name string) (uint, bool) {
+	m := autoDatasetNamePattern.FindStringSubmatch(strings.TrimSpace(name))
+	if len(m) != 2 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// findKnowledgeOrphanDatasets 对比本地知识库（含保留期内的软删除记录），返回未被 external_kb_id 引用的自动数据集。
+// 知识库仍在首次同步中（尚未写回 external_kb_id）时不视为孤儿，避免误删正在创建的数据集
+func findKnowledgeOrphanDatasets(db *gorm.DB, provider string, datasets []knowledgeProviderDataset) ([]knowledgeOrphanDataset, error) {
+	var referenced []string
+	if err := db.Unscoped().Model(&models.KnowledgeBase{}).Where("external_kb_id <> ''").Pluck("external_kb_id", &referenced).Error; err != nil {
+		return nil, err
+	}
+	referencedSet := make(map[string]bool, len(referenced))
+	for _, id := range referenced {
+		referencedSet[strings.TrimSpace(id)] = true
+	}
+
+	orphans := make([]knowledgeOrphanDataset, 0)
+	for _, ds := range datasets {
+		datasetID := strings.TrimSpace(ds.ID)
+		kbID, ok := autoDatasetKnowledgeBaseID(ds.Name)
+		if datasetID == "" || !ok || referencedSet[datasetID] {
+			continue
+		}
+		orphan := knowledgeOrphanDataset{
+			Provider:        provider,
+			DatasetID:       datasetID,
+			Name:            ds.Name,
+			KnowledgeBaseID: kbID,
+			Reason:          knowledgeOrphanReasonNotReferenced,
+		}
+		var kb models.KnowledgeBase
+		err := db.Unscoped().Where("id = ?", kbID).First(&kb).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			orphan.Reason = knowledgeOrphanReasonMissing
+		case err != nil:
+			return nil, err
+		case strings.TrimSpace(kb.ExternalKBID) == "" && isKnowledgeSyncInProgress(kb.SyncStatus):
+			continue
+		}
+		orphans = append(orphans, orphan)
+	}
+	return orphans, nil
+}
+
+func isKnowledgeSyncInProgress(status string) bool {
+	switch status {
+	case knowledgeSyncStatusPending, knowledgeSyncStatusUploading, knowledgeSyncStatusUploaded, knowledgeSyncStatusParsing:
+		return true
+	}
+	return false
+}
+
+// parseProviderDatasetList 兼容 data 为数组或 data.list/data.items 的列表响应
+func parseProviderDatasetList(body []byte) ([]knowledgeProviderDataset, error) {
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析数据集列表失败: %w", err)
+	}
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil, nil
+	}
+	var items []knowledgeProviderDataset
+	if err := json.Unmarshal(resp.Data, &items); err == nil {
+		return items, nil
+	}
+	var wrapped struct {
+		List  []knowledgeProviderDataset `json:"list"`
+		Items []knowledgeProviderDataset `json:"items"`
+	}
+	if err := json.Unmarshal(resp.Data, &wrapped); err != nil {
+		return nil, fmt.Errorf("解析数据集列表失败: %w", err)
+	}
+	if len(wrapped.List) > 0 {
+		return wrapped.List, nil
+	}
+	return wrapped.Items, nil
+}
+
+// listKnowledgeProviderDatasets 分页列出 provider 上的全部数据集
+func listKnowledgeProviderDatasets(client *http.Client, provider string, providerData map[string]interface{}) ([]knowledgeProviderDataset, error) {
+	var fetch func(page int) ([]byte, error)
+	switch provider {
+	case "dify":
+		cfg, err := parseDifyKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, err
+		}
+		fetch = func(page int) ([]byte, error) {
+			path := fmt.Sprintf("/datasets?page=%d&limit=%d", page, knowledgeOrphanListPageSize)
+			_, body, err := doDifyJSONRequest(client, http.MethodGet, buildDifyURL(cfg.BaseURL, path), cfg.APIKey, nil, nil)
+			return body, err
+		}
+	case "ragflow":
+		cfg, err := parseRagflowKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, err
+		}
+		fetch = func(page int) ([]byte, error) {
+			path := fmt.Sprintf("/datasets?page=%d&page_size=%d", page, knowledgeOrphanListPageSize)
+			_, body, err := doRagflowJSONRequest(client, http.MethodGet, buildRagflowURL(cfg.BaseURL, path), cfg.APIKey, nil, nil)
+			return body, err
+		}
+	case "weknora":
+		cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, err
+		}
+		fetch = func(page int) ([]byte, error) {
+			path := fmt.Sprintf("/knowledge-bases?page=%d&page_size=%d", page, knowledgeOrphanListPageSize)
+			_, body, err := doWeknoraJSONRequest(client, http.MethodGet, buildWeknoraURL(cfg.BaseURL, path), cfg.APIKey, nil, nil)
+			return body, err
+		}
+	default:
+		return nil, fmt.Errorf("孤儿数据集扫描暂不支持provider: %s", provider)
+	}
+
+	all := make([]knowledgeProviderDataset, 0)
+	seen := make(map[string]bool)
+	for page := 1; page <= knowledgeOrphanListMaxPages; page++ {
+		body, err := fetch(page)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s数据集列表失败: %w", provider, err)
+		}
+		items, err := parseProviderDatasetList(body)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, item := range items {
+			if item.ID == "" || seen[item.ID] {
+				continue
+			}
+			seen[item.ID] = true
+			all = append(all, item)
+			added++
+		}
+		// 不支持分页的部署会重复返回同一页
+		if len(items) < knowledgeOrphanListPageSize || added == 0 {
+			break
+		}
+	}
+	return all, nil
+}
+
+// deleteKnowledgeProviderDataset 删除 provider 上的数据集
+func deleteKnowledgeProviderDataset(client *http.Client, provider string, providerData map[string]interface{}, datasetID string) error {
+	switch provider {
+	case "dify":
+		cfg, err := parseDifyKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return err
+		}
+		return deleteDifyDataset(client, cfg, datasetID)
+	case "ragflow":
+		cfg, err := parseRagflowKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return err
+		}
+		return deleteRagflowDataset(client, cfg, datasetID)
+	case "weknora":
+		cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return err
+		}
+		return deleteWeknoraKnowledgeBase(client, cfg, datasetID)
+	default:
+		return fmt.Errorf("孤儿数据集清理暂不支持provider: %s", provider)
+	}
+}
+
+func newKnowledgeOrphanHTTPClient(provider string) *http.Client {
+	return &http.Client{Timeout: knowledgeOrphanHTTPTimeout, Transport: knowledgeSyncTransport(provider)}
+}
+
+// loadEnabledKnowledgeProviders 按 provider 去重返回已启用的 knowledge_search 配置（默认配置优先）
+func loadEnabledKnowledgeProviders(db *gorm.DB) (map[string]map[string]interface{}, error) {
+	var configs []models.Config
+	if err := db.Where("type = ? AND enabled = ?", "knowledge_search", true).Order("is_default DESC, id DESC").Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	providers := make(map[string]map[string]interface{})
+	for i := range configs {
+		provider := strings.ToLower(strings.TrimSpace(configs[i].Provider))
+		if provider == "" {
+			continue
+		}
+		if _, exists := providers[provider]; exists {
+			continue
+		}
+		_, providerData, err := parseKnowledgeProviderConfigPayload(&configs[i])
+		if err != nil {
+			logger.Warnf("[KnowledgeOrphan] skip config id=%d err=%v", configs[i].ID, err)
+			continue
+		}
+		providers[provider] = providerData
+	}
+	return providers, nil
+}
+
+// scanKnowledgeOrphanDatasets 扫描全部已启用 provider 的孤儿数据集，单个 provider 失败不影响其他 provider
+func scanKnowledgeOrphanDatasets(db *gorm.DB) *knowledgeOrphanReport {
+	report := &knowledgeOrphanReport{
+		ScannedAt: time.Now(),
+		Orphans:   make([]knowledgeOrphanDataset, 0),
+		Errors:    make([]knowledgeOrphanProviderError, 0),
+	}
+	providers, err := loadEnabledKnowledgeProviders(db)
+	if err != nil {
+		report.Errors = append(report.Errors, knowledgeOrphanProviderError{Error: "加载知识库provider配置失败: " + err.Error()})
+		return report
+	}
+	names := make([]string, 0, len(providers))
+	for provider := range providers {
+		names = append(names, provider)
+	}
+	sort.Strings(names)
+	for _, provider := range names {
+		datasets, err := listKnowledgeProviderDatasets(newKnowledgeOrphanHTTPClient(provider), provider, providers[provider])
+		if err != nil {
+			report.Errors = append(report.Errors, knowledgeOrphanProviderError{Provider: provider, Error: err.Error()})
+			continue
+		}
+		report.Scanned += len(datasets)
+		orphans, err := findKnowledgeOrphanDatasets(db, provider, datasets)
+		if err != nil {
+			report.Errors = append(report.Errors, knowledgeOrphanProviderError{Provider: provider, Error: err.Error()})
+			continue
+		}
+		report.Orphans = append(report.Orphans, orphans...)
+	}
+
+	knowledgeOrphanLastMu.Lock()
+	knowledgeOrphanLast = report
+	knowledgeOrphanLastMu.Unlock()
+	return report
+}
+
+// GetKnowledgeOrphanDatasets 获取孤儿数据集报告；refresh=true 时立即重新扫描，否则返回最近一次定时扫描结果
+func (ac *AdminController) GetKnowledgeOrphanDatasets(c *gin.Context) {
+	knowledgeOrphanLastMu.Lock()
+	report := knowledgeOrphanLast
+	knowledgeOrphanLastMu.Unlock()
+	if report == nil || c.Query("refresh") == "true" {
+		report = scanKnowledgeOrphanDatasets(ac.DB)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// CleanupKnowledgeOrphanDatasets 删除孤儿数据集；未携带 confirm=true 时仅返回将被删除的数据集。
+// 删除前重新扫描，只删除本次扫描仍判定为孤儿的数据集；可通过 dataset_ids 限定范围
+func (ac *AdminController) CleanupKnowledgeOrphanDatasets(c *gin.Context) {
+	var req struct {
+		Confirm    bool     `json:"confirm"`
+		DatasetIDs []string `json:"dataset_ids"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+			return
+		}
+	}
+
+	report := scanKnowledgeOrphanDatasets(ac.DB)
+	targets := report.Orphans
+	if len(req.DatasetIDs) > 0 {
+		wanted := make(map[string]bool, len(req.DatasetIDs))
+		for _, id := range req.DatasetIDs {
+			wanted[strings.TrimSpace(id)] = true
+		}
+		targets = make([]knowledgeOrphanDataset, 0, len(req.DatasetIDs))
+		for _, orphan := range report.Orphans {
+			if wanted[orphan.DatasetID] {
+				targets = append(targets, orphan)
+			}
+		}
+	}
+
+	if !req.Confirm {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{
+			"orphans":          targets,
+			"errors":           report.Errors,
+			"requires_confirm": true,
+		}})
+		return
+	}
+
+	providers, err := loadEnabledKnowledgeProviders(ac.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "加载知识库provider配置失败"})
+		return
+	}
+	deleted := make([]knowledgeOrphanDataset, 0, len(targets))
+	failed := make([]gin.H, 0)
+	for _, orphan := range targets {
+		providerData, ok := providers[orphan.Provider]
+		if !ok {
+			failed = append(failed, gin.H{"dataset_id": orphan.DatasetID, "provider": orphan.Provider, "error": "provider配置已停用"})
+			continue
+		}
+		if err := deleteKnowledgeProviderDataset(newKnowledgeOrphanHTTPClient(orphan.Provider), orphan.Provider, providerData, orphan.DatasetID); err != nil {
+			failed = append(failed, gin.H{"dataset_id": orphan.DatasetID, "provider": orphan.Provider, "error": err.Error()})
+			continue
+		}
+		deleted = append(deleted, orphan)
+		logger.Infof("[KnowledgeOrphan] deleted dataset provider=%s dataset_id=%s name=%s reason=%s", orphan.Provider, orphan.DatasetID, orphan.Name, orphan.Reason)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "孤儿数据集清理完成", "data": gin.H{
+		"deleted": deleted,
+		"failed":  failed,
+		"errors":  report.Errors,
+	}})
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestFindKnowledgeOrphanDatasets(t *testing.T) {
+	db := newTestDB(t, &models.KnowledgeBase{})
+	kbs := []models.KnowledgeBase{
+		{UserID: 1, Name: "在用", ExternalKBID: "ds-live", SyncStatus: knowledgeSyncStatusSynced},
+		{UserID: 1, Name: "重建", ExternalKBID: "ds-new", SyncStatus: knowledgeSyncStatusSynced},
+		{UserID: 1, Name: "同步中", SyncStatus: knowledgeSyncStatusPending},
+		{UserID: 1, Name: "已删除", ExternalKBID: "ds-deleted", SyncStatus: knowledgeSyncStatusSynced},
+	}
+	for i := range kbs {
+		if err := db.Create(&kbs[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 保留期内的软删除知识库仍引用其数据集
+	if err := db.Delete(&kbs[3]).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	datasets := []knowledgeProviderDataset{
+		{ID: "ds-live", Name: "kb-1-在用"},
+		{ID: "ds-old", Name: "kb-2-重建"},
+		{ID: "ds-creating", Name: "kb-3-同步中"},
+		{ID: "ds-deleted", Name: "kb-4-已删除"},
+		{ID: "ds-gone", Name: "kb-99-已清理"},
+		{ID: "ds-manual", Name: "手动创建的数据集"},
+	}
+	orphans, err := findKnowledgeOrphanDatasets(db, "dify", datasets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 2 {
+		t.Fatalf("orphans = %+v, want 2", orphans)
+	}
+	if orphans[0].DatasetID != "ds-old" || orphans[0].Reason != knowledgeOrphanReasonNotReferenced || orphans[0].KnowledgeBaseID != 2 {
+		t.Fatalf("unexpected orphan[0]: %+v", orphans[0])
+	}
+	if orphans[1].DatasetID != "ds-gone" || orphans[1].Reason != knowledgeOrphanReasonMissing || orphans[1].Provider != "dify" {
+		t.Fatalf("unexpected orphan[1]: %+v", orphans[1])
+	}
+}
+
+func TestListKnowledgeProviderDatasetsPaginates(t *testing.T) {
+	pages := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		if r.URL.Path != "/v1/datasets" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			w.Write([]byte(`{"data":[` + fakeDatasetItems(knowledgeOrphanListPageSize) + `],"has_more":true}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"last","name":"kb-7-x"}],"has_more":false}`))
+	}))
+	defer srv.Close()
+
+	datasets, err := listKnowledgeProviderDatasets(srv.Client(), "dify", map[string]interface{}{"base_url": srv.URL, "api_key": "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 2 || len(datasets) != knowledgeOrphanListPageSize+1 {
+		t.Fatalf("pages=%d datasets=%d", pages, len(datasets))
+	}
+
+	items, err := parseProviderDatasetList([]byte(`{"data":{"list":[{"id":"a","name":"kb-1-a"}]}}`))
+	if err != nil || len(items) != 1 || items[0].ID != "a" {
+		t.Fatalf("wrapped list parse = %+v, %v", items, err)
+	}
+}
+
+func fakeDatasetItems(n int) string {
+	out := ""
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			out += ","
+		}
+		out += fmt.Sprintf(`{"id":"ds-%d","name":"kb-1-x"}`, i)
+	}
+	return out
+}
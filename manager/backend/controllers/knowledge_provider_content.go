@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	knowledgeProviderContentPageSize = 100
+	knowledgeProviderContentMaxPages = 20
+)
+
+// errKnowledgeContentRetrievalUnsupported provider 不支持回读文档内容
+var errKnowledgeContentRetrievalUnsupported = errors.New("当前 provider 不支持回读文档内容")
+
+// knowledgeProviderChunk provider 侧存储的单个分段
+type knowledgeProviderChunk struct {
+	Position int    `json:"position"`
+	Content  string `json:"content"`
+}
+
+// knowledgeProviderDocumentContent 从 provider 回读的文档内容
+type knowledgeProviderDocumentContent struct {
+	Provider   string                   `json:"provider"`
+	DatasetID  string                   `json:"dataset_id"`
+	DocumentID string                   `json:"document_id"`
+	Chunks     []knowledgeProviderChunk `json:"chunks"`
+	Total      int                      `json:"total"`     // provider 报告的分段总数，未报告时为已读取数
+	Truncated  bool                     `json:"truncated"` // 超过读取上限，仅返回前若干页
+}
+
+// knowledgeProviderChunkPage 单页分段及 provider 报告的总数
+type knowledgeProviderChunkPage struct {
+	Chunks []knowledgeProviderChunk
+	Total  int
+}
+
+// parseKnowledgeProviderChunkItems 解析分段数组，兼容 content/text 字段及 position/chunk_index 序号
+func parseKnowledgeProviderChunkItems(items []map[string]interface{}, offset int) []knowledgeProviderChunk {
+	chunks := make([]knowledgeProviderChunk, 0, len(items))
+	for i, item := range items {
+		content, _ := item["content"].(string)
+		if content == "" {
+			content, _ = item["text"].(string)
+		}
+		position := offset + i + 1
+		if v, ok := parseInt(item["position"]); ok && v > 0 {
+			position = v
+		} else if v, ok := parseInt(item["chunk_index"]); ok && v >= 0 {
+			position = v + 1
+		}
+		chunks = append(chunks, knowledgeProviderChunk{Position: position, Content: content})
+	}
+	return chunks
+}
+
+// fetchKnowledgeProviderDocumentContent 分页读取 provider 中指定文档的全部分段
+func fetchKnowledgeProviderDocumentContent(kb *models.KnowledgeBase, provider string, providerData map[string]interface{}, documentID string) (*knowledgeProviderDocumentContent, error) {
+	datasetID := strings.TrimSpace(kb.ExternalKBID)
+	if datasetID == "" {
+		return nil, fmt.Errorf("知识库尚未同步到 provider")
+	}
+	var fetchPage func(page int) (*knowledgeProviderChunkPage, error)
+	switch provider {
+	case "dify":
+		cfg, err := parseDifyKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, err
+		}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "dify", difyHTTPTimeout)
+		fetchPage = func(page int) (*knowledgeProviderChunkPage, error) {
+			path := fmt.Sprintf("/datasets/%s/documents/%s/segments?page=%d&limit=%d",
+				url.PathEscape(datasetID), url.PathEscape(documentID), page, knowledgeProviderContentPageSize)
+			var resp struct {
+				Data  []map[string]interface{} `json:"data"`
+				Total int                      `json:"total"`
+			}
+			if _, _, err := doDifyJSONRequest(client, http.MethodGet, buildDifyURL(cfg.BaseURL, path), cfg.APIKey, nil, &resp); err != nil {
+				return nil, fmt.Errorf("获取Dify文档分段失败(dataset_id=%s, document_id=%s): %w", datasetID, documentID, err)
+			}
+			return &knowledgeProviderChunkPage{Chunks: parseKnowledgeProviderChunkItems(resp.Data, (page-1)*knowledgeProviderContentPageSize), Total: resp.Total}, nil
+		}
+	case "ragflow":
+		cfg, err := parseRagflowKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, err
+		}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "ragflow", 20*time.Second)
+		fetchPage = func(page int) (*knowledgeProviderChunkPage, error) {
+			path := fmt.Sprintf("/datasets/%s/documents/%s/chunks?page=%d&page_size=%d",
+				url.PathEscape(datasetID), url.PathEscape(documentID), page, knowledgeProviderContentPageSize)
+			var resp struct {
+				Data struct {
+					Chunks []map[string]interface{} `json:"chunks"`
+					Total  int                      `json:"total"`
+				} `json:"data"`
+			}
+			if _, _, err := doRagflowJSONRequest(client, http.MethodGet, buildRagflowURL(cfg.BaseURL, path), cfg.APIKey, nil, &resp); err != nil {
+				return nil, fmt.Errorf("获取RAGFlow文档分段失败(dataset_id=%s, document_id=%s): %w", datasetID, documentID, err)
+			}
+			return &knowledgeProviderChunkPage{Chunks: parseKnowledgeProviderChunkItems(resp.Data.Chunks, (page-1)*knowledgeProviderContentPageSize), Total: resp.Data.Total}, nil
+		}
+	case "weknora":
+		cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return nil, err
+		}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "weknora", weknoraHTTPTimeout)
+		fetchPage = func(page int) (*knowledgeProviderChunkPage, error) {
+			path := fmt.Sprintf("/chunks/%s?page=%d&page_size=%d", url.PathEscape(documentID), page, knowledgeProviderContentPageSize)
+			var resp struct {
+				Data  []map[string]interface{} `json:"data"`
+				Total int                      `json:"total"`
+			}
+			if _, _, err := doWeknoraJSONRequest(client, http.MethodGet, buildWeknoraURL(cfg.BaseURL, path), cfg.APIKey, nil, &resp); err != nil {
+				return nil, fmt.Errorf("获取WeKnora文档分段失败(knowledge_id=%s): %w", documentID, err)
+			}
+			return &knowledgeProviderChunkPage{Chunks: parseKnowledgeProviderChunkItems(resp.Data, (page-1)*knowledgeProviderContentPageSize), Total: resp.Total}, nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errKnowledgeContentRetrievalUnsupported, provider)
+	}
+
+	result := &knowledgeProviderDocumentContent{
+		Provider:   provider,
+		DatasetID:  datasetID,
+		DocumentID: documentID,
+		Chunks:     make([]knowledgeProviderChunk, 0),
+	}
+	for page := 1; ; page++ {
+		if page > knowledgeProviderContentMaxPages {
+			result.Truncated = true
+			break
+		}
+		p, err := fetchPage(page)
+		if err != nil {
+			return nil, err
+		}
+		result.Chunks = append(result.Chunks, p.Chunks...)
+		if p.Total > result.Total {
+			result.Total = p.Total
+		}
+		if len(p.Chunks) < knowledgeProviderContentPageSize || (p.Total > 0 && len(result.Chunks) >= p.Total) {
+			break
+		}
+	}
+	if result.Total < len(result.Chunks) {
+		result.Total = len(result.Chunks)
+	}
+	return result, nil
+}
+
+// GetProviderDocumentContent 从 provider 回读文档的分段内容，用于核对同步后实际入库的内容是否完整
+// GET /api/user/knowledge-bases/:id/documents/:doc_id/provider-content
+func (uc *UserController) GetProviderDocumentContent(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	kbID, _ := strconv.Atoi(c.Param("id"))
+	docID, _ := strconv.Atoi(c.Param("doc_id"))
+	if kbID <= 0 || docID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+	kb, err := uc.getOwnedKnowledgeBase(userID.(uint), uint(kbID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	var doc models.KnowledgeBaseDocument
+	if err := uc.DB.Where("id = ? AND knowledge_base_id = ?", docID, kb.ID).First(&doc).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+	documentID := strings.TrimSpace(doc.ExternalDocID)
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文档尚未同步到 provider（external_doc_id 为空）"})
+		return
+	}
+
+	provider, _, providerData, err := resolveKnowledgeProviderForKB(uc.DB, kb)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	content, err := fetchKnowledgeProviderDocumentContent(kb, provider, providerData, documentID)
+	if err != nil {
+		if errors.Is(err, errKnowledgeContentRetrievalUnsupported) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	providerLength := 0
+	for _, chunk := range content.Chunks {
+		providerLength += len([]rune(chunk.Content))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"document":        gin.H{"id": doc.ID, "name": doc.Name, "sync_status": doc.SyncStatus},
+		"local_length":    len([]rune(doc.Content)),
+		"provider_length": providerLength, // 分段可能带重叠，与本地长度仅作粗略比对
+		"content":         content,
+	}})
+}
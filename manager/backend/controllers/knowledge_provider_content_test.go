@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestFetchKnowledgeProviderDocumentContentDifyPaging(t *testing.T) {
+	// 回读请求会记录同步事件，清空共享通道避免影响其他用例
+	defer func() {
+		for drained := false; !drained; {
+			select {
+			case <-knowledgeSyncEventCh:
+			default:
+				drained = true
+			}
+		}
+	}()
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery)
+		page := r.URL.Query().Get("page")
+		items := make([]string, 0)
+		count := knowledgeProviderContentPageSize
+		if page == "2" {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			items = append(items, fmt.Sprintf(`{"position":%s%d,"content":"段落"}`, map[string]string{"1": "", "2": "10"}[page], i+1))
+		}
+		fmt.Fprintf(w, `{"data":[%s],"total":%d}`, strings.Join(items, ","), knowledgeProviderContentPageSize+1)
+	}))
+	defer srv.Close()
+
+	kb := &models.KnowledgeBase{ID: 1, ExternalKBID: "ds-1"}
+	got, err := fetchKnowledgeProviderDocumentContent(kb, "dify", map[string]interface{}{"base_url": srv.URL, "api_key": "k"}, "doc-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Chunks) != knowledgeProviderContentPageSize+1 || got.Total != knowledgeProviderContentPageSize+1 || got.Truncated {
+		t.Fatalf("chunks=%d total=%d truncated=%v", len(got.Chunks), got.Total, got.Truncated)
+	}
+	if len(paths) != 2 || !strings.HasPrefix(paths[0], "/v1/datasets/ds-1/documents/doc-9/segments?") {
+		t.Fatalf("paths = %v", paths)
+	}
+}
+
+func TestFetchKnowledgeProviderDocumentContentRagflow(t *testing.T) {
+	// 回读请求会记录同步事件，清空共享通道避免影响其他用例
+	defer func() {
+		for drained := false; !drained; {
+			select {
+			case <-knowledgeSyncEventCh:
+			default:
+				drained = true
+			}
+		}
+	}()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/datasets/ds-2/documents/doc-3/chunks" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"code":0,"data":{"chunks":[{"content":"第一段"},{"content":"第二段"}],"total":2}}`))
+	}))
+	defer srv.Close()
+
+	kb := &models.KnowledgeBase{ID: 2, ExternalKBID: "ds-2"}
+	got, err := fetchKnowledgeProviderDocumentContent(kb, "ragflow", map[string]interface{}{"base_url": srv.URL, "api_key": "k"}, "doc-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Chunks) != 2 || got.Chunks[1].Content != "第二段" || got.Chunks[1].Position != 2 {
+		t.Fatalf("chunks = %+v", got.Chunks)
+	}
+}
+
+func TestFetchKnowledgeProviderDocumentContentUnsupported(t *testing.T) {
+	kb := &models.KnowledgeBase{ID: 3, ExternalKBID: "ds-3"}
+	if _, err := fetchKnowledgeProviderDocumentContent(kb, "unknown", nil, "doc"); !errors.Is(err, errKnowledgeContentRetrievalUnsupported) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// 软删除知识库的保留期，期满后才真正清理外部 provider 数据
+	knowledgeBasePurgeGracePeriod = 7 * 24 * time.Hour
+	knowledgeBasePurgeInterval    = time.Hour
+	knowledgeBasePurgeBatchSize   = 50
+)
+
+var knowledgeBasePurgeOnce sync.Once
+
+// StartKnowledgeBasePurgeWorker 启动软删除知识库的定时清理任务（仅启动一次）
+func StartKnowledgeBasePurgeWorker(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	knowledgeBasePurgeOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(knowledgeBasePurgeInterval)
+			defer ticker.Stop()
+			for {
+				purgeExpiredKnowledgeBases(db, time.Now(), enqueueKnowledgeBaseProviderCleanup)
+				<-ticker.C
+			}
+		}()
+		logger.Infof("[KnowledgePurge] worker started grace=%s interval=%s", knowledgeBasePurgeGracePeriod, knowledgeBasePurgeInterval)
+	})
+}
+
+// softDeleteKnowledgeBase 软删除知识库：记录并解除智能体关联，保留文档与外部数据集
+func softDeleteKnowledgeBase(db *gorm.DB, kb *models.KnowledgeBase) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var links []models.AgentKnowledgeBase
+		if err := tx.Where("knowledge_base_id = ?", kb.ID).Order("id ASC").Find(&links).Error; err != nil {
+			return err
+		}
+		agentIDs := make([]uint, 0, len(links))
+		for _, link := range links {
+			agentIDs = append(agentIDs, link.AgentID)
+		}
+		linksJSON, err := json.Marshal(agentIDs)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.KnowledgeBase{}).Where("id = ?", kb.ID).Update("deleted_agent_links", string(linksJSON)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("knowledge_base_id = ?", kb.ID).Delete(&models.AgentKnowledgeBase{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.KnowledgeBase{}, kb.ID).Error
+	})
+}
+
+// restoreKnowledgeBase 恢复软删除的知识库，并重新关联仍存在且属于同一用户的智能体
+func restoreKnowledgeBase(db *gorm.DB, userID, kbID uint) (*models.KnowledgeBase, []uint, error) {
+	var kb models.KnowledgeBase
+	if err := db.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", kbID, userID).First(&kb).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, fmt.Errorf("已删除的知识库不存在或已被清理")
+		}
+		return nil, nil, err
+	}
+
+	var agentIDs []uint
+	if kb.DeletedAgentLinks != "" {
+		if err := json.Unmarshal([]byte(kb.DeletedAgentLinks), &agentIDs); err != nil {
+			logger.Warnf("[KnowledgePurge] 解析知识库关联失败 kb_id=%d err=%v", kb.ID, err)
+			agentIDs = nil
+		}
+	}
+
+	relinked := make([]uint, 0, len(agentIDs))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.KnowledgeBase{}).Where("id = ?", kb.ID).Updates(map[string]interface{}{
+			"deleted_at":          nil,
+			"deleted_agent_links": "",
+		}).Error; err != nil {
+			return err
+		}
+		if len(agentIDs) == 0 {
+			return nil
+		}
+		var agents []models.Agent
+		if err := tx.Where("id IN ? AND user_id = ?", uniqueUintSlice(agentIDs), userID).Find(&agents).Error; err != nil {
+			return err
+		}
+		for _, agent := range agents {
+			var count int64
+			if err := tx.Model(&models.AgentKnowledgeBase{}).Where("agent_id = ? AND knowledge_base_id = ?", agent.ID, kb.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				if err := tx.Create(&models.AgentKnowledgeBase{AgentID: agent.ID, KnowledgeBaseID: kb.ID}).Error; err != nil {
+					return err
+				}
+			}
+			relinked = append(relinked, agent.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	kb.DeletedAt = gorm.DeletedAt{}
+	kb.DeletedAgentLinks = ""
+	return &kb, relinked, nil
+}
+
+// knowledgeBaseCleanupEnqueuer 投递已删除知识库的外部 provider 清理任务
+type knowledgeBaseCleanupEnqueuer func(db *gorm.DB, kb models.KnowledgeBase, docs []models.KnowledgeBaseDocument) error
+
+// enqueueKnowledgeBaseProviderCleanup 投递外部 provider 清理任务：有文档则逐个删除文档，否则删除整个知识库
+func enqueueKnowledgeBaseProviderCleanup(db *gorm.DB, kb models.KnowledgeBase, docs []models.KnowledgeBaseDocument) error {
+	for _, doc := range docs {
+		if err := enqueueKnowledgeDocumentSyncDelete(db, kb, doc); err != nil {
+			return err
+		}
+	}
+	if len(docs) == 0 {
+		return enqueueKnowledgeSyncDelete(db, kb)
+	}
+	return nil
+}
+
+// purgeExpiredKnowledgeBases 清理超过保留期的软删除知识库：先投递 provider 清理，再物理删除本地数据
+func purgeExpiredKnowledgeBases(db *gorm.DB, now time.Time, cleanup knowledgeBaseCleanupEnqueuer) {
+	cutoff := now.Add(-knowledgeBasePurgeGracePeriod)
+	var kbs []models.KnowledgeBase
+	if err := db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at ASC").
+		Limit(knowledgeBasePurgeBatchSize).
+		Find(&kbs).Error; err != nil {
+		logger.Warnf("[KnowledgePurge] 查询待清理知识库失败: %v", err)
+		return
+	}
+
+	for _, kb := range kbs {
+		var docs []models.KnowledgeBaseDocument
+		if err := db.Where("knowledge_base_id = ?", kb.ID).Find(&docs).Error; err != nil {
+			logger.Warnf("[KnowledgePurge] 查询知识库文档失败 kb_id=%d err=%v", kb.ID, err)
+			continue
+		}
+		// 入队失败（如队列已满）时保留本地记录，下一轮重试
+		if err := cleanup(db, kb, docs); err != nil {
+			logger.Warnf("[KnowledgePurge] 清理任务入队失败 kb_id=%d err=%v", kb.ID, err)
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("knowledge_base_id = ?", kb.ID).Delete(&models.KnowledgeBaseDocument{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("knowledge_base_id = ?", kb.ID).Delete(&models.AgentKnowledgeBase{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&models.KnowledgeBase{}, kb.ID).Error
+		})
+		if err != nil {
+			logger.Errorf("[KnowledgePurge] 物理删除知识库失败 kb_id=%d err=%v", kb.ID, err)
+			continue
+		}
+		logger.Infof("[KnowledgePurge] purged kb_id=%d user_id=%d docs=%d", kb.ID, kb.UserID, len(docs))
+	}
+}
+
+// knowledgeBasePurgeAt 返回软删除知识库的计划清理时间
+func knowledgeBasePurgeAt(kb models.KnowledgeBase) *time.Time {
+	if !kb.DeletedAt.Valid {
+		return nil
+	}
+	purgeAt := kb.DeletedAt.Time.Add(knowledgeBasePurgeGracePeriod)
+	return &purgeAt
+}
+
+// GetDeletedKnowledgeBases 获取当前用户已删除（保留期内）的知识库列表
+func (uc *UserController) GetDeletedKnowledgeBases(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var items []models.KnowledgeBase
+	if err := uc.DB.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("deleted_at DESC").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取已删除知识库列表失败"})
+		return
+	}
+	data := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		data = append(data, gin.H{
+			"id":          item.ID,
+			"name":        item.Name,
+			"description": item.Description,
+			"deleted_at":  item.DeletedAt.Time,
+			"purge_at":    knowledgeBasePurgeAt(item),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// RestoreKnowledgeBase 恢复当前用户软删除的知识库
+func (uc *UserController) RestoreKnowledgeBase(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的知识库ID"})
+		return
+	}
+	kb, relinked, err := restoreKnowledgeBase(uc.DB, userID.(uint), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": kb, "relinked_agent_ids": relinked, "message": "知识库已恢复"})
+}
+
+// RestoreUserKnowledgeBaseAdmin 管理员恢复指定用户软删除的知识库
+func (ac *AdminController) RestoreUserKnowledgeBaseAdmin(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+	kbID, _ := strconv.Atoi(c.Param("kb_id"))
+	if userID <= 0 || kbID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+	kb, relinked, err := restoreKnowledgeBase(ac.DB, uint(userID), uint(kbID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": kb, "relinked_agent_ids": relinked, "message": "知识库已恢复"})
+}
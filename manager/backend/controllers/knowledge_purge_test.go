@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+func newKnowledgePurgeTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return newTestDB(t, &models.KnowledgeBase{}, &models.KnowledgeBaseDocument{}, &models.AgentKnowledgeBase{}, &models.Agent{})
+}
+
+func TestRestoreKnowledgeBaseWithinGracePeriod(t *testing.T) {
+	db := newKnowledgePurgeTestDB(t)
+	kb := models.KnowledgeBase{UserID: 1, Name: "产品", ExternalKBID: "ds-1"}
+	if err := db.Create(&kb).Error; err != nil {
+		t.Fatal(err)
+	}
+	agents := []models.Agent{{UserID: 1, Name: "a"}, {UserID: 1, Name: "b"}}
+	for i := range agents {
+		if err := db.Create(&agents[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&models.AgentKnowledgeBase{AgentID: agents[i].ID, KnowledgeBaseID: kb.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := softDeleteKnowledgeBase(db, &kb); err != nil {
+		t.Fatal(err)
+	}
+	var links int64
+	db.Model(&models.AgentKnowledgeBase{}).Where("knowledge_base_id = ?", kb.ID).Count(&links)
+	if links != 0 {
+		t.Fatalf("links after soft delete = %d", links)
+	}
+	// 删除期间智能体 b 被删除，恢复时只重新关联仍存在的智能体
+	db.Delete(&agents[1])
+
+	if _, _, err := restoreKnowledgeBase(db, 2, kb.ID); err == nil {
+		t.Fatal("other user should not restore the knowledge base")
+	}
+	restored, relinked, err := restoreKnowledgeBase(db, 1, kb.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt.Valid || restored.ExternalKBID != "ds-1" || len(relinked) != 1 || relinked[0] != agents[0].ID {
+		t.Fatalf("restored = %+v relinked = %v", restored, relinked)
+	}
+	var stored models.KnowledgeBase
+	if err := db.First(&stored, kb.ID).Error; err != nil || stored.DeletedAgentLinks != "" {
+		t.Fatalf("stored = %+v err = %v", stored, err)
+	}
+	if _, _, err := restoreKnowledgeBase(db, 1, kb.ID); err == nil {
+		t.Fatal("restoring an active knowledge base should fail")
+	}
+}
+
+func TestPurgeExpiredKnowledgeBases(t *testing.T) {
+	db := newKnowledgePurgeTestDB(t)
+	now := time.Now()
+	expired := models.KnowledgeBase{UserID: 1, Name: "过期", ExternalKBID: "ds-expired"}
+	recent := models.KnowledgeBase{UserID: 1, Name: "保留期内", ExternalKBID: "ds-recent"}
+	empty := models.KnowledgeBase{UserID: 1, Name: "无文档", ExternalKBID: "ds-empty"}
+	active := models.KnowledgeBase{UserID: 1, Name: "正常"}
+	for _, kb := range []*models.KnowledgeBase{&expired, &recent, &empty, &active} {
+		if err := db.Create(kb).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, kb := range []*models.KnowledgeBase{&expired, &recent} {
+		if err := db.Create(&models.KnowledgeBaseDocument{KnowledgeBaseID: kb.ID, Name: "doc", Content: "c"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&models.AgentKnowledgeBase{AgentID: 9, KnowledgeBaseID: expired.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	deletedAt := map[uint]time.Time{
+		expired.ID: now.Add(-knowledgeBasePurgeGracePeriod - time.Hour),
+		recent.ID:  now.Add(-knowledgeBasePurgeGracePeriod + time.Hour),
+		empty.ID:   now.Add(-knowledgeBasePurgeGracePeriod - time.Minute),
+	}
+	for id, at := range deletedAt {
+		if err := db.Model(&models.KnowledgeBase{}).Where("id = ?", id).Update("deleted_at", at).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cleaned := make(map[uint]int)
+	cleanup := func(_ *gorm.DB, kb models.KnowledgeBase, docs []models.KnowledgeBaseDocument) error {
+		cleaned[kb.ID] = len(docs)
+		return nil
+	}
+	purgeExpiredKnowledgeBases(db, now, cleanup)
+
+	if len(cleaned) != 2 || cleaned[expired.ID] != 1 || cleaned[empty.ID] != 0 {
+		t.Fatalf("provider cleanup = %v", cleaned)
+	}
+	countUnscoped := func(id uint) int64 {
+		var n int64
+		db.Unscoped().Model(&models.KnowledgeBase{}).Where("id = ?", id).Count(&n)
+		return n
+	}
+	if countUnscoped(expired.ID) != 0 || countUnscoped(empty.ID) != 0 {
+		t.Fatal("expired knowledge bases should be removed including unscoped rows")
+	}
+	if countUnscoped(recent.ID) != 1 || countUnscoped(active.ID) != 1 {
+		t.Fatal("knowledge bases within grace period or active should be kept")
+	}
+	var docs, links int64
+	db.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ?", expired.ID).Count(&docs)
+	db.Model(&models.AgentKnowledgeBase{}).Where("knowledge_base_id = ?", expired.ID).Count(&links)
+	if docs != 0 || links != 0 {
+		t.Fatalf("expired docs=%d links=%d", docs, links)
+	}
+	db.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ?", recent.ID).Count(&docs)
+	if docs != 1 {
+		t.Fatal("documents of knowledge base within grace period should be kept")
+	}
+
+	// 保留期内的知识库仍可恢复
+	if _, _, err := restoreKnowledgeBase(db, 1, recent.ID); err != nil {
+		t.Fatalf("restore within grace period: %v", err)
+	}
+
+	// 入队失败时保留本地记录，等待下一轮重试
+	failing := models.KnowledgeBase{UserID: 1, Name: "入队失败"}
+	if err := db.Create(&failing).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Model(&models.KnowledgeBase{}).Where("id = ?", failing.ID).Update("deleted_at", now.Add(-knowledgeBasePurgeGracePeriod-time.Hour))
+	purgeExpiredKnowledgeBases(db, now, func(*gorm.DB, models.KnowledgeBase, []models.KnowledgeBaseDocument) error {
+		return errors.New("队列已满")
+	})
+	if countUnscoped(failing.ID) != 1 {
+		t.Fatal("knowledge base should be kept when provider cleanup cannot be enqueued")
+	}
+}
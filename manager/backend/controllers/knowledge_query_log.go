@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	knowledgeQueryLogTimeout = 5 * time.Second
+	knowledgeQueryLogLimit   = 20
+)
+
+// KnowledgeQueryLogEntry 主程序记录的一次知识库检索
+type KnowledgeQueryLogEntry struct {
+	Query     string    `json:"query"`
+	Timestamp time.Time `json:"timestamp"`
+	HitCount  int       `json:"hit_count"`
+}
+
+// mergeKnowledgeQueryLogs 合并多个主程序实例的检索日志，按时间倒序截取前 limit 条
+func mergeKnowledgeQueryLogs(lists [][]KnowledgeQueryLogEntry, limit int) []KnowledgeQueryLogEntry {
+	merged := make([]KnowledgeQueryLogEntry, 0)
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Timestamp.After(merged[j].Timestamp)
+	})
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// RequestKnowledgeQueryLogFromClients 向所有已连接的主程序查询知识库检索日志并合并
+// 检索日志仅保存在各主程序内存中，部分实例失败时忽略其结果
+func (ctrl *WebSocketController) RequestKnowledgeQueryLogFromClients(ctx context.Context, kbID uint) ([]KnowledgeQueryLogEntry, error) {
+	lists := make([][]KnowledgeQueryLogEntry, 0)
+	connected := 0
+	var lastErr error
+	for item := range ctrl.clientsMap.IterBuffered() {
+		client := item.Val
+		if !client.isConnected {
+			continue
+		}
+		connected++
+		resp, err := client.SendRequestWithResponse(ctx, "GET", "/api/knowledge/query-log", map[string]interface{}{"knowledge_base_id": kbID})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.Status != http.StatusOK {
+			lastErr = fmt.Errorf("%s", resp.Error)
+			continue
+		}
+		var result struct {
+			Entries []KnowledgeQueryLogEntry `json:"entries"`
+		}
+		raw, err := json.Marshal(resp.Body)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			lastErr = err
+			continue
+		}
+		lists = append(lists, result.Entries)
+	}
+	if connected == 0 {
+		return nil, fmt.Errorf("没有连接的客户端")
+	}
+	if len(lists) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return mergeKnowledgeQueryLogs(lists, knowledgeQueryLogLimit), nil
+}
+
+// GetKnowledgeBaseQueryLog 获取知识库最近的检索记录（查询文本、时间、命中数）
+// GET /api/user/knowledge-bases/:id/query-log
+func (uc *UserController) GetKnowledgeBaseQueryLog(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	kbID, _ := strconv.Atoi(c.Param("id"))
+	if kbID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的知识库ID"})
+		return
+	}
+	kb, err := uc.getOwnedKnowledgeBase(userID.(uint), uint(kbID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if uc.WebSocketController == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "WebSocket 服务未初始化"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), knowledgeQueryLogTimeout)
+	defer cancel()
+	entries, err := uc.WebSocketController.RequestKnowledgeQueryLogFromClients(ctx, kb.ID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "主程序查询失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"knowledge_base_id": kb.ID,
+		"entries":           entries,
+	}})
+}
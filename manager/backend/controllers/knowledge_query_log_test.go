@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeKnowledgeQueryLogs(t *testing.T) {
+	base := time.Now()
+	a := []KnowledgeQueryLogEntry{
+		{Query: "a2", Timestamp: base.Add(2 * time.Second), HitCount: 1},
+		{Query: "a0", Timestamp: base},
+	}
+	b := []KnowledgeQueryLogEntry{
+		{Query: "b3", Timestamp: base.Add(3 * time.Second), HitCount: 2},
+		{Query: "b1", Timestamp: base.Add(time.Second)},
+	}
+	got := mergeKnowledgeQueryLogs([][]KnowledgeQueryLogEntry{a, b}, 3)
+	want := []string{"b3", "a2", "b1"}
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d", len(got), len(want))
+	}
+	for i, q := range want {
+		if got[i].Query != q {
+			t.Fatalf("got[%d] = %s, want %s", i, got[i].Query, q)
+		}
+	}
+	if got := mergeKnowledgeQueryLogs(nil, 3); len(got) != 0 {
+		t.Fatalf("empty merge = %+v", got)
+	}
+}
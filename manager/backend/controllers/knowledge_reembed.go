@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WeKnora 知识库的向量化模型在创建时确定，切换 embedding_model_id 后已有文档仍是旧模型的向量。
+// 重新向量化：删除系统自动创建的 WeKnora 知识库，按新模型重建并重新上传全部文档。
+
+const knowledgeReembedWarning = "切换向量模型后，已同步的知识库仍使用旧模型的向量，检索结果可能不一致。重新向量化将删除并重建这些知识库在 WeKnora 中的数据，重新解析全部文档，耗时较长且会消耗模型调用额度，期间检索不可用。"
+
+// weknoraEmbeddingModelID 从 knowledge_search 配置中读取 WeKnora embedding_model_id，非 weknora 配置返回空
+func weknoraEmbeddingModelID(cfg *models.Config) string {
+	if cfg == nil || strings.ToLower(strings.TrimSpace(cfg.Provider)) != "weknora" {
+		return ""
+	}
+	providerData := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(cfg.JsonData), &providerData); err != nil {
+		return ""
+	}
+	modelID, _ := providerData["embedding_model_id"].(string)
+	return strings.TrimSpace(modelID)
+}
+
+// weknoraReembedCandidates 已同步到 WeKnora 的知识库，按是否由系统自动创建分为可重建与需跳过两类
+func weknoraReembedCandidates(db *gorm.DB) ([]models.KnowledgeBase, []models.KnowledgeBase, error) {
+	var kbs []models.KnowledgeBase
+	if err := db.Where("LOWER(sync_provider) = ? AND external_kb_id <> ''", "weknora").Order("id ASC").Find(&kbs).Error; err != nil {
+		return nil, nil, err
+	}
+	reembed := make([]models.KnowledgeBase, 0, len(kbs))
+	skipped := make([]models.KnowledgeBase, 0)
+	for _, kb := range kbs {
+		// 用户手动关联的知识库不由系统删除重建
+		if kb.AutoDataset {
+			reembed = append(reembed, kb)
+		} else {
+			skipped = append(skipped, kb)
+		}
+	}
+	return reembed, skipped, nil
+}
+
+// UpdateKnowledgeSearchConfig 更新知识库检索配置；WeKnora 向量模型变更时在响应中提示受影响的知识库
+func (ac *AdminController) UpdateKnowledgeSearchConfig(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var before models.Config
+	beforeModelID := ""
+	if err := ac.DB.Where("id = ? AND type = ?", id, "knowledge_search").First(&before).Error; err == nil {
+		beforeModelID = weknoraEmbeddingModelID(&before)
+	}
+
+	config, ok := ac.saveConfigUpdate(c, "knowledge_search")
+	if !ok {
+		return
+	}
+	ac.notifySystemConfigChanged()
+
+	afterModelID := weknoraEmbeddingModelID(config)
+	if beforeModelID == "" || afterModelID == "" || beforeModelID == afterModelID {
+		c.JSON(http.StatusOK, gin.H{"data": config})
+		return
+	}
+
+	reembed, skipped, err := weknoraReembedCandidates(ac.DB)
+	if err != nil {
+		logger.Warnf("[KnowledgeReembed] load affected knowledge bases failed config_id=%d err=%v", config.ID, err)
+	}
+	logger.Infof("[KnowledgeReembed] embedding model changed config_id=%d from=%s to=%s affected=%d", config.ID, beforeModelID, afterModelID, len(reembed))
+	c.JSON(http.StatusOK, gin.H{
+		"data": config,
+		"embedding_model_change": gin.H{
+			"from":                     beforeModelID,
+			"to":                       afterModelID,
+			"affected_knowledge_bases": len(reembed),
+			"skipped_knowledge_bases":  len(skipped),
+			"warning":                  knowledgeReembedWarning,
+		},
+	})
+}
+
+// ReembedKnowledgeBases 使用当前 WeKnora 向量模型重建已同步的知识库
+// 未携带 confirm=true 时仅返回受影响范围与提示，不执行任何操作
+func (ac *AdminController) ReembedKnowledgeBases(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var config models.Config
+	if err := ac.DB.Where("id = ? AND type = ?", id, "knowledge_search").First(&config).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在"})
+		return
+	}
+	modelID := weknoraEmbeddingModelID(&config)
+	if modelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持已配置 embedding_model_id 的 WeKnora 配置"})
+		return
+	}
+
+	var req struct {
+		Confirm bool `json:"confirm"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	reembed, skipped, err := weknoraReembedCandidates(ac.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询受影响的知识库失败"})
+		return
+	}
+	skippedIDs := make([]uint, 0, len(skipped))
+	for _, kb := range skipped {
+		skippedIDs = append(skippedIDs, kb.ID)
+	}
+
+	if !req.Confirm {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{
+			"embedding_model_id":       modelID,
+			"affected_knowledge_bases": len(reembed),
+			"skipped_knowledge_bases":  skippedIDs,
+			"requires_confirm":         true,
+			"warning":                  knowledgeReembedWarning,
+		}})
+		return
+	}
+
+	queued := make([]uint, 0, len(reembed))
+	failed := make([]gin.H, 0)
+	for _, kb := range reembed {
+		if err := enqueueKnowledgeSyncReembed(ac.DB, kb.ID); err != nil {
+			failed = append(failed, gin.H{"id": kb.ID, "error": err.Error()})
+			continue
+		}
+		queued = append(queued, kb.ID)
+	}
+	logger.Infof("[KnowledgeReembed] submitted config_id=%d model=%s queued=%d failed=%d skipped=%d", config.ID, modelID, len(queued), len(failed), len(skippedIDs))
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "重新向量化任务已提交", "data": gin.H{
+		"embedding_model_id":      modelID,
+		"queued":                  queued,
+		"failed":                  failed,
+		"skipped_knowledge_bases": skippedIDs,
+	}})
+}
+
+// reembedKnowledgeBaseOnWeknora 删除旧的 WeKnora 知识库后按当前配置重建，并重新同步全部文档
+func reembedKnowledgeBaseOnWeknora(db *gorm.DB, kbID uint) error {
+	var kb models.KnowledgeBase
+	if err := db.Where("id = ?", kbID).First(&kb).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("加载知识库失败: %w", err)
+	}
+	if !kb.AutoDataset || strings.TrimSpace(kb.ExternalKBID) == "" {
+		return nil
+	}
+
+	provider, _, providerData, err := resolveKnowledgeProviderForKB(db, &kb)
+	if err != nil {
+		return err
+	}
+	if provider != "weknora" {
+		return fmt.Errorf("知识库当前provider为%s，无需重新向量化", provider)
+	}
+	cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
+	if err != nil {
+		return err
+	}
+
+	client := newKnowledgeSyncHTTPClient(kb.ID, "weknora", weknoraHTTPTimeout)
+	if err := deleteWeknoraKnowledgeBase(client, cfg, kb.ExternalKBID); err != nil {
+		return err
+	}
+
+	// 旧知识库已删除，清空外部ID后按新模型重建
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.KnowledgeBase{}).Where("id = ?", kb.ID).Updates(map[string]interface{}{
+			"external_kb_id":  "",
+			"external_doc_id": "",
+			"sync_status":     knowledgeSyncStatusPending,
+			"sync_error":      "",
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ?", kb.ID).Updates(map[string]interface{}{
+			"external_doc_id": "",
+			"sync_status":     knowledgeSyncStatusPending,
+			"sync_error":      "",
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("重置知识库同步状态失败: %w", err)
+	}
+	if err := db.Where("id = ?", kb.ID).First(&kb).Error; err != nil {
+		return fmt.Errorf("加载知识库失败: %w", err)
+	}
+
+	if err := syncKnowledgeBaseBestEffort(context.Background(), db, &kb); err != nil {
+		return err
+	}
+
+	var docIDs []uint
+	if err := db.Model(&models.KnowledgeBaseDocument{}).Where("knowledge_base_id = ?", kb.ID).Order("id ASC").Pluck("id", &docIDs).Error; err != nil {
+		return fmt.Errorf("加载知识库文档失败: %w", err)
+	}
+	failed := 0
+	for _, docID := range docIDs {
+		if err := syncKnowledgeDocumentBestEffort(db, kb.ID, docID); err != nil {
+			failed++
+			logger.Warnf("[KnowledgeReembed] document sync failed kb_id=%d doc_id=%d err=%v", kb.ID, docID, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个文档重新同步失败", failed, len(docIDs))
+	}
+	return nil
+}
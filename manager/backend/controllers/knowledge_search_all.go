@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// knowledgeProviderSearchResult 单个 provider 的对比检索结果
+type knowledgeProviderSearchResult struct {
+	Provider        string                   `json:"provider"`
+	ConfigID        string                   `json:"config_id"`
+	ConfigName      string                   `json:"config_name"`
+	DatasetID       string                   `json:"dataset_id,omitempty"`
+	KnowledgeBaseID uint                     `json:"knowledge_base_id,omitempty"`
+	ElapsedMs       int64                    `json:"elapsed_ms"`
+	Count           int                      `json:"count"`
+	Hits            []knowledgeSearchTestHit `json:"hits"`
+	Skipped         bool                     `json:"skipped,omitempty"`
+	Error           string                   `json:"error,omitempty"`
+}
+
+// knowledgeSearchTarget 一个 provider 的检索目标：provider 配置与其上的数据集
+type knowledgeSearchTarget struct {
+	config       models.Config
+	providerData map[string]interface{}
+	kb           models.KnowledgeBase
+}
+
+// SearchAllKnowledgeProviders 使用同一查询对所有已启用的知识库 provider 执行测试检索，并列返回结果便于对比检索质量
+// 数据集在各 provider 侧独立，需通过 datasets（provider -> dataset_id）或 knowledge_base_ids（取同步到对应 provider 的知识库）指定
+func (ac *AdminController) SearchAllKnowledgeProviders(c *gin.Context) {
+	var req struct {
+		Query            string            `json:"query" binding:"required"`
+		TopK             int               `json:"top_k"`
+		Threshold        *float64          `json:"threshold"`
+		Datasets         map[string]string `json:"datasets"`
+		KnowledgeBaseIDs []uint            `json:"knowledge_base_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query 不能为空"})
+		return
+	}
+	topK := req.TopK
+	if topK <= 0 {
+		topK = 5
+	}
+	if topK > 20 {
+		topK = 20
+	}
+	if req.Threshold != nil && (*req.Threshold < 0 || *req.Threshold > 1) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold 必须在 0~1 之间"})
+		return
+	}
+
+	var configs []models.Config
+	if err := ac.DB.Where("type = ? AND enabled = ?", "knowledge_search", true).Order("id ASC").Find(&configs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取知识库检索配置失败"})
+		return
+	}
+	// 每个 provider 取一条配置，默认配置优先
+	selected := make(map[string]models.Config)
+	for _, cfg := range configs {
+		provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
+		if provider == "" {
+			continue
+		}
+		if prev, exists := selected[provider]; !exists || (!prev.IsDefault && cfg.IsDefault) {
+			selected[provider] = cfg
+		}
+	}
+	if len(selected) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未找到已启用的知识库provider配置"})
+		return
+	}
+
+	// 指定的知识库按同步 provider 归类，同一 provider 取第一个
+	kbByProvider := make(map[string]models.KnowledgeBase)
+	if len(req.KnowledgeBaseIDs) > 0 {
+		var kbs []models.KnowledgeBase
+		if err := ac.DB.Where("id IN ?", uniqueUintSlice(req.KnowledgeBaseIDs)).Order("id ASC").Find(&kbs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询知识库失败"})
+			return
+		}
+		for _, kb := range kbs {
+			provider := strings.ToLower(strings.TrimSpace(kb.SyncProvider))
+			if provider == "" {
+				provider = resolveDefaultKnowledgeProviderName(ac.DB)
+			}
+			if _, exists := kbByProvider[provider]; !exists && strings.TrimSpace(kb.ExternalKBID) != "" {
+				kbByProvider[provider] = kb
+			}
+		}
+	}
+
+	providers := make([]string, 0, len(selected))
+	for provider := range selected {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	results := make([]knowledgeProviderSearchResult, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		cfg := selected[provider]
+		results[i] = knowledgeProviderSearchResult{Provider: provider, ConfigID: cfg.ConfigID, ConfigName: cfg.Name, Hits: []knowledgeSearchTestHit{}}
+
+		target := knowledgeSearchTarget{config: cfg}
+		if datasetID := strings.TrimSpace(req.Datasets[provider]); datasetID != "" {
+			target.kb = models.KnowledgeBase{ExternalKBID: datasetID}
+		} else if kb, ok := kbByProvider[provider]; ok {
+			target.kb = kb
+		} else {
+			results[i].Skipped = true
+			results[i].Error = "未指定该 provider 的数据集（datasets 或同步到该 provider 的知识库）"
+			continue
+		}
+		results[i].DatasetID = strings.TrimSpace(target.kb.ExternalKBID)
+		results[i].KnowledgeBaseID = target.kb.ID
+		_, providerData, err := parseKnowledgeProviderConfigPayload(&target.config)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		target.providerData = providerData
+
+		wg.Add(1)
+		go func(result *knowledgeProviderSearchResult, target knowledgeSearchTarget) {
+			defer wg.Done()
+			startAt := time.Now()
+			client := &http.Client{Timeout: 12 * time.Second}
+			hits, _, err := queryKnowledgeTestHits(client, result.Provider, target.providerData, &target.kb, req.Threshold, result.DatasetID, query, topK)
+			result.ElapsedMs = time.Since(startAt).Milliseconds()
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+			if target.kb.ID != 0 {
+				fillKnowledgeHitLocalDocuments(ac.DB, target.kb.ID, hits)
+			}
+			result.Hits = hits
+			result.Count = len(hits)
+		}(&results[i], target)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		logger.Infof("[KnowledgeTest][SearchAll] provider=%s dataset_id=%s skipped=%t hits=%d elapsed_ms=%d err=%q",
+			result.Provider, result.DatasetID, result.Skipped, result.Count, result.ElapsedMs, result.Error)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"query":     query,
+		"top_k":     topK,
+		"threshold": req.Threshold,
+		"results":   results,
+	}})
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSearchAllKnowledgeProviders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/datasets/ds-1/retrieve" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"records":[{"score":0.8,"segment":{"content":"小智支持离线唤醒","document_id":"doc-1"}}]}`))
+	}))
+	defer dify.Close()
+
+	db := newTestDB(t, &models.Config{}, &models.KnowledgeBase{}, &models.KnowledgeBaseDocument{})
+	for _, cfg := range []models.Config{
+		{Type: "knowledge_search", Name: "Dify", ConfigID: "dify", Provider: "dify", JsonData: `{"base_url":"` + dify.URL + `","api_key":"k"}`, Enabled: true, IsDefault: true},
+		{Type: "knowledge_search", Name: "RAGFlow", ConfigID: "ragflow", Provider: "ragflow", JsonData: `{}`, Enabled: true},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/search-all", ac.SearchAllKnowledgeProviders)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/search-all", strings.NewReader(`{"query":"离线唤醒","datasets":{"dify":"ds-1"}}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Results []knowledgeProviderSearchResult `json:"results"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	results := resp.Data.Results
+	if len(results) != 2 || results[0].Provider != "dify" || results[1].Provider != "ragflow" {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[0].Error != "" || results[0].Count != 1 || results[0].Hits[0].DocumentID != "doc-1" {
+		t.Fatalf("dify result = %+v", results[0])
+	}
+	// 未指定数据集的 provider 跳过而不是整体失败
+	if !results[1].Skipped || results[1].Error == "" {
+		t.Fatalf("ragflow result = %+v", results[1])
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/search-all", strings.NewReader(`{"query":"  "}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("blank query status = %d", w.Code)
+	}
+}
@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 知识库文档自动摘要：知识库开启 auto_summary 后，长文档同步成功时调用默认 LLM 生成摘要，
+// 摘要保存在文档记录上，并作为一篇独立文档同步到 provider，便于“这篇文档讲了什么”类问题直接命中。
+// 摘要失败只记录 summary_error，不影响文档本身的同步状态
+
+const (
+	// knowledgeSummaryMinRunes 原文少于该字数时不生成摘要
+	knowledgeSummaryMinRunes = 2000
+	// knowledgeSummaryMaxInputRunes 送入 LLM 的原文上限，超出部分截断
+	knowledgeSummaryMaxInputRunes = 12000
+	knowledgeSummaryMaxTokens     = 512
+	knowledgeSummaryLLMTimeout    = 60 * time.Second
+	knowledgeSummaryDocSuffix     = "（摘要）"
+)
+
+const knowledgeSummaryPrompt = "你是知识库助手。请用中文为下面的文档写一段不超过300字的摘要，概括主题、关键事实与结论，只输出摘要正文。"
+
+// knowledgeSummaryLLM 生成摘要使用的 OpenAI 兼容接口
+type knowledgeSummaryLLM struct {
+	ConfigID string
+	Endpoint string // 完整的 chat/completions 地址
+	APIKey   string
+	Model    string
+	Headers  map[string]string
+	ProxyURL string
+}
+
+// loadKnowledgeSummaryLLM 取默认的已启用 LLM 配置；仅支持 OpenAI 兼容接口（含 Ollama 的 /v1 兼容接口）
+func loadKnowledgeSummaryLLM(db *gorm.DB) (*knowledgeSummaryLLM, error) {
+	var cfg models.Config
+	if err := db.Where("type = ? AND enabled = ?", "llm", true).Order("is_default DESC, id ASC").First(&cfg).Error; err != nil {
+		return nil, fmt.Errorf("未配置可用的LLM")
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(cfg.JsonData), &data); err != nil {
+		return nil, fmt.Errorf("LLM 配置解析失败: %v", err)
+	}
+	llmType := strings.ToLower(strings.TrimSpace(getStringAny(data, "type")))
+	if llmType == "" {
+		llmType = strings.ToLower(strings.TrimSpace(cfg.Provider))
+	}
+	if llmType == "dify" || llmType == "coze" {
+		return nil, fmt.Errorf("自动摘要仅支持 OpenAI 兼容接口的 LLM，当前默认 LLM 类型: %s", llmType)
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(getStringAny(data, "base_url")), "/")
+	model := strings.TrimSpace(getStringAny(data, "model_name"))
+	if baseURL == "" || model == "" {
+		return nil, fmt.Errorf("LLM 配置 %s 缺少 base_url 或 model_name", cfg.ConfigID)
+	}
+	if llmType == "ollama" && !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+	llm := &knowledgeSummaryLLM{
+		ConfigID: cfg.ConfigID,
+		Endpoint: baseURL + "/chat/completions",
+		APIKey:   strings.TrimSpace(getStringAny(data, "api_key")),
+		Model:    model,
+	}
+	if raw, ok := data["extra_headers"]; ok && raw != nil {
+		headers, err := normalizeLLMExtraHeaders(raw)
+		if err != nil {
+			return nil, err
+		}
+		llm.Headers = headers
+	}
+	if proxy := getStringAny(data, "proxy_url"); strings.TrimSpace(proxy) != "" {
+		normalized, err := validateLLMProxyURL(proxy)
+		if err != nil {
+			return nil, err
+		}
+		llm.ProxyURL = normalized
+	}
+	return llm, nil
+}
+
+func (l *knowledgeSummaryLLM) httpClient() *http.Client {
+	client := &http.Client{Timeout: knowledgeSummaryLLMTimeout}
+	if l.ProxyURL != "" {
+		if u, err := url.Parse(l.ProxyURL); err == nil {
+			client.Transport = &http.Transport{Proxy: http.ProxyURL(u)}
+		}
+	}
+	return client
+}
+
+// generateKnowledgeSummary 调用 LLM 生成摘要
+func generateKnowledgeSummary(l *knowledgeSummaryLLM, name, text string) (string, error) {
+	if runes := []rune(text); len(runes) > knowledgeSummaryMaxInputRunes {
+		text = string(runes[:knowledgeSummaryMaxInputRunes])
+	}
+	payload := map[string]interface{}{
+		"model": l.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": knowledgeSummaryPrompt},
+			{"role": "user", "content": "文档标题: " + name + "\n\n" + text},
+		},
+		"max_tokens":  knowledgeSummaryMaxTokens,
+		"temperature": 0.3,
+		"stream":      false,
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, l.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("创建LLM请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.APIKey)
+	}
+	for name, value := range l.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := l.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("LLM请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("LLM返回HTTP %d: %s", resp.StatusCode, truncateSyncError(string(respBody)))
+	}
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("LLM响应解析失败: %v", err)
+	}
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("LLM未返回摘要内容")
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// knowledgeSummarySourceText 取文档用于摘要的纯文本：上传文件时按扩展名提取文本
+func knowledgeSummarySourceText(doc *models.KnowledgeBaseDocument) (string, error) {
+	fileName, fileData, isUploadFile, err := decodeKnowledgeUploadContent(doc.Content)
+	if err != nil {
+		return "", err
+	}
+	if isUploadFile {
+		return extractKnowledgeDocumentText(fileName, fileData)
+	}
+	return strings.TrimSpace(doc.Content), nil
+}
+
+func buildKnowledgeSummaryDocumentName(docName string) string {
+	return strings.TrimSpace(docName) + knowledgeSummaryDocSuffix
+}
+
+// uploadKnowledgeSummaryDocument 将摘要作为文本文档上传到知识库对应的 provider 数据集
+func uploadKnowledgeSummaryDocument(kb *models.KnowledgeBase, provider string, providerData map[string]interface{}, name, summary string) (string, error) {
+	datasetID := strings.TrimSpace(kb.ExternalKBID)
+	if datasetID == "" {
+		return "", fmt.Errorf("知识库尚未同步到 provider")
+	}
+	switch provider {
+	case "dify":
+		cfg, err := parseDifyKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return "", err
+		}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "dify", difyHTTPTimeout)
+		return createDifyDocumentByText(client, cfg, datasetID, &models.KnowledgeBase{ID: kb.ID, Name: name, Content: summary})
+	case "ragflow":
+		cfg, err := parseRagflowKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return "", err
+		}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "ragflow", 20*time.Second)
+		return createAndParseRagflowDocumentByText(client, cfg, datasetID, name, summary)
+	case "weknora":
+		cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return "", err
+		}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "weknora", weknoraHTTPTimeout)
+		return createWeknoraKnowledgeByText(client, cfg, datasetID, name, summary)
+	default:
+		return "", fmt.Errorf("自动摘要暂不支持provider: %s", provider)
+	}
+}
+
+// deleteKnowledgeProviderDocument 删除 provider 数据集中的单篇文档
+func deleteKnowledgeProviderDocument(kb *models.KnowledgeBase, provider string, providerData map[string]interface{}, documentID string) error {
+	datasetID := strings.TrimSpace(kb.ExternalKBID)
+	if datasetID == "" || documentID == "" {
+		return nil
+	}
+	switch provider {
+	case "dify":
+		cfg, err := parseDifyKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return err
+		}
+		return deleteDifyDocument(newKnowledgeSyncHTTPClient(kb.ID, "dify", difyHTTPTimeout), cfg, datasetID, documentID)
+	case "ragflow":
+		cfg, err := parseRagflowKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return err
+		}
+		return deleteRagflowDocument(newKnowledgeSyncHTTPClient(kb.ID, "ragflow", 20*time.Second), cfg, datasetID, documentID)
+	case "weknora":
+		cfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
+		if err != nil {
+			return err
+		}
+		return deleteWeknoraKnowledge(newKnowledgeSyncHTTPClient(kb.ID, "weknora", weknoraHTTPTimeout), cfg, documentID)
+	default:
+		return fmt.Errorf("知识库文档删除暂不支持provider: %s", provider)
+	}
+}
+
+// summarizeKnowledgeDocumentBestEffort 文档同步成功后生成并同步摘要；未开启、原文过短或原文未变化时跳过
+func summarizeKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) {
+	var kb models.KnowledgeBase
+	if err := db.Where("id = ?", kbID).First(&kb).Error; err != nil || !kb.AutoSummary {
+		return
+	}
+	var doc models.KnowledgeBaseDocument
+	if err := db.Where("id = ? AND knowledge_base_id = ?", docID, kbID).First(&doc).Error; err != nil {
+		return
+	}
+	if err := summarizeKnowledgeDocument(db, &kb, &doc); err != nil {
+		logger.Warnf("[KnowledgeSync][Summary] kb_id=%d doc_id=%d err=%v", kbID, docID, err)
+		_ = db.Model(&models.KnowledgeBaseDocument{}).Where("id = ?", doc.ID).
+			Update("summary_error", truncateSyncError(err.Error())).Error
+	}
+}
+
+func summarizeKnowledgeDocument(db *gorm.DB, kb *models.KnowledgeBase, doc *models.KnowledgeBaseDocument) error {
+	text, err := knowledgeSummarySourceText(doc)
+	if err != nil {
+		return fmt.Errorf("提取文档文本失败: %w", err)
+	}
+	if len([]rune(text)) < knowledgeSummaryMinRunes {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(text))
+	sourceHash := hex.EncodeToString(sum[:])
+	if sourceHash == doc.SummarySourceHash && doc.Summary != "" && doc.SummaryExternalDocID != "" {
+		return nil
+	}
+
+	llm, err := loadKnowledgeSummaryLLM(db)
+	if err != nil {
+		return err
+	}
+	summary, err := generateKnowledgeSummary(llm, doc.Name, text)
+	if err != nil {
+		return err
+	}
+	// 先保存摘要，provider 上传失败时摘要仍可在管理端查看
+	updates := map[string]interface{}{
+		"summary":             summary,
+		"summary_source_hash": sourceHash,
+		"summary_error":       "",
+	}
+	if err := db.Model(&models.KnowledgeBaseDocument{}).Where("id = ?", doc.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("保存摘要失败: %w", err)
+	}
+
+	provider, _, providerData, err := resolveKnowledgeProviderForKB(db, kb)
+	if err != nil {
+		return err
+	}
+	summaryDocID, err := uploadKnowledgeSummaryDocument(kb, provider, providerData, buildKnowledgeSummaryDocumentName(doc.Name), summary)
+	if err != nil {
+		return fmt.Errorf("同步摘要文档失败: %w", err)
+	}
+	if oldID := strings.TrimSpace(doc.SummaryExternalDocID); oldID != "" && oldID != summaryDocID {
+		if err := deleteKnowledgeProviderDocument(kb, provider, providerData, oldID); err != nil {
+			logger.Warnf("[KnowledgeSync][Summary] delete old summary document warning kb_id=%d doc_id=%d old_document_id=%s err=%v", kb.ID, doc.ID, oldID, err)
+		}
+	}
+	if err := db.Model(&models.KnowledgeBaseDocument{}).Where("id = ?", doc.ID).Update("summary_external_doc_id", summaryDocID).Error; err != nil {
+		return fmt.Errorf("保存摘要文档ID失败: %w", err)
+	}
+	logger.Infof("[KnowledgeSync][Summary] kb_id=%d doc_id=%d llm=%s summary_doc_id=%s", kb.ID, doc.ID, llm.ConfigID, summaryDocID)
+	return nil
+}
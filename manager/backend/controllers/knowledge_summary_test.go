@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+func newKnowledgeSummaryTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return newTestDB(t, &models.Config{}, &models.KnowledgeBase{}, &models.KnowledgeBaseDocument{})
+}
+
+func TestLoadKnowledgeSummaryLLM(t *testing.T) {
+	db := newKnowledgeSummaryTestDB(t)
+	if _, err := loadKnowledgeSummaryLLM(db); err == nil {
+		t.Fatal("expected error without llm config")
+	}
+
+	db.Create(&models.Config{Type: "llm", Name: "coze", ConfigID: "llm-coze", Provider: "coze", JsonData: `{"type":"coze"}`, Enabled: true, IsDefault: true})
+	if _, err := loadKnowledgeSummaryLLM(db); err == nil || !strings.Contains(err.Error(), "OpenAI 兼容") {
+		t.Fatalf("coze err = %v", err)
+	}
+
+	db.Model(&models.Config{}).Where("config_id = ?", "llm-coze").Update("is_default", false)
+	db.Create(&models.Config{Type: "llm", Name: "ollama", ConfigID: "llm-ollama", Provider: "ollama", IsDefault: true, Enabled: true,
+		JsonData: `{"type":"ollama","base_url":"http://localhost:11434/","model_name":"qwen2.5","extra_headers":{"x-team":"kb"}}`})
+	llm, err := loadKnowledgeSummaryLLM(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if llm.Endpoint != "http://localhost:11434/v1/chat/completions" || llm.Model != "qwen2.5" || llm.Headers["X-Team"] != "kb" {
+		t.Fatalf("llm = %+v", llm)
+	}
+}
+
+func TestGenerateKnowledgeSummary(t *testing.T) {
+	var gotAuth, gotTeam string
+	var gotReq struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotTeam = r.Header.Get("X-Team")
+		json.NewDecoder(r.Body).Decode(&gotReq)
+		w.Write([]byte(`{"choices":[{"message":{"content":" 这是摘要 "}}]}`))
+	}))
+	defer srv.Close()
+
+	llm := &knowledgeSummaryLLM{Endpoint: srv.URL, APIKey: "sk", Model: "m", Headers: map[string]string{"X-Team": "kb"}}
+	summary, err := generateKnowledgeSummary(llm, "手册", strings.Repeat("字", knowledgeSummaryMaxInputRunes+100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != "这是摘要" || gotAuth != "Bearer sk" || gotTeam != "kb" || gotReq.Model != "m" {
+		t.Fatalf("summary = %q auth = %q team = %q req = %+v", summary, gotAuth, gotTeam, gotReq.Model)
+	}
+	if n := len([]rune(gotReq.Messages[1].Content)); n > knowledgeSummaryMaxInputRunes+20 {
+		t.Fatalf("input not truncated: %d runes", n)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+	if _, err := generateKnowledgeSummary(&knowledgeSummaryLLM{Endpoint: failing.URL, Model: "m"}, "手册", "内容"); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSummarizeKnowledgeDocumentBestEffort(t *testing.T) {
+	db := newKnowledgeSummaryTestDB(t)
+	kb := models.KnowledgeBase{UserID: 1, Name: "kb", AutoSummary: true}
+	db.Create(&kb)
+	short := models.KnowledgeBaseDocument{KnowledgeBaseID: kb.ID, Name: "短文", Content: "很短的内容"}
+	long := models.KnowledgeBaseDocument{KnowledgeBaseID: kb.ID, Name: "长文", Content: strings.Repeat("长", knowledgeSummaryMinRunes)}
+	db.Create(&short)
+	db.Create(&long)
+
+	// 短文档跳过；长文档因未配置 LLM 失败，只记录 summary_error
+	summarizeKnowledgeDocumentBestEffort(db, kb.ID, short.ID)
+	summarizeKnowledgeDocumentBestEffort(db, kb.ID, long.ID)
+	db.First(&short, short.ID)
+	db.First(&long, long.ID)
+	if short.SummaryError != "" || short.Summary != "" {
+		t.Fatalf("short = %+v", short)
+	}
+	if long.SummaryError == "" || long.Summary != "" {
+		t.Fatalf("long = %+v", long)
+	}
+
+	// 未开启 auto_summary 时不处理
+	db.Model(&models.KnowledgeBaseDocument{}).Where("id = ?", long.ID).Update("summary_error", "")
+	db.Model(&models.KnowledgeBase{}).Where("id = ?", kb.ID).Update("auto_summary", false)
+	summarizeKnowledgeDocumentBestEffort(db, kb.ID, long.ID)
+	db.First(&long, long.ID)
+	if long.SummaryError != "" {
+		t.Fatalf("disabled kb should be skipped: %+v", long)
+	}
+}
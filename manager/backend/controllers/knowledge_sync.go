@@ -2,11 +2,11 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net"
 	"net/http"
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	"gorm.io/gorm"
@@ -48,6 +49,8 @@ type knowledgeProviderSyncResult struct {
 	AutoDataset  bool
 	SyncProvider string
 	LastSyncedAt *time.Time
+	FallbackFrom string // 主 provider 不可用时改用备用 provider 同步，记录原 provider
+	ContentHash  string // 本次同步的正文 sha256，成功后写回知识库
 }
 
 type difyKnowledgeSyncConfig struct {
@@ -56,6 +59,7 @@ type difyKnowledgeSyncConfig struct {
 	DatasetPermission        string
 	DatasetProvider          string
 	DatasetIndexingTechnique string
+	IndexWait                knowledgeIndexWaitConfig
 }
 
 type ragflowKnowledgeSyncConfig struct {
@@ -63,6 +67,7 @@ type ragflowKnowledgeSyncConfig struct {
 	APIKey             string
 	DatasetPermission  string
 	DatasetChunkMethod string
+	IndexWait          knowledgeIndexWaitConfig
 }
 
 type weknoraKnowledgeSyncConfig struct {
@@ -164,8 +169,8 @@ func resolveKnowledgeProviderForKB(db *gorm.DB, kb *models.KnowledgeBase) (strin
 	return resolvedProvider, cfg, providerData, nil
 }
 
-func syncKnowledgeBaseBestEffort(db *gorm.DB, kb *models.KnowledgeBase) error {
-	result, syncErr := syncKnowledgeBaseWithProvider(db, kb)
+func syncKnowledgeBaseBestEffort(ctx context.Context, db *gorm.DB, kb *models.KnowledgeBase) error {
+	result, syncErr := syncKnowledgeBaseWithProvider(ctx, db, kb)
 	persistErr := persistKnowledgeSyncState(db, kb, result, syncErr)
 	if persistErr != nil {
 		if syncErr != nil {
@@ -206,31 +211,51 @@ func syncKnowledgeBaseDeleteBestEffort(db *gorm.DB, kb *models.KnowledgeBase) er
 	}
 }
 
-func syncKnowledgeBaseWithProvider(db *gorm.DB, kb *models.KnowledgeBase) (*knowledgeProviderSyncResult, error) {
+// syncKnowledgeBaseWithProvider ctx 被取消时中止进行中的 provider 请求，返回的错误包装 errKnowledgeSyncCanceled
+func syncKnowledgeBaseWithProvider(ctx context.Context, db *gorm.DB, kb *models.KnowledgeBase) (*knowledgeProviderSyncResult, error) {
 	provider, _, providerData, err := resolveKnowledgeProviderForKB(db, kb)
 	if err != nil {
 		return nil, err
 	}
 
+	result, err := syncKnowledgeBaseToProvider(ctx, db, kb, provider, providerData)
+	err = knowledgeSyncCanceledError(ctx, err)
+	if err != nil && isKnowledgeProviderUnavailableError(err) {
+		result, err = syncKnowledgeBaseWithFallback(ctx, db, kb, provider, providerData, result, err)
+		return result, knowledgeSyncCanceledError(ctx, err)
+	}
+	return result, err
+}
+
+// syncKnowledgeBaseToProvider 将知识库同步到指定 provider，成功时在结果中记录正文哈希
+func syncKnowledgeBaseToProvider(ctx context.Context, db *gorm.DB, kb *models.KnowledgeBase, provider string, providerData map[string]interface{}) (*knowledgeProviderSyncResult, error) {
+	result, err := syncKnowledgeBaseToProviderRaw(ctx, db, kb, provider, providerData)
+	if err == nil && result != nil {
+		result.ContentHash = knowledgeContentHash(kb.Content)
+	}
+	return result, err
+}
+
+func syncKnowledgeBaseToProviderRaw(ctx context.Context, db *gorm.DB, kb *models.KnowledgeBase, provider string, providerData map[string]interface{}) (*knowledgeProviderSyncResult, error) {
 	switch provider {
 	case "dify":
 		difyCfg, err := parseDifyKnowledgeSyncConfig(providerData)
 		if err != nil {
 			return nil, err
 		}
-		return syncKnowledgeBaseToDify(difyCfg, kb)
+		return syncKnowledgeBaseToDify(ctx, difyCfg, kb, knowledgeBoundExternalDocIDs(db, kb, 0))
 	case "ragflow":
 		ragflowCfg, err := parseRagflowKnowledgeSyncConfig(providerData)
 		if err != nil {
 			return nil, err
 		}
-		return syncKnowledgeBaseToRagflow(ragflowCfg, kb)
+		return syncKnowledgeBaseToRagflow(ctx, ragflowCfg, kb, knowledgeBoundExternalDocIDs(db, kb, 0))
 	case "weknora":
 		weknoraCfg, err := parseWeknoraKnowledgeSyncConfig(providerData)
 		if err != nil {
 			return nil, err
 		}
-		return syncKnowledgeBaseToWeknora(weknoraCfg, kb)
+		return syncKnowledgeBaseToWeknora(ctx, weknoraCfg, kb)
 	default:
 		return nil, fmt.Errorf("知识库同步暂不支持provider: %s", provider)
 	}
@@ -246,7 +271,8 @@ func persistKnowledgeSyncState(db *gorm.DB, kb *models.KnowledgeBase, result *kn
 		if strings.TrimSpace(result.DatasetID) != "" {
 			updates["external_kb_id"] = strings.TrimSpace(result.DatasetID)
 		}
-		if strings.TrimSpace(result.DocumentID) != "" {
+		if strings.TrimSpace(result.DocumentID) != "" || result.FallbackFrom != "" {
+			// 切换到备用 provider 后原文档 ID 失效，即使未创建文档也需清除
 			updates["external_doc_id"] = strings.TrimSpace(result.DocumentID)
 		}
 		updates["auto_dataset"] = result.AutoDataset
@@ -260,10 +286,16 @@ func persistKnowledgeSyncState(db *gorm.DB, kb *models.KnowledgeBase, result *kn
 
 	if syncErr != nil {
 		updates["sync_status"] = knowledgeSyncStatusFailed
+		if errors.Is(syncErr, errKnowledgeSyncCanceled) {
+			updates["sync_status"] = knowledgeSyncStatusPending
+		}
 		updates["sync_error"] = truncateSyncError(syncErr.Error())
 	} else {
 		updates["sync_status"] = knowledgeSyncStatusSynced
 		updates["sync_error"] = ""
+		if result != nil {
+			updates["content_hash"] = result.ContentHash
+		}
 		if _, ok := updates["last_synced_at"]; !ok {
 			now := time.Now()
 			updates["last_synced_at"] = &now
@@ -313,6 +345,7 @@ func parseDifyKnowledgeSyncConfig(providerData map[string]interface{}) (*difyKno
 	if v, ok := providerData["dataset_indexing_technique"].(string); ok && strings.TrimSpace(v) != "" {
 		cfg.DatasetIndexingTechnique = strings.TrimSpace(v)
 	}
+	cfg.IndexWait = parseKnowledgeIndexWaitConfig(providerData)
 	return cfg, nil
 }
 
@@ -341,6 +374,7 @@ func parseRagflowKnowledgeSyncConfig(providerData map[string]interface{}) (*ragf
 	if v, ok := providerData["dataset_chunk_method"].(string); ok && strings.TrimSpace(v) != "" {
 		cfg.DatasetChunkMethod = strings.TrimSpace(v)
 	}
+	cfg.IndexWait = parseKnowledgeIndexWaitConfig(providerData)
 	return cfg, nil
 }
 
@@ -414,7 +448,7 @@ func parseWeknoraKnowledgeSyncConfig(providerData map[string]interface{}) (*wekn
 	}, nil
 }
 
-func syncKnowledgeBaseToDify(cfg *difyKnowledgeSyncConfig, kb *models.KnowledgeBase) (*knowledgeProviderSyncResult, error) {
+func syncKnowledgeBaseToDify(ctx context.Context, cfg *difyKnowledgeSyncConfig, kb *models.KnowledgeBase, boundDocIDs map[string]bool) (*knowledgeProviderSyncResult, error) {
 	if kb == nil {
 		return nil, fmt.Errorf("知识库数据为空")
 	}
@@ -425,7 +459,7 @@ func syncKnowledgeBaseToDify(cfg *difyKnowledgeSyncConfig, kb *models.KnowledgeB
 		AutoDataset:  kb.AutoDataset,
 		SyncProvider: "dify",
 	}
-	client := &http.Client{Timeout: difyHTTPTimeout}
+	client := newKnowledgeSyncHTTPClientContext(ctx, kb.ID, "dify", difyHTTPTimeout)
 
 	if result.DatasetID == "" {
 		datasetID, err := createDifyDataset(client, cfg, kb)
@@ -443,18 +477,41 @@ func syncKnowledgeBaseToDify(cfg *difyKnowledgeSyncConfig, kb *models.KnowledgeB
 		return result, nil
 	}
 
+	// 正文未变化：跳过文档重新同步，仅更新 dataset 元数据
+	if knowledgeContentUnchanged(kb, result) {
+		if result.AutoDataset {
+			if err := updateDifyDatasetMetadata(client, cfg, result.DatasetID, kb); err != nil {
+				logger.Warnf("[KnowledgeSync][Dify] update dataset metadata failed kb_id=%d dataset_id=%s err=%v", kb.ID, result.DatasetID, err)
+			}
+		}
+		now := time.Now()
+		result.LastSyncedAt = &now
+		return result, nil
+	}
+
 	if result.DocumentID == "" {
-		docID, err := createDifyDocumentByText(client, cfg, result.DatasetID, kb)
-		if err != nil {
-			return result, err
+		// 重试时复用上次已创建但未记录的文档，并以当前内容覆盖
+		if docID := findReusableDifyDocument(client, cfg, result.DatasetID, buildAutoDocumentName(kb), boundDocIDs); docID != "" {
+			result.DocumentID = docID
+			if err := updateDifyDocumentByText(client, cfg, result.DatasetID, docID, kb); err != nil {
+				return result, err
+			}
+		} else {
+			docID, err := createDifyDocumentByText(client, cfg, result.DatasetID, kb)
+			if err != nil {
+				return result, err
+			}
+			result.DocumentID = docID
 		}
-		result.DocumentID = docID
 	} else {
 		if err := updateDifyDocumentByText(client, cfg, result.DatasetID, result.DocumentID, kb); err != nil {
 			return result, err
 		}
 	}
 
+	if err := waitDifyDocumentIndexed(kb.ID, client, cfg, result.DatasetID, result.DocumentID); err != nil {
+		return result, err
+	}
 	now := time.Now()
 	result.LastSyncedAt = &now
 	return result, nil
@@ -470,7 +527,7 @@ func deleteKnowledgeBaseFromDify(cfg *difyKnowledgeSyncConfig, kb *models.Knowle
 		return nil
 	}
 
-	client := &http.Client{Timeout: difyHTTPTimeout}
+	client := newKnowledgeSyncHTTPClient(kb.ID, "dify", difyHTTPTimeout)
 
 	if documentID != "" {
 		if err := deleteDifyDocument(client, cfg, datasetID, documentID); err != nil {
@@ -494,7 +551,7 @@ func deleteKnowledgeBaseFromDify(cfg *difyKnowledgeSyncConfig, kb *models.Knowle
 	return nil
 }
 
-func syncKnowledgeBaseToRagflow(cfg *ragflowKnowledgeSyncConfig, kb *models.KnowledgeBase) (*knowledgeProviderSyncResult, error) {
+func syncKnowledgeBaseToRagflow(ctx context.Context, cfg *ragflowKnowledgeSyncConfig, kb *models.KnowledgeBase, boundDocIDs map[string]bool) (*knowledgeProviderSyncResult, error) {
 	if kb == nil {
 		return nil, fmt.Errorf("知识库数据为空")
 	}
@@ -505,7 +562,7 @@ func syncKnowledgeBaseToRagflow(cfg *ragflowKnowledgeSyncConfig, kb *models.Know
 		AutoDataset:  kb.AutoDataset,
 		SyncProvider: "ragflow",
 	}
-	client := &http.Client{Timeout: 20 * time.Second}
+	client := newKnowledgeSyncHTTPClientContext(ctx, kb.ID, "ragflow", 20*time.Second)
 
 	if result.DatasetID == "" {
 		datasetID, err := createRagflowDataset(client, cfg, kb)
@@ -523,12 +580,33 @@ func syncKnowledgeBaseToRagflow(cfg *ragflowKnowledgeSyncConfig, kb *models.Know
 		return result, nil
 	}
 
+	// 正文未变化：跳过文档重新同步，仅更新 dataset 元数据
+	if knowledgeContentUnchanged(kb, result) {
+		if result.AutoDataset {
+			if err := updateRagflowDatasetMetadata(client, cfg, result.DatasetID, kb); err != nil {
+				logger.Warnf("[KnowledgeSync][Ragflow] update dataset metadata failed kb_id=%d dataset_id=%s err=%v", kb.ID, result.DatasetID, err)
+			}
+		}
+		now := time.Now()
+		result.LastSyncedAt = &now
+		return result, nil
+	}
+
 	if result.DocumentID == "" {
-		docID, err := createAndParseRagflowDocumentByText(client, cfg, result.DatasetID, buildAutoDocumentName(kb), kb.Content)
-		if err != nil {
-			return result, err
+		fileName := buildRagflowUploadFileNameForText(buildAutoDocumentName(kb))
+		docID := findReusableRagflowDocument(client, cfg, result.DatasetID, fileName, int64(len(kb.Content)), boundDocIDs)
+		if docID == "" {
+			var err error
+			docID, err = uploadRagflowDocumentByBytes(client, cfg, result.DatasetID, fileName, []byte(kb.Content))
+			if err != nil {
+				return result, err
+			}
 		}
+		// 上传成功即记录文档ID，解析失败后重试不会重复上传
 		result.DocumentID = docID
+		if err := parseRagflowDocuments(client, cfg, result.DatasetID, []string{docID}); err != nil {
+			return result, err
+		}
 	} else {
 		newDocID, err := replaceRagflowDocumentByText(client, cfg, result.DatasetID, result.DocumentID, buildAutoDocumentName(kb), kb.Content)
 		if err != nil {
@@ -537,6 +615,9 @@ func syncKnowledgeBaseToRagflow(cfg *ragflowKnowledgeSyncConfig, kb *models.Know
 		result.DocumentID = newDocID
 	}
 
+	if err := waitRagflowDocumentIndexed(kb.ID, client, cfg, result.DatasetID, result.DocumentID); err != nil {
+		return result, err
+	}
 	now := time.Now()
 	result.LastSyncedAt = &now
 	return result, nil
@@ -552,7 +633,7 @@ func deleteKnowledgeBaseFromRagflow(cfg *ragflowKnowledgeSyncConfig, kb *models.
 		return nil
 	}
 
-	client := &http.Client{Timeout: 20 * time.Second}
+	client := newKnowledgeSyncHTTPClient(kb.ID, "ragflow", 20*time.Second)
 
 	if documentID != "" {
 		if err := deleteRagflowDocument(client, cfg, datasetID, documentID); err != nil {
@@ -576,7 +657,7 @@ func deleteKnowledgeBaseFromRagflow(cfg *ragflowKnowledgeSyncConfig, kb *models.
 	return nil
 }
 
-func syncKnowledgeBaseToWeknora(cfg *weknoraKnowledgeSyncConfig, kb *models.KnowledgeBase) (*knowledgeProviderSyncResult, error) {
+func syncKnowledgeBaseToWeknora(ctx context.Context, cfg *weknoraKnowledgeSyncConfig, kb *models.KnowledgeBase) (*knowledgeProviderSyncResult, error) {
 	if kb == nil {
 		return nil, fmt.Errorf("知识库数据为空")
 	}
@@ -587,7 +668,7 @@ func syncKnowledgeBaseToWeknora(cfg *weknoraKnowledgeSyncConfig, kb *models.Know
 		AutoDataset:  kb.AutoDataset,
 		SyncProvider: "weknora",
 	}
-	client := &http.Client{Timeout: weknoraHTTPTimeout}
+	client := newKnowledgeSyncHTTPClientContext(ctx, kb.ID, "weknora", weknoraHTTPTimeout)
 
 	if result.DatasetID == "" {
 		datasetID, err := createWeknoraKnowledgeBase(client, cfg, kb)
@@ -609,6 +690,13 @@ func syncKnowledgeBaseToWeknora(cfg *weknoraKnowledgeSyncConfig, kb *models.Know
 		return result, nil
 	}
 
+	// 正文未变化：知识库元数据已在上面更新，跳过文档重新同步
+	if knowledgeContentUnchanged(kb, result) {
+		now := time.Now()
+		result.LastSyncedAt = &now
+		return result, nil
+	}
+
 	if result.DocumentID == "" {
 		documentID, err := createWeknoraKnowledgeByText(client, cfg, result.DatasetID, buildAutoDocumentName(kb), kb.Content)
 		if err != nil {
@@ -623,7 +711,15 @@ func syncKnowledgeBaseToWeknora(cfg *weknoraKnowledgeSyncConfig, kb *models.Know
 		result.DocumentID = documentID
 	}
 
-	if err := waitWeknoraKnowledgeParsed(client, cfg, result.DocumentID); err != nil {
+	// 本次同步指定了更长的超时时间时，同样放宽解析等待
+	if override := knowledgeSyncTimeout(ctx, 0); override > cfg.ParseTimeout {
+		parseCfg := *cfg
+		parseCfg.ParseTimeout = override
+		cfg = &parseCfg
+	}
+	waitCtx, done := beginKnowledgeSyncCancelable(kb.ID)
+	defer done()
+	if err := waitWeknoraKnowledgeParsed(waitCtx, client, cfg, result.DocumentID); err != nil {
 		return result, err
 	}
 	now := time.Now()
@@ -641,7 +737,7 @@ func deleteKnowledgeBaseFromWeknora(cfg *weknoraKnowledgeSyncConfig, kb *models.
 		return nil
 	}
 
-	client := &http.Client{Timeout: weknoraHTTPTimeout}
+	client := newKnowledgeSyncHTTPClient(kb.ID, "weknora", weknoraHTTPTimeout)
 	if documentID != "" {
 		if err := deleteWeknoraKnowledge(client, cfg, documentID); err != nil {
 			return err
@@ -684,7 +780,7 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 
 	persistBestEffort := func(externalDocID, status string, syncErr error) {
 		if err := persistKnowledgeDocumentSyncState(db, &doc, externalDocID, status, syncErr); err != nil {
-			log.Printf(
+			logger.Errorf(
 				"[KnowledgeSync][Doc] persist status failed kb_id=%d doc_id=%d status=%s external_doc_id=%s err=%v",
 				kbID,
 				docID,
@@ -731,7 +827,7 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 		if isUploadFile {
 			clientTimeout = difyFileUploadHTTPTimeout
 		}
-		client := &http.Client{Timeout: clientTimeout}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "dify", clientTimeout)
 		datasetID, err := ensureDifyDatasetForKnowledgeBase(db, &kb, client, difyCfg)
 		if err != nil {
 			return failUpload("", err)
@@ -740,9 +836,13 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 		documentID := strings.TrimSpace(doc.ExternalDocID)
 		if isUploadFile {
 			if documentID == "" {
-				documentID, err = createDifyDocumentByFile(client, difyCfg, datasetID, uploadFileName, uploadFileData)
-				if err != nil {
-					return failUpload(strings.TrimSpace(doc.ExternalDocID), err)
+				// 重试时复用上次已创建但未记录的文档
+				documentID = findReusableDifyDocument(client, difyCfg, datasetID, sanitizeKnowledgeUploadFileName(uploadFileName), knowledgeBoundExternalDocIDs(db, &kb, doc.ID))
+				if documentID == "" {
+					documentID, err = createDifyDocumentByFile(client, difyCfg, datasetID, uploadFileName, uploadFileData)
+					if err != nil {
+						return failUpload(strings.TrimSpace(doc.ExternalDocID), err)
+					}
 				}
 			} else {
 				documentID, err = replaceDifyDocumentByFile(client, difyCfg, datasetID, documentID, uploadFileName, uploadFileData)
@@ -755,6 +855,10 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 				err := fmt.Errorf("文档内容为空，无法同步")
 				return failUpload(strings.TrimSpace(doc.ExternalDocID), err)
 			}
+			if documentID == "" {
+				// 重试时复用上次已创建但未记录的文档，随后按当前内容更新
+				documentID = findReusableDifyDocument(client, difyCfg, datasetID, buildAutoDocumentName(&models.KnowledgeBase{ID: kb.ID, Name: doc.Name}), knowledgeBoundExternalDocIDs(db, &kb, doc.ID))
+			}
 			if documentID == "" {
 				documentID, err = createDifyDocumentByText(client, difyCfg, datasetID, &models.KnowledgeBase{
 					ID:      kb.ID,
@@ -774,8 +878,18 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 				}
 			}
 		}
+		if err := applyDifyDocumentMetadata(client, difyCfg, datasetID, documentID, decodeKnowledgeDocumentMetadata(doc.MetadataJSON)); err != nil {
+			return failUpload(documentID, err)
+		}
 		markProgress(documentID, knowledgeSyncStatusUploaded)
 		markProgress(documentID, knowledgeSyncStatusParsing)
+		if err := waitDifyDocumentIndexed(kb.ID, client, difyCfg, datasetID, documentID); err != nil {
+			if errors.Is(err, errKnowledgeSyncCanceled) {
+				persistBestEffort(documentID, knowledgeSyncStatusPending, err)
+				return err
+			}
+			return failParse(documentID, err)
+		}
 		return syncSuccess(documentID)
 
 	case "ragflow":
@@ -789,22 +903,24 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 			return failUpload(strings.TrimSpace(doc.ExternalDocID), err)
 		}
 
-		client := &http.Client{Timeout: 20 * time.Second}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "ragflow", 20*time.Second)
 		datasetID, err := ensureRagflowDatasetForKnowledgeBase(db, &kb, client, ragflowCfg)
 		if err != nil {
 			return failUpload(strings.TrimSpace(doc.ExternalDocID), err)
 		}
 
 		oldDocumentID := strings.TrimSpace(doc.ExternalDocID)
-		documentID := oldDocumentID
-		if isUploadFile {
-			documentID, err = uploadRagflowDocumentByBytes(client, ragflowCfg, datasetID, uploadFileName, uploadFileData)
-			if err != nil {
-				return failUpload(oldDocumentID, err)
-			}
-		} else {
-			fileName := buildRagflowUploadFileNameForText(doc.Name)
-			documentID, err = uploadRagflowDocumentByBytes(client, ragflowCfg, datasetID, fileName, []byte(doc.Content))
+		fileName, fileData := uploadFileName, uploadFileData
+		if !isUploadFile {
+			fileName, fileData = buildRagflowUploadFileNameForText(doc.Name), []byte(doc.Content)
+		}
+		documentID := ""
+		if oldDocumentID == "" {
+			// 首次同步重试时复用上次已上传但未记录的文档
+			documentID = findReusableRagflowDocument(client, ragflowCfg, datasetID, sanitizeKnowledgeUploadFileName(fileName), int64(len(fileData)), knowledgeBoundExternalDocIDs(db, &kb, doc.ID))
+		}
+		if documentID == "" {
+			documentID, err = uploadRagflowDocumentByBytes(client, ragflowCfg, datasetID, fileName, fileData)
 			if err != nil {
 				return failUpload(oldDocumentID, err)
 			}
@@ -814,9 +930,16 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 		if err := parseRagflowDocuments(client, ragflowCfg, datasetID, []string{documentID}); err != nil {
 			return failParse(documentID, err)
 		}
+		if err := waitRagflowDocumentIndexed(kb.ID, client, ragflowCfg, datasetID, documentID); err != nil {
+			if errors.Is(err, errKnowledgeSyncCanceled) {
+				persistBestEffort(documentID, knowledgeSyncStatusPending, err)
+				return err
+			}
+			return failParse(documentID, err)
+		}
 		if oldDocumentID != "" && oldDocumentID != documentID {
 			if err := deleteRagflowDocument(client, ragflowCfg, datasetID, oldDocumentID); err != nil {
-				log.Printf("[KnowledgeSync][Ragflow] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
+				logger.Warnf("[KnowledgeSync][Ragflow] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
 			}
 		}
 		return syncSuccess(documentID)
@@ -836,7 +959,7 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 		if isUploadFile {
 			clientTimeout = weknoraFileUploadHTTPTimeout
 		}
-		client := &http.Client{Timeout: clientTimeout}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "weknora", clientTimeout)
 		datasetID, err := ensureWeknoraDatasetForKnowledgeBase(db, &kb, client, weknoraCfg)
 		if err != nil {
 			return failUpload(strings.TrimSpace(doc.ExternalDocID), err)
@@ -844,25 +967,28 @@ func syncKnowledgeDocumentBestEffort(db *gorm.DB, kbID, docID uint) error {
 
 		oldDocumentID := strings.TrimSpace(doc.ExternalDocID)
 		documentID := oldDocumentID
-		if isUploadFile {
-			documentID, err = createWeknoraKnowledgeByFile(client, weknoraCfg, datasetID, uploadFileName, uploadFileData)
-			if err != nil {
-				return failUpload(oldDocumentID, err)
-			}
-		} else {
-			documentID, err = createWeknoraKnowledgeByText(client, weknoraCfg, datasetID, doc.Name, doc.Content)
-			if err != nil {
-				return failUpload(oldDocumentID, err)
-			}
+		fileName, fileData := uploadFileName, uploadFileData
+		if !isUploadFile {
+			fileName, fileData = buildWeknoraUploadFileNameForText(doc.Name), []byte(doc.Content)
+		}
+		documentID, err = createWeknoraKnowledgeByFileWithMetadata(client, weknoraCfg, datasetID, fileName, fileData, doc.MetadataJSON)
+		if err != nil {
+			return failUpload(oldDocumentID, err)
 		}
 		markProgress(documentID, knowledgeSyncStatusUploaded)
 		markProgress(documentID, knowledgeSyncStatusParsing)
-		if err := waitWeknoraKnowledgeParsed(client, weknoraCfg, documentID); err != nil {
+		waitCtx, done := beginKnowledgeSyncCancelable(kb.ID)
+		defer done()
+		if err := waitWeknoraKnowledgeParsed(waitCtx, client, weknoraCfg, documentID); err != nil {
+			if errors.Is(err, errKnowledgeSyncCanceled) {
+				persistBestEffort(documentID, knowledgeSyncStatusPending, err)
+				return err
+			}
 			return failParse(documentID, err)
 		}
 		if oldDocumentID != "" && oldDocumentID != documentID {
 			if err := deleteWeknoraKnowledge(client, weknoraCfg, oldDocumentID); err != nil {
-				log.Printf("[KnowledgeSync][Weknora] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
+				logger.Warnf("[KnowledgeSync][Weknora] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
 			}
 		}
 		if err := syncSuccess(documentID); err != nil {
@@ -895,6 +1021,11 @@ func syncKnowledgeDocumentDeleteBestEffort(db *gorm.DB, kb models.KnowledgeBase,
 	if datasetID == "" {
 		return nil
 	}
+	if summaryDocID := strings.TrimSpace(doc.SummaryExternalDocID); summaryDocID != "" {
+		if err := deleteKnowledgeProviderDocument(&kb, provider, providerData, summaryDocID); err != nil {
+			logger.Warnf("[KnowledgeSync][Summary] delete summary document warning kb_id=%d doc_id=%d summary_document_id=%s err=%v", kb.ID, doc.ID, summaryDocID, err)
+		}
+	}
 
 	switch provider {
 	case "dify":
@@ -902,7 +1033,7 @@ func syncKnowledgeDocumentDeleteBestEffort(db *gorm.DB, kb models.KnowledgeBase,
 		if err != nil {
 			return err
 		}
-		client := &http.Client{Timeout: difyHTTPTimeout}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "dify", difyHTTPTimeout)
 		if docID := strings.TrimSpace(doc.ExternalDocID); docID != "" {
 			if err := deleteDifyDocument(client, difyCfg, datasetID, docID); err != nil {
 				return err
@@ -935,7 +1066,7 @@ func syncKnowledgeDocumentDeleteBestEffort(db *gorm.DB, kb models.KnowledgeBase,
 		if err != nil {
 			return err
 		}
-		client := &http.Client{Timeout: 20 * time.Second}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "ragflow", 20*time.Second)
 		if docID := strings.TrimSpace(doc.ExternalDocID); docID != "" {
 			if err := deleteRagflowDocument(client, ragflowCfg, datasetID, docID); err != nil {
 				return err
@@ -967,7 +1098,7 @@ func syncKnowledgeDocumentDeleteBestEffort(db *gorm.DB, kb models.KnowledgeBase,
 		if err != nil {
 			return err
 		}
-		client := &http.Client{Timeout: weknoraHTTPTimeout}
+		client := newKnowledgeSyncHTTPClient(kb.ID, "weknora", weknoraHTTPTimeout)
 		if docID := strings.TrimSpace(doc.ExternalDocID); docID != "" {
 			if err := deleteWeknoraKnowledge(client, weknoraCfg, docID); err != nil {
 				return err
@@ -1318,7 +1449,8 @@ func createDifyDocumentByFile(client *http.Client, cfg *difyKnowledgeSyncConfig,
 				return "", fmt.Errorf("创建Dify文件文档失败(dataset_id=%s): %w", datasetID, err)
 			}
 			waitDuration := time.Duration(attempt) * difyFileUploadRetryStep
-			log.Printf(
+			knowledgeSyncMetrics.incSync("dify", string(knowledgeSyncJobDocUpsert), knowledgeSyncOutcomeRetry)
+			logger.Warnf(
 				"[KnowledgeSync][Dify] create-by-file retry dataset_id=%s attempt=%d/%d wait_ms=%d err=%v",
 				datasetID,
 				attempt,
@@ -1357,7 +1489,7 @@ func replaceDifyDocumentByFile(client *http.Client, cfg *difyKnowledgeSyncConfig
 	oldDocumentID = strings.TrimSpace(oldDocumentID)
 	if oldDocumentID != "" && oldDocumentID != newDocumentID {
 		if err := deleteDifyDocument(client, cfg, datasetID, oldDocumentID); err != nil {
-			log.Printf("[KnowledgeSync][Dify] delete old file document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
+			logger.Warnf("[KnowledgeSync][Dify] delete old file document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
 		}
 	}
 	return newDocumentID, nil
@@ -1489,7 +1621,7 @@ func replaceRagflowDocumentByText(client *http.Client, cfg *ragflowKnowledgeSync
 	oldDocumentID = strings.TrimSpace(oldDocumentID)
 	if oldDocumentID != "" && oldDocumentID != newDocumentID {
 		if err := deleteRagflowDocument(client, cfg, datasetID, oldDocumentID); err != nil {
-			log.Printf("[KnowledgeSync][Ragflow] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
+			logger.Warnf("[KnowledgeSync][Ragflow] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
 		}
 	}
 	return newDocumentID, nil
@@ -1529,7 +1661,7 @@ func replaceRagflowDocumentByFile(client *http.Client, cfg *ragflowKnowledgeSync
 	oldDocumentID = strings.TrimSpace(oldDocumentID)
 	if oldDocumentID != "" && oldDocumentID != newDocumentID {
 		if err := deleteRagflowDocument(client, cfg, datasetID, oldDocumentID); err != nil {
-			log.Printf("[KnowledgeSync][Ragflow] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
+			logger.Warnf("[KnowledgeSync][Ragflow] delete old document warning dataset_id=%s old_document_id=%s err=%v", datasetID, oldDocumentID, err)
 		}
 	}
 	return newDocumentID, nil
@@ -1701,7 +1833,7 @@ func updateWeknoraKnowledgeBase(client *http.Client, cfg *weknoraKnowledgeSyncCo
 		legacyPayload := buildWeknoraKnowledgeBasePayload(cfg, kb)
 		legacyStatus, legacyBody, legacyErr := doWeknoraJSONRequest(client, http.MethodPut, endpoint, cfg.APIKey, legacyPayload, nil)
 		if legacyErr == nil {
-			log.Printf(
+			logger.Infof(
 				"[KnowledgeSync][Weknora] Update fallback succeeded knowledge_base_id=%s primary_status=%d primary_body=%s",
 				kbID,
 				status,
@@ -1733,7 +1865,7 @@ func deleteWeknoraKnowledgeBase(client *http.Client, cfg *weknoraKnowledgeSyncCo
 	return nil
 }
 
-func createWeknoraKnowledgeByText(client *http.Client, cfg *weknoraKnowledgeSyncConfig, kbID, name, content string) (string, error) {
+func buildWeknoraUploadFileNameForText(name string) string {
 	fileName := sanitizeKnowledgeUploadFileName(strings.TrimSpace(name))
 	if fileName == "" {
 		fileName = "document.md"
@@ -1741,7 +1873,11 @@ func createWeknoraKnowledgeByText(client *http.Client, cfg *weknoraKnowledgeSync
 	if filepath.Ext(fileName) == "" {
 		fileName = fileName + ".md"
 	}
-	return createWeknoraKnowledgeByFile(client, cfg, kbID, fileName, []byte(content))
+	return fileName
+}
+
+func createWeknoraKnowledgeByText(client *http.Client, cfg *weknoraKnowledgeSyncConfig, kbID, name, content string) (string, error) {
+	return createWeknoraKnowledgeByFile(client, cfg, kbID, buildWeknoraUploadFileNameForText(name), []byte(content))
 }
 
 func replaceWeknoraKnowledgeByText(client *http.Client, cfg *weknoraKnowledgeSyncConfig, kbID, oldKnowledgeID, name, content string) (string, error) {
@@ -1752,13 +1888,18 @@ func replaceWeknoraKnowledgeByText(client *http.Client, cfg *weknoraKnowledgeSyn
 	oldKnowledgeID = strings.TrimSpace(oldKnowledgeID)
 	if oldKnowledgeID != "" && oldKnowledgeID != newKnowledgeID {
 		if err := deleteWeknoraKnowledge(client, cfg, oldKnowledgeID); err != nil {
-			log.Printf("[KnowledgeSync][Weknora] delete old text document warning knowledge_base_id=%s old_knowledge_id=%s err=%v", kbID, oldKnowledgeID, err)
+			logger.Warnf("[KnowledgeSync][Weknora] delete old text document warning knowledge_base_id=%s old_knowledge_id=%s err=%v", kbID, oldKnowledgeID, err)
 		}
 	}
 	return newKnowledgeID, nil
 }
 
 func createWeknoraKnowledgeByFile(client *http.Client, cfg *weknoraKnowledgeSyncConfig, kbID, fileName string, fileData []byte) (string, error) {
+	return createWeknoraKnowledgeByFileWithMetadata(client, cfg, kbID, fileName, fileData, "")
+}
+
+// createWeknoraKnowledgeByFileWithMetadata 上传文件创建文档，metadataJSON 非空时随表单字段 metadata 一并提交
+func createWeknoraKnowledgeByFileWithMetadata(client *http.Client, cfg *weknoraKnowledgeSyncConfig, kbID, fileName string, fileData []byte, metadataJSON string) (string, error) {
 	kbID = strings.TrimSpace(kbID)
 	if kbID == "" {
 		return "", fmt.Errorf("weknora知识库id为空")
@@ -1771,6 +1912,9 @@ func createWeknoraKnowledgeByFile(client *http.Client, cfg *weknoraKnowledgeSync
 	fields := map[string]string{
 		"enable_multimodel": strconv.FormatBool(cfg.EnableMultimodal),
 	}
+	if metadataJSON = strings.TrimSpace(metadataJSON); metadataJSON != "" {
+		fields["metadata"] = metadataJSON
+	}
 	var resp struct {
 		Data struct {
 			ID string `json:"id"`
@@ -1840,7 +1984,7 @@ func getWeknoraKnowledgeParseStatus(client *http.Client, cfg *weknoraKnowledgeSy
 	return status, errMsg, nil
 }
 
-func waitWeknoraKnowledgeParsed(client *http.Client, cfg *weknoraKnowledgeSyncConfig, knowledgeID string) error {
+func waitWeknoraKnowledgeParsed(ctx context.Context, client *http.Client, cfg *weknoraKnowledgeSyncConfig, knowledgeID string) error {
 	knowledgeID = strings.TrimSpace(knowledgeID)
 	if knowledgeID == "" {
 		return fmt.Errorf("weknora文档id为空")
@@ -1853,7 +1997,8 @@ func waitWeknoraKnowledgeParsed(client *http.Client, cfg *weknoraKnowledgeSyncCo
 	if interval <= 0 {
 		interval = defaultWeknoraParsePollInterval
 	}
-	deadline := time.Now().Add(timeout)
+	startedAt := time.Now()
+	deadline := startedAt.Add(timeout)
 	for {
 		status, errMsg, err := getWeknoraKnowledgeParseStatus(client, cfg, knowledgeID)
 		if err != nil {
@@ -1861,6 +2006,7 @@ func waitWeknoraKnowledgeParsed(client *http.Client, cfg *weknoraKnowledgeSyncCo
 		}
 		switch status {
 		case "completed":
+			knowledgeSyncMetrics.observeParse("weknora", time.Since(startedAt))
 			return nil
 		case "failed":
 			if errMsg == "" {
@@ -1871,12 +2017,15 @@ func waitWeknoraKnowledgeParsed(client *http.Client, cfg *weknoraKnowledgeSyncCo
 			if time.Now().After(deadline) {
 				return fmt.Errorf("等待Weknora文档解析超时(knowledge_id=%s timeout_ms=%d)", knowledgeID, timeout.Milliseconds())
 			}
-			time.Sleep(interval)
 		default:
 			if time.Now().After(deadline) {
 				return fmt.Errorf("等待Weknora文档解析超时(knowledge_id=%s status=%s timeout_ms=%d)", knowledgeID, status, timeout.Milliseconds())
 			}
-			time.Sleep(interval)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待Weknora文档解析已中止(knowledge_id=%s): %w", knowledgeID, errKnowledgeSyncCanceled)
+		case <-time.After(interval):
 		}
 	}
 }
@@ -2019,7 +2168,7 @@ func doWeknoraJSONRequest(client *http.Client, method, endpoint, apiKey string,
 		payloadBytes = payloadBytesLocal
 		bodyReader = bytes.NewReader(payloadBytes)
 	}
-	log.Printf("[KnowledgeSync][Weknora] Request method=%s url=%s payload=%s", method, endpoint, serializePayloadForLog(payloadBytes))
+	logger.Debugf("[KnowledgeSync][Weknora] Request method=%s url=%s payload=%s", method, endpoint, serializePayloadForLog(payloadBytes))
 
 	startAt := time.Now()
 	req, err := http.NewRequest(method, endpoint, bodyReader)
@@ -2033,13 +2182,13 @@ func doWeknoraJSONRequest(client *http.Client, method, endpoint, apiKey string,
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[KnowledgeSync][Weknora] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
+		logger.Warnf("[KnowledgeSync][Weknora] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Weknora] Response method=%s url=%s status=%d elapsed_ms=%d body=%s",
 		method,
 		endpoint,
@@ -2080,7 +2229,7 @@ func doWeknoraJSONRequest(client *http.Client, method, endpoint, apiKey string,
 
 	if out != nil && len(bodyBytes) > 0 {
 		if err := json.Unmarshal(bodyBytes, out); err != nil {
-			log.Printf("解析Weknora响应失败: %v, body: %s", err, string(bodyBytes))
+			logger.Warnf("解析Weknora响应失败: %v, body: %s", err, string(bodyBytes))
 			return resp.StatusCode, bodyBytes, fmt.Errorf("解析响应失败: %w", err)
 		}
 	}
@@ -2114,7 +2263,7 @@ func doWeknoraMultipartFileRequest(
 	}
 
 	fieldsBytes, _ := json.Marshal(fields)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Weknora] Request method=%s url=%s multipart_file=%s size=%d fields=%s",
 		method,
 		endpoint,
@@ -2132,13 +2281,13 @@ func doWeknoraMultipartFileRequest(
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[KnowledgeSync][Weknora] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
+		logger.Warnf("[KnowledgeSync][Weknora] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Weknora] Response method=%s url=%s status=%d elapsed_ms=%d body=%s",
 		method,
 		endpoint,
@@ -2179,7 +2328,7 @@ func doWeknoraMultipartFileRequest(
 
 	if out != nil && len(bodyBytes) > 0 {
 		if err := json.Unmarshal(bodyBytes, out); err != nil {
-			log.Printf("解析Weknora响应失败: %v, body: %s", err, string(bodyBytes))
+			logger.Warnf("解析Weknora响应失败: %v, body: %s", err, string(bodyBytes))
 			return resp.StatusCode, bodyBytes, fmt.Errorf("解析响应失败: %w", err)
 		}
 	}
@@ -2197,7 +2346,7 @@ func doRagflowJSONRequest(client *http.Client, method, endpoint, apiKey string,
 		payloadBytes = payloadBytesLocal
 		bodyReader = bytes.NewReader(payloadBytes)
 	}
-	log.Printf("[KnowledgeSync][Ragflow] Request method=%s url=%s payload=%s", method, endpoint, serializePayloadForLog(payloadBytes))
+	logger.Debugf("[KnowledgeSync][Ragflow] Request method=%s url=%s payload=%s", method, endpoint, serializePayloadForLog(payloadBytes))
 
 	startAt := time.Now()
 	req, err := http.NewRequest(method, endpoint, bodyReader)
@@ -2211,13 +2360,13 @@ func doRagflowJSONRequest(client *http.Client, method, endpoint, apiKey string,
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[KnowledgeSync][Ragflow] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
+		logger.Warnf("[KnowledgeSync][Ragflow] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Ragflow] Response method=%s url=%s status=%d elapsed_ms=%d body=%s",
 		method,
 		endpoint,
@@ -2243,7 +2392,7 @@ func doRagflowJSONRequest(client *http.Client, method, endpoint, apiKey string,
 
 	if out != nil && len(bodyBytes) > 0 {
 		if err := json.Unmarshal(bodyBytes, out); err != nil {
-			log.Printf("解析RAGFlow响应失败: %v, body: %s", err, string(bodyBytes))
+			logger.Warnf("解析RAGFlow响应失败: %v, body: %s", err, string(bodyBytes))
 			return resp.StatusCode, bodyBytes, fmt.Errorf("解析响应失败: %w", err)
 		}
 	}
@@ -2276,7 +2425,7 @@ func doRagflowMultipartFileRequest(
 		return 0, nil, fmt.Errorf("关闭表单写入器失败: %w", err)
 	}
 
-	log.Printf("[KnowledgeSync][Ragflow] Request method=%s url=%s multipart_file=%s size=%d", method, endpoint, fileName, len(fileContent))
+	logger.Debugf("[KnowledgeSync][Ragflow] Request method=%s url=%s multipart_file=%s size=%d", method, endpoint, fileName, len(fileContent))
 	startAt := time.Now()
 	req, err := http.NewRequest(method, endpoint, &body)
 	if err != nil {
@@ -2287,13 +2436,13 @@ func doRagflowMultipartFileRequest(
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[KnowledgeSync][Ragflow] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
+		logger.Warnf("[KnowledgeSync][Ragflow] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Ragflow] Response method=%s url=%s status=%d elapsed_ms=%d body=%s",
 		method,
 		endpoint,
@@ -2319,7 +2468,7 @@ func doRagflowMultipartFileRequest(
 
 	if out != nil && len(bodyBytes) > 0 {
 		if err := json.Unmarshal(bodyBytes, out); err != nil {
-			log.Printf("解析RAGFlow响应失败: %v, body: %s", err, string(bodyBytes))
+			logger.Warnf("解析RAGFlow响应失败: %v, body: %s", err, string(bodyBytes))
 			return resp.StatusCode, bodyBytes, fmt.Errorf("解析响应失败: %w", err)
 		}
 	}
@@ -2337,7 +2486,7 @@ func doDifyJSONRequest(client *http.Client, method, endpoint, apiKey string, pay
 		payloadBytes = payloadBytesLocal
 		bodyReader = bytes.NewReader(payloadBytes)
 	}
-	log.Printf("[KnowledgeSync][Dify] Request method=%s url=%s payload=%s", method, endpoint, serializePayloadForLog(payloadBytes))
+	logger.Debugf("[KnowledgeSync][Dify] Request method=%s url=%s payload=%s", method, endpoint, serializePayloadForLog(payloadBytes))
 
 	startAt := time.Now()
 	req, err := http.NewRequest(method, endpoint, bodyReader)
@@ -2351,13 +2500,13 @@ func doDifyJSONRequest(client *http.Client, method, endpoint, apiKey string, pay
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[KnowledgeSync][Dify] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
+		logger.Warnf("[KnowledgeSync][Dify] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Dify] Response method=%s url=%s status=%d elapsed_ms=%d body=%s",
 		method,
 		endpoint,
@@ -2371,7 +2520,7 @@ func doDifyJSONRequest(client *http.Client, method, endpoint, apiKey string, pay
 
 	if out != nil && len(bodyBytes) > 0 {
 		if err := json.Unmarshal(bodyBytes, out); err != nil {
-			log.Printf("解析Dify响应失败: %v, body: %s", err, string(bodyBytes))
+			logger.Warnf("解析Dify响应失败: %v, body: %s", err, string(bodyBytes))
 			return resp.StatusCode, bodyBytes, fmt.Errorf("解析响应失败: %w", err)
 		}
 	}
@@ -2405,7 +2554,7 @@ func doDifyMultipartFileRequest(
 	}
 
 	fieldsBytes, _ := json.Marshal(fields)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Dify] Request method=%s url=%s multipart_file=%s size=%d fields=%s",
 		method,
 		endpoint,
@@ -2423,13 +2572,13 @@ func doDifyMultipartFileRequest(
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[KnowledgeSync][Dify] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
+		logger.Warnf("[KnowledgeSync][Dify] Response method=%s url=%s elapsed_ms=%d error=%v", method, endpoint, time.Since(startAt).Milliseconds(), err)
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	log.Printf(
+	logger.Debugf(
 		"[KnowledgeSync][Dify] Response method=%s url=%s status=%d elapsed_ms=%d body=%s",
 		method,
 		endpoint,
@@ -2443,7 +2592,7 @@ func doDifyMultipartFileRequest(
 
 	if out != nil && len(bodyBytes) > 0 {
 		if err := json.Unmarshal(bodyBytes, out); err != nil {
-			log.Printf("解析Dify响应失败: %v, body: %s", err, string(bodyBytes))
+			logger.Warnf("解析Dify响应失败: %v, body: %s", err, string(bodyBytes))
 			return resp.StatusCode, bodyBytes, fmt.Errorf("解析响应失败: %w", err)
 		}
 	}
@@ -2,10 +2,11 @@ package controllers
 
 import (
 	"fmt"
-	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	"gorm.io/gorm"
@@ -23,6 +24,7 @@ const (
 	knowledgeSyncJobDelete    knowledgeSyncJobType = "delete"
 	knowledgeSyncJobDocUpsert knowledgeSyncJobType = "doc_upsert"
 	knowledgeSyncJobDocDelete knowledgeSyncJobType = "doc_delete"
+	knowledgeSyncJobReembed   knowledgeSyncJobType = "reembed"
 )
 
 type knowledgeSyncJob struct {
@@ -33,6 +35,35 @@ type knowledgeSyncJob struct {
 	knowledgeSnapshot *models.KnowledgeBase
 	documentSnapshot  *models.KnowledgeBaseDocument
 	enqueuedAt        time.Time
+	timeout           time.Duration       // 本次同步的 provider 请求超时，0 表示使用默认值
+	batch             *knowledgeSyncBatch // 所属批次，批次取消后 worker 跳过该任务
+}
+
+// knowledgeSyncBatch 需要整体投递的一组同步任务；部分入队失败时取消已入队的任务
+type knowledgeSyncBatch struct {
+	canceled atomic.Bool
+}
+
+func (b *knowledgeSyncBatch) isCanceled() bool {
+	return b != nil && b.canceled.Load()
+}
+
+// enqueueKnowledgeSyncBatch 将任务作为一个批次投递，任一任务入队失败时取消整个批次并返回错误
+func enqueueKnowledgeSyncBatch(queue chan<- knowledgeSyncJob, jobs []knowledgeSyncJob) error {
+	batch := &knowledgeSyncBatch{}
+	for i, job := range jobs {
+		job.batch = batch
+		job.enqueuedAt = time.Now()
+		select {
+		case queue <- job:
+		default:
+			batch.canceled.Store(true)
+			logger.Warnf("[KnowledgeSync][Async] batch enqueue failed, canceled queued=%d total=%d kb_id=%d", i, len(jobs), job.knowledgeBaseID)
+			return fmt.Errorf("知识库同步队列已满，请稍后重试")
+		}
+	}
+	logger.Infof("[KnowledgeSync][Async] enqueue batch jobs=%d", len(jobs))
+	return nil
 }
 
 var (
@@ -46,11 +77,16 @@ func ensureKnowledgeSyncWorkersStarted() {
 		for i := 1; i <= knowledgeSyncWorkerCount; i++ {
 			go runKnowledgeSyncWorker(i)
 		}
-		log.Printf("[KnowledgeSync][Async] workers started count=%d queue_size=%d", knowledgeSyncWorkerCount, knowledgeSyncQueueSize)
+		logger.Infof("[KnowledgeSync][Async] workers started count=%d queue_size=%d", knowledgeSyncWorkerCount, knowledgeSyncQueueSize)
 	})
 }
 
 func enqueueKnowledgeSyncUpsert(db *gorm.DB, knowledgeBaseID uint) error {
+	return enqueueKnowledgeSyncUpsertWithTimeout(db, knowledgeBaseID, 0)
+}
+
+// enqueueKnowledgeSyncUpsertWithTimeout 投递知识库同步任务，timeout > 0 时覆盖本次同步的 provider 请求超时
+func enqueueKnowledgeSyncUpsertWithTimeout(db *gorm.DB, knowledgeBaseID uint, timeout time.Duration) error {
 	if db == nil {
 		return fmt.Errorf("数据库连接为空")
 	}
@@ -64,10 +100,11 @@ func enqueueKnowledgeSyncUpsert(db *gorm.DB, knowledgeBaseID uint) error {
 		db:              db,
 		knowledgeBaseID: knowledgeBaseID,
 		enqueuedAt:      time.Now(),
+		timeout:         timeout,
 	}
 	select {
 	case knowledgeSyncQueue <- job:
-		log.Printf("[KnowledgeSync][Async] enqueue type=%s kb_id=%d", job.jobType, job.knowledgeBaseID)
+		logger.Infof("[KnowledgeSync][Async] enqueue type=%s kb_id=%d", job.jobType, job.knowledgeBaseID)
 		return nil
 	default:
 		return fmt.Errorf("知识库同步队列已满，请稍后重试")
@@ -90,7 +127,7 @@ func enqueueKnowledgeSyncDelete(db *gorm.DB, snapshot models.KnowledgeBase) erro
 	}
 	select {
 	case knowledgeSyncQueue <- job:
-		log.Printf("[KnowledgeSync][Async] enqueue type=%s kb_id=%d", job.jobType, job.knowledgeBaseID)
+		logger.Infof("[KnowledgeSync][Async] enqueue type=%s kb_id=%d", job.jobType, job.knowledgeBaseID)
 		return nil
 	default:
 		return fmt.Errorf("知识库同步队列已满，请稍后重试")
@@ -115,7 +152,7 @@ func enqueueKnowledgeDocumentSyncUpsert(db *gorm.DB, knowledgeBaseID, documentID
 	}
 	select {
 	case knowledgeSyncQueue <- job:
-		log.Printf("[KnowledgeSync][Async] enqueue type=%s kb_id=%d doc_id=%d", job.jobType, job.knowledgeBaseID, job.documentID)
+		logger.Infof("[KnowledgeSync][Async] enqueue type=%s kb_id=%d doc_id=%d", job.jobType, job.knowledgeBaseID, job.documentID)
 		return nil
 	default:
 		return fmt.Errorf("知识库同步队列已满，请稍后重试")
@@ -141,7 +178,31 @@ func enqueueKnowledgeDocumentSyncDelete(db *gorm.DB, kbSnapshot models.Knowledge
 	}
 	select {
 	case knowledgeSyncQueue <- job:
-		log.Printf("[KnowledgeSync][Async] enqueue type=%s kb_id=%d doc_id=%d", job.jobType, job.knowledgeBaseID, job.documentID)
+		logger.Infof("[KnowledgeSync][Async] enqueue type=%s kb_id=%d doc_id=%d", job.jobType, job.knowledgeBaseID, job.documentID)
+		return nil
+	default:
+		return fmt.Errorf("知识库同步队列已满，请稍后重试")
+	}
+}
+
+func enqueueKnowledgeSyncReembed(db *gorm.DB, knowledgeBaseID uint) error {
+	if db == nil {
+		return fmt.Errorf("数据库连接为空")
+	}
+	if knowledgeBaseID == 0 {
+		return fmt.Errorf("无效的知识库ID")
+	}
+	ensureKnowledgeSyncWorkersStarted()
+
+	job := knowledgeSyncJob{
+		jobType:         knowledgeSyncJobReembed,
+		db:              db,
+		knowledgeBaseID: knowledgeBaseID,
+		enqueuedAt:      time.Now(),
+	}
+	select {
+	case knowledgeSyncQueue <- job:
+		logger.Infof("[KnowledgeSync][Async] enqueue type=%s kb_id=%d", job.jobType, job.knowledgeBaseID)
 		return nil
 	default:
 		return fmt.Errorf("知识库同步队列已满，请稍后重试")
@@ -150,40 +211,58 @@ func enqueueKnowledgeDocumentSyncDelete(db *gorm.DB, kbSnapshot models.Knowledge
 
 func runKnowledgeSyncWorker(workerID int) {
 	for job := range knowledgeSyncQueue {
+		if job.batch.isCanceled() {
+			logger.Infof("[KnowledgeSync][Async] worker=%d skip canceled type=%s kb_id=%d doc_id=%d", workerID, job.jobType, job.knowledgeBaseID, job.documentID)
+			continue
+		}
 		waitMs := time.Since(job.enqueuedAt).Milliseconds()
 		start := time.Now()
+		done := knowledgeSyncMetrics.trackInFlight(string(job.jobType))
 		switch job.jobType {
 		case knowledgeSyncJobUpsert:
 			err := processKnowledgeSyncUpsert(job)
+			recordKnowledgeSyncJobOutcome(job, err)
 			if err != nil {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds(), err)
+				logger.Warnf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds(), err)
 			} else {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds())
+				logger.Infof("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds())
 			}
 		case knowledgeSyncJobDelete:
 			err := processKnowledgeSyncDelete(job)
+			recordKnowledgeSyncJobOutcome(job, err)
 			if err != nil {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds(), err)
+				logger.Warnf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds(), err)
 			} else {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds())
+				logger.Infof("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds())
 			}
 		case knowledgeSyncJobDocUpsert:
 			err := processKnowledgeDocumentSyncUpsert(job)
+			recordKnowledgeSyncJobOutcome(job, err)
 			if err != nil {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds(), err)
+				logger.Warnf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds(), err)
 			} else {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds())
+				logger.Infof("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds())
 			}
 		case knowledgeSyncJobDocDelete:
 			err := processKnowledgeDocumentSyncDelete(job)
+			recordKnowledgeSyncJobOutcome(job, err)
 			if err != nil {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds(), err)
+				logger.Warnf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds(), err)
 			} else {
-				log.Printf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds())
+				logger.Infof("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d doc_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, job.documentID, waitMs, time.Since(start).Milliseconds())
+			}
+		case knowledgeSyncJobReembed:
+			err := processKnowledgeSyncReembed(job)
+			recordKnowledgeSyncJobOutcome(job, err)
+			if err != nil {
+				logger.Warnf("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d err=%v", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds(), err)
+			} else {
+				logger.Infof("[KnowledgeSync][Async] worker=%d type=%s kb_id=%d wait_ms=%d cost_ms=%d status=ok", workerID, job.jobType, job.knowledgeBaseID, waitMs, time.Since(start).Milliseconds())
 			}
 		default:
-			log.Printf("[KnowledgeSync][Async] worker=%d unknown_job_type=%s kb_id=%d", workerID, job.jobType, job.knowledgeBaseID)
+			logger.Warnf("[KnowledgeSync][Async] worker=%d unknown_job_type=%s kb_id=%d", workerID, job.jobType, job.knowledgeBaseID)
 		}
+		done()
 	}
 }
 
@@ -198,7 +277,10 @@ func processKnowledgeSyncUpsert(job knowledgeSyncJob) error {
 		}
 		return fmt.Errorf("加载知识库失败: %w", err)
 	}
-	return syncKnowledgeBaseBestEffort(job.db, &kb)
+	// 同步全程登记为可取消，取消接口可中止进行中的 provider 请求
+	ctx, done := beginKnowledgeSyncCancelable(kb.ID)
+	defer done()
+	return syncKnowledgeBaseBestEffort(withKnowledgeSyncTimeout(ctx, job.timeout), job.db, &kb)
 }
 
 func processKnowledgeSyncDelete(job knowledgeSyncJob) error {
@@ -215,7 +297,11 @@ func processKnowledgeDocumentSyncUpsert(job knowledgeSyncJob) error {
 	if job.db == nil {
 		return fmt.Errorf("数据库连接为空")
 	}
-	return syncKnowledgeDocumentBestEffort(job.db, job.knowledgeBaseID, job.documentID)
+	if err := syncKnowledgeDocumentBestEffort(job.db, job.knowledgeBaseID, job.documentID); err != nil {
+		return err
+	}
+	summarizeKnowledgeDocumentBestEffort(job.db, job.knowledgeBaseID, job.documentID)
+	return nil
 }
 
 func processKnowledgeDocumentSyncDelete(job knowledgeSyncJob) error {
@@ -227,3 +313,10 @@ func processKnowledgeDocumentSyncDelete(job knowledgeSyncJob) error {
 	}
 	return syncKnowledgeDocumentDeleteBestEffort(job.db, *job.knowledgeSnapshot, *job.documentSnapshot)
 }
+
+func processKnowledgeSyncReembed(job knowledgeSyncJob) error {
+	if job.db == nil {
+		return fmt.Errorf("数据库连接为空")
+	}
+	return reembedKnowledgeBaseOnWeknora(job.db, job.knowledgeBaseID)
+}
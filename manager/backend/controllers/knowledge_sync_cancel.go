@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 同步取消：同步及等待 provider 解析期间按知识库登记可取消的 context，取消后同步结果回落为 pending 以便稍后重试
+
+var errKnowledgeSyncCanceled = errors.New("同步已取消")
+
+// 单次同步可指定的 provider 请求超时范围
+const (
+	knowledgeSyncTimeoutMin = 5 * time.Second
+	knowledgeSyncTimeoutMax = 30 * time.Minute
+)
+
+type knowledgeSyncTimeoutKey struct{}
+
+// withKnowledgeSyncTimeout 在 ctx 中记录本次同步的超时覆盖值，timeout <= 0 时原样返回
+func withKnowledgeSyncTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, knowledgeSyncTimeoutKey{}, timeout)
+}
+
+// knowledgeSyncTimeout 返回 ctx 中的超时覆盖值，未指定时返回 fallback
+func knowledgeSyncTimeout(ctx context.Context, fallback time.Duration) time.Duration {
+	if timeout, ok := ctx.Value(knowledgeSyncTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return fallback
+}
+
+// parseKnowledgeSyncTimeout 校验请求中的超时秒数，0 表示使用默认超时
+func parseKnowledgeSyncTimeout(seconds int) (time.Duration, error) {
+	if seconds == 0 {
+		return 0, nil
+	}
+	timeout := time.Duration(seconds) * time.Second
+	if timeout < knowledgeSyncTimeoutMin || timeout > knowledgeSyncTimeoutMax {
+		return 0, fmt.Errorf("timeout_seconds 必须在 %d 到 %d 之间", int(knowledgeSyncTimeoutMin.Seconds()), int(knowledgeSyncTimeoutMax.Seconds()))
+	}
+	return timeout, nil
+}
+
+// knowledgeSyncCanceledError ctx 已被取消时将同步错误包装为 errKnowledgeSyncCanceled，使状态回落为 pending 且不触发备用 provider
+func knowledgeSyncCanceledError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, errKnowledgeSyncCanceled) || !errors.Is(ctx.Err(), context.Canceled) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", errKnowledgeSyncCanceled, err)
+}
+
+// knowledgeSyncContextTransport ctx 取消时中止进行中的 provider 请求（含响应体读取）
+type knowledgeSyncContextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *knowledgeSyncContextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.ctx.Err() != nil {
+		return nil, errKnowledgeSyncCanceled
+	}
+	reqCtx, cancel := context.WithCancel(req.Context())
+	stop := context.AfterFunc(t.ctx, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+	resp, err := t.base.RoundTrip(req.WithContext(reqCtx))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &knowledgeSyncCancelableBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// knowledgeSyncCancelableBody 响应体关闭后才释放请求 context，避免读取途中被提前取消
+type knowledgeSyncCancelableBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *knowledgeSyncCancelableBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// newKnowledgeSyncHTTPClientContext 创建随 ctx 取消的 provider HTTP 客户端，ctx 指定了超时覆盖值时替换默认超时
+func newKnowledgeSyncHTTPClientContext(ctx context.Context, kbID uint, provider string, timeout time.Duration) *http.Client {
+	client := newKnowledgeSyncHTTPClient(kbID, provider, knowledgeSyncTimeout(ctx, timeout))
+	if ctx.Done() != nil {
+		client.Transport = &knowledgeSyncContextTransport{base: client.Transport, ctx: ctx}
+	}
+	return client
+}
+
+type knowledgeSyncCancelEntry struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	refs   int
+}
+
+var (
+	knowledgeSyncCancelMu sync.Mutex
+	knowledgeSyncCancels  = make(map[uint]*knowledgeSyncCancelEntry)
+)
+
+// beginKnowledgeSyncCancelable 登记知识库的可取消等待，同一知识库的并发同步共享同一个 context；结束后必须调用 done
+func beginKnowledgeSyncCancelable(kbID uint) (context.Context, func()) {
+	knowledgeSyncCancelMu.Lock()
+	defer knowledgeSyncCancelMu.Unlock()
+
+	entry, ok := knowledgeSyncCancels[kbID]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		entry = &knowledgeSyncCancelEntry{ctx: ctx, cancel: cancel}
+		knowledgeSyncCancels[kbID] = entry
+	}
+	entry.refs++
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			knowledgeSyncCancelMu.Lock()
+			defer knowledgeSyncCancelMu.Unlock()
+			entry.refs--
+			if entry.refs == 0 {
+				entry.cancel()
+				if knowledgeSyncCancels[kbID] == entry {
+					delete(knowledgeSyncCancels, kbID)
+				}
+			}
+		})
+	}
+	return entry.ctx, done
+}
+
+// cancelKnowledgeSync 取消知识库进行中的等待，没有进行中的同步时返回 false
+func cancelKnowledgeSync(kbID uint) bool {
+	knowledgeSyncCancelMu.Lock()
+	defer knowledgeSyncCancelMu.Unlock()
+
+	entry, ok := knowledgeSyncCancels[kbID]
+	if !ok {
+		return false
+	}
+	entry.cancel()
+	delete(knowledgeSyncCancels, kbID)
+	return true
+}
+
+// CancelKnowledgeSync 中止知识库进行中的同步，并将其标记为 pending 以便稍后重试
+func (uc *UserController) CancelKnowledgeSync(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的知识库ID"})
+		return
+	}
+
+	var item models.KnowledgeBase
+	if err := uc.DB.Where("id = ? AND user_id = ?", id, userID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "知识库不存在"})
+		return
+	}
+
+	if !cancelKnowledgeSync(item.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "当前没有进行中的同步"})
+		return
+	}
+	if err := uc.DB.Model(&models.KnowledgeBase{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+		"sync_status": knowledgeSyncStatusPending,
+		"sync_error":  errKnowledgeSyncCanceled.Error(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新同步状态失败: " + err.Error()})
+		return
+	}
+	_ = uc.DB.Where("id = ?", item.ID).First(&item).Error
+	c.JSON(http.StatusOK, gin.H{"message": "同步已取消，可稍后重新同步", "data": item})
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKnowledgeSyncCancel(t *testing.T) {
+	if cancelKnowledgeSync(42) {
+		t.Fatal("cancel without active sync should return false")
+	}
+
+	ctx1, done1 := beginKnowledgeSyncCancelable(42)
+	ctx2, done2 := beginKnowledgeSyncCancelable(42)
+	if ctx1 != ctx2 {
+		t.Fatal("concurrent syncs of the same knowledge base should share a context")
+	}
+	done1()
+	if ctx2.Err() != nil {
+		t.Fatal("context canceled while another sync is still waiting")
+	}
+
+	if !cancelKnowledgeSync(42) {
+		t.Fatal("cancel of active sync should return true")
+	}
+	if ctx2.Err() == nil {
+		t.Fatal("context not canceled")
+	}
+	done2()
+
+	// 取消后新的同步获得新的 context
+	ctx3, done3 := beginKnowledgeSyncCancelable(42)
+	defer done3()
+	if ctx3.Err() != nil {
+		t.Fatal("new sync should not inherit canceled context")
+	}
+}
+
+func TestKnowledgeSyncHTTPClientContextCancelsInFlightRequest(t *testing.T) {
+	defer func() {
+		for drained := false; !drained; {
+			select {
+			case <-knowledgeSyncEventCh:
+			default:
+				drained = true
+			}
+		}
+	}()
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, done := beginKnowledgeSyncCancelable(43)
+	defer done()
+	client := newKnowledgeSyncHTTPClientContext(ctx, 43, "dify", time.Minute)
+	go func() {
+		<-started
+		cancelKnowledgeSync(43)
+	}()
+
+	start := time.Now()
+	_, _, err := doDifyJSONRequest(client, http.MethodGet, srv.URL+"/v1/datasets", "k", nil, nil)
+	if err == nil {
+		t.Fatal("request should fail after cancel")
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("cancel did not abort the in-flight request")
+	}
+	if err := knowledgeSyncCanceledError(ctx, err); !errors.Is(err, errKnowledgeSyncCanceled) {
+		t.Fatalf("err = %v, want errKnowledgeSyncCanceled", err)
+	}
+	if isKnowledgeProviderUnavailableError(knowledgeSyncCanceledError(ctx, err)) {
+		t.Fatal("canceled sync must not trigger fallback provider")
+	}
+}
+
+func TestKnowledgeSyncTimeoutOverride(t *testing.T) {
+	ctx := context.Background()
+	if got := newKnowledgeSyncHTTPClientContext(ctx, 1, "dify", difyHTTPTimeout).Timeout; got != difyHTTPTimeout {
+		t.Fatalf("default timeout = %v, want %v", got, difyHTTPTimeout)
+	}
+	ctx = withKnowledgeSyncTimeout(ctx, 10*time.Minute)
+	if got := newKnowledgeSyncHTTPClientContext(ctx, 1, "dify", difyHTTPTimeout).Timeout; got != 10*time.Minute {
+		t.Fatalf("override timeout = %v, want 10m", got)
+	}
+	if err := knowledgeSyncCanceledError(context.Background(), errors.New("x")); errors.Is(err, errKnowledgeSyncCanceled) {
+		t.Fatal("error without cancellation should be unchanged")
+	}
+
+	if d, err := parseKnowledgeSyncTimeout(0); err != nil || d != 0 {
+		t.Fatalf("parse(0) = %v, %v", d, err)
+	}
+	if d, err := parseKnowledgeSyncTimeout(600); err != nil || d != 10*time.Minute {
+		t.Fatalf("parse(600) = %v, %v", d, err)
+	}
+	for _, seconds := range []int{-1, 1, 3600} {
+		if _, err := parseKnowledgeSyncTimeout(seconds); err == nil {
+			t.Fatalf("parse(%d) should fail", seconds)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// 同步事件保留期与单知识库保留条数上限，超出部分由定时任务清理
+	knowledgeSyncEventRetention     = 7 * 24 * time.Hour
+	knowledgeSyncEventMaxPerKB      = 200
+	knowledgeSyncEventPruneInterval = time.Hour
+	knowledgeSyncEventBufferSize    = 256
+)
+
+var (
+	// 事件异步落库，缓冲满时丢弃，避免拖慢同步请求
+	knowledgeSyncEventCh   = make(chan models.KnowledgeSyncEvent, knowledgeSyncEventBufferSize)
+	knowledgeSyncEventOnce sync.Once
+)
+
+// StartKnowledgeSyncEventSink 启动同步事件落库与定期清理任务（仅启动一次）
+func StartKnowledgeSyncEventSink(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	knowledgeSyncEventOnce.Do(func() {
+		go func() {
+			for event := range knowledgeSyncEventCh {
+				if err := db.Create(&event).Error; err != nil {
+					logger.Errorf("[KnowledgeSyncEvent] 写入同步事件失败 kb_id=%d err=%v", event.KnowledgeBaseID, err)
+				}
+			}
+		}()
+		go func() {
+			ticker := time.NewTicker(knowledgeSyncEventPruneInterval)
+			defer ticker.Stop()
+			for {
+				if err := pruneKnowledgeSyncEvents(db, time.Now()); err != nil {
+					logger.Warnf("[KnowledgeSyncEvent] 清理同步事件失败: %v", err)
+				}
+				<-ticker.C
+			}
+		}()
+		logger.Infof("[KnowledgeSyncEvent] sink started retention=%s max_per_kb=%d", knowledgeSyncEventRetention, knowledgeSyncEventMaxPerKB)
+	})
+}
+
+// recordKnowledgeSyncEvent 非阻塞投递同步事件
+func recordKnowledgeSyncEvent(event models.KnowledgeSyncEvent) {
+	select {
+	case knowledgeSyncEventCh <- event:
+	default:
+	}
+}
+
+// knowledgeSyncEventTransport 记录每次 provider 请求摘要的 RoundTripper，绑定到具体知识库
+type knowledgeSyncEventTransport struct {
+	base     http.RoundTripper
+	kbID     uint
+	provider string
+}
+
+func (t *knowledgeSyncEventTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	startAt := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	event := models.KnowledgeSyncEvent{
+		KnowledgeBaseID: t.kbID,
+		Provider:        t.provider,
+		Method:          req.Method,
+		Path:            req.URL.Path,
+		ElapsedMs:       time.Since(startAt).Milliseconds(),
+		CreatedAt:       startAt,
+	}
+	if err != nil {
+		event.Error = truncateSyncError(err.Error())
+	} else {
+		event.StatusCode = resp.StatusCode
+		if resp.StatusCode >= 400 {
+			// 失败时保留响应体摘要，并还原 Body 供调用方继续读取
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			event.Error = truncateSyncError(string(body))
+		}
+	}
+	recordKnowledgeSyncEvent(event)
+	return resp, err
+}
+
+// newKnowledgeSyncHTTPClient 创建记录同步事件的 provider HTTP 客户端，底层连接按 provider 复用
+func newKnowledgeSyncHTTPClient(kbID uint, provider string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &knowledgeSyncEventTransport{
+			base:     knowledgeSyncTransport(provider),
+			kbID:     kbID,
+			provider: provider,
+		},
+	}
+}
+
+// pruneKnowledgeSyncEvents 删除过期事件，并将每个知识库的事件数裁剪到上限
+func pruneKnowledgeSyncEvents(db *gorm.DB, now time.Time) error {
+	if err := db.Where("created_at < ?", now.Add(-knowledgeSyncEventRetention)).Delete(&models.KnowledgeSyncEvent{}).Error; err != nil {
+		return err
+	}
+
+	var kbIDs []uint
+	if err := db.Model(&models.KnowledgeSyncEvent{}).
+		Group("knowledge_base_id").
+		Having("COUNT(*) > ?", knowledgeSyncEventMaxPerKB).
+		Pluck("knowledge_base_id", &kbIDs).Error; err != nil {
+		return err
+	}
+	for _, kbID := range kbIDs {
+		var boundaryIDs []uint
+		if err := db.Model(&models.KnowledgeSyncEvent{}).
+			Where("knowledge_base_id = ?", kbID).
+			Order("id DESC").
+			Offset(knowledgeSyncEventMaxPerKB).
+			Limit(1).
+			Pluck("id", &boundaryIDs).Error; err != nil {
+			return err
+		}
+		if len(boundaryIDs) == 0 {
+			continue
+		}
+		if err := db.Where("knowledge_base_id = ? AND id <= ?", kbID, boundaryIDs[0]).Delete(&models.KnowledgeSyncEvent{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetKnowledgeSyncEvents 查询知识库最近的同步请求记录（按时间倒序），limit 默认 50，最大 200
+func (uc *UserController) GetKnowledgeSyncEvents(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的知识库ID"})
+		return
+	}
+
+	var item models.KnowledgeBase
+	if err := uc.DB.Where("id = ? AND user_id = ?", id, userID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "知识库不存在"})
+		return
+	}
+
+	// 兼容旧的 limit 参数：未传 page_size 时作为每页条数
+	hq, err := parseHistoryQuery(c, parsePositiveInt(c.Query("limit"), 50), knowledgeSyncEventMaxPerKB)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := hq.Filter(uc.DB.Model(&models.KnowledgeSyncEvent{}).Where("knowledge_base_id = ?", item.ID), "created_at")
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询同步事件失败"})
+		return
+	}
+	var events []models.KnowledgeSyncEvent
+	if err := hq.Paginate(query.Order("id DESC")).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询同步事件失败"})
+		return
+	}
+	c.JSON(http.StatusOK, historyPageResponse(events, total, hq))
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestKnowledgeSyncEventTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/datasets/missing" {
+			http.Error(w, "dataset not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	client := newKnowledgeSyncHTTPClient(7, "dify", time.Second)
+	for _, path := range []string{"/v1/datasets?api_key=secret", "/v1/datasets/missing"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if len(body) == 0 {
+			t.Fatalf("%s: response body consumed by transport", path)
+		}
+	}
+
+	ok := <-knowledgeSyncEventCh
+	if ok.KnowledgeBaseID != 7 || ok.Provider != "dify" || ok.Method != http.MethodGet || ok.Path != "/v1/datasets" || ok.StatusCode != 200 || ok.Error != "" {
+		t.Fatalf("ok event = %+v", ok)
+	}
+	failed := <-knowledgeSyncEventCh
+	if failed.StatusCode != http.StatusNotFound || failed.Error != "dataset not found" {
+		t.Fatalf("failed event = %+v", failed)
+	}
+}
+
+func TestPruneKnowledgeSyncEvents(t *testing.T) {
+	db := newTestDB(t, &models.KnowledgeSyncEvent{})
+
+	now := time.Now()
+	events := []models.KnowledgeSyncEvent{{KnowledgeBaseID: 2, CreatedAt: now.Add(-knowledgeSyncEventRetention - time.Hour)}}
+	for i := 0; i < knowledgeSyncEventMaxPerKB+5; i++ {
+		events = append(events, models.KnowledgeSyncEvent{KnowledgeBaseID: 1, CreatedAt: now})
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneKnowledgeSyncEvents(db, now); err != nil {
+		t.Fatal(err)
+	}
+	var expired, kept int64
+	db.Model(&models.KnowledgeSyncEvent{}).Where("knowledge_base_id = ?", 2).Count(&expired)
+	db.Model(&models.KnowledgeSyncEvent{}).Where("knowledge_base_id = ?", 1).Count(&kept)
+	if expired != 0 || kept != knowledgeSyncEventMaxPerKB {
+		t.Fatalf("expired = %d, kept = %d", expired, kept)
+	}
+	// 保留的是最新的记录
+	var oldest models.KnowledgeSyncEvent
+	db.Where("knowledge_base_id = ?", 1).Order("id ASC").First(&oldest)
+	if oldest.ID != events[6].ID {
+		t.Fatalf("oldest kept id = %d, want %d", oldest.ID, events[6].ID)
+	}
+}
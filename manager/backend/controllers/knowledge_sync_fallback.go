@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// parseKnowledgeFallbackProviders 读取 knowledge_search 配置中的 fallback_providers（有序），去重并排除主 provider
+func parseKnowledgeFallbackProviders(providerData map[string]interface{}, primary string) []string {
+	var raw []string
+	switch v := providerData["fallback_providers"].(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	case []string:
+		raw = v
+	case string:
+		raw = strings.Split(v, ",")
+	}
+
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(primary)): true}
+	var out []string
+	for _, p := range raw {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	return out
+}
+
+// isKnowledgeProviderUnavailableError 判断是否为 provider 不可达（网络错误、超时、网关错误），鉴权或内容错误不触发备用 provider
+func isKnowledgeProviderUnavailableError(err error) bool {
+	if err == nil || errors.Is(err, errKnowledgeSyncCanceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// provider 请求错误多以 %v 包装，按错误信息识别
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{
+		"connection refused",
+		"no such host",
+		"connection reset",
+		"network is unreachable",
+		"context deadline exceeded",
+		"client.timeout exceeded",
+		"tls handshake timeout",
+		"i/o timeout",
+		"status=502",
+		"status=503",
+		"status=504",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncKnowledgeBaseWithFallback 主 provider 不可达时按 fallback_providers 顺序尝试备用 provider。
+// 外部 dataset/文档 ID 属于原 provider，切换后在备用 provider 重新创建；成功后 sync_provider 记录实际同步的 provider，
+// 已同步的文档重置为待同步，以便后续同步到新的 provider。
+func syncKnowledgeBaseWithFallback(ctx context.Context, db *gorm.DB, kb *models.KnowledgeBase, primary string, primaryData map[string]interface{}, primaryResult *knowledgeProviderSyncResult, primaryErr error) (*knowledgeProviderSyncResult, error) {
+	fallbacks := parseKnowledgeFallbackProviders(primaryData, primary)
+	if len(fallbacks) == 0 {
+		return primaryResult, primaryErr
+	}
+
+	errs := []string{fmt.Sprintf("%s: %v", primary, primaryErr)}
+	for _, provider := range fallbacks {
+		cfg, providerData, err := loadKnowledgeProviderConfigByProvider(db, provider)
+		if err != nil {
+			logger.Warnf("[KnowledgeSync] fallback provider unavailable kb_id=%d provider=%s err=%v", kb.ID, provider, err)
+			errs = append(errs, fmt.Sprintf("%s: 未找到已启用的配置", provider))
+			continue
+		}
+		if p := strings.ToLower(strings.TrimSpace(cfg.Provider)); p != "" {
+			provider = p
+		}
+
+		logger.Warnf("[KnowledgeSync] primary provider unavailable, fallback kb_id=%d from=%s to=%s err=%v", kb.ID, primary, provider, primaryErr)
+		candidate := *kb
+		candidate.ExternalKBID = ""
+		candidate.ExternalDocID = ""
+		candidate.AutoDataset = false
+		result, err := syncKnowledgeBaseToProvider(ctx, db, &candidate, provider, providerData)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", provider, err))
+			if isKnowledgeProviderUnavailableError(err) {
+				continue
+			}
+			// 保持绑定原 provider，避免部分创建的外部数据覆盖原有 ID
+			return primaryResult, fmt.Errorf("主 provider 不可用，备用 provider 同步失败: %s", strings.Join(errs, "; "))
+		}
+
+		result.FallbackFrom = primary
+		if err := resetKnowledgeDocumentsForProviderSwitch(db, kb.ID); err != nil {
+			logger.Warnf("[KnowledgeSync] reset documents after fallback failed kb_id=%d err=%v", kb.ID, err)
+		}
+		logger.Infof("[KnowledgeSync] fallback sync succeeded kb_id=%d provider=%s dataset_id=%s", kb.ID, provider, result.DatasetID)
+		return result, nil
+	}
+	return primaryResult, fmt.Errorf("所有知识库 provider 均不可用: %s", strings.Join(errs, "; "))
+}
+
+// resetKnowledgeDocumentsForProviderSwitch 切换 provider 后清除文档在原 provider 的 ID，并标记为待同步
+func resetKnowledgeDocumentsForProviderSwitch(db *gorm.DB, kbID uint) error {
+	return db.Model(&models.KnowledgeBaseDocument{}).
+		Where("knowledge_base_id = ?", kbID).
+		Updates(map[string]interface{}{
+			"external_doc_id": "",
+			"sync_status":     knowledgeSyncStatusPending,
+			"sync_error":      "",
+		}).Error
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestParseKnowledgeFallbackProviders(t *testing.T) {
+	data := map[string]interface{}{"fallback_providers": []interface{}{"RAGFlow", "dify", "", "ragflow", "weknora"}}
+	if got, want := parseKnowledgeFallbackProviders(data, "dify"), []string{"ragflow", "weknora"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("fallbacks = %v, want %v", got, want)
+	}
+	if got := parseKnowledgeFallbackProviders(map[string]interface{}{"fallback_providers": "weknora, ragflow"}, "ragflow"); !reflect.DeepEqual(got, []string{"weknora"}) {
+		t.Fatalf("fallbacks from string = %v", got)
+	}
+	if got := parseKnowledgeFallbackProviders(map[string]interface{}{}, "dify"); len(got) != 0 {
+		t.Fatalf("fallbacks = %v, want none", got)
+	}
+}
+
+func TestIsKnowledgeProviderUnavailableError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{fmt.Errorf("创建Dify数据集失败: Post \"http://x\": dial tcp 127.0.0.1:1: connect: connection refused"), true},
+		{fmt.Errorf("status=503 body=upstream unavailable"), true},
+		{fmt.Errorf("status=401 body=invalid api key"), false},
+		{fmt.Errorf("status=400 body=content too large"), false},
+		{errKnowledgeSyncCanceled, false},
+	}
+	for _, tc := range cases {
+		if got := isKnowledgeProviderUnavailableError(tc.err); got != tc.want {
+			t.Fatalf("%v: got %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestSyncKnowledgeBaseFallbackAllUnavailable(t *testing.T) {
+	db := newTestDB(t, &models.Config{}, &models.KnowledgeBaseDocument{})
+	// 监听后立即关闭，得到一个拒绝连接的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadURL := "http://" + ln.Addr().String()
+	ln.Close()
+
+	configs := []models.Config{
+		{Type: "knowledge_search", ConfigID: "dify_main", Name: "dify", Provider: "dify", Enabled: true, IsDefault: true,
+			JsonData: `{"base_url":"` + deadURL + `","api_key":"k","fallback_providers":["ragflow"]}`},
+		{Type: "knowledge_search", ConfigID: "ragflow_backup", Name: "ragflow", Provider: "ragflow", Enabled: true,
+			JsonData: `{"base_url":"` + deadURL + `","api_key":"k"}`},
+	}
+	if err := db.Create(&configs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	kb := &models.KnowledgeBase{ID: 1, Name: "faq", Content: "hello"}
+	_, err = syncKnowledgeBaseWithProvider(context.Background(), db, kb)
+	if err == nil || !strings.Contains(err.Error(), "所有知识库 provider 均不可用") || !strings.Contains(err.Error(), "ragflow:") {
+		t.Fatalf("err = %v", err)
+	}
+}
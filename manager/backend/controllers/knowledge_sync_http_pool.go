@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	knowledgeSyncMaxIdleConns        = 64
+	knowledgeSyncMaxIdleConnsPerHost = 16
+	knowledgeSyncIdleConnTimeout     = 90 * time.Second
+)
+
+// knowledgeSyncTransportPool 按 provider 复用底层 Transport，避免每次同步都重建连接
+var knowledgeSyncTransportPool = struct {
+	sync.Mutex
+	transports map[string]*http.Transport
+}{transports: make(map[string]*http.Transport)}
+
+// newKnowledgeSyncTransport 创建开启 keep-alive 并调优空闲连接数的 Transport
+func newKnowledgeSyncTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          knowledgeSyncMaxIdleConns,
+		MaxIdleConnsPerHost:   knowledgeSyncMaxIdleConnsPerHost,
+		IdleConnTimeout:       knowledgeSyncIdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// knowledgeSyncTransport 获取 provider 对应的共享 Transport，不存在时创建
+func knowledgeSyncTransport(provider string) *http.Transport {
+	key := strings.ToLower(strings.TrimSpace(provider))
+	knowledgeSyncTransportPool.Lock()
+	defer knowledgeSyncTransportPool.Unlock()
+	if transport, ok := knowledgeSyncTransportPool.transports[key]; ok {
+		return transport
+	}
+	transport := newKnowledgeSyncTransport()
+	knowledgeSyncTransportPool.transports[key] = transport
+	return transport
+}
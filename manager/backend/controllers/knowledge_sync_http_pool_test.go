@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKnowledgeSyncTransportPoolReuse(t *testing.T) {
+	if knowledgeSyncTransport("dify") != knowledgeSyncTransport(" Dify ") {
+		t.Fatal("same provider should share transport")
+	}
+	if knowledgeSyncTransport("dify") == knowledgeSyncTransport("ragflow") {
+		t.Fatal("different providers should not share transport")
+	}
+
+	var newConns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&newConns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		// 每次同步都新建 client，但应复用同一条底层连接
+		client := newKnowledgeSyncHTTPClient(uint(i+1), "pool-test", time.Second)
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if got := atomic.LoadInt32(&newConns); got != 1 {
+		t.Fatalf("expected 1 connection reused across clients, got %d", got)
+	}
+	for i := 0; i < 3; i++ {
+		<-knowledgeSyncEventCh
+	}
+}
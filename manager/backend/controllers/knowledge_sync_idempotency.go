@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 创建文档的幂等处理：provider 已创建成功但响应丢失（超时）时，重试会重复创建文档。
+// 创建前先按文档名在 dataset 中查找尚未关联到本地的文档，找到则复用。
+
+const knowledgeReusableDocumentLookupLimit = 100
+
+type knowledgeProviderDocument struct {
+	ID   string
+	Name string
+	Size int64 // provider 未返回时为 -1
+}
+
+// knowledgeBoundExternalDocIDs 知识库下已关联到本地的 provider 文档ID（excludeDocID 对应的本地文档除外）
+func knowledgeBoundExternalDocIDs(db *gorm.DB, kb *models.KnowledgeBase, excludeDocID uint) map[string]bool {
+	bound := make(map[string]bool)
+	if kb == nil {
+		return bound
+	}
+	if id := strings.TrimSpace(kb.ExternalDocID); id != "" {
+		bound[id] = true
+	}
+	var ids []string
+	if err := db.Model(&models.KnowledgeBaseDocument{}).
+		Where("knowledge_base_id = ? AND id <> ? AND external_doc_id <> ''", kb.ID, excludeDocID).
+		Pluck("external_doc_id", &ids).Error; err != nil {
+		logger.Warnf("[KnowledgeSync] load bound document ids failed kb_id=%d err=%v", kb.ID, err)
+		return bound
+	}
+	// 自动摘要文档同样属于本知识库，不能被当作可复用的残留文档
+	var summaryIDs []string
+	if err := db.Model(&models.KnowledgeBaseDocument{}).
+		Where("knowledge_base_id = ? AND summary_external_doc_id <> ''", kb.ID).
+		Pluck("summary_external_doc_id", &summaryIDs).Error; err != nil {
+		logger.Warnf("[KnowledgeSync] load summary document ids failed kb_id=%d err=%v", kb.ID, err)
+	}
+	for _, id := range append(ids, summaryIDs...) {
+		if id = strings.TrimSpace(id); id != "" {
+			bound[id] = true
+		}
+	}
+	return bound
+}
+
+// parseKnowledgeProviderDocumentList 解析文档列表响应，兼容 data 为数组（Dify）与 data.docs（RAGFlow）
+func parseKnowledgeProviderDocumentList(body []byte) []knowledgeProviderDocument {
+	var generic map[string]interface{}
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return nil
+	}
+	var items []interface{}
+	switch data := generic["data"].(type) {
+	case []interface{}:
+		items = data
+	case map[string]interface{}:
+		items, _ = data["docs"].([]interface{})
+	}
+
+	docs := make([]knowledgeProviderDocument, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := m["id"].(string)
+		name, _ := m["name"].(string)
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+		doc := knowledgeProviderDocument{ID: strings.TrimSpace(id), Name: strings.TrimSpace(name), Size: -1}
+		if size, ok := m["size"].(float64); ok {
+			doc.Size = int64(size)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// pickReusableKnowledgeDocument 选出名称一致、未被本地关联且大小匹配（size<0 不校验）的文档
+func pickReusableKnowledgeDocument(docs []knowledgeProviderDocument, name string, size int64, bound map[string]bool) string {
+	name = strings.TrimSpace(name)
+	for _, doc := range docs {
+		if doc.Name != name || bound[doc.ID] {
+			continue
+		}
+		if size >= 0 && doc.Size >= 0 && doc.Size != size {
+			continue
+		}
+		return doc.ID
+	}
+	return ""
+}
+
+// findReusableDifyDocument 在 Dify dataset 中查找可复用的同名文档；查询失败时返回空，由调用方继续创建
+func findReusableDifyDocument(client *http.Client, cfg *difyKnowledgeSyncConfig, datasetID, name string, bound map[string]bool) string {
+	path := fmt.Sprintf("/datasets/%s/documents?keyword=%s&page=1&limit=%d", url.PathEscape(datasetID), url.QueryEscape(name), knowledgeReusableDocumentLookupLimit)
+	_, body, err := doDifyJSONRequest(client, http.MethodGet, buildDifyURL(cfg.BaseURL, path), cfg.APIKey, nil, nil)
+	if err != nil {
+		logger.Warnf("[KnowledgeSync][Dify] lookup existing document warning dataset_id=%s name=%s err=%v", datasetID, name, err)
+		return ""
+	}
+	documentID := pickReusableKnowledgeDocument(parseKnowledgeProviderDocumentList(body), name, -1, bound)
+	if documentID != "" {
+		logger.Infof("[KnowledgeSync][Dify] reuse existing document dataset_id=%s name=%s document_id=%s", datasetID, name, documentID)
+	}
+	return documentID
+}
+
+// findReusableRagflowDocument 在 RAGFlow dataset 中查找可复用的同名同大小文档；查询失败时返回空，由调用方继续上传
+func findReusableRagflowDocument(client *http.Client, cfg *ragflowKnowledgeSyncConfig, datasetID, fileName string, size int64, bound map[string]bool) string {
+	endpoint := buildRagflowURL(cfg.BaseURL, fmt.Sprintf("/datasets/%s/documents?name=%s&page=1&page_size=%d", url.PathEscape(datasetID), url.QueryEscape(fileName), knowledgeReusableDocumentLookupLimit))
+	_, body, err := doRagflowJSONRequest(client, http.MethodGet, endpoint, cfg.APIKey, nil, nil)
+	if err != nil {
+		logger.Warnf("[KnowledgeSync][Ragflow] lookup existing document warning dataset_id=%s name=%s err=%v", datasetID, fileName, err)
+		return ""
+	}
+	documentID := pickReusableKnowledgeDocument(parseKnowledgeProviderDocumentList(body), fileName, size, bound)
+	if documentID != "" {
+		logger.Infof("[KnowledgeSync][Ragflow] reuse existing document dataset_id=%s name=%s document_id=%s", datasetID, fileName, documentID)
+	}
+	return documentID
+}
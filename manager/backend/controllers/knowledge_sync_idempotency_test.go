@@ -0,0 +1,31 @@
+package controllers
+
+import "testing"
+
+func TestPickReusableKnowledgeDocument(t *testing.T) {
+	difyBody := []byte(`{"data":[{"id":"d1","name":"faq"},{"id":"d2","name":"faq"},{"id":"d3","name":"other"}],"total":3}`)
+	docs := parseKnowledgeProviderDocumentList(difyBody)
+	if len(docs) != 3 {
+		t.Fatalf("dify docs = %d, want 3", len(docs))
+	}
+	// d1 已关联到其它本地文档，应跳过
+	if got := pickReusableKnowledgeDocument(docs, "faq", -1, map[string]bool{"d1": true}); got != "d2" {
+		t.Fatalf("reusable dify doc = %q, want d2", got)
+	}
+	if got := pickReusableKnowledgeDocument(docs, "missing", -1, nil); got != "" {
+		t.Fatalf("reusable dify doc = %q, want empty", got)
+	}
+
+	ragflowBody := []byte(`{"code":0,"data":{"docs":[{"id":"r1","name":"faq.txt","size":10},{"id":"r2","name":"faq.txt","size":12}],"total":2}}`)
+	docs = parseKnowledgeProviderDocumentList(ragflowBody)
+	if len(docs) != 2 {
+		t.Fatalf("ragflow docs = %d, want 2", len(docs))
+	}
+	// 大小不一致的同名文档不复用
+	if got := pickReusableKnowledgeDocument(docs, "faq.txt", 12, nil); got != "r2" {
+		t.Fatalf("reusable ragflow doc = %q, want r2", got)
+	}
+	if got := pickReusableKnowledgeDocument(docs, "faq.txt", 99, nil); got != "" {
+		t.Fatalf("reusable ragflow doc = %q, want empty", got)
+	}
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 可选的索引完成确认：Dify/RAGFlow 创建或解析文档后默认立即标记为已同步；
+// provider 配置 wait_indexed=true 时轮询文档状态，直到 provider 报告索引完成才标记 synced（与 WeKnora 的解析等待一致）
+
+const (
+	defaultKnowledgeIndexPollInterval = 1000 * time.Millisecond
+	defaultKnowledgeIndexTimeout      = 120000 * time.Millisecond
+
+	knowledgeIndexStateCompleted = "completed"
+	knowledgeIndexStateFailed    = "failed"
+	knowledgeIndexStatePending   = "pending"
+)
+
+// knowledgeIndexWaitConfig 索引完成确认设置，Enabled 为 false 时走快速路径
+type knowledgeIndexWaitConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+func parseKnowledgeIndexWaitConfig(providerData map[string]interface{}) knowledgeIndexWaitConfig {
+	cfg := knowledgeIndexWaitConfig{
+		Enabled:      parseProviderBool(providerData["wait_indexed"], false),
+		PollInterval: defaultKnowledgeIndexPollInterval,
+		Timeout:      defaultKnowledgeIndexTimeout,
+	}
+	if v, ok := parseInt(providerData["index_poll_interval_ms"]); ok && v > 0 {
+		cfg.PollInterval = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := parseInt(providerData["index_timeout_ms"]); ok && v > 0 {
+		cfg.Timeout = time.Duration(v) * time.Millisecond
+	}
+	return cfg
+}
+
+// waitKnowledgeDocumentIndexed 轮询 fetch 直到文档索引完成、失败、超时或同步被取消
+func waitKnowledgeDocumentIndexed(ctx context.Context, provider, documentID string, cfg knowledgeIndexWaitConfig, fetch func() (state, errMsg string, err error)) error {
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultKnowledgeIndexPollInterval
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultKnowledgeIndexTimeout
+	}
+	startedAt := time.Now()
+	deadline := startedAt.Add(timeout)
+	for {
+		state, errMsg, err := fetch()
+		if err != nil {
+			return err
+		}
+		switch state {
+		case knowledgeIndexStateCompleted:
+			knowledgeSyncMetrics.observeParse(provider, time.Since(startedAt))
+			return nil
+		case knowledgeIndexStateFailed:
+			if errMsg == "" {
+				errMsg = "unknown error"
+			}
+			return fmt.Errorf("%s文档索引失败(document_id=%s): %s", provider, documentID, errMsg)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待%s文档索引超时(document_id=%s timeout_ms=%d)", provider, documentID, timeout.Milliseconds())
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待%s文档索引已中止(document_id=%s): %w", provider, documentID, errKnowledgeSyncCanceled)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// difyIndexingState 映射 Dify indexing_status：waiting/parsing/cleaning/splitting/indexing/paused 视为进行中
+func difyIndexingState(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "completed":
+		return knowledgeIndexStateCompleted
+	case "error":
+		return knowledgeIndexStateFailed
+	default:
+		return knowledgeIndexStatePending
+	}
+}
+
+// getDifyDocumentIndexingState 查询 Dify 文档详情中的 indexing_status
+func getDifyDocumentIndexingState(client *http.Client, cfg *difyKnowledgeSyncConfig, datasetID, documentID string) (string, string, error) {
+	endpoint := buildDifyURL(cfg.BaseURL, fmt.Sprintf("/datasets/%s/documents/%s", url.PathEscape(datasetID), url.PathEscape(documentID)))
+	_, body, err := doDifyJSONRequest(client, http.MethodGet, endpoint, cfg.APIKey, nil, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("获取Dify文档索引状态失败(document_id=%s): %w", documentID, err)
+	}
+	var doc struct {
+		IndexingStatus string `json:"indexing_status"`
+		Error          string `json:"error"`
+		Data           *struct {
+			IndexingStatus string `json:"indexing_status"`
+			Error          string `json:"error"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", "", fmt.Errorf("解析Dify文档索引状态失败(document_id=%s): %w", documentID, err)
+	}
+	status, errMsg := doc.IndexingStatus, doc.Error
+	if status == "" && doc.Data != nil {
+		status, errMsg = doc.Data.IndexingStatus, doc.Data.Error
+	}
+	return difyIndexingState(status), strings.TrimSpace(errMsg), nil
+}
+
+// ragflowRunState 映射 RAGFlow 文档 run 状态，兼容名称（DONE）与数字编码（"3"）
+func ragflowRunState(run string) string {
+	switch strings.ToUpper(strings.TrimSpace(run)) {
+	case "DONE", "3":
+		return knowledgeIndexStateCompleted
+	case "FAIL", "CANCEL", "4", "2":
+		return knowledgeIndexStateFailed
+	default:
+		return knowledgeIndexStatePending
+	}
+}
+
+// getRagflowDocumentRunState 按文档ID查询 RAGFlow 文档解析状态
+func getRagflowDocumentRunState(client *http.Client, cfg *ragflowKnowledgeSyncConfig, datasetID, documentID string) (string, string, error) {
+	endpoint := buildRagflowURL(cfg.BaseURL, fmt.Sprintf("/datasets/%s/documents?id=%s", url.PathEscape(datasetID), url.QueryEscape(documentID)))
+	var resp struct {
+		Data struct {
+			Docs []struct {
+				ID          string `json:"id"`
+				Run         string `json:"run"`
+				ProgressMsg string `json:"progress_msg"`
+			} `json:"docs"`
+		} `json:"data"`
+	}
+	if _, _, err := doRagflowJSONRequest(client, http.MethodGet, endpoint, cfg.APIKey, nil, &resp); err != nil {
+		return "", "", fmt.Errorf("获取RAGFlow文档解析状态失败(document_id=%s): %w", documentID, err)
+	}
+	for _, doc := range resp.Data.Docs {
+		if doc.ID == documentID {
+			return ragflowRunState(doc.Run), strings.TrimSpace(doc.ProgressMsg), nil
+		}
+	}
+	return "", "", fmt.Errorf("RAGFlow文档不存在(document_id=%s)", documentID)
+}
+
+// waitDifyDocumentIndexed 未开启 wait_indexed 时直接返回；等待期间可被取消同步中止
+func waitDifyDocumentIndexed(kbID uint, client *http.Client, cfg *difyKnowledgeSyncConfig, datasetID, documentID string) error {
+	if !cfg.IndexWait.Enabled {
+		return nil
+	}
+	ctx, done := beginKnowledgeSyncCancelable(kbID)
+	defer done()
+	return waitKnowledgeDocumentIndexed(ctx, "dify", documentID, cfg.IndexWait, func() (string, string, error) {
+		return getDifyDocumentIndexingState(client, cfg, datasetID, documentID)
+	})
+}
+
+// waitRagflowDocumentIndexed 未开启 wait_indexed 时直接返回；等待期间可被取消同步中止
+func waitRagflowDocumentIndexed(kbID uint, client *http.Client, cfg *ragflowKnowledgeSyncConfig, datasetID, documentID string) error {
+	if !cfg.IndexWait.Enabled {
+		return nil
+	}
+	ctx, done := beginKnowledgeSyncCancelable(kbID)
+	defer done()
+	return waitKnowledgeDocumentIndexed(ctx, "ragflow", documentID, cfg.IndexWait, func() (string, string, error) {
+		return getRagflowDocumentRunState(client, cfg, datasetID, documentID)
+	})
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKnowledgeIndexWaitConfig(t *testing.T) {
+	cfg := parseKnowledgeIndexWaitConfig(map[string]interface{}{})
+	if cfg.Enabled || cfg.Timeout != defaultKnowledgeIndexTimeout {
+		t.Fatalf("default config = %+v", cfg)
+	}
+	cfg = parseKnowledgeIndexWaitConfig(map[string]interface{}{"wait_indexed": true, "index_timeout_ms": float64(5000)})
+	if !cfg.Enabled || cfg.Timeout != 5*time.Second {
+		t.Fatalf("config = %+v", cfg)
+	}
+}
+
+func TestWaitKnowledgeDocumentIndexed(t *testing.T) {
+	cfg := knowledgeIndexWaitConfig{Enabled: true, PollInterval: time.Millisecond, Timeout: time.Second}
+
+	calls := 0
+	err := waitKnowledgeDocumentIndexed(context.Background(), "dify", "doc-1", cfg, func() (string, string, error) {
+		calls++
+		if calls < 3 {
+			return knowledgeIndexStatePending, "", nil
+		}
+		return knowledgeIndexStateCompleted, "", nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+
+	err = waitKnowledgeDocumentIndexed(context.Background(), "ragflow", "doc-1", cfg, func() (string, string, error) {
+		return knowledgeIndexStateFailed, "embedding failed", nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "embedding failed") {
+		t.Fatalf("failed state err = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = waitKnowledgeDocumentIndexed(ctx, "dify", "doc-1", cfg, func() (string, string, error) {
+		return knowledgeIndexStatePending, "", nil
+	})
+	if !errors.Is(err, errKnowledgeSyncCanceled) {
+		t.Fatalf("canceled err = %v", err)
+	}
+
+	short := knowledgeIndexWaitConfig{Enabled: true, PollInterval: time.Millisecond, Timeout: 5 * time.Millisecond}
+	err = waitKnowledgeDocumentIndexed(context.Background(), "dify", "doc-1", short, func() (string, string, error) {
+		return knowledgeIndexStatePending, "", nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "超时") {
+		t.Fatalf("timeout err = %v", err)
+	}
+}
+
+func TestProviderIndexingStateLookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/datasets/ds/documents/doc-1":
+			w.Write([]byte(`{"id":"doc-1","indexing_status":"indexing"}`))
+		case r.URL.Path == "/v1/datasets/ds/documents/doc-2":
+			w.Write([]byte(`{"id":"doc-2","indexing_status":"error","error":"quota exceeded"}`))
+		case r.URL.Path == "/api/v1/datasets/ds/documents" && r.URL.Query().Get("id") == "doc-3":
+			w.Write([]byte(`{"code":0,"data":{"docs":[{"id":"doc-3","run":"DONE","progress_msg":""}],"total":1}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	difyCfg := &difyKnowledgeSyncConfig{BaseURL: srv.URL, APIKey: "k"}
+	if state, _, err := getDifyDocumentIndexingState(srv.Client(), difyCfg, "ds", "doc-1"); err != nil || state != knowledgeIndexStatePending {
+		t.Fatalf("dify indexing state=%q err=%v", state, err)
+	}
+	if state, msg, err := getDifyDocumentIndexingState(srv.Client(), difyCfg, "ds", "doc-2"); err != nil || state != knowledgeIndexStateFailed || msg != "quota exceeded" {
+		t.Fatalf("dify error state=%q msg=%q err=%v", state, msg, err)
+	}
+
+	ragflowCfg := &ragflowKnowledgeSyncConfig{BaseURL: srv.URL, APIKey: "k"}
+	if state, _, err := getRagflowDocumentRunState(srv.Client(), ragflowCfg, "ds", "doc-3"); err != nil || state != knowledgeIndexStateCompleted {
+		t.Fatalf("ragflow state=%q err=%v", state, err)
+	}
+
+	// 未开启 wait_indexed 时不发起请求
+	if err := waitDifyDocumentIndexed(1, srv.Client(), difyCfg, "ds", "missing"); err != nil {
+		t.Fatalf("fast path err = %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 知识库同步指标：按 provider/任务类型/结果计数、文档解析耗时直方图、进行中任务数，以 Prometheus 文本格式导出
+
+const (
+	knowledgeSyncOutcomeSuccess  = "success"
+	knowledgeSyncOutcomeFailure  = "failure"
+	knowledgeSyncOutcomeRetry    = "retry"
+	knowledgeSyncOutcomeCanceled = "canceled"
+)
+
+// knowledgeParseDurationBuckets 解析耗时直方图分桶（秒），覆盖小文档秒级到大文件十分钟级
+var knowledgeParseDurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600}
+
+type knowledgeSyncCounterKey struct {
+	provider string
+	job      string
+	outcome  string
+}
+
+type knowledgeParseHistogram struct {
+	buckets []uint64 // 与 knowledgeParseDurationBuckets 对应的累计计数
+	count   uint64
+	sum     float64
+}
+
+type knowledgeSyncMetricsRegistry struct {
+	mu       sync.Mutex
+	counters map[knowledgeSyncCounterKey]uint64
+	parse    map[string]*knowledgeParseHistogram // provider -> 解析耗时
+	inFlight map[string]int64                    // 任务类型 -> 进行中数量
+}
+
+var knowledgeSyncMetrics = newKnowledgeSyncMetricsRegistry()
+
+func newKnowledgeSyncMetricsRegistry() *knowledgeSyncMetricsRegistry {
+	return &knowledgeSyncMetricsRegistry{
+		counters: make(map[knowledgeSyncCounterKey]uint64),
+		parse:    make(map[string]*knowledgeParseHistogram),
+		inFlight: make(map[string]int64),
+	}
+}
+
+// incSync 记录一次同步结果
+func (m *knowledgeSyncMetricsRegistry) incSync(provider, job, outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[knowledgeSyncCounterKey{provider: normalizeMetricsProvider(provider), job: job, outcome: outcome}]++
+}
+
+// observeParse 记录一次文档解析耗时
+func (m *knowledgeSyncMetricsRegistry) observeParse(provider string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	provider = normalizeMetricsProvider(provider)
+	h := m.parse[provider]
+	if h == nil {
+		h = &knowledgeParseHistogram{buckets: make([]uint64, len(knowledgeParseDurationBuckets))}
+		m.parse[provider] = h
+	}
+	seconds := d.Seconds()
+	for i, le := range knowledgeParseDurationBuckets {
+		if seconds <= le {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// trackInFlight 进行中任务数加一，返回的函数用于结束时减一
+func (m *knowledgeSyncMetricsRegistry) trackInFlight(job string) func() {
+	m.mu.Lock()
+	m.inFlight[job]++
+	m.mu.Unlock()
+	return func() {
+		m.mu.Lock()
+		m.inFlight[job]--
+		m.mu.Unlock()
+	}
+}
+
+func normalizeMetricsProvider(provider string) string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return "unknown"
+	}
+	return provider
+}
+
+// knowledgeSyncOutcome 将同步错误归类为结果标签
+func knowledgeSyncOutcome(err error) string {
+	switch {
+	case err == nil:
+		return knowledgeSyncOutcomeSuccess
+	case errors.Is(err, errKnowledgeSyncCanceled):
+		return knowledgeSyncOutcomeCanceled
+	default:
+		return knowledgeSyncOutcomeFailure
+	}
+}
+
+// knowledgeSyncJobProvider 解析同步任务所属 provider：优先快照，其次库中记录，最后默认 provider
+func knowledgeSyncJobProvider(job knowledgeSyncJob) string {
+	if job.knowledgeSnapshot != nil && strings.TrimSpace(job.knowledgeSnapshot.SyncProvider) != "" {
+		return job.knowledgeSnapshot.SyncProvider
+	}
+	if job.db == nil {
+		return ""
+	}
+	var kb models.KnowledgeBase
+	if err := job.db.Unscoped().Select("id", "sync_provider").Where("id = ?", job.knowledgeBaseID).First(&kb).Error; err == nil && strings.TrimSpace(kb.SyncProvider) != "" {
+		return kb.SyncProvider
+	}
+	return resolveDefaultKnowledgeProviderName(job.db)
+}
+
+// recordKnowledgeSyncJobOutcome 记录异步同步任务结果
+func recordKnowledgeSyncJobOutcome(job knowledgeSyncJob, err error) {
+	knowledgeSyncMetrics.incSync(knowledgeSyncJobProvider(job), string(job.jobType), knowledgeSyncOutcome(err))
+}
+
+// render 以 Prometheus 文本格式输出，标签排序保证输出稳定
+func (m *knowledgeSyncMetricsRegistry) render(queueLength int) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	b.WriteString("# HELP xiaozhi_knowledge_sync_total Knowledge sync jobs by provider, job type and outcome.\n")
+	b.WriteString("# TYPE xiaozhi_knowledge_sync_total counter\n")
+	keys := make([]knowledgeSyncCounterKey, 0, len(m.counters))
+	for k := range m.counters {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		if keys[i].job != keys[j].job {
+			return keys[i].job < keys[j].job
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "xiaozhi_knowledge_sync_total{provider=%q,job=%q,outcome=%q} %d\n", k.provider, k.job, k.outcome, m.counters[k])
+	}
+
+	b.WriteString("# HELP xiaozhi_knowledge_parse_duration_seconds Time from upload until the provider finished parsing a document.\n")
+	b.WriteString("# TYPE xiaozhi_knowledge_parse_duration_seconds histogram\n")
+	providers := make([]string, 0, len(m.parse))
+	for p := range m.parse {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	for _, p := range providers {
+		h := m.parse[p]
+		for i, le := range knowledgeParseDurationBuckets {
+			fmt.Fprintf(&b, "xiaozhi_knowledge_parse_duration_seconds_bucket{provider=%q,le=\"%g\"} %d\n", p, le, h.buckets[i])
+		}
+		fmt.Fprintf(&b, "xiaozhi_knowledge_parse_duration_seconds_bucket{provider=%q,le=\"+Inf\"} %d\n", p, h.count)
+		fmt.Fprintf(&b, "xiaozhi_knowledge_parse_duration_seconds_sum{provider=%q} %g\n", p, h.sum)
+		fmt.Fprintf(&b, "xiaozhi_knowledge_parse_duration_seconds_count{provider=%q} %d\n", p, h.count)
+	}
+
+	b.WriteString("# HELP xiaozhi_knowledge_sync_in_flight Knowledge sync jobs currently running by job type.\n")
+	b.WriteString("# TYPE xiaozhi_knowledge_sync_in_flight gauge\n")
+	jobs := make([]string, 0, len(m.inFlight))
+	for j := range m.inFlight {
+		jobs = append(jobs, j)
+	}
+	sort.Strings(jobs)
+	for _, j := range jobs {
+		fmt.Fprintf(&b, "xiaozhi_knowledge_sync_in_flight{job=%q} %d\n", j, m.inFlight[j])
+	}
+
+	b.WriteString("# HELP xiaozhi_knowledge_sync_queue_length Knowledge sync jobs waiting in the queue.\n")
+	b.WriteString("# TYPE xiaozhi_knowledge_sync_queue_length gauge\n")
+	fmt.Fprintf(&b, "xiaozhi_knowledge_sync_queue_length %d\n", queueLength)
+	return b.String()
+}
+
+// GetKnowledgeSyncMetrics 以 Prometheus 文本格式导出知识库同步指标
+func (ac *AdminController) GetKnowledgeSyncMetrics(c *gin.Context) {
+	queueLength := 0
+	if knowledgeSyncQueue != nil {
+		queueLength = len(knowledgeSyncQueue)
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(knowledgeSyncMetrics.render(queueLength)))
+}
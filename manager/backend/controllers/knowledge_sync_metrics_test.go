@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKnowledgeSyncOutcome(t *testing.T) {
+	if got := knowledgeSyncOutcome(nil); got != knowledgeSyncOutcomeSuccess {
+		t.Fatalf("nil err outcome = %q", got)
+	}
+	if got := knowledgeSyncOutcome(fmt.Errorf("wrap: %w", errKnowledgeSyncCanceled)); got != knowledgeSyncOutcomeCanceled {
+		t.Fatalf("canceled outcome = %q", got)
+	}
+	if got := knowledgeSyncOutcome(errors.New("boom")); got != knowledgeSyncOutcomeFailure {
+		t.Fatalf("failure outcome = %q", got)
+	}
+}
+
+func TestKnowledgeSyncMetricsRender(t *testing.T) {
+	m := newKnowledgeSyncMetricsRegistry()
+	m.incSync("Dify", "doc_upsert", knowledgeSyncOutcomeSuccess)
+	m.incSync("dify", "doc_upsert", knowledgeSyncOutcomeSuccess)
+	m.incSync("", "delete", knowledgeSyncOutcomeFailure)
+	m.observeParse("weknora", 3*time.Second)
+	m.observeParse("weknora", 90*time.Second)
+	done := m.trackInFlight("doc_upsert")
+
+	out := m.render(4)
+	for _, want := range []string{
+		`xiaozhi_knowledge_sync_total{provider="dify",job="doc_upsert",outcome="success"} 2`,
+		`xiaozhi_knowledge_sync_total{provider="unknown",job="delete",outcome="failure"} 1`,
+		`xiaozhi_knowledge_parse_duration_seconds_bucket{provider="weknora",le="1"} 0`,
+		`xiaozhi_knowledge_parse_duration_seconds_bucket{provider="weknora",le="5"} 1`,
+		`xiaozhi_knowledge_parse_duration_seconds_bucket{provider="weknora",le="120"} 2`,
+		`xiaozhi_knowledge_parse_duration_seconds_bucket{provider="weknora",le="+Inf"} 2`,
+		`xiaozhi_knowledge_parse_duration_seconds_sum{provider="weknora"} 93`,
+		`xiaozhi_knowledge_parse_duration_seconds_count{provider="weknora"} 2`,
+		`xiaozhi_knowledge_sync_in_flight{job="doc_upsert"} 1`,
+		`xiaozhi_knowledge_sync_queue_length 4`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("render output missing %q:\n%s", want, out)
+		}
+	}
+
+	done()
+	if out := m.render(0); !strings.Contains(out, `xiaozhi_knowledge_sync_in_flight{job="doc_upsert"} 0`) {
+		t.Fatalf("in-flight gauge not decremented:\n%s", out)
+	}
+}
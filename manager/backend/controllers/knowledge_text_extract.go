@@ -0,0 +1,757 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// knowledgeTextExtractableExt 支持服务端提取文本的文件扩展名
+var knowledgeTextExtractableExt = map[string]struct{}{
+	".txt":      {},
+	".text":     {},
+	".md":       {},
+	".markdown": {},
+	".csv":      {},
+	".log":      {},
+	".json":     {},
+	".yml":      {},
+	".yaml":     {},
+	".pdf":      {},
+	".docx":     {},
+}
+
+// knowledgeExtractMaxStreamBytes 单个 PDF 流解压后的上限，防止压缩炸弹
+const knowledgeExtractMaxStreamBytes = 16 * 1024 * 1024
+
+// knowledgeExtractMaxTotalBytes 单个 PDF 全部流解压后的总上限，防止大量压缩流累积耗尽内存
+const knowledgeExtractMaxTotalBytes = 64 * 1024 * 1024
+
+// knowledgeTextExtraction 上传文件的文本提取结果，随上传响应返回
+type knowledgeTextExtraction struct {
+	Attempted bool   `json:"attempted"`
+	OK        bool   `json:"ok"`
+	Chars     int    `json:"chars,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// FallbackToFile 提取失败后改为按原文件上传（provider 支持文件时）
+	FallbackToFile bool `json:"fallback_to_file,omitempty"`
+}
+
+// knowledgeTextExtractSupportedText 可提取文本的格式说明
+func knowledgeTextExtractSupportedText() string {
+	exts := make([]string, 0, len(knowledgeTextExtractableExt))
+	for ext := range knowledgeTextExtractableExt {
+		exts = append(exts, strings.TrimPrefix(ext, "."))
+	}
+	sort.Strings(exts)
+	return strings.Join(exts, ", ")
+}
+
+// knowledgeProviderTextOnly provider 是否只接受文本文档（knowledge_search 配置 text_only=true），此时上传文件需先提取文本
+func knowledgeProviderTextOnly(providerData map[string]interface{}) bool {
+	return parseKnowledgeSearchBool(providerData["text_only"], false)
+}
+
+// extractKnowledgeDocumentText 按扩展名从上传文件中提取纯文本
+func extractKnowledgeDocumentText(fileName string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if _, ok := knowledgeTextExtractableExt[ext]; !ok {
+		return "", fmt.Errorf("不支持从 %s 文件提取文本，支持格式: %s", ext, knowledgeTextExtractSupportedText())
+	}
+
+	var text string
+	var err error
+	switch ext {
+	case ".pdf":
+		text, err = extractPDFText(data)
+	case ".docx":
+		text, err = extractDOCXText(data)
+	default:
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("文本文件不是有效的 UTF-8 编码")
+		}
+		text = string(data)
+	}
+	if err != nil {
+		return "", err
+	}
+	text = normalizeExtractedText(text)
+	if text == "" {
+		return "", fmt.Errorf("未从文件中提取到文本内容")
+	}
+	return text, nil
+}
+
+// normalizeExtractedText 统一换行、去除行尾空白并压缩连续空行
+func normalizeExtractedText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// extractDOCXText 读取 word/document.xml，段落与换行保留为换行，表格单元格以制表符分隔
+func extractDOCXText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("解析 DOCX 失败: %w", err)
+	}
+	var docFile *zip.File
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			docFile = f
+			break
+		}
+	}
+	if docFile == nil {
+		return "", fmt.Errorf("解析 DOCX 失败: 缺少 word/document.xml")
+	}
+	rc, err := docFile.Open()
+	if err != nil {
+		return "", fmt.Errorf("解析 DOCX 失败: %w", err)
+	}
+	defer rc.Close()
+
+	var sb strings.Builder
+	dec := xml.NewDecoder(io.LimitReader(rc, knowledgeExtractMaxStreamBytes))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("解析 DOCX 失败: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteByte('\n')
+			case "tc":
+				sb.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+var (
+	pdfObjectRe    = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfFontRefRe   = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R`)
+	pdfFontDictRe  = regexp.MustCompile(`/Font\s*<<([^>]*)>>`)
+	pdfFontRefDict = regexp.MustCompile(`/Font\s+(\d+)\s+\d+\s+R`)
+	pdfObjStmNRe   = regexp.MustCompile(`/N\s+(\d+)`)
+	pdfObjStmFirst = regexp.MustCompile(`/First\s+(\d+)`)
+	pdfToUnicodeRe = regexp.MustCompile(`/ToUnicode\s+(\d+)\s+\d+\s+R`)
+	pdfLengthRe    = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+)
+
+// pdfObject PDF 间接对象：字典部分与（解码后的）流数据
+type pdfObject struct {
+	dict   string
+	stream []byte
+}
+
+// extractPDFText 从未加密 PDF 的内容流中提取文本：支持 FlateDecode 压缩流与 ToUnicode 映射；
+// 扫描件（仅图片）或使用未提供 ToUnicode 的自定义编码字体时无法提取
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data[:min(len(data), 1024)]), []byte("%PDF")) {
+		return "", fmt.Errorf("解析 PDF 失败: 文件头无效")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", fmt.Errorf("PDF 已加密，无法提取文本")
+	}
+
+	objects, err := parsePDFObjects(data)
+	if err != nil {
+		return "", err
+	}
+
+	// 字体资源名 → ToUnicode 映射（各页通常使用一致的资源名，按名称合并）
+	cmaps := make(map[int]map[string]string)
+	fontMaps := make(map[string]map[string]string)
+	for _, obj := range objects {
+		var fontDicts []string
+		for _, m := range pdfFontDictRe.FindAllStringSubmatch(obj.dict, -1) {
+			fontDicts = append(fontDicts, m[1])
+		}
+		for _, m := range pdfFontRefDict.FindAllStringSubmatch(obj.dict, -1) {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				fontDicts = append(fontDicts, objects[n].dict)
+			}
+		}
+		for _, fontDict := range fontDicts {
+			for _, ref := range pdfFontRefRe.FindAllStringSubmatch(fontDict, -1) {
+				fontNum, _ := strconv.Atoi(ref[2])
+				font, ok := objects[fontNum]
+				if !ok {
+					continue
+				}
+				m := pdfToUnicodeRe.FindStringSubmatch(font.dict)
+				if m == nil {
+					continue
+				}
+				cmapNum, _ := strconv.Atoi(m[1])
+				if _, ok := cmaps[cmapNum]; !ok {
+					if cmapObj, ok := objects[cmapNum]; ok {
+						cmaps[cmapNum] = parsePDFToUnicodeCMap(cmapObj.stream)
+					}
+				}
+				if cmap := cmaps[cmapNum]; len(cmap) > 0 {
+					fontMaps[ref[1]] = cmap
+				}
+			}
+		}
+	}
+
+	nums := make([]int, 0, len(objects))
+	for num := range objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	var sb strings.Builder
+	for _, num := range nums {
+		obj := objects[num]
+		if len(obj.stream) == 0 || strings.Contains(obj.dict, "/Image") || strings.Contains(obj.dict, "/ObjStm") || strings.Contains(obj.dict, "/XRef") {
+			continue
+		}
+		if bytes.Contains(obj.stream, []byte("begincmap")) || !bytes.Contains(obj.stream, []byte("BT")) {
+			continue
+		}
+		sb.WriteString(extractPDFContentStreamText(obj.stream, fontMaps))
+		sb.WriteByte('\n')
+	}
+
+	text := sb.String()
+	printable := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			printable++
+		}
+	}
+	if printable == 0 {
+		return "", fmt.Errorf("未能从 PDF 中提取文本（可能为扫描件或字体未提供 ToUnicode 映射）")
+	}
+	return text, nil
+}
+
+// parsePDFObjects 扫描文件中的间接对象，解码 FlateDecode 流；不支持的过滤器保留空流，
+// 解压后总量超过 knowledgeExtractMaxTotalBytes 时返回错误
+func parsePDFObjects(data []byte) (map[int]pdfObject, error) {
+	objects := make(map[int]pdfObject)
+	remaining := knowledgeExtractMaxTotalBytes
+	locs := pdfObjectRe.FindAllSubmatchIndex(data, -1)
+	for i, loc := range locs {
+		num, err := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		if err != nil {
+			continue
+		}
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		body := data[loc[1]:end]
+		if idx := bytes.Index(body, []byte("endobj")); idx >= 0 && !bytes.Contains(body[:idx], []byte("stream")) {
+			body = body[:idx]
+		}
+
+		obj := pdfObject{dict: string(body)}
+		if idx := bytes.Index(body, []byte("stream")); idx >= 0 {
+			obj.dict = string(body[:idx])
+			raw := body[idx+len("stream"):]
+			raw = bytes.TrimPrefix(raw, []byte("\r"))
+			raw = bytes.TrimPrefix(raw, []byte("\n"))
+			if m := pdfLengthRe.FindStringSubmatch(obj.dict); m != nil && m[2] == "" {
+				if n, err := strconv.Atoi(m[1]); err == nil && n <= len(raw) {
+					raw = raw[:n]
+				}
+			} else if idx := bytes.Index(raw, []byte("endstream")); idx >= 0 {
+				raw = raw[:idx]
+			}
+			decoded, inflated := decodePDFStream(obj.dict, raw, remaining)
+			if inflated {
+				if len(decoded) > remaining {
+					return nil, fmt.Errorf("解析 PDF 失败: 解压后内容超过%dMB上限", knowledgeExtractMaxTotalBytes/1024/1024)
+				}
+				remaining -= len(decoded)
+			}
+			obj.stream = decoded
+		}
+		objects[num] = obj
+	}
+
+	// PDF 1.5+ 可将字体等非流对象压缩在对象流（/Type /ObjStm）中
+	for _, obj := range objects {
+		if len(obj.stream) > 0 && strings.Contains(obj.dict, "/ObjStm") {
+			for num, dict := range parsePDFObjectStream(obj) {
+				if _, exists := objects[num]; !exists {
+					objects[num] = pdfObject{dict: dict}
+				}
+			}
+		}
+	}
+	return objects, nil
+}
+
+// parsePDFObjectStream 解析对象流：头部为 N 对“对象号 偏移”，偏移相对 /First
+func parsePDFObjectStream(obj pdfObject) map[int]string {
+	nm := pdfObjStmNRe.FindStringSubmatch(obj.dict)
+	fm := pdfObjStmFirst.FindStringSubmatch(obj.dict)
+	if nm == nil || fm == nil {
+		return nil
+	}
+	n, _ := strconv.Atoi(nm[1])
+	first, _ := strconv.Atoi(fm[1])
+	if first <= 0 || first > len(obj.stream) {
+		return nil
+	}
+	header := strings.Fields(string(obj.stream[:first]))
+	type entry struct{ num, offset int }
+	var entries []entry
+	for i := 0; i+1 < len(header) && len(entries) < n; i += 2 {
+		num, err1 := strconv.Atoi(header[i])
+		offset, err2 := strconv.Atoi(header[i+1])
+		// 偏移来自文件内容，负数或越界（含溢出）均视为损坏
+		if err1 != nil || err2 != nil || offset < 0 || offset > len(obj.stream)-first {
+			return nil
+		}
+		entries = append(entries, entry{num, first + offset})
+	}
+	out := make(map[int]string, len(entries))
+	for i, e := range entries {
+		end := len(obj.stream)
+		if i+1 < len(entries) && entries[i+1].offset >= e.offset {
+			end = entries[i+1].offset
+		}
+		out[e.num] = string(obj.stream[e.offset:end])
+	}
+	return out
+}
+
+// decodePDFStream 解码流数据：无过滤器原样返回，FlateDecode 解压，其他过滤器（图片等）返回 nil；
+// 解压时最多读取 budget+1 字节（且不超过单流上限），inflated 表示结果为新解压的数据，供调用方累计总量
+func decodePDFStream(dict string, raw []byte, budget int) ([]byte, bool) {
+	if !strings.Contains(dict, "/Filter") {
+		return raw, false
+	}
+	if !strings.Contains(dict, "/FlateDecode") {
+		return nil, false
+	}
+	for _, other := range []string{"/DCTDecode", "/JPXDecode", "/LZWDecode", "/ASCII85Decode", "/ASCIIHexDecode", "/RunLengthDecode", "/CCITTFaxDecode", "/JBIG2Decode", "/Predictor"} {
+		if strings.Contains(dict, other) {
+			return nil, false
+		}
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer zr.Close()
+	out, _ := io.ReadAll(io.LimitReader(zr, int64(min(knowledgeExtractMaxStreamBytes, budget+1))))
+	return out, true
+}
+
+// parsePDFToUnicodeCMap 解析 ToUnicode CMap 的 bfchar / bfrange，key 为源编码的十六进制（大写）
+func parsePDFToUnicodeCMap(stream []byte) map[string]string {
+	cmap := make(map[string]string)
+	lex := &pdfLexer{data: stream}
+	var operands []pdfToken
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		if tok.kind != pdfTokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		switch tok.text {
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				cmap[strings.ToUpper(hex.EncodeToString(operands[i].bytes))] = decodeUTF16BE(operands[i+1].bytes)
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); {
+				lo, hi, dst := operands[i].bytes, operands[i+1].bytes, operands[i+2]
+				i += 3
+				if dst.kind == pdfTokenArrayStart {
+					// [<dst1> <dst2> ...] 逐个对应
+					var dsts [][]byte
+					for i < len(operands) && operands[i].kind != pdfTokenArrayEnd {
+						dsts = append(dsts, operands[i].bytes)
+						i++
+					}
+					i++
+					for k, d := range dsts {
+						cmap[pdfCodeHex(lo, k)] = decodeUTF16BE(d)
+					}
+					continue
+				}
+				count := pdfCodeValue(hi) - pdfCodeValue(lo)
+				if count < 0 || count > 0xFFFF {
+					continue
+				}
+				base := []rune(decodeUTF16BE(dst.bytes))
+				if len(base) == 0 {
+					continue
+				}
+				for k := 0; k <= count; k++ {
+					r := append([]rune(nil), base...)
+					r[len(r)-1] += rune(k)
+					cmap[pdfCodeHex(lo, k)] = string(r)
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return cmap
+}
+
+func pdfCodeValue(code []byte) int {
+	v := 0
+	for _, b := range code {
+		v = v<<8 | int(b)
+	}
+	return v
+}
+
+// pdfCodeHex 源编码 code+offset 的定长十六进制表示
+func pdfCodeHex(code []byte, offset int) string {
+	v := pdfCodeValue(code) + offset
+	return fmt.Sprintf("%0*X", len(code)*2, v)
+}
+
+func decodeUTF16BE(b []byte) string {
+	if len(b) < 2 {
+		return string(b)
+	}
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+// extractPDFContentStreamText 解释内容流中的文本操作符（Tf/Tj/TJ/'/"/T*/Td/TD/Tm/ET）
+func extractPDFContentStreamText(stream []byte, fontMaps map[string]map[string]string) string {
+	var sb strings.Builder
+	var cmap map[string]string
+	lex := &pdfLexer{data: stream}
+	var operands []pdfToken
+	newline := func() {
+		s := sb.String()
+		if len(s) > 0 && !strings.HasSuffix(s, "\n") {
+			sb.WriteByte('\n')
+		}
+	}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		if tok.kind != pdfTokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		switch tok.text {
+		case "Tf":
+			cmap = nil
+			for _, op := range operands {
+				if op.kind == pdfTokenName {
+					cmap = fontMaps[op.text]
+				}
+			}
+		case "Tj":
+			if n := len(operands); n > 0 {
+				sb.WriteString(decodePDFString(operands[n-1], cmap))
+			}
+		case "'", "\"":
+			newline()
+			if n := len(operands); n > 0 {
+				sb.WriteString(decodePDFString(operands[n-1], cmap))
+			}
+		case "TJ":
+			for _, op := range operands {
+				switch op.kind {
+				case pdfTokenString, pdfTokenHexString:
+					sb.WriteString(decodePDFString(op, cmap))
+				case pdfTokenNumber:
+					// 大的负向字距通常表示词间空格
+					if v, err := strconv.ParseFloat(op.text, 64); err == nil && v < -200 {
+						sb.WriteByte(' ')
+					}
+				}
+			}
+		case "T*", "ET":
+			newline()
+		case "Td", "TD":
+			if n := len(operands); n >= 2 {
+				if ty, err := strconv.ParseFloat(operands[n-1].text, 64); err == nil && ty != 0 {
+					newline()
+				}
+			}
+		case "Tm":
+			newline()
+		}
+		operands = operands[:0]
+	}
+	return sb.String()
+}
+
+// decodePDFString 有 ToUnicode 映射时按 2 字节（回退 1 字节）编码查表，否则按 PDFDocEncoding 近似为 Latin-1
+func decodePDFString(tok pdfToken, cmap map[string]string) string {
+	b := tok.bytes
+	if len(cmap) > 0 {
+		var sb strings.Builder
+		for i := 0; i < len(b); {
+			if i+1 < len(b) {
+				if s, ok := cmap[strings.ToUpper(hex.EncodeToString(b[i:i+2]))]; ok {
+					sb.WriteString(s)
+					i += 2
+					continue
+				}
+			}
+			if s, ok := cmap[strings.ToUpper(hex.EncodeToString(b[i:i+1]))]; ok {
+				sb.WriteString(s)
+			}
+			i++
+		}
+		return sb.String()
+	}
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		return decodeUTF16BE(b[2:])
+	}
+	var sb strings.Builder
+	for _, c := range b {
+		if c >= 0x20 || c == '\t' {
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
+
+type pdfTokenKind int
+
+const (
+	pdfTokenOperator pdfTokenKind = iota
+	pdfTokenNumber
+	pdfTokenName
+	pdfTokenString
+	pdfTokenHexString
+	pdfTokenArrayStart
+	pdfTokenArrayEnd
+	pdfTokenOther
+)
+
+type pdfToken struct {
+	kind  pdfTokenKind
+	text  string
+	bytes []byte
+}
+
+// pdfLexer 内容流/CMap 的简易词法分析器
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+	if l.pos >= len(l.data) {
+		return pdfToken{}, false
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return pdfToken{kind: pdfTokenString, bytes: l.readLiteralString()}, true
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return pdfToken{kind: pdfTokenOther, text: "<<"}, true
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfToken{kind: pdfTokenOther, text: ">>"}, true
+	case c == '<':
+		return pdfToken{kind: pdfTokenHexString, bytes: l.readHexString()}, true
+	case c == '[':
+		l.pos++
+		return pdfToken{kind: pdfTokenArrayStart}, true
+	case c == ']':
+		l.pos++
+		return pdfToken{kind: pdfTokenArrayEnd}, true
+	case c == '/':
+		l.pos++
+		return pdfToken{kind: pdfTokenName, text: l.readRegular()}, true
+	case c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return pdfToken{kind: pdfTokenOther, text: string(c)}, true
+	}
+
+	word := l.readRegular()
+	if word == "" {
+		l.pos++
+		return pdfToken{kind: pdfTokenOther}, true
+	}
+	if _, err := strconv.ParseFloat(word, 64); err == nil {
+		return pdfToken{kind: pdfTokenNumber, text: word}, true
+	}
+	if word == "BI" {
+		// 内联图片数据可能包含任意字节，直接跳到 EI
+		if idx := bytes.Index(l.data[l.pos:], []byte("EI")); idx >= 0 {
+			l.pos += idx + 2
+		} else {
+			l.pos = len(l.data)
+		}
+		return pdfToken{kind: pdfTokenOther}, true
+	}
+	return pdfToken{kind: pdfTokenOperator, text: word}, true
+}
+
+func (l *pdfLexer) readRegular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+func (l *pdfLexer) readHexString() []byte {
+	l.pos++ // '<'
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // '>'
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func (l *pdfLexer) readLiteralString() []byte {
+	l.pos++ // '('
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for k := 0; k < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; k++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
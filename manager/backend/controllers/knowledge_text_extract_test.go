@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildTestDOCX(t *testing.T, documentXML string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(documentXML))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// buildTestPDF 按顺序生成间接对象（对象号从 1 开始），不写 xref（提取器按对象扫描）
+func buildTestPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func pdfStreamObject(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func flate(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(data))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestExtractDOCXText(t *testing.T) {
+	docx := buildTestDOCX(t, `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>第一段</w:t></w:r><w:r><w:t xml:space="preserve"> 继续</w:t></w:r></w:p>
+<w:p><w:r><w:t>A</w:t><w:tab/><w:t>B</w:t><w:br/><w:t>C</w:t></w:r></w:p>
+</w:body></w:document>`)
+	text, err := extractKnowledgeDocumentText("report.DOCX", docx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "第一段 继续\nA\tB\nC"; text != want {
+		t.Fatalf("text = %q, want %q", text, want)
+	}
+
+	if _, err := extractKnowledgeDocumentText("broken.docx", []byte("not a zip")); err == nil {
+		t.Fatal("expected error for invalid docx")
+	}
+}
+
+func TestExtractPDFTextLiteralStrings(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) -50 (ld) -300 (again)] TJ ET"
+	pdf := buildTestPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		pdfStreamObject("", []byte(content)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+	text, err := extractKnowledgeDocumentText("a.pdf", pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Hello (PDF)\nWorld again"; text != want {
+		t.Fatalf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExtractPDFTextToUnicode(t *testing.T) {
+	// 内容流使用 2 字节 CID 编码，经 ToUnicode 映射为中文
+	cmap := `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0001> <4F60>
+<0002> <597D>
+endbfchar
+1 beginbfrange
+<0003> <0004> <4E16>
+endbfrange
+endcmap
+end end`
+	content := "BT /F1 12 Tf 72 720 Td <00010002> Tj 0 -14 Td <00030004> Tj ET"
+	pdf := buildTestPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font 6 0 R >> /Contents 4 0 R >>",
+		pdfStreamObject("/Filter /FlateDecode", flate(t, content)),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /SimSun /ToUnicode 7 0 R >>",
+		"<< /F1 5 0 R >>",
+		pdfStreamObject("/Filter /FlateDecode", flate(t, cmap)),
+	)
+	text, err := extractKnowledgeDocumentText("zh.pdf", pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "你好\n世丗"; text != want {
+		t.Fatalf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExtractPDFTextFailures(t *testing.T) {
+	// 仅含图片、无文本操作符的“扫描件”
+	scanned := buildTestPDF(
+		"<< /Type /Catalog >>",
+		pdfStreamObject("/Type /XObject /Subtype /Image /Filter /DCTDecode", []byte{0xff, 0xd8, 0xff}),
+		pdfStreamObject("", []byte("q 100 0 0 100 0 0 cm /Im1 Do Q")),
+	)
+	if _, err := extractKnowledgeDocumentText("scan.pdf", scanned); err == nil || !strings.Contains(err.Error(), "扫描件") {
+		t.Fatalf("scanned pdf err = %v", err)
+	}
+	if _, err := extractKnowledgeDocumentText("enc.pdf", buildTestPDF("<< /Encrypt 2 0 R >>")); err == nil {
+		t.Fatal("expected error for encrypted pdf")
+	}
+	if _, err := extractKnowledgeDocumentText("x.pdf", []byte("hello")); err == nil {
+		t.Fatal("expected error for invalid pdf header")
+	}
+}
+
+func TestExtractPDFTextMalformedObjectStream(t *testing.T) {
+	// 对象流头部偏移为负数或溢出时应视为损坏而不是 panic
+	for _, header := range []string{"5 -9 ", "5 9223372036854775807 "} {
+		body := header + "<< /Type /Font >>"
+		objStm := pdfObject{dict: fmt.Sprintf("<< /Type /ObjStm /N 1 /First %d >>", len(header)), stream: []byte(body)}
+		if got := parsePDFObjectStream(objStm); got != nil {
+			t.Fatalf("header %q: got %v, want nil", header, got)
+		}
+
+		pdf := buildTestPDF(
+			"<< /Type /Catalog >>",
+			pdfStreamObject(fmt.Sprintf("/Type /ObjStm /N 1 /First %d", len(header)), []byte(body)),
+		)
+		if _, err := extractKnowledgeDocumentText("bad.pdf", pdf); err == nil {
+			t.Fatalf("header %q: expected extraction error", header)
+		}
+	}
+}
+
+func TestExtractPDFTextTotalDecodedLimit(t *testing.T) {
+	// 每个流都在单流上限内，但累计解压量超过总上限
+	stream := flate(t, strings.Repeat("\x00", knowledgeExtractMaxStreamBytes))
+	objects := []string{"<< /Type /Catalog >>"}
+	for i := 0; i <= knowledgeExtractMaxTotalBytes/knowledgeExtractMaxStreamBytes; i++ {
+		objects = append(objects, pdfStreamObject("/Filter /FlateDecode", stream))
+	}
+	if _, err := extractKnowledgeDocumentText("bomb.pdf", buildTestPDF(objects...)); err == nil || !strings.Contains(err.Error(), "上限") {
+		t.Fatalf("err = %v, want total decoded limit error", err)
+	}
+}
+
+func TestExtractPlainTextAndUnsupported(t *testing.T) {
+	text, err := extractKnowledgeDocumentText("notes.md", []byte("\xef\xbb\xbf# 标题\r\n\r\n\r\n\r\n正文  \r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# 标题\n\n正文"; text != want {
+		t.Fatalf("text = %q, want %q", text, want)
+	}
+	if _, err := extractKnowledgeDocumentText("bad.txt", []byte{0xff, 0xfe, 0x00}); err == nil {
+		t.Fatal("expected error for invalid utf-8")
+	}
+	if _, err := extractKnowledgeDocumentText("slides.pptx", []byte("x")); err == nil {
+		t.Fatal("expected error for unsupported extension")
+	}
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// knowledgeURLFetchMaxBytes 抓取网页的响应体上限
+	knowledgeURLFetchMaxBytes = 5 * 1024 * 1024
+	knowledgeURLFetchTimeout  = 15 * time.Second
+	knowledgeURLMaxLength     = 2048
+	knowledgeURLMaxRedirects  = 5
+)
+
+var errKnowledgeURLForbiddenAddress = errors.New("不允许抓取内网或本机地址")
+
+// knowledgeURLCGNATRange 运营商级 NAT 地址段，net.IP.IsPrivate 不包含
+var knowledgeURLCGNATRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicFetchIP 判断抓取目标地址是否为公网地址，拒绝本机、内网、链路本地、未指定及组播地址
+func isPublicFetchIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if ip4[0] == 0 || knowledgeURLCGNATRange.Contains(ip4) {
+			return false
+		}
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// newKnowledgeURLFetchClient 创建抓取网页用的客户端：在 DNS 解析后的建连阶段按 allowIP 校验目标地址
+// （每次重定向都会重新建连，可防止 DNS 重绑定与重定向绕过），重定向时重新校验地址并限制次数；
+// 不使用环境变量代理，否则校验的将是代理地址
+func newKnowledgeURLFetchClient(allowIP func(net.IP) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowIP(ip) {
+				return errKnowledgeURLForbiddenAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: knowledgeURLFetchTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= knowledgeURLMaxRedirects {
+				return fmt.Errorf("重定向次数超过%d次", knowledgeURLMaxRedirects)
+			}
+			_, err := validateKnowledgeDocumentURL(req.URL.String())
+			return err
+		},
+	}
+}
+
+var knowledgeURLFetchClient = newKnowledgeURLFetchClient(isPublicFetchIP)
+
+var (
+	htmlTitlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlInvisiblePattern = regexp.MustCompile(`(?is)<(script|style|noscript|template|svg|head|title)\b[^>]*>.*?</(script|style|noscript|template|svg|head|title)\s*>`)
+	htmlCommentPattern   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlBlockTagPattern  = regexp.MustCompile(`(?i)</?(p|div|br|hr|li|ul|ol|tr|table|section|article|header|footer|blockquote|pre|h[1-6])\b[^>]*>`)
+	htmlCellTagPattern   = regexp.MustCompile(`(?i)</t[dh]\s*>`)
+	htmlTagPattern       = regexp.MustCompile(`(?s)<[^>]*>`)
+	inlineSpacePattern   = regexp.MustCompile(`[ \t\f\v\x{00a0}]+`)
+)
+
+// validateKnowledgeDocumentURL 校验待抓取的网页地址，仅允许 http/https
+func validateKnowledgeDocumentURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("URL 不能为空")
+	}
+	if len(raw) > knowledgeURLMaxLength {
+		return nil, fmt.Errorf("URL 长度不能超过%d个字符", knowledgeURLMaxLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("URL 格式无效")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("仅支持 http/https 地址")
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("URL 缺少主机名")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("URL 不能包含用户名或密码")
+	}
+	u.Fragment = ""
+	return u, nil
+}
+
+// fetchKnowledgeURLText 抓取网页并提取可读文本，返回页面标题与正文
+func fetchKnowledgeURLText(ctx context.Context, target *url.URL) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return "", "", fmt.Errorf("构建请求失败: %v", err)
+	}
+	req.Header.Set("User-Agent", "xiaozhi-knowledge-fetcher/1.0")
+	req.Header.Set("Accept", "text/html,text/plain,text/markdown;q=0.9,*/*;q=0.1")
+	resp, err := knowledgeURLFetchClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("抓取网页失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", "", fmt.Errorf("抓取网页失败: HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > knowledgeURLFetchMaxBytes {
+		return "", "", fmt.Errorf("网页内容超过%dMB上限", knowledgeURLFetchMaxBytes/1024/1024)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, knowledgeURLFetchMaxBytes+1))
+	if err != nil {
+		return "", "", fmt.Errorf("读取网页内容失败: %v", err)
+	}
+	if len(data) > knowledgeURLFetchMaxBytes {
+		return "", "", fmt.Errorf("网页内容超过%dMB上限", knowledgeURLFetchMaxBytes/1024/1024)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		if !utf8.Valid(data) {
+			return "", "", fmt.Errorf("网页不是有效的 UTF-8 编码")
+		}
+		title, text := extractHTMLText(string(data))
+		if text == "" {
+			return "", "", fmt.Errorf("未从网页中提取到文本内容")
+		}
+		return title, text, nil
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json":
+		text, err := extractKnowledgeDocumentText("page.txt", data)
+		return "", text, err
+	default:
+		return "", "", fmt.Errorf("不支持的网页内容类型: %s", mediaType)
+	}
+}
+
+// extractHTMLText 去除脚本、样式与标签，块级元素保留为换行，返回标题与正文
+func extractHTMLText(doc string) (string, string) {
+	title := ""
+	if m := htmlTitlePattern.FindStringSubmatch(doc); len(m) == 2 {
+		title = strings.TrimSpace(inlineSpacePattern.ReplaceAllString(html.UnescapeString(htmlTagPattern.ReplaceAllString(m[1], "")), " "))
+	}
+	body := htmlCommentPattern.ReplaceAllString(doc, "")
+	body = htmlInvisiblePattern.ReplaceAllString(body, "")
+	body = htmlBlockTagPattern.ReplaceAllString(body, "\n")
+	body = htmlCellTagPattern.ReplaceAllString(body, "\t")
+	body = htmlTagPattern.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(inlineSpacePattern.ReplaceAllString(line, " "))
+	}
+	return title, normalizeExtractedText(strings.Join(lines, "\n"))
+}
+
+// AddKnowledgeDocumentFromURL 抓取网页正文并作为文本文档加入知识库
+// POST /api/user/knowledge-bases/:id/documents/from-url {"url": "...", "name": "可选", "metadata": {...}}
+func (uc *UserController) AddKnowledgeDocumentFromURL(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	kbID, _ := strconv.Atoi(c.Param("id"))
+	if kbID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的知识库ID"})
+		return
+	}
+	kb, err := uc.getOwnedKnowledgeBase(userID.(uint), uint(kbID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		URL      string                 `json:"url" binding:"required"`
+		Name     string                 `json:"name" binding:"max=200"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	target, err := validateKnowledgeDocumentURL(req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 未指定时记录来源地址，便于溯源
+	if _, ok := req.Metadata["source_url"]; !ok && utf8.RuneCountInString(target.String()) <= knowledgeDocumentMetadataMaxValueLen {
+		if req.Metadata == nil {
+			req.Metadata = make(map[string]interface{})
+		}
+		if len(req.Metadata) < knowledgeDocumentMetadataMaxKeys {
+			req.Metadata["source_url"] = target.String()
+		}
+	}
+	metadataJSON, err := normalizeKnowledgeDocumentMetadata(req.Metadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	title, content, err := fetchKnowledgeURLText(c.Request.Context(), target)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = title
+	}
+	if name == "" {
+		name = target.Host + target.Path
+	}
+
+	doc, enqueueErr, err := uc.createKnowledgeBaseDocumentRecord(kb.ID, name, content, metadataJSON)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文档失败"})
+		return
+	}
+	estimate := estimateKnowledgeContent(uc.DB, kb, content)
+	if enqueueErr != nil {
+		c.JSON(http.StatusCreated, gin.H{
+			"data":       doc,
+			"estimate":   estimate,
+			"warning":    "网页已抓取并创建文档，但同步任务入队失败",
+			"sync_error": enqueueErr.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": doc, "estimate": estimate, "message": "网页已抓取，文档已创建并提交异步同步"})
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// LLM 网关字段：json_data 中可选的 extra_headers（附加请求头）与 proxy_url（代理地址），
+// 用于部署在企业 LLM 网关之后或需要自定义鉴权头的场景
+
+const llmExtraHeadersMaxCount = 20
+
+// llmReservedHeaders 由 HTTP 客户端或鉴权逻辑维护的请求头，不允许通过 extra_headers 覆盖
+var llmReservedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Upgrade":           true,
+	"Te":                true,
+	"Trailer":           true,
+	"Proxy-Connection":  true,
+	"Keep-Alive":        true,
+}
+
+var llmProxySchemes = map[string]bool{"http": true, "https": true, "socks5": true}
+
+// isHTTPHeaderToken 校验请求头名称是否为 RFC 7230 token
+func isHTTPHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeLLMExtraHeaders 校验并规范化 extra_headers，返回以规范请求头名称为键的字符串映射
+func normalizeLLMExtraHeaders(raw interface{}) (map[string]string, error) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("extra_headers 必须是对象")
+	}
+	if len(obj) > llmExtraHeadersMaxCount {
+		return nil, fmt.Errorf("extra_headers 最多%d个", llmExtraHeadersMaxCount)
+	}
+	headers := make(map[string]string, len(obj))
+	for name, value := range obj {
+		name = strings.TrimSpace(name)
+		if !isHTTPHeaderToken(name) {
+			return nil, fmt.Errorf("extra_headers 请求头名称无效: %q", name)
+		}
+		canonical := http.CanonicalHeaderKey(name)
+		if llmReservedHeaders[canonical] {
+			return nil, fmt.Errorf("extra_headers 不允许设置 %s", canonical)
+		}
+		if _, exists := headers[canonical]; exists {
+			return nil, fmt.Errorf("extra_headers 请求头重复: %s", canonical)
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("extra_headers.%s 的值必须是字符串", canonical)
+		}
+		if strings.ContainsAny(str, "\r\n\x00") {
+			return nil, fmt.Errorf("extra_headers.%s 的值不能包含换行或控制字符", canonical)
+		}
+		headers[canonical] = strings.TrimSpace(str)
+	}
+	return headers, nil
+}
+
+// validateLLMProxyURL 校验 proxy_url，仅允许 http/https/socks5
+func validateLLMProxyURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("proxy_url 格式无效")
+	}
+	if !llmProxySchemes[strings.ToLower(u.Scheme)] {
+		return "", fmt.Errorf("proxy_url 仅支持 http/https/socks5")
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("proxy_url 缺少主机名")
+	}
+	return raw, nil
+}
+
+// normalizeLLMGatewayFields 校验 LLM 配置 json_data 中的 extra_headers 与 proxy_url，
+// 返回规范化后的 json_data（请求头名称统一为规范格式，空值字段移除）；未使用这两个字段时原样返回
+func normalizeLLMGatewayFields(jsonData string) (string, error) {
+	if strings.TrimSpace(jsonData) == "" {
+		return jsonData, nil
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		// JSON 格式问题由其他校验处理
+		return jsonData, nil
+	}
+	rawHeaders, hasHeaders := data["extra_headers"]
+	rawProxy, hasProxy := data["proxy_url"]
+	if !hasHeaders && !hasProxy {
+		return jsonData, nil
+	}
+
+	if hasHeaders {
+		if rawHeaders == nil {
+			delete(data, "extra_headers")
+		} else {
+			headers, err := normalizeLLMExtraHeaders(rawHeaders)
+			if err != nil {
+				return "", err
+			}
+			if len(headers) == 0 {
+				delete(data, "extra_headers")
+			} else {
+				data["extra_headers"] = headers
+			}
+		}
+	}
+	if hasProxy {
+		proxy, ok := rawProxy.(string)
+		if rawProxy != nil && !ok {
+			return "", fmt.Errorf("proxy_url 必须是字符串")
+		}
+		if strings.TrimSpace(proxy) == "" {
+			delete(data, "proxy_url")
+		} else {
+			normalized, err := validateLLMProxyURL(proxy)
+			if err != nil {
+				return "", err
+			}
+			data["proxy_url"] = normalized
+		}
+	}
+
+	out, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("json_data 编码失败: %v", err)
+	}
+	return string(out), nil
+}
+
+// llmGatewaySummary 返回 LLM 配置使用的网关字段摘要，请求头只列名称不返回值（可能含密钥）
+func llmGatewaySummary(jsonData string) map[string]interface{} {
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil
+	}
+	summary := make(map[string]interface{})
+	if headers, ok := data["extra_headers"].(map[string]interface{}); ok && len(headers) > 0 {
+		names := make([]string, 0, len(headers))
+		for name := range headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		summary["extra_header_names"] = names
+	}
+	if proxy, ok := data["proxy_url"].(string); ok && strings.TrimSpace(proxy) != "" {
+		if u, err := url.Parse(proxy); err == nil {
+			u.User = nil
+			summary["proxy_url"] = u.String()
+		}
+	}
+	if len(summary) == 0 {
+		return nil
+	}
+	return summary
+}
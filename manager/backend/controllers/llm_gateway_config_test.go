@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizeLLMGatewayFields(t *testing.T) {
+	out, err := normalizeLLMGatewayFields(`{"type":"openai","extra_headers":{"x-gateway-key":" secret ","X-Tenant":"t1"},"proxy_url":" http://user:pw@proxy:8080 "}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &data); err != nil {
+		t.Fatal(err)
+	}
+	headers := data["extra_headers"].(map[string]interface{})
+	if headers["X-Gateway-Key"] != "secret" || headers["X-Tenant"] != "t1" || len(headers) != 2 {
+		t.Fatalf("headers = %v", headers)
+	}
+	if data["proxy_url"] != "http://user:pw@proxy:8080" || data["type"] != "openai" {
+		t.Fatalf("data = %v", data)
+	}
+
+	summary := llmGatewaySummary(out)
+	names := summary["extra_header_names"].([]string)
+	if len(names) != 2 || names[0] != "X-Gateway-Key" || summary["proxy_url"] != "http://proxy:8080" {
+		t.Fatalf("summary = %v", summary)
+	}
+
+	// 未使用网关字段时原样返回
+	raw := `{"type":"openai","model_name":"m"}`
+	if out, err := normalizeLLMGatewayFields(raw); err != nil || out != raw {
+		t.Fatalf("untouched = %q, %v", out, err)
+	}
+	if summary := llmGatewaySummary(raw); summary != nil {
+		t.Fatalf("summary without gateway fields = %v", summary)
+	}
+
+	// 空值字段移除
+	out, err = normalizeLLMGatewayFields(`{"extra_headers":{},"proxy_url":""}`)
+	if err != nil || out != `{}` {
+		t.Fatalf("empty fields = %q, %v", out, err)
+	}
+
+	invalid := map[string]string{
+		`{"extra_headers":"x"}`:                   "必须是对象",
+		`{"extra_headers":{"bad header":"v"}}`:    "名称无效",
+		`{"extra_headers":{"Host":"evil"}}`:       "不允许设置 Host",
+		`{"extra_headers":{"X-A":1}}`:             "必须是字符串",
+		`{"extra_headers":{"X-A":"a\r\nB: c"}}`:   "换行",
+		`{"extra_headers":{"x-a":"1","X-A":"2"}}`: "重复",
+		`{"proxy_url":"ftp://proxy:21"}`:          "仅支持",
+		`{"proxy_url":"http://"}`:                 "缺少主机名",
+		`{"proxy_url":8080}`:                      "必须是字符串",
+	}
+	for input, want := range invalid {
+		_, err := normalizeLLMGatewayFields(input)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want contains %q", input, err, want)
+		}
+	}
+}
+
+func TestGenericConfigEndpointsNormalizeLLMGateway(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/configs", ac.CreateConfig)
+	r.PUT("/configs/:id", ac.UpdateConfig)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/configs", `{"type":"llm","name":"bad","config_id":"bad","json_data":"{\"extra_headers\":{\"Host\":\"evil\"}}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("create with Host header: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/configs", `{"type":"llm","name":"gw","config_id":"gw","json_data":"{\"extra_headers\":{\"x-tenant\":\" t1 \"}}"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var saved models.Config
+	if err := db.Where("config_id = ?", "gw").First(&saved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.JsonData != `{"extra_headers":{"X-Tenant":"t1"}}` {
+		t.Fatalf("stored json_data not normalized: %s", saved.JsonData)
+	}
+	if w := do(http.MethodPut, "/configs/1", `{"name":"gw","json_data":"{\"proxy_url\":\"ftp://proxy:21\"}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("update with bad proxy: code=%d body=%s", w.Code, w.Body.String())
+	}
+}
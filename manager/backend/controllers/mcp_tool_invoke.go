@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+	"xiaozhi/manager/backend/services/mcp_schema"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mcpToolInvokeTimeout = 30 * time.Second
+
+// mcpToolInvokeError 单工具调用失败，附带对应的 HTTP 状态码
+type mcpToolInvokeError struct {
+	status int
+	msg    string
+}
+
+func (e *mcpToolInvokeError) Error() string {
+	return e.msg
+}
+
+// InvokeMCPTool 经主程序执行智能体的单个 MCP 工具，调用前按工具 input_schema 校验参数，返回结果与耗时
+func (ac *AdminController) InvokeMCPTool(ctx context.Context, agentID, toolName string, args map[string]interface{}) (gin.H, error) {
+	if ac.WebSocketController == nil {
+		return nil, &mcpToolInvokeError{status: http.StatusServiceUnavailable, msg: "WebSocket 服务未初始化"}
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	tools, err := ac.WebSocketController.RequestMcpToolDetailsFromClient(ctx, agentID)
+	if err != nil {
+		return nil, &mcpToolInvokeError{status: http.StatusBadGateway, msg: "获取MCP工具列表失败: " + err.Error()}
+	}
+	var target *MCPTool
+	for i := range tools {
+		if tools[i].Name == toolName {
+			target = &tools[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, &mcpToolInvokeError{status: http.StatusNotFound, msg: "工具不存在: " + toolName}
+	}
+	if err := mcp_schema.ValidateArguments(target.InputSchema, args); err != nil {
+		return nil, &mcpToolInvokeError{status: http.StatusBadRequest, msg: "参数校验失败: " + err.Error()}
+	}
+
+	t0 := time.Now()
+	result, err := ac.WebSocketController.CallMcpToolFromClient(ctx, map[string]interface{}{
+		"agent_id":  agentID,
+		"tool_name": toolName,
+		"arguments": args,
+	})
+	elapsed := time.Since(t0).Milliseconds()
+	if err != nil {
+		logger.Warnf("[mcp_tool_invoke] agent=%s tool=%s 调用失败 elapsed=%dms: %v", agentID, toolName, elapsed, err)
+		return nil, &mcpToolInvokeError{status: http.StatusBadGateway, msg: "调用MCP工具失败: " + err.Error()}
+	}
+	logger.Infof("[mcp_tool_invoke] agent=%s tool=%s 调用成功 elapsed=%dms", agentID, toolName, elapsed)
+
+	ret := gin.H{
+		"agent_id":   agentID,
+		"tool_name":  toolName,
+		"arguments":  args,
+		"result":     result["result"],
+		"elapsed_ms": elapsed,
+	}
+	// 主程序侧工具执行耗时（不含 WebSocket 往返）
+	if toolMs, ok := result["elapsed_ms"]; ok {
+		ret["tool_elapsed_ms"] = toolMs
+	}
+	return ret, nil
+}
+
+// TestAgentMcpTool 管理员试调用智能体的单个MCP工具
+func (ac *AdminController) TestAgentMcpTool(c *gin.Context) {
+	agentID := c.Param("id")
+	var req struct {
+		ToolName  string                 `json:"tool_name" binding:"required"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	var agent models.Agent
+	if err := ac.DB.Where("id = ?", agentID).First(&agent).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "智能体不存在"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), mcpToolInvokeTimeout)
+	defer cancel()
+	result, err := ac.InvokeMCPTool(ctx, agentID, req.ToolName, req.Arguments)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if invokeErr, ok := err.(*mcpToolInvokeError); ok {
+			status = invokeErr.status
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
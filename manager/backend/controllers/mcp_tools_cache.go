@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+)
+
+// 智能体 MCP 工具列表缓存有效期：设备侧工具变化（如设备重连）最多延迟该时长可见，可用 refresh=true 跳过缓存
+const mcpToolsCacheTTL = 15 * time.Second
+
+// mcpToolsCacheEntry 单个智能体的工具列表缓存
+type mcpToolsCacheEntry struct {
+	tools    []MCPTool
+	loadedAt time.Time
+}
+
+// mcpToolsCache 按智能体缓存 RequestMcpToolDetailsFromClient 的结果，减少管理后台反复加载时对主程序的请求
+// generation 在每次失效时递增，查询期间发生失效则丢弃该次结果，避免旧数据覆盖新数据
+type mcpToolsCache struct {
+	mu         sync.Mutex
+	entries    map[string]mcpToolsCacheEntry
+	generation uint64
+}
+
+var agentMcpToolsCache mcpToolsCache
+
+// invalidateAll MCP 配置变更时清空全部缓存
+func (c *mcpToolsCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+	c.generation++
+}
+
+// invalidate 清除指定智能体的缓存
+func (c *mcpToolsCache) invalidate(agentID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, agentID)
+	c.generation++
+}
+
+// get 返回未过期的缓存及当前代数
+func (c *mcpToolsCache) get(agentID string, now time.Time) ([]MCPTool, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[agentID]
+	if ok && now.Sub(entry.loadedAt) < mcpToolsCacheTTL {
+		return entry.tools, true, c.generation
+	}
+	return nil, false, c.generation
+}
+
+// store 仅当查询期间未失效时写入缓存
+func (c *mcpToolsCache) store(agentID string, tools []MCPTool, generation uint64, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]mcpToolsCacheEntry)
+	}
+	c.entries[agentID] = mcpToolsCacheEntry{tools: tools, loadedAt: now}
+}
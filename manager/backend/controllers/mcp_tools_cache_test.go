@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type countingMcpToolsSource struct {
+	calls int
+}
+
+func (s *countingMcpToolsSource) RequestMcpToolDetailsFromClient(ctx context.Context, agentID string) ([]MCPTool, error) {
+	s.calls++
+	return []MCPTool{{Name: "get_weather"}}, nil
+}
+
+func TestMcpToolsCache(t *testing.T) {
+	var cache mcpToolsCache
+	now := time.Now()
+
+	_, ok, gen := cache.get("1", now)
+	if ok {
+		t.Fatal("empty cache hit")
+	}
+	cache.store("1", []MCPTool{{Name: "a"}}, gen, now)
+	if _, ok, _ := cache.get("1", now.Add(time.Second)); !ok {
+		t.Fatal("expected cached tools")
+	}
+	if _, ok, _ := cache.get("1", now.Add(mcpToolsCacheTTL)); ok {
+		t.Fatal("expected cache expired after TTL")
+	}
+
+	// 查询期间失效，旧结果不应写入
+	_, _, gen = cache.get("2", now)
+	cache.invalidateAll()
+	cache.store("2", []MCPTool{{Name: "stale"}}, gen, now)
+	if _, ok, _ := cache.get("2", now); ok {
+		t.Fatal("stale tools stored after invalidation")
+	}
+}
+
+func TestGetAgentMcpToolsCommonCached(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	agentMcpToolsCache.invalidateAll()
+	defer agentMcpToolsCache.invalidateAll()
+
+	source := &countingMcpToolsSource{}
+	r := gin.New()
+	r.GET("/agents/:id/mcp-tools", func(c *gin.Context) {
+		GetAgentMcpToolsCommon(c, c.Param("id"), source, func(string) error { return nil })
+	})
+	cached := func(path string) bool {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp struct {
+			Data struct {
+				Tools  []MCPTool `json:"tools"`
+				Cached bool      `json:"cached"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Data.Tools) != 1 {
+			t.Fatalf("tools = %+v", resp.Data.Tools)
+		}
+		return resp.Data.Cached
+	}
+
+	if cached("/agents/7/mcp-tools") || !cached("/agents/7/mcp-tools") || source.calls != 1 {
+		t.Fatalf("second load should hit cache, calls=%d", source.calls)
+	}
+	if cached("/agents/7/mcp-tools?refresh=true") || source.calls != 2 {
+		t.Fatalf("refresh should bypass cache, calls=%d", source.calls)
+	}
+	agentMcpToolsCache.invalidate("7")
+	if cached("/agents/7/mcp-tools") || source.calls != 3 {
+		t.Fatalf("invalidated agent should reload, calls=%d", source.calls)
+	}
+}
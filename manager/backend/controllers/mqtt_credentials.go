@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errMQTTSignatureKeyMissing = errors.New("MQTT服务配置未设置 signature_key")
+
+// loadMQTTSignatureKey 读取当前使用的 mqtt_server 配置（默认优先，否则第一条）中的 signature_key
+func loadMQTTSignatureKey(db *gorm.DB) (models.Config, string, error) {
+	var cfg models.Config
+	if err := db.Where("type = ?", "mqtt_server").Order("is_default DESC, id ASC").First(&cfg).Error; err != nil {
+		return cfg, "", err
+	}
+	var data struct {
+		SignatureKey string `json:"signature_key"`
+	}
+	if strings.TrimSpace(cfg.JsonData) != "" {
+		if err := json.Unmarshal([]byte(cfg.JsonData), &data); err != nil {
+			return cfg, "", err
+		}
+	}
+	key := strings.TrimSpace(data.SignatureKey)
+	if key == "" {
+		return cfg, "", errMQTTSignatureKeyMissing
+	}
+	return cfg, key, nil
+}
+
+type deviceMQTTCredential struct {
+	DeviceID string `json:"device_id"`
+	ClientID string `json:"client_id"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// newDeviceMQTTCredential 按 OTA 下发凭据的规则生成设备 MQTT 凭据，与 MQTT 服务端 ValidateMqttCredentials 的校验一致：
+// client_id 为 GID_test@@@<mac>@@@<uuid>，username 为 base64 编码的 JSON，password 为 HMAC-SHA256(client_id|username) 的 base64；
+// clientUUID 为空时随机生成
+func newDeviceMQTTCredential(deviceID, clientUUID, signatureKey string) (deviceMQTTCredential, error) {
+	if clientUUID == "" {
+		clientUUID = uuid.NewString()
+	}
+	userJSON, err := json.Marshal(struct {
+		Ip string `json:"ip"`
+	}{})
+	if err != nil {
+		return deviceMQTTCredential{}, err
+	}
+	username := base64.StdEncoding.EncodeToString(userJSON)
+	clientID := fmt.Sprintf("GID_test@@@%s@@@%s", strings.ReplaceAll(deviceID, ":", "_"), clientUUID)
+
+	h := hmac.New(sha256.New, []byte(signatureKey))
+	h.Write([]byte(clientID + "|" + username))
+	return deviceMQTTCredential{
+		DeviceID: deviceID,
+		ClientID: clientID,
+		Username: username,
+		Password: base64.StdEncoding.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// GenerateDeviceMQTTCredentials 按 MQTT 服务签名密钥为指定设备生成 client_id/用户名/密码，供设备预置工具获取；
+// client_id 查询参数为设备 UUID（与 OTA 请求头 Client-Id 相同），不传时随机生成
+func (ac *AdminController) GenerateDeviceMQTTCredentials(c *gin.Context) {
+	deviceID := strings.TrimSpace(c.Query("device_id"))
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "device_id 不能为空"})
+		return
+	}
+	clientUUID := strings.TrimSpace(c.Query("client_id"))
+	if strings.Contains(deviceID, "@@@") || strings.Contains(clientUUID, "@@@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "device_id 与 client_id 不能包含 @@@"})
+		return
+	}
+
+	cfg, signatureKey, err := loadMQTTSignatureKey(ac.DB)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "未找到MQTT服务配置"})
+		case errors.Is(err, errMQTTSignatureKeyMissing):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "读取MQTT服务配置失败"})
+		}
+		return
+	}
+
+	cred, err := newDeviceMQTTCredential(deviceID, clientUUID, signatureKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成MQTT凭据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"device_id": cred.DeviceID,
+		"client_id": cred.ClientID,
+		"username":  cred.Username,
+		"password":  cred.Password,
+		"config_id": cfg.ConfigID,
+	}})
+}
+
+// mqttSignatureKeyMinLength 手动指定的签名密钥最短长度
+const mqttSignatureKeyMinLength = 16
+
+// setConfigSignatureKey 替换 json_data 中的 signature_key，保留其他字段
+func setConfigSignatureKey(jsonData, key string) (string, error) {
+	data := make(map[string]interface{})
+	if strings.TrimSpace(jsonData) != "" {
+		if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+			return "", err
+		}
+	}
+	data["signature_key"] = key
+	out, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// RotateMQTTSignatureKey 轮换 MQTT 服务签名密钥：更新当前使用的 mqtt_server 配置（与旧密钥相同的 OTA 签名密钥一并更新），
+// 下发新配置，并返回所有设备按新密钥生成的凭据。轮换后设备旧凭据立即失效，需重新获取（OTA 或预置工具）
+func (ac *AdminController) RotateMQTTSignatureKey(c *gin.Context) {
+	if rejectReadOnlyConfigType(c, "mqtt_server") {
+		return
+	}
+	var req struct {
+		SignatureKey string `json:"signature_key"` // 为空时随机生成
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	newKey := strings.TrimSpace(req.SignatureKey)
+	if newKey == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成签名密钥失败"})
+			return
+		}
+		newKey = hex.EncodeToString(buf)
+	} else if len(newKey) < mqttSignatureKeyMinLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("签名密钥长度不能少于 %d", mqttSignatureKeyMinLength)})
+		return
+	}
+
+	mqttCfg, oldKey, err := loadMQTTSignatureKey(ac.DB)
+	if err != nil && !errors.Is(err, errMQTTSignatureKeyMissing) {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "未找到MQTT服务配置"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取MQTT服务配置失败"})
+		return
+	}
+	if oldKey == newKey {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "新签名密钥与当前密钥相同"})
+		return
+	}
+
+	updatedOTA := make([]string, 0)
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		jsonData, err := setConfigSignatureKey(mqttCfg.JsonData, newKey)
+		if err != nil {
+			return fmt.Errorf("MQTT服务配置 json_data 解析失败: %w", err)
+		}
+		if err := tx.Model(&models.Config{}).Where("id = ?", mqttCfg.ID).Update("json_data", jsonData).Error; err != nil {
+			return err
+		}
+
+		// OTA 下发凭据与 MQTT 服务校验需使用同一密钥，仅同步与旧密钥一致的 OTA 配置
+		if oldKey == "" || isConfigTypeReadOnly("ota") {
+			return nil
+		}
+		var otaConfigs []models.Config
+		if err := tx.Where("type = ?", "ota").Find(&otaConfigs).Error; err != nil {
+			return err
+		}
+		for _, ota := range otaConfigs {
+			var data struct {
+				SignatureKey string `json:"signature_key"`
+			}
+			if json.Unmarshal([]byte(ota.JsonData), &data) != nil || strings.TrimSpace(data.SignatureKey) != oldKey {
+				continue
+			}
+			jsonData, err := setConfigSignatureKey(ota.JsonData, newKey)
+			if err != nil {
+				continue
+			}
+			if err := tx.Model(&models.Config{}).Where("id = ?", ota.ID).Update("json_data", jsonData).Error; err != nil {
+				return err
+			}
+			updatedOTA = append(updatedOTA, ota.ConfigID)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "轮换签名密钥失败: " + err.Error()})
+		return
+	}
+	ac.notifySystemConfigChanged()
+
+	var deviceNames []string
+	if err := ac.DB.Model(&models.Device{}).Where("device_name <> ''").Order("id ASC").Pluck("device_name", &deviceNames).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "签名密钥已轮换，但获取设备列表失败"})
+		return
+	}
+	credentials := make([]deviceMQTTCredential, 0, len(deviceNames))
+	for _, name := range deviceNames {
+		cred, err := newDeviceMQTTCredential(name, "", newKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "签名密钥已轮换，但生成设备凭据失败"})
+			return
+		}
+		credentials = append(credentials, cred)
+	}
+	logger.Infof("MQTT签名密钥已轮换: config_id=%s ota_updated=%v devices=%d", mqttCfg.ConfigID, updatedOTA, len(credentials))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "签名密钥已轮换，设备原有MQTT凭据已失效，需重新获取凭据后才能连接",
+		"data": gin.H{
+			"config_id":          mqttCfg.ConfigID,
+			"ota_configs_synced": updatedOTA,
+			"credentials":        credentials,
+		},
+	})
+}
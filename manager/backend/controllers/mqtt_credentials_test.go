@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validateMQTTCredentials 与 MQTT 服务端 internal/util.ValidateMqttCredentials 的校验规则一致（后端为独立模块，无法直接引用）
+func validateMQTTCredentials(clientID, username, password, signatureKey string) error {
+	if len(strings.Split(clientID, "@@@")) != 3 {
+		return errors.New("clientId格式错误")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(username)
+	if err != nil {
+		return err
+	}
+	var userData map[string]interface{}
+	if err := json.Unmarshal(decoded, &userData); err != nil {
+		return err
+	}
+	h := hmac.New(sha256.New, []byte(signatureKey))
+	h.Write([]byte(clientID + "|" + username))
+	if password != base64.StdEncoding.EncodeToString(h.Sum(nil)) {
+		return errors.New("密码签名验证失败")
+	}
+	return nil
+}
+
+func TestGenerateDeviceMQTTCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+
+	ac := &AdminController{DB: db}
+	do := func(query string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/creds", ac.GenerateDeviceMQTTCredentials)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/creds"+query, nil))
+		return w
+	}
+
+	if w := do("?device_id=aa:bb"); w.Code != http.StatusNotFound {
+		t.Fatalf("no mqtt_server config: code=%d", w.Code)
+	}
+
+	cfg := models.Config{Type: "mqtt_server", Name: "m", ConfigID: "mqtt1", JsonData: `{"enable":true}`}
+	if err := db.Create(&cfg).Error; err != nil {
+		t.Fatal(err)
+	}
+	if w := do("?device_id=aa:bb"); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing signature_key: code=%d", w.Code)
+	}
+
+	if err := db.Model(&cfg).Update("json_data", `{"signature_key":"secret"}`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if w := do(""); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing device_id: code=%d", w.Code)
+	}
+	if w := do("?device_id=aa:bb&client_id=a@@@b"); w.Code != http.StatusBadRequest {
+		t.Fatalf("client_id with separator: code=%d", w.Code)
+	}
+	w := do("?device_id=aa:bb&client_id=uuid-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data deviceMQTTCredential `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.ClientID != "GID_test@@@aa_bb@@@uuid-1" {
+		t.Fatalf("client_id = %q", resp.Data.ClientID)
+	}
+	if err := validateMQTTCredentials(resp.Data.ClientID, resp.Data.Username, resp.Data.Password, "secret"); err != nil {
+		t.Fatalf("credentials rejected by broker: %v (%+v)", err, resp.Data)
+	}
+	if validateMQTTCredentials(resp.Data.ClientID, resp.Data.Username, resp.Data.Password, "other") == nil {
+		t.Fatal("credentials should not validate under another key")
+	}
+
+	// 不传 client_id 时随机生成，仍可通过校验
+	w = do("?device_id=aa:bb")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Data.ClientID, "GID_test@@@aa_bb@@@") || len(resp.Data.ClientID) == len("GID_test@@@aa_bb@@@") {
+		t.Fatalf("generated client_id = %q", resp.Data.ClientID)
+	}
+	if err := validateMQTTCredentials(resp.Data.ClientID, resp.Data.Username, resp.Data.Password, "secret"); err != nil {
+		t.Fatalf("generated credentials rejected: %v", err)
+	}
+}
+
+func TestRotateMQTTSignatureKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{}, &models.Device{})
+	for _, cfg := range []models.Config{
+		{Type: "mqtt_server", ConfigID: "mqtt1", JsonData: `{"listen_port":1883,"signature_key":"old-key"}`},
+		{Type: "ota", ConfigID: "ota_same", JsonData: `{"signature_key":"old-key"}`},
+		{Type: "ota", ConfigID: "ota_other", JsonData: `{"signature_key":"unrelated"}`},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&models.Device{DeviceName: "aa:bb"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ac := &AdminController{DB: db}
+	do := func(body string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.POST("/rotate", ac.RotateMQTTSignatureKey)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/rotate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(`{"signature_key":"short"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("short key: code=%d", w.Code)
+	}
+
+	const newKey = "new-signature-key-0001"
+	w := do(`{"signature_key":"` + newKey + `"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			OTASynced   []string               `json:"ota_configs_synced"`
+			Credentials []deviceMQTTCredential `json:"credentials"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.OTASynced) != 1 || resp.Data.OTASynced[0] != "ota_same" {
+		t.Fatalf("ota synced = %v", resp.Data.OTASynced)
+	}
+	if len(resp.Data.Credentials) != 1 || resp.Data.Credentials[0].DeviceID != "aa:bb" {
+		t.Fatalf("credentials = %+v", resp.Data.Credentials)
+	}
+	cred := resp.Data.Credentials[0]
+	if err := validateMQTTCredentials(cred.ClientID, cred.Username, cred.Password, newKey); err != nil {
+		t.Fatalf("rotated credentials rejected by broker: %v (%+v)", err, cred)
+	}
+	if validateMQTTCredentials(cred.ClientID, cred.Username, cred.Password, "old-key") == nil {
+		t.Fatal("rotated credentials should not validate under the old key")
+	}
+
+	_, key, err := loadMQTTSignatureKey(db)
+	if err != nil || key != newKey {
+		t.Fatalf("stored key = %q err=%v", key, err)
+	}
+	var mqttCfg, otherOTA models.Config
+	db.Where("config_id = ?", "mqtt1").First(&mqttCfg)
+	db.Where("config_id = ?", "ota_other").First(&otherOTA)
+	if !strings.Contains(mqttCfg.JsonData, "listen_port") {
+		t.Fatalf("other mqtt_server fields lost: %s", mqttCfg.JsonData)
+	}
+	if !strings.Contains(otherOTA.JsonData, "unrelated") {
+		t.Fatalf("unrelated ota config changed: %s", otherOTA.JsonData)
+	}
+
+	// 不指定密钥时随机生成
+	if w := do(""); w.Code != http.StatusOK {
+		t.Fatalf("generated key: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if _, key, _ := loadMQTTSignatureKey(db); key == newKey || len(key) != 64 {
+		t.Fatalf("generated key = %q", key)
+	}
+}
@@ -24,6 +24,7 @@ func NewPoolStatsController() *PoolStatsController {
 func (c *PoolStatsController) ReportPoolStats(ctx *gin.Context) {
 	var request struct {
 		Stats map[string]interface{} `json:"stats" binding:"required"`
+		MQTT  map[string]interface{} `json:"mqtt"`
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -33,6 +34,9 @@ func (c *PoolStatsController) ReportPoolStats(ctx *gin.Context) {
 
 	// 保存统计数据
 	c.storage.AddStats(request.Stats)
+	if len(request.MQTT) > 0 {
+		c.storage.SetMqttMetrics(request.MQTT)
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "统计数据上报成功",
@@ -122,3 +126,18 @@ func (c *PoolStatsController) GetPoolStatsSummary(ctx *gin.Context) {
 		"data": summary,
 	})
 }
+
+// GetMqttMetrics 获取主服务上报的 MQTT 运行指标（管理员接口）
+func (c *PoolStatsController) GetMqttMetrics(ctx *gin.Context) {
+	latest := c.storage.GetLatestMqttMetrics()
+	if latest == nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"data":    nil,
+			"message": "暂无MQTT指标数据，可能未启用MQTT或主服务尚未上报",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": latest,
+	})
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReorderRoles 按给定顺序批量设置角色排序，sort_order 从 1 开始依次递增
+// 请求体: {"role_ids": [3, 1, 2]}；同一次排序只能包含全局角色或同一用户的角色，全局角色仅管理员可排序，用户角色仅所有者或管理员可排序
+func (ac *AdminController) ReorderRoles(c *gin.Context) {
+	var req struct {
+		RoleIDs []uint `json:"role_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if len(req.RoleIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role_ids 不能为空"})
+		return
+	}
+	seen := make(map[uint]bool, len(req.RoleIDs))
+	for _, id := range req.RoleIDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("角色ID重复: %d", id)})
+			return
+		}
+		seen[id] = true
+	}
+
+	var roles []models.Role
+	if err := ac.DB.Where("id IN ?", req.RoleIDs).Find(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询角色失败"})
+		return
+	}
+	if len(roles) != len(req.RoleIDs) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "部分角色不存在"})
+		return
+	}
+
+	// 权限检查
+	userID, exists := c.Get("user_id")
+	userRole, roleExists := c.Get("role")
+	isAdmin := roleExists && userRole.(string) == "admin"
+	var currentUserID uint
+	if exists {
+		currentUserID, _ = userID.(uint)
+	}
+	globalOnly := strings.Contains(c.FullPath(), "/admin/roles/global/")
+
+	scope := ""
+	for _, role := range roles {
+		roleScope := "global"
+		if role.RoleType != "global" {
+			if role.UserID == nil {
+				roleScope = "user:"
+			} else {
+				roleScope = fmt.Sprintf("user:%d", *role.UserID)
+			}
+		}
+		if scope == "" {
+			scope = roleScope
+		} else if scope != roleScope {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "同一次排序只能包含全局角色或同一用户的角色"})
+			return
+		}
+		if globalOnly && role.RoleType != "global" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "该接口仅允许操作全局角色"})
+			return
+		}
+		isOwner := role.UserID != nil && currentUserID != 0 && *role.UserID == currentUserID
+		if !isAdmin && !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("无权修改角色: %d", role.ID)})
+			return
+		}
+	}
+
+	if err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range req.RoleIDs {
+			if err := tx.Model(&models.Role{}).Where("id = ?", id).Update("sort_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新角色排序失败"})
+		return
+	}
+
+	orders := make([]gin.H, 0, len(req.RoleIDs))
+	for i, id := range req.RoleIDs {
+		orders = append(orders, gin.H{"id": id, "sort_order": i + 1})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": orders, "message": "角色排序已更新"})
+}
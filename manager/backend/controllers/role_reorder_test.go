@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReorderRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Role{})
+	owner, other := uint(1), uint(2)
+	roles := []models.Role{
+		{Name: "g1", RoleType: "global"},
+		{Name: "u1", RoleType: "user", UserID: &owner},
+		{Name: "u2", RoleType: "user", UserID: &owner},
+		{Name: "o1", RoleType: "user", UserID: &other},
+	}
+	for i := range roles {
+		if err := db.Create(&roles[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ac := &AdminController{DB: db}
+	do := func(role string, userID uint, body string) int {
+		r := gin.New()
+		r.POST("/api/user/roles/reorder", func(c *gin.Context) {
+			c.Set("user_id", userID)
+			c.Set("role", role)
+			ac.ReorderRoles(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/user/roles/reorder", strings.NewReader(body)))
+		return w.Code
+	}
+
+	if code := do("user", owner, `{"role_ids":[3,2]}`); code != http.StatusOK {
+		t.Fatalf("owner reorder = %d", code)
+	}
+	var u1, u2 models.Role
+	db.First(&u1, roles[1].ID)
+	db.First(&u2, roles[2].ID)
+	if u2.SortOrder != 1 || u1.SortOrder != 2 {
+		t.Fatalf("sort orders = %d, %d", u1.SortOrder, u2.SortOrder)
+	}
+
+	// 普通用户不能排序他人角色或全局角色
+	if code := do("user", owner, `{"role_ids":[4]}`); code != http.StatusForbidden {
+		t.Fatalf("other user's role = %d", code)
+	}
+	if code := do("user", owner, `{"role_ids":[1]}`); code != http.StatusForbidden {
+		t.Fatalf("global role by user = %d", code)
+	}
+	// 不能混合范围、不能重复、不能包含不存在的角色
+	if code := do("admin", 99, `{"role_ids":[1,2]}`); code != http.StatusBadRequest {
+		t.Fatalf("mixed scope = %d", code)
+	}
+	if code := do("admin", 99, `{"role_ids":[2,2]}`); code != http.StatusBadRequest {
+		t.Fatalf("duplicate = %d", code)
+	}
+	if code := do("admin", 99, `{"role_ids":[2,42]}`); code != http.StatusNotFound {
+		t.Fatalf("missing = %d", code)
+	}
+	if code := do("admin", 99, `{"role_ids":[4]}`); code != http.StatusOK {
+		t.Fatalf("admin reorder user role = %d", code)
+	}
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildVersion 构建版本，发布时通过 -ldflags "-X xiaozhi/manager/backend/controllers.BuildVersion=v1.2.3" 注入
+var BuildVersion = "dev"
+
+// serverInfoProviderTypes 服务信息中汇报提供商的配置类型
+var serverInfoProviderTypes = []string{"vad", "asr", "llm", "tts", "vision", "memory", "knowledge_search", "voice_identify"}
+
+type serverProviderInfo struct {
+	ConfigID     string `json:"config_id"`
+	Provider     string `json:"provider"`
+	EnabledCount int    `json:"enabled_count"` // 该类型已启用的配置数
+}
+
+// serverBuildInfo 构建版本及 Go 嵌入的 VCS 信息（go build 在 git 仓库内构建时可用）
+func serverBuildInfo() gin.H {
+	info := gin.H{
+		"version":    BuildVersion,
+		"go_version": runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info["git_commit"] = s.Value
+			case "vcs.time":
+				info["git_commit_time"] = s.Value
+			case "vcs.modified":
+				info["git_dirty"] = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// speakerFeatureActive 声纹识别是否可用：voice_identify 业务开关未关闭且配置了服务地址（与下发配置的判断一致）
+func speakerFeatureActive(configs []models.Config) bool {
+	baseURL := os.Getenv("SPEAKER_SERVICE_URL")
+	enabled := true
+	var selected *models.Config
+	for i := range configs {
+		if selected == nil || configs[i].IsDefault {
+			selected = &configs[i]
+		}
+	}
+	if selected != nil && selected.JsonData != "" {
+		var data struct {
+			Enable  *bool `json:"enable"`
+			Service struct {
+				BaseURL string `json:"base_url"`
+			} `json:"service"`
+		}
+		if err := json.Unmarshal([]byte(selected.JsonData), &data); err == nil {
+			if data.Enable != nil {
+				enabled = *data.Enable
+			}
+			if baseURL == "" {
+				baseURL = strings.TrimSpace(data.Service.BaseURL)
+			}
+		}
+	}
+	return enabled && baseURL != ""
+}
+
+// GetServerInfo 返回构建版本、各类型当前使用的提供商及可选功能（知识库、视觉、声纹）的启用情况，便于支持人员远程了解部署能力
+func (ac *AdminController) GetServerInfo(c *gin.Context) {
+	var configs []models.Config
+	if err := ac.DB.Where("type IN ?", serverInfoProviderTypes).Order("id ASC").Find(&configs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配置失败"})
+		return
+	}
+	byType := make(map[string][]models.Config)
+	for _, cfg := range configs {
+		if cfg.Type == "vision" && cfg.ConfigID == "vision_base" {
+			continue
+		}
+		byType[cfg.Type] = append(byType[cfg.Type], cfg)
+	}
+
+	// 每种类型取已启用配置中的默认项，否则取第一条已启用配置
+	providers := make(map[string]serverProviderInfo)
+	for _, typ := range serverInfoProviderTypes {
+		var info serverProviderInfo
+		found := false
+		for _, cfg := range byType[typ] {
+			if !cfg.Enabled {
+				continue
+			}
+			info.EnabledCount++
+			if !found || cfg.IsDefault {
+				info.ConfigID, info.Provider = cfg.ConfigID, cfg.Provider
+				found = true
+			}
+		}
+		if found {
+			providers[typ] = info
+		}
+	}
+
+	knowledgeEnabled, err := isKnowledgeFeatureEnabled(ac.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "检查知识库开关状态失败"})
+		return
+	}
+	speakerServiceURL := ""
+	if ac.SpeakerGroups != nil {
+		speakerServiceURL = ac.SpeakerGroups.ServiceURL
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"build":     serverBuildInfo(),
+		"providers": providers,
+		"features": gin.H{
+			"knowledge": knowledgeEnabled,
+			"vision":    providers["vision"].EnabledCount > 0,
+			"speaker": gin.H{
+				"active":             speakerFeatureActive(byType["voice_identify"]),
+				"service_configured": speakerServiceURL != "", // 管理后台声纹组使用的服务地址
+			},
+		},
+	}})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetServerInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SPEAKER_SERVICE_URL", "")
+	db := newTestDB(t, &models.Config{})
+	for _, cfg := range []models.Config{
+		{Type: "llm", ConfigID: "llm_a", Provider: "openai", Enabled: true},
+		{Type: "llm", ConfigID: "llm_b", Provider: "ollama", Enabled: true, IsDefault: true},
+		{Type: "tts", ConfigID: "tts_off", Provider: "edge", Enabled: false},
+		{Type: "vision", ConfigID: "vision_base", Enabled: true},
+		{Type: "knowledge_search", ConfigID: "kb", Provider: "dify", Enabled: true, IsDefault: true},
+		{Type: "voice_identify", ConfigID: "vi", Enabled: true, JsonData: `{"enable":true,"service":{"base_url":"http://127.0.0.1:9000"}}`},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// enabled 列带默认值，false 需创建后单独更新
+	if err := db.Model(&models.Config{}).Where("config_id = ?", "tts_off").Update("enabled", false).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.GET("/server-info", ac.GetServerInfo)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/server-info", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Build     map[string]interface{}        `json:"build"`
+			Providers map[string]serverProviderInfo `json:"providers"`
+			Features  struct {
+				Knowledge bool `json:"knowledge"`
+				Vision    bool `json:"vision"`
+				Speaker   struct {
+					Active bool `json:"active"`
+				} `json:"speaker"`
+			} `json:"features"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Build["version"] != BuildVersion {
+		t.Fatalf("build = %v", resp.Data.Build)
+	}
+	if llm := resp.Data.Providers["llm"]; llm.Provider != "ollama" || llm.EnabledCount != 2 {
+		t.Fatalf("llm provider = %+v", llm)
+	}
+	if _, ok := resp.Data.Providers["tts"]; ok {
+		t.Fatal("disabled tts config should not be reported")
+	}
+	if !resp.Data.Features.Knowledge || resp.Data.Features.Vision || !resp.Data.Features.Speaker.Active {
+		t.Fatalf("features = %+v", resp.Data.Features)
+	}
+}
@@ -1,8 +1,8 @@
 package controllers
 
 import (
-	"log"
 	"net/http"
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	"github.com/gin-gonic/gin"
@@ -84,7 +84,7 @@ func (sc *SetupController) InitializeDatabase(c *gin.Context) {
 	}()
 
 	// 1. 自动迁移表结构
-	log.Println("开始自动迁移数据库表结构...")
+	logger.Infof("开始自动迁移数据库表结构...")
 	err := tx.AutoMigrate(
 		&models.User{},
 		&models.Device{},
@@ -98,11 +98,11 @@ func (sc *SetupController) InitializeDatabase(c *gin.Context) {
 	)
 	if err != nil {
 		tx.Rollback()
-		log.Printf("数据库表结构迁移失败: %v", err)
+		logger.Errorf("数据库表结构迁移失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库表结构迁移失败: " + err.Error()})
 		return
 	}
-	log.Println("数据库表结构迁移成功")
+	logger.Infof("数据库表结构迁移成功")
 
 	// 2. 检查是否已存在管理员用户
 	var existingAdmin models.User
@@ -145,7 +145,7 @@ func (sc *SetupController) InitializeDatabase(c *gin.Context) {
 
 	if err := tx.Create(&admin).Error; err != nil {
 		tx.Rollback()
-		log.Printf("创建管理员用户失败: %v", err)
+		logger.Errorf("创建管理员用户失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建管理员用户失败: " + err.Error()})
 		return
 	}
@@ -174,7 +174,7 @@ func (sc *SetupController) InitializeDatabase(c *gin.Context) {
 
 	for _, role := range defaultRoles {
 		if err := tx.Create(&role).Error; err != nil {
-			log.Printf("创建默认角色失败: %v", err)
+			logger.Errorf("创建默认角色失败: %v", err)
 			// 不中断初始化过程，继续执行
 		}
 	}
@@ -185,7 +185,7 @@ func (sc *SetupController) InitializeDatabase(c *gin.Context) {
 		return
 	}
 
-	log.Printf("数据库初始化成功，管理员用户: %s", req.AdminUsername)
+	logger.Infof("数据库初始化成功，管理员用户: %s", req.AdminUsername)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "数据库初始化成功",
 		"admin": gin.H{
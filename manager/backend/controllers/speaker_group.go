@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -17,6 +16,7 @@ import (
 	"time"
 
 	"xiaozhi/manager/backend/config"
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 	"xiaozhi/manager/backend/storage"
 
@@ -396,7 +396,7 @@ func (sgc *SpeakerGroupController) DeleteSpeakerGroup(c *gin.Context) {
 	// 调用 asr_server 删除接口（通过 speaker_id，即声纹组的主键 ID，一次性删除所有样本）
 	err = sgc.callDeleteAPI(fmt.Sprintf("%d", speakerGroup.ID), speakerGroup.AgentID, userID)
 	if err != nil {
-		log.Printf("asr_server 删除声纹组失败 (speaker_id: %d): %v", speakerGroup.ID, err)
+		logger.Errorf("asr_server 删除声纹组失败 (speaker_id: %d): %v", speakerGroup.ID, err)
 		// 继续执行本地删除，不中断流程
 	}
 
@@ -1000,9 +1000,9 @@ func (sgc *SpeakerGroupController) callDeleteAPI(speakerID string, agentID uint,
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		if len(uuid) > 0 && uuid[0] != "" {
-			log.Printf("asr_server 删除失败 (speaker_id: %s, uuid: %s): %s", speakerID, uuid[0], string(body))
+			logger.Errorf("asr_server 删除失败 (speaker_id: %s, uuid: %s): %s", speakerID, uuid[0], string(body))
 		} else {
-			log.Printf("asr_server 删除失败 (speaker_id: %s): %s", speakerID, string(body))
+			logger.Errorf("asr_server 删除失败 (speaker_id: %s): %s", speakerID, string(body))
 		}
 		// 如果提供了 uuid，不返回错误（可能已经删除或不存在）
 		// 如果是通过 speaker_id 删除，返回错误
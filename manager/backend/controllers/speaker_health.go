@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	speakerServiceHealthPath    = "/health"
+	speakerServiceCheckTimeout  = 5 * time.Second
+	speakerServiceDefaultThresh = 0.4
+)
+
+var speakerServiceCheckClient = &http.Client{Timeout: speakerServiceCheckTimeout}
+
+// voiceIdentifySettings 声纹识别的生效配置（与下发给主程序的 voice_identify 一致）
+type voiceIdentifySettings struct {
+	BaseURL   string  `json:"base_url"`
+	URLSource string  `json:"url_source"` // env/config/none
+	Threshold float64 `json:"threshold"`
+	Enabled   bool    `json:"enable"`
+	ConfigID  string  `json:"config_id,omitempty"`
+}
+
+// loadVoiceIdentifySettings 读取声纹服务地址与阈值，SPEAKER_SERVICE_URL 环境变量优先
+func loadVoiceIdentifySettings(db *gorm.DB) (voiceIdentifySettings, error) {
+	settings := voiceIdentifySettings{URLSource: "none", Threshold: speakerServiceDefaultThresh, Enabled: true}
+	if envURL := strings.TrimSpace(os.Getenv("SPEAKER_SERVICE_URL")); envURL != "" {
+		settings.BaseURL, settings.URLSource = envURL, "env"
+	}
+
+	var cfg models.Config
+	err := db.Where("type = ?", "voice_identify").Order("is_default DESC, id ASC").First(&cfg).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return settings, err
+	}
+	if err == nil && cfg.JsonData != "" {
+		settings.ConfigID = cfg.ConfigID
+		var data struct {
+			Enable  *bool `json:"enable"`
+			Service struct {
+				BaseURL   string   `json:"base_url"`
+				Threshold *float64 `json:"threshold"`
+			} `json:"service"`
+		}
+		if json.Unmarshal([]byte(cfg.JsonData), &data) == nil {
+			if data.Enable != nil {
+				settings.Enabled = *data.Enable
+			}
+			if baseURL := strings.TrimSpace(data.Service.BaseURL); baseURL != "" && settings.BaseURL == "" {
+				settings.BaseURL, settings.URLSource = baseURL, "config"
+			}
+			if t := data.Service.Threshold; t != nil && *t >= 0 && *t <= 1 {
+				settings.Threshold = *t
+			}
+		}
+	}
+	settings.BaseURL = strings.TrimRight(settings.BaseURL, "/")
+	return settings, nil
+}
+
+// CheckSpeakerService 探测声纹服务是否可达，返回延迟与当前生效的阈值
+// 服务返回任意非 5xx 响应即视为可达（部分部署未实现 /health，会返回 404）
+func (ac *AdminController) CheckSpeakerService(c *gin.Context) {
+	settings, err := loadVoiceIdentifySettings(ac.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取声纹配置失败"})
+		return
+	}
+	result := gin.H{
+		"configured": settings.BaseURL != "",
+		"reachable":  false,
+		"settings":   settings,
+	}
+	if settings.BaseURL == "" {
+		result["error"] = "未配置声纹服务地址（SPEAKER_SERVICE_URL 或 voice_identify.service.base_url）"
+		c.JSON(http.StatusOK, gin.H{"data": result})
+		return
+	}
+
+	target := settings.BaseURL + speakerServiceHealthPath
+	result["target"] = target
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		result["error"] = "声纹服务地址无效: " + err.Error()
+		c.JSON(http.StatusOK, gin.H{"data": result})
+		return
+	}
+	startAt := time.Now()
+	resp, err := speakerServiceCheckClient.Do(req)
+	result["latency_ms"] = time.Since(startAt).Milliseconds()
+	if err != nil {
+		result["error"] = err.Error()
+		c.JSON(http.StatusOK, gin.H{"data": result})
+		return
+	}
+	resp.Body.Close()
+	result["status_code"] = resp.StatusCode
+	if resp.StatusCode >= 500 {
+		result["error"] = "声纹服务返回异常状态: " + resp.Status
+	} else {
+		result["reachable"] = true
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckSpeakerService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SPEAKER_SERVICE_URL", "")
+	db := newTestDB(t, &models.Config{})
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != speakerServiceHealthPath {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.GET("/health", ac.CheckSpeakerService)
+	check := func() map[string]interface{} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+
+	// 未配置地址
+	if data := check(); data["configured"] != false || data["reachable"] != false || data["error"] == nil {
+		t.Fatalf("unconfigured = %+v", data)
+	}
+
+	cfg := models.Config{Type: "voice_identify", Name: "声纹", ConfigID: "vi", IsDefault: true, Enabled: true,
+		JsonData: fmt.Sprintf(`{"enable":true,"service":{"base_url":"%s/","threshold":0.6}}`, healthy.URL)}
+	if err := db.Create(&cfg).Error; err != nil {
+		t.Fatal(err)
+	}
+	data := check()
+	settings, _ := data["settings"].(map[string]interface{})
+	if data["reachable"] != true || data["status_code"] != float64(200) || data["latency_ms"] == nil ||
+		settings["threshold"] != 0.6 || settings["url_source"] != "config" || settings["base_url"] != healthy.URL {
+		t.Fatalf("healthy = %+v", data)
+	}
+
+	// 环境变量优先于配置中的地址
+	t.Setenv("SPEAKER_SERVICE_URL", broken.URL)
+	data = check()
+	settings, _ = data["settings"].(map[string]interface{})
+	if data["reachable"] != false || data["status_code"] != float64(503) || settings["url_source"] != "env" {
+		t.Fatalf("broken = %+v", data)
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 系统配置缓存的兜底有效期：未经 notifySystemConfigChanged 的改动（如直接改库）最多延迟该时长生效
+const systemConfigsCacheTTL = 30 * time.Second
+
+// systemConfigsCache getSystemConfigsData 结果的进程内缓存
+// generation 在每次失效时递增，查询期间发生失效则丢弃该次结果，避免旧数据覆盖新数据
+type systemConfigsCache struct {
+	mu         sync.Mutex
+	data       gin.H
+	loadedAt   time.Time
+	generation uint64
+}
+
+var sysConfigsCache systemConfigsCache
+
+// invalidate 使缓存失效
+func (c *systemConfigsCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = nil
+	c.generation++
+}
+
+// get 返回未过期的缓存及当前代数
+func (c *systemConfigsCache) get(now time.Time) (gin.H, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.data != nil && now.Sub(c.loadedAt) < systemConfigsCacheTTL {
+		return c.data, c.generation
+	}
+	return nil, c.generation
+}
+
+// store 仅当查询期间未失效时写入缓存
+func (c *systemConfigsCache) store(data gin.H, generation uint64, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+	c.data = data
+	c.loadedAt = now
+}
+
+// getSystemConfigsDataCached 带缓存的 getSystemConfigsData，forceRefresh 为 true 时跳过缓存重新查询
+// 返回顶层浅拷贝，嵌套的配置项与缓存共享，调用方只读使用
+func (ac *AdminController) getSystemConfigsDataCached(forceRefresh bool) (gin.H, error) {
+	if forceRefresh {
+		sysConfigsCache.invalidate()
+	}
+	data, generation := sysConfigsCache.get(time.Now())
+	if data == nil {
+		var err error
+		if data, err = ac.getSystemConfigsData(); err != nil {
+			return nil, err
+		}
+		sysConfigsCache.store(data, generation, time.Now())
+	}
+
+	out := make(gin.H, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	return out, nil
+}
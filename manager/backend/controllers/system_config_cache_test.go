@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSystemConfigsCache(t *testing.T) {
+	var cache systemConfigsCache
+	now := time.Now()
+
+	data, gen := cache.get(now)
+	if data != nil {
+		t.Fatal("empty cache returned data")
+	}
+	cache.store(gin.H{"vad": 1}, gen, now)
+	if data, _ := cache.get(now.Add(time.Second)); data == nil {
+		t.Fatal("expected cached data")
+	}
+	if data, _ := cache.get(now.Add(systemConfigsCacheTTL)); data != nil {
+		t.Fatal("expected cache expired after TTL")
+	}
+
+	// 查询期间失效，旧结果不应写入
+	_, gen = cache.get(now.Add(time.Hour))
+	cache.invalidate()
+	cache.store(gin.H{"vad": "stale"}, gen, now)
+	if data, _ := cache.get(now); data != nil {
+		t.Fatalf("stale data stored after invalidation: %v", data)
+	}
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetSystemConfigsDataReportsMalformedJSON(t *testing.T) {
+	db := newTestDB(t, &models.Config{})
+	for _, cfg := range []models.Config{
+		{Type: "asr", Name: "ok", ConfigID: "asr-ok", Provider: "funasr", JsonData: `{"host":"127.0.0.1"}`, Enabled: true, IsDefault: true},
+		{Type: "asr", Name: "broken", ConfigID: "asr-broken", Provider: "funasr", JsonData: `{"host":`, Enabled: true},
+		{Type: "llm", Name: "broken", ConfigID: "llm-broken", Provider: "openai", JsonData: `not json`, Enabled: true},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ac := &AdminController{DB: db}
+	data, err := ac.getSystemConfigsData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	asr, _ := data["asr"].(gin.H)
+	if _, ok := asr["asr-ok"]; !ok {
+		t.Fatalf("valid asr config missing: %v", asr)
+	}
+	if _, ok := asr["asr-broken"]; ok {
+		t.Fatal("malformed asr config should be skipped")
+	}
+	if _, ok := data["llm"]; ok {
+		t.Fatalf("llm with only malformed config should be absent: %v", data["llm"])
+	}
+	warnings, _ := data["warnings"].([]gin.H)
+	got := map[string]bool{}
+	for _, w := range warnings {
+		got[w["config_id"].(string)] = true
+	}
+	if len(warnings) != 2 || !got["asr-broken"] || !got["llm-broken"] {
+		t.Fatalf("warnings = %v", warnings)
+	}
+}
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 租户即用户账号：角色、智能体按用户隔离，LLM/TTS 等配置为全局共享
+// 克隆时复制模板用户的角色与智能体，并为其引用的配置生成租户专属副本（新 config_id、清空密钥、默认禁用）
+
+// tenantCloneRequest 克隆租户环境的请求参数
+type tenantCloneRequest struct {
+	SourceUserID uint   `json:"source_user_id" binding:"required"`
+	TargetUserID uint   `json:"target_user_id" binding:"required"`
+	ConfigSuffix string `json:"config_suffix"` // 副本 config_id 后缀，默认 u<target_user_id>
+}
+
+// tenantCloneResult 克隆结果统计
+type tenantCloneResult struct {
+	ConfigsCreated int               `json:"configs_created"`
+	ConfigsReused  int               `json:"configs_reused"`
+	RolesCreated   int               `json:"roles_created"`
+	RolesSkipped   int               `json:"roles_skipped"`
+	AgentsCreated  int               `json:"agents_created"`
+	AgentsSkipped  int               `json:"agents_skipped"`
+	ConfigIDMap    map[string]string `json:"config_id_map"`   // 模板 config_id → 副本 config_id（按 类型:config_id 组织）
+	ClearedSecrets map[string]string `json:"cleared_secrets"` // 副本 config_id → 被清空的敏感字段路径（逗号分隔）
+	Warnings       []string          `json:"warnings,omitempty"`
+}
+
+// clearConfigSecretValues 递归清空 json_data 中的敏感字段，返回被清空的字段路径（已排序）
+func clearConfigSecretValues(typ, provider string, data interface{}) []string {
+	var cleared []string
+	var walk func(value interface{}, path string)
+	walk = func(value interface{}, path string) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				if s, ok := child.(string); ok && IsConfigSecretField(typ, provider, key) {
+					if s != "" {
+						v[key] = ""
+						cleared = append(cleared, childPath)
+					}
+					continue
+				}
+				walk(child, childPath)
+			}
+		case []interface{}:
+			for i, child := range v {
+				walk(child, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	walk(data, "")
+	sort.Strings(cleared)
+	return cleared
+}
+
+// cloneTenantConfig 为租户生成配置副本；副本已存在时直接复用，保证重复克隆幂等
+func cloneTenantConfig(tx *gorm.DB, typ, configID, suffix, tenantName string, result *tenantCloneResult) (string, error) {
+	mapKey := typ + ":" + configID
+	if mapped, ok := result.ConfigIDMap[mapKey]; ok {
+		return mapped, nil
+	}
+	var src models.Config
+	if err := tx.Where("type = ? AND config_id = ?", typ, configID).First(&src).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s 配置 %s 不存在，保留原引用", typ, configID))
+			return configID, nil
+		}
+		return "", err
+	}
+
+	newConfigID := fmt.Sprintf("%s_%s", configID, suffix)
+	if len(newConfigID) > 100 {
+		newConfigID = newConfigID[:100]
+	}
+	var existing models.Config
+	if err := tx.Where("type = ? AND config_id = ?", typ, newConfigID).First(&existing).Error; err == nil {
+		result.ConfigsReused++
+		result.ConfigIDMap[mapKey] = newConfigID
+		return newConfigID, nil
+	} else if err != gorm.ErrRecordNotFound {
+		return "", err
+	}
+
+	jsonData := src.JsonData
+	var data interface{}
+	if err := json.Unmarshal([]byte(src.JsonData), &data); err == nil {
+		if cleared := clearConfigSecretValues(src.Type, src.Provider, data); len(cleared) > 0 {
+			result.ClearedSecrets[newConfigID] = strings.Join(cleared, ",")
+		}
+		if b, err := json.Marshal(data); err == nil {
+			jsonData = string(b)
+		}
+	}
+	name := fmt.Sprintf("%s (%s)", src.Name, tenantName)
+	if len([]rune(name)) > 100 {
+		name = string([]rune(name)[:100])
+	}
+	clone := models.Config{
+		Type:            src.Type,
+		Name:            name,
+		ConfigID:        newConfigID,
+		Provider:        src.Provider,
+		JsonData:        jsonData,
+		Enabled:         false, // 密钥已清空，补全后由管理员启用；禁用期间引用方回退到默认配置
+		IsDefault:       false,
+		SchemaVersion:   src.SchemaVersion,
+		MatchConditions: src.MatchConditions,
+	}
+	if err := tx.Create(&clone).Error; err != nil {
+		return "", err
+	}
+	// gorm 对零值 bool 使用列默认值（enabled 默认 true），显式写回禁用状态
+	if err := tx.Model(&models.Config{}).Where("id = ?", clone.ID).Update("enabled", false).Error; err != nil {
+		return "", err
+	}
+	result.ConfigsCreated++
+	result.ConfigIDMap[mapKey] = newConfigID
+	return newConfigID, nil
+}
+
+// cloneTenantConfigRef 重映射可选的配置引用
+func cloneTenantConfigRef(tx *gorm.DB, typ string, ref *string, suffix, tenantName string, result *tenantCloneResult) (*string, error) {
+	if ref == nil || strings.TrimSpace(*ref) == "" {
+		return ref, nil
+	}
+	mapped, err := cloneTenantConfig(tx, typ, strings.TrimSpace(*ref), suffix, tenantName, result)
+	if err != nil {
+		return nil, err
+	}
+	return &mapped, nil
+}
+
+// cloneTenantEnvironment 将模板用户的角色、智能体及其引用的配置复制到目标用户，不修改或删除目标已有数据
+func cloneTenantEnvironment(tx *gorm.DB, source, target models.User, suffix string) (*tenantCloneResult, error) {
+	result := &tenantCloneResult{
+		ConfigIDMap:    make(map[string]string),
+		ClearedSecrets: make(map[string]string),
+	}
+
+	var roles []models.Role
+	if err := tx.Where("user_id = ? AND role_type = ?", source.ID, "user").Order("sort_order ASC, id ASC").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("查询模板角色失败: %w", err)
+	}
+	for _, role := range roles {
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("user_id = ? AND name = ?", target.ID, role.Name).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("查询目标角色失败: %w", err)
+		}
+		if count > 0 {
+			result.RolesSkipped++
+			continue
+		}
+		llmRef, err := cloneTenantConfigRef(tx, "llm", role.LLMConfigID, suffix, target.Username, result)
+		if err != nil {
+			return nil, fmt.Errorf("复制角色 %s 的LLM配置失败: %w", role.Name, err)
+		}
+		ttsRef, err := cloneTenantConfigRef(tx, "tts", role.TTSConfigID, suffix, target.Username, result)
+		if err != nil {
+			return nil, fmt.Errorf("复制角色 %s 的TTS配置失败: %w", role.Name, err)
+		}
+		targetID := target.ID
+		clone := role
+		clone.ID = 0
+		clone.UserID = &targetID
+		clone.LLMConfigID = llmRef
+		clone.TTSConfigID = ttsRef
+		clone.IsDefault = false
+		if err := tx.Create(&clone).Error; err != nil {
+			return nil, fmt.Errorf("创建角色 %s 失败: %w", role.Name, err)
+		}
+		result.RolesCreated++
+	}
+
+	var agents []models.Agent
+	if err := tx.Where("user_id = ?", source.ID).Order("id ASC").Find(&agents).Error; err != nil {
+		return nil, fmt.Errorf("查询模板智能体失败: %w", err)
+	}
+	for _, agent := range agents {
+		var count int64
+		if err := tx.Model(&models.Agent{}).Where("user_id = ? AND name = ?", target.ID, agent.Name).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("查询目标智能体失败: %w", err)
+		}
+		if count > 0 {
+			result.AgentsSkipped++
+			continue
+		}
+		llmRef, err := cloneTenantConfigRef(tx, "llm", agent.LLMConfigID, suffix, target.Username, result)
+		if err != nil {
+			return nil, fmt.Errorf("复制智能体 %s 的LLM配置失败: %w", agent.Name, err)
+		}
+		ttsRef, err := cloneTenantConfigRef(tx, "tts", agent.TTSConfigID, suffix, target.Username, result)
+		if err != nil {
+			return nil, fmt.Errorf("复制智能体 %s 的TTS配置失败: %w", agent.Name, err)
+		}
+		clone := agent
+		clone.ID = 0
+		clone.UserID = target.ID
+		clone.LLMConfigID = llmRef
+		clone.TTSConfigID = ttsRef
+		if err := tx.Create(&clone).Error; err != nil {
+			return nil, fmt.Errorf("创建智能体 %s 失败: %w", agent.Name, err)
+		}
+		result.AgentsCreated++
+	}
+	return result, nil
+}
+
+// CloneTenantEnvironment 以模板用户为基线初始化新租户（用户）
+// POST /api/admin/tenants/clone
+func (ac *AdminController) CloneTenantEnvironment(c *gin.Context) {
+	var req tenantCloneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if req.SourceUserID == req.TargetUserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模板用户与目标用户不能相同"})
+		return
+	}
+	var source, target models.User
+	if err := ac.DB.First(&source, req.SourceUserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "模板用户不存在"})
+		return
+	}
+	if err := ac.DB.First(&target, req.TargetUserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "目标用户不存在"})
+		return
+	}
+	suffix := strings.TrimSpace(req.ConfigSuffix)
+	if suffix == "" {
+		suffix = fmt.Sprintf("u%d", target.ID)
+	}
+
+	var result *tenantCloneResult
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = cloneTenantEnvironment(tx, source, target, suffix)
+		return err
+	})
+	if err != nil {
+		logger.Errorf("克隆租户环境失败: source=%d target=%d err=%v", source.ID, target.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result.ConfigsCreated > 0 {
+		ac.notifySystemConfigChanged()
+	}
+	logger.Infof("克隆租户环境完成: source=%d target=%d configs=%d roles=%d agents=%d",
+		source.ID, target.ID, result.ConfigsCreated, result.RolesCreated, result.AgentsCreated)
+	c.JSON(http.StatusOK, gin.H{"data": result, "message": "租户环境已克隆，配置副本的密钥已清空且默认禁用，请补全后启用"})
+}
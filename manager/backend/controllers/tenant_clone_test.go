@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCloneTenantEnvironment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.User{}, &models.Config{}, &models.Role{}, &models.Agent{})
+	source := models.User{Username: "template", Email: "t@example.com", Password: "x", Role: "user"}
+	target := models.User{Username: "acme", Email: "a@example.com", Password: "x", Role: "user"}
+	for _, u := range []*models.User{&source, &target} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, cfg := range []models.Config{
+		{Type: "llm", Name: "GPT", ConfigID: "gpt", Provider: "openai", Enabled: true, IsDefault: true,
+			JsonData: `{"api_key":"sk-secret","model":"gpt-4o","extra":{"password":"p"}}`},
+		{Type: "tts", Name: "Doubao", ConfigID: "doubao", Provider: "doubao", Enabled: true,
+			JsonData: `{"token":"tok","voice":"v1"}`},
+	} {
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	llm, tts := "gpt", "doubao"
+	sourceID := source.ID
+	targetID := target.ID
+	if err := db.Create(&models.Role{UserID: &sourceID, Name: "客服", Prompt: "你是客服", LLMConfigID: &llm, RoleType: "user", Status: "active"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Agent{UserID: source.ID, Name: "前台", LLMConfigID: &llm, TTSConfigID: &tts}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 目标已有同名智能体：跳过，不覆盖
+	if err := db.Create(&models.Agent{UserID: target.ID, Name: "前台", CustomPrompt: "保留"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Agent{UserID: source.ID, Name: "导购", TTSConfigID: &tts}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/tenants/clone", ac.CloneTenantEnvironment)
+	post := func(body interface{}) (int, tenantCloneResult) {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tenants/clone", bytes.NewReader(data)))
+		var resp struct {
+			Data tenantCloneResult `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	code, result := post(gin.H{"source_user_id": source.ID, "target_user_id": target.ID, "config_suffix": "acme"})
+	if code != http.StatusOK {
+		t.Fatalf("clone status = %d", code)
+	}
+	if result.ConfigsCreated != 2 || result.RolesCreated != 1 || result.AgentsCreated != 1 || result.AgentsSkipped != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.ConfigIDMap["llm:gpt"] != "gpt_acme" || result.ClearedSecrets["gpt_acme"] != "api_key,extra.password" || result.ClearedSecrets["doubao_acme"] != "token" {
+		t.Fatalf("unexpected mapping %+v", result)
+	}
+
+	var clone models.Config
+	if err := db.Where("config_id = ?", "gpt_acme").First(&clone).Error; err != nil {
+		t.Fatal(err)
+	}
+	if clone.Enabled || clone.IsDefault || clone.JsonData != `{"api_key":"","extra":{"password":""},"model":"gpt-4o"}` {
+		t.Fatalf("unexpected clone %+v", clone)
+	}
+	var role models.Role
+	if err := db.Where("user_id = ?", target.ID).First(&role).Error; err != nil {
+		t.Fatal(err)
+	}
+	if role.LLMConfigID == nil || *role.LLMConfigID != "gpt_acme" || *role.UserID != targetID {
+		t.Fatalf("unexpected role %+v", role)
+	}
+	var kept models.Agent
+	if err := db.Where("user_id = ? AND name = ?", target.ID, "前台").First(&kept).Error; err != nil || kept.CustomPrompt != "保留" {
+		t.Fatalf("existing agent overwritten: %+v %v", kept, err)
+	}
+	var guide models.Agent
+	if err := db.Where("user_id = ? AND name = ?", target.ID, "导购").First(&guide).Error; err != nil || guide.TTSConfigID == nil || *guide.TTSConfigID != "doubao_acme" {
+		t.Fatalf("unexpected cloned agent %+v %v", guide, err)
+	}
+	// 模板配置保持不变
+	var original models.Config
+	if err := db.Where("config_id = ?", "gpt").First(&original).Error; err != nil || !original.IsDefault || original.JsonData == clone.JsonData {
+		t.Fatalf("template config modified: %+v", original)
+	}
+
+	// 重复克隆幂等
+	code, result = post(gin.H{"source_user_id": source.ID, "target_user_id": target.ID, "config_suffix": "acme"})
+	if code != http.StatusOK || result.ConfigsCreated != 0 || result.RolesSkipped != 1 || result.AgentsSkipped != 2 {
+		t.Fatalf("second clone = %d %+v", code, result)
+	}
+
+	if code, _ := post(gin.H{"source_user_id": source.ID, "target_user_id": source.ID}); code != http.StatusBadRequest {
+		t.Fatalf("same user status = %d", code)
+	}
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestDB 创建内存 SQLite 数据库并迁移给定模型，供各控制器测试共用
+func newTestDB(t *testing.T, dst ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dst) > 0 {
+		if err := db.AutoMigrate(dst...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
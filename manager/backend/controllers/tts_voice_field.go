@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"xiaozhi/manager/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ttsVoiceFieldKey 返回 TTS 配置 json_data 中指定音色的字段：cosyvoice 使用 spk_id，其余提供商使用 voice
+// provider 为空时取 json_data.provider
+func ttsVoiceFieldKey(provider string, data map[string]interface{}) string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		if v, ok := data["provider"].(string); ok {
+			provider = strings.ToLower(strings.TrimSpace(v))
+		}
+	}
+	if provider == "cosyvoice" {
+		return "spk_id"
+	}
+	return "voice"
+}
+
+// validateTTSVoiceField 校验 TTS 配置的音色字段与提供商一致：
+// 提供商期望的字段为空而另一字段有值时拒绝，避免下发时音色写入错误字段导致静默使用默认音色
+func validateTTSVoiceField(provider, jsonData string) error {
+	if strings.TrimSpace(jsonData) == "" {
+		return nil
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		// JSON 格式问题由其他校验处理
+		return nil
+	}
+	expected := ttsVoiceFieldKey(provider, data)
+	other := "spk_id"
+	if expected == "spk_id" {
+		other = "voice"
+	}
+	if configYAMLValueEmpty(data[expected]) && !configYAMLValueEmpty(data[other]) {
+		return fmt.Errorf("TTS 音色字段与提供商不匹配：该提供商使用 %s 指定音色，请将 %s 改为 %s", expected, other, expected)
+	}
+	return nil
+}
+
+// validateTTSConfigVoiceRef 保存角色/智能体时校验引用的 TTS 配置音色字段，未引用或配置不存在时跳过
+func validateTTSConfigVoiceRef(db *gorm.DB, ttsConfigID *string) error {
+	if ttsConfigID == nil || strings.TrimSpace(*ttsConfigID) == "" {
+		return nil
+	}
+	var cfg models.Config
+	if err := db.Where("config_id = ? AND type = ?", strings.TrimSpace(*ttsConfigID), "tts").First(&cfg).Error; err != nil {
+		return nil
+	}
+	if err := validateTTSVoiceField(cfg.Provider, cfg.JsonData); err != nil {
+		return fmt.Errorf("TTS 配置 %s 无效: %v", cfg.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateTTSVoiceField(t *testing.T) {
+	cases := []struct {
+		provider, jsonData string
+		wantErr            bool
+	}{
+		{"cosyvoice", `{"api_url":"x","spk_id":"spk1"}`, false},
+		{"cosyvoice", `{"api_url":"x","voice":"spk1"}`, true},
+		{"cosyvoice", `{"api_url":"x","voice":"a","spk_id":"b"}`, false},
+		{"", `{"provider":"cosyvoice","voice":"spk1"}`, true},
+		{"edge", `{"voice":"zh-CN-XiaoxiaoNeural"}`, false},
+		{"edge", `{"spk_id":"zh-CN-XiaoxiaoNeural"}`, true},
+		{"edge_offline", `{"server_url":"ws://localhost"}`, false},
+		{"edge", `not json`, false},
+	}
+	for _, tc := range cases {
+		if err := validateTTSVoiceField(tc.provider, tc.jsonData); (err != nil) != tc.wantErr {
+			t.Errorf("validateTTSVoiceField(%q, %s) err = %v, wantErr %v", tc.provider, tc.jsonData, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidateTTSConfigVoiceRef(t *testing.T) {
+	db := newTestDB(t, &models.Config{})
+	bad := models.Config{Type: "tts", Name: "cosy", ConfigID: "cosy", Provider: "cosyvoice", JsonData: `{"voice":"spk1"}`, Enabled: true}
+	if err := db.Create(&bad).Error; err != nil {
+		t.Fatal(err)
+	}
+	id := "cosy"
+	if err := validateTTSConfigVoiceRef(db, &id); err == nil {
+		t.Fatal("expected error for cosyvoice config with voice but no spk_id")
+	}
+	missing := "missing"
+	if err := validateTTSConfigVoiceRef(db, &missing); err != nil {
+		t.Fatalf("missing config should be skipped: %v", err)
+	}
+	if err := validateTTSConfigVoiceRef(db, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenericConfigEndpointsValidateTTSVoice(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.Config{})
+	ok := models.Config{Type: "tts", Name: "cosy", ConfigID: "cosy", Provider: "cosyvoice", JsonData: `{"spk_id":"spk1"}`}
+	if err := db.Create(&ok).Error; err != nil {
+		t.Fatal(err)
+	}
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/configs", ac.CreateConfig)
+	r.PUT("/configs/:id", ac.UpdateConfig)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/configs", `{"type":"tts","name":"c2","config_id":"c2","provider":"cosyvoice","json_data":"{\"voice\":\"spk1\"}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("create: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPut, "/configs/1", `{"name":"cosy","provider":"cosyvoice","json_data":"{\"voice\":\"spk1\"}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("update: code=%d body=%s", w.Code, w.Body.String())
+	}
+	var saved models.Config
+	db.First(&saved, ok.ID)
+	if saved.JsonData != `{"spk_id":"spk1"}` {
+		t.Fatalf("rejected update persisted: %s", saved.JsonData)
+	}
+}
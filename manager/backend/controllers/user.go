@@ -4,13 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +24,7 @@ type UserController struct {
 		RequestDeviceMcpToolDetailsFromClient(ctx context.Context, deviceID string) ([]MCPTool, error)
 		CallMcpToolFromClient(ctx context.Context, body map[string]interface{}) (map[string]interface{}, error)
 		InjectMessageToDevice(ctx context.Context, deviceID, message string, skipLlm bool) error
+		RequestKnowledgeQueryLogFromClients(ctx context.Context, kbID uint) ([]KnowledgeQueryLogEntry, error)
 	}
 }
 
@@ -96,7 +97,7 @@ func (uc *UserController) InjectMessage(c *gin.Context) {
 	var device models.Device
 
 	if err := uc.DB.Where("device_name = ? AND user_id = ?", req.DeviceID, userID).First(&device).Error; err != nil {
-		log.Printf("[InjectMessage] 设备查询失败: %v", err)
+		logger.Warnf("[InjectMessage] 设备查询失败: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "设备不存在或不属于当前用户"})
 		return
 	}
@@ -201,7 +202,9 @@ func (uc *UserController) GetMyDevices(c *gin.Context) {
 		AgentID      uint       `json:"agent_id"`
 		AgentName    string     `json:"agent_name,omitempty"`
 		Activated    bool       `json:"activated"`
+		Status       string     `json:"status"`
 		LastActiveAt *time.Time `json:"last_active_at"`
+		LastSeenAt   *time.Time `json:"last_seen_at"`
 		CreatedAt    time.Time  `json:"created_at"`
 	}
 
@@ -220,7 +223,9 @@ func (uc *UserController) GetMyDevices(c *gin.Context) {
 			DeviceCode:   device.DeviceCode,
 			AgentID:      device.AgentID,
 			Activated:    device.Activated,
+			Status:       device.Status,
 			LastActiveAt: device.LastActiveAt,
+			LastSeenAt:   device.LastSeenAt,
 			CreatedAt:    device.CreatedAt,
 		}
 
@@ -291,6 +296,7 @@ func (uc *UserController) CreateAgent(c *gin.Context) {
 	var req struct {
 		Name             string  `json:"name" binding:"required,min=2,max=50"`
 		CustomPrompt     string  `json:"custom_prompt"`
+		Greeting         string  `json:"greeting"`
 		LLMConfigID      *string `json:"llm_config_id"`
 		TTSConfigID      *string `json:"tts_config_id"`
 		Voice            *string `json:"voice"`
@@ -320,11 +326,16 @@ func (uc *UserController) CreateAgent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateTTSConfigVoiceRef(uc.DB, req.TTSConfigID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	agent := models.Agent{
 		UserID:          userID.(uint),
 		Name:            req.Name,
 		CustomPrompt:    req.CustomPrompt,
+		Greeting:        req.Greeting,
 		LLMConfigID:     req.LLMConfigID,
 		TTSConfigID:     req.TTSConfigID,
 		Voice:           req.Voice,
@@ -401,6 +412,7 @@ func (uc *UserController) UpdateAgent(c *gin.Context) {
 	var req struct {
 		Name             string  `json:"name" binding:"required,min=2,max=50"`
 		CustomPrompt     string  `json:"custom_prompt"`
+		Greeting         string  `json:"greeting"`
 		LLMConfigID      *string `json:"llm_config_id"`
 		TTSConfigID      *string `json:"tts_config_id"`
 		Voice            *string `json:"voice"`
@@ -414,10 +426,15 @@ func (uc *UserController) UpdateAgent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
 		return
 	}
+	if err := validateTTSConfigVoiceRef(uc.DB, req.TTSConfigID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 更新字段
 	agent.Name = req.Name
 	agent.CustomPrompt = req.CustomPrompt
+	agent.Greeting = req.Greeting
 	agent.LLMConfigID = req.LLMConfigID
 	agent.TTSConfigID = req.TTSConfigID
 	agent.Voice = req.Voice
@@ -438,15 +455,16 @@ func (uc *UserController) UpdateAgent(c *gin.Context) {
 		return
 	}
 	agent.MCPServiceNames = normalizedMCPServiceNames
+	if err := uc.validateKnowledgeBaseOwnership(userID.(uint), req.KnowledgeBaseIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := uc.DB.Save(&agent).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新智能体失败"})
 		return
 	}
-	if err := uc.validateKnowledgeBaseOwnership(userID.(uint), req.KnowledgeBaseIDs); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+	agentMcpToolsCache.invalidate(strconv.Itoa(int(agent.ID)))
 	if err := uc.updateAgentKnowledgeBaseLinks(agent.ID, req.KnowledgeBaseIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新智能体知识库关联失败"})
 		return
@@ -848,6 +866,8 @@ func (uc *UserController) GetDashboardStats(c *gin.Context) {
 		TotalDevices  int64 `json:"totalDevices"`
 		TotalAgents   int64 `json:"totalAgents"`
 		OnlineDevices int64 `json:"onlineDevices"`
+		// ActiveDevices 未因长期离线被停用的设备数
+		ActiveDevices int64 `json:"activeDevices"`
 	}
 
 	stats := DashboardStats{}
@@ -860,6 +880,7 @@ func (uc *UserController) GetDashboardStats(c *gin.Context) {
 		// 在线设备：最近5分钟内活跃的设备
 		fiveMinutesAgo := time.Now().Add(-5 * time.Minute)
 		uc.DB.Model(&models.Device{}).Where("last_active_at > ?", fiveMinutesAgo).Count(&stats.OnlineDevices)
+		uc.DB.Model(&models.Device{}).Where("status <> ?", deviceStatusInactive).Count(&stats.ActiveDevices)
 	} else {
 		// 普通用户只查看自己的数据
 		stats.TotalUsers = 0 // 普通用户不显示用户数
@@ -868,6 +889,7 @@ func (uc *UserController) GetDashboardStats(c *gin.Context) {
 		// 在线设备：用户自己的最近5分钟内活跃的设备
 		fiveMinutesAgo := time.Now().Add(-5 * time.Minute)
 		uc.DB.Model(&models.Device{}).Where("user_id = ? AND last_active_at > ?", userID, fiveMinutesAgo).Count(&stats.OnlineDevices)
+		uc.DB.Model(&models.Device{}).Where("user_id = ? AND status <> ?", userID, deviceStatusInactive).Count(&stats.ActiveDevices)
 	}
 
 	c.JSON(http.StatusOK, stats)
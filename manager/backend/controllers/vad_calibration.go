@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"xiaozhi/manager/backend/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// vadCalibrationSegment 标注的语音区间（毫秒）
+type vadCalibrationSegment struct {
+	StartMs int `json:"start_ms"`
+	EndMs   int `json:"end_ms"`
+}
+
+// parseVADCalibrationSegments 解析并校验语音区间标注，区间须有效且不重叠
+func parseVADCalibrationSegments(raw string) ([]vadCalibrationSegment, error) {
+	var segments []vadCalibrationSegment
+	if err := json.Unmarshal([]byte(raw), &segments); err != nil {
+		return nil, fmt.Errorf("segments 格式错误，应为 [{\"start_ms\":0,\"end_ms\":1000}]")
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("至少需要标注一个语音区间")
+	}
+	for i, seg := range segments {
+		if seg.StartMs < 0 || seg.EndMs <= seg.StartMs {
+			return nil, fmt.Errorf("第%d个语音区间无效: %d-%d", i+1, seg.StartMs, seg.EndMs)
+		}
+		if i > 0 && seg.StartMs < segments[i-1].EndMs {
+			return nil, fmt.Errorf("语音区间须按时间排序且不重叠（第%d个）", i+1)
+		}
+	}
+	return segments, nil
+}
+
+// CalibrateVADThreshold 上传带语音区间标注的 WAV，由主程序扫描 VAD 阈值，返回 F1 最高的推荐阈值及各阈值的混淆矩阵
+// 表单字段：file（16kHz WAV）、segments（JSON 语音区间）、vad_config_id（可选）、
+// threshold_min/threshold_max/threshold_step（可选，默认 0.1/0.9/0.05）、client_uuid（可选）
+func (ac *AdminController) CalibrateVADThreshold(c *gin.Context) {
+	if ac.WebSocketController == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "WebSocket 服务未初始化"})
+		return
+	}
+
+	wavData, ok := readUploadedTestWav(c)
+	if !ok {
+		return
+	}
+	segments, err := parseVADCalibrationSegments(c.PostForm("segments"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payload := map[string]interface{}{"segments": segments, "format": "wav"}
+	for _, key := range []string{"threshold_min", "threshold_max", "threshold_step"} {
+		raw := strings.TrimSpace(c.PostForm(key))
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 || v > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": key + " 取值范围为 0-1"})
+			return
+		}
+		payload[key] = v
+	}
+
+	configID := strings.TrimSpace(c.PostForm("vad_config_id"))
+	if configID == "" {
+		configID = ac.selectPipelineTestConfigID("vad")
+	}
+	if configID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未配置或未启用VAD"})
+		return
+	}
+	item := ac.getConfigItemByTypeAndID("vad", configID)
+	if item == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "VAD 配置不存在: " + configID})
+		return
+	}
+	payload["data"] = gin.H{"vad": map[string]interface{}{configID: item}}
+
+	clientUUID := strings.TrimSpace(c.PostForm("client_uuid"))
+	if clientUUID == "" {
+		clientUUID = ac.WebSocketController.GetFirstConnectedClientUUID()
+	}
+	if clientUUID == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "无主程序连接，无法标定"})
+		return
+	}
+
+	if !checkConfigTestRateLimit(c, []string{"vad"}) {
+		return
+	}
+
+	logger.Debugf("[vad_calibrate] 发送请求 client=%s wav_size=%d config_id=%s segments=%d", clientUUID, len(wavData), configID, len(segments))
+	resp, err := ac.WebSocketController.SendRequestWithBinaryToClient(c.Request.Context(), clientUUID, "POST", "/api/vad/calibrate", payload, wavData, pipelineTestTimeout)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "主程序标定请求失败: " + err.Error()})
+		return
+	}
+	if resp.Status != http.StatusOK {
+		errMsg := resp.Error
+		if errMsg == "" {
+			errMsg = "主程序返回异常状态"
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": errMsg})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"client_uuid": clientUUID,
+		"config_id":   configID,
+		"result":      resp.Body,
+	}})
+}
+
+// vadInfoTimeout 创建临时 VAD 实例并读取版本的超时
+const vadInfoTimeout = 15 * time.Second
+
+// GetVADRuntimeInfo 查询主程序加载的 VAD 原生库版本与指定配置生效后的参数（hop_size、threshold 等）
+// GET /api/admin/configs/test/vad-info?vad_config_id=xxx&client_uuid=xxx（均可选）
+func (ac *AdminController) GetVADRuntimeInfo(c *gin.Context) {
+	if ac.WebSocketController == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "WebSocket 服务未初始化"})
+		return
+	}
+	configID := strings.TrimSpace(c.Query("vad_config_id"))
+	if configID == "" {
+		configID = ac.selectPipelineTestConfigID("vad")
+	}
+	if configID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未配置或未启用VAD"})
+		return
+	}
+	item := ac.getConfigItemByTypeAndID("vad", configID)
+	if item == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "VAD 配置不存在: " + configID})
+		return
+	}
+
+	clientUUID := strings.TrimSpace(c.Query("client_uuid"))
+	if clientUUID == "" {
+		clientUUID = ac.WebSocketController.GetFirstConnectedClientUUID()
+	}
+	if clientUUID == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "无主程序连接，无法查询"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vadInfoTimeout)
+	defer cancel()
+	payload := map[string]interface{}{"data": gin.H{"vad": map[string]interface{}{configID: item}}}
+	resp, err := ac.WebSocketController.SendRequestToClient(ctx, clientUUID, "GET", "/api/vad/info", payload)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "主程序查询失败: " + err.Error()})
+		return
+	}
+	if resp.Status != http.StatusOK {
+		errMsg := resp.Error
+		if errMsg == "" {
+			errMsg = "主程序返回异常状态"
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": errMsg})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"client_uuid": clientUUID,
+		"config_id":   configID,
+		"result":      resp.Body,
+	}})
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import "testing"
+
+func TestParseVADCalibrationSegments(t *testing.T) {
+	segments, err := parseVADCalibrationSegments(`[{"start_ms":500,"end_ms":1500},{"start_ms":2000,"end_ms":2600}]`)
+	if err != nil || len(segments) != 2 || segments[1].EndMs != 2600 {
+		t.Fatalf("segments = %+v, err = %v", segments, err)
+	}
+	for _, raw := range []string{``, `[]`, `[{"start_ms":100,"end_ms":100}]`, `[{"start_ms":0,"end_ms":500},{"start_ms":400,"end_ms":900}]`} {
+		if _, err := parseVADCalibrationSegments(raw); err == nil {
+			t.Fatalf("%q: expected error", raw)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type vadProfileRequest struct {
+	Name                 string   `json:"name"`
+	DeviceID             *uint    `json:"device_id"`
+	AgentID              *uint    `json:"agent_id"`
+	Threshold            *float64 `json:"threshold"`
+	HopSize              *int     `json:"hop_size"`
+	SpeechPadMs          *int     `json:"speech_pad_ms"`
+	MinSilenceDurationMs *int     `json:"min_silence_duration_ms"`
+	Enabled              *bool    `json:"enabled"`
+}
+
+// validate 校验请求参数，device_id 与 agent_id 必须且只能指定一个
+func (r *vadProfileRequest) validate() string {
+	hasDevice := r.DeviceID != nil && *r.DeviceID > 0
+	hasAgent := r.AgentID != nil && *r.AgentID > 0
+	if hasDevice == hasAgent {
+		return "device_id 与 agent_id 必须且只能指定一个"
+	}
+	if r.Threshold != nil && (*r.Threshold < 0 || *r.Threshold > 1) {
+		return "threshold 取值范围为 0-1"
+	}
+	if r.HopSize != nil && *r.HopSize <= 0 {
+		return "hop_size 必须大于0"
+	}
+	if r.SpeechPadMs != nil && *r.SpeechPadMs < 0 {
+		return "speech_pad_ms 不能为负数"
+	}
+	if r.MinSilenceDurationMs != nil && *r.MinSilenceDurationMs < 0 {
+		return "min_silence_duration_ms 不能为负数"
+	}
+	return ""
+}
+
+func (r *vadProfileRequest) applyTo(profile *models.VADProfile) {
+	profile.Name = r.Name
+	profile.DeviceID = nil
+	profile.AgentID = nil
+	if r.DeviceID != nil && *r.DeviceID > 0 {
+		profile.DeviceID = r.DeviceID
+	}
+	if r.AgentID != nil && *r.AgentID > 0 {
+		profile.AgentID = r.AgentID
+	}
+	profile.Threshold = r.Threshold
+	profile.HopSize = r.HopSize
+	profile.SpeechPadMs = r.SpeechPadMs
+	profile.MinSilenceDurationMs = r.MinSilenceDurationMs
+	if r.Enabled != nil {
+		profile.Enabled = *r.Enabled
+	}
+}
+
+// GetVADProfiles 获取VAD调优配置列表，支持 device_id / agent_id 过滤
+func (ac *AdminController) GetVADProfiles(c *gin.Context) {
+	query := ac.DB.Model(&models.VADProfile{})
+	if deviceID := c.Query("device_id"); deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if agentID := c.Query("agent_id"); agentID != "" {
+		query = query.Where("agent_id = ?", agentID)
+	}
+
+	var profiles []models.VADProfile
+	if err := query.Order("id ASC").Find(&profiles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取VAD调优配置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profiles})
+}
+
+// CreateVADProfile 创建设备或智能体的VAD调优配置
+func (ac *AdminController) CreateVADProfile(c *gin.Context) {
+	var req vadProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if !ac.ensureVADProfileTargetExists(c, &req) {
+		return
+	}
+
+	profile := models.VADProfile{Enabled: true}
+	req.applyTo(&profile)
+	enabled := profile.Enabled
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&profile).Error; err != nil {
+			return err
+		}
+		if enabled {
+			return nil
+		}
+		// gorm 对零值 bool 使用列默认值（enabled 默认 true），显式写回禁用状态
+		profile.Enabled = false
+		return tx.Model(&models.VADProfile{}).Where("id = ?", profile.ID).Update("enabled", false).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建VAD调优配置失败，目标可能已存在配置"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": profile})
+}
+
+// UpdateVADProfile 更新VAD调优配置
+func (ac *AdminController) UpdateVADProfile(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var profile models.VADProfile
+	if err := ac.DB.First(&profile, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "VAD调优配置不存在"})
+		return
+	}
+
+	var req vadProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if !ac.ensureVADProfileTargetExists(c, &req) {
+		return
+	}
+
+	req.applyTo(&profile)
+	if err := ac.DB.Save(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新VAD调优配置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// DeleteVADProfile 删除VAD调优配置
+func (ac *AdminController) DeleteVADProfile(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := ac.DB.Delete(&models.VADProfile{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除VAD调优配置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+func (ac *AdminController) ensureVADProfileTargetExists(c *gin.Context, req *vadProfileRequest) bool {
+	var err error
+	if req.DeviceID != nil && *req.DeviceID > 0 {
+		err = ac.DB.First(&models.Device{}, *req.DeviceID).Error
+	} else {
+		err = ac.DB.First(&models.Agent{}, *req.AgentID).Error
+	}
+	if err == nil {
+		return true
+	}
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "关联的设备或智能体不存在"})
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询关联对象失败"})
+	}
+	return false
+}
+
+// resolveVADProfile 按 设备 > 智能体 的优先级查找启用的VAD调优配置，未找到返回 nil
+func (ac *AdminController) resolveVADProfile(deviceID, agentID uint) *models.VADProfile {
+	var profile models.VADProfile
+	if deviceID != 0 {
+		if err := ac.DB.Where("device_id = ? AND enabled = ?", deviceID, true).First(&profile).Error; err == nil {
+			return &profile
+		}
+	}
+	if agentID != 0 {
+		if err := ac.DB.Where("agent_id = ? AND enabled = ?", agentID, true).First(&profile).Error; err == nil {
+			return &profile
+		}
+	}
+	return nil
+}
+
+// applyVADProfile 将调优配置中的非空字段覆盖到 VAD json_data 上，解析失败时返回原数据
+func applyVADProfile(jsonData string, profile *models.VADProfile) string {
+	configData := make(map[string]interface{})
+	if jsonData != "" {
+		if err := json.Unmarshal([]byte(jsonData), &configData); err != nil {
+			logger.Warnf("解析VAD配置失败，跳过调优覆盖: %v", err)
+			return jsonData
+		}
+	}
+	if profile.Threshold != nil {
+		configData["threshold"] = *profile.Threshold
+	}
+	if profile.HopSize != nil {
+		configData["hop_size"] = *profile.HopSize
+	}
+	if profile.SpeechPadMs != nil {
+		configData["speech_pad_ms"] = *profile.SpeechPadMs
+	}
+	if profile.MinSilenceDurationMs != nil {
+		configData["min_silence_duration_ms"] = *profile.MinSilenceDurationMs
+	}
+	updated, err := json.Marshal(configData)
+	if err != nil {
+		return jsonData
+	}
+	return string(updated)
+}
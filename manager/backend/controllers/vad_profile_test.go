@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVADProfileEnabledRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.VADProfile{}, &models.Device{}, &models.Agent{})
+	agent := models.Agent{UserID: 1, Name: "a"}
+	if err := db.Create(&agent).Error; err != nil {
+		t.Fatal(err)
+	}
+	device := models.Device{UserID: 1, AgentID: agent.ID, DeviceName: "d"}
+	if err := db.Create(&device).Error; err != nil {
+		t.Fatal(err)
+	}
+	ac := &AdminController{DB: db}
+	r := gin.New()
+	r.POST("/vad-profiles", ac.CreateVADProfile)
+	r.PUT("/vad-profiles/:id", ac.UpdateVADProfile)
+	do := func(method, path, body string) models.VADProfile {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+			t.Fatalf("%s %s: code=%d body=%s", method, path, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data models.VADProfile `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+	stored := func(id uint) bool {
+		var p models.VADProfile
+		if err := db.First(&p, id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return p.Enabled
+	}
+
+	// 创建时显式禁用应落库为 false
+	disabled := do(http.MethodPost, "/vad-profiles", `{"name":"d","device_id":1,"threshold":0.6,"enabled":false}`)
+	if disabled.Enabled || stored(disabled.ID) {
+		t.Fatal("profile created with enabled=false was stored enabled")
+	}
+	if ac.resolveVADProfile(device.ID, 0) != nil {
+		t.Fatal("disabled profile should not resolve")
+	}
+
+	// 未指定时默认启用
+	enabled := do(http.MethodPost, "/vad-profiles", `{"name":"a","agent_id":1,"threshold":0.4}`)
+	if !enabled.Enabled || !stored(enabled.ID) {
+		t.Fatal("profile created without enabled should default to enabled")
+	}
+	if p := ac.resolveVADProfile(device.ID, agent.ID); p == nil || p.ID != enabled.ID {
+		t.Fatalf("resolved profile = %+v, want agent profile", p)
+	}
+
+	// 更新可来回切换启用状态
+	do(http.MethodPut, "/vad-profiles/1", `{"name":"d","device_id":1,"enabled":true}`)
+	if !stored(disabled.ID) {
+		t.Fatal("update to enabled=true not stored")
+	}
+	if p := ac.resolveVADProfile(device.ID, agent.ID); p == nil || p.ID != disabled.ID {
+		t.Fatalf("resolved profile = %+v, want device profile", p)
+	}
+	do(http.MethodPut, "/vad-profiles/1", `{"name":"d","device_id":1,"enabled":false}`)
+	if stored(disabled.ID) {
+		t.Fatal("update to enabled=false not stored")
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -22,6 +21,7 @@ import (
 	"time"
 
 	"xiaozhi/manager/backend/config"
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 	"xiaozhi/manager/backend/storage"
 
@@ -193,7 +193,7 @@ func (vcc *VoiceCloneController) CreateVoiceClone(c *gin.Context) {
 		return
 	}
 	defer file.Close()
-	log.Printf("[voice_clone][%s] incoming audio: source_type=%s filename=%q ext=%q content_type=%q header_size=%d",
+	logger.Debugf("[voice_clone][%s] incoming audio: source_type=%s filename=%q ext=%q content_type=%q header_size=%d",
 		rawProvider,
 		sourceType,
 		header.Filename,
@@ -1052,7 +1052,7 @@ func (vcc *VoiceCloneController) cloneWithMinimax(ctx context.Context, ttsCfg mo
 	}
 	voiceID := buildMinimaxCustomVoiceID(ttsConfigID)
 	groupID := strings.TrimSpace(getStringAny(cfgMap, "group_id", "GroupId"))
-	log.Printf("[voice_clone][minimax] prepare request: upload_endpoint=%s clone_endpoint=%s model=%q voice_id=%q transcript_len=%d group_id=%q file_name=%q file_path=%q api_key=%s",
+	logger.Debugf("[voice_clone][minimax] prepare request: upload_endpoint=%s clone_endpoint=%s model=%q voice_id=%q transcript_len=%d group_id=%q file_name=%q file_path=%q api_key=%s",
 		uploadEndpoint,
 		endpoint,
 		model,
@@ -1089,7 +1089,7 @@ func (vcc *VoiceCloneController) cloneWithMinimaxEndpoints(ctx context.Context,
 	if err != nil {
 		return nil, fmt.Errorf("构建Minimax复刻请求失败: %w", err)
 	}
-	log.Printf("[voice_clone][minimax] clone request: endpoint=%s file_id_type=%T body=%s group_id=%q api_key=%s",
+	logger.Debugf("[voice_clone][minimax] clone request: endpoint=%s file_id_type=%T body=%s group_id=%q api_key=%s",
 		cloneEndpoint,
 		fileIDPayload,
 		truncateForLog(string(bodyBytes), 1024),
@@ -1119,7 +1119,7 @@ func (vcc *VoiceCloneController) cloneWithMinimaxEndpoints(ctx context.Context,
 	if err != nil {
 		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
-	log.Printf("[voice_clone][minimax] clone response: status=%d body=%s",
+	logger.Debugf("[voice_clone][minimax] clone response: status=%d body=%s",
 		resp.StatusCode,
 		truncateForLog(strings.TrimSpace(string(respBody)), 4096),
 	)
@@ -1140,10 +1140,10 @@ func (vcc *VoiceCloneController) cloneWithMinimaxEndpoints(ctx context.Context,
 		return nil, errors.New("请求 voice_id 为空")
 	}
 	if payloadVoiceID := pickVoiceID(parsed); payloadVoiceID != "" && payloadVoiceID != resolvedVoiceID {
-		log.Printf("[voice_clone][minimax] clone response voice_id=%q ignored, using requested voice_id=%q", payloadVoiceID, resolvedVoiceID)
+		logger.Infof("[voice_clone][minimax] clone response voice_id=%q ignored, using requested voice_id=%q", payloadVoiceID, resolvedVoiceID)
 	}
 	if pickVoiceID(parsed) == "" {
-		log.Printf("[voice_clone][minimax] clone response missing voice_id, using requested voice_id=%q", resolvedVoiceID)
+		logger.Infof("[voice_clone][minimax] clone response missing voice_id, using requested voice_id=%q", resolvedVoiceID)
 	}
 	return &minimaxVoiceCloneResult{
 		VoiceID:      resolvedVoiceID,
@@ -1176,7 +1176,7 @@ func (vcc *VoiceCloneController) cloneWithCosyVoice(ctx context.Context, filePat
 	if transcript == "" {
 		return nil, errors.New("CosyVoice 复刻要求必须填写音频对应文字(train_text)")
 	}
-	log.Printf("[voice_clone][cosyvoice] prepare request: endpoint=%s file_name=%q file_ext=%q file_size=%d transcript_len=%d fixed_key=%q",
+	logger.Debugf("[voice_clone][cosyvoice] prepare request: endpoint=%s file_name=%q file_ext=%q file_size=%d transcript_len=%d fixed_key=%q",
 		cloneURL.String(),
 		fileName,
 		strings.ToLower(filepath.Ext(fileName)),
@@ -1218,7 +1218,7 @@ func (vcc *VoiceCloneController) cloneWithCosyVoice(ctx context.Context, filePat
 	if err != nil {
 		return nil, fmt.Errorf("读取CosyVoice响应失败: %w", err)
 	}
-	log.Printf("[voice_clone][cosyvoice] clone response: status=%d body=%s",
+	logger.Debugf("[voice_clone][cosyvoice] clone response: status=%d body=%s",
 		resp.StatusCode,
 		truncateForLog(strings.TrimSpace(string(respBody)), 4096),
 	)
@@ -1268,7 +1268,7 @@ func (vcc *VoiceCloneController) cloneWithAliyunQwen(ctx context.Context, ttsCfg
 
 	transcript = strings.TrimSpace(transcript)
 	language := mapAliyunQwenCloneLanguage(transcriptLang)
-	log.Printf("[voice_clone][aliyun_qwen] prepare request: endpoint=%s model=%q target_model=%q preferred_name=%q file_name=%q file_ext=%q file_size=%d mime_type=%q transcript_len=%d language=%q api_key=%s",
+	logger.Debugf("[voice_clone][aliyun_qwen] prepare request: endpoint=%s model=%q target_model=%q preferred_name=%q file_name=%q file_ext=%q file_size=%d mime_type=%q transcript_len=%d language=%q api_key=%s",
 		endpoint,
 		defaultAliyunQwenCloneModel,
 		targetModel,
@@ -1323,7 +1323,7 @@ func (vcc *VoiceCloneController) cloneWithAliyunQwen(ctx context.Context, ttsCfg
 	if err != nil {
 		return nil, fmt.Errorf("读取千问复刻响应失败: %w", err)
 	}
-	log.Printf("[voice_clone][aliyun_qwen] clone response: status=%d body=%s",
+	logger.Debugf("[voice_clone][aliyun_qwen] clone response: status=%d body=%s",
 		resp.StatusCode,
 		truncateForLog(strings.TrimSpace(string(respBody)), 4096),
 	)
@@ -1379,7 +1379,7 @@ func (vcc *VoiceCloneController) uploadMinimaxVoiceCloneFile(ctx context.Context
 		}
 		_, _ = f.Seek(0, io.SeekStart)
 	}
-	log.Printf("[voice_clone][minimax] upload request: endpoint=%s purpose=voice_clone file_name=%q file_ext=%q stored_ext=%q file_size=%d detected_content_type=%q group_id=%q api_key=%s",
+	logger.Debugf("[voice_clone][minimax] upload request: endpoint=%s purpose=voice_clone file_name=%q file_ext=%q stored_ext=%q file_size=%d detected_content_type=%q group_id=%q api_key=%s",
 		uploadEndpoint,
 		fileName,
 		strings.ToLower(filepath.Ext(fileName)),
@@ -1428,7 +1428,7 @@ func (vcc *VoiceCloneController) uploadMinimaxVoiceCloneFile(ctx context.Context
 	if err != nil {
 		return "", fmt.Errorf("读取上传响应失败: %w", err)
 	}
-	log.Printf("[voice_clone][minimax] upload response: status=%d body=%s",
+	logger.Debugf("[voice_clone][minimax] upload response: status=%d body=%s",
 		resp.StatusCode,
 		truncateForLog(strings.TrimSpace(string(respBody)), 4096),
 	)
@@ -1528,7 +1528,7 @@ func validateCloneAudioForProvider(provider, filePath string) error {
 		if err != nil {
 			return fmt.Errorf("音频格式校验失败: %w", err)
 		}
-		log.Printf("[voice_clone][minimax] local duration check: file=%q duration=%.3fs min=%.1fs", filePath, audioSeconds, minMinimaxCloneAudioSeconds)
+		logger.Debugf("[voice_clone][minimax] local duration check: file=%q duration=%.3fs min=%.1fs", filePath, audioSeconds, minMinimaxCloneAudioSeconds)
 		if audioSeconds < minMinimaxCloneAudioSeconds {
 			return fmt.Errorf("Minimax 声音复刻要求音频时长至少 %.0f 秒，当前 %.2f 秒", minMinimaxCloneAudioSeconds, audioSeconds)
 		}
@@ -1541,7 +1541,7 @@ func validateCloneAudioForProvider(provider, filePath string) error {
 		if err != nil {
 			return fmt.Errorf("音频格式校验失败: %w", err)
 		}
-		log.Printf("[voice_clone][cosyvoice] local wav check: file=%q duration=%.3fs", filePath, audioSeconds)
+		logger.Debugf("[voice_clone][cosyvoice] local wav check: file=%q duration=%.3fs", filePath, audioSeconds)
 		return nil
 	case "aliyun_qwen":
 		mimeType, supported := aliyunQwenCloneAudioMimeTypeByExt(ext)
@@ -1563,12 +1563,12 @@ func validateCloneAudioForProvider(provider, filePath string) error {
 			if err != nil {
 				return fmt.Errorf("音频格式校验失败: %w", err)
 			}
-			log.Printf("[voice_clone][aliyun_qwen] local wav check: file=%q duration=%.3fs max=%.1fs", filePath, audioSeconds, maxAliyunQwenCloneAudioSeconds)
+			logger.Debugf("[voice_clone][aliyun_qwen] local wav check: file=%q duration=%.3fs max=%.1fs", filePath, audioSeconds, maxAliyunQwenCloneAudioSeconds)
 			if audioSeconds > maxAliyunQwenCloneAudioSeconds {
 				return fmt.Errorf("千问声音复刻音频时长不能超过 %.0f 秒，当前 %.2f 秒", maxAliyunQwenCloneAudioSeconds, audioSeconds)
 			}
 		} else {
-			log.Printf("[voice_clone][aliyun_qwen] local audio check: file=%q ext=%q size=%d mime=%q", filePath, ext, stat.Size(), mimeType)
+			logger.Debugf("[voice_clone][aliyun_qwen] local audio check: file=%q ext=%q size=%d mime=%q", filePath, ext, stat.Size(), mimeType)
 		}
 		return nil
 	default:
@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 
 	"gorm.io/gorm"
@@ -29,14 +29,14 @@ func (vcc *VoiceCloneController) reloadPendingVoiceCloneTasks() {
 	if err := vcc.DB.Where("status IN ?", []string{voiceCloneTaskStatusQueued, voiceCloneTaskStatusProcessing}).
 		Order("created_at ASC").
 		Find(&pendingTasks).Error; err != nil {
-		log.Printf("[voice_clone][task] reload pending tasks failed: %v", err)
+		logger.Warnf("[voice_clone][task] reload pending tasks failed: %v", err)
 		return
 	}
 	for _, task := range pendingTasks {
 		vcc.enqueueVoiceCloneTask(task.ID)
 	}
 	if len(pendingTasks) > 0 {
-		log.Printf("[voice_clone][task] reloaded pending tasks: %d", len(pendingTasks))
+		logger.Infof("[voice_clone][task] reloaded pending tasks: %d", len(pendingTasks))
 	}
 }
 
@@ -47,7 +47,7 @@ func (vcc *VoiceCloneController) enqueueVoiceCloneTask(taskPrimaryID uint) {
 	select {
 	case vcc.taskQueue <- taskPrimaryID:
 	default:
-		log.Printf("[voice_clone][task] queue is full, fallback to async enqueue: task_primary_id=%d", taskPrimaryID)
+		logger.Warnf("[voice_clone][task] queue is full, fallback to async enqueue: task_primary_id=%d", taskPrimaryID)
 		go func(id uint) {
 			vcc.taskQueue <- id
 		}(taskPrimaryID)
@@ -56,7 +56,7 @@ func (vcc *VoiceCloneController) enqueueVoiceCloneTask(taskPrimaryID uint) {
 
 func (vcc *VoiceCloneController) voiceCloneTaskWorkerLoop(workerID int) {
 	for taskPrimaryID := range vcc.taskQueue {
-		log.Printf("[voice_clone][task] worker=%d picked task_primary_id=%d", workerID, taskPrimaryID)
+		logger.Infof("[voice_clone][task] worker=%d picked task_primary_id=%d", workerID, taskPrimaryID)
 		vcc.processVoiceCloneTask(taskPrimaryID)
 	}
 }
@@ -64,7 +64,7 @@ func (vcc *VoiceCloneController) voiceCloneTaskWorkerLoop(workerID int) {
 func (vcc *VoiceCloneController) processVoiceCloneTask(taskPrimaryID uint) {
 	task, claimed, err := vcc.claimVoiceCloneTask(taskPrimaryID)
 	if err != nil {
-		log.Printf("[voice_clone][task] claim task failed: task_primary_id=%d err=%v", taskPrimaryID, err)
+		logger.Warnf("[voice_clone][task] claim task failed: task_primary_id=%d err=%v", taskPrimaryID, err)
 		return
 	}
 	if !claimed || task == nil {
@@ -113,10 +113,10 @@ func (vcc *VoiceCloneController) processVoiceCloneTask(taskPrimaryID uint) {
 		return
 	}
 	if err = vcc.finishVoiceCloneTaskSuccess(task, &clone, &audio, result); err != nil {
-		log.Printf("[voice_clone][task] finish success failed: task_primary_id=%d err=%v", taskPrimaryID, err)
+		logger.Warnf("[voice_clone][task] finish success failed: task_primary_id=%d err=%v", taskPrimaryID, err)
 		return
 	}
-	log.Printf("[voice_clone][task] task completed: task_primary_id=%d task_id=%s voice_clone_id=%d", taskPrimaryID, task.TaskID, task.VoiceCloneID)
+	logger.Infof("[voice_clone][task] task completed: task_primary_id=%d task_id=%s voice_clone_id=%d", taskPrimaryID, task.TaskID, task.VoiceCloneID)
 }
 
 func (vcc *VoiceCloneController) claimVoiceCloneTask(taskPrimaryID uint) (*models.VoiceCloneTask, bool, error) {
@@ -270,10 +270,10 @@ func (vcc *VoiceCloneController) finishVoiceCloneTaskFailed(task *models.VoiceCl
 		return nil
 	})
 	if err != nil {
-		log.Printf("[voice_clone][task] mark failed status failed: task_primary_id=%d err=%v origin=%s", task.ID, err, lastError)
+		logger.Warnf("[voice_clone][task] mark failed status failed: task_primary_id=%d err=%v origin=%s", task.ID, err, lastError)
 		return
 	}
-	log.Printf("[voice_clone][task] task failed: task_primary_id=%d task_id=%s reason=%s", task.ID, task.TaskID, lastError)
+	logger.Warnf("[voice_clone][task] task failed: task_primary_id=%d task_id=%s reason=%s", task.ID, task.TaskID, lastError)
 }
 
 func mergeJSONMeta(raw string, updates map[string]any) string {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 	"time"
@@ -15,6 +14,7 @@ import (
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"gorm.io/gorm"
 
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/models"
 )
 
@@ -32,8 +32,10 @@ type WebSocketClient struct {
 	requestChans map[string]chan *WebSocketResponse
 	callbacks    map[string]func(*WebSocketResponse)
 	mu           sync.RWMutex
+	writeMu      sync.Mutex // 串行化写入，gorilla/websocket 不支持并发写
 	isConnected  bool
-	stopChan     chan struct{} // 停止信号通道
+	stopChan     chan struct{}       // 停止信号通道
+	devices      map[string]struct{} // 该主程序当前服务的设备（device_name），由设备上下线上报维护，受 mu 保护
 }
 
 type WebSocketRequest struct {
@@ -77,7 +79,7 @@ func (ctrl *WebSocketController) HandleWebSocket(c *gin.Context) {
 	// 获取UUID header
 	clientUUID := c.GetHeader("UUID")
 	if clientUUID == "" {
-		log.Printf("WebSocket连接缺少UUID header")
+		logger.Warnf("WebSocket连接缺少UUID header")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少UUID header"})
 		return
 	}
@@ -85,13 +87,13 @@ func (ctrl *WebSocketController) HandleWebSocket(c *gin.Context) {
 	// 升级HTTP连接为WebSocket连接
 	conn, err := ctrl.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("WebSocket升级失败: %v", err)
+		logger.Warnf("WebSocket升级失败: %v", err)
 		return
 	}
 
 	// 检查是否已存在相同UUID的连接
 	if existingClient, exists := ctrl.clientsMap.Get(clientUUID); exists {
-		log.Printf("断开现有连接: %s", clientUUID)
+		logger.Infof("断开现有连接: %s", clientUUID)
 		existingClient.conn.Close()
 		existingClient.isConnected = false
 	}
@@ -105,12 +107,13 @@ func (ctrl *WebSocketController) HandleWebSocket(c *gin.Context) {
 		callbacks:    make(map[string]func(*WebSocketResponse)),
 		isConnected:  true,
 		stopChan:     make(chan struct{}),
+		devices:      make(map[string]struct{}),
 	}
 
 	// 存储到clientsMap中
 	ctrl.clientsMap.Set(clientUUID, client)
 
-	log.Printf("新的WebSocket客户端已连接: %s", clientUUID)
+	logger.Infof("新的WebSocket客户端已连接: %s", clientUUID)
 
 	// 启动客户端消息处理
 	go client.handleMessages()
@@ -125,7 +128,7 @@ func (ctrl *WebSocketController) removeClient(clientID string) {
 		// 发送停止信号给心跳检测
 		select {
 		case client.stopChan <- struct{}{}:
-			log.Printf("已发送停止信号给客户端: %s", clientID)
+			logger.Infof("已发送停止信号给客户端: %s", clientID)
 		default:
 			// 通道可能已满或已关闭，忽略
 		}
@@ -134,7 +137,7 @@ func (ctrl *WebSocketController) removeClient(clientID string) {
 		client.isConnected = false
 		// 从映射中移除
 		ctrl.clientsMap.Remove(clientID)
-		log.Printf("WebSocket客户端已断开: %s", clientID)
+		logger.Infof("WebSocket客户端已断开: %s", clientID)
 	}
 }
 
@@ -167,7 +170,7 @@ func (ctrl *WebSocketController) GetFirstConnectedClientUUID() string {
 // 向指定UUID的客户端发送消息
 func (ctrl *WebSocketController) SendToClient(uuid string, message interface{}) error {
 	if client, exists := ctrl.clientsMap.Get(uuid); exists && client.isConnected {
-		return client.conn.WriteJSON(message)
+		return client.writeJSON(message)
 	}
 	return fmt.Errorf("客户端 %s 未连接", uuid)
 }
@@ -176,8 +179,8 @@ func (ctrl *WebSocketController) SendToClient(uuid string, message interface{})
 func (ctrl *WebSocketController) Broadcast(message interface{}) {
 	for item := range ctrl.clientsMap.IterBuffered() {
 		if client := item.Val; client.isConnected {
-			if err := client.conn.WriteJSON(message); err != nil {
-				log.Printf("向客户端 %s 广播消息失败: %v", client.ID, err)
+			if err := client.writeJSON(message); err != nil {
+				logger.Warnf("向客户端 %s 广播消息失败: %v", client.ID, err)
 			}
 		}
 	}
@@ -205,7 +208,7 @@ func (client *WebSocketClient) handleMessages() {
 		messageType, reader, err := client.conn.NextReader()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket读取错误: %v", err)
+				logger.Warnf("WebSocket读取错误: %v", err)
 			}
 			return
 		}
@@ -216,7 +219,7 @@ func (client *WebSocketClient) handleMessages() {
 			// 处理JSON消息
 			var rawMessage map[string]interface{}
 			if err := json.NewDecoder(reader).Decode(&rawMessage); err != nil {
-				log.Printf("解析JSON消息失败: %v", err)
+				logger.Warnf("解析JSON消息失败: %v", err)
 				continue
 			}
 			// 处理消息
@@ -224,22 +227,22 @@ func (client *WebSocketClient) handleMessages() {
 
 		case websocket.PingMessage:
 			// 处理ping消息，自动回复pong
-			log.Printf("收到ping消息，自动回复pong")
+			logger.Debugf("收到ping消息，自动回复pong")
 			if err := client.conn.WriteControl(websocket.PongMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-				log.Printf("发送pong失败: %v", err)
+				logger.Warnf("发送pong失败: %v", err)
 			}
 
 		case websocket.PongMessage:
 			// 处理pong消息
-			log.Printf("收到pong消息")
+			logger.Debugf("收到pong消息")
 
 		case websocket.CloseMessage:
 			// 处理关闭消息
-			log.Printf("收到关闭消息")
+			logger.Debugf("收到关闭消息")
 			return
 
 		default:
-			log.Printf("收到未知类型的WebSocket消息: %d", messageType)
+			logger.Debugf("收到未知类型的WebSocket消息: %d", messageType)
 		}
 	}
 }
@@ -258,18 +261,18 @@ func (client *WebSocketClient) handleMessage(rawMessage map[string]interface{})
 		return
 	}
 
-	log.Printf("收到无法识别的消息: %+v", rawMessage)
+	logger.Debugf("收到无法识别的消息: %+v", rawMessage)
 }
 
 // 处理请求消息
 func (client *WebSocketClient) handleRequest(rawMessage map[string]interface{}) {
 	var request WebSocketRequest
 	if err := mapToStruct(rawMessage, &request); err != nil {
-		log.Printf("解析请求失败: %v", err)
+		logger.Warnf("解析请求失败: %v", err)
 		return
 	}
 
-	log.Printf("收到请求: ID=%s, Method=%s, Path=%s", request.ID, request.Method, request.Path)
+	logger.Debugf("收到请求: ID=%s, Method=%s, Path=%s", request.ID, request.Method, request.Path)
 
 	// 处理请求并发送响应
 	client.processRequest(&request)
@@ -279,11 +282,11 @@ func (client *WebSocketClient) handleRequest(rawMessage map[string]interface{})
 func (client *WebSocketClient) handleResponse(rawMessage map[string]interface{}) {
 	var response WebSocketResponse
 	if err := mapToStruct(rawMessage, &response); err != nil {
-		log.Printf("解析响应失败: %v", err)
+		logger.Warnf("解析响应失败: %v", err)
 		return
 	}
 
-	log.Printf("收到响应: ID=%s, Status=%d", response.ID, response.Status)
+	logger.Debugf("收到响应: ID=%s, Status=%d", response.ID, response.Status)
 
 	// 查找对应的响应通道
 	client.mu.RLock()
@@ -295,7 +298,7 @@ func (client *WebSocketClient) handleResponse(rawMessage map[string]interface{})
 		select {
 		case responseChan <- &response:
 		default:
-			log.Printf("响应通道已满，丢弃响应: %s", response.ID)
+			logger.Warnf("响应通道已满，丢弃响应: %s", response.ID)
 		}
 	}
 
@@ -304,7 +307,7 @@ func (client *WebSocketClient) handleResponse(rawMessage map[string]interface{})
 	}
 
 	if !exists && !callbackExists {
-		log.Printf("收到未知的响应ID: %s", response.ID)
+		logger.Debugf("收到未知的响应ID: %s", response.ID)
 	}
 }
 
@@ -324,7 +327,7 @@ func (client *WebSocketClient) processRequest(request *WebSocketRequest) {
 		client.handleDeviceInactiveRequest(request)
 
 	default:
-		log.Printf("未知的请求路径: %s", request.Path)
+		logger.Warnf("未知的请求路径: %s", request.Path)
 		client.sendResponse(request.ID, 404, nil, "Unknown endpoint")
 	}
 }
@@ -364,31 +367,38 @@ func (client *WebSocketClient) handleDeviceActiveRequest(request *WebSocketReque
 	}
 
 	if deviceID == "" {
-		log.Printf("收到设备活跃请求，但缺少device_id")
+		logger.Debugf("收到设备活跃请求，但缺少device_id")
 		client.sendResponse(request.ID, 400, nil, "缺少device_id参数")
 		return
 	}
 
-	log.Printf("处理设备活跃时间更新请求，device_id: %s", deviceID)
+	logger.Infof("处理设备活跃时间更新请求，device_id: %s", deviceID)
 
 	// 更新设备最后活跃时间
 	now := time.Now()
+	// 设备重新上线时恢复因长期离线被自动停用的状态
 	result := client.controller.DB.Model(&models.Device{}).
 		Where("device_name = ?", deviceID).
-		Update("last_active_at", now)
+		Updates(map[string]interface{}{
+			"last_active_at": now,
+			"last_seen_at":   now,
+			"status":         deviceStatusActive,
+		})
 
 	if result.Error != nil {
-		log.Printf("更新设备活跃时间失败: %v", result.Error)
+		logger.Errorf("更新设备活跃时间失败: %v", result.Error)
 		client.sendResponse(request.ID, 500, nil, fmt.Sprintf("更新设备活跃时间失败: %v", result.Error))
 		return
 	}
 
 	if result.RowsAffected == 0 {
-		log.Printf("设备不存在: %s", deviceID)
+		logger.Warnf("设备不存在: %s", deviceID)
 		client.sendResponse(request.ID, 404, nil, "设备不存在")
 		return
 	}
 
+	client.trackDevice(deviceID, true)
+
 	// 构造成功响应
 	response := map[string]interface{}{
 		"device_id":      deviceID,
@@ -397,7 +407,7 @@ func (client *WebSocketClient) handleDeviceActiveRequest(request *WebSocketReque
 	}
 
 	client.sendResponse(request.ID, 200, response, "")
-	log.Printf("设备 %s 活跃时间已更新为: %s", deviceID, now.Format(time.RFC3339))
+	logger.Infof("设备 %s 活跃时间已更新为: %s", deviceID, now.Format(time.RFC3339))
 }
 
 // 处理设备离线请求
@@ -411,26 +421,30 @@ func (client *WebSocketClient) handleDeviceInactiveRequest(request *WebSocketReq
 	}
 
 	if deviceID == "" {
-		log.Printf("收到设备离线请求，但缺少device_id")
+		logger.Debugf("收到设备离线请求，但缺少device_id")
 		client.sendResponse(request.ID, 400, nil, "缺少device_id参数")
 		return
 	}
 
-	log.Printf("处理设备离线请求，device_id: %s", deviceID)
+	logger.Infof("处理设备离线请求，device_id: %s", deviceID)
+	client.trackDevice(deviceID, false)
 
-	// 将设备最后活跃时间设置为0（离线状态）
+	// 将设备最后活跃时间设置为0（离线状态），last_seen_at 记录下线时间用于长期离线判断
 	result := client.controller.DB.Model(&models.Device{}).
 		Where("device_name = ?", deviceID).
-		Update("last_active_at", nil) // 设置为NULL表示离线
+		Updates(map[string]interface{}{
+			"last_active_at": nil, // 设置为NULL表示离线
+			"last_seen_at":   time.Now(),
+		})
 
 	if result.Error != nil {
-		log.Printf("更新设备离线状态失败: %v", result.Error)
+		logger.Errorf("更新设备离线状态失败: %v", result.Error)
 		client.sendResponse(request.ID, 500, nil, fmt.Sprintf("更新设备离线状态失败: %v", result.Error))
 		return
 	}
 
 	if result.RowsAffected == 0 {
-		log.Printf("设备不存在: %s", deviceID)
+		logger.Warnf("设备不存在: %s", deviceID)
 		client.sendResponse(request.ID, 404, nil, "设备不存在")
 		return
 	}
@@ -443,7 +457,7 @@ func (client *WebSocketClient) handleDeviceInactiveRequest(request *WebSocketReq
 	}
 
 	client.sendResponse(request.ID, 200, response, "")
-	log.Printf("设备 %s 已设置为离线状态", deviceID)
+	logger.Infof("设备 %s 已设置为离线状态", deviceID)
 }
 
 // 发送响应
@@ -455,10 +469,10 @@ func (client *WebSocketClient) sendResponse(requestID string, status int, body m
 		Error:  errorMsg,
 	}
 
-	if err := client.conn.WriteJSON(response); err != nil {
-		log.Printf("发送响应失败: %v", err)
+	if err := client.writeJSON(response); err != nil {
+		logger.Warnf("发送响应失败: %v", err)
 	} else {
-		log.Printf("已发送响应: ID=%s, Status=%d", requestID, status)
+		logger.Infof("已发送响应: ID=%s, Status=%d", requestID, status)
 	}
 }
 
@@ -474,7 +488,7 @@ func (client *WebSocketClient) heartbeat() {
 	for {
 		select {
 		case <-client.stopChan:
-			log.Printf("收到停止信号，停止心跳检测")
+			logger.Debugf("收到停止信号，停止心跳检测")
 			return
 		case <-ticker.C:
 			if !client.isConnected {
@@ -483,25 +497,25 @@ func (client *WebSocketClient) heartbeat() {
 
 			// 检查连接是否仍然有效
 			if client.conn == nil {
-				log.Printf("WebSocket连接已为空，停止心跳检测")
+				logger.Infof("WebSocket连接已为空，停止心跳检测")
 				return
 			}
 
 			// 发送WebSocket原生ping
 			if err := client.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
 				pingFailCount++
-				log.Printf("发送ping失败 (第%d次): %v", pingFailCount, err)
+				logger.Warnf("发送ping失败 (第%d次): %v", pingFailCount, err)
 
 				// 只有连续失败超过阈值才断开连接
 				if pingFailCount >= maxPingFailCount {
-					log.Printf("连续ping失败%d次，断开WebSocket连接", maxPingFailCount)
+					logger.Warnf("连续ping失败%d次，断开WebSocket连接", maxPingFailCount)
 					client.conn.Close()
 					return
 				}
 			} else {
 				// ping成功，重置失败计数
 				if pingFailCount > 0 {
-					log.Printf("ping恢复成功，重置失败计数")
+					logger.Infof("ping恢复成功，重置失败计数")
 					pingFailCount = 0
 				}
 			}
@@ -518,7 +532,7 @@ func (client *WebSocketClient) SendRequest(method, path string, body map[string]
 		Body:   body,
 	}
 
-	return client.conn.WriteJSON(request)
+	return client.writeJSON(request)
 }
 
 // 发送请求并等待响应
@@ -547,7 +561,7 @@ func (client *WebSocketClient) SendRequestWithResponse(ctx context.Context, meth
 	}()
 
 	// 发送请求
-	if err := client.conn.WriteJSON(request); err != nil {
+	if err := client.writeJSON(request); err != nil {
 		return nil, fmt.Errorf("发送请求失败: %v", err)
 	}
 
@@ -595,7 +609,7 @@ func (ctrl *WebSocketController) RequestMcpToolsFromClient(ctx context.Context,
 }
 
 func (ctrl *WebSocketController) RequestMcpToolDetailsFromClient(ctx context.Context, agentID string) ([]MCPTool, error) {
-	log.Printf("开始请求客户端MCP工具列表，agentID: %s", agentID)
+	logger.Infof("开始请求客户端MCP工具列表，agentID: %s", agentID)
 	return ctrl.requestMcpToolsByBody(ctx, map[string]interface{}{"agent_id": agentID})
 }
 
@@ -615,7 +629,7 @@ func (ctrl *WebSocketController) RequestDeviceMcpToolsFromClient(ctx context.Con
 }
 
 func (ctrl *WebSocketController) RequestDeviceMcpToolDetailsFromClient(ctx context.Context, deviceID string) ([]MCPTool, error) {
-	log.Printf("开始请求设备MCP工具列表，deviceID: %s", deviceID)
+	logger.Infof("开始请求设备MCP工具列表，deviceID: %s", deviceID)
 	return ctrl.requestMcpToolsByBody(ctx, map[string]interface{}{"device_id": deviceID})
 }
 
@@ -691,7 +705,7 @@ func (ctrl *WebSocketController) broadcastRequestAndWaitFirstSuccess(ctx context
 		select {
 		case responseChan <- response:
 		default:
-			log.Printf("响应通道已满，丢弃响应: %s", response.ID)
+			logger.Warnf("响应通道已满，丢弃响应: %s", response.ID)
 		}
 	}
 
@@ -708,8 +722,8 @@ func (ctrl *WebSocketController) broadcastRequestAndWaitFirstSuccess(ctx context
 		callbacksRegistered++
 
 		request := WebSocketRequest{ID: requestID, Method: method, Path: path, Body: body}
-		if err := client.conn.WriteJSON(request); err != nil {
-			log.Printf("向客户端 %s 发送请求失败: %v", client.ID, err)
+		if err := client.writeJSON(request); err != nil {
+			logger.Warnf("向客户端 %s 发送请求失败: %v", client.ID, err)
 		}
 	}
 
@@ -786,11 +800,11 @@ func (ctrl *WebSocketController) InjectMessageToDevice(ctx context.Context, devi
 		client := item.Val
 		if client.isConnected {
 			clientCount++
-			if err := client.conn.WriteJSON(request); err != nil {
-				log.Printf("向客户端 %s 广播注入消息失败: %v", client.ID, err)
+			if err := client.writeJSON(request); err != nil {
+				logger.Warnf("向客户端 %s 广播注入消息失败: %v", client.ID, err)
 				lastError = err
 			} else {
-				log.Printf("向客户端 %s 广播注入消息成功", client.ID)
+				logger.Infof("向客户端 %s 广播注入消息成功", client.ID)
 			}
 		}
 	}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// 控制协议二进制帧：用于随请求携带音频等二进制数据
+// 帧格式：[4字节大端 header 长度][JSON header][负载]
+// 发送方先按序发送同一 request_id 的全部二进制帧，再发送 JSON 请求；
+// 请求体 binary 字段给出帧数与总字节数，接收方据此校验并取出负载
+const (
+	websocketBinaryFrameHeaderLen = 4
+	websocketBinaryChunkSize      = 256 * 1024
+)
+
+// websocketBinaryFrameHeader 二进制帧头
+type websocketBinaryFrameHeader struct {
+	RequestID string `json:"request_id"`
+	Seq       int    `json:"seq"`
+	Final     bool   `json:"final"`
+}
+
+// encodeWebSocketBinaryFrame 编码一个二进制帧
+func encodeWebSocketBinaryFrame(header websocketBinaryFrameHeader, payload []byte) ([]byte, error) {
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, websocketBinaryFrameHeaderLen+len(headerBytes)+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(headerBytes)))
+	copy(frame[websocketBinaryFrameHeaderLen:], headerBytes)
+	copy(frame[websocketBinaryFrameHeaderLen+len(headerBytes):], payload)
+	return frame, nil
+}
+
+// writeJSON 串行写入 JSON 消息
+func (client *WebSocketClient) writeJSON(v interface{}) error {
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	return client.conn.WriteJSON(v)
+}
+
+// SendRequestWithBinary 发送携带二进制负载的请求并等待响应
+// 二进制帧与 JSON 请求在同一把写锁内连续发出，保证接收方先收齐负载再处理请求
+func (client *WebSocketClient) SendRequestWithBinary(ctx context.Context, method, path string, body map[string]interface{}, payload []byte) (*WebSocketResponse, error) {
+	requestID := uuid.New().String()
+
+	frames := make([][]byte, 0, len(payload)/websocketBinaryChunkSize+1)
+	for seq, offset := 0, 0; offset < len(payload) || seq == 0; seq++ {
+		end := offset + websocketBinaryChunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		frame, err := encodeWebSocketBinaryFrame(websocketBinaryFrameHeader{
+			RequestID: requestID,
+			Seq:       seq,
+			Final:     end >= len(payload),
+		}, payload[offset:end])
+		if err != nil {
+			return nil, fmt.Errorf("编码二进制帧失败: %v", err)
+		}
+		frames = append(frames, frame)
+		offset = end
+	}
+
+	if body == nil {
+		body = make(map[string]interface{})
+	}
+	body["binary"] = map[string]interface{}{
+		"frames": len(frames),
+		"size":   len(payload),
+	}
+	request := WebSocketRequest{
+		ID:     requestID,
+		Method: method,
+		Path:   path,
+		Body:   body,
+	}
+
+	responseChan := make(chan *WebSocketResponse, 1)
+	client.mu.Lock()
+	client.requestChans[requestID] = responseChan
+	client.mu.Unlock()
+
+	defer func() {
+		client.mu.Lock()
+		delete(client.requestChans, requestID)
+		client.mu.Unlock()
+		close(responseChan)
+	}()
+
+	err := func() error {
+		client.writeMu.Lock()
+		defer client.writeMu.Unlock()
+		for _, frame := range frames {
+			if err := client.conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+				return err
+			}
+		}
+		return client.conn.WriteJSON(request)
+	}()
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+
+	select {
+	case response := <-responseChan:
+		return response, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("请求超时")
+		}
+		return nil, fmt.Errorf("上下文取消")
+	}
+}
+
+// SendRequestWithBinaryToClient 向指定UUID的客户端发送携带二进制负载的请求并等待响应
+func (ctrl *WebSocketController) SendRequestWithBinaryToClient(ctx context.Context, uuid string, method, path string, body map[string]interface{}, payload []byte, timeout time.Duration) (*WebSocketResponse, error) {
+	client, exists := ctrl.clientsMap.Get(uuid)
+	if !exists || !client.isConnected {
+		return nil, fmt.Errorf("客户端 %s 未连接", uuid)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return client.SendRequestWithBinary(ctx, method, path, body, payload)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+func TestEncodeWebSocketBinaryFrame(t *testing.T) {
+	payload := []byte{0x52, 0x49, 0x46, 0x46, 0x00, 0xff}
+	frame, err := encodeWebSocketBinaryFrame(websocketBinaryFrameHeader{RequestID: "req-1", Seq: 2, Final: true}, payload)
+	if err != nil {
+		t.Fatalf("encodeWebSocketBinaryFrame error: %v", err)
+	}
+
+	headerLen := int(binary.BigEndian.Uint32(frame))
+	if websocketBinaryFrameHeaderLen+headerLen > len(frame) {
+		t.Fatalf("header length %d exceeds frame length %d", headerLen, len(frame))
+	}
+	var header websocketBinaryFrameHeader
+	if err := json.Unmarshal(frame[websocketBinaryFrameHeaderLen:websocketBinaryFrameHeaderLen+headerLen], &header); err != nil {
+		t.Fatalf("decode header error: %v", err)
+	}
+	if header.RequestID != "req-1" || header.Seq != 2 || !header.Final {
+		t.Fatalf("header = %+v, want request_id=req-1 seq=2 final=true", header)
+	}
+	if got := frame[websocketBinaryFrameHeaderLen+headerLen:]; !bytes.Equal(got, payload) {
+		t.Fatalf("payload = %x, want %x", got, payload)
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"xiaozhi/manager/backend/logger"
+	"xiaozhi/manager/backend/models"
+)
+
+// BroadcastFilter 定向推送的设备筛选条件，多个条件同时生效（AND），零值表示不限
+type BroadcastFilter struct {
+	AgentID       uint `json:"agent_id"`
+	UserID        uint `json:"user_id"`
+	DeviceGroupID uint `json:"device_group_id"`
+}
+
+// IsEmpty 未设置任何筛选条件
+func (f BroadcastFilter) IsEmpty() bool {
+	return f.AgentID == 0 && f.UserID == 0 && f.DeviceGroupID == 0
+}
+
+// TargetedBroadcastResult 定向推送结果
+type TargetedBroadcastResult struct {
+	MatchedDevices int      `json:"matched_devices"` // 符合条件的设备数（含离线）
+	OnlineDevices  int      `json:"online_devices"`  // 其中当前由主程序服务的设备数
+	Clients        []string `json:"clients"`         // 已推送的主程序连接
+	Failed         []string `json:"failed,omitempty"`
+}
+
+// trackDevice 记录设备上下线，用于定向推送时判断主程序服务的设备
+func (client *WebSocketClient) trackDevice(deviceName string, online bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if online {
+		client.devices[deviceName] = struct{}{}
+	} else {
+		delete(client.devices, deviceName)
+	}
+}
+
+// servedDevices 返回该主程序当前服务且在 targets 中的设备（已排序）
+func (client *WebSocketClient) servedDevices(targets map[string]struct{}) []string {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	var served []string
+	for name := range client.devices {
+		if _, ok := targets[name]; ok {
+			served = append(served, name)
+		}
+	}
+	sort.Strings(served)
+	return served
+}
+
+// matchDeviceNames 按筛选条件查询设备标识（device_name）
+func (ctrl *WebSocketController) matchDeviceNames(filter BroadcastFilter) (map[string]struct{}, error) {
+	query := ctrl.DB.Model(&models.Device{}).Where("device_name <> ''")
+	if filter.AgentID > 0 {
+		query = query.Where("agent_id = ?", filter.AgentID)
+	}
+	if filter.UserID > 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.DeviceGroupID > 0 {
+		query = query.Where("id IN (?)", ctrl.DB.Model(&models.DeviceGroupMember{}).Select("device_id").Where("group_id = ?", filter.DeviceGroupID))
+	}
+	var names []string
+	if err := query.Pluck("device_name", &names).Error; err != nil {
+		return nil, err
+	}
+	targets := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		targets[name] = struct{}{}
+	}
+	return targets, nil
+}
+
+// BroadcastSystemConfigTo 仅向服务匹配设备的主程序推送系统配置，避免唤醒无关设备重载配置
+// 消息格式同 BroadcastSystemConfig，额外携带 device_ids 表示该连接下受影响的设备
+func (ctrl *WebSocketController) BroadcastSystemConfigTo(filter BroadcastFilter, data gin.H) (*TargetedBroadcastResult, error) {
+	if filter.IsEmpty() {
+		return nil, fmt.Errorf("定向推送至少需要一个筛选条件")
+	}
+	targets, err := ctrl.matchDeviceNames(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TargetedBroadcastResult{MatchedDevices: len(targets), Clients: []string{}}
+	if len(targets) == 0 {
+		return result, nil
+	}
+	for item := range ctrl.clientsMap.IterBuffered() {
+		client := item.Val
+		if !client.isConnected {
+			continue
+		}
+		served := client.servedDevices(targets)
+		if len(served) == 0 {
+			continue
+		}
+		result.OnlineDevices += len(served)
+		if err := client.writeJSON(gin.H{"type": "system_config", "data": data, "device_ids": served}); err != nil {
+			logger.Warnf("向客户端 %s 定向推送系统配置失败: %v", client.ID, err)
+			result.Failed = append(result.Failed, client.ID)
+			continue
+		}
+		result.Clients = append(result.Clients, client.ID)
+	}
+	sort.Strings(result.Clients)
+	return result, nil
+}
+
+// PushSystemConfig 按智能体/用户/设备分组定向推送当前系统配置
+func (ac *AdminController) PushSystemConfig(c *gin.Context) {
+	if ac.WebSocketController == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "WebSocket 服务未初始化"})
+		return
+	}
+	var filter BroadcastFilter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if filter.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "agent_id、user_id、device_group_id 至少指定一个"})
+		return
+	}
+
+	data, err := ac.getSystemConfigsDataCached(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get system configs"})
+		return
+	}
+	result, err := ac.WebSocketController.BroadcastSystemConfigTo(filter, data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "定向推送失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
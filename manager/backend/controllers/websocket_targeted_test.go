@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"testing"
+
+	"xiaozhi/manager/backend/models"
+)
+
+func TestMatchDeviceNamesAndServedDevices(t *testing.T) {
+	db := newTestDB(t, &models.Device{}, &models.DeviceGroupMember{})
+	devices := []models.Device{
+		{UserID: 1, AgentID: 10, DeviceName: "aa:01", DeviceCode: "c1"},
+		{UserID: 1, AgentID: 11, DeviceName: "aa:02", DeviceCode: "c2"},
+		{UserID: 2, AgentID: 10, DeviceName: "bb:01", DeviceCode: "c3"},
+	}
+	if err := db.Create(&devices).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.DeviceGroupMember{GroupID: 5, DeviceID: devices[2].ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := NewWebSocketController(db)
+	cases := []struct {
+		filter BroadcastFilter
+		want   []string
+	}{
+		{BroadcastFilter{AgentID: 10}, []string{"aa:01", "bb:01"}},
+		{BroadcastFilter{UserID: 1}, []string{"aa:01", "aa:02"}},
+		{BroadcastFilter{AgentID: 10, UserID: 1}, []string{"aa:01"}},
+		{BroadcastFilter{DeviceGroupID: 5}, []string{"bb:01"}},
+	}
+	for _, tc := range cases {
+		targets, err := ctrl.matchDeviceNames(tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(targets) != len(tc.want) {
+			t.Fatalf("%+v: targets = %v, want %v", tc.filter, targets, tc.want)
+		}
+		for _, name := range tc.want {
+			if _, ok := targets[name]; !ok {
+				t.Fatalf("%+v: missing %s in %v", tc.filter, name, targets)
+			}
+		}
+	}
+
+	client := &WebSocketClient{devices: make(map[string]struct{})}
+	client.trackDevice("aa:01", true)
+	client.trackDevice("bb:01", true)
+	client.trackDevice("bb:01", false)
+	targets, _ := ctrl.matchDeviceNames(BroadcastFilter{AgentID: 10})
+	if served := client.servedDevices(targets); len(served) != 1 || served[0] != "aa:01" {
+		t.Fatalf("served = %v", served)
+	}
+}
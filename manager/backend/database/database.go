@@ -60,6 +60,7 @@ func Init(cfg config.DatabaseConfig) *gorm.DB {
 		&models.KnowledgeBase{},
 		&models.KnowledgeBaseDocument{},
 		&models.AgentKnowledgeBase{},
+		&models.KnowledgeSyncEvent{},
 		&models.Config{},
 		&models.MCPMarketService{},
 		&models.GlobalRole{},
@@ -71,6 +72,12 @@ func Init(cfg config.DatabaseConfig) *gorm.DB {
 		&models.VoiceCloneAudio{},
 		&models.VoiceCloneTask{},
 		&models.UserVoiceCloneQuota{},
+		&models.VADProfile{},
+		&models.ConfigDraftBundle{},
+		&models.ConfigGoodSnapshot{},
+		&models.DeviceGroup{},
+		&models.DeviceGroupMember{},
+		&models.APIToken{},
 	)
 	if err != nil {
 		log.Printf("数据库表结构迁移失败: %v", err)
@@ -79,6 +86,13 @@ func Init(cfg config.DatabaseConfig) *gorm.DB {
 	}
 	log.Println("数据库表结构迁移成功")
 
+	// 将旧版本配置的 json_data 升级到最新结构并写回
+	if migrated, err := models.MigrateStoredConfigs(db); err != nil {
+		log.Printf("配置结构迁移失败: %v", err)
+	} else if migrated > 0 {
+		log.Printf("已升级 %d 条配置的 json_data 结构", migrated)
+	}
+
 	// 迁移现有全局角色数据到新的 roles 表
 	log.Println("检查是否需要迁移全局角色数据...")
 	if err := migrateGlobalRolesToRoles(db); err != nil {
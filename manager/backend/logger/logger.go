@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level 日志级别
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LEVEL(%d)", int32(l))
+}
+
+var currentLevel atomic.Int32
+
+func init() {
+	currentLevel.Store(int32(LevelInfo))
+}
+
+// ParseLevel 解析级别名称（debug/info/warn/warning/error，不区分大小写）
+func ParseLevel(s string) (Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, true
+	case "info":
+		return LevelInfo, true
+	case "warn", "warning":
+		return LevelWarn, true
+	case "error":
+		return LevelError, true
+	}
+	return LevelInfo, false
+}
+
+// SetLevel 设置最低输出级别
+func SetLevel(l Level) {
+	currentLevel.Store(int32(l))
+}
+
+// GetLevel 当前最低输出级别
+func GetLevel() Level {
+	return Level(currentLevel.Load())
+}
+
+// Enabled 指定级别的日志是否会输出
+func Enabled(l Level) bool {
+	return l >= GetLevel()
+}
+
+func output(l Level, format string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	// calldepth=3：跳过 output 与 Xxxf，文件行号指向调用方
+	_ = log.Output(3, "["+l.String()+"] "+fmt.Sprintf(format, args...))
+}
+
+func Debugf(format string, args ...interface{}) { output(LevelDebug, format, args...) }
+
+func Infof(format string, args ...interface{}) { output(LevelInfo, format, args...) }
+
+func Warnf(format string, args ...interface{}) { output(LevelWarn, format, args...) }
+
+func Errorf(format string, args ...interface{}) { output(LevelError, format, args...) }
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+		SetLevel(LevelInfo)
+	}()
+
+	SetLevel(LevelWarn)
+	Debugf("d %d", 1)
+	Infof("i %d", 2)
+	Warnf("w %d", 3)
+	Errorf("e %d", 4)
+
+	got := buf.String()
+	if strings.Contains(got, "d 1") || strings.Contains(got, "i 2") {
+		t.Fatalf("debug/info should be filtered at warn level: %q", got)
+	}
+	if !strings.Contains(got, "[WARN] w 3") || !strings.Contains(got, "[ERROR] e 4") {
+		t.Fatalf("warn/error missing: %q", got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{"debug": LevelDebug, " INFO ": LevelInfo, "warning": LevelWarn, "Error": LevelError}
+	for input, want := range cases {
+		if got, ok := ParseLevel(input); !ok || got != want {
+			t.Fatalf("ParseLevel(%q) = %v, %v; want %v", input, got, ok, want)
+		}
+	}
+	if _, ok := ParseLevel("verbose"); ok {
+		t.Fatal("unknown level should not parse")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"xiaozhi/manager/backend/config"
 	"xiaozhi/manager/backend/database"
+	"xiaozhi/manager/backend/logger"
 	"xiaozhi/manager/backend/router"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +21,15 @@ func main() {
 	// 加载配置
 	cfg := config.LoadWithPath(configFile)
 
+	// 设置日志级别
+	if cfg.Log.Level != "" {
+		if level, ok := logger.ParseLevel(cfg.Log.Level); ok {
+			logger.SetLevel(level)
+		} else {
+			log.Printf("未知的日志级别 %q，使用默认级别 info", cfg.Log.Level)
+		}
+	}
+
 	// 初始化数据库
 	db := database.Init(cfg.Database)
 	defer database.Close(db)
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenPrefix 用户 API 令牌前缀，用于与 JWT 区分
+const APITokenPrefix = "xzt_"
+
+// API 令牌可授予的权限范围；write 隐含 read
+var APITokenScopes = []string{
+	"devices:read", "devices:write",
+	"agents:read", "agents:write",
+	"configs:read", "configs:write",
+}
+
+// APITokenIdentity API 令牌对应的用户身份
+type APITokenIdentity struct {
+	TokenID  uint
+	UserID   uint
+	Username string
+	Role     string
+	Scopes   []string
+}
+
+var apiTokenResolver func(token string) (*APITokenIdentity, error)
+
+// SetAPITokenResolver 设置 API 令牌校验函数（由控制器层基于数据库实现）
+func SetAPITokenResolver(fn func(token string) (*APITokenIdentity, error)) {
+	apiTokenResolver = fn
+}
+
+// apiTokenResource 按路径首段归类资源：/api、/api/user、/api/admin 之后的第一段
+func apiTokenResource(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 && segments[0] == "api" {
+		segments = segments[1:]
+	}
+	if len(segments) > 0 && (segments[0] == "user" || segments[0] == "admin") {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		return ""
+	}
+	switch first := segments[0]; {
+	case first == "devices" || first == "device-groups":
+		return "devices"
+	case first == "agents":
+		return "agents"
+	case first == "configs" || first == "config-drafts" || first == "vad-profiles" || first == "chat-settings" ||
+		strings.HasSuffix(first, "-configs") || strings.HasSuffix(first, "-config"):
+		return "configs"
+	}
+	return ""
+}
+
+// APITokenScopeForRequest 返回请求所需的权限范围，空字符串表示 API 令牌不可访问该接口（如令牌管理、用户管理）
+func APITokenScopeForRequest(method, path string) string {
+	resource := apiTokenResource(path)
+	if resource == "" {
+		return ""
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return resource + ":read"
+	}
+	return resource + ":write"
+}
+
+// HasAPITokenScope 判断已授予的范围是否满足要求
+func HasAPITokenScope(granted []string, required string) bool {
+	if required == "" {
+		return false
+	}
+	resource := strings.TrimSuffix(required, ":read")
+	for _, scope := range granted {
+		if scope == required || (resource != required && scope == resource+":write") {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateAPIToken 校验 API 令牌及其权限范围，通过后写入与 JWT 一致的上下文字段
+func authenticateAPIToken(c *gin.Context, token string) bool {
+	if apiTokenResolver == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
+		c.Abort()
+		return false
+	}
+	identity, err := apiTokenResolver(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的API令牌: " + err.Error()})
+		c.Abort()
+		return false
+	}
+	required := APITokenScopeForRequest(c.Request.Method, c.Request.URL.Path)
+	if !HasAPITokenScope(identity.Scopes, required) {
+		msg := "API令牌无权访问该接口"
+		if required != "" {
+			msg = "API令牌缺少权限: " + required
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": msg})
+		c.Abort()
+		return false
+	}
+	c.Set("user_id", identity.UserID)
+	c.Set("username", identity.Username)
+	c.Set("role", identity.Role)
+	c.Set("api_token_id", identity.TokenID)
+	return true
+}
@@ -1,23 +1,16 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"xiaozhi/manager/backend/logger"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// min 函数用于获取两个整数的最小值
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
@@ -63,31 +56,36 @@ func ParseToken(tokenString string) (*Claims, error) {
 // JWT认证中间件
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 添加调试日志
-		log.Printf("[JWTAuth] 处理请求: %s %s, 客户端IP: %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
-		
+		logger.Debugf("[JWTAuth] 处理请求: %s %s, 客户端IP: %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+
+		// 认证头及令牌均为凭证，日志只记录认证方案
 		authHeader := c.GetHeader("Authorization")
-		log.Printf("[JWTAuth] Authorization头: %s", authHeader)
-		
 		if authHeader == "" {
-			log.Printf("[JWTAuth] ❌ 缺少认证头")
+			logger.Debugf("[JWTAuth] 缺少认证头: %s %s", c.Request.Method, c.Request.URL.Path)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少认证头"})
 			c.Abort()
 			return
 		}
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		log.Printf("[JWTAuth] 提取的token长度: %d, 前缀: %s", len(tokenString), tokenString[:min(20, len(tokenString))])
-		
+		if strings.HasPrefix(tokenString, APITokenPrefix) {
+			logger.Debugf("[JWTAuth] 认证方案: api_token")
+			if authenticateAPIToken(c, tokenString) {
+				c.Next()
+			}
+			return
+		}
+		logger.Debugf("[JWTAuth] 认证方案: %s", authorizationScheme(authHeader))
+
 		claims, err := ParseToken(tokenString)
 		if err != nil {
-			log.Printf("[JWTAuth] ❌ token解析失败: %v", err)
+			logger.Warnf("[JWTAuth] token解析失败: %v, 客户端IP: %s", err, c.ClientIP())
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
 			c.Abort()
 			return
 		}
 
-		log.Printf("[JWTAuth] ✅ token验证成功 - 用户ID: %d, 用户名: %s, 角色: %s", claims.UserID, claims.Username, claims.Role)
+		logger.Debugf("[JWTAuth] token验证成功 - 用户ID: %d, 用户名: %s, 角色: %s", claims.UserID, claims.Username, claims.Role)
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
@@ -95,6 +93,14 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// authorizationScheme 返回认证头中的方案名（如 Bearer），无方案时返回 none
+func authorizationScheme(header string) string {
+	if i := strings.IndexByte(header, ' '); i > 0 {
+		return header[:i]
+	}
+	return "none"
+}
+
 // 管理员权限中间件
 func AdminAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
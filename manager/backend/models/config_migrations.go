@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"xiaozhi/manager/backend/logger"
+
+	"gorm.io/gorm"
+)
+
+// 配置 json_data 结构迁移：provider 重命名或废弃字段时注册迁移函数，
+// 旧配置在加载（AfterFind）与保存（BeforeSave）时逐版本升级，并记录到 Config.SchemaVersion
+
+// ConfigMigration 将 json_data 从 Version-1 升级到 Version；Migrate 需容忍字段已是新格式的数据
+type ConfigMigration struct {
+	Version     int
+	Description string
+	Migrate     func(data map[string]interface{})
+}
+
+var (
+	configMigrationsMu sync.RWMutex
+	configMigrations   = make(map[string][]ConfigMigration) // key: type/provider
+)
+
+func configMigrationKey(typ, provider string) string {
+	return strings.ToLower(strings.TrimSpace(typ)) + "/" + strings.ToLower(strings.TrimSpace(provider))
+}
+
+// RegisterConfigMigration 注册某类型/提供商的迁移，版本号需从 1 开始连续递增
+func RegisterConfigMigration(typ, provider string, migration ConfigMigration) {
+	configMigrationsMu.Lock()
+	defer configMigrationsMu.Unlock()
+
+	key := configMigrationKey(typ, provider)
+	list := configMigrations[key]
+	if migration.Version != len(list)+1 {
+		panic(fmt.Sprintf("配置迁移版本不连续: %s 期望版本 %d，实际 %d", key, len(list)+1, migration.Version))
+	}
+	configMigrations[key] = append(list, migration)
+}
+
+// LatestConfigSchemaVersion 返回类型/提供商的最新结构版本，未注册迁移时为 0
+func LatestConfigSchemaVersion(typ, provider string) int {
+	configMigrationsMu.RLock()
+	defer configMigrationsMu.RUnlock()
+	return len(configMigrations[configMigrationKey(typ, provider)])
+}
+
+// RenameConfigField 返回字段重命名迁移：仅在新字段不存在时搬移旧字段的值
+func RenameConfigField(oldName, newName string) func(data map[string]interface{}) {
+	return func(data map[string]interface{}) {
+		value, ok := data[oldName]
+		if !ok {
+			return
+		}
+		if _, exists := data[newName]; !exists {
+			data[newName] = value
+		}
+		delete(data, oldName)
+	}
+}
+
+// MigrateConfigJSON 将 json_data 从 version 升级到最新版本，返回新 json、新版本及是否有变化
+func MigrateConfigJSON(typ, provider, jsonData string, version int) (string, int, bool, error) {
+	configMigrationsMu.RLock()
+	migrations := configMigrations[configMigrationKey(typ, provider)]
+	configMigrationsMu.RUnlock()
+
+	if version >= len(migrations) {
+		return jsonData, version, false, nil
+	}
+	data := make(map[string]interface{})
+	if strings.TrimSpace(jsonData) != "" {
+		if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+			return jsonData, version, false, fmt.Errorf("解析json_data失败: %w", err)
+		}
+	}
+	for _, migration := range migrations[version:] {
+		migration.Migrate(data)
+	}
+	out, err := json.Marshal(data)
+	if err != nil {
+		return jsonData, version, false, fmt.Errorf("序列化json_data失败: %w", err)
+	}
+	return string(out), len(migrations), true, nil
+}
+
+// migrateSchema 原地升级配置；json_data 无法解析时保持原样，交由调用方的校验报错
+func (c *Config) migrateSchema() {
+	jsonData, version, changed, err := MigrateConfigJSON(c.Type, c.Provider, c.JsonData, c.SchemaVersion)
+	if err != nil || !changed {
+		return
+	}
+	c.JsonData = jsonData
+	c.SchemaVersion = version
+}
+
+// MigrateStoredConfigs 启动时将库中旧版本的配置升级到最新结构并写回，返回写回的条数；
+// AfterFind 只在内存中升级，未经保存的配置会在每次加载时重复迁移，且直接读表的调用方看到的仍是旧格式。
+// json_data 无法解析的配置跳过并记录警告
+func MigrateStoredConfigs(db *gorm.DB) (int, error) {
+	var rows []struct {
+		ID            uint
+		Type          string
+		Provider      string
+		JsonData      string
+		SchemaVersion int
+	}
+	// 直接读取原始列，避免 AfterFind 先行升级导致无法判断是否需要写回
+	if err := db.Table("configs").Select("id, type, provider, json_data, schema_version").Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	migrated := 0
+	for _, row := range rows {
+		if row.SchemaVersion >= LatestConfigSchemaVersion(row.Type, row.Provider) {
+			continue
+		}
+		jsonData, version, changed, err := MigrateConfigJSON(row.Type, row.Provider, row.JsonData, row.SchemaVersion)
+		if err != nil {
+			logger.Warnf("配置结构迁移跳过: id=%d type=%s provider=%s err=%v", row.ID, row.Type, row.Provider, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if err := db.Table("configs").Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+			"json_data":      jsonData,
+			"schema_version": version,
+		}).Error; err != nil {
+			return migrated, fmt.Errorf("写回配置 %d 失败: %w", row.ID, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// AfterFind GORM hook - 加载时升级旧版本 json_data
+func (c *Config) AfterFind(tx *gorm.DB) error {
+	c.migrateSchema()
+	return nil
+}
+
+// BeforeSave GORM hook - 保存前升级 json_data 并记录结构版本
+func (c *Config) BeforeSave(tx *gorm.DB) error {
+	c.migrateSchema()
+	return nil
+}
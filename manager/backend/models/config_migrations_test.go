@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrateConfigJSON(t *testing.T) {
+	RegisterConfigMigration("migration_test", "demo", ConfigMigration{Version: 1, Description: "url -> api_url", Migrate: RenameConfigField("url", "api_url")})
+	RegisterConfigMigration("migration_test", "demo", ConfigMigration{Version: 2, Description: "key -> api_key", Migrate: RenameConfigField("key", "api_key")})
+	if v := LatestConfigSchemaVersion("migration_test", "Demo"); v != 2 {
+		t.Fatalf("latest version = %d, want 2", v)
+	}
+
+	cfg := Config{Type: "migration_test", Provider: "demo", JsonData: `{"url":"http://a","key":"k","model":"m"}`}
+	if err := cfg.AfterFind(nil); err != nil {
+		t.Fatal(err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(cfg.JsonData), &data); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SchemaVersion != 2 || data["api_url"] != "http://a" || data["api_key"] != "k" || data["model"] != "m" || data["url"] != nil || data["key"] != nil {
+		t.Fatalf("migrated = %s version %d", cfg.JsonData, cfg.SchemaVersion)
+	}
+
+	// 已是最新版本时不改动
+	before := cfg.JsonData
+	if _, _, changed, _ := MigrateConfigJSON("migration_test", "demo", before, 2); changed {
+		t.Fatal("up-to-date config should not change")
+	}
+
+	// 只升级未执行过的版本：v1 数据中的 url 不再被改写
+	out, version, changed, err := MigrateConfigJSON("migration_test", "demo", `{"url":"keep","key":"k"}`, 1)
+	if err != nil || !changed || version != 2 {
+		t.Fatalf("partial migration: %s %d %v %v", out, version, changed, err)
+	}
+	if err := json.Unmarshal([]byte(out), &data); err != nil || data["url"] != "keep" {
+		t.Fatalf("partial migration rewrote earlier field: %s", out)
+	}
+
+	// 无效 JSON 保持原样
+	bad := Config{Type: "migration_test", Provider: "demo", JsonData: `{bad`}
+	_ = bad.BeforeSave(nil)
+	if bad.JsonData != `{bad` || bad.SchemaVersion != 0 {
+		t.Fatalf("invalid json modified: %+v", bad)
+	}
+}
+
+func TestMigrateStoredConfigs(t *testing.T) {
+	RegisterConfigMigration("stored_migration_test", "demo", ConfigMigration{Version: 1, Description: "url -> api_url", Migrate: RenameConfigField("url", "api_url")})
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Config{}); err != nil {
+		t.Fatal(err)
+	}
+	// 直接写表，绕过 BeforeSave，模拟升级前存量数据
+	for _, row := range []map[string]interface{}{
+		{"type": "stored_migration_test", "name": "old", "config_id": "old", "provider": "demo", "json_data": `{"url":"http://a"}`, "schema_version": 0},
+		{"type": "stored_migration_test", "name": "bad", "config_id": "bad", "provider": "demo", "json_data": `{bad`, "schema_version": 0},
+		{"type": "stored_migration_test", "name": "new", "config_id": "new", "provider": "demo", "json_data": `{"url":"keep"}`, "schema_version": 1},
+		{"type": "llm", "name": "other", "config_id": "other", "provider": "openai", "json_data": `{"url":"x"}`, "schema_version": 0},
+	} {
+		if err := db.Table("configs").Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrated, err := MigrateStoredConfigs(db)
+	if err != nil || migrated != 1 {
+		t.Fatalf("migrated = %d, err = %v", migrated, err)
+	}
+	stored := func(configID string) (string, int) {
+		var row struct {
+			JsonData      string
+			SchemaVersion int
+		}
+		db.Table("configs").Select("json_data, schema_version").Where("config_id = ?", configID).Take(&row)
+		return row.JsonData, row.SchemaVersion
+	}
+	if data, version := stored("old"); data != `{"api_url":"http://a"}` || version != 1 {
+		t.Fatalf("old row = %s v%d", data, version)
+	}
+	if data, version := stored("bad"); data != `{bad` || version != 0 {
+		t.Fatalf("invalid row modified: %s v%d", data, version)
+	}
+	if data, _ := stored("new"); data != `{"url":"keep"}` {
+		t.Fatalf("up-to-date row modified: %s", data)
+	}
+	if data, _ := stored("other"); data != `{"url":"x"}` {
+		t.Fatalf("row without migrations modified: %s", data)
+	}
+
+	// 再次执行无需写回
+	if migrated, err := MigrateStoredConfigs(db); err != nil || migrated != 0 {
+		t.Fatalf("second pass migrated = %d, err = %v", migrated, err)
+	}
+}
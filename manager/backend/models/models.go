@@ -20,18 +20,55 @@ type User struct {
 
 // 设备模型
 type Device struct {
-	ID           uint       `json:"id" gorm:"primarykey"`
-	UserID       uint       `json:"user_id" gorm:"not null"`
-	AgentID      uint       `json:"agent_id" gorm:"not null;default:0"`                                       // 智能体ID，一台设备只能属于一个智能体
-	RoleID       *uint      `json:"role_id" gorm:"index"`                                                     // 角色ID（可选，覆盖智能体配置）
-	DeviceCode   string     `json:"device_code" gorm:"type:varchar(100);uniqueIndex:idx_devices_device_code"` // 6位激活码
-	DeviceName   string     `json:"device_name" gorm:"type:varchar(100)"`
-	Challenge    string     `json:"challenge" gorm:"type:varchar(128)"`      // 激活挑战码
-	PreSecretKey string     `json:"pre_secret_key" gorm:"type:varchar(128)"` // 预激活密钥
-	Activated    bool       `json:"activated" gorm:"default:false"`          // 设备是否已激活
-	LastActiveAt *time.Time `json:"last_active_at"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID              uint       `json:"id" gorm:"primarykey"`
+	UserID          uint       `json:"user_id" gorm:"not null"`
+	AgentID         uint       `json:"agent_id" gorm:"not null;default:0"`                                       // 智能体ID，一台设备只能属于一个智能体
+	RoleID          *uint      `json:"role_id" gorm:"index"`                                                     // 角色ID（可选，覆盖智能体配置）
+	DeviceCode      string     `json:"device_code" gorm:"type:varchar(100);uniqueIndex:idx_devices_device_code"` // 6位激活码
+	DeviceName      string     `json:"device_name" gorm:"type:varchar(100)"`
+	Challenge       string     `json:"challenge" gorm:"type:varchar(128)"`      // 激活挑战码
+	PreSecretKey    string     `json:"pre_secret_key" gorm:"type:varchar(128)"` // 预激活密钥
+	Activated       bool       `json:"activated" gorm:"default:false"`          // 设备是否已激活
+	LastActiveAt    *time.Time `json:"last_active_at"`
+	LastSeenAt      *time.Time `json:"last_seen_at" gorm:"index"`                             // 最后一次上线/心跳时间，离线后保留
+	Status          string     `json:"status" gorm:"type:varchar(20);default:'active';index"` // active, inactive（长期离线自动停用）
+	ConfigOverride  string     `json:"config_override" gorm:"type:text"`                      // 设备级配置覆盖（JSON对象，按 vad/asr/llm/tts/memory 分节），下发配置时最后合并，优先级最高
+	FirmwareVersion string     `json:"firmware_version" gorm:"type:varchar(50)"`              // 设备上报的固件版本，用于按条件选择配置
+	Region          string     `json:"region" gorm:"type:varchar(50)"`                        // 设备所在区域，用于按条件选择配置
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// DeviceGroup 设备分组（按位置/用途管理设备）
+type DeviceGroup struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DeviceGroupMember 设备分组成员，一台设备可属于多个分组
+type DeviceGroupMember struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	GroupID   uint      `json:"group_id" gorm:"not null;uniqueIndex:idx_device_group_member,priority:1"`
+	DeviceID  uint      `json:"device_id" gorm:"not null;index;uniqueIndex:idx_device_group_member,priority:2"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIToken 用户长期 API 令牌，供脚本/CI 管理设备与配置；仅保存令牌哈希，明文只在创建时返回一次
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"` // sha256(明文令牌)
+	Prefix     string     `json:"prefix" gorm:"type:varchar(20)"`                 // 令牌前若干位，便于识别
+	Scopes     string     `json:"scopes" gorm:"type:varchar(255)"`                // 逗号分隔，如 devices:read,configs:write
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"` // 为空表示不过期
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 // 智能体模型
@@ -40,6 +77,7 @@ type Agent struct {
 	UserID          uint      `json:"user_id" gorm:"not null"`
 	Name            string    `json:"name" gorm:"type:varchar(100);not null"`              // 昵称
 	CustomPrompt    string    `json:"custom_prompt" gorm:"type:text"`                      // 角色介绍(prompt)
+	Greeting        string    `json:"greeting" gorm:"type:text"`                           // 开场白，会话开始时播报，支持 {{assistant_name}}
 	LLMConfigID     *string   `json:"llm_config_id" gorm:"type:varchar(100)"`              // 语言模型配置ID
 	TTSConfigID     *string   `json:"tts_config_id" gorm:"type:varchar(100)"`              // 音色配置ID
 	Voice           *string   `json:"voice" gorm:"type:varchar(200)"`                      // 音色值
@@ -62,13 +100,18 @@ type KnowledgeBase struct {
 	ExternalKBID       string     `json:"external_kb_id" gorm:"type:varchar(255);index"`  // 外部知识库ID（Dify dataset_id）
 	ExternalDocID      string     `json:"external_doc_id" gorm:"type:varchar(255);index"` // 外部文档ID（Dify document_id）
 	AutoDataset        bool       `json:"auto_dataset" gorm:"default:false"`              // 是否由系统自动创建dataset
+	AutoSummary        bool       `json:"auto_summary" gorm:"default:false"`              // 同步长文档时自动生成 LLM 摘要并作为额外文档同步
 	SyncProvider       string     `json:"sync_provider" gorm:"type:varchar(50);index"`    // 同步provider（当前为dify）
 	SyncStatus         string     `json:"sync_status" gorm:"type:varchar(20);default:'pending';index"`
 	SyncError          string     `json:"sync_error" gorm:"type:text"`
+	ContentHash        string     `json:"-" gorm:"type:varchar(64)"` // 最近一次成功同步的正文 sha256，正文未变时跳过文档重新同步
 	LastSyncedAt       *time.Time `json:"last_synced_at"`
 	Status             string     `json:"status" gorm:"type:varchar(20);default:'active';index"`
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
+	// 软删除：删除后隐藏，宽限期内保留外部数据集，可恢复；过期后由清理任务执行真正删除
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	DeletedAgentLinks string         `json:"-" gorm:"type:text"` // 软删除时解除的智能体关联（JSON数组），恢复时重新关联
 }
 
 // KnowledgeBaseDocument 知识库文档（一个知识库可包含多个文档）
@@ -77,12 +120,31 @@ type KnowledgeBaseDocument struct {
 	KnowledgeBaseID uint       `json:"knowledge_base_id" gorm:"not null;index"`
 	Name            string     `json:"name" gorm:"type:varchar(200);not null"`
 	Content         string     `json:"content" gorm:"type:text"`
+	MetadataJSON    string     `json:"metadata_json" gorm:"type:text"`                 // 文档元数据（JSON对象），同步到支持元数据过滤的provider
 	ExternalDocID   string     `json:"external_doc_id" gorm:"type:varchar(255);index"` // Dify document_id
 	SyncStatus      string     `json:"sync_status" gorm:"type:varchar(20);default:'pending';index"`
 	SyncError       string     `json:"sync_error" gorm:"type:text"`
 	LastSyncedAt    *time.Time `json:"last_synced_at"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
+	// 自动摘要（知识库开启 auto_summary 时生成），摘要另作为一篇文档同步到 provider 供检索
+	Summary              string    `json:"summary" gorm:"type:text"`
+	SummaryExternalDocID string    `json:"summary_external_doc_id" gorm:"type:varchar(255)"`
+	SummaryError         string    `json:"summary_error" gorm:"type:text"`
+	SummarySourceHash    string    `json:"-" gorm:"type:varchar(64)"` // 生成摘要时原文的 sha256，原文未变时跳过重新生成
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// KnowledgeSyncEvent 知识库同步时对外部 provider 的一次 HTTP 请求摘要，用于按知识库排查同步问题
+type KnowledgeSyncEvent struct {
+	ID              uint      `json:"id" gorm:"primarykey"`
+	KnowledgeBaseID uint      `json:"knowledge_base_id" gorm:"not null;index"`
+	Provider        string    `json:"provider" gorm:"type:varchar(50)"`
+	Method          string    `json:"method" gorm:"type:varchar(10)"`
+	Path            string    `json:"path" gorm:"type:varchar(500)"` // 请求路径（不含 query 与 host）
+	StatusCode      int       `json:"status_code"`                   // 0 表示请求未得到响应
+	ElapsedMs       int64     `json:"elapsed_ms"`
+	Error           string    `json:"error" gorm:"type:text"`
+	CreatedAt       time.Time `json:"created_at" gorm:"index"`
 }
 
 // AgentKnowledgeBase 智能体与知识库的多对多关联
@@ -95,16 +157,18 @@ type AgentKnowledgeBase struct {
 
 // 通用配置模型
 type Config struct {
-	ID        uint      `json:"id" gorm:"primarykey"`
-	Type      string    `json:"type" gorm:"type:varchar(50);not null;uniqueIndex:type_config_id,priority:1"` // vad, asr, llm, tts, ota, mqtt, udp, mqtt_server, vision
-	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
-	ConfigID  string    `json:"config_id" gorm:"type:varchar(100);not null;uniqueIndex:type_config_id,priority:2"` // 配置ID，用于关联
-	Provider  string    `json:"provider" gorm:"type:varchar(50)"`                                                  // 某些配置类型需要provider字段
-	JsonData  string    `json:"json_data" gorm:"type:text"`                                                        // JSON配置数据
-	Enabled   bool      `json:"enabled" gorm:"default:true"`
-	IsDefault bool      `json:"is_default" gorm:"default:false"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uint      `json:"id" gorm:"primarykey"`
+	Type            string    `json:"type" gorm:"type:varchar(50);not null;uniqueIndex:type_config_id,priority:1"` // vad, asr, llm, tts, ota, mqtt, udp, mqtt_server, vision
+	Name            string    `json:"name" gorm:"type:varchar(100);not null"`
+	ConfigID        string    `json:"config_id" gorm:"type:varchar(100);not null;uniqueIndex:type_config_id,priority:2"` // 配置ID，用于关联
+	Provider        string    `json:"provider" gorm:"type:varchar(50)"`                                                  // 某些配置类型需要provider字段
+	JsonData        string    `json:"json_data" gorm:"type:text"`                                                        // JSON配置数据
+	Enabled         bool      `json:"enabled" gorm:"default:true"`
+	IsDefault       bool      `json:"is_default" gorm:"default:false"`
+	SchemaVersion   int       `json:"schema_version" gorm:"default:0"`   // json_data 结构版本，加载/保存时按 config_migrations 升级
+	MatchConditions string    `json:"match_conditions" gorm:"type:text"` // 设备匹配条件（JSON：firmware_min/firmware_max/regions），命中时优先于默认配置下发
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // MCPMarketService 市场导入的MCP服务配置
@@ -133,7 +197,8 @@ type Role struct {
 	UserID      *uint  `json:"user_id" gorm:"index"` // 所属用户ID，NULL表示全局角色
 	Name        string `json:"name" gorm:"type:varchar(100);not null"`
 	Description string `json:"description" gorm:"type:text"`
-	Prompt      string `json:"prompt" gorm:"type:text"` // 系统提示词
+	Prompt      string `json:"prompt" gorm:"type:text"`   // 系统提示词
+	Greeting    string `json:"greeting" gorm:"type:text"` // 开场白，会话开始时播报，支持 {{assistant_name}}
 
 	// LLM/TTS 配置（与 Agent 字段保持一致）
 	LLMConfigID *string `json:"llm_config_id" gorm:"type:varchar(100)"` // LLM配置ID
@@ -259,6 +324,48 @@ type UserVoiceCloneQuota struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// ConfigDraftBundle 配置草稿包：一组待验证的配置修改，可反复测试，验证通过后一次性提升为正式配置
+type ConfigDraftBundle struct {
+	ID             uint       `json:"id" gorm:"primarykey"`
+	Name           string     `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_config_draft_bundles_name"`
+	Description    string     `json:"description" gorm:"type:text"`
+	ItemsJSON      string     `json:"-" gorm:"type:text;column:items"`                      // 草稿配置项列表（JSON）
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'draft';index"` // draft, promoted
+	LastTestPassed bool       `json:"last_test_passed" gorm:"default:false"`
+	LastTestResult string     `json:"-" gorm:"type:text"`
+	LastTestedAt   *time.Time `json:"last_tested_at"`
+	PromotedAt     *time.Time `json:"promoted_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ConfigGoodSnapshot 按配置类型保存的"最近可用"快照，一键测试全部通过时自动更新
+type ConfigGoodSnapshot struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	ConfigType  string    `json:"config_type" gorm:"type:varchar(50);not null;uniqueIndex"`
+	ConfigsJSON string    `json:"-" gorm:"type:text;column:configs"` // 该类型全部配置（JSON数组）
+	ConfigCount int       `json:"config_count"`
+	TestResult  string    `json:"-" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// VADProfile 设备/智能体级VAD调优配置，覆盖默认VAD配置中的部分参数
+// 设备级优先于智能体级；字段为空表示沿用默认VAD配置
+type VADProfile struct {
+	ID                   uint      `json:"id" gorm:"primarykey"`
+	Name                 string    `json:"name" gorm:"type:varchar(100)"`
+	DeviceID             *uint     `json:"device_id" gorm:"uniqueIndex:idx_vad_profiles_device_id"`
+	AgentID              *uint     `json:"agent_id" gorm:"uniqueIndex:idx_vad_profiles_agent_id"`
+	Threshold            *float64  `json:"threshold" gorm:"type:double"`
+	HopSize              *int      `json:"hop_size"`
+	SpeechPadMs          *int      `json:"speech_pad_ms"`
+	MinSilenceDurationMs *int      `json:"min_silence_duration_ms"`
+	Enabled              bool      `json:"enabled" gorm:"default:true"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
 // ChatMessage 聊天消息模型
 type ChatMessage struct {
 	ID        uint   `json:"id" gorm:"primarykey"`
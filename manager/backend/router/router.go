@@ -31,9 +31,34 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 	deviceActivationController := &controllers.DeviceActivationController{DB: db}
 	setupController := &controllers.SetupController{DB: db}
 	speakerGroupController := controllers.NewSpeakerGroupController(db, cfg)
+	adminController.SpeakerGroups = speakerGroupController
 	voiceCloneController := controllers.NewVoiceCloneController(db, cfg)
 	poolStatsController := controllers.NewPoolStatsController()
 
+	// API 令牌认证（脚本/CI 使用，与 JWT 共用认证中间件）
+	middleware.SetAPITokenResolver(controllers.NewAPITokenResolver(db))
+
+	// 配置一键测试频率限制
+	controllers.SetConfigTestRateLimits(cfg.ConfigTest.RateLimits)
+
+	// 只读配置类型（运维锁定）
+	controllers.SetReadOnlyConfigTypes(cfg.ConfigLock.ReadOnlyTypes)
+
+	// 启动软删除知识库的定时清理任务
+	controllers.StartKnowledgeBasePurgeWorker(db)
+
+	// 启动外部 provider 孤儿数据集定时扫描（仅报告）
+	controllers.StartKnowledgeOrphanScanWorker(db)
+
+	// 启动知识库同步事件落库与清理任务
+	controllers.StartKnowledgeSyncEventSink(db)
+
+	// 启动长期离线设备自动停用任务（按配置开启）
+	controllers.StartDeviceInactivityWorker(db, cfg.DeviceInactivity)
+
+	// 启动定时配置备份任务（按配置开启）
+	controllers.StartConfigBackupWorker(adminController, cfg.ConfigBackup)
+
 	// 初始化聊天历史控制器（使用传入的 cfg，不重新 Load 避免内嵌时读错路径）
 	audioBasePath := "./storage/chat_history/audio"
 	maxFileSize := int64(10 * 1024 * 1024) // 默认10MB
@@ -92,6 +117,8 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			auth.PUT("/roles/:id", adminController.UpdateRoleNew)
 			auth.DELETE("/roles/:id", adminController.DeleteRoleNew)
 			auth.PATCH("/roles/:id/toggle", adminController.ToggleRoleStatus)
+			// 批量调整角色排序（拖拽排序）
+			auth.POST("/roles/reorder", adminController.ReorderRoles)
 
 			// 用户路由
 			user := auth.Group("/user")
@@ -103,6 +130,12 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				user.PUT("/roles/:id", adminController.UpdateRoleNew)
 				user.DELETE("/roles/:id", adminController.DeleteRoleNew)
 				user.PATCH("/roles/:id/toggle", adminController.ToggleRoleStatus)
+				user.POST("/roles/reorder", adminController.ReorderRoles)
+
+				// API 令牌管理（令牌本身无权访问这些接口）
+				user.GET("/api-tokens", userController.GetAPITokens)
+				user.POST("/api-tokens", userController.CreateAPIToken)
+				user.DELETE("/api-tokens/:id", userController.RevokeAPIToken)
 
 				// 设备管理
 				user.GET("/devices", userController.GetMyDevices)
@@ -121,19 +154,34 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				user.PUT("/agents/:id/knowledge-bases", userController.UpdateAgentKnowledgeBases)
 
 				// 用户知识库管理（纯文本）
+				user.GET("/knowledge-providers/capabilities", userController.GetKnowledgeProviderCapabilities)
 				user.GET("/knowledge-bases", userController.GetKnowledgeBases)
+				user.GET("/knowledge-bases/deleted", userController.GetDeletedKnowledgeBases)
 				user.POST("/knowledge-bases", userController.CreateKnowledgeBase)
 				user.GET("/knowledge-bases/:id", userController.GetKnowledgeBase)
 				user.PUT("/knowledge-bases/:id", userController.UpdateKnowledgeBase)
 				user.DELETE("/knowledge-bases/:id", userController.DeleteKnowledgeBase)
+				user.POST("/knowledge-bases/:id/restore", userController.RestoreKnowledgeBase)
+				// 合并多个知识库到目标知识库
+				user.POST("/knowledge-bases/:id/merge", userController.MergeKnowledgeBases)
 				user.POST("/knowledge-bases/:id/sync", userController.SyncKnowledgeBase)
+				user.POST("/knowledge-bases/:id/sync/cancel", userController.CancelKnowledgeSync)
+				user.GET("/knowledge-bases/:id/sync-events", userController.GetKnowledgeSyncEvents)
 				user.POST("/knowledge-bases/:id/test-search", userController.TestKnowledgeBaseSearch)
+				// 知识库最近检索记录（来自主程序内存）
+				user.GET("/knowledge-bases/:id/query-log", userController.GetKnowledgeBaseQueryLog)
+				// 本地分段预览 + 测试检索，命中结果映射回分段
+				user.POST("/knowledge-bases/:id/chunk-preview", userController.PreviewKnowledgeChunks)
 				user.GET("/knowledge-bases/:id/documents", userController.GetKnowledgeBaseDocuments)
 				user.POST("/knowledge-bases/:id/documents", userController.CreateKnowledgeBaseDocument)
 				user.POST("/knowledge-bases/:id/documents/upload", userController.CreateKnowledgeBaseDocumentByUpload)
+				// 抓取网页正文创建文档
+				user.POST("/knowledge-bases/:id/documents/from-url", userController.AddKnowledgeDocumentFromURL)
 				user.PUT("/knowledge-bases/:id/documents/:doc_id", userController.UpdateKnowledgeBaseDocument)
 				user.DELETE("/knowledge-bases/:id/documents/:doc_id", userController.DeleteKnowledgeBaseDocument)
 				user.POST("/knowledge-bases/:id/documents/:doc_id/sync", userController.SyncKnowledgeBaseDocument)
+				// 从 provider 回读文档分段，核对实际入库内容
+				user.GET("/knowledge-bases/:id/documents/:doc_id/provider-content", userController.GetProviderDocumentContent)
 
 				// 角色模板和音色选项
 				user.GET("/role-templates", userController.GetRoleTemplates)
@@ -198,6 +246,13 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				// 通用配置管理
 				admin.GET("/configs", adminController.GetConfigs)
 				admin.POST("/configs", adminController.CreateConfig)
+				admin.POST("/configs/bulk-delete", adminController.BulkDeleteConfigs)
+				// 按 类型_名称_时间戳 约定重新生成 config_id，同步更新引用
+				admin.POST("/configs/regenerate-ids", adminController.RegenerateConfigIDs)
+				admin.GET("/configs/graph", adminController.ExportConfigGraph)
+				// 全库失效引用审计（上线前检查）
+				admin.GET("/configs/audit-references", adminController.AuditReferences)
+				admin.GET("/configs/validate", adminController.ValidateAllConfigs)
 				admin.GET("/configs/:id", adminController.GetConfig)
 				admin.PUT("/configs/:id", adminController.UpdateConfig)
 				admin.DELETE("/configs/:id", adminController.DeleteConfig)
@@ -208,6 +263,11 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				admin.POST("/vad-configs", adminController.CreateVADConfig)
 				admin.PUT("/vad-configs/:id", adminController.UpdateVADConfig)
 				admin.DELETE("/vad-configs/:id", adminController.DeleteVADConfig)
+				// 设备/智能体级VAD调优配置
+				admin.GET("/vad-profiles", adminController.GetVADProfiles)
+				admin.POST("/vad-profiles", adminController.CreateVADProfile)
+				admin.PUT("/vad-profiles/:id", adminController.UpdateVADProfile)
+				admin.DELETE("/vad-profiles/:id", adminController.DeleteVADProfile)
 
 				admin.GET("/asr-configs", adminController.GetASRConfigs)
 				admin.POST("/asr-configs", adminController.CreateASRConfig)
@@ -228,6 +288,8 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				admin.POST("/speaker-configs", adminController.CreateSpeakerConfig)
 				admin.PUT("/speaker-configs/:id", adminController.UpdateSpeakerConfig)
 				admin.DELETE("/speaker-configs/:id", adminController.DeleteSpeakerConfig)
+				// 声纹服务连通性检查
+				admin.GET("/speaker-configs/health", adminController.CheckSpeakerService)
 
 				admin.GET("/vision-configs", adminController.GetVisionConfigs)
 				admin.POST("/vision-configs", adminController.CreateVisionConfig)
@@ -256,6 +318,10 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				admin.POST("/mqtt-server-configs", adminController.CreateMQTTServerConfig)
 				admin.PUT("/mqtt-server-configs/:id", adminController.UpdateMQTTServerConfig)
 				admin.DELETE("/mqtt-server-configs/:id", adminController.DeleteMQTTServerConfig)
+				// 按 MQTT 服务签名密钥生成设备凭据（设备预置用）
+				admin.GET("/mqtt-server-configs/device-credentials", adminController.GenerateDeviceMQTTCredentials)
+				// 轮换 MQTT 签名密钥并返回各设备新凭据
+				admin.POST("/mqtt-server-configs/rotate-signature-key", adminController.RotateMQTTSignatureKey)
 
 				admin.GET("/udp-configs", adminController.GetUDPConfigs)
 				admin.POST("/udp-configs", adminController.CreateUDPConfig)
@@ -291,8 +357,11 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				admin.GET("/knowledge-search-configs", adminController.GetKnowledgeSearchConfigs)
 				admin.POST("/knowledge-search-configs", adminController.CreateKnowledgeSearchConfig)
 				admin.PUT("/knowledge-search-configs/:id", adminController.UpdateKnowledgeSearchConfig)
+				admin.POST("/knowledge-search-configs/:id/reembed", adminController.ReembedKnowledgeBases)
 				admin.DELETE("/knowledge-search-configs/:id", adminController.DeleteKnowledgeSearchConfig)
 				admin.POST("/knowledge-search-configs/weknora/models", adminController.ListWeknoraModels)
+				// 同一查询对所有知识库 provider 检索，对比检索质量
+				admin.POST("/knowledge-search-configs/search-all", adminController.SearchAllKnowledgeProviders)
 
 				// 全局角色管理（保留兼容旧API）
 				admin.GET("/global-roles", adminController.GetGlobalRoles)
@@ -308,22 +377,43 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				admin.DELETE("/roles/global/:id", adminController.DeleteRoleNew)
 				admin.PATCH("/roles/global/:id/toggle", adminController.ToggleRoleStatus)
 				admin.PATCH("/roles/global/:id/default", adminController.SetDefaultRole)
+				admin.POST("/roles/global/reorder", adminController.ReorderRoles)
 
 				// 设备管理
 				admin.GET("/devices", adminController.GetDevices)
 				admin.GET("/devices/validate-code", adminController.ValidateDeviceCode)
+				// 设备配置解析链路诊断（设备 -> 智能体 -> 角色 -> 配置来源）
+				admin.GET("/devices/resolution", adminController.GetDeviceResolution)
+				// 模拟设备开机流程（OTA -> WebSocket/MQTT -> 拉取设备配置）
+				admin.POST("/devices/boot-simulation", adminController.SimulateDeviceBoot)
 				admin.POST("/devices", adminController.CreateDevice)
 				admin.PUT("/devices/:id", adminController.UpdateDevice)
 				admin.DELETE("/devices/:id", adminController.DeleteDevice)
+				// 设备级配置覆盖（下发配置时最后合并，优先级最高）
+				admin.GET("/devices/:id/config-override", adminController.GetDeviceConfigOverride)
+				admin.PUT("/devices/:id/config-override", adminController.UpdateDeviceConfigOverride)
+				// 设备分组：按位置/用途批量管理设备
+				admin.GET("/device-groups", adminController.GetDeviceGroups)
+				admin.POST("/device-groups", adminController.CreateDeviceGroup)
+				admin.PUT("/device-groups/:id", adminController.UpdateDeviceGroup)
+				admin.DELETE("/device-groups/:id", adminController.DeleteDeviceGroup)
+				admin.GET("/device-groups/:id/devices", adminController.GetDeviceGroupDevices)
+				admin.POST("/device-groups/:id/devices", adminController.AddDevicesToGroup)
+				admin.DELETE("/device-groups/:id/devices/:device_id", adminController.RemoveDeviceFromGroup)
+				admin.POST("/device-groups/:id/apply-role", adminController.ApplyRoleToDeviceGroup)
 
 				// 智能体管理
 				admin.GET("/agents", adminController.GetAgents)
 				admin.POST("/agents", adminController.CreateAgent)
 				admin.PUT("/agents/:id", adminController.UpdateAgent)
 				admin.DELETE("/agents/:id", adminController.DeleteAgent)
+				admin.POST("/agents/:id/devices/activate", adminController.BulkActivateDevices)
+				// 向智能体下所有在线设备下发最新配置
+				admin.POST("/agents/:id/push-config", adminController.PushAgentDeviceConfigs)
 				admin.GET("/agents/:id/mcp-endpoint", adminController.GetAgentMCPEndpoint)
 				admin.GET("/agents/:id/mcp-tools", adminController.GetAgentMcpTools)
 				admin.POST("/agents/:id/mcp-call", adminController.CallAgentMcpTool)
+				admin.POST("/agents/:id/mcp-tools/test", adminController.TestAgentMcpTool)
 				admin.GET("/devices/:id/mcp-tools", adminController.GetDeviceMcpTools)
 				admin.POST("/devices/:id/mcp-call", adminController.CallDeviceMcpTool)
 
@@ -333,11 +423,14 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				admin.PUT("/users/:id", adminController.UpdateUser)
 				admin.DELETE("/users/:id", adminController.DeleteUser)
 				admin.POST("/users/:id/reset-password", adminController.ResetUserPassword)
+				// 以模板用户的角色/智能体/所引用配置初始化新租户（配置副本清空密钥、默认禁用）
+				admin.POST("/tenants/clone", adminController.CloneTenantEnvironment)
 
 				admin.GET("/users/:id/knowledge-bases", adminController.GetUserKnowledgeBasesAdmin)
 				admin.POST("/users/:id/knowledge-bases", adminController.CreateUserKnowledgeBaseAdmin)
 				admin.PUT("/users/:id/knowledge-bases/:kb_id", adminController.UpdateUserKnowledgeBaseAdmin)
 				admin.DELETE("/users/:id/knowledge-bases/:kb_id", adminController.DeleteUserKnowledgeBaseAdmin)
+				admin.POST("/users/:id/knowledge-bases/:kb_id/restore", adminController.RestoreUserKnowledgeBaseAdmin)
 
 				admin.GET("/users/:id/voice-clone-quotas", adminController.GetUserVoiceCloneQuotas)
 				admin.PUT("/users/:id/voice-clone-quotas", adminController.UpdateUserVoiceCloneQuotas)
@@ -345,12 +438,52 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				// 配置导入导出
 				admin.GET("/configs/export", adminController.ExportConfigs)
 				admin.POST("/configs/import", adminController.ImportConfigs)
+				// 定时配置备份状态与手动触发
+				admin.GET("/configs/backup/status", adminController.GetConfigBackupStatus)
+				admin.POST("/configs/backup/run", adminController.RunConfigBackup)
+				admin.POST("/configs/validate-yaml", adminController.ValidateConfigYAML)
 				// 一键测试配置（OTA 在 manager 内，VAD/ASR/LLM/TTS 经 WebSocket 发主程序）
 				admin.POST("/configs/test", adminController.TestConfigs)
+				// 上传 WAV 经主程序执行 VAD→ASR→LLM→TTS 全链路测试
+				admin.POST("/configs/test/pipeline", adminController.TestConfigPipeline)
+				// 上传带语音区间标注的 WAV，扫描 VAD 阈值并推荐 F1 最高者
+				admin.POST("/configs/test/vad-calibration", adminController.CalibrateVADThreshold)
+				// VAD 原生库版本与生效配置诊断
+				admin.GET("/configs/test/vad-info", adminController.GetVADRuntimeInfo)
+				// 按智能体/用户/设备分组向服务相关设备的主程序定向推送系统配置
+				admin.POST("/configs/push", adminController.PushSystemConfig)
+				// 新建配置时按类型与提供商获取 json_data 模板
+				admin.GET("/configs/templates", adminController.GetConfigTemplates)
+				// 按类型与提供商获取 json_data 中需脱敏的字段名
+				admin.GET("/configs/secret-fields", adminController.GetSecretFields)
+				// 预览假设的设备/角色/智能体组合解析出的配置及字段来源（不持久化）
+				admin.GET("/configs/preview-resolved", adminController.PreviewResolvedConfig)
+				// 对比两个配置的 json_data 差异
+				admin.GET("/configs/compare", adminController.CompareConfigs)
+				// 各类型最近可用配置快照（一键测试通过后自动记录），支持一键回滚
+				admin.GET("/configs/last-good", adminController.GetLastGoodConfigs)
+				admin.POST("/configs/last-good/:type/restore", adminController.RestoreLastGoodConfig)
+				// 配置草稿包：保存一组配置修改，反复测试后一次性提升为正式配置
+				admin.GET("/config-drafts", adminController.GetConfigDraftBundles)
+				admin.POST("/config-drafts", adminController.CreateConfigDraftBundle)
+				admin.GET("/config-drafts/:id", adminController.GetConfigDraftBundle)
+				admin.PUT("/config-drafts/:id", adminController.UpdateConfigDraftBundle)
+				admin.DELETE("/config-drafts/:id", adminController.DeleteConfigDraftBundle)
+				admin.POST("/config-drafts/:id/test", adminController.TestConfigDraftBundle)
+				admin.POST("/config-drafts/:id/promote", adminController.PromoteConfigDraftBundle)
 
 				// 资源池统计
 				admin.GET("/pool/stats", poolStatsController.GetPoolStats)
 				admin.GET("/pool/stats/summary", poolStatsController.GetPoolStatsSummary)
+				// 服务信息：构建版本、当前提供商与可选功能启用情况
+				admin.GET("/server-info", adminController.GetServerInfo)
+				// MQTT 运行指标
+				admin.GET("/mqtt/metrics", poolStatsController.GetMqttMetrics)
+				// 知识库同步指标（Prometheus 文本格式）
+				admin.GET("/knowledge-sync/metrics", adminController.GetKnowledgeSyncMetrics)
+				// 外部 provider 孤儿数据集报告与清理
+				admin.GET("/knowledge-sync/orphan-datasets", adminController.GetKnowledgeOrphanDatasets)
+				admin.POST("/knowledge-sync/orphan-datasets/cleanup", adminController.CleanupKnowledgeOrphanDatasets)
 			}
 		}
 	}
@@ -0,0 +1,250 @@
+// Package mcp_schema 按 MCP 工具声明的 inputSchema 校验调用参数，供管理端调试调用与主程序工具调用共用
+package mcp_schema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateArguments 按 MCP 工具 inputSchema 校验调用参数，支持 JSON Schema 常用子集：
+// type（含类型数组）、required、properties、additionalProperties、enum、const、嵌套 object/array（items、minItems/maxItems）、
+// 字符串 minLength/maxLength/pattern、数值 minimum/maximum/exclusiveMinimum/exclusiveMaximum
+// 返回全部校验错误，每条带参数路径（如 location.city、tags[2]）
+func ValidateArguments(schema map[string]interface{}, args map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var errs []string
+	validateNode(schema, args, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
+
+// validateNode 递归校验单个值，错误追加到 errs
+func validateNode(schema map[string]interface{}, value interface{}, path string, errs *[]string) {
+	fail := func(format string, a ...interface{}) {
+		msg := fmt.Sprintf(format, a...)
+		if path != "" {
+			msg = "参数 " + path + " " + msg
+		}
+		*errs = append(*errs, msg)
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, typ := range types {
+			if matchesType(typ, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("类型应为 %s，实际为 %s", strings.Join(types, "/"), valueTypeName(value))
+			return
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		found := false
+		for _, candidate := range enum {
+			if fmt.Sprint(candidate) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("取值 %v 不在可选范围 %v 内", value, enum)
+			return
+		}
+	}
+	if expected, ok := schema["const"]; ok && fmt.Sprint(expected) != fmt.Sprint(value) {
+		fail("取值应为 %v", expected)
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if min, ok := schemaNumber(schema["minLength"]); ok && length < min {
+			fail("长度不能小于 %v", min)
+		}
+		if max, ok := schemaNumber(schema["maxLength"]); ok && length > max {
+			fail("长度不能大于 %v", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail("不匹配格式 %s", pattern)
+			}
+		}
+	case float64:
+		if min, ok := schemaNumber(schema["minimum"]); ok && v < min {
+			fail("不能小于 %v", min)
+		}
+		if max, ok := schemaNumber(schema["maximum"]); ok && v > max {
+			fail("不能大于 %v", max)
+		}
+		if min, ok := schemaNumber(schema["exclusiveMinimum"]); ok && v <= min {
+			fail("必须大于 %v", min)
+		}
+		if max, ok := schemaNumber(schema["exclusiveMaximum"]); ok && v >= max {
+			fail("必须小于 %v", max)
+		}
+	case []interface{}:
+		if min, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < min {
+			fail("元素个数不能少于 %v", min)
+		}
+		if max, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > max {
+			fail("元素个数不能多于 %v", max)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateNode(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		validateObject(schema, v, path, errs)
+	}
+}
+
+// validateObject 校验对象的必填字段、未定义字段及各属性值
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, errs *[]string) {
+	joinPath := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, item := range required {
+			name, _ := item.(string)
+			if name == "" {
+				continue
+			}
+			if _, exists := obj[name]; !exists {
+				*errs = append(*errs, "缺少必填参数 "+joinPath(name))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unknown []string
+	for _, name := range names {
+		if prop, ok := properties[name].(map[string]interface{}); ok {
+			validateNode(prop, obj[name], joinPath(name), errs)
+			continue
+		}
+		if _, declared := properties[name]; declared {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				unknown = append(unknown, joinPath(name))
+			}
+		case map[string]interface{}:
+			validateNode(additional, obj[name], joinPath(name), errs)
+		}
+	}
+	if len(unknown) > 0 {
+		*errs = append(*errs, "未定义的参数 "+strings.Join(unknown, ", "))
+	}
+}
+
+// schemaTypes 解析 type 字段，兼容字符串与字符串数组
+func schemaTypes(raw interface{}) []string {
+	switch v := raw.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []interface{}:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// schemaNumber 读取 schema 中的数值约束
+func schemaNumber(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// valueTypeName 返回参数值对应的 JSON 类型名，用于错误提示
+func valueTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// matchesType 判断 JSON 解码后的值是否符合 schema 类型
+func matchesType(typ string, value interface{}) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,100 @@
+package mcp_schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"volume": {"type": "integer"},
+			"mode": {"type": "string", "enum": ["on", "off"]},
+			"muted": {"type": "boolean"}
+		},
+		"required": ["volume"],
+		"additionalProperties": false
+	}`), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		args    string
+		wantErr bool
+	}{
+		{`{"volume": 50}`, false},
+		{`{"volume": 50, "mode": "on", "muted": true}`, false},
+		{`{}`, true},
+		{`{"volume": 50.5}`, true},
+		{`{"volume": "50"}`, true},
+		{`{"volume": 50, "mode": "auto"}`, true},
+		{`{"volume": 50, "extra": 1}`, true},
+	}
+	for _, tc := range cases {
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(tc.args), &args); err != nil {
+			t.Fatal(err)
+		}
+		err := ValidateArguments(schema, args)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("args %s: err = %v, wantErr %v", tc.args, err, tc.wantErr)
+		}
+	}
+
+	if err := ValidateArguments(nil, map[string]interface{}{"any": 1}); err != nil {
+		t.Fatalf("empty schema should accept any args: %v", err)
+	}
+}
+
+func TestValidateArgumentsNested(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"city": {"type": "string", "minLength": 2, "maxLength": 10},
+			"days": {"type": "integer", "minimum": 1, "maximum": 7},
+			"location": {
+				"type": "object",
+				"properties": {"lat": {"type": "number"}, "lng": {"type": "number"}},
+				"required": ["lat", "lng"],
+				"additionalProperties": false
+			},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "maxItems": 3},
+			"note": {"type": ["string", "null"]}
+		},
+		"required": ["city"]
+	}`), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		args    string
+		wantErr string
+	}{
+		{`{"city": "北京", "days": 3, "location": {"lat": 1, "lng": 2}, "tags": ["a"], "note": null}`, ""},
+		{`{"city": "北"}`, "参数 city 长度不能小于 2"},
+		{`{"city": "北京", "days": 8}`, "参数 days 不能大于 7"},
+		{`{"city": "北京", "location": {"lat": 1}}`, "缺少必填参数 location.lng"},
+		{`{"city": "北京", "location": {"lat": 1, "lng": "2"}}`, "参数 location.lng 类型应为 number，实际为 string"},
+		{`{"city": "北京", "location": {"lat": 1, "lng": 2, "alt": 3}}`, "未定义的参数 location.alt"},
+		{`{"city": "北京", "tags": ["ok", "Bad"]}`, "参数 tags[1] 不匹配格式 ^[a-z]+$"},
+		{`{"city": "北京", "note": 1}`, "参数 note 类型应为 string/null，实际为 integer"},
+		{`{"days": 0}`, "缺少必填参数 city; 参数 days 不能小于 1"},
+	}
+	for _, tc := range cases {
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(tc.args), &args); err != nil {
+			t.Fatal(err)
+		}
+		err := ValidateArguments(schema, args)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tc.wantErr {
+			t.Fatalf("args %s: err = %q, want %q", tc.args, got, tc.wantErr)
+		}
+	}
+}
@@ -15,6 +15,7 @@ type PoolStatsData struct {
 type PoolStatsStorage struct {
 	mu       sync.RWMutex
 	latest   *PoolStatsData // 只保存最新的统计数据
+	mqtt     *PoolStatsData // 最新的 MQTT 运行指标
 }
 
 var (
@@ -97,3 +98,27 @@ func (s *PoolStatsStorage) GetStatsCount() int {
 	}
 	return 1
 }
+
+// SetMqttMetrics 保存最新的 MQTT 运行指标（覆盖旧数据）
+func (s *PoolStatsStorage) SetMqttMetrics(metrics map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mqtt = &PoolStatsData{
+		Timestamp: time.Now(),
+		Stats:     metrics,
+	}
+}
+
+// GetLatestMqttMetrics 获取最新的 MQTT 运行指标
+func (s *PoolStatsStorage) GetLatestMqttMetrics() *PoolStatsData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.mqtt == nil {
+		return nil
+	}
+
+	latest := *s.mqtt
+	return &latest
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
 
+	xzaudio "xiaozhi-esp32-server-golang/internal/domain/audio"
+
 	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
 	"gopkg.in/hraban/opus.v2"
@@ -149,6 +152,28 @@ func OpusToWav(opusData [][]byte, sampleRate int, channels int, fileName string)
 }
 
 func Wav2Pcm(wavData []byte, sampleRate int, channels int) ([][]float32, [][]byte, error) {
+	// 先校验WAV头：仅支持16位未压缩PCM，压缩格式或其他位深在此给出明确错误
+	info, err := xzaudio.ParseWavHeader(wavData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := info.ValidatePCM(16); err != nil {
+		return nil, nil, err
+	}
+	// 采样率与请求不一致时重采样到请求采样率后再分帧，避免按错误采样率解读音频
+	if info.SampleRate != sampleRate {
+		if info.Channels != channels {
+			return nil, nil, fmt.Errorf("%v；且 WAV 声道数 %d 与请求的 %d 不一致，无法自动转换", info.CheckSampleRate(sampleRate), info.Channels, channels)
+		}
+		fmt.Printf("WAV 采样率 %dHz 与请求的 %dHz 不一致，自动重采样\n", info.SampleRate, sampleRate)
+		samples, _, err := xzaudio.DecodeWavPCM16(wavData, sampleRate)
+		if err != nil {
+			return nil, nil, err
+		}
+		resultFloat32, result := splitPcm16Frames(samples, sampleRate, channels)
+		return resultFloat32, result, nil
+	}
+
 	// 创建WAV解码器
 	wavReader := bytes.NewReader(wavData)
 	wavDecoder := wav.NewDecoder(wavReader)
@@ -193,8 +218,8 @@ func Wav2Pcm(wavData []byte, sampleRate int, channels int) ([][]float32, [][]byt
 		float32Data := audioBuf.AsFloat32Buffer()
 		resultFloat32 = append(resultFloat32, float32Data.Data)
 
-		// 将int16数组转换为字节数组
-		frameBytes := PcmInt16ToByte(pcmBuffer)
+		// 将int16数组按小端序转换为字节数组
+		frameBytes := xzaudio.Int16PCMToBytes(pcmBuffer, binary.LittleEndian)
 
 		result = append(result, frameBytes)
 	}
@@ -202,11 +227,20 @@ func Wav2Pcm(wavData []byte, sampleRate int, channels int) ([][]float32, [][]byt
 	return resultFloat32, result, nil
 }
 
-func PcmInt16ToByte(pcmData []int16) []byte {
-	byteData := make([]byte, len(pcmData)*2)
-	for i := 0; i < len(pcmData); i++ {
-		byteData[i*2] = byte(pcmData[i] & 0xFF)
-		byteData[i*2+1] = byte((pcmData[i] >> 8) & 0xFF)
+// splitPcm16Frames 将交错的 int16 样本按 20ms 分帧，返回与 Wav2Pcm 相同格式的 float32 帧与字节帧
+func splitPcm16Frames(samples []int16, sampleRate int, channels int) ([][]float32, [][]byte) {
+	frameLen := sampleRate * 20 / 1000 * channels
+	resultFloat32 := make([][]float32, 0, len(samples)/frameLen+1)
+	result := make([][]byte, 0, len(samples)/frameLen+1)
+	for start := 0; start < len(samples); start += frameLen {
+		pcmBuffer := make([]int16, frameLen)
+		copy(pcmBuffer, samples[start:])
+		float32Data := make([]float32, frameLen)
+		for i, v := range pcmBuffer {
+			float32Data[i] = float32(v)
+		}
+		resultFloat32 = append(resultFloat32, float32Data)
+		result = append(result, xzaudio.Int16PCMToBytes(pcmBuffer, binary.LittleEndian))
 	}
-	return byteData
+	return resultFloat32, result
 }
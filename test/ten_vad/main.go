@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,10 +26,11 @@ func genFloat32Empty(sampleRate int, durationMs int, channels int, count int) []
 	for i := 0; i < numSamples*channels; i++ {
 		binary.Write(&buf, binary.LittleEndian, float32(0.0))
 	}
-	//将数据转换为float32
-	float32Data := make([]float32, numSamples*channels)
-	for i := 0; i < numSamples*channels; i++ {
-		float32Data[i] = float32(buf.Bytes()[i])
+	//将数据按小端序解析为float32
+	float32Data, err := audio.BytesToFloat32(buf.Bytes(), binary.LittleEndian)
+	if err != nil {
+		fmt.Printf("转换float32失败: %v", err)
+		return nil
 	}
 	result := make([][]float32, 0)
 	for i := 0; i < count; i++ {
@@ -139,7 +139,9 @@ func main() {
 	}
 	defer vadImpl.Close()
 
-	fmt.Printf("TEN-VAD创建成功 (hop_size=%d, threshold=%.2f)，开始测试...\n", hopSize, threshold)
+	version := vadImpl.Version()
+	fmt.Printf("TEN-VAD创建成功 (library=%s, hop_size=%d, threshold=%.2f, sample_rate=%d)，开始测试...\n",
+		version.LibraryVersion, version.HopSize, version.Threshold, version.SampleRate)
 
 	// 直接测试VAD是否能正常工作
 	if len(pcmFloat32) == 0 {
@@ -257,14 +259,6 @@ func main() {
 	detectVoice(allPcmData)
 }
 
-func float32ToByte(pcmFrame []float32) []byte {
-	byteData := make([]byte, len(pcmFrame)*4)
-	for i, sample := range pcmFrame {
-		binary.LittleEndian.PutUint32(byteData[i*4:], math.Float32bits(sample))
-	}
-	return byteData
-}
-
 // generateOutputFileName 生成输出文件名，在原文件名基础上添加 "_speech" 后缀
 func generateOutputFileName(inputPath string) string {
 	dir := filepath.Dir(inputPath)
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"os"
 
 	"xiaozhi-esp32-server-golang/internal/domain/audio"
@@ -22,10 +21,11 @@ func genFloat32Empty(sampleRate int, durationMs int, channels int, count int) []
 	for i := 0; i < numSamples*channels; i++ {
 		binary.Write(&buf, binary.LittleEndian, float32(0.0))
 	}
-	//将数据转换为float32
-	float32Data := make([]float32, numSamples*channels)
-	for i := 0; i < numSamples*channels; i++ {
-		float32Data[i] = float32(buf.Bytes()[i])
+	//将数据按小端序解析为float32
+	float32Data, err := audio.BytesToFloat32(buf.Bytes(), binary.LittleEndian)
+	if err != nil {
+		fmt.Printf("转换float32失败: %v", err)
+		return nil
 	}
 	result := make([][]float32, 0)
 	for i := 0; i < count; i++ {
@@ -219,11 +219,3 @@ func main() {
 	// 使用实际的WAV文件数据进行测试
 	detectVoice(allPcmData)
 }
-
-func float32ToByte(pcmFrame []float32) []byte {
-	byteData := make([]byte, len(pcmFrame)*4)
-	for i, sample := range pcmFrame {
-		binary.LittleEndian.PutUint32(byteData[i*4:], math.Float32bits(sample))
-	}
-	return byteData
-}